@@ -0,0 +1,407 @@
+package document
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ErrArchiveLimit is returned when an archive expands beyond the limits set
+// on ArchiveParser, as zip bombs do. It wraps ErrTooLarge.
+var ErrArchiveLimit = fmt.Errorf("archive exceeds expansion limits: %w", ErrTooLarge)
+
+// archiveRatioFloor is the expanded size below which compression ratios are
+// not checked, since small text files routinely compress very well.
+const archiveRatioFloor = 1 << 20
+
+// ArchiveParser expands ZIP, TAR and gzip archives and parses every file
+// inside with the first parser that supports its extension. Nested archives
+// are expanded recursively.
+type ArchiveParser struct {
+	// Parsers handles the files inside the archive. Defaults to the
+	// parsers of DefaultRegistry.
+	Parsers []Parser
+	// MaxFiles limits the number of files expanded. Defaults to 1000.
+	MaxFiles int
+	// MaxFileSize skips files larger than this many bytes. Defaults to 5 MB.
+	MaxFileSize int64
+	// MaxTotalSize limits the bytes expanded across all files. Defaults to
+	// 100 MB.
+	MaxTotalSize int64
+	// MaxDepth limits how many archives may be nested. Defaults to 3.
+	MaxDepth int
+	// MaxRatio limits the compression ratio of any entry. Defaults to 100.
+	MaxRatio int64
+}
+
+// NewArchiveParser creates a new archive parser instance.
+func NewArchiveParser() *ArchiveParser {
+	return &ArchiveParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *ArchiveParser) Supports(mimeType string) bool {
+	switch mimeType {
+	case "application/zip", "application/x-zip-compressed", "application/x-tar",
+		"application/gzip", "application/x-gzip", "application/x-gtar",
+		"application/x-compressed-tar":
+		return true
+	}
+	return false
+}
+
+// Parse joins the parsed files under a "# path" heading each. Headings,
+// pages and segments of the inner documents are kept, one level deeper.
+func (p *ArchiveParser) Parse(buffer []byte, filename string) (*Document, error) {
+	return p.ParseContext(context.Background(), buffer, filename)
+}
+
+// ParseContext works like Parse but stops between files, and passes ctx
+// to the parsers of the files, when ctx is done.
+func (p *ArchiveParser) ParseContext(ctx context.Context, buffer []byte, filename string) (*Document, error) {
+	docs, err := p.parseAll(ctx, buffer, filename)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	var headings []Heading
+	var pages []Page
+	var segments []Segment
+	for _, d := range docs {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		name := d.Metadata["archive_path"]
+		headings = append(headings, Heading{Level: 1, Text: name, Offset: b.Len()})
+		b.WriteString("# " + name + "\n\n")
+		base := b.Len()
+		for _, h := range d.Headings {
+			h.Level++
+			h.Offset += base
+			headings = append(headings, h)
+		}
+		for _, pg := range d.Pages {
+			pg.Start += base
+			pg.End += base
+			pages = append(pages, pg)
+		}
+		for _, s := range d.Segments {
+			s.Start += base
+			s.End += base
+			segments = append(segments, s)
+		}
+		b.WriteString(strings.TrimRight(d.Content, "\n"))
+	}
+	content := b.String()
+	return &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Pages:     pages,
+		Headings:  headings,
+		Segments:  segments,
+		Metadata:  map[string]string{"files": strconv.Itoa(len(docs))},
+	}, nil
+}
+
+// ParseAll returns one document per parsed file. Source is the archive name
+// followed by the path inside it, with nested archives separated by "!/"
+// ("bundle.zip!/data.tar.gz!/notes.md"); metadata records the outer
+// "archive" and the "archive_path" within it. Files no parser supports, or
+// that fail to parse, are skipped.
+func (p *ArchiveParser) ParseAll(buffer []byte, filename string) ([]*Document, error) {
+	return p.parseAll(context.Background(), buffer, filename)
+}
+
+// parseAll expands the archive and parses its files until ctx is done.
+func (p *ArchiveParser) parseAll(ctx context.Context, buffer []byte, filename string) ([]*Document, error) {
+	x := p.expander()
+	if err := x.archive(buffer, filename, "", 0); err != nil {
+		return nil, err
+	}
+	parsers := p.Parsers
+	if parsers == nil {
+		parsers = DefaultRegistry.Parsers()
+	}
+
+	var docs []*Document
+	for _, f := range x.files {
+		parser := archiveParserFor(parsers, DetectMIME(f.data, f.path))
+		if parser == nil {
+			continue
+		}
+		doc, err := ParseContext(ctx, parser, f.data, f.path)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			continue
+		}
+		if doc.Metadata == nil {
+			doc.Metadata = map[string]string{}
+		}
+		doc.Source = filename + "!/" + f.path
+		doc.Metadata["archive"] = filename
+		doc.Metadata["archive_path"] = f.path
+		docs = append(docs, doc)
+	}
+	if len(docs) == 0 {
+		return nil, errors.New("archive contains no parseable files")
+	}
+	return docs, nil
+}
+
+// archiveParserFor returns the first parser supporting mimeType. Archive
+// parsers are skipped because nested archives are expanded while reading.
+func archiveParserFor(parsers []Parser, mimeType string) Parser {
+	for _, parser := range parsers {
+		if _, ok := parser.(*ArchiveParser); ok {
+			continue
+		}
+		if parser.Supports(mimeType) {
+			return parser
+		}
+	}
+	return nil
+}
+
+// archiveFile is a regular file read from an archive.
+type archiveFile struct {
+	path string
+	data []byte
+}
+
+// archiveExpander reads archives within the parser's limits, collecting
+// every regular file.
+type archiveExpander struct {
+	maxFiles, maxDepth              int
+	maxFileSize, maxTotal, maxRatio int64
+	isArchive                       func(name string) bool
+
+	total int64
+	files []archiveFile
+}
+
+func (p *ArchiveParser) expander() *archiveExpander {
+	x := &archiveExpander{
+		maxFiles:    p.MaxFiles,
+		maxDepth:    p.MaxDepth,
+		maxFileSize: p.MaxFileSize,
+		maxTotal:    p.MaxTotalSize,
+		maxRatio:    p.MaxRatio,
+		isArchive: func(name string) bool {
+			return p.Supports(MimeTypeForFile(name))
+		},
+	}
+	if x.maxFiles <= 0 {
+		x.maxFiles = 1000
+	}
+	if x.maxDepth <= 0 {
+		x.maxDepth = 3
+	}
+	if x.maxFileSize <= 0 {
+		x.maxFileSize = 5 << 20
+	}
+	if x.maxTotal <= 0 {
+		x.maxTotal = 100 << 20
+	}
+	if x.maxRatio <= 0 {
+		x.maxRatio = 100
+	}
+	return x
+}
+
+// archive detects the format of buffer from its signature, falling back to
+// the file name for old TAR files without one, and expands it.
+func (x *archiveExpander) archive(buffer []byte, name, prefix string, depth int) error {
+	switch {
+	case bytes.HasPrefix(buffer, []byte("PK\x03\x04")), bytes.HasPrefix(buffer, []byte("PK\x05\x06")):
+		return x.zip(buffer, prefix, depth)
+	case bytes.HasPrefix(buffer, []byte{0x1f, 0x8b}):
+		return x.gzip(buffer, name, prefix, depth)
+	case isTarHeader(buffer), strings.EqualFold(path.Ext(name), ".tar"):
+		return x.tar(bytes.NewReader(buffer), prefix, depth)
+	}
+	return fmt.Errorf("unrecognized archive format: %s", path.Base(name))
+}
+
+func isTarHeader(b []byte) bool {
+	return len(b) >= 262 && string(b[257:262]) == "ustar"
+}
+
+func (x *archiveExpander) zip(buffer []byte, prefix string, depth int) error {
+	zr, err := zip.NewReader(bytes.NewReader(buffer), int64(len(buffer)))
+	if err != nil {
+		return fmt.Errorf("open zip: %w", err)
+	}
+	for _, f := range zr.File {
+		name, ok := archiveEntryName(f.Name)
+		if !ok || !f.Mode().IsRegular() || f.UncompressedSize64 > uint64(x.maxFileSize) {
+			continue
+		}
+		if f.UncompressedSize64 > archiveRatioFloor &&
+			f.UncompressedSize64 > f.CompressedSize64*uint64(x.maxRatio) {
+			return fmt.Errorf("%w: %s compresses more than %d:1", ErrArchiveLimit, prefix+name, x.maxRatio)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			continue
+		}
+		data, err := x.read(rc)
+		rc.Close()
+		if err != nil || data == nil {
+			continue
+		}
+		if err := x.add(prefix+name, data, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gzip decompresses a gzip stream, expanding it as a TAR archive when it
+// holds one and as a single file otherwise.
+func (x *archiveExpander) gzip(buffer []byte, name, prefix string, depth int) error {
+	zr, err := gzip.NewReader(bytes.NewReader(buffer))
+	if err != nil {
+		return fmt.Errorf("open gzip: %w", err)
+	}
+	defer zr.Close()
+	// The stream is limited as a whole, so TAR entries that are skipped
+	// still count towards the ratio.
+	limit := min(x.maxTotal, max(int64(len(buffer))*x.maxRatio, archiveRatioFloor))
+	br := bufio.NewReader(&archiveLimitReader{r: zr, n: limit})
+	if head, _ := br.Peek(262); isTarHeader(head) {
+		return x.tar(br, prefix, depth)
+	}
+
+	base := path.Base(name)
+	switch ext := strings.ToLower(path.Ext(base)); ext {
+	case ".tgz":
+		base = strings.TrimSuffix(base, base[len(base)-len(ext):]) + ".tar"
+	case ".gz":
+		base = strings.TrimSuffix(base, base[len(base)-len(ext):])
+	}
+	if zr.Name != "" {
+		base = path.Base(zr.Name)
+	}
+	entry, ok := archiveEntryName(base)
+	if !ok {
+		return nil
+	}
+	data, err := x.read(br)
+	if err != nil {
+		return fmt.Errorf("read gzip: %w", err)
+	}
+	if data == nil {
+		return nil
+	}
+	return x.add(prefix+entry, data, depth)
+}
+
+func (x *archiveExpander) tar(r io.Reader, prefix string, depth int) error {
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read tar: %w", err)
+		}
+		name, ok := archiveEntryName(h.Name)
+		if !ok || !h.FileInfo().Mode().IsRegular() || h.Size > x.maxFileSize {
+			continue
+		}
+		data, err := x.read(tr)
+		if err != nil {
+			return fmt.Errorf("read tar: %w", err)
+		}
+		if data == nil {
+			continue
+		}
+		if err := x.add(prefix+name, data, depth); err != nil {
+			return err
+		}
+	}
+}
+
+// read reads one file, returning nil when it is larger than MaxFileSize
+// regardless of the size the archive declared.
+func (x *archiveExpander) read(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, x.maxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > x.maxFileSize {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// add records a file, or expands it when it is itself an archive and
+// MaxDepth allows. Nested archives that fail to open are skipped.
+func (x *archiveExpander) add(name string, data []byte, depth int) error {
+	x.total += int64(len(data))
+	if x.total > x.maxTotal {
+		return fmt.Errorf("%w: more than %d bytes expanded", ErrArchiveLimit, x.maxTotal)
+	}
+	if x.isArchive(name) {
+		if depth+1 >= x.maxDepth {
+			return nil
+		}
+		err := x.archive(data, name, name+"!/", depth+1)
+		if errors.Is(err, ErrArchiveLimit) {
+			return err
+		}
+		return nil
+	}
+	if len(x.files) >= x.maxFiles {
+		return fmt.Errorf("%w: more than %d files", ErrArchiveLimit, x.maxFiles)
+	}
+	x.files = append(x.files, archiveFile{path: name, data: data})
+	return nil
+}
+
+// archiveEntryName cleans an entry name and reports whether the entry
+// should be read. Hidden files and macOS resource forks are skipped.
+func archiveEntryName(name string) (string, bool) {
+	name = path.Clean("/" + strings.ReplaceAll(name, `\`, "/"))[1:]
+	if name == "" {
+		return "", false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return "", false
+		}
+	}
+	return name, true
+}
+
+// archiveLimitReader fails with ErrArchiveLimit once more than n bytes
+// have been read.
+type archiveLimitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *archiveLimitReader) Read(b []byte) (int, error) {
+	if l.n <= 0 {
+		return 0, fmt.Errorf("%w: gzip stream expands too far", ErrArchiveLimit)
+	}
+	if int64(len(b)) > l.n {
+		b = b[:l.n]
+	}
+	n, err := l.r.Read(b)
+	l.n -= int64(n)
+	return n, err
+}
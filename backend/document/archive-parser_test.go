@@ -0,0 +1,86 @@
+package document
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func tarGz(t testing.TB, pairs ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		h := &tar.Header{Name: pairs[i], Mode: 0o644, Size: int64(len(pairs[i+1])), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(h); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(pairs[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveParser(t *testing.T) {
+	inner := tarGz(t, "docs/a.txt", "Inner text.")
+	bundle := zipFiles(t,
+		"notes.md", "# Notes\n\nOuter text.",
+		"data.tar.gz", string(inner),
+		".hidden.txt", "secret",
+		"__MACOSX/notes.md", "fork",
+		"../escape.txt", "Escaped text.",
+	)
+	docs, err := NewArchiveParser().ParseAll(bundle, "bundle.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sources []string
+	for _, d := range docs {
+		sources = append(sources, d.Source)
+	}
+	want := "bundle.zip!/notes.md bundle.zip!/data.tar.gz!/docs/a.txt bundle.zip!/escape.txt"
+	if got := strings.Join(sources, " "); got != want {
+		t.Errorf("sources %q, want %q", got, want)
+	}
+
+	doc, err := NewArchiveParser().Parse(bundle, "bundle.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(doc.Content, "# notes.md\n\n# Notes") || doc.Metadata["files"] != "3" {
+		t.Errorf("content %q, metadata %v", doc.Content, doc.Metadata)
+	}
+	if len(doc.Headings) != 4 || doc.Headings[1].Level != 2 || !strings.HasPrefix(doc.Content[doc.Headings[1].Offset:], "# Notes") {
+		t.Errorf("headings %+v", doc.Headings)
+	}
+
+	if _, err := (&ArchiveParser{MaxDepth: 1}).ParseAll(zipFiles(t, "data.tar.gz", string(inner)), "b.zip"); err == nil {
+		t.Error("nested archive past MaxDepth parsed")
+	}
+	if _, err := (&ArchiveParser{MaxFiles: 1}).ParseAll(bundle, "bundle.zip"); !errors.Is(err, ErrArchiveLimit) {
+		t.Errorf("MaxFiles: err = %v, want ErrArchiveLimit", err)
+	}
+	bomb := zipFiles(t, "zeros.txt", strings.Repeat("0", 4<<20))
+	if _, err := NewArchiveParser().ParseAll(bomb, "bomb.zip"); !errors.Is(err, ErrArchiveLimit) {
+		t.Errorf("compression ratio: err = %v, want ErrArchiveLimit", err)
+	}
+
+	fx, err := NewArchiveParser().Parse(mustRead(t, fixture("test-zip.zip")), "test-zip.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(fx.Content, "# testdocx.html") || len(fx.Headings) == 0 {
+		t.Errorf("fixture parsed to %d headings", len(fx.Headings))
+	}
+}
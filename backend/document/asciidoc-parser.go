@@ -0,0 +1,374 @@
+package document
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AsciiDocParser converts AsciiDoc to Markdown-style text: section titles
+// become "#" headings recorded in Document.Headings, listing blocks become
+// code fences, tables become pipe rows and admonitions keep their label.
+type AsciiDocParser struct{}
+
+// NewAsciiDocParser creates a new AsciiDoc parser instance.
+func NewAsciiDocParser() *AsciiDocParser {
+	return &AsciiDocParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *AsciiDocParser) Supports(mimeType string) bool {
+	return mimeType == "text/asciidoc" || mimeType == "text/x-asciidoc"
+}
+
+var (
+	adocHeading    = regexp.MustCompile(`^(={1,6}|#{1,6})[ \t]+(.+?)(?:[ \t]+=+)?[ \t]*$`)
+	adocAttribute  = regexp.MustCompile(`^:(!?[\w][\w-]*!?):(?:[ \t]+(.*))?$`)
+	adocDelimiter  = regexp.MustCompile(`^(-{4,}|\.{4,}|={4,}|\*{4,}|_{4,}|\+{4,}|/{4,}|--)[ \t]*$`)
+	adocListItem   = regexp.MustCompile(`^[ \t]*(\*{1,5}|-|\.{1,5}|\d+\.)[ \t]+(.*)$`)
+	adocDescItem   = regexp.MustCompile(`^(.*?\S)(:{2,4}|;;)(?:[ \t]+(.*))?$`)
+	adocBlockAttr  = regexp.MustCompile(`^\[([^\[\]]*)\][ \t]*$`)
+	adocBlockTitle = regexp.MustCompile(`^\.([^.\s].*)$`)
+	adocBlockImage = regexp.MustCompile(`^image::[^\[]*\[([^\],]*)`)
+	adocDirective  = regexp.MustCompile(`^(include|ifdef|ifndef|ifeval|endif|toc)::`)
+	adocRevision   = regexp.MustCompile(`^v?(\d[\w.]*)(?:,\s*([^:]+))?(?::\s*(.*))?$`)
+
+	adocURLMacro  = regexp.MustCompile(`\b((?:https?|ftp|irc)://[^\s\[]+)\[([^\]]*)\]`)
+	adocLinkMacro = regexp.MustCompile(`\b(?:link|xref|mailto):([^\s\[]+)\[([^\]]*)\]`)
+	adocXref      = regexp.MustCompile(`<<([^,>]+)(?:,\s*([^>]+))?>>`)
+	adocFootnote  = regexp.MustCompile(`footnote:(?:[\w-]+)?\[([^\]]*)\]`)
+	adocImage     = regexp.MustCompile(`image:[^\s\[]+\[([^\],\]]*)[^\]]*\]`)
+	adocAnchor    = regexp.MustCompile(`\[\[[^\]]*\]\]|\[#[^\]]*\]`)
+	adocAttrRef   = regexp.MustCompile(`\{([\w-]+)\}`)
+)
+
+// adocHeaderKeys maps document header attributes to metadata keys.
+var adocHeaderKeys = map[string]string{
+	"author": "author", "email": "email", "revnumber": "version", "revdate": "date",
+	"description": "description", "keywords": "keywords", "doctitle": "title",
+}
+
+// adocConv converts AsciiDoc lines, tracking the attributes defined so
+// far for {name} references.
+type adocConv struct {
+	w     *markupWriter
+	attrs map[string]string
+}
+
+// Parse reads the document header (title, author and revision lines and
+// header attributes) into metadata and converts the body.
+func (p *AsciiDocParser) Parse(buffer []byte, filename string) (*Document, error) {
+	src := decodeText(buffer)
+	lines := strings.Split(src, "\n")
+	c := &adocConv{w: &markupWriter{}, attrs: map[string]string{}}
+	meta := map[string]string{}
+
+	// The header is the document title and the lines directly below it.
+	i := 0
+	for i < len(lines) && (strings.TrimSpace(lines[i]) == "" || strings.HasPrefix(lines[i], "//")) {
+		i++
+	}
+	if i < len(lines) && (strings.HasPrefix(lines[i], "= ") || strings.HasPrefix(lines[i], "# ")) {
+		title := c.inline(strings.TrimSpace(lines[i][2:]))
+		c.w.heading(1, title)
+		i++
+		for n := 0; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i, n = i+1, n+1 {
+			line := lines[i]
+			if m := adocAttribute.FindStringSubmatch(line); m != nil {
+				c.attribute(m[1], m[2])
+				if key := adocHeaderKeys[m[1]]; key != "" && m[2] != "" {
+					meta[key] = c.inline(m[2])
+				}
+				continue
+			}
+			if strings.HasPrefix(line, "//") {
+				continue
+			}
+			switch n {
+			case 0:
+				authors := strings.Split(line, ";")
+				for j, a := range authors {
+					name, _, _ := strings.Cut(a, "<")
+					authors[j] = strings.TrimSpace(name)
+				}
+				meta["author"] = strings.Join(authors, ", ")
+			case 1:
+				if m := adocRevision.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+					meta["version"] = m[1]
+					if m[2] != "" {
+						meta["date"] = strings.TrimSpace(m[2])
+					}
+				}
+			}
+		}
+	}
+	c.convert(lines[i:])
+	return c.w.document(filename, meta)
+}
+
+func (c *adocConv) attribute(name, value string) {
+	if strings.HasPrefix(name, "!") || strings.HasSuffix(name, "!") {
+		delete(c.attrs, strings.Trim(name, "!"))
+		return
+	}
+	c.attrs[name] = value
+}
+
+// convert writes a run of block-level lines.
+func (c *adocConv) convert(lines []string) {
+	w := c.w
+	attr, prevBlank := "", true
+	ordered := map[int]int{}
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		blank := line == ""
+		wasBlank := prevBlank
+		prevBlank = blank
+		switch {
+		case blank:
+			w.line("")
+			continue
+		case strings.HasPrefix(line, "//") && !strings.HasPrefix(line, "////"):
+			continue
+		}
+
+		if m := adocDelimiter.FindStringSubmatch(line); m != nil {
+			end := i + 1
+			for end < len(lines) && strings.TrimRight(lines[end], " \t") != m[1] {
+				end++
+			}
+			c.block(m[1], attr, lines[i+1:min(end, len(lines))])
+			attr, i, prevBlank = "", end, true
+			continue
+		}
+		if strings.HasPrefix(line, "|===") || strings.HasPrefix(line, ",===") {
+			end := i + 1
+			for end < len(lines) && !strings.HasPrefix(lines[end], line[:4]) {
+				end++
+			}
+			c.table(line[0], attr, lines[i+1:min(end, len(lines))])
+			attr, i = "", end
+			continue
+		}
+		if m := adocAttribute.FindStringSubmatch(line); m != nil {
+			c.attribute(m[1], m[2])
+			continue
+		}
+		if m := adocBlockAttr.FindStringSubmatch(line); m != nil {
+			if !strings.HasPrefix(line, "[[") && !strings.HasPrefix(line, "[#") {
+				attr = m[1]
+			}
+			continue
+		}
+		if adocDirective.MatchString(line) || line == "'''" || line == "<<<" || line == "+" {
+			continue
+		}
+		if m := adocHeading.FindStringSubmatch(line); m != nil && wasBlank {
+			w.heading(len(m[1]), c.inline(m[2]))
+			attr = ""
+			continue
+		}
+		if m := adocBlockImage.FindStringSubmatch(line); m != nil {
+			if alt := strings.TrimSpace(m[1]); alt != "" {
+				w.line(alt)
+			}
+			continue
+		}
+		if m := adocBlockTitle.FindStringSubmatch(line); m != nil && wasBlank {
+			w.line(c.inline(m[1]))
+			continue
+		}
+
+		if m := adocListItem.FindStringSubmatch(line); m != nil {
+			marker, depth := m[1], len(m[1])
+			if marker == "-" || (marker[0] >= '0' && marker[0] <= '9') {
+				depth = 1
+			}
+			for d := range ordered {
+				if d > depth {
+					delete(ordered, d)
+				}
+			}
+			bullet := "-"
+			if marker[0] == '.' || (marker[0] >= '0' && marker[0] <= '9') {
+				ordered[depth]++
+				bullet = strconv.Itoa(ordered[depth]) + "."
+			}
+			w.line(strings.Repeat("  ", depth-1) + bullet + " " + c.inline(m[2]))
+			continue
+		}
+		if wasBlank {
+			clear(ordered)
+		}
+		if m := adocDescItem.FindStringSubmatch(line); m != nil && !strings.Contains(m[1], "://") {
+			text := c.inline(m[1]) + ":"
+			if m[3] != "" {
+				text += " " + c.inline(m[3])
+			}
+			w.line(text)
+			continue
+		}
+		if strings.HasPrefix(attr, "NOTE") || strings.HasPrefix(attr, "TIP") || strings.HasPrefix(attr, "IMPORTANT") ||
+			strings.HasPrefix(attr, "WARNING") || strings.HasPrefix(attr, "CAUTION") {
+			line = attr + ": " + line
+		}
+		attr = ""
+		w.line(c.inline(strings.TrimSuffix(line, " +")))
+	}
+}
+
+// block writes a delimited block. Listing and literal blocks become
+// fences, comment and passthrough blocks are dropped, quotes are prefixed
+// with "> " and other compound blocks are converted in place.
+func (c *adocConv) block(delim, attr string, body []string) {
+	style, rest, _ := strings.Cut(attr, ",")
+	switch delim[0] {
+	case '/', '+':
+		return
+	case '-':
+		if delim != "--" {
+			lang := ""
+			if style == "source" || style == "" {
+				lang, _, _ = strings.Cut(rest, ",")
+			}
+			c.w.fence(strings.TrimSpace(lang), body)
+			return
+		}
+	case '.':
+		c.w.fence("", body)
+		return
+	case '_':
+		inner := &adocConv{w: &markupWriter{}, attrs: c.attrs}
+		inner.convert(body)
+		c.w.line("")
+		for _, l := range strings.Split(strings.TrimRight(inner.w.b.String(), "\n"), "\n") {
+			c.w.line(strings.TrimRight("> "+l, " "))
+		}
+		c.w.line("")
+		return
+	}
+	switch style {
+	case "NOTE", "TIP", "IMPORTANT", "WARNING", "CAUTION":
+		c.w.line("")
+		c.w.line(style + ":")
+	case "source", "listing", "literal":
+		lang, _, _ := strings.Cut(rest, ",")
+		c.w.fence(strings.TrimSpace(lang), body)
+		return
+	}
+	c.convert(body)
+	c.w.line("")
+}
+
+// table writes a table block as pipe-separated rows. The column count is
+// taken from the cols attribute or the first row.
+func (c *adocConv) table(sep byte, attr string, body []string) {
+	var cells []string
+	cols := adocColumns(attr)
+	for _, line := range body {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var parts []string
+		if sep == ',' {
+			parts = strings.Split(line, ",")
+		} else {
+			if !strings.Contains(line, "|") {
+				// A continuation of the previous cell.
+				if len(cells) > 0 {
+					cells[len(cells)-1] += " " + line
+				}
+				continue
+			}
+			parts = strings.Split(line, "|")
+			if parts[0] == "" {
+				parts = parts[1:]
+			} else if len(cells) > 0 {
+				cells[len(cells)-1] += " " + strings.TrimSpace(parts[0])
+				parts = parts[1:]
+			}
+		}
+		if cols == 0 {
+			cols = len(parts)
+		}
+		for _, p := range parts {
+			cells = append(cells, strings.TrimSpace(p))
+		}
+	}
+	if cols == 0 {
+		return
+	}
+	c.w.line("")
+	for r := 0; r < len(cells); r += cols {
+		row := cells[r:min(r+cols, len(cells))]
+		for i := range row {
+			row[i] = c.inline(row[i])
+		}
+		c.w.line(strings.Join(row, " | "))
+	}
+	c.w.line("")
+}
+
+// adocColumns counts the columns in a cols="..." table attribute, which
+// may use "3*" repeat counts.
+func adocColumns(attr string) int {
+	_, spec, ok := strings.Cut(attr, "cols=")
+	if !ok {
+		return 0
+	}
+	spec = strings.Trim(spec, `"'`)
+	if i := strings.IndexAny(spec, `"'`); i >= 0 {
+		spec = spec[:i]
+	}
+	if n, err := strconv.Atoi(strings.TrimSuffix(spec, "*")); err == nil && strings.HasSuffix(spec, "*") {
+		return n
+	}
+	n := 0
+	for _, part := range strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ';' }) {
+		if count, _, ok := strings.Cut(part, "*"); ok {
+			if k, err := strconv.Atoi(count); err == nil {
+				n += k
+				continue
+			}
+		}
+		n++
+	}
+	return n
+}
+
+// inline reduces macros, cross references and anchors to their text and
+// substitutes attribute references.
+func (c *adocConv) inline(s string) string {
+	s = adocAttrRef.ReplaceAllStringFunc(s, func(m string) string {
+		if v, ok := c.attrs[m[1:len(m)-1]]; ok {
+			return v
+		}
+		return m
+	})
+	s = adocImage.ReplaceAllString(s, "$1")
+	s = adocFootnote.ReplaceAllString(s, "($1)")
+	// Link macros go first, as their targets may be URLs themselves.
+	s = adocLinkMacro.ReplaceAllStringFunc(s, func(m string) string {
+		sub := adocLinkMacro.FindStringSubmatch(m)
+		return adocLinkText(sub[1], sub[2])
+	})
+	s = adocURLMacro.ReplaceAllStringFunc(s, func(m string) string {
+		sub := adocURLMacro.FindStringSubmatch(m)
+		return adocLinkText(sub[1], sub[2])
+	})
+	s = adocXref.ReplaceAllStringFunc(s, func(m string) string {
+		sub := adocXref.FindStringSubmatch(m)
+		return adocLinkText(sub[1], sub[2])
+	})
+	return adocAnchor.ReplaceAllString(s, "")
+}
+
+// adocLinkText returns a macro's text, falling back to its target.
+func adocLinkText(target, text string) string {
+	text, _, _ = strings.Cut(text, ",")
+	if text = strings.Trim(strings.TrimSpace(text), "\""); text != "" {
+		return text
+	}
+	return target
+}
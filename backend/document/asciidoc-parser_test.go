@@ -0,0 +1,36 @@
+package document
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAsciiDocParser(t *testing.T) {
+	const src = "= User Guide\nAda Lovelace <ada@example.com>\nv1.2, 2024-01-01\n:product: Widget\n\n" +
+		"== Install\n\nInstall {product} with the <<setup,setup tool>>.\n\n" +
+		"[source,go]\n----\nfmt.Println(1)\n----\n\nNOTE: Back up first.\n\n" +
+		"|===\n|Name |Role\n|Ada |Author\n|===\n\n////\nhidden comment\n////\n\n" +
+		"=== Details\n\n* one\n** two\n\nSee https://example.com[the site] and link:https://example.com/faq[the FAQ].\n"
+	doc, err := NewAsciiDocParser().Parse([]byte(src), "a.adoc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# User Guide\n\n## Install\n\nInstall Widget with the setup tool.\n\n```go\nfmt.Println(1)\n```\n\n" +
+		"NOTE: Back up first.\n\nName | Role\nAda | Author\n\n### Details\n\n- one\n  - two\n\nSee the site and the FAQ."
+	if doc.Content != want {
+		t.Errorf("content %q, want %q", doc.Content, want)
+	}
+	meta := map[string]string{"title": "User Guide", "author": "Ada Lovelace", "version": "1.2", "date": "2024-01-01"}
+	if !reflect.DeepEqual(doc.Metadata, meta) {
+		t.Errorf("metadata %v, want %v", doc.Metadata, meta)
+	}
+	for _, h := range doc.Headings {
+		if !strings.HasPrefix(doc.Content[h.Offset:], strings.Repeat("#", h.Level)+" "+h.Text) {
+			t.Errorf("heading %+v is not at its offset", h)
+		}
+	}
+	if len(doc.Headings) != 3 {
+		t.Errorf("%d headings, want 3", len(doc.Headings))
+	}
+}
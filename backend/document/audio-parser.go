@@ -0,0 +1,104 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AudioParser transcribes speech recordings through a Transcriber and
+// merges the transcript into timed passages, like SubtitleParser does for
+// captions.
+type AudioParser struct {
+	// Transcriber converts the audio to text. Parse fails when it is nil.
+	Transcriber Transcriber
+	// MaxDuration caps the length of a passage. Defaults to 30 seconds.
+	MaxDuration time.Duration
+	// MaxGap starts a new passage when the silence between segments
+	// exceeds it. Defaults to 2 seconds.
+	MaxGap time.Duration
+}
+
+// NewAudioParser creates a new audio parser instance.
+func NewAudioParser() *AudioParser {
+	return &AudioParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *AudioParser) Supports(mimeType string) bool {
+	switch mimeType {
+	case "audio/mpeg", "audio/mp3", "audio/wav", "audio/x-wav", "audio/wave", "audio/vnd.wave",
+		"audio/mp4", "audio/m4a", "audio/x-m4a":
+		return true
+	}
+	return false
+}
+
+// Parse joins the passages with blank lines and records each one in
+// Document.Segments with "start", "end" and "start_seconds" metadata.
+func (p *AudioParser) Parse(buffer []byte, filename string) (*Document, error) {
+	return p.ParseContext(context.Background(), buffer, filename)
+}
+
+// ParseContext works like Parse but stops transcribing when ctx is done.
+func (p *AudioParser) ParseContext(ctx context.Context, buffer []byte, filename string) (*Document, error) {
+	passages, err := p.passages(ctx, buffer)
+	if err != nil {
+		return nil, err
+	}
+	return subtitleDocument(passages, filename), nil
+}
+
+// ParseAll returns one document per passage, with the timestamps in its
+// metadata.
+func (p *AudioParser) ParseAll(buffer []byte, filename string) ([]*Document, error) {
+	passages, err := p.passages(context.Background(), buffer)
+	if err != nil {
+		return nil, err
+	}
+	return subtitleDocuments(passages, filename), nil
+}
+
+// passages transcribes the audio. Segments with a speaker are prefixed
+// with "Speaker: ".
+func (p *AudioParser) passages(ctx context.Context, buffer []byte) ([]subtitleCue, error) {
+	if p.Transcriber == nil {
+		return nil, errors.New("no transcriber configured for audio")
+	}
+	mimeType := detectAudioType(buffer)
+	if mimeType == "" {
+		return nil, errors.New("unrecognized audio format")
+	}
+	segments, err := transcribe(ctx, p.Transcriber, buffer, mimeType)
+	if err != nil {
+		return nil, fmt.Errorf("transcribe audio: %w", err)
+	}
+	cues := make([]subtitleCue, 0, len(segments))
+	for _, s := range segments {
+		text := strings.Join(strings.Fields(s.Text), " ")
+		if text == "" {
+			continue
+		}
+		if s.Speaker != "" {
+			text = s.Speaker + ": " + text
+		}
+		cues = append(cues, subtitleCue{start: s.Start, end: max(s.End, s.Start), text: text})
+	}
+	return mergeSubtitleCues(cues, p.MaxDuration, p.MaxGap)
+}
+
+// detectAudioType identifies an audio format by its magic bytes.
+func detectAudioType(b []byte) string {
+	s := string(b[:min(len(b), 12)])
+	switch {
+	case strings.HasPrefix(s, "ID3"), len(s) >= 2 && s[0] == '\xff' && s[1]&0xe0 == 0xe0:
+		return "audio/mpeg"
+	case strings.HasPrefix(s, "RIFF") && len(s) == 12 && s[8:] == "WAVE":
+		return "audio/wav"
+	case len(s) == 12 && s[4:8] == "ftyp":
+		return "audio/mp4"
+	}
+	return ""
+}
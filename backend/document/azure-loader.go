@@ -0,0 +1,99 @@
+package document
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AzureBlobLoader loads blobs from an Azure Blob Storage container,
+// authenticating with a shared access signature. Sources are blob names.
+type AzureBlobLoader struct {
+	// Account is the storage account name.
+	Account   string
+	Container string
+	// SASToken is a shared access signature with read and list
+	// permissions, with or without its leading "?". Requests are
+	// anonymous when it is empty, for public containers.
+	SASToken string
+	// Endpoint defaults to https://<Account>.blob.core.windows.net.
+	Endpoint string
+	// MaxRetries is the number of times a failed request is retried, with
+	// exponential backoff. Defaults to 3; negative turns retries off.
+	MaxRetries int
+	// Client defaults to an http.Client with a five minute timeout.
+	Client *http.Client
+}
+
+// NewAzureBlobLoader creates a loader for container in account that
+// authenticates with sasToken.
+func NewAzureBlobLoader(account, container, sasToken string) *AzureBlobLoader {
+	return &AzureBlobLoader{Account: account, Container: container, SASToken: sasToken}
+}
+
+// Load downloads the blob named source.
+func (l *AzureBlobLoader) Load(ctx context.Context, source string) ([]byte, string, error) {
+	segments := strings.Split(source, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	data, err := l.get(ctx, "/"+strings.Join(segments, "/"), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("get azure blob: %w", err)
+	}
+	return data, source, nil
+}
+
+// List returns the names of the blobs whose names start with prefix.
+func (l *AzureBlobLoader) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	query := url.Values{"restype": {"container"}, "comp": {"list"}, "prefix": {prefix}}
+	for {
+		data, err := l.get(ctx, "", query)
+		if err != nil {
+			return nil, fmt.Errorf("list azure blobs: %w", err)
+		}
+		var page struct {
+			Blobs []struct {
+				Name string
+			} `xml:"Blobs>Blob"`
+			NextMarker string
+		}
+		if err := xml.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("decode azure listing: %w", err)
+		}
+		for _, b := range page.Blobs {
+			names = append(names, b.Name)
+		}
+		if page.NextMarker == "" {
+			return names, nil
+		}
+		query.Set("marker", page.NextMarker)
+	}
+}
+
+// get sends a GET for path, relative to the container, with query.
+func (l *AzureBlobLoader) get(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	endpoint := strings.TrimSuffix(l.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://" + l.Account + ".blob.core.windows.net"
+	}
+	params := []string{}
+	if len(query) > 0 {
+		params = append(params, query.Encode())
+	}
+	if sas := strings.TrimPrefix(l.SASToken, "?"); sas != "" {
+		params = append(params, sas)
+	}
+	u := endpoint + "/" + url.PathEscape(l.Container) + path
+	if len(params) > 0 {
+		u += "?" + strings.Join(params, "&")
+	}
+	header := http.Header{}
+	header.Set("X-Ms-Version", "2021-08-06")
+	return retryPolicy{maxRetries: l.MaxRetries}.send(ctx, httpClient(l.Client, 5*time.Minute), http.MethodGet, u, header, nil, "")
+}
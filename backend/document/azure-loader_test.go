@@ -0,0 +1,61 @@
+package document
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestAzureBlobLoader(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		q := r.URL.Query()
+		if q.Get("sig") != "abc" || r.Header.Get("X-Ms-Version") == "" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/my%20docs":
+			if q.Get("restype") != "container" || q.Get("comp") != "list" || q.Get("prefix") != "notes/" {
+				http.Error(w, "bad listing", http.StatusBadRequest)
+				return
+			}
+			if q.Get("marker") == "" {
+				w.Write([]byte(`<EnumerationResults><Blobs><Blob><Name>notes/a.txt</Name></Blob></Blobs><NextMarker>m2</NextMarker></EnumerationResults>`))
+			} else {
+				w.Write([]byte(`<EnumerationResults><Blobs><Blob><Name>notes/b c.txt</Name></Blob></Blobs><NextMarker/></EnumerationResults>`))
+			}
+		case "/my%20docs/notes/b%20c.txt":
+			w.Write([]byte("B"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	l := NewAzureBlobLoader("acct", "my docs", "?sv=2021&sig=abc")
+	l.Endpoint = srv.URL
+	ctx := context.Background()
+	names, err := l.List(ctx, "notes/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"notes/a.txt", "notes/b c.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names %q, want %q", names, want)
+	}
+	data, name, err := l.Load(ctx, "notes/b c.txt")
+	if err != nil || string(data) != "B" || name != "notes/b c.txt" {
+		t.Errorf("Load = %q, %q, %v", data, name, err)
+	}
+	if len(requests) != 3 {
+		t.Errorf("requests %q", requests)
+	}
+	anonymous := NewAzureBlobLoader("acct", "my docs", "")
+	anonymous.Endpoint = srv.URL
+	if _, _, err := anonymous.Load(ctx, "notes/b c.txt"); err == nil {
+		t.Error("request without the SAS returned no error")
+	}
+}
@@ -0,0 +1,120 @@
+package document
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchInput is one file of a batch.
+type BatchInput struct {
+	Filename string
+	Buffer   []byte
+}
+
+// BatchOptions configures ProcessBatch.
+type BatchOptions struct {
+	// Workers is the number of files processed at once. Defaults to the
+	// number of CPUs.
+	Workers int
+	// Pipeline parses and chunks each file. Its sinks are called from
+	// several goroutines at once and must be safe for concurrent use.
+	// Defaults to NewPipeline().
+	Pipeline *Pipeline
+	// Progress, when set, is called as each file starts and finishes.
+	Progress ProgressFunc
+}
+
+// BatchResult is the outcome of one file of a batch.
+type BatchResult struct {
+	Filename string
+	Document *Document
+	Chunks   []Chunk
+	// Err is the failure processing the file, if any.
+	Err error
+}
+
+// BatchError lists the files of a batch that failed.
+type BatchError struct {
+	Failed []BatchResult
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, r := range e.Failed {
+		msgs[i] = r.Filename + ": " + r.Err.Error()
+	}
+	return strconv.Itoa(len(e.Failed)) + " files failed: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors of the failed files, for errors.Is and
+// errors.As.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, r := range e.Failed {
+		errs[i] = r.Err
+	}
+	return errs
+}
+
+// ProcessBatch parses and chunks inputs across a pool of workers and
+// returns a result for each input, in input order. A failing file does
+// not stop the others: its error is recorded in its result, and the
+// returned error is a *BatchError listing every failure, or nil. Inputs
+// not started when ctx is done fail with ctx.Err().
+func ProcessBatch(ctx context.Context, inputs []BatchInput, opts BatchOptions) ([]BatchResult, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = min(workers, len(inputs))
+	p := opts.Pipeline
+	if p == nil {
+		p = NewPipeline()
+	}
+
+	start := time.Now()
+	progress := newProgressTracker(opts.Progress, len(inputs))
+	results := make([]BatchResult, len(inputs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				in := inputs[i]
+				r := BatchResult{Filename: in.Filename}
+				if r.Err = ctx.Err(); r.Err == nil {
+					progress.begin(in.Filename, len(in.Buffer))
+					r.Document, r.Chunks, r.Err = p.Process(ctx, in.Buffer, in.Filename)
+				}
+				progress.done(len(r.Chunks), r.Err)
+				p.notifyDocument(ctx, in.Filename, r.Document, len(r.Chunks), r.Err)
+				results[i] = r
+			}
+		}()
+	}
+	for i := range inputs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var failed []BatchResult
+	chunks := 0
+	for _, r := range results {
+		chunks += len(r.Chunks)
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	p.notifyBatch(ctx, len(results), len(failed), chunks, start)
+	if len(failed) > 0 {
+		return results, &BatchError{Failed: failed}
+	}
+	return results, nil
+}
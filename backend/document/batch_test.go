@@ -0,0 +1,48 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+func TestProcessBatch(t *testing.T) {
+	var inputs []BatchInput
+	for i := range 20 {
+		inputs = append(inputs, BatchInput{Filename: fmt.Sprintf("%02d.txt", i), Buffer: []byte(fmt.Sprintf("File %d has words.", i))})
+	}
+	inputs[7].Buffer = nil
+	var written atomic.Int32
+	sink := SinkFunc(func(ctx context.Context, doc *Document, chunks []Chunk) error {
+		written.Add(1)
+		return nil
+	})
+	results, err := ProcessBatch(context.Background(), inputs, BatchOptions{Workers: 4, Pipeline: NewPipeline().To(sink)})
+	if len(results) != len(inputs) || written.Load() != 19 {
+		t.Fatalf("%d results, %d written", len(results), written.Load())
+	}
+	for i, r := range results {
+		if r.Filename != inputs[i].Filename {
+			t.Errorf("result %d is for %s", i, r.Filename)
+		}
+		if i != 7 && (r.Err != nil || r.Document.Content != string(inputs[i].Buffer) || len(r.Chunks) != 1) {
+			t.Errorf("result %d = %+v", i, r)
+		}
+	}
+	var be *BatchError
+	if !errors.As(err, &be) || len(be.Failed) != 1 || be.Failed[0].Filename != "07.txt" || !errors.Is(err, ErrEmptyDocument) {
+		t.Errorf("err = %v, want a BatchError for 07.txt", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err = ProcessBatch(ctx, inputs[:3], BatchOptions{})
+	if !errors.Is(err, context.Canceled) || len(results) != 3 || results[2].Err == nil {
+		t.Errorf("canceled batch: %+v, %v", results, err)
+	}
+	if results, err := ProcessBatch(context.Background(), nil, BatchOptions{}); len(results) != 0 || err != nil {
+		t.Errorf("empty batch: %+v, %v", results, err)
+	}
+}
@@ -0,0 +1,315 @@
+package document
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// CharsetDecoder converts text in some charset to UTF-8.
+type CharsetDecoder func(data []byte) (string, error)
+
+var (
+	charsetsMu sync.RWMutex
+	// charsets holds the decoders by canonical name.
+	charsets = map[string]CharsetDecoder{
+		"utf-8":        func(data []byte) (string, error) { return strings.ToValidUTF8(string(data), "\ufffd"), nil },
+		"iso-8859-1":   singleByteDecoder(nil),
+		"windows-1252": singleByteDecoder(windows1252Rune),
+		"iso-8859-15":  singleByteDecoder(iso885915Rune),
+		"iso-8859-2":   singleByteDecoder(iso88592Rune),
+		"utf-16le":     utf16Decoder(false),
+		"utf-16be":     utf16Decoder(true),
+		"shift_jis":    encodingDecoder(japanese.ShiftJIS),
+		"gbk":          encodingDecoder(simplifiedchinese.GBK),
+		"gb18030":      encodingDecoder(simplifiedchinese.GB18030),
+	}
+)
+
+// charsetAliases maps other names in use to canonical charset names.
+var charsetAliases = map[string]string{
+	"utf8": "utf-8", "us-ascii": "utf-8", "ascii": "utf-8",
+	"latin1": "iso-8859-1", "iso_8859-1": "iso-8859-1", "l1": "iso-8859-1", "iso8859-1": "iso-8859-1",
+	"cp1252": "windows-1252", "x-cp1252": "windows-1252",
+	"latin-9": "iso-8859-15", "latin9": "iso-8859-15", "iso8859-15": "iso-8859-15",
+	"latin2": "iso-8859-2", "l2": "iso-8859-2", "iso8859-2": "iso-8859-2",
+	"sjis": "shift_jis", "shift-jis": "shift_jis", "x-sjis": "shift_jis", "ms_kanji": "shift_jis", "cp932": "shift_jis", "windows-31j": "shift_jis",
+	"gb2312": "gbk", "cp936": "gbk", "x-gbk": "gbk",
+	"utf-16": "utf-16le", "utf16": "utf-16le", "ucs-2": "utf-16le", "unicode": "utf-16le", "utf16le": "utf-16le", "utf16be": "utf-16be",
+}
+
+// canonicalCharset returns the canonical lower-case name of a charset.
+func canonicalCharset(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if c, ok := charsetAliases[name]; ok {
+		return c
+	}
+	return name
+}
+
+// RegisterCharset makes decode available for the named charset, replacing
+// any decoder already registered under it.
+func RegisterCharset(name string, decode CharsetDecoder) {
+	charsetsMu.Lock()
+	defer charsetsMu.Unlock()
+	charsets[canonicalCharset(name)] = decode
+}
+
+// decodeCharset converts text in the named charset to UTF-8. Charsets
+// without a decoder, and data that fails to decode, are passed through
+// unchanged.
+func decodeCharset(data []byte, charset string) string {
+	charsetsMu.RLock()
+	decode, ok := charsets[canonicalCharset(charset)]
+	charsetsMu.RUnlock()
+	if !ok {
+		return string(data)
+	}
+	text, err := decode(data)
+	if err != nil {
+		return string(data)
+	}
+	return text
+}
+
+// hasCharset reports whether a decoder is registered for charset.
+func hasCharset(charset string) bool {
+	charsetsMu.RLock()
+	defer charsetsMu.RUnlock()
+	_, ok := charsets[canonicalCharset(charset)]
+	return ok
+}
+
+// decodeText converts buffer to UTF-8 from the charset DetectCharset
+// finds, drops a leading byte order mark and turns CRLF and CR line
+// endings into LF. Text in a charset without a decoder keeps its original
+// bytes.
+func decodeText(buffer []byte) string {
+	charset := DetectCharset(buffer)
+	text := string(buffer)
+	if charset != "utf-8" && hasCharset(charset) {
+		text = strings.ToValidUTF8(decodeCharset(buffer, charset), "\ufffd")
+	}
+	return normalizeNewlines(strings.TrimPrefix(text, "\ufeff"))
+}
+
+// decodeTextAs works like decodeText for buffer known to be in charset.
+func decodeTextAs(buffer []byte, charset string) string {
+	text := strings.ToValidUTF8(decodeCharset(buffer, charset), "\ufffd")
+	return normalizeNewlines(strings.TrimPrefix(text, "\ufeff"))
+}
+
+// normalizeNewlines replaces CRLF and lone CR line endings with LF.
+func normalizeNewlines(text string) string {
+	if !strings.Contains(text, "\r") {
+		return text
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+}
+
+var declaredCharset = regexp.MustCompile(`(?i)(?:charset|encoding)\s*=\s*["']?([\w.:-]+)`)
+
+// DetectCharset returns the charset of buffer: the one named by a byte
+// order mark, "utf-16le" or "utf-16be" for text whose every other byte is
+// zero, "utf-8" for valid UTF-8, otherwise the charset declared in an HTML
+// meta tag or XML prolog, or else a guess among "shift_jis", "gbk",
+// "windows-1252" and "iso-8859-1" from the byte patterns.
+func DetectCharset(buffer []byte) string {
+	switch {
+	case bytes.HasPrefix(buffer, []byte("\xef\xbb\xbf")):
+		return "utf-8"
+	case bytes.HasPrefix(buffer, []byte("\xff\xfe")):
+		return "utf-16le"
+	case bytes.HasPrefix(buffer, []byte("\xfe\xff")):
+		return "utf-16be"
+	}
+	if c := utf16Zeros(buffer); c != "" {
+		return c
+	}
+	if utf8.Valid(buffer) {
+		return "utf-8"
+	}
+	if m := declaredCharset.FindSubmatch(buffer[:min(len(buffer), 1024)]); m != nil {
+		if c := canonicalCharset(string(m[1])); c != "utf-8" {
+			return c
+		}
+	}
+	sjisPairs, sjisLow, sjisOK := multibytePairs(buffer, sjisSingle, sjisLead, sjisTrail)
+	gbkPairs, _, gbkOK := multibytePairs(buffer, func(byte) bool { return false }, gbkLead, gbkTrail)
+	switch {
+	// Kana and most common kanji have Shift_JIS lead bytes below 0xA0,
+	// where GB2312 has none.
+	case sjisOK && sjisPairs > 0 && (!gbkOK || 2*sjisLow >= sjisPairs):
+		return "shift_jis"
+	case gbkOK && gbkPairs > 0:
+		return "gbk"
+	}
+	// Bytes left undefined by Windows-1252 suggest plain Latin-1.
+	for _, c := range []byte{0x81, 0x8D, 0x8F, 0x90, 0x9D} {
+		if bytes.IndexByte(buffer, c) >= 0 {
+			return "iso-8859-1"
+		}
+	}
+	return "windows-1252"
+}
+
+// utf16Zeros recognizes UTF-16 without a byte order mark from the zero
+// high bytes of Latin text: "utf-16le" when most odd bytes of the start of
+// buffer are zero and no even ones, "utf-16be" for the reverse.
+func utf16Zeros(buffer []byte) string {
+	head := buffer[:min(len(buffer), 1024)&^1]
+	if len(head) < 4 {
+		return ""
+	}
+	var even, odd int
+	for i := 0; i < len(head); i += 2 {
+		if head[i] == 0 {
+			even++
+		}
+		if head[i+1] == 0 {
+			odd++
+		}
+	}
+	pairs := len(head) / 2
+	switch {
+	case even == 0 && 2*odd > pairs:
+		return "utf-16le"
+	case odd == 0 && 2*even > pairs:
+		return "utf-16be"
+	}
+	return ""
+}
+
+// utf16Decoder decodes UTF-16 in the given byte order, skipping a byte
+// order mark and replacing a dangling final byte with U+FFFD.
+func utf16Decoder(bigEndian bool) CharsetDecoder {
+	return func(data []byte) (string, error) {
+		units := make([]uint16, 0, len(data)/2)
+		for i := 0; i+1 < len(data); i += 2 {
+			if bigEndian {
+				units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+			} else {
+				units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+			}
+		}
+		if len(units) > 0 && units[0] == 0xFEFF {
+			units = units[1:]
+		}
+		text := string(utf16.Decode(units))
+		if len(data)%2 == 1 {
+			text += "\ufffd"
+		}
+		return text, nil
+	}
+}
+
+// Byte classes of Shift_JIS and GBK.
+func sjisSingle(b byte) bool { return b >= 0xA1 && b <= 0xDF }
+func sjisLead(b byte) bool   { return b >= 0x81 && b <= 0x9F || b >= 0xE0 && b <= 0xFC }
+func sjisTrail(b byte) bool  { return b >= 0x40 && b <= 0x7E || b >= 0x80 && b <= 0xFC }
+func gbkLead(b byte) bool    { return b >= 0x81 && b <= 0xFE }
+func gbkTrail(b byte) bool   { return b >= 0x40 && b <= 0x7E || b >= 0x80 && b <= 0xFE }
+
+// multibytePairs checks that every byte of buffer above 0x7F is a single
+// byte accepted by single or a lead byte followed by a trail byte. It
+// returns the number of pairs, how many of them have a lead byte below
+// 0xA0, and whether the whole buffer fits.
+func multibytePairs(buffer []byte, single, lead, trail func(byte) bool) (pairs, low int, ok bool) {
+	for i := 0; i < len(buffer); i++ {
+		c := buffer[i]
+		switch {
+		case c < 0x80 || single(c):
+		case lead(c) && i+1 < len(buffer) && trail(buffer[i+1]):
+			pairs++
+			if c < 0xA0 {
+				low++
+			}
+			i++
+		default:
+			return pairs, low, false
+		}
+	}
+	return pairs, low, true
+}
+
+// encodingDecoder decodes with an encoding from golang.org/x/text, which
+// replaces invalid sequences with U+FFFD.
+func encodingDecoder(e encoding.Encoding) CharsetDecoder {
+	return func(data []byte) (string, error) {
+		text, err := e.NewDecoder().Bytes(data)
+		return string(text), err
+	}
+}
+
+// singleByteDecoder decodes a single-byte charset with high mapping bytes
+// 0x80-0xFF, or as ISO-8859-1 when high is nil.
+func singleByteDecoder(high func(b byte) rune) CharsetDecoder {
+	return func(data []byte) (string, error) {
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			if b >= 0x80 && high != nil {
+				runes[i] = high(b)
+			} else {
+				runes[i] = rune(b)
+			}
+		}
+		return string(runes), nil
+	}
+}
+
+// windows1252High maps bytes 0x80-0x9F, where Windows-1252 differs from
+// ISO-8859-1.
+var windows1252High = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// windows1252Rune decodes a single Windows-1252 byte.
+func windows1252Rune(b byte) rune {
+	if b >= 0x80 && b <= 0x9F {
+		return windows1252High[b-0x80]
+	}
+	return rune(b)
+}
+
+// iso885915Rune decodes a single ISO-8859-15 byte, which differs from
+// ISO-8859-1 in eight positions.
+func iso885915Rune(b byte) rune {
+	switch b {
+	case 0xA4:
+		return '€'
+	case 0xA6:
+		return 'Š'
+	case 0xA8:
+		return 'š'
+	case 0xB4:
+		return 'Ž'
+	case 0xB8:
+		return 'ž'
+	case 0xBC:
+		return 'Œ'
+	case 0xBD:
+		return 'œ'
+	case 0xBE:
+		return 'Ÿ'
+	}
+	return rune(b)
+}
+
+// iso88592High maps bytes 0xA0-0xFF of ISO-8859-2.
+var iso88592High = []rune("\u00a0Ą˘Ł¤ĽŚ§¨ŠŞŤŹ\u00adŽŻ°ą˛ł´ľśˇ¸šşťź˝žżŔÁÂĂÄĹĆÇČÉĘËĚÍÎĎĐŃŇÓÔŐÖ×ŘŮÚŰÜÝŢßŕáâăäĺćçčéęëěíîďđńňóôőö÷řůúűüýţ˙")
+
+// iso88592Rune decodes a single ISO-8859-2 byte.
+func iso88592Rune(b byte) rune {
+	if b >= 0xA0 {
+		return iso88592High[b-0xA0]
+	}
+	return rune(b)
+}
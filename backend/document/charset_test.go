@@ -0,0 +1,130 @@
+package document
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestDetectCharset(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		text  string
+	}{
+		{"plain", "utf-8", "plain"},
+		{"caf\xe9 \x93quoted\x94", "windows-1252", "café “quoted”"},
+		// 0x81 is undefined in Windows-1252.
+		{"caf\xe9 \x81", "iso-8859-1", "café \u0081"},
+		{`<meta charset="iso-8859-2"><p>` + "\xb1</p>", "iso-8859-2", `<meta charset="iso-8859-2"><p>ą</p>`},
+		{`<?xml version="1.0" encoding="ISO-8859-15"?><a>` + "\xa4</a>", "iso-8859-15", `<?xml version="1.0" encoding="ISO-8859-15"?><a>€</a>`},
+		// "こんにちは" and "中文测试".
+		{"\x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd", "shift_jis", "こんにちは"},
+		{"\xd6\xd0\xce\xc4\xb2\xe2\xca\xd4", "gbk", "中文测试"},
+	}
+	for _, tt := range tests {
+		got := DetectCharset([]byte(tt.input))
+		if got != tt.want {
+			t.Errorf("DetectCharset(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+		if tt.text != "" {
+			if text := decodeText([]byte(tt.input)); text != tt.text {
+				t.Errorf("decodeText(%q) = %q, want %q", tt.input, text, tt.text)
+			}
+		}
+	}
+}
+
+func TestCharsetRoundTrip(t *testing.T) {
+	tests := []struct {
+		charset string
+		enc     encoding.Encoding
+		text    string
+	}{
+		{"shift_jis", japanese.ShiftJIS, "日本語のテキスト、ｶﾀｶﾅ"},
+		{"gbk", simplifiedchinese.GBK, "简体中文文本"},
+		// U+20000 and U+1F600 are outside GBK and take four bytes.
+		{"gb18030", simplifiedchinese.GB18030, "中文 \U00020000 \U0001F600"},
+		{"windows-1252", charmap.Windows1252, "café “quoted” – €"},
+		{"iso-8859-1", charmap.ISO8859_1, "naïve façade"},
+		{"iso-8859-15", charmap.ISO8859_15, "€ Œuvre Šárka"},
+		{"iso-8859-2", charmap.ISO8859_2, "Zażółć gęślą jaźń"},
+		{"utf-16le", unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM), "Grüße"},
+		{"utf-16be", unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM), "Grüße"},
+	}
+	for _, tt := range tests {
+		encoded, err := tt.enc.NewEncoder().Bytes([]byte(tt.text))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.charset, err)
+		}
+		if got := decodeCharset(encoded, tt.charset); got != tt.text {
+			t.Errorf("%s: decoded %q, want %q", tt.charset, got, tt.text)
+		}
+	}
+	if got := decodeCharset([]byte("\x95\x32\x82\x36"), "GB18030"); got != "\U00020000" {
+		t.Errorf("gb18030 is decoded as GBK: %q", got)
+	}
+}
+
+func TestRegisterCharset(t *testing.T) {
+	RegisterCharset("X-Upper", func(data []byte) (string, error) { return strings.ToUpper(string(data)), nil })
+	defer func() {
+		charsetsMu.Lock()
+		delete(charsets, "x-upper")
+		charsetsMu.Unlock()
+	}()
+	if got := decodeCharset([]byte("abc"), "x-upper"); got != "ABC" {
+		t.Errorf("registered decoder gave %q", got)
+	}
+	if got := decodeCharset([]byte("abc"), "x-unknown"); got != "abc" {
+		t.Errorf("unknown charset gave %q", got)
+	}
+}
+
+func TestTextParserCharset(t *testing.T) {
+	doc, err := NewTextParser().Parse([]byte("caf\xe9 na\xefve"), "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Content != "café naïve" || doc.Metadata["charset"] != "windows-1252" || doc.Metadata["charset_unsupported"] != "" {
+		t.Errorf("Content %q, metadata %v", doc.Content, doc.Metadata)
+	}
+
+	// A declared charset without a decoder keeps the original bytes.
+	input := "<?xml encoding=\"koi8-r\"?>\n\xf0\xd2\xc9\xd7\xc5\xd4"
+	doc, err = NewTextParser().Parse([]byte(input), "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Content != input || doc.Metadata["charset"] != "koi8-r" || doc.Metadata["charset_unsupported"] != "true" {
+		t.Errorf("Content %q, metadata %v", doc.Content, doc.Metadata)
+	}
+}
+
+func TestDecodeTextUTF16(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"le bom", "\xff\xfeH\x00i\x00\r\x00\n\x00\xe9\x00", "Hi\né"},
+		{"be bom", "\xfe\xff\x00H\x00i\x00\r\x00!", "Hi\n!"},
+		{"le without bom", "H\x00e\x00l\x00l\x00o\x00", "Hello"},
+		{"be without bom", "\x00H\x00e\x00l\x00l\x00o", "Hello"},
+		{"dangling byte", "\xff\xfeH\x00i", "H�"},
+		{"utf-8 bom and crlf", "\xef\xbb\xbfone\r\ntwo\rthree", "one\ntwo\nthree"},
+	}
+	for _, tt := range tests {
+		if got := decodeText([]byte(tt.input)); got != tt.want {
+			t.Errorf("%s: decodeText = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	if got := DetectMIME([]byte("\xff\xfeH\x00i\x00"), "upload"); got != "text/plain" {
+		t.Errorf("DetectMIME of UTF-16 = %q", got)
+	}
+}
@@ -0,0 +1,551 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChatExportParser rebuilds conversations from Slack workspace exports and
+// DiscordChatExporter JSON files. Slack thread replies are placed under
+// their parent message and every message keeps its speaker and time.
+type ChatExportParser struct{}
+
+// NewChatExportParser creates a new chat export parser instance.
+func NewChatExportParser() *ChatExportParser {
+	return &ChatExportParser{}
+}
+
+// Supports checks if the parser handles the given MIME type. Exports are
+// ordinary ZIP and JSON files, so callers tag them with these types, for
+// example after checking DetectChatExport.
+func (p *ChatExportParser) Supports(mimeType string) bool {
+	return mimeType == "application/x-slack-export" || mimeType == "application/x-discord-export"
+}
+
+// DetectChatExport returns "application/x-slack-export" or
+// "application/x-discord-export" when buffer is a ZIP or JSON file in one
+// of the export formats, and "" otherwise.
+func DetectChatExport(buffer []byte) string {
+	platform, _, _, err := readChatExport(buffer, "")
+	if err != nil {
+		return ""
+	}
+	return "application/x-" + platform + "-export"
+}
+
+// chatMessage is a message in render order.
+type chatMessage struct {
+	id      string
+	thread  string // id of the thread root, for Slack threads
+	replyTo string // speaker replied to, for Discord replies
+	reply   bool
+	time    time.Time
+	speaker string
+	text    string
+}
+
+// chatChannel is a conversation and its messages.
+type chatChannel struct {
+	name     string
+	topic    string
+	messages []chatMessage
+}
+
+// Parse renders every channel under a "# #channel" heading, one
+// "[YYYY-MM-DD HH:MM] Speaker: text" line per message with thread replies
+// indented beneath their parent. Each message is recorded in
+// Document.Segments with "channel", "speaker", "timestamp" and, for
+// threads, "thread" metadata.
+func (p *ChatExportParser) Parse(buffer []byte, filename string) (*Document, error) {
+	platform, workspace, channels, err := readChatExport(buffer, filename)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	var headings []Heading
+	var segments []Segment
+	names := make([]string, len(channels))
+	count := 0
+	for i, ch := range channels {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		headings = append(headings, Heading{Level: 1, Text: "#" + ch.name, Offset: b.Len()})
+		b.WriteString("# #" + ch.name + "\n")
+		if ch.topic != "" {
+			b.WriteString("\n" + ch.topic + "\n")
+		}
+		b.WriteString("\n")
+		for j, m := range ch.messages {
+			if j > 0 {
+				b.WriteString("\n")
+			}
+			start := b.Len()
+			b.WriteString(m.line())
+			segments = append(segments, Segment{Start: start, End: b.Len(), Metadata: m.metadata(ch.name)})
+		}
+		names[i] = ch.name
+		count += len(ch.messages)
+	}
+	content := b.String()
+	meta := map[string]string{
+		"platform": platform,
+		"channels": strings.Join(names, ", "),
+		"messages": strconv.Itoa(count),
+	}
+	if workspace != "" {
+		meta["workspace"] = workspace
+	}
+	return &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Headings:  headings,
+		Segments:  segments,
+		Metadata:  meta,
+	}, nil
+}
+
+// ParseAll returns one document per channel. Metadata holds "platform",
+// "channel", "messages", "participants", "start" and "end", and
+// "workspace" when the export names it.
+func (p *ChatExportParser) ParseAll(buffer []byte, filename string) ([]*Document, error) {
+	platform, workspace, channels, err := readChatExport(buffer, filename)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]*Document, len(channels))
+	for i, ch := range channels {
+		lines := make([]string, len(ch.messages))
+		speakers := map[string]bool{}
+		for j, m := range ch.messages {
+			lines[j] = m.line()
+			speakers[m.speaker] = true
+		}
+		participants := make([]string, 0, len(speakers))
+		for s := range speakers {
+			participants = append(participants, s)
+		}
+		sort.Strings(participants)
+		content := strings.Join(lines, "\n")
+		if ch.topic != "" {
+			content = ch.topic + "\n\n" + content
+		}
+		first, last := ch.messages[0].time, ch.messages[0].time
+		for _, m := range ch.messages {
+			if m.time.Before(first) {
+				first = m.time
+			}
+			if m.time.After(last) {
+				last = m.time
+			}
+		}
+		meta := map[string]string{
+			"platform":     platform,
+			"channel":      ch.name,
+			"messages":     strconv.Itoa(len(ch.messages)),
+			"participants": strings.Join(participants, ", "),
+			"start":        first.Format(time.RFC3339),
+			"end":          last.Format(time.RFC3339),
+		}
+		if workspace != "" {
+			meta["workspace"] = workspace
+		}
+		docs[i] = &Document{
+			Content:   content,
+			Source:    filename,
+			WordCount: len(strings.Fields(content)),
+			Metadata:  meta,
+		}
+	}
+	return docs, nil
+}
+
+func (m chatMessage) line() string {
+	speaker := m.speaker
+	if m.replyTo != "" {
+		speaker += " (replying to " + m.replyTo + ")"
+	}
+	text := strings.ReplaceAll(m.text, "\n", "\n    ")
+	line := "[" + m.time.Format("2006-01-02 15:04") + "] " + speaker + ": " + text
+	if m.reply {
+		line = "    " + strings.ReplaceAll(line, "\n", "\n    ")
+	}
+	return line
+}
+
+func (m chatMessage) metadata(channel string) map[string]string {
+	meta := map[string]string{
+		"channel":   channel,
+		"speaker":   m.speaker,
+		"timestamp": m.time.Format(time.RFC3339),
+	}
+	if m.thread != "" {
+		meta["thread"] = m.thread
+	}
+	return meta
+}
+
+// readChatExport detects the export format and reads its channels in
+// name order, dropping channels without messages.
+func readChatExport(buffer []byte, filename string) (platform, workspace string, channels []chatChannel, err error) {
+	trimmed := bytes.TrimLeft(buffer, " \t\r\n\ufeff")
+	switch {
+	case bytes.HasPrefix(buffer, []byte("PK")):
+		x := NewArchiveParser().expander()
+		x.isArchive = func(string) bool { return false }
+		if err := x.zip(buffer, "", 0); err != nil {
+			return "", "", nil, err
+		}
+		platform, workspace, channels = readChatArchive(x.files)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var export discordExport
+		if json.Unmarshal(trimmed, &export) == nil && export.Channel.Name != "" {
+			platform, workspace = "discord", export.Guild.Name
+			channels = []chatChannel{export.channel()}
+		}
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		var msgs []slackMessage
+		if json.Unmarshal(trimmed, &msgs) == nil && len(msgs) > 0 && msgs[0].TS != "" {
+			name := strings.TrimSuffix(path.Base(filename), path.Ext(filename))
+			platform = "slack"
+			channels = []chatChannel{slackChannel(name, "", msgs, nil)}
+		}
+	}
+	if platform == "" {
+		return "", "", nil, errors.New("not a Slack or Discord export")
+	}
+	var out []chatChannel
+	for _, ch := range channels {
+		if len(ch.messages) > 0 {
+			out = append(out, ch)
+		}
+	}
+	if len(out) == 0 {
+		return "", "", nil, ErrEmptyDocument
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return platform, workspace, out, nil
+}
+
+// readChatArchive reads a Slack export, whose channel folders hold one
+// JSON file of messages per day next to users.json and channels.json, or
+// a ZIP of DiscordChatExporter files.
+func readChatArchive(files []archiveFile) (string, string, []chatChannel) {
+	root := ""
+	for _, f := range files {
+		if dir, base := path.Split(f.path); base == "channels.json" || base == "users.json" {
+			root = dir
+			break
+		}
+	}
+	byPath := map[string][]byte{}
+	for _, f := range files {
+		byPath[f.path] = f.data
+	}
+
+	_, hasUsers := byPath[root+"users.json"]
+	_, hasChannels := byPath[root+"channels.json"]
+	if hasUsers || hasChannels {
+		users := map[string]string{}
+		var list []struct {
+			ID       string `json:"id"`
+			Name     string `json:"name"`
+			RealName string `json:"real_name"`
+			Profile  struct {
+				DisplayName string `json:"display_name"`
+				RealName    string `json:"real_name"`
+			} `json:"profile"`
+		}
+		json.Unmarshal(byPath[root+"users.json"], &list)
+		for _, u := range list {
+			users[u.ID] = firstNonEmpty(u.Profile.DisplayName, u.RealName, u.Profile.RealName, u.Name)
+		}
+
+		// Channel folders are named after the channel, or its id for DMs.
+		names, topics := map[string]string{}, map[string]string{}
+		for _, file := range []string{"channels.json", "groups.json", "mpims.json", "dms.json"} {
+			var chans []struct {
+				ID      string   `json:"id"`
+				Name    string   `json:"name"`
+				Members []string `json:"members"`
+				Topic   struct {
+					Value string `json:"value"`
+				} `json:"topic"`
+				Purpose struct {
+					Value string `json:"value"`
+				} `json:"purpose"`
+			}
+			json.Unmarshal(byPath[root+file], &chans)
+			for _, c := range chans {
+				name := c.Name
+				if name == "" {
+					var members []string
+					for _, m := range c.Members {
+						members = append(members, firstNonEmpty(users[m], m))
+					}
+					name = strings.Join(members, ", ")
+				}
+				for _, key := range []string{c.ID, c.Name} {
+					if key != "" {
+						names[key] = name
+						topics[key] = firstNonEmpty(c.Topic.Value, c.Purpose.Value)
+					}
+				}
+			}
+		}
+
+		byDir := map[string][]slackMessage{}
+		var dirs []string
+		for _, f := range files {
+			rel, ok := strings.CutPrefix(f.path, root)
+			dir, base := path.Split(rel)
+			dir = strings.TrimSuffix(dir, "/")
+			if !ok || dir == "" || strings.Contains(dir, "/") || path.Ext(base) != ".json" {
+				continue
+			}
+			var msgs []slackMessage
+			if json.Unmarshal(f.data, &msgs) != nil {
+				continue
+			}
+			if _, seen := byDir[dir]; !seen {
+				dirs = append(dirs, dir)
+			}
+			byDir[dir] = append(byDir[dir], msgs...)
+		}
+		var channels []chatChannel
+		for _, dir := range dirs {
+			channels = append(channels, slackChannel(firstNonEmpty(names[dir], dir), topics[dir], byDir[dir], users))
+		}
+		return "slack", "", channels
+	}
+
+	var channels []chatChannel
+	guild := ""
+	for _, f := range files {
+		if path.Ext(f.path) != ".json" {
+			continue
+		}
+		var export discordExport
+		if json.Unmarshal(f.data, &export) == nil && export.Channel.Name != "" {
+			channels = append(channels, export.channel())
+			guild = firstNonEmpty(guild, export.Guild.Name)
+		}
+	}
+	if len(channels) == 0 {
+		return "", "", nil
+	}
+	return "discord", guild, channels
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// slackMessage is a message object from a Slack export day file.
+type slackMessage struct {
+	Subtype     string `json:"subtype"`
+	User        string `json:"user"`
+	Username    string `json:"username"`
+	Text        string `json:"text"`
+	TS          string `json:"ts"`
+	ThreadTS    string `json:"thread_ts"`
+	UserProfile struct {
+		DisplayName string `json:"display_name"`
+		RealName    string `json:"real_name"`
+	} `json:"user_profile"`
+	BotProfile struct {
+		Name string `json:"name"`
+	} `json:"bot_profile"`
+	Files []struct {
+		Name  string `json:"name"`
+		Title string `json:"title"`
+	} `json:"files"`
+}
+
+// slackSkipped are membership and housekeeping events.
+var slackSkipped = map[string]bool{
+	"channel_join": true, "channel_leave": true, "group_join": true, "group_leave": true,
+	"channel_purpose": true, "channel_topic": true, "channel_name": true, "channel_archive": true,
+	"bot_add": true, "bot_remove": true, "pinned_item": true, "unpinned_item": true,
+}
+
+// slackChannel orders top-level messages by time and places each thread's
+// replies, also in time order, directly after the parent.
+func slackChannel(name, topic string, msgs []slackMessage, users map[string]string) chatChannel {
+	var roots []chatMessage
+	replies := map[string][]chatMessage{}
+	ids := map[string]bool{}
+	for _, m := range msgs {
+		if slackSkipped[m.Subtype] || m.TS == "" {
+			continue
+		}
+		text := slackText(m.Text, users)
+		for _, f := range m.Files {
+			text = strings.TrimSpace(text + " [file: " + firstNonEmpty(f.Title, f.Name) + "]")
+		}
+		if text == "" {
+			continue
+		}
+		cm := chatMessage{
+			id:      m.TS,
+			time:    slackTime(m.TS),
+			speaker: firstNonEmpty(m.UserProfile.DisplayName, m.UserProfile.RealName, users[m.User], m.Username, m.BotProfile.Name, m.User, "unknown"),
+			text:    text,
+		}
+		if m.ThreadTS != "" {
+			cm.thread = m.ThreadTS
+		}
+		if m.ThreadTS != "" && m.ThreadTS != m.TS {
+			replies[m.ThreadTS] = append(replies[m.ThreadTS], cm)
+			continue
+		}
+		ids[m.TS] = true
+		roots = append(roots, cm)
+	}
+	// Replies whose parent is missing from the export stand on their own.
+	for thread, rs := range replies {
+		if !ids[thread] {
+			roots = append(roots, rs...)
+			delete(replies, thread)
+		}
+	}
+	sort.SliceStable(roots, func(i, j int) bool { return roots[i].time.Before(roots[j].time) })
+	ch := chatChannel{name: name, topic: topic}
+	for _, r := range roots {
+		ch.messages = append(ch.messages, r)
+		if r.id != r.thread {
+			continue
+		}
+		rs := replies[r.id]
+		sort.SliceStable(rs, func(i, j int) bool { return rs[i].time.Before(rs[j].time) })
+		for _, reply := range rs {
+			reply.reply = true
+			ch.messages = append(ch.messages, reply)
+		}
+	}
+	return ch
+}
+
+// slackTime converts a "seconds.micros" message timestamp.
+func slackTime(ts string) time.Time {
+	sec, frac, _ := strings.Cut(ts, ".")
+	s, _ := strconv.ParseInt(sec, 10, 64)
+	us, _ := strconv.ParseInt((frac + "000000")[:6], 10, 64)
+	return time.Unix(s, us*1000).UTC()
+}
+
+var slackLink = regexp.MustCompile(`<([^<>|]+)(?:\|([^<>]*))?>`)
+
+// slackText resolves user, channel and link markup and decodes the HTML
+// entities Slack escapes.
+func slackText(s string, users map[string]string) string {
+	s = slackLink.ReplaceAllStringFunc(s, func(m string) string {
+		sub := slackLink.FindStringSubmatch(m)
+		target, label := sub[1], sub[2]
+		switch {
+		case strings.HasPrefix(target, "@"):
+			return "@" + firstNonEmpty(label, users[target[1:]], target[1:])
+		case strings.HasPrefix(target, "#"):
+			return "#" + firstNonEmpty(label, target[1:])
+		case strings.HasPrefix(target, "!"):
+			if label != "" {
+				return strings.TrimPrefix(label, "@")
+			}
+			special, _, _ := strings.Cut(target[1:], "^")
+			return "@" + special
+		}
+		return firstNonEmpty(label, target)
+	})
+	s = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&amp;", "&").Replace(s)
+	return strings.TrimSpace(s)
+}
+
+// discordExport is a channel exported by DiscordChatExporter in JSON.
+type discordExport struct {
+	Guild struct {
+		Name string `json:"name"`
+	} `json:"guild"`
+	Channel struct {
+		Name     string `json:"name"`
+		Category string `json:"category"`
+		Topic    string `json:"topic"`
+	} `json:"channel"`
+	Messages []struct {
+		ID        string `json:"id"`
+		Type      string `json:"type"`
+		Timestamp string `json:"timestamp"`
+		Content   string `json:"content"`
+		Author    struct {
+			Name     string `json:"name"`
+			Nickname string `json:"nickname"`
+		} `json:"author"`
+		Attachments []struct {
+			FileName string `json:"fileName"`
+		} `json:"attachments"`
+		Mentions []struct {
+			ID       string `json:"id"`
+			Name     string `json:"name"`
+			Nickname string `json:"nickname"`
+		} `json:"mentions"`
+		Reference struct {
+			MessageID string `json:"messageId"`
+		} `json:"reference"`
+	} `json:"messages"`
+}
+
+var discordMention = regexp.MustCompile(`<@!?(\d+)>`)
+
+// channel converts the export, resolving mentions and noting which
+// message a reply answers.
+func (e *discordExport) channel() chatChannel {
+	name := e.Channel.Name
+	if e.Channel.Category != "" {
+		name = e.Channel.Category + " / " + name
+	}
+	ch := chatChannel{name: name, topic: strings.TrimSpace(e.Channel.Topic)}
+	speakers := map[string]string{}
+	for _, m := range e.Messages {
+		speaker := firstNonEmpty(m.Author.Nickname, m.Author.Name, "unknown")
+		speakers[m.ID] = speaker
+		if m.Type != "" && m.Type != "Default" && m.Type != "Reply" {
+			continue
+		}
+		mentions := map[string]string{}
+		for _, u := range m.Mentions {
+			mentions[u.ID] = firstNonEmpty(u.Nickname, u.Name)
+		}
+		text := discordMention.ReplaceAllStringFunc(m.Content, func(s string) string {
+			id := discordMention.FindStringSubmatch(s)[1]
+			return "@" + firstNonEmpty(mentions[id], id)
+		})
+		for _, a := range m.Attachments {
+			text += " [file: " + a.FileName + "]"
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		t, _ := time.Parse(time.RFC3339, m.Timestamp)
+		ch.messages = append(ch.messages, chatMessage{
+			id:      m.ID,
+			time:    t.UTC(),
+			speaker: speaker,
+			replyTo: speakers[m.Reference.MessageID],
+			text:    text,
+		})
+	}
+	return ch
+}
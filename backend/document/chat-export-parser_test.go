@@ -0,0 +1,64 @@
+package document
+
+import "testing"
+
+func TestChatExportParserSlack(t *testing.T) {
+	export := zipFiles(t,
+		"export/users.json", `[{"id":"U1","name":"ada","profile":{"display_name":"Ada"}},{"id":"U2","name":"bob","real_name":"Bob Smith"}]`,
+		"export/channels.json", `[{"id":"C1","name":"general","topic":{"value":"Company news"}}]`,
+		"export/general/2024-01-02.json", `[{"user":"U2","text":"Thanks <@U1>!","ts":"1704153660.000200","thread_ts":"1704153600.000100"},`+
+			`{"subtype":"channel_join","user":"U2","text":"joined","ts":"1704153500.000000"}]`,
+		"export/general/2024-01-01.json", `[{"user":"U1","text":"Release is out &amp; live: <https://example.com|notes>","ts":"1704153600.000100","thread_ts":"1704153600.000100"},`+
+			`{"user":"U2","text":"Lunch?","ts":"1704157200.000000"}]`,
+	)
+	if got := DetectChatExport(export); got != "application/x-slack-export" {
+		t.Errorf("DetectChatExport = %q", got)
+	}
+	doc, err := NewChatExportParser().Parse(export, "slack.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# #general\n\nCompany news\n\n" +
+		"[2024-01-02 00:00] Ada: Release is out & live: notes\n" +
+		"    [2024-01-02 00:01] Bob Smith: Thanks @Ada!\n" +
+		"[2024-01-02 01:00] Bob Smith: Lunch?"
+	if doc.Content != want {
+		t.Errorf("content = %q, want %q", doc.Content, want)
+	}
+	if doc.Metadata["platform"] != "slack" || doc.Metadata["messages"] != "3" {
+		t.Errorf("metadata = %v", doc.Metadata)
+	}
+	if len(doc.Segments) != 3 || doc.Segments[1].Metadata["thread"] != "1704153600.000100" || doc.Segments[2].Metadata["thread"] != "" {
+		t.Errorf("segments = %+v", doc.Segments)
+	}
+}
+
+func TestChatExportParserDiscord(t *testing.T) {
+	export := `{"guild":{"name":"Gophers"},"channel":{"name":"help","category":"Support"},"messages":[
+{"id":"1","type":"Default","timestamp":"2024-03-01T10:00:00+00:00","content":"How do I build?","author":{"name":"ada"}},
+{"id":"2","type":"Reply","timestamp":"2024-03-01T10:05:00+00:00","content":"Ask <@9>, or run go build","author":{"name":"bob","nickname":"Bobby"},"mentions":[{"id":"9","name":"carol"}],"reference":{"messageId":"1"}},
+{"id":"3","type":"ChannelPinnedMessage","timestamp":"2024-03-01T10:06:00+00:00","content":"pinned","author":{"name":"bob"}}]}`
+	docs, err := NewChatExportParser().ParseAll([]byte(export), "help.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("got %d documents", len(docs))
+	}
+	want := "[2024-03-01 10:00] ada: How do I build?\n[2024-03-01 10:05] Bobby (replying to ada): Ask @carol, or run go build"
+	if docs[0].Content != want {
+		t.Errorf("content = %q, want %q", docs[0].Content, want)
+	}
+	meta := docs[0].Metadata
+	if meta["channel"] != "Support / help" || meta["workspace"] != "Gophers" || meta["participants"] != "Bobby, ada" ||
+		meta["start"] != "2024-03-01T10:00:00Z" || meta["end"] != "2024-03-01T10:05:00Z" {
+		t.Errorf("metadata = %v", meta)
+	}
+
+	if DetectChatExport([]byte("{}")) != "" {
+		t.Error("plain JSON detected as a chat export")
+	}
+	if _, err := NewChatExportParser().Parse([]byte(`{"channel":{"name":"empty"},"messages":[]}`), "empty.json"); err == nil {
+		t.Error("export without messages parsed")
+	}
+}
@@ -0,0 +1,76 @@
+package document
+
+import (
+	"path"
+	"strings"
+)
+
+// maxSummaryLength caps the summary taken from a document's first
+// sentence.
+const maxSummaryLength = 200
+
+// Contextualize sets EmbedText on each chunk to its Text preceded by
+// document-level context: the document title, a one-line summary and the
+// chunk's section breadcrumb, so that a chunk like "It rose 4% in Q3" is
+// embedded together with what "it" is. Text is left unchanged for display
+// and citation.
+//
+// The title comes from the "title" metadata or the source file name, and
+// the summary from the "summary", "description" or "subject" metadata or
+// else the first sentence of the content. The breadcrumb is the chunk's
+// "heading_path" metadata.
+func Contextualize(doc *Document, chunks []Chunk) {
+	contextualize(doc, chunks, defaultSegmenter)
+}
+
+// contextualize is Contextualize taking the first sentence with seg.
+func contextualize(doc *Document, chunks []Chunk, seg *SentenceSegmenter) {
+	title := doc.Metadata["title"]
+	if title == "" && doc.Source != "" {
+		title = path.Base(strings.ReplaceAll(doc.Source, `\`, "/"))
+	}
+	summary := documentSummary(doc, seg)
+	for i := range chunks {
+		var b strings.Builder
+		if title != "" {
+			b.WriteString("Document: " + title + "\n")
+		}
+		if summary != "" && summary != strings.TrimSpace(chunks[i].Text) {
+			b.WriteString("Summary: " + summary + "\n")
+		}
+		if section := chunks[i].Metadata["heading_path"]; section != "" {
+			b.WriteString("Section: " + section + "\n")
+		}
+		if b.Len() == 0 {
+			chunks[i].EmbedText = ""
+			continue
+		}
+		b.WriteString("\n" + chunks[i].Text)
+		chunks[i].EmbedText = b.String()
+	}
+}
+
+// documentSummary returns a one-line description of doc.
+func documentSummary(doc *Document, seg *SentenceSegmenter) string {
+	for _, key := range []string{"summary", "description", "subject"} {
+		if s := strings.Join(strings.Fields(doc.Metadata[key]), " "); s != "" {
+			return s
+		}
+	}
+	for _, sp := range seg.spans(doc.Content) {
+		s := strings.Join(strings.Fields(doc.Content[sp.start:sp.end]), " ")
+		// Skip headings that merely repeat the title.
+		if s == "" || strings.HasPrefix(s, "#") || s == doc.Metadata["title"] {
+			continue
+		}
+		if len(s) > maxSummaryLength {
+			cut := graphemeStart(s, maxSummaryLength)
+			if i := strings.LastIndexByte(s[:cut], ' '); i > 0 {
+				cut = i
+			}
+			s = s[:cut] + "…"
+		}
+		return s
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContextualize(t *testing.T) {
+	doc := &Document{
+		Content: "# Report\n\nRevenue grew in the third quarter. It rose 4% in Q3.",
+		Source:  `C:\reports\q3.md`,
+	}
+	chunks := []Chunk{
+		{Text: "It rose 4% in Q3.", Metadata: map[string]string{"heading_path": "Report > Revenue"}},
+		{Text: "Revenue grew in the third quarter."},
+	}
+	Contextualize(doc, chunks)
+	want := "Document: q3.md\nSummary: Revenue grew in the third quarter.\nSection: Report > Revenue\n\nIt rose 4% in Q3."
+	if chunks[0].EmbedText != want {
+		t.Errorf("EmbedText = %q, want %q", chunks[0].EmbedText, want)
+	}
+	// The summary is not repeated on the chunk it came from.
+	if want := "Document: q3.md\n\nRevenue grew in the third quarter."; chunks[1].EmbedText != want {
+		t.Errorf("EmbedText = %q, want %q", chunks[1].EmbedText, want)
+	}
+	if chunks[0].EmbeddingText() != chunks[0].EmbedText || (Chunk{Text: "x"}).EmbeddingText() != "x" {
+		t.Error("EmbeddingText does not prefer EmbedText")
+	}
+
+	doc = &Document{Content: strings.Repeat("word ", 100), Metadata: map[string]string{"title": "Notes"}}
+	if s := documentSummary(doc, defaultSegmenter); len(s) > maxSummaryLength+len("…") || !strings.HasSuffix(s, "word…") {
+		t.Errorf("long summary = %q", s)
+	}
+	doc.Metadata["description"] = "  Meeting\nnotes "
+	if s := documentSummary(doc, defaultSegmenter); s != "Meeting notes" {
+		t.Errorf("summary from metadata = %q", s)
+	}
+
+	chunks, err := ChunkDocument("recursive", doc, Options{Contextual: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(chunks[0].EmbedText, "Document: Notes\nSummary: Meeting notes\n\n") {
+		t.Errorf("Contextual option gave %q", chunks[0].EmbedText)
+	}
+}
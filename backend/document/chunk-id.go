@@ -0,0 +1,77 @@
+package document
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// ChunkID returns a deterministic ID for a chunk of source: the hex
+// SHA-256 of source, the chunk's parent ID and offsets, and its text with
+// whitespace runs collapsed. Re-chunking an unchanged document gives the
+// same IDs, so vector stores can upsert rather than duplicate. Chunks
+// without offsets, such as those of HTMLChunker, mix in their Index
+// instead, so repeated text still gets distinct IDs.
+func ChunkID(source string, c Chunk) string {
+	h := sha256.New()
+	parts := []string{
+		source,
+		c.ParentID,
+		strconv.Itoa(c.StartOffset),
+		strconv.Itoa(c.EndOffset),
+		strings.Join(strings.Fields(c.Text), " "),
+	}
+	if c.StartOffset == 0 && c.EndOffset == 0 {
+		parts = append(parts, "#"+strconv.Itoa(c.Index))
+	}
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AssignChunkIDs replaces the ID of every chunk with ChunkID and rewrites
+// ParentID links to match. Parents are given their IDs before children,
+// so a child's ID depends on its parent's.
+func AssignChunkIDs(source string, chunks []Chunk) {
+	ids := map[string]string{}
+	assign := func(children bool) {
+		for i := range chunks {
+			c := &chunks[i]
+			if (c.ParentID != "") != children {
+				continue
+			}
+			if children {
+				if id, ok := ids[c.ParentID]; ok {
+					c.ParentID = id
+				}
+			}
+			id := ChunkID(source, *c)
+			if c.ID != "" {
+				ids[c.ID] = id
+			}
+			c.ID = id
+		}
+	}
+	assign(false)
+	assign(true)
+}
+
+// LinkChunks sets PrevID and NextID of every chunk to its neighbours in
+// order. Top-level chunks are linked to each other and child chunks to
+// each other, across parents, so a hit can be widened at its own
+// granularity.
+func LinkChunks(chunks []Chunk) {
+	last := map[bool]int{}
+	for i := range chunks {
+		child := chunks[i].ParentID != ""
+		chunks[i].PrevID, chunks[i].NextID = "", ""
+		if j, ok := last[child]; ok {
+			chunks[i].PrevID = chunks[j].ID
+			chunks[j].NextID = chunks[i].ID
+		}
+		last[child] = i
+	}
+}
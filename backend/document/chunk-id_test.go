@@ -0,0 +1,62 @@
+package document
+
+import "testing"
+
+func TestChunkID(t *testing.T) {
+	c := Chunk{Text: "Hello  world\n", StartOffset: 4, EndOffset: 17}
+	id := ChunkID("a.txt", c)
+	if len(id) != 64 || id != ChunkID("a.txt", c) {
+		t.Fatalf("ChunkID = %q, not a stable SHA-256", id)
+	}
+	if same := (Chunk{Text: "Hello world", StartOffset: 4, EndOffset: 17}); ChunkID("a.txt", same) != id {
+		t.Error("whitespace changes the ID")
+	}
+	moved := c
+	moved.StartOffset = 5
+	for name, other := range map[string]string{
+		"source": ChunkID("b.txt", c),
+		"offset": ChunkID("a.txt", moved),
+		"text":   ChunkID("a.txt", Chunk{Text: "Hello there", StartOffset: 4, EndOffset: 17}),
+	} {
+		if other == id {
+			t.Errorf("changing the %s keeps the ID", name)
+		}
+	}
+}
+
+func TestAssignChunkIDs(t *testing.T) {
+	chunks := (&HierarchicalChunker{ParentSize: 6, ChildSize: 3}).Chunk("One two three. Four five six.\n\nSeven eight.")
+	AssignChunkIDs("a.txt", chunks)
+	ids := map[string]bool{}
+	for _, c := range chunks {
+		if len(c.ID) != 64 || ids[c.ID] {
+			t.Errorf("chunk %d has ID %q", c.Index, c.ID)
+		}
+		ids[c.ID] = true
+	}
+	for _, c := range chunks {
+		if c.ParentID != "" && !ids[c.ParentID] {
+			t.Errorf("chunk %d links to unknown parent %q", c.Index, c.ParentID)
+		}
+	}
+	if chunks[1].ParentID != chunks[0].ID || chunks[4].ParentID != chunks[3].ID {
+		t.Errorf("parents not rewritten: %q %q", chunks[1].ParentID, chunks[4].ParentID)
+	}
+}
+
+func TestLinkChunks(t *testing.T) {
+	chunks := []Chunk{
+		{ID: "p1"},
+		{ID: "c1", ParentID: "p1"},
+		{ID: "c2", ParentID: "p1"},
+		{ID: "p2"},
+		{ID: "c3", ParentID: "p2", PrevID: "stale"},
+	}
+	LinkChunks(chunks)
+	want := [][2]string{{"", "p2"}, {"", "c2"}, {"c1", "c3"}, {"p1", ""}, {"c2", ""}}
+	for i, c := range chunks {
+		if got := [2]string{c.PrevID, c.NextID}; got != want[i] {
+			t.Errorf("%s links = %q, want %q", c.ID, got, want[i])
+		}
+	}
+}
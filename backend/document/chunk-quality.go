@@ -0,0 +1,186 @@
+package document
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Flags reported by AssessQuality and ScoreChunks for low-value text.
+const (
+	// QualityShort marks text with fewer than three words.
+	QualityShort = "short"
+	// QualityPunctuation marks text that is mostly symbols, punctuation
+	// or digits rather than letters.
+	QualityPunctuation = "punctuation"
+	// QualityRepetitive marks text that repeats a few words, such as
+	// filler or tables of identical values.
+	QualityRepetitive = "repetitive"
+	// QualityNavigation marks menus, breadcrumbs and link lists.
+	QualityNavigation = "navigation"
+	// QualityBoilerplate marks text made of lines repeated across many
+	// chunks, such as headers, footers and disclaimers.
+	QualityBoilerplate = "boilerplate"
+)
+
+// Quality is a score from 0 (junk) to 1 (prose) and the reasons the score
+// was lowered.
+type Quality struct {
+	Score float64
+	Flags []string
+}
+
+// navigationWords are words typical of site menus and footers.
+var navigationWords = map[string]bool{
+	"home": true, "about": true, "contact": true, "login": true, "logout": true,
+	"sign": true, "menu": true, "search": true, "next": true, "previous": true,
+	"prev": true, "back": true, "top": true, "privacy": true, "terms": true,
+	"cookies": true, "careers": true, "blog": true, "faq": true, "help": true,
+	"sitemap": true, "subscribe": true, "share": true, "skip": true,
+}
+
+// AssessQuality scores text on its own: its length, share of letters,
+// lexical diversity and resemblance to navigation.
+func AssessQuality(text string) Quality {
+	q := Quality{Score: 1}
+	penalize := func(flag string, factor float64) {
+		q.Flags = append(q.Flags, flag)
+		q.Score *= factor
+	}
+
+	var letters, visible int
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+		case unicode.IsLetter(r):
+			letters++
+			visible++
+		default:
+			visible++
+		}
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	if len(words) < 3 {
+		penalize(QualityShort, 0.5)
+	}
+	if visible > 0 {
+		if ratio := float64(letters) / float64(visible); ratio < 0.5 {
+			penalize(QualityPunctuation, ratio/0.5)
+		}
+	}
+	if len(words) >= 20 {
+		unique := map[string]bool{}
+		for _, w := range words {
+			unique[w] = true
+		}
+		// Type-token ratio falls with length, so compare against the
+		// square root of the word count.
+		diversity := float64(len(unique)) / math.Sqrt(float64(len(words)))
+		if diversity < 2 {
+			penalize(QualityRepetitive, math.Max(diversity/2, 0.1))
+		}
+	}
+	if looksLikeNavigation(text) {
+		penalize(QualityNavigation, 0.3)
+	}
+	return q
+}
+
+// looksLikeNavigation reports whether text is mostly short lines or
+// separator-joined items with menu words and no sentences.
+func looksLikeNavigation(text string) bool {
+	items := strings.FieldsFunc(text, func(r rune) bool {
+		return r == '\n' || r == '|' || r == '»' || r == '•' || r == '·'
+	})
+	var short, nav, sentences, total int
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		total++
+		fields := strings.Fields(strings.ToLower(item))
+		if len(fields) <= 3 {
+			short++
+		}
+		for _, f := range fields {
+			if navigationWords[strings.Trim(f, ".,:;!?()[]")] {
+				nav++
+				break
+			}
+		}
+		if strings.IndexByte(".!?", item[len(item)-1]) >= 0 && len(fields) > 3 {
+			sentences++
+		}
+	}
+	return total >= 4 && 10*short >= 7*total && 5*nav >= total && 5*sentences < total
+}
+
+// ScoreChunks assesses every chunk and additionally flags as boilerplate
+// chunks whose lines mostly recur in at least three chunks and a fifth of
+// all chunks. The score is stored in each chunk's "quality" metadata and
+// the flags, comma-separated, in "quality_flags".
+func ScoreChunks(chunks []Chunk) []Quality {
+	seen := map[string]int{}
+	for _, c := range chunks {
+		for line := range chunkLines(c.Text) {
+			seen[line]++
+		}
+	}
+	threshold := max(3, (len(chunks)+4)/5)
+
+	out := make([]Quality, len(chunks))
+	for i := range chunks {
+		c := &chunks[i]
+		q := AssessQuality(c.Text)
+		lines := chunkLines(c.Text)
+		repeated := 0
+		for line := range lines {
+			if seen[line] >= threshold {
+				repeated++
+			}
+		}
+		if len(lines) > 0 && 2*repeated > len(lines) {
+			q.Flags = append(q.Flags, QualityBoilerplate)
+			q.Score *= 0.2
+		}
+		out[i] = q
+		if c.Metadata == nil {
+			c.Metadata = map[string]string{}
+		}
+		c.Metadata["quality"] = strconv.FormatFloat(q.Score, 'f', 2, 64)
+		if len(q.Flags) > 0 {
+			c.Metadata["quality_flags"] = strings.Join(q.Flags, ",")
+		}
+	}
+	return out
+}
+
+// FilterChunks returns the chunks whose ScoreChunks score is at least
+// minScore, keeping their Index, IDs and links as they were.
+func FilterChunks(chunks []Chunk, minScore float64) []Chunk {
+	scores := ScoreChunks(chunks)
+	out := make([]Chunk, 0, len(chunks))
+	for i, c := range chunks {
+		if scores[i].Score >= minScore {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// chunkLines returns the distinct non-blank lines of text, with spacing
+// collapsed.
+func chunkLines(text string) map[string]bool {
+	lines := map[string]bool{}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines[line] = true
+		}
+	}
+	return lines
+}
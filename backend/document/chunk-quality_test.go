@@ -0,0 +1,55 @@
+package document
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAssessQuality(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		score float64
+		flags []string
+	}{
+		{"prose", "The committee met on Tuesday to review the budget and agreed to fund the new library.", 1, nil},
+		{"short", "Hi there", 0.5, []string{QualityShort}},
+		{"punctuation", "--- *** ### 123 456 !!! ??? ...", 0, []string{QualityShort, QualityPunctuation}},
+		{"navigation", "Home\nAbout us\nBlog\nContact\nPrivacy", 0.3, []string{QualityNavigation}},
+	}
+	for _, tt := range tests {
+		q := AssessQuality(tt.text)
+		if q.Score != tt.score || !reflect.DeepEqual(q.Flags, tt.flags) {
+			t.Errorf("%s: AssessQuality = %+v, want score %v and flags %v", tt.name, q, tt.score, tt.flags)
+		}
+	}
+	if q := AssessQuality(strings.Repeat("buy now ", 15)); q.Score > 0.5 || !reflect.DeepEqual(q.Flags, []string{QualityRepetitive}) {
+		t.Errorf("repetitive: AssessQuality = %+v", q)
+	}
+}
+
+func TestScoreChunks(t *testing.T) {
+	footer := "Copyright 2024 Example Corp. All rights reserved."
+	chunks := []Chunk{
+		{Text: "Our river cleanup drew forty volunteers this spring.\n" + footer},
+		{Text: footer},
+		{Text: "The bakery on Main Street now opens at six every morning.\n" + footer},
+		{Text: "Rain is expected across the valley for most of the week."},
+	}
+	scores := ScoreChunks(chunks)
+	if scores[1].Score != 0.2 || !reflect.DeepEqual(scores[1].Flags, []string{QualityBoilerplate}) {
+		t.Errorf("footer chunk scored %+v", scores[1])
+	}
+	for _, i := range []int{0, 2, 3} {
+		if scores[i].Score != 1 {
+			t.Errorf("chunk %d scored %+v", i, scores[i])
+		}
+	}
+	if chunks[1].Metadata["quality"] != "0.20" || chunks[1].Metadata["quality_flags"] != QualityBoilerplate || chunks[0].Metadata["quality"] != "1.00" {
+		t.Errorf("metadata %v and %v", chunks[0].Metadata, chunks[1].Metadata)
+	}
+	if kept := FilterChunks(chunks, 0.5); len(kept) != 3 || kept[1].Text != chunks[2].Text {
+		t.Errorf("FilterChunks kept %d chunks", len(kept))
+	}
+}
@@ -0,0 +1,137 @@
+package document
+
+import (
+	"errors"
+	"iter"
+)
+
+// pagedStrategies are the built-in strategies that chunk every page on its
+// own, so ChunkSeq can chunk a page at a time with the same result.
+var pagedStrategies = map[string]bool{
+	"fixed": true, "recursive": true, "paragraph": true, "sentence": true,
+	"semantic": true, "table": true, "window": true, "hierarchical": true,
+}
+
+// ChunkSeq yields the chunks ChunkDocument returns for opts.Strategy in
+// order. For documents with pages and strategies that keep to pages, it
+// chunks one page at a time, so a consumer that stops early skips the
+// remaining pages and at most two pages of chunks are held at once. Other
+// documents are chunked whole before the first chunk is yielded. An error
+// is yielded once, with a zero Chunk, and ends the sequence.
+func ChunkSeq(doc *Document, opts Options) iter.Seq2[Chunk, error] {
+	return func(yield func(Chunk, error) bool) {
+		if doc == nil {
+			yield(Chunk{}, errors.New("document cannot be nil"))
+			return
+		}
+		name := opts.Strategy
+		if name == "" {
+			name = "recursive"
+		}
+		if len(doc.Pages) == 0 || !pagedStrategies[name] {
+			chunks, err := ChunkDocument(name, doc, opts)
+			if err != nil {
+				yield(Chunk{}, err)
+				return
+			}
+			for _, c := range chunks {
+				if !yield(c, nil) {
+					return
+				}
+			}
+			return
+		}
+
+		c, err := LookupChunker(name)
+		if err != nil {
+			yield(Chunk{}, err)
+			return
+		}
+		// Line offsets are computed once for all pages.
+		lined := *doc
+		if len(lined.Lines) == 0 {
+			lined.Lines = lineOffsets(doc.Content)
+		}
+		var prev []Chunk
+		index := 0
+		for _, pg := range doc.Pages {
+			start, end := max(pg.Start, 0), min(pg.End, len(doc.Content))
+			if start >= end {
+				continue
+			}
+			chunks, err := c.Chunk(pageDocument(doc, pg, start, end), opts)
+			if err != nil {
+				yield(Chunk{}, err)
+				return
+			}
+			for i := range chunks {
+				shiftChunk(&chunks[i], start)
+				chunks[i].Index = index
+				index++
+			}
+			SetChunkLines(&lined, chunks)
+			AssignChunkIDs(doc.Source, chunks)
+			LinkChunks(chunks)
+			linkAcross(prev, chunks)
+			SetProvenance(doc, chunks)
+			if opts.DetectLanguage {
+				TagLanguages(doc, chunks)
+			}
+			if opts.Contextual {
+				contextualize(doc, chunks, opts.segmenter())
+			}
+			// The chunks of a page are yielded once the next page has
+			// linked its first chunks to them.
+			for _, ch := range prev {
+				if !yield(ch, nil) {
+					return
+				}
+			}
+			prev = chunks
+		}
+		for _, ch := range prev {
+			if !yield(ch, nil) {
+				return
+			}
+		}
+	}
+}
+
+// pageDocument returns page pg of doc, spanning Content[start:end], as a
+// document of its own with the headings on the page.
+func pageDocument(doc *Document, pg Page, start, end int) *Document {
+	page := &Document{
+		Content:    doc.Content[start:end],
+		Source:     doc.Source,
+		Metadata:   doc.Metadata,
+		Info:       doc.Info,
+		Provenance: doc.Provenance,
+		Pages:      []Page{{Number: pg.Number, Start: 0, End: end - start}},
+	}
+	for _, h := range doc.Headings {
+		if h.Offset >= start && h.Offset < end {
+			h.Offset -= start
+			page.Headings = append(page.Headings, h)
+		}
+	}
+	return page
+}
+
+// linkAcross links the last chunks of prev to the first chunks of next at
+// the same level, continuing LinkChunks across a page boundary.
+func linkAcross(prev, next []Chunk) {
+	for _, child := range []bool{false, true} {
+		i := len(prev) - 1
+		for i >= 0 && (prev[i].ParentID != "") != child {
+			i--
+		}
+		j := 0
+		for j < len(next) && (next[j].ParentID != "") != child {
+			j++
+		}
+		if i >= 0 && j < len(next) {
+			prev[i].NextID = next[j].ID
+			next[j].PrevID = prev[i].ID
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package document
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChunkSeq(t *testing.T) {
+	content := "One two three.\n\nFour five six.\n\nSeven eight.\n\nNine ten eleven twelve."
+	doc := &Document{Source: "a.pdf", Content: content, Pages: []Page{{Number: 1, Start: 0, End: 30}, {Number: 2, Start: 30, End: len(content)}}}
+	// markdown does not keep to pages, so it is chunked whole.
+	for _, name := range []string{"paragraph", "recursive", "sentence", "hierarchical", "markdown"} {
+		t.Run(name, func(t *testing.T) {
+			opts := Options{Strategy: name, ChunkSize: 3}
+			want, err := ChunkDocument(name, doc, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []Chunk
+			for c, err := range ChunkSeq(doc, opts) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, c)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ChunkSeq =\n%+v\nChunkDocument =\n%+v", got, want)
+			}
+		})
+	}
+
+	// Yielding after the loop body breaks would panic.
+	n := 0
+	for range ChunkSeq(doc, Options{Strategy: "paragraph", ChunkSize: 3}) {
+		if n++; n == 2 {
+			break
+		}
+	}
+	for _, tt := range []struct {
+		doc  *Document
+		opts Options
+	}{
+		{nil, Options{}},
+		{doc, Options{Strategy: "no-such-strategy"}},
+	} {
+		count := 0
+		for c, err := range ChunkSeq(tt.doc, tt.opts) {
+			if count++; err == nil || c.Text != "" {
+				t.Errorf("ChunkSeq(%v) yielded %+v, %v", tt.opts, c, err)
+			}
+		}
+		if count != 1 {
+			t.Errorf("ChunkSeq(%v) yielded %d values, want one error", tt.opts, count)
+		}
+	}
+}
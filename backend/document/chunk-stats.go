@@ -0,0 +1,86 @@
+package document
+
+import (
+	"time"
+	"unicode"
+)
+
+// ReadingWordsPerMinute is the reading speed behind ChunkStats.ReadingTime.
+// Text in scripts written without spaces, such as Chinese and Japanese, is
+// counted as one word per character.
+var ReadingWordsPerMinute = 238
+
+// ChunkStats measures a chunk, for budgeting embedding costs and filtering
+// degenerate chunks.
+type ChunkStats struct {
+	// Tokens is the chunk's TokenCount, as counted by the chunker's
+	// Tokenizer.
+	Tokens int
+	// Words is the number of runs of non-space characters.
+	Words int
+	// Characters is the number of user-perceived characters (grapheme
+	// clusters), and Bytes the length of the UTF-8 text.
+	Characters int
+	Bytes      int
+	// ReadingTime estimates how long the text takes to read at
+	// ReadingWordsPerMinute.
+	ReadingTime time.Duration
+}
+
+// Stats returns the statistics of c.Text.
+func (c Chunk) Stats() ChunkStats {
+	return ChunkStats{
+		Tokens:      c.TokenCount,
+		Words:       WhitespaceTokenizer{}.Count(c.Text),
+		Characters:  graphemeCount(c.Text),
+		Bytes:       len(c.Text),
+		ReadingTime: readingTime(c.Text),
+	}
+}
+
+// SummarizeChunks returns the totals of the statistics of chunks.
+func SummarizeChunks(chunks []Chunk) ChunkStats {
+	var total ChunkStats
+	for _, c := range chunks {
+		s := c.Stats()
+		total.Tokens += s.Tokens
+		total.Words += s.Words
+		total.Characters += s.Characters
+		total.Bytes += s.Bytes
+		total.ReadingTime += s.ReadingTime
+	}
+	return total
+}
+
+// graphemeCount returns the number of grapheme clusters in text.
+func graphemeCount(text string) int {
+	n := 0
+	for i := range text {
+		if graphemeBoundary(text, i) {
+			n++
+		}
+	}
+	return n
+}
+
+// readingTime estimates the time to read text, counting each Han and kana
+// character as a word of its own.
+func readingTime(text string) time.Duration {
+	if ReadingWordsPerMinute <= 0 {
+		return 0
+	}
+	words, inWord := 0, false
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			inWord = false
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			words++
+			inWord = false
+		case !inWord:
+			words++
+			inWord = true
+		}
+	}
+	return time.Duration(words) * time.Minute / time.Duration(ReadingWordsPerMinute)
+}
@@ -0,0 +1,35 @@
+package document
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChunkStats(t *testing.T) {
+	tests := []struct {
+		text string
+		want ChunkStats
+	}{
+		{"Hello brave new world", ChunkStats{Tokens: 4, Words: 4, Characters: 21, Bytes: 21, ReadingTime: 4 * time.Minute / 238}},
+		// "é" as e plus a combining accent is one character of three bytes.
+		{"cafe\u0301 ok", ChunkStats{Tokens: 4, Words: 2, Characters: 7, Bytes: 9, ReadingTime: 2 * time.Minute / 238}},
+		// Each Han character reads as a word.
+		{"東京タワー", ChunkStats{Tokens: 4, Words: 1, Characters: 5, Bytes: 15, ReadingTime: 5 * time.Minute / 238}},
+	}
+	var total ChunkStats
+	chunks := make([]Chunk, len(tests))
+	for i, tt := range tests {
+		chunks[i] = Chunk{Text: tt.text, TokenCount: tt.want.Tokens}
+		if got := chunks[i].Stats(); got != tt.want {
+			t.Errorf("Stats(%q) = %+v, want %+v", tt.text, got, tt.want)
+		}
+		total.Tokens += tt.want.Tokens
+		total.Words += tt.want.Words
+		total.Characters += tt.want.Characters
+		total.Bytes += tt.want.Bytes
+		total.ReadingTime += tt.want.ReadingTime
+	}
+	if got := SummarizeChunks(chunks); got != total {
+		t.Errorf("SummarizeChunks = %+v, want %+v", got, total)
+	}
+}
@@ -0,0 +1,165 @@
+package document
+
+import "cmp"
+
+// Chunk is a piece of a document sized for embedding, with metadata that
+// describes where it came from.
+type Chunk struct {
+	// ID identifies the chunk among the output of one chunker call. It is
+	// set by chunkers that link chunks, such as HierarchicalChunker, and
+	// replaced with a content-derived ID by AssignChunkIDs.
+	ID string
+	// ParentID is the ID of the larger chunk that contains this one, or ""
+	// for top-level chunks.
+	ParentID string
+	// PrevID and NextID are the IDs of the chunks before and after this
+	// one at the same level, or "" at either end. See LinkChunks.
+	PrevID string
+	NextID string
+	// Index is the position of the chunk in the chunker's output.
+	Index int
+	Text  string
+	// StartOffset and EndOffset are the byte range of the chunk in the
+	// source text, for highlighting it there. Text is usually
+	// Content[StartOffset:EndOffset]; chunks that repeat a table header or
+	// were merged cover their whole source range instead. Both are 0 for
+	// chunks with no position in Content, such as those of HTMLChunker.
+	StartOffset int
+	EndOffset   int
+	// StartLine and EndLine are the 1-based numbers of the first and last
+	// line of the source text the chunk covers, or 0 when unknown.
+	StartLine int
+	EndLine   int
+	// TokenCount is the size of Text as counted by the chunker's Tokenizer.
+	TokenCount int
+	// PageStart and PageEnd are the numbers of the first and last page or
+	// slide the chunk covers, or 0 for documents without pages.
+	PageStart int
+	PageEnd   int
+	// EmbedText, when set, is the text to embed instead of Text, such as
+	// Text with document context added by Contextualize.
+	EmbedText string
+	// Embedding is the vector of EmbeddingText, as set by EmbedChunks.
+	Embedding []float32
+	// Provenance records the chunk's source for citations. See
+	// SetProvenance.
+	Provenance Provenance
+	Metadata   map[string]string
+}
+
+// EmbeddingText returns the text to embed for c: EmbedText when set, or
+// Text.
+func (c Chunk) EmbeddingText() string {
+	if c.EmbedText != "" {
+		return c.EmbedText
+	}
+	return c.Text
+}
+
+// spanChunks returns the chunk for each span of text, numbered in order.
+func spanChunks(text string, spans []textSpan, tok Tokenizer) []Chunk {
+	out := make([]Chunk, len(spans))
+	for i, sp := range spans {
+		out[i] = Chunk{
+			Index:       i,
+			Text:        text[sp.start:sp.end],
+			StartOffset: sp.start,
+			EndOffset:   sp.end,
+			TokenCount:  tok.Count(text[sp.start:sp.end]),
+		}
+	}
+	return out
+}
+
+// SetChunkLines sets StartLine and EndLine of every chunk of doc from its
+// offsets, using doc.Lines when set. Chunks without offsets are left
+// alone.
+func SetChunkLines(doc *Document, chunks []Chunk) {
+	lines := doc.Lines
+	if len(lines) == 0 {
+		lines = lineOffsets(doc.Content)
+	}
+	for i := range chunks {
+		if c := &chunks[i]; c.EndOffset > c.StartOffset {
+			c.StartLine = lineIndex(lines, c.StartOffset)
+			c.EndLine = lineIndex(lines, c.EndOffset-1)
+		}
+	}
+}
+
+// chunkTexts returns the text of each chunk.
+func chunkTexts(chunks []Chunk) []string {
+	out := make([]string, len(chunks))
+	for i, c := range chunks {
+		out[i] = c.Text
+	}
+	return out
+}
+
+// MergeSmallChunks merges every chunk with fewer than minSize tokens, as
+// counted by tok or DefaultTokenizer when nil, into the chunk after it, or
+// into the one before it at the end of the list. Fragments such as stray
+// headings thus join the section they introduce. Chunks with different
+// parents are never merged; the absorbing chunk keeps its ID and
+// metadata, and its offsets grow to cover both. Chunks are renumbered.
+func MergeSmallChunks(chunks []Chunk, minSize int, tok Tokenizer) []Chunk {
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	var out []Chunk
+	var pending *Chunk
+	for _, c := range chunks {
+		if pending != nil {
+			if pending.ParentID == c.ParentID {
+				mergeSpan(&c, *pending, c)
+				c.Text = pending.Text + "\n\n" + c.Text
+				c.TokenCount = tok.Count(c.Text)
+			} else {
+				out = appendSmallChunk(out, *pending, tok)
+			}
+			pending = nil
+		}
+		if tok.Count(c.Text) < minSize {
+			pending = &c
+			continue
+		}
+		out = append(out, c)
+	}
+	if pending != nil {
+		out = appendSmallChunk(out, *pending, tok)
+	}
+	for i := range out {
+		out[i].Index = i
+	}
+	return out
+}
+
+// appendSmallChunk merges c into the last chunk of out when they share a
+// parent, or appends it.
+func appendSmallChunk(out []Chunk, c Chunk, tok Tokenizer) []Chunk {
+	if n := len(out); n > 0 && out[n-1].ParentID == c.ParentID {
+		mergeSpan(&out[n-1], out[n-1], c)
+		out[n-1].Text += "\n\n" + c.Text
+		out[n-1].TokenCount = tok.Count(out[n-1].Text)
+		return out
+	}
+	return append(out, c)
+}
+
+// mergeSpan sets the offsets, lines and pages of c to run from the start
+// of first to the end of last, which follows it. Lines and pages unknown
+// in one are taken from the other.
+func mergeSpan(c *Chunk, first, last Chunk) {
+	c.StartOffset, c.EndOffset = first.StartOffset, last.EndOffset
+	c.StartLine, c.EndLine = cmp.Or(first.StartLine, last.StartLine), cmp.Or(last.EndLine, first.EndLine)
+	c.PageStart, c.PageEnd = cmp.Or(first.PageStart, last.PageStart), cmp.Or(last.PageEnd, first.PageEnd)
+}
+
+// MergeSmallTexts works like MergeSmallChunks on plain chunk texts.
+func MergeSmallTexts(texts []string, minSize int, tok Tokenizer) []string {
+	chunks := make([]Chunk, len(texts))
+	for i, t := range texts {
+		chunks[i].Text = t
+	}
+	return chunkTexts(MergeSmallChunks(chunks, minSize, tok))
+}
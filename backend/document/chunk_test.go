@@ -0,0 +1,54 @@
+package document
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeSmallChunks(t *testing.T) {
+	got := MergeSmallTexts([]string{"# Title", "Body text goes here.", "More body text here.", "End"}, 3, nil)
+	want := []string{"# Title\n\nBody text goes here.", "More body text here.\n\nEnd"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeSmallTexts = %q, want %q", got, want)
+	}
+
+	chunks := MergeSmallChunks([]Chunk{
+		{ID: "a", ParentID: "p0", Text: "x"},
+		{ID: "b", ParentID: "p1", Text: "long enough text"},
+		{ID: "c", ParentID: "p1", Text: "y"},
+	}, 2, nil)
+	if len(chunks) != 2 || chunks[0].ID != "a" || chunks[1].ID != "b" || chunks[1].Text != "long enough text\n\ny" {
+		t.Errorf("chunks across parents merged as %+v", chunks)
+	}
+
+	// Merged chunks run from the start of the first to the end of the last.
+	chunks = MergeSmallChunks([]Chunk{
+		{Text: "x", StartOffset: 0, EndOffset: 1, StartLine: 1, EndLine: 1, PageStart: 1, PageEnd: 1},
+		{Text: "long enough text", StartOffset: 3, EndOffset: 19, StartLine: 3, EndLine: 4, PageStart: 1, PageEnd: 2},
+		{Text: "y", StartOffset: 21, EndOffset: 22, StartLine: 6, EndLine: 6, PageStart: 3, PageEnd: 3},
+	}, 2, nil)
+	if len(chunks) != 1 {
+		t.Fatalf("chunks %+v", chunks)
+	}
+	c := chunks[0]
+	if got := [6]int{c.StartOffset, c.EndOffset, c.StartLine, c.EndLine, c.PageStart, c.PageEnd}; got != [6]int{0, 22, 1, 6, 1, 3} {
+		t.Errorf("merged offsets, lines and pages %v", got)
+	}
+}
+
+func TestSetChunkLines(t *testing.T) {
+	doc := &Document{Content: "one\ntwo\n\nthree\nfour"}
+	chunks := []Chunk{
+		{StartOffset: 0, EndOffset: 7},
+		{StartOffset: 4, EndOffset: 9},
+		{StartOffset: 9, EndOffset: 19},
+		{Text: "no offsets"},
+	}
+	SetChunkLines(doc, chunks)
+	want := [][2]int{{1, 2}, {2, 3}, {4, 5}, {0, 0}}
+	for i, c := range chunks {
+		if got := [2]int{c.StartLine, c.EndLine}; got != want[i] {
+			t.Errorf("chunk %d lines = %v, want %v", i, got, want[i])
+		}
+	}
+}
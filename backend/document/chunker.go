@@ -0,0 +1,392 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Chunker splits a parsed document into chunks with one strategy.
+type Chunker interface {
+	Chunk(doc *Document, opts Options) ([]Chunk, error)
+}
+
+// Options configures a Chunker. Zero values select each strategy's
+// defaults.
+type Options struct {
+	// ChunkSize is the maximum chunk size in tokens.
+	ChunkSize int
+	// Overlap is the number of tokens repeated between consecutive chunks,
+	// for strategies that support it.
+	Overlap int
+	// Tokenizer measures sizes. Defaults to DefaultTokenizer.
+	Tokenizer Tokenizer
+	// Embedder is required by the "semantic" strategy.
+	Embedder Embedder
+	// Context bounds calls to the Embedder. Defaults to
+	// context.Background.
+	Context context.Context
+	// Contextual adds document context to each chunk's EmbedText with
+	// Contextualize.
+	Contextual bool
+	// DetectLanguage records the language of the document and of each
+	// chunk in their "lang" metadata with TagLanguages.
+	DetectLanguage bool
+	// Language is the ISO 639-1 code of the text, such as "de", selecting
+	// the sentence rules of the strategies that split at sentences.
+	// Defaults to English.
+	Language string
+	// Strategy names the registered chunker ChunkSeq uses, which
+	// ChunkDocument takes as an argument instead. Defaults to
+	// "recursive".
+	Strategy string
+}
+
+// ChunkOption sets a field of Options, for NewOptions and the chunker
+// constructors. Constructors ignore options their chunker has no field
+// for.
+type ChunkOption func(*Options)
+
+// WithChunkSize sets the maximum chunk size in tokens.
+func WithChunkSize(n int) ChunkOption {
+	return func(o *Options) { o.ChunkSize = n }
+}
+
+// WithOverlap sets the number of tokens repeated between chunks.
+func WithOverlap(n int) ChunkOption {
+	return func(o *Options) { o.Overlap = n }
+}
+
+// WithTokenizer sets the tokenizer that measures sizes.
+func WithTokenizer(tok Tokenizer) ChunkOption {
+	return func(o *Options) { o.Tokenizer = tok }
+}
+
+// WithEmbedder sets the embedder of the "semantic" strategy.
+func WithEmbedder(e Embedder) ChunkOption {
+	return func(o *Options) { o.Embedder = e }
+}
+
+// WithContextual adds document context to each chunk's EmbedText.
+func WithContextual() ChunkOption {
+	return func(o *Options) { o.Contextual = true }
+}
+
+// WithLanguageDetection records the language of the document and chunks.
+func WithLanguageDetection() ChunkOption {
+	return func(o *Options) { o.DetectLanguage = true }
+}
+
+// WithLanguage sets the language whose sentence rules the chunker uses.
+func WithLanguage(lang string) ChunkOption {
+	return func(o *Options) { o.Language = lang }
+}
+
+// WithStrategy sets the chunking strategy of ChunkSeq.
+func WithStrategy(name string) ChunkOption {
+	return func(o *Options) { o.Strategy = name }
+}
+
+// NewOptions returns the Options set by opts.
+func NewOptions(opts ...ChunkOption) Options {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// ChunkerFunc adapts a function to the Chunker interface.
+type ChunkerFunc func(doc *Document, opts Options) ([]Chunk, error)
+
+// Chunk calls f(doc, opts).
+func (f ChunkerFunc) Chunk(doc *Document, opts Options) ([]Chunk, error) {
+	return f(doc, opts)
+}
+
+var (
+	chunkersMu sync.RWMutex
+	chunkers   = map[string]Chunker{
+		"fixed":        ChunkerFunc(chunkFixed),
+		"recursive":    ChunkerFunc(chunkRecursive),
+		"paragraph":    ChunkerFunc(chunkParagraphs),
+		"sentence":     ChunkerFunc(chunkSentences),
+		"semantic":     ChunkerFunc(chunkSemantic),
+		"markdown":     ChunkerFunc(chunkMarkdown),
+		"code":         ChunkerFunc(chunkCode),
+		"table":        ChunkerFunc(chunkTables),
+		"window":       ChunkerFunc(chunkWindows),
+		"hierarchical": ChunkerFunc(chunkHierarchical),
+	}
+)
+
+// spanStrategies are the built-in strategies that chunk each page into
+// spans of its text, keyed by name, each returning the span function for
+// opts. DryRun uses them to count chunks and tokens without building
+// chunks. Guarded by chunkersMu.
+var spanStrategies = map[string]func(opts Options) func(text string) []textSpan{
+	"fixed":     spansFixed,
+	"recursive": spansRecursive,
+	"paragraph": spansParagraph,
+	"window":    spansWindow,
+}
+
+// RegisterChunker makes a chunker available under name, replacing any
+// chunker already registered with that name.
+func RegisterChunker(name string, c Chunker) {
+	chunkersMu.Lock()
+	defer chunkersMu.Unlock()
+	chunkers[name] = c
+	delete(spanStrategies, name)
+}
+
+// lookupSpans returns the span function of the built-in strategy name for
+// opts, or nil when name chunks otherwise or was replaced.
+func lookupSpans(name string, opts Options) func(text string) []textSpan {
+	chunkersMu.RLock()
+	defer chunkersMu.RUnlock()
+	if f := spanStrategies[name]; f != nil {
+		return f(opts)
+	}
+	return nil
+}
+
+// LookupChunker returns the chunker registered under name.
+func LookupChunker(name string) (Chunker, error) {
+	chunkersMu.RLock()
+	defer chunkersMu.RUnlock()
+	c, ok := chunkers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown chunking strategy %q", name)
+	}
+	return c, nil
+}
+
+// ChunkerNames returns the registered strategy names in sorted order.
+func ChunkerNames() []string {
+	chunkersMu.RLock()
+	defer chunkersMu.RUnlock()
+	names := make([]string, 0, len(chunkers))
+	for name := range chunkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ChunkDocument chunks doc with the strategy registered under name, records
+// line ranges with SetChunkLines, gives every chunk a stable ID with
+// AssignChunkIDs, links neighbours with LinkChunks and records their
+// provenance with SetProvenance.
+func ChunkDocument(name string, doc *Document, opts Options) ([]Chunk, error) {
+	if doc == nil {
+		return nil, errors.New("document cannot be nil")
+	}
+	c, err := LookupChunker(name)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := c.Chunk(doc, opts)
+	if err != nil {
+		return nil, err
+	}
+	SetChunkLines(doc, chunks)
+	AssignChunkIDs(doc.Source, chunks)
+	LinkChunks(chunks)
+	SetProvenance(doc, chunks)
+	if opts.DetectLanguage {
+		TagLanguages(doc, chunks)
+	}
+	if opts.Contextual {
+		contextualize(doc, chunks, opts.segmenter())
+	}
+	return chunks, nil
+}
+
+// perPage applies split to each page of doc separately, so no chunk spans
+// a page boundary, and records the page on every chunk. Documents without
+// pages are split whole. Offsets are made relative to doc.Content and
+// chunks are renumbered across pages.
+func perPage(doc *Document, split func(text string) ([]Chunk, error)) ([]Chunk, error) {
+	if len(doc.Pages) == 0 {
+		return split(doc.Content)
+	}
+	var out []Chunk
+	for _, pg := range doc.Pages {
+		start, end := max(pg.Start, 0), min(pg.End, len(doc.Content))
+		if start >= end {
+			continue
+		}
+		chunks, err := split(doc.Content[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", pg.Number, err)
+		}
+		for i := range chunks {
+			chunks[i].PageStart, chunks[i].PageEnd = pg.Number, pg.Number
+			shiftChunk(&chunks[i], start)
+			chunks[i].Index = len(out) + i
+		}
+		out = append(out, chunks...)
+	}
+	return out, nil
+}
+
+// shiftChunk moves the offsets of c, a chunk of text starting at offset
+// start in a larger text, to that text.
+func shiftChunk(c *Chunk, start int) {
+	if c.EndOffset > 0 {
+		c.StartOffset += start
+		c.EndOffset += start
+	}
+}
+
+// tokenizer returns the tokenizer of opts, DefaultTokenizer when unset.
+func (opts Options) tokenizer() Tokenizer {
+	if opts.Tokenizer == nil {
+		return DefaultTokenizer
+	}
+	return opts.Tokenizer
+}
+
+// segmenter returns the SentenceSegmenter for opts.Language.
+func (opts Options) segmenter() *SentenceSegmenter {
+	if opts.Language == "" {
+		return defaultSegmenter
+	}
+	return &SentenceSegmenter{Language: opts.Language}
+}
+
+func chunkFixed(doc *Document, opts Options) ([]Chunk, error) {
+	return chunkSpans(doc, opts, spansFixed(opts))
+}
+
+func spansFixed(opts Options) func(text string) []textSpan {
+	size := opts.ChunkSize
+	if size <= 0 {
+		size = 200
+	}
+	tok, seg := opts.tokenizer(), opts.segmenter()
+	return func(text string) []textSpan {
+		return overlapSpans(text, sentenceGroups(text, size, tok, seg), opts.Overlap, tok)
+	}
+}
+
+func chunkRecursive(doc *Document, opts Options) ([]Chunk, error) {
+	return chunkSpans(doc, opts, spansRecursive(opts))
+}
+
+func spansRecursive(opts Options) func(text string) []textSpan {
+	s := &RecursiveSplitter{ChunkSize: opts.ChunkSize, Tokenizer: opts.Tokenizer, Overlap: opts.Overlap, Segmenter: opts.segmenter()}
+	return s.spans
+}
+
+func chunkParagraphs(doc *Document, opts Options) ([]Chunk, error) {
+	return chunkSpans(doc, opts, spansParagraph(opts))
+}
+
+func spansParagraph(opts Options) func(text string) []textSpan {
+	c := &ParagraphChunker{ChunkSize: opts.ChunkSize, Tokenizer: opts.Tokenizer, Overlap: opts.Overlap, Segmenter: opts.segmenter()}
+	return c.spans
+}
+
+// chunkSpans returns the chunks of the spans split finds on each page of
+// doc.
+func chunkSpans(doc *Document, opts Options, split func(text string) []textSpan) ([]Chunk, error) {
+	return perPage(doc, func(text string) ([]Chunk, error) {
+		return spanChunks(text, split(text), opts.tokenizer()), nil
+	})
+}
+
+func chunkSentences(doc *Document, opts Options) ([]Chunk, error) {
+	c := &SentenceChunker{ChunkSize: opts.ChunkSize, Tokenizer: opts.Tokenizer, Segmenter: opts.segmenter()}
+	return perPage(doc, func(text string) ([]Chunk, error) {
+		return c.Chunk(text), nil
+	})
+}
+
+func chunkSemantic(doc *Document, opts Options) ([]Chunk, error) {
+	if opts.Embedder == nil {
+		return nil, errors.New("semantic chunking requires an embedder")
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c := &SemanticChunker{
+		Embedder:     opts.Embedder,
+		MaxChunkSize: opts.ChunkSize,
+		Tokenizer:    opts.Tokenizer,
+		Overlap:      opts.Overlap,
+		Segmenter:    opts.segmenter(),
+	}
+	return perPage(doc, func(text string) ([]Chunk, error) {
+		spans, err := c.spans(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		return spanChunks(text, spans, opts.tokenizer()), nil
+	})
+}
+
+func chunkMarkdown(doc *Document, opts Options) ([]Chunk, error) {
+	c := &MarkdownChunker{ChunkSize: opts.ChunkSize, Tokenizer: opts.Tokenizer, Overlap: opts.Overlap}
+	return c.Chunk(doc), nil
+}
+
+func chunkCode(doc *Document, opts Options) ([]Chunk, error) {
+	c := &CodeChunker{ChunkSize: opts.ChunkSize, Tokenizer: opts.Tokenizer}
+	return c.Chunk(doc), nil
+}
+
+func chunkTables(doc *Document, opts Options) ([]Chunk, error) {
+	c := &TableChunker{ChunkSize: opts.ChunkSize, Tokenizer: opts.Tokenizer}
+	return perPage(doc, func(text string) ([]Chunk, error) {
+		return c.chunks(text), nil
+	})
+}
+
+// chunkWindows uses ChunkSize as the window size and advances by
+// ChunkSize minus Overlap.
+func chunkWindows(doc *Document, opts Options) ([]Chunk, error) {
+	return chunkSpans(doc, opts, spansWindow(opts))
+}
+
+func spansWindow(opts Options) func(text string) []textSpan {
+	c := &WindowChunker{Size: opts.ChunkSize, Tokenizer: opts.Tokenizer}
+	if opts.ChunkSize > 0 && opts.Overlap > 0 && opts.Overlap < opts.ChunkSize {
+		c.Stride = opts.ChunkSize - opts.Overlap
+	}
+	return c.spans
+}
+
+// chunkHierarchical uses ChunkSize as the child size and five times that
+// as the parent size.
+func chunkHierarchical(doc *Document, opts Options) ([]Chunk, error) {
+	c := &HierarchicalChunker{ChildSize: opts.ChunkSize, ParentSize: 5 * opts.ChunkSize, Tokenizer: opts.Tokenizer}
+	if len(doc.Pages) == 0 {
+		return c.Chunk(doc.Content), nil
+	}
+	// IDs are prefixed with the page so they stay unique across pages.
+	var out []Chunk
+	for _, pg := range doc.Pages {
+		start, end := max(pg.Start, 0), min(pg.End, len(doc.Content))
+		if start >= end {
+			continue
+		}
+		prefix := "page" + strconv.Itoa(pg.Number) + "."
+		for _, ch := range c.Chunk(doc.Content[start:end]) {
+			ch.ID = prefix + ch.ID
+			if ch.ParentID != "" {
+				ch.ParentID = prefix + ch.ParentID
+			}
+			ch.PageStart, ch.PageEnd = pg.Number, pg.Number
+			shiftChunk(&ch, start)
+			ch.Index = len(out)
+			out = append(out, ch)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,167 @@
+package document
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestChunkerRegistry(t *testing.T) {
+	doc := &Document{Content: "one two three"}
+	if _, err := ChunkDocument("no-such-strategy", doc, Options{}); err == nil {
+		t.Error("unknown strategy accepted")
+	}
+	if _, err := ChunkDocument("fixed", nil, Options{}); err == nil {
+		t.Error("nil document accepted")
+	}
+	if _, err := ChunkDocument("semantic", doc, Options{}); err == nil {
+		t.Error("semantic strategy ran without an embedder")
+	}
+
+	RegisterChunker("test-whole", ChunkerFunc(func(doc *Document, opts Options) ([]Chunk, error) {
+		return []Chunk{{Text: doc.Content}}, nil
+	}))
+	chunks, err := ChunkDocument("test-whole", doc, Options{})
+	if err != nil || len(chunks) != 1 || chunks[0].Text != doc.Content {
+		t.Errorf("registered chunker returned %+v, %v", chunks, err)
+	}
+	if names := ChunkerNames(); !sort.StringsAreSorted(names) {
+		t.Errorf("ChunkerNames not sorted: %v", names)
+	}
+}
+
+func TestChunkOptions(t *testing.T) {
+	tok := CharacterTokenizer{}
+	o := NewOptions(WithChunkSize(5), WithOverlap(1), WithTokenizer(tok), WithContextual(), WithLanguageDetection())
+	if o.ChunkSize != 5 || o.Overlap != 1 || o.Tokenizer != tok || !o.Contextual || !o.DetectLanguage {
+		t.Errorf("NewOptions = %+v", o)
+	}
+	if p := NewParagraphChunker(WithChunkSize(7), WithOverlap(2)); p.ChunkSize != 7 || p.Overlap != 2 {
+		t.Errorf("NewParagraphChunker = %+v", p)
+	}
+	if h := NewHierarchicalChunker(WithChunkSize(10)); h.ChildSize != 10 || h.ParentSize != 50 {
+		t.Errorf("NewHierarchicalChunker = %+v", h)
+	}
+	if s := NewSemanticChunker(topicEmbedder{}, WithChunkSize(9)); s.MaxChunkSize != 9 || s.Embedder == nil {
+		t.Errorf("NewSemanticChunker = %+v", s)
+	}
+}
+
+func TestChunkPages(t *testing.T) {
+	doc := &Document{
+		Content: "One two.\n\nThree four.\n\nFive six.",
+		Pages:   []Page{{Number: 1, Start: 0, End: 10}, {Number: 2, Start: 10, End: 32}},
+	}
+	if doc.PageAt(0) != 1 || doc.PageAt(12) != 2 || doc.PageAt(40) != 0 {
+		t.Errorf("PageAt = %d, %d, %d", doc.PageAt(0), doc.PageAt(12), doc.PageAt(40))
+	}
+	for _, strategy := range []string{"fixed", "recursive", "paragraph", "markdown", "hierarchical"} {
+		chunks, err := ChunkDocument(strategy, doc, Options{ChunkSize: 50})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The large chunk size would join the pages if they were not
+		// split apart first.
+		if len(chunks) < 2 || chunks[0].Text != "One two." || chunks[0].PageStart != 1 || chunks[len(chunks)-1].PageEnd != 2 {
+			t.Errorf("%s: chunks %+v cross the page boundary", strategy, chunks)
+		}
+		for _, c := range chunks {
+			if c.PageStart != c.PageEnd {
+				t.Errorf("%s: chunk %q covers pages %d-%d", strategy, c.Text, c.PageStart, c.PageEnd)
+			}
+		}
+	}
+
+	merged := MergeSmallChunks([]Chunk{{Text: "a", PageStart: 1, PageEnd: 1}, {Text: "b c d", PageStart: 2, PageEnd: 2}}, 2, nil)
+	if len(merged) != 1 || merged[0].PageStart != 1 || merged[0].PageEnd != 2 {
+		t.Errorf("merged pages = %+v", merged)
+	}
+}
+
+func TestChunkersOnFixtures(t *testing.T) {
+	const size = 100
+	tests := []struct {
+		strategy string
+		file     string
+		parser   Parser
+		// fits is whether every chunk fits in size; strategies that keep
+		// sentences whole may go over.
+		fits bool
+		// spans is whether chunk texts are the content at their offsets;
+		// the table strategy repeats headers in split tables.
+		spans bool
+	}{
+		{"fixed", "test-txt.txt", NewTextParser(), false, true},
+		{"recursive", "test-markdown.md", NewMarkdownParser(), true, true},
+		{"recursive", "test-pdf.pdf", NewPDFParser(), true, true},
+		{"paragraph", "test-txt.txt", NewTextParser(), true, true},
+		{"sentence", "test-text.txt", NewTextParser(), false, true},
+		{"semantic", "test-md.md", NewMarkdownParser(), false, true},
+		{"markdown", "test-markdown.md", NewMarkdownParser(), true, true},
+		{"code", "test-go.go", NewCodeParser(), true, true},
+		{"code", "test-python.py", NewCodeParser(), true, true},
+		{"table", "test-csv.csv", NewCSVParser(), true, false},
+		{"window", "test-pptx.pptx", NewPPTXParser(), true, true},
+		{"hierarchical", "test-docx.docx", NewDocxParser(), true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy+"/"+tt.file, func(t *testing.T) {
+			doc, err := tt.parser.Parse(mustRead(t, fixture(tt.file)), tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			chunks, err := ChunkDocument(tt.strategy, doc, Options{ChunkSize: size, Embedder: topicEmbedder{}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(chunks) < 2 {
+				t.Fatalf("%d chunks, want the fixture split", len(chunks))
+			}
+			ids := map[string]bool{}
+			for i, c := range chunks {
+				if c.Index != i || c.Text == "" || ids[c.ID] {
+					t.Fatalf("chunk %d: index %d, %d bytes, ID %s repeated %v", i, c.Index, len(c.Text), c.ID, ids[c.ID])
+				}
+				ids[c.ID] = true
+				if c.StartOffset < 0 || c.EndOffset < c.StartOffset || c.EndOffset > len(doc.Content) {
+					t.Fatalf("chunk %d: offsets %d-%d outside the content", i, c.StartOffset, c.EndOffset)
+				}
+				if tt.spans && doc.Content[c.StartOffset:c.EndOffset] != c.Text {
+					t.Errorf("chunk %d: text is not the content at %d-%d", i, c.StartOffset, c.EndOffset)
+				}
+				limit := size
+				if tt.strategy == "hierarchical" && c.ParentID == "" {
+					limit = 5 * size
+				}
+				if n := DefaultTokenizer.Count(c.Text); tt.fits && n > limit {
+					t.Errorf("chunk %d: %d tokens, over %d", i, n, limit)
+				}
+			}
+		})
+	}
+}
+
+// WithLanguage gives the chunkers that split at sentences the sentence
+// rules of that language.
+func TestChunkLanguage(t *testing.T) {
+	doc := &Document{Content: "Sie läuft seit Jahren. Die Anlage ist alt, vgl. Kapitel Wartung und Pflege der Anlage."}
+	want := []string{"Sie läuft seit Jahren.", "Die Anlage ist alt, vgl. Kapitel Wartung und Pflege der Anlage."}
+	for _, strategy := range []string{"fixed", "recursive", "paragraph", "sentence"} {
+		t.Run(strategy, func(t *testing.T) {
+			chunks, err := ChunkDocument(strategy, doc, NewOptions(WithChunkSize(63), WithTokenizer(CharacterTokenizer{}), WithLanguage("de")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := chunkTexts(chunks); !reflect.DeepEqual(got, want) {
+				t.Errorf("German chunks %q, want %q", got, want)
+			}
+			chunks, err = ChunkDocument(strategy, doc, NewOptions(WithChunkSize(63), WithTokenizer(CharacterTokenizer{})))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := chunkTexts(chunks); reflect.DeepEqual(got, want) {
+				t.Errorf("English rules kept %q together", got[1])
+			}
+		})
+	}
+}
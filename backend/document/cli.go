@@ -0,0 +1,278 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CLIOptions are the flags of the document command line tool, which
+// cmd/document binds. The zero value is the tool's defaults.
+type CLIOptions struct {
+	// Format is "json", the default, or "jsonl".
+	Format string
+	// Name is the file name stdin is parsed as, which picks its parser.
+	// Defaults to "stdin".
+	Name string
+	// Strategy is the chunking strategy of chunk and ingest. Defaults to
+	// "recursive".
+	Strategy string
+	// Size and Overlap are in tokens; 0 is the strategy's default size.
+	Size, Overlap int
+	// Embedder is the embedding server, openai, tei or llama.cpp, that
+	// ingest embeds chunks with and the semantic strategy splits with.
+	// EmbedURL and EmbedModel configure it; the OpenAI key is read from
+	// OPENAI_API_KEY.
+	Embedder, EmbedURL, EmbedModel string
+}
+
+// RunCLI runs command, which is parse, chunk or ingest, on the files in
+// args and returns the tool's exit status: 0 on success, 1 when a file
+// failed and 2 for a usage error. Args may be globs, with ** matching any
+// number of directories; with none, or "-", input is read from stdin.
+//
+// Files that fail are reported on stderr and the rest are still
+// processed. Output is a JSON array, or with Format jsonl one JSON value
+// per line: a document, a chunk with its "source", or an ingest result.
+func RunCLI(ctx context.Context, command string, opts CLIOptions, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if command != "parse" && command != "chunk" && command != "ingest" {
+		fmt.Fprintf(stderr, "document: unknown command %q\n", command)
+		return 2
+	}
+	if opts.Format == "" {
+		opts.Format = "json"
+	}
+	if opts.Format != "json" && opts.Format != "jsonl" {
+		fmt.Fprintf(stderr, "document: unknown format %q\n", opts.Format)
+		return 2
+	}
+	if opts.Name == "" {
+		opts.Name = "stdin"
+	}
+	if opts.Strategy == "" {
+		opts.Strategy = "recursive"
+	}
+
+	p := NewPipeline()
+	if command != "parse" {
+		if _, err := LookupChunker(opts.Strategy); err != nil {
+			fmt.Fprintf(stderr, "document: %v\n", err)
+			return 2
+		}
+		p.Chunker(opts.Strategy, WithChunkSize(opts.Size), WithOverlap(opts.Overlap))
+		if opts.Embedder != "" {
+			e, err := cliEmbedder(opts.Embedder, opts.EmbedURL, opts.EmbedModel)
+			if err != nil {
+				fmt.Fprintf(stderr, "document: %v\n", err)
+				return 2
+			}
+			if command == "ingest" {
+				p.Embed(e)
+			}
+			if opts.Strategy == "semantic" {
+				p.opts.Embedder = e
+			}
+		}
+	}
+
+	inputs, err := expandInputs(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "document: %v\n", err)
+		return 2
+	}
+	out := &cliOutput{w: stdout, jsonl: opts.Format == "jsonl"}
+	status := 0
+	for _, input := range inputs {
+		src, err := cliRead(input, opts.Name, stdin)
+		if err == nil {
+			err = cliRun(ctx, command, p, src, out)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "document: %v\n", err)
+			status = 1
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if err := out.close(); err != nil {
+		fmt.Fprintf(stderr, "document: %v\n", err)
+		return 1
+	}
+	return status
+}
+
+// cliRun runs cmd on src and writes its output.
+func cliRun(ctx context.Context, cmd string, p *Pipeline, src *Fetched, out *cliOutput) error {
+	switch cmd {
+	case "parse":
+		doc, err := p.parse(ctx, src)
+		if err != nil {
+			return err
+		}
+		return out.write(newHTTPDocument(doc))
+	case "chunk":
+		doc, err := p.parse(ctx, src)
+		if err != nil {
+			return err
+		}
+		chunks, err := ChunkContext(ctx, p.strategy, doc, p.opts)
+		if err != nil {
+			return fmt.Errorf("chunk %s: %w", src.Filename, err)
+		}
+		source := storeSource(doc)
+		if !out.jsonl {
+			list := make([]JobChunk, len(chunks))
+			for i, c := range chunks {
+				list[i] = newJobChunk(doc, c)
+			}
+			return out.write(struct {
+				Source string     `json:"source"`
+				Chunks []JobChunk `json:"chunks"`
+			}{source, list})
+		}
+		for _, c := range chunks {
+			err := out.write(struct {
+				Source string `json:"source"`
+				JobChunk
+			}{source, newJobChunk(doc, c)})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		job := &Job{Content: src.Buffer, Filename: src.Filename}
+		result := p.runJob(ctx, job)
+		if err := out.write(result); err != nil {
+			return err
+		}
+		if result.Error != "" {
+			return errors.New(result.Error)
+		}
+		return nil
+	}
+}
+
+// cliOutput writes values as a JSON array or as JSON lines.
+type cliOutput struct {
+	w      io.Writer
+	jsonl  bool
+	values []any
+}
+
+func (o *cliOutput) write(v any) error {
+	if !o.jsonl {
+		o.values = append(o.values, v)
+		return nil
+	}
+	return json.NewEncoder(o.w).Encode(v)
+}
+
+// close writes the array of a json output.
+func (o *cliOutput) close() error {
+	if o.jsonl {
+		return nil
+	}
+	if o.values == nil {
+		o.values = []any{}
+	}
+	enc := json.NewEncoder(o.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(o.values)
+}
+
+// cliEmbedder returns the embedder of the embedder options. The OpenAI key
+// is read from OPENAI_API_KEY.
+func cliEmbedder(server, endpoint, model string) (Embedder, error) {
+	switch server {
+	case "openai":
+		key := os.Getenv("OPENAI_API_KEY")
+		if key == "" {
+			return nil, errors.New("OPENAI_API_KEY is not set")
+		}
+		e := NewOpenAIEmbedder(key)
+		if endpoint != "" {
+			e.Endpoint = endpoint
+		}
+		if model != "" {
+			e.Model = model
+		}
+		return e, nil
+	case TEIServer, LlamaCppServer:
+		if endpoint == "" {
+			return nil, fmt.Errorf("an embedding server url is required for %s", server)
+		}
+		e := NewLocalEmbedder(server, endpoint)
+		e.Model = model
+		return e, nil
+	}
+	return nil, fmt.Errorf("unknown embedder %q", server)
+}
+
+// expandInputs expands globs among args into the files they match, in
+// order. No args means stdin, given as "-".
+func expandInputs(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return []string{"-"}, nil
+	}
+	var inputs []string
+	for _, arg := range args {
+		if arg == "-" || !strings.ContainsAny(arg, "*?[") {
+			inputs = append(inputs, arg)
+			continue
+		}
+		var matches []string
+		if strings.Contains(arg, "**") {
+			// Walk from the directory before the first wildcard, matching
+			// the paths found against the cleaned pattern.
+			clean := filepath.Clean(arg)
+			root := filepath.Dir(clean[:strings.IndexAny(clean, "*?[")] + "x")
+			pattern := filepath.ToSlash(clean)
+			err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if !d.IsDir() && matchGlob(pattern, filepath.ToSlash(path)) {
+					matches = append(matches, path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			var err error
+			if matches, err = filepath.Glob(arg); err != nil {
+				return nil, fmt.Errorf("bad pattern %q: %w", arg, err)
+			}
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", arg)
+		}
+		inputs = append(inputs, matches...)
+	}
+	return inputs, nil
+}
+
+// cliRead reads input, or stdin as name when input is "-".
+func cliRead(input, name string, stdin io.Reader) (*Fetched, error) {
+	if input == "-" {
+		data, err := readAllLimited(stdin, DefaultLimits.MaxInputBytes)
+		if err != nil {
+			return nil, fmt.Errorf("read stdin: %w", err)
+		}
+		return &Fetched{Buffer: data, Filename: name}, nil
+	}
+	data, filename, err := (FileLoader{MaxSize: DefaultLimits.MaxInputBytes}).Load(context.Background(), input)
+	if err != nil {
+		return nil, err
+	}
+	return &Fetched{Buffer: data, Filename: filename}, nil
+}
@@ -0,0 +1,130 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCLIRun(t *testing.T) {
+	ctx := context.Background()
+	p := NewPipeline().Chunker("paragraph", WithChunkSize(4))
+	src := &Fetched{Buffer: []byte("First paragraph here.\n\nSecond paragraph here."), Filename: "a.txt"}
+
+	var buf bytes.Buffer
+	out := &cliOutput{w: &buf}
+	if err := cliRun(ctx, "parse", p, src, out); err != nil {
+		t.Fatal(err)
+	}
+	if err := cliRun(ctx, "chunk", p, src, out); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.close(); err != nil {
+		t.Fatal(err)
+	}
+	var values []map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &values); err != nil {
+		t.Fatalf("%v: %s", err, buf.Bytes())
+	}
+	if len(values) != 2 || values[0]["source"] != "a.txt" || values[0]["content"] == nil ||
+		values[1]["source"] != "a.txt" || len(values[1]["chunks"].([]any)) != 2 {
+		t.Errorf("json output %s", buf.Bytes())
+	}
+
+	// jsonl writes each chunk on its own line, with its source.
+	buf.Reset()
+	out = &cliOutput{w: &buf, jsonl: true}
+	if err := cliRun(ctx, "chunk", p, src, out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("jsonl output %q", lines)
+	}
+	for i, line := range lines {
+		var c map[string]any
+		if err := json.Unmarshal([]byte(line), &c); err != nil || c["source"] != "a.txt" || c["index"] != float64(i) || c["text"] == "" {
+			t.Errorf("line %d: %s", i, line)
+		}
+	}
+
+	buf.Reset()
+	out = &cliOutput{w: &buf, jsonl: true}
+	err := cliRun(ctx, "ingest", p, &Fetched{Buffer: []byte{0, 1, 2}, Filename: "a.bin"}, out)
+	if err == nil || !strings.Contains(buf.String(), `"error"`) {
+		t.Errorf("failed ingest: %v, output %s", err, buf.String())
+	}
+
+	buf.Reset()
+	if err := (&cliOutput{w: &buf}).close(); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("empty output %q, %v", buf.String(), err)
+	}
+}
+
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.md", "b.txt", "docs/c.md", "docs/deep/d.md"} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rel := func(paths []string) []string {
+		var out []string
+		for _, p := range paths {
+			r, _ := filepath.Rel(dir, p)
+			out = append(out, filepath.ToSlash(r))
+		}
+		return out
+	}
+
+	got, err := expandInputs([]string{filepath.Join(dir, "*.md"), filepath.Join(dir, "b.txt")})
+	if err != nil || !reflect.DeepEqual(rel(got), []string{"a.md", "b.txt"}) {
+		t.Errorf("glob %q, %v", got, err)
+	}
+	got, err = expandInputs([]string{filepath.Join(dir, "**", "*.md")})
+	if err != nil || !reflect.DeepEqual(rel(got), []string{"a.md", "docs/c.md", "docs/deep/d.md"}) {
+		t.Errorf("** glob %q, %v", got, err)
+	}
+	if got, err := expandInputs(nil); err != nil || !reflect.DeepEqual(got, []string{"-"}) {
+		t.Errorf("no args %q, %v", got, err)
+	}
+	if _, err := expandInputs([]string{filepath.Join(dir, "*.pdf")}); err == nil {
+		t.Error("glob matching nothing returned no error")
+	}
+
+	f, err := cliRead("-", "notes.md", strings.NewReader("# Notes"))
+	if err != nil || f.Filename != "notes.md" || string(f.Buffer) != "# Notes" {
+		t.Errorf("stdin read %+v, %v", f, err)
+	}
+	if f, err := cliRead(filepath.Join(dir, "b.txt"), "stdin", nil); err != nil || string(f.Buffer) != "b.txt" {
+		t.Errorf("file read %+v, %v", f, err)
+	}
+}
+
+func TestCLIEmbedder(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	if _, err := cliEmbedder("openai", "", ""); err == nil {
+		t.Error("openai without a key returned no error")
+	}
+	t.Setenv("OPENAI_API_KEY", "key")
+	e, err := cliEmbedder("openai", "http://localhost:1/v1", "small")
+	if o, ok := e.(*OpenAIEmbedder); err != nil || !ok || o.Endpoint != "http://localhost:1/v1" || o.Model != "small" {
+		t.Errorf("openai embedder %+v, %v", e, err)
+	}
+	if _, err := cliEmbedder(TEIServer, "", ""); err == nil {
+		t.Error("tei without a url returned no error")
+	}
+	if e, err := cliEmbedder(TEIServer, "http://localhost:1", ""); err != nil || e.(*LocalEmbedder).Server != TEIServer {
+		t.Errorf("tei embedder %+v, %v", e, err)
+	}
+	if _, err := cliEmbedder("ollama", "http://localhost:1", ""); err == nil {
+		t.Error("unknown embedder returned no error")
+	}
+}
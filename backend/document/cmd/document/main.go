@@ -0,0 +1,82 @@
+// Command document parses, chunks and ingests documents from the command
+// line, printing JSON for scripts.
+//
+//	document parse report.pdf
+//	document chunk --strategy markdown --size 256 --format jsonl 'docs/**/*.md'
+//	cat notes.txt | document ingest --embedder tei --embed-url http://localhost:8080
+//
+// It exits with status 1 when a file failed and 2 for a usage error.
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/ashish141199/agento.sh/backend/document"
+	"github.com/spf13/cobra"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the tool with args, which exclude the program name, and
+// returns its exit status.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var opts document.CLIOptions
+	status := 0
+	root := &cobra.Command{
+		Use:   "document",
+		Short: "Parse, chunk and ingest documents",
+		Long: `Parse, chunk and ingest documents, printing JSON.
+
+Files may be globs, with ** matching any number of directories. With no
+files, or "-", input is read from stdin.`,
+		SilenceUsage: true,
+	}
+	root.PersistentFlags().StringVar(&opts.Format, "format", "json", "output format: json or jsonl")
+	root.PersistentFlags().StringVar(&opts.Name, "name", "stdin", "file name stdin is parsed as, which picks its parser")
+
+	commands := []struct {
+		name, short string
+	}{
+		{"parse", "Parse each file and print its document"},
+		{"chunk", "Parse and chunk each file and print its chunks"},
+		{"ingest", "Run each file through the pipeline, embedding its chunks if asked"},
+	}
+	for _, c := range commands {
+		cmd := &cobra.Command{
+			Use:   c.name + " [file | glob | -]...",
+			Short: c.short,
+			RunE: func(cmd *cobra.Command, files []string) error {
+				status = document.RunCLI(cmd.Context(), c.name, opts, files, cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+				return nil
+			},
+		}
+		if c.name != "parse" {
+			flags := cmd.Flags()
+			flags.StringVar(&opts.Strategy, "strategy", "recursive", "chunking strategy: "+strings.Join(document.ChunkerNames(), ", "))
+			flags.IntVar(&opts.Size, "size", 0, "maximum chunk size in tokens; 0 for the strategy's default")
+			flags.IntVar(&opts.Overlap, "overlap", 0, "tokens repeated between chunks")
+			// chunk needs an embedder for the semantic strategy.
+			flags.StringVar(&opts.Embedder, "embedder", "", "embedding server: openai, tei or llama.cpp")
+			flags.StringVar(&opts.EmbedURL, "embed-url", "", "embedding server url; openai defaults to the OpenAI API")
+			flags.StringVar(&opts.EmbedModel, "embed-model", "", "embedding model")
+		}
+		root.AddCommand(cmd)
+	}
+
+	root.SetArgs(args)
+	root.SetIn(stdin)
+	root.SetOut(stdout)
+	root.SetErr(stderr)
+	if err := root.ExecuteContext(ctx); err != nil {
+		return 2
+	}
+	return status
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	// tei serves one embedding per input, alternating between two
+	// directions so the semantic strategy finds breakpoints.
+	tei := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Inputs []string }
+		json.NewDecoder(r.Body).Decode(&req)
+		out := make([][]float32, len(req.Inputs))
+		for i := range out {
+			out[i] = []float32{float32(i % 2), float32(1 - i%2)}
+		}
+		json.NewEncoder(w).Encode(out)
+	}))
+	defer tei.Close()
+	fixture := "../../../scripts/chunking/files/test-txt.txt"
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdin  string
+		status int
+		output string
+	}{
+		{"parse", []string{"parse", fixture}, "", 0, `"content"`},
+		{"chunk stdin", []string{"chunk", "--format", "jsonl", "--size", "20"}, "Some text to chunk.", 0, `"source":"stdin"`},
+		{"semantic chunk", []string{"chunk", "--strategy", "semantic", "--embedder", "tei", "--embed-url", tei.URL, fixture}, "", 0, `"chunks"`},
+		{"missing file", []string{"parse", "missing.txt"}, "", 1, ""},
+		{"unknown strategy", []string{"chunk", "--strategy", "nope", fixture}, "", 2, ""},
+		{"unknown flag", []string{"parse", "--strategy", "fixed", fixture}, "", 2, ""},
+		{"unknown command", []string{"split"}, "", 2, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			status := run(context.Background(), tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if status != tt.status {
+				t.Fatalf("status %d, want %d; stderr: %s", status, tt.status, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.output) {
+				t.Errorf("output %s, want it to contain %s", stdout.String(), tt.output)
+			}
+		})
+	}
+}
@@ -0,0 +1,439 @@
+package document
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CodeChunker splits source files at top-level declarations, so each chunk
+// holds whole functions, types or classes. Go is parsed with go/parser;
+// indentation-scoped languages (Python, Ruby) and brace languages are
+// split with line-based rules. A declaration larger than ChunkSize is
+// split at its nested declarations, such as the methods of a class, and
+// only then at blank lines.
+type CodeChunker struct {
+	// ChunkSize is the maximum chunk size in tokens. Small neighbouring
+	// declarations share a chunk up to this size. Defaults to 200.
+	ChunkSize int
+	// Tokenizer measures chunk size. Defaults to DefaultTokenizer.
+	Tokenizer Tokenizer
+}
+
+// NewCodeChunker creates a new code chunker instance configured by opts.
+func NewCodeChunker(opts ...ChunkOption) *CodeChunker {
+	o := NewOptions(opts...)
+	return &CodeChunker{ChunkSize: o.ChunkSize, Tokenizer: o.Tokenizer}
+}
+
+// codeUnit is a declaration and the comments before it.
+type codeUnit struct {
+	span   textSpan
+	symbol string
+	// nested splits an oversized unit, or is nil.
+	nested func() []codeUnit
+}
+
+// codeSeparators is used for units that have no nested declarations.
+var codeSeparators = []string{"\n\n", "\n", " ", ""}
+
+// Chunk returns the chunks of doc, a document produced by CodeParser or
+// any document whose Source names a source file. Each chunk's metadata
+// holds the "language", the 1-based "start_line" and "end_line", also set
+// as StartLine and EndLine, and the "symbols" it declares, joined with
+// ", ".
+func (c *CodeChunker) Chunk(doc *Document) []Chunk {
+	size := c.ChunkSize
+	if size <= 0 {
+		size = 200
+	}
+	tok := c.Tokenizer
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	text := doc.Content
+	lang := doc.Metadata["language"]
+	if lang == "" {
+		lang = DetectLanguage(doc.Source, []byte(text))
+	}
+	lines := doc.Lines
+	if len(lines) == 0 {
+		lines = lineOffsets(text)
+	}
+
+	var chunks []Chunk
+	emit := func(sp textSpan, symbols []string) {
+		if sp = trimSpan(text, sp); sp.end <= sp.start {
+			return
+		}
+		startLine, endLine := lineIndex(lines, sp.start), lineIndex(lines, sp.end-1)
+		meta := map[string]string{
+			"start_line": strconv.Itoa(startLine),
+			"end_line":   strconv.Itoa(endLine),
+		}
+		if lang != "" {
+			meta["language"] = lang
+		}
+		if len(symbols) > 0 {
+			meta["symbols"] = strings.Join(symbols, ", ")
+		}
+		chunks = append(chunks, Chunk{
+			Index:       len(chunks),
+			Text:        text[sp.start:sp.end],
+			StartOffset: sp.start,
+			EndOffset:   sp.end,
+			StartLine:   startLine,
+			EndLine:     endLine,
+			TokenCount:  tok.Count(text[sp.start:sp.end]),
+			Metadata:    meta,
+		})
+	}
+
+	var pack func(units []codeUnit)
+	pack = func(units []codeUnit) {
+		cur := textSpan{-1, -1}
+		var symbols []string
+		flush := func() {
+			if cur.start >= 0 {
+				emit(cur, symbols)
+			}
+			cur, symbols = textSpan{-1, -1}, nil
+		}
+		for _, u := range units {
+			if cur.start >= 0 && tok.Count(text[cur.start:u.span.end]) <= size {
+				cur.end = u.span.end
+				if u.symbol != "" {
+					symbols = append(symbols, u.symbol)
+				}
+				continue
+			}
+			flush()
+			if tok.Count(text[u.span.start:u.span.end]) <= size {
+				cur = u.span
+				if u.symbol != "" {
+					symbols = []string{u.symbol}
+				}
+				continue
+			}
+			var nested []codeUnit
+			if u.nested != nil {
+				nested = u.nested()
+			}
+			if len(nested) > 1 {
+				if nested[0].symbol == "" {
+					nested[0].symbol = u.symbol
+				}
+				pack(nested)
+				continue
+			}
+			splitter := &RecursiveSplitter{ChunkSize: size, Separators: codeSeparators, Tokenizer: tok}
+			var sym []string
+			if u.symbol != "" {
+				sym = []string{u.symbol}
+			}
+			for _, sp := range recursiveSplit(text, u.span.start, u.span.end, splitter.Separators, size, tok, defaultSegmenter) {
+				emit(sp, sym)
+			}
+		}
+		flush()
+	}
+
+	var units []codeUnit
+	switch lang {
+	case "go":
+		units = goUnits(text)
+	case "python", "ruby", "elixir", "nim":
+		units = indentUnits(text, 0, len(text), 0)
+	}
+	if units == nil {
+		units = braceUnits(text, 0, len(text), 0, 0)
+	}
+	pack(units)
+	return chunks
+}
+
+// lineOffsets returns the byte offset at which each line of text starts.
+func lineOffsets(text string) []int {
+	lines := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' && i+1 < len(text) {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+// lineIndex returns the 1-based line containing offset.
+func lineIndex(lines []int, offset int) int {
+	return (&Document{Lines: lines}).LineAt(offset)
+}
+
+// lineStart moves offset back to the start of its line.
+func lineStart(text string, offset int) int {
+	return strings.LastIndexByte(text[:offset], '\n') + 1
+}
+
+// contiguous turns declaration starts into units covering [start, end):
+// each unit runs to the start of the next, and text before the first
+// start becomes an unnamed unit.
+func contiguous(start, end int, starts []int, symbols []string) []codeUnit {
+	var units []codeUnit
+	if len(starts) == 0 || starts[0] > start {
+		first := end
+		if len(starts) > 0 {
+			first = starts[0]
+		}
+		units = append(units, codeUnit{span: textSpan{start, first}})
+	}
+	for i, s := range starts {
+		e := end
+		if i+1 < len(starts) {
+			e = starts[i+1]
+		}
+		units = append(units, codeUnit{span: textSpan{s, e}, symbol: symbols[i]})
+	}
+	return units
+}
+
+// goUnits splits Go source at top-level declarations, keeping doc
+// comments with their declaration and the package clause with the
+// imports. It returns nil when the source does not parse.
+func goUnits(text string) []codeUnit {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", text, parser.ParseComments)
+	if err != nil {
+		return nil
+	}
+	var starts []int
+	var symbols []string
+	for _, decl := range f.Decls {
+		var name string
+		pos := decl.Pos()
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				pos = d.Doc.Pos()
+			}
+			name = d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = goReceiverName(d.Recv.List[0].Type) + "." + name
+			}
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			if d.Doc != nil {
+				pos = d.Doc.Pos()
+			}
+			var names []string
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, s.Name.Name)
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						names = append(names, n.Name)
+					}
+				}
+			}
+			name = strings.Join(names, ", ")
+		}
+		starts = append(starts, lineStart(text, fset.Position(pos).Offset))
+		symbols = append(symbols, name)
+	}
+	return contiguous(0, len(text), starts, symbols)
+}
+
+// goReceiverName returns the type name of a method receiver.
+func goReceiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return goReceiverName(t.X)
+	case *ast.IndexExpr:
+		return goReceiverName(t.X)
+	case *ast.IndexListExpr:
+		return goReceiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+var (
+	indentDecl = regexp.MustCompile(`^(?:async\s+def|def|class|module|defmodule|defp?|proc|func|type)\s+([\w.:?!]+)`)
+	braceDecl  = regexp.MustCompile(`\b(?:function\*?|class|interface|struct|enum|union|fn|func|trait|impl|type|object|record|namespace|module)\s+([A-Za-z_$][\w$]*)`)
+	braceCall  = regexp.MustCompile(`(?:^|[^.\w$])([A-Za-z_$][\w$]*)\s*\(`)
+)
+
+// indentUnits splits text[start:end] at lines indented by exactly indent
+// that open a def, class or module, keeping decorators and comments
+// directly above with them.
+func indentUnits(text string, start, end, indent int) []codeUnit {
+	var starts []int
+	var symbols []string
+	pending := -1
+	for i := start; i < end; {
+		eol := strings.IndexByte(text[i:end], '\n')
+		next := end
+		if eol >= 0 {
+			next = i + eol + 1
+		}
+		line := strings.TrimRight(text[i:next], "\r\n")
+		body := strings.TrimLeft(line, " \t")
+		width := len(line) - len(body)
+		switch {
+		case body == "":
+			pending = -1
+		case width == indent && (strings.HasPrefix(body, "@") || strings.HasPrefix(body, "#")):
+			if pending < 0 {
+				pending = i
+			}
+		case width == indent:
+			if m := indentDecl.FindStringSubmatch(body); m != nil {
+				s := i
+				if pending >= 0 {
+					s = pending
+				}
+				starts = append(starts, s)
+				symbols = append(symbols, strings.TrimRight(m[1], ":"))
+			}
+			pending = -1
+		default:
+			pending = -1
+		}
+		i = next
+	}
+	units := contiguous(start, end, starts, symbols)
+	for i := range units {
+		u := &units[i]
+		if u.symbol == "" {
+			continue
+		}
+		if inner := nestedIndent(text, u.span, indent); inner > indent {
+			sp := u.span
+			u.nested = func() []codeUnit { return indentUnits(text, sp.start, sp.end, inner) }
+		}
+	}
+	return units
+}
+
+// nestedIndent returns the indentation of the first nested def in sp, or
+// -1 when there is none.
+func nestedIndent(text string, sp textSpan, indent int) int {
+	for _, line := range strings.Split(text[sp.start:sp.end], "\n")[1:] {
+		body := strings.TrimLeft(line, " \t")
+		if width := len(line) - len(body); width > indent && indentDecl.MatchString(body) {
+			return width
+		}
+	}
+	return -1
+}
+
+// braceUnits splits text[start:end], which begins at brace depth base, at
+// lines at brace depth depth that follow a blank line or a closed block.
+// Braces inside strings and comments are ignored.
+func braceUnits(text string, start, end, base, depth int) []codeUnit {
+	var starts []int
+	var symbols []string
+	d := base
+	inBlock := false
+	var quote byte
+	boundary := true
+	for i := start; i < end; {
+		eol := strings.IndexByte(text[i:end], '\n')
+		next := end
+		if eol >= 0 {
+			next = i + eol + 1
+		}
+		line := text[i:next]
+		blank := strings.TrimSpace(line) == ""
+		atDepth := d == depth && !inBlock && quote == 0
+		if atDepth && !blank && boundary {
+			starts = append(starts, i)
+			symbols = append(symbols, braceSymbol(text[i:end]))
+			boundary = false
+		}
+		closed := false
+		for j := 0; j < len(line); j++ {
+			ch := line[j]
+			switch {
+			case inBlock:
+				if ch == '*' && j+1 < len(line) && line[j+1] == '/' {
+					inBlock = false
+					j++
+				}
+			case quote != 0:
+				if ch == '\\' {
+					j++
+				} else if ch == quote {
+					quote = 0
+				}
+			case ch == '/' && j+1 < len(line) && line[j+1] == '/':
+				j = len(line)
+			case ch == '/' && j+1 < len(line) && line[j+1] == '*':
+				inBlock = true
+				j++
+			case ch == '"' || ch == '\'' || ch == '`':
+				quote = ch
+			case ch == '{':
+				d++
+			case ch == '}':
+				d--
+				if d == depth {
+					closed = true
+				}
+			}
+		}
+		if quote == '"' || quote == '\'' {
+			// Unterminated quotes (such as apostrophes in Rust
+			// lifetimes) end with the line.
+			quote = 0
+		}
+		if d == depth && !inBlock && quote == 0 && (blank || closed) {
+			boundary = true
+		}
+		i = next
+	}
+	units := contiguous(start, end, starts, symbols)
+	for i := range units {
+		sp := units[i].span
+		if strings.Contains(text[sp.start:sp.end], "{") {
+			units[i].nested = func() []codeUnit { return braceUnits(text, sp.start, sp.end, depth, depth+1) }
+		}
+	}
+	return units
+}
+
+// braceControl lists keywords that open blocks but declare nothing.
+var braceControl = map[string]bool{
+	"if": true, "else": true, "for": true, "foreach": true, "while": true, "do": true,
+	"switch": true, "case": true, "try": true, "catch": true, "finally": true, "return": true,
+	"match": true, "loop": true, "unsafe": true, "synchronized": true, "using": true, "lock": true,
+	"await": true, "new": true, "throw": true, "yield": true, "defer": true, "go": true,
+}
+
+// braceSymbol names the declaration at the start of s, skipping comments.
+// Statements inside function bodies have no name.
+func braceSymbol(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "/*") ||
+			strings.HasPrefix(line, "*") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "@") {
+			continue
+		}
+		if m := braceDecl.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+		first, _, _ := strings.Cut(line, " ")
+		if strings.HasSuffix(line, "{") && !braceControl[first] && !strings.ContainsAny(line, "=}") {
+			if m := braceCall.FindStringSubmatch(line); m != nil && !braceControl[m[1]] {
+				return m[1]
+			}
+		}
+		return ""
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package document
+
+import "testing"
+
+func TestCodeChunker(t *testing.T) {
+	type want struct{ text, symbols, lines string }
+	tests := []struct {
+		name   string
+		source string
+		size   int
+		src    string
+		want   []want
+	}{
+		{"go", "demo.go", 10,
+			"package demo\n\nimport \"fmt\"\n\n// Hello greets.\nfunc Hello() {\n\tfmt.Println(\"hi\")\n}\n\n" +
+				"type T struct{ A int }\n\nfunc (t *T) Get() int {\n\treturn t.A\n}\n",
+			[]want{
+				{"package demo\n\nimport \"fmt\"", "", "1-3"},
+				{"// Hello greets.\nfunc Hello() {\n\tfmt.Println(\"hi\")\n}", "Hello", "5-8"},
+				{"type T struct{ A int }", "T", "10-10"},
+				{"func (t *T) Get() int {\n\treturn t.A\n}", "T.Get", "12-14"},
+			}},
+		{"python class split at methods", "g.py", 10,
+			"import os\n\nclass Greeter:\n    def hello(self):\n        return 'hello world from the greeter'\n\n" +
+				"    def bye(self):\n        return 'goodbye world from the greeter'\n\ndef main():\n    print(Greeter().hello())\n",
+			[]want{
+				{"import os", "", "1-1"},
+				{"class Greeter:\n    def hello(self):\n        return 'hello world from the greeter'", "Greeter, hello", "3-5"},
+				{"def bye(self):\n        return 'goodbye world from the greeter'", "bye", "7-8"},
+				{"def main():\n    print(Greeter().hello())", "main", "10-11"},
+			}},
+		{"braces", "a.js", 6,
+			"function a() {\n  return 1;\n}\n\nclass B {\n  m() { return 2; }\n}\n",
+			[]want{
+				{"function a() {\n  return 1;\n}", "a", "1-3"},
+				{"class B {", "B", "5-5"},
+				{"m() { return 2; }\n}", "", "6-7"},
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := (&CodeChunker{ChunkSize: tt.size}).Chunk(&Document{Content: tt.src, Source: tt.source})
+			if len(chunks) != len(tt.want) {
+				t.Fatalf("got %d chunks, want %d", len(chunks), len(tt.want))
+			}
+			for i, c := range chunks {
+				w := tt.want[i]
+				lines := c.Metadata["start_line"] + "-" + c.Metadata["end_line"]
+				if c.Text != w.text || c.Metadata["symbols"] != w.symbols || lines != w.lines {
+					t.Errorf("chunk %d = %q, symbols %q, lines %s; want %q, %q, %s", i, c.Text, c.Metadata["symbols"], lines, w.text, w.symbols, w.lines)
+				}
+			}
+		})
+	}
+}
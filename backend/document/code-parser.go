@@ -0,0 +1,142 @@
+package document
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CodeParser keeps source files verbatim and records their language and
+// line offsets so chunkers can split on code boundaries and cite line
+// numbers.
+type CodeParser struct{}
+
+// NewCodeParser creates a new source code parser instance.
+func NewCodeParser() *CodeParser {
+	return &CodeParser{}
+}
+
+// codeExtensions maps file extensions to language names.
+var codeExtensions = map[string]string{
+	".go": "go", ".py": "python", ".pyw": "python", ".js": "javascript", ".mjs": "javascript",
+	".cjs": "javascript", ".jsx": "javascript", ".ts": "typescript", ".tsx": "typescript",
+	".java": "java", ".kt": "kotlin", ".kts": "kotlin", ".scala": "scala", ".rs": "rust",
+	".c": "c", ".h": "c", ".cc": "cpp", ".cpp": "cpp", ".cxx": "cpp", ".hpp": "cpp", ".hh": "cpp",
+	".cs": "csharp", ".rb": "ruby", ".php": "php", ".swift": "swift", ".m": "objective-c",
+	".sh": "shell", ".bash": "shell", ".zsh": "shell", ".ps1": "powershell", ".pl": "perl",
+	".lua": "lua", ".r": "r", ".jl": "julia", ".dart": "dart", ".ex": "elixir", ".exs": "elixir",
+	".erl": "erlang", ".hs": "haskell", ".clj": "clojure", ".sql": "sql", ".vue": "vue",
+	".svelte": "svelte", ".css": "css", ".scss": "scss", ".proto": "protobuf", ".tf": "hcl",
+	".yaml": "yaml", ".yml": "yaml", ".toml": "toml", ".zig": "zig", ".nim": "nim",
+}
+
+// codeFilenames maps well-known extensionless file names to languages.
+var codeFilenames = map[string]string{
+	"makefile": "make", "gnumakefile": "make", "dockerfile": "dockerfile",
+	"cmakelists.txt": "cmake", "rakefile": "ruby", "gemfile": "ruby", "jenkinsfile": "groovy",
+}
+
+// codeMimeTypes maps MIME types to languages.
+var codeMimeTypes = map[string]string{
+	"text/x-go": "go", "text/x-python": "python", "text/x-script.python": "python",
+	"application/javascript": "javascript", "text/javascript": "javascript",
+	"application/typescript": "typescript", "text/x-typescript": "typescript",
+	"text/x-java-source": "java", "text/x-java": "java", "text/x-kotlin": "kotlin",
+	"text/x-rust": "rust", "text/x-c": "c", "text/x-csrc": "c", "text/x-chdr": "c",
+	"text/x-c++": "cpp", "text/x-c++src": "cpp", "text/x-csharp": "csharp",
+	"text/x-ruby": "ruby", "application/x-httpd-php": "php", "text/x-php": "php",
+	"text/x-swift": "swift", "text/x-sh": "shell", "application/x-sh": "shell",
+	"text/x-perl": "perl", "text/x-lua": "lua", "text/x-sql": "sql", "application/sql": "sql",
+	"text/x-scala": "scala", "text/x-haskell": "haskell", "text/css": "css",
+	"text/typescript": "typescript", "text/x-cpp": "cpp", "text/yaml": "yaml",
+}
+
+// codeInterpreters maps shebang interpreters to languages.
+var codeInterpreters = map[string]string{
+	"sh": "shell", "bash": "shell", "zsh": "shell", "dash": "shell", "ksh": "shell",
+	"python": "python", "node": "javascript", "deno": "typescript", "ruby": "ruby",
+	"perl": "perl", "php": "php", "lua": "lua", "Rscript": "r", "pwsh": "powershell",
+}
+
+// Supports checks if the parser handles the given MIME type. Besides the
+// registered types it accepts "text/x-<language>" for every language
+// DetectLanguage knows, as produced by MimeTypeForFile.
+func (p *CodeParser) Supports(mimeType string) bool {
+	if _, ok := codeMimeTypes[mimeType]; ok {
+		return true
+	}
+	lang, ok := strings.CutPrefix(mimeType, "text/x-")
+	if !ok {
+		return false
+	}
+	for _, known := range codeExtensions {
+		if known == lang {
+			return true
+		}
+	}
+	for _, known := range codeFilenames {
+		if known == lang {
+			return true
+		}
+	}
+	return false
+}
+
+var codeShebang = regexp.MustCompile(`^#!\s*(\S+)(?:\s+(\S+))?`)
+
+// DetectLanguage names the programming language of a source file from its
+// file name or, failing that, its shebang line. It returns "" when unknown.
+func DetectLanguage(filename string, buffer []byte) string {
+	base := strings.ToLower(path.Base(strings.ReplaceAll(filename, `\`, "/")))
+	if lang := codeFilenames[base]; lang != "" {
+		return lang
+	}
+	if lang := codeExtensions[path.Ext(base)]; lang != "" {
+		return lang
+	}
+	line := string(buffer)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	if m := codeShebang.FindStringSubmatch(line); m != nil {
+		interp := path.Base(m[1])
+		if interp == "env" {
+			interp = m[2]
+		}
+		// python3.11 -> python
+		interp = strings.TrimRight(interp, "0123456789.")
+		return codeInterpreters[interp]
+	}
+	return ""
+}
+
+// Parse keeps the source unchanged. Metadata records the "path",
+// "language" and number of "lines"; Document.Lines holds the byte offset
+// of every line.
+func (p *CodeParser) Parse(buffer []byte, filename string) (*Document, error) {
+	content := decodeText(buffer)
+	if strings.TrimSpace(content) == "" {
+		return nil, ErrEmptyDocument
+	}
+	lines := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' && i+1 < len(content) {
+			lines = append(lines, i+1)
+		}
+	}
+	meta := map[string]string{
+		"path":  filename,
+		"lines": strconv.Itoa(len(lines)),
+	}
+	if lang := DetectLanguage(filename, buffer); lang != "" {
+		meta["language"] = lang
+	}
+	return &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Lines:     lines,
+		Metadata:  meta,
+	}, nil
+}
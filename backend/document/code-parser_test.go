@@ -0,0 +1,50 @@
+package document
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		filename, source, want string
+	}{
+		{"main.go", "", "go"},
+		{"src/App.TSX", "", "typescript"},
+		{`C:\build\Makefile`, "", "make"},
+		{"deploy", "#!/usr/bin/env python3.11\nprint(1)\n", "python"},
+		{"run", "#!/bin/bash -e\n", "shell"},
+		{"notes", "plain text", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.filename, []byte(tt.source)); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestCodeParser(t *testing.T) {
+	doc, err := NewCodeParser().Parse([]byte("package a\n\nfunc A() {}\n"), "a/a.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Lines) != 3 || doc.Lines[2] != 11 {
+		t.Errorf("line offsets %v, want [0 10 11]", doc.Lines)
+	}
+	if m := doc.Metadata; m["path"] != "a/a.go" || m["language"] != "go" || m["lines"] != "3" {
+		t.Errorf("metadata %v", m)
+	}
+
+	fixtures := map[string]string{
+		"test-c.c": "c", "test-cpp.cpp": "cpp", "test-go.go": "go", "test-java.java": "java",
+		"test-php.php": "php", "test-python.py": "python", "test-ruby.rb": "ruby",
+		"test-rust.rs": "rust", "test-ts.ts": "typescript", "test-yaml.yaml": "yaml",
+	}
+	for file, lang := range fixtures {
+		buffer := mustRead(t, fixture(file))
+		doc, err := NewCodeParser().Parse(buffer, file)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		if doc.Content != string(buffer) || doc.Metadata["language"] != lang {
+			t.Errorf("%s: language %q, want %q", file, doc.Metadata["language"], lang)
+		}
+	}
+}
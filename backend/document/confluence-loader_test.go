@@ -0,0 +1,96 @@
+package document
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfluenceLoader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, token, ok := r.BasicAuth(); !ok || user != "a@example.com" || token != "tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/wiki/rest/api/content":
+			if q.Get("start") == "" {
+				if q.Get("spaceKey") != "ENG" || q.Get("type") != "page" {
+					http.Error(w, "bad listing", http.StatusBadRequest)
+					return
+				}
+				w.Write([]byte(`{"results": [{"id": "1", "version": {"number": 2}}, {"id": "2", "version": {"number": 1}}],
+					"_links": {"next": "/wiki/rest/api/content?spaceKey=ENG&start=2"}}`))
+				return
+			}
+			w.Write([]byte(`{"results": [{"id": "3", "version": {"number": 1}}], "_links": {}}`))
+		case "/wiki/rest/api/content/1":
+			if q.Get("expand") != "body.storage,version,space,ancestors" {
+				http.Error(w, "missing expansions", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"id": "1", "title": "Deploy / Rollback", "version": {"number": 2},
+				"space": {"key": "ENG", "name": "Engineering"},
+				"ancestors": [{"title": "Runbooks"}, {"title": "Backend"}],
+				"body": {"storage": {"value": "<p>Run the <strong>deploy</strong> job.</p><ac:structured-macro ac:name=\"code\"><ac:plain-text-body><![CDATA[make deploy && echo <done>]]></ac:plain-text-body></ac:structured-macro>"}},
+				"_links": {"webui": "/spaces/ENG/pages/1"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	l := NewConfluenceLoader(srv.URL+"/", "a@example.com", "tok")
+	l.Synced = map[string]int{"1": 1, "2": 1}
+	ctx := context.Background()
+	ids, err := l.List(ctx, "ENG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1", "3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("changed pages %q, want %q", ids, want)
+	}
+
+	f, err := l.Fetch(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Filename != "Deploy - Rollback.html" || f.MIMEType != "text/html" || f.Version != "2" ||
+		f.URI != srv.URL+"/wiki/spaces/ENG/pages/1" {
+		t.Errorf("fetched %+v", f)
+	}
+	wantMeta := map[string]string{
+		"title":            "Deploy / Rollback",
+		"confluence_id":    "1",
+		"confluence_space": "ENG",
+		"confluence_path":  "Runbooks > Backend > Deploy / Rollback",
+	}
+	if !reflect.DeepEqual(f.Metadata, wantMeta) {
+		t.Errorf("metadata %v, want %v", f.Metadata, wantMeta)
+	}
+	if l.Synced["1"] != 2 {
+		t.Errorf("synced version %d, want 2", l.Synced["1"])
+	}
+
+	doc, err := NewHTMLParser().Parse(f.Buffer, f.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"Deploy / Rollback", "Run the deploy job.", "make deploy && echo <done>"} {
+		if !strings.Contains(doc.Content, s) {
+			t.Errorf("content %q lacks %q", doc.Content, s)
+		}
+	}
+
+	// After the sync only page 3, not yet loaded, is listed.
+	if ids, err := l.List(ctx, "ENG"); err != nil || !reflect.DeepEqual(ids, []string{"3"}) {
+		t.Errorf("second sync %q, %v", ids, err)
+	}
+	if _, err := NewConfluenceLoader(srv.URL, "a@example.com", "wrong").Fetch(ctx, "1"); err == nil {
+		t.Error("unauthorized request returned no error")
+	}
+}
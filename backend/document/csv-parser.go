@@ -0,0 +1,197 @@
+package document
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSVParser renders delimited tabular data as one "column: value" line per
+// row so it can be chunked and embedded like prose.
+type CSVParser struct {
+	// Delimiter overrides delimiter detection when non-zero.
+	Delimiter rune
+	// Columns restricts output to the named header columns (case-insensitive).
+	Columns []string
+	// MaxRows stops after this many data rows when positive.
+	MaxRows int
+}
+
+// NewCSVParser creates a new CSV parser instance.
+func NewCSVParser() *CSVParser {
+	return &CSVParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *CSVParser) Supports(mimeType string) bool {
+	switch mimeType {
+	case "text/csv", "text/tab-separated-values", "application/csv":
+		return true
+	}
+	return false
+}
+
+// Parse treats the first record as the header row. Empty cells are omitted
+// from the rendered row.
+func (p *CSVParser) Parse(buffer []byte, filename string) (*Document, error) {
+	delim := p.Delimiter
+	if delim == 0 {
+		delim = detectDelimiter(buffer)
+	}
+	return p.parseCSV(strings.NewReader(decodeText(buffer)), delim, filename)
+}
+
+// ParseReader works like Parse but reads UTF-8 input row by row, so only
+// the rendered rows are held in memory. Input in other charsets is read
+// fully and decoded first.
+func (p *CSVParser) ParseReader(src io.Reader, filename string) (*Document, error) {
+	br, isUTF8 := peekText(src)
+	if !isUTF8 {
+		buffer, err := io.ReadAll(br)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", filename, err)
+		}
+		return p.Parse(buffer, filename)
+	}
+	delim := p.Delimiter
+	if delim == 0 {
+		head, _ := br.Peek(streamPeekSize)
+		delim = detectDelimiter(head)
+	}
+	return p.parseCSV(br, delim, filename)
+}
+
+// parseCSV renders the records read from src.
+func (p *CSVParser) parseCSV(src io.Reader, delim rune, filename string) (*Document, error) {
+	r := csv.NewReader(src)
+	r.Comma = delim
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err == io.EOF {
+		return nil, ErrEmptyDocument
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read csv header: %w", err)
+	}
+	for i, h := range header {
+		header[i] = strings.TrimSpace(h)
+		if header[i] == "" {
+			header[i] = "Column " + strconv.Itoa(i+1)
+		}
+	}
+	selected := p.selectColumns(header)
+
+	var b strings.Builder
+	rows := 0
+	for p.MaxRows <= 0 || rows < p.MaxRows {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read csv row %d: %w", rows+1, err)
+		}
+		var fields []string
+		for _, i := range selected {
+			if i >= len(record) {
+				continue
+			}
+			if v := strings.Join(strings.Fields(record[i]), " "); v != "" {
+				fields = append(fields, header[i]+": "+v)
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(strings.Join(fields, ", "))
+		rows++
+	}
+
+	content := strings.ToValidUTF8(b.String(), "\ufffd")
+	if content == "" {
+		return nil, ErrEmptyDocument
+	}
+	columns := make([]string, len(selected))
+	for i, c := range selected {
+		columns[i] = header[c]
+	}
+	return &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Metadata: map[string]string{
+			"columns": strings.Join(columns, ", "),
+			"rows":    strconv.Itoa(rows),
+		},
+	}, nil
+}
+
+// selectColumns returns the indexes of the configured columns, or every
+// column when none are configured.
+func (p *CSVParser) selectColumns(header []string) []int {
+	var idx []int
+	if len(p.Columns) == 0 {
+		for i := range header {
+			idx = append(idx, i)
+		}
+		return idx
+	}
+	for i, h := range header {
+		for _, c := range p.Columns {
+			if strings.EqualFold(h, strings.TrimSpace(c)) {
+				idx = append(idx, i)
+				break
+			}
+		}
+	}
+	return idx
+}
+
+// detectDelimiter picks the candidate that splits the first lines into the
+// most consistent number of fields, ignoring delimiters inside quotes.
+func detectDelimiter(buffer []byte) rune {
+	sample := buffer
+	if len(sample) > 64*1024 {
+		sample = sample[:64*1024]
+	}
+	lines := strings.Split(strings.ReplaceAll(string(sample), "\r\n", "\n"), "\n")
+	if len(lines) > 20 {
+		lines = lines[:20]
+	}
+
+	best, bestScore := ',', 0
+	for _, d := range []rune{',', '\t', ';', '|'} {
+		counts := map[int]int{}
+		for _, line := range lines {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			n, quoted := 0, false
+			for _, c := range line {
+				switch {
+				case c == '"':
+					quoted = !quoted
+				case c == d && !quoted:
+					n++
+				}
+			}
+			if n > 0 {
+				counts[n]++
+			}
+		}
+		// Score by how many lines share the most common field count.
+		for n, lines := range counts {
+			if score := lines*100 + n; score > bestScore {
+				best, bestScore = d, score
+			}
+		}
+	}
+	return best
+}
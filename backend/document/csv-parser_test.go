@@ -0,0 +1,49 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCSVParser(t *testing.T) {
+	tests := []struct {
+		name    string
+		parser  *CSVParser
+		input   string
+		want    []string
+		without []string
+	}{
+		{"comma", NewCSVParser(), "name,role\nAda,Engineer\nGrace,\n",
+			[]string{"name: Ada, role: Engineer", "name: Grace"}, []string{"role: \n", "role:\n"}},
+		{"semicolon", NewCSVParser(), "name;role\nAda;Engineer\n", []string{"name: Ada, role: Engineer"}, nil},
+		{"tab", NewCSVParser(), "name\trole\nAda\tEngineer\n", []string{"name: Ada, role: Engineer"}, nil},
+		{"columns", &CSVParser{Columns: []string{"ROLE"}}, "name,role\nAda,Engineer\n", []string{"role: Engineer"}, []string{"Ada"}},
+		{"max rows", &CSVParser{MaxRows: 1}, "name\nAda\nGrace\n", []string{"name: Ada"}, []string{"Grace"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := tt.parser.Parse([]byte(tt.input), "a.csv")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(doc.Content, want) {
+					t.Errorf("content %q does not contain %q", doc.Content, want)
+				}
+			}
+			for _, gone := range tt.without {
+				if strings.Contains(doc.Content, gone) {
+					t.Errorf("content %q contains %q", doc.Content, gone)
+				}
+			}
+		})
+	}
+
+	doc, err := NewCSVParser().Parse(mustRead(t, fixture("test-csv.csv")), "test-csv.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(doc.Content, "Scope Item: Opening Hours changes") {
+		t.Errorf("fixture content starts %q", doc.Content[:min(60, len(doc.Content))])
+	}
+}
@@ -0,0 +1,109 @@
+package document
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DelimiterSplitter splits text exactly where a delimiter occurs, for
+// exports that already mark their record boundaries with lines such as
+// "---", form feeds or custom markers.
+type DelimiterSplitter struct {
+	// Delimiters are literal strings that end a piece.
+	Delimiters []string
+	// Pattern is a regular expression whose matches end a piece, used
+	// together with Delimiters.
+	Pattern *regexp.Regexp
+	// KeepDelimiter starts each piece with the delimiter before it instead
+	// of dropping it, for markers that label the record that follows.
+	KeepDelimiter bool
+	// ChunkSize, when positive, splits pieces over this many tokens
+	// further with RecursiveSplitter.
+	ChunkSize int
+	// Tokenizer measures ChunkSize. Defaults to DefaultTokenizer.
+	Tokenizer Tokenizer
+}
+
+// NewDelimiterSplitter creates a splitter for the given literal
+// delimiters.
+func NewDelimiterSplitter(delimiters ...string) *DelimiterSplitter {
+	return &DelimiterSplitter{Delimiters: delimiters}
+}
+
+// Split returns the pieces of text with surrounding whitespace trimmed,
+// skipping empty ones.
+func (s *DelimiterSplitter) Split(text string) []string {
+	spans := s.spans(text)
+	out := make([]string, len(spans))
+	for i, sp := range spans {
+		out[i] = text[sp.start:sp.end]
+	}
+	return out
+}
+
+func (s *DelimiterSplitter) spans(text string) []textSpan {
+	var pieces []textSpan
+	start := 0
+	for pos := 0; pos <= len(text); {
+		m := s.next(text, pos)
+		if m.start < 0 {
+			break
+		}
+		if m.end == m.start {
+			// Empty matches would never advance.
+			pos = m.end + 1
+			continue
+		}
+		pieces = append(pieces, textSpan{start, m.start})
+		start = m.end
+		if s.KeepDelimiter {
+			start = m.start
+		}
+		pos = m.end
+	}
+	pieces = append(pieces, textSpan{start, len(text)})
+
+	tok := s.Tokenizer
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	var out []textSpan
+	for _, p := range pieces {
+		if p = trimSpan(text, p); p.end <= p.start {
+			continue
+		}
+		if s.ChunkSize <= 0 {
+			out = append(out, p)
+			continue
+		}
+		for _, sp := range recursiveSplit(text, p.start, p.end, DefaultSeparators, s.ChunkSize, tok, defaultSegmenter) {
+			if sp = trimSpan(text, sp); sp.end > sp.start {
+				out = append(out, sp)
+			}
+		}
+	}
+	return out
+}
+
+// next returns the first delimiter at or after pos, preferring the longest
+// at the same offset, or a span with start -1 when there is none.
+func (s *DelimiterSplitter) next(text string, pos int) textSpan {
+	best := textSpan{-1, -1}
+	better := func(start, end int) bool {
+		return best.start < 0 || start < best.start || start == best.start && end > best.end
+	}
+	for _, d := range s.Delimiters {
+		if d == "" {
+			continue
+		}
+		if i := strings.Index(text[pos:], d); i >= 0 && better(pos+i, pos+i+len(d)) {
+			best = textSpan{pos + i, pos + i + len(d)}
+		}
+	}
+	if s.Pattern != nil && pos <= len(text) {
+		if m := s.Pattern.FindStringIndex(text[pos:]); m != nil && better(pos+m[0], pos+m[1]) {
+			best = textSpan{pos + m[0], pos + m[1]}
+		}
+	}
+	return best
+}
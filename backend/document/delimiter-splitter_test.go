@@ -0,0 +1,31 @@
+package document
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestDelimiterSplitter(t *testing.T) {
+	tests := []struct {
+		name string
+		s    *DelimiterSplitter
+		text string
+		want []string
+	}{
+		{"literals", NewDelimiterSplitter("---", "\f"), "one\n---\ntwo\fthree\n---\n---\n", []string{"one", "two", "three"}},
+		{"longest at an offset", NewDelimiterSplitter("--", "---"), "a---b--c", []string{"a", "b", "c"}},
+		{"pattern kept", &DelimiterSplitter{Pattern: regexp.MustCompile(`(?m)^Record \d+:`), KeepDelimiter: true},
+			"Header\nRecord 1: a\nRecord 2: b", []string{"Header", "Record 1: a", "Record 2: b"}},
+		{"empty matches", &DelimiterSplitter{Pattern: regexp.MustCompile(`x*`)}, "abc", []string{"abc"}},
+		{"oversized pieces", &DelimiterSplitter{Delimiters: []string{"|"}, ChunkSize: 2, Tokenizer: WhitespaceTokenizer{}},
+			"a b c|d", []string{"a b", "c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.Split(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package document
+
+import (
+	"strings"
+	"time"
+)
+
+// DocumentInfo is the descriptive metadata a format records about a
+// document, such as the PDF info dictionary, DOCX core properties or HTML
+// meta tags. Parsers that fill it also copy the common fields into
+// Document.Metadata under "title", "author", "subject", "keywords",
+// "language", "created" and "modified".
+type DocumentInfo struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords []string
+	Language string
+	Created  time.Time
+	Modified time.Time
+	// Properties holds the format's other properties under their names in
+	// the format, such as "Producer" for PDF, "lastModifiedBy" for DOCX or
+	// "og:site_name" for HTML.
+	Properties map[string]string
+}
+
+// IsZero reports whether no field of i is set.
+func (i DocumentInfo) IsZero() bool {
+	return i.Title == "" && i.Author == "" && i.Subject == "" && len(i.Keywords) == 0 &&
+		i.Language == "" && i.Created.IsZero() && i.Modified.IsZero() && len(i.Properties) == 0
+}
+
+// metadata returns the common fields of i as Document.Metadata entries,
+// with dates in RFC 3339 form, or nil when none is set.
+func (i DocumentInfo) metadata() map[string]string {
+	meta := map[string]string{}
+	set := func(key, v string) {
+		if v = strings.TrimSpace(v); v != "" {
+			meta[key] = v
+		}
+	}
+	set("title", i.Title)
+	set("author", i.Author)
+	set("subject", i.Subject)
+	set("keywords", strings.Join(i.Keywords, ", "))
+	set("language", i.Language)
+	if !i.Created.IsZero() {
+		meta["created"] = i.Created.Format(time.RFC3339)
+	}
+	if !i.Modified.IsZero() {
+		meta["modified"] = i.Modified.Format(time.RFC3339)
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
+
+// setProperty records a format-specific property, ignoring empty values.
+func (i *DocumentInfo) setProperty(name, v string) {
+	if v = strings.TrimSpace(v); v == "" {
+		return
+	}
+	if i.Properties == nil {
+		i.Properties = map[string]string{}
+	}
+	i.Properties[name] = v
+}
+
+// withInfo sets d.Info and merges its common fields into d.Metadata without
+// overwriting entries the parser already set.
+func (d *Document) withInfo(info DocumentInfo) *Document {
+	d.Info = info
+	for k, v := range info.metadata() {
+		if d.Metadata == nil {
+			d.Metadata = map[string]string{}
+		}
+		if _, ok := d.Metadata[k]; !ok {
+			d.Metadata[k] = v
+		}
+	}
+	return d
+}
+
+// splitKeywords splits a keyword list separated by commas or semicolons.
+func splitKeywords(s string) []string {
+	var out []string
+	for _, k := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		if k = strings.TrimSpace(k); k != "" {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// infoDateLayouts are the date forms found in document properties, from
+// W3C date-times to bare dates.
+var infoDateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// parseInfoDate parses a property date, returning the zero time when s
+// matches no known layout.
+func parseInfoDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range infoDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parsePDFDate parses a PDF date string such as "D:20240131120000+01'00'".
+// Missing trailing fields default as the PDF specification describes.
+func parsePDFDate(s string) time.Time {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "D:")
+	digits := 0
+	for digits < len(s) && digits < 14 && s[digits] >= '0' && s[digits] <= '9' {
+		digits++
+	}
+	if digits < 4 {
+		return time.Time{}
+	}
+	// Pad the missing month, day and time of day.
+	stamp := s[:digits] + "0101000000"[digits-4:]
+	t, err := time.Parse("20060102150405", stamp)
+	if err != nil {
+		return time.Time{}
+	}
+	zone := strings.ReplaceAll(s[digits:], "'", "")
+	if len(zone) >= 5 && (zone[0] == '+' || zone[0] == '-') {
+		if z, err := time.Parse("-0700", zone[:5]); err == nil {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, z.Location())
+		}
+	} else if len(zone) >= 3 && (zone[0] == '+' || zone[0] == '-') {
+		if z, err := time.Parse("-07", zone[:3]); err == nil {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, z.Location())
+		}
+	}
+	return t
+}
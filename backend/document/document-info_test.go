@@ -0,0 +1,90 @@
+package document
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePDFDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"D:20240131120000+01'00'", "2024-01-31T12:00:00+01:00"},
+		{"D:199812231952-08'00'", "1998-12-23T19:52:00-08:00"},
+		{"D:2024", "2024-01-01T00:00:00Z"},
+		{"20240131", "2024-01-31T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		if got := parsePDFDate(tt.in).Format(time.RFC3339); got != tt.want {
+			t.Errorf("parsePDFDate(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+	if !parsePDFDate("junk").IsZero() || !parseInfoDate("someday").IsZero() {
+		t.Error("invalid dates parsed")
+	}
+	if got := parseInfoDate("2024-05-06T07:08:09"); got != time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC) {
+		t.Errorf("parseInfoDate = %v", got)
+	}
+}
+
+func TestDocumentInfo(t *testing.T) {
+	pdf := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		pdfStreamObject("BT /F1 12 Tf 72 720 Td (Body) Tj ET"),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Title <FEFF00520065007000f6> /Author (Ada) /Keywords (go; pdf, parsing) /CreationDate (D:20240131120000+01'00') /Producer (Writer) >>",
+	)
+	// The trailer follows the cross-reference table, so offsets stay valid.
+	pdf = []byte(strings.Replace(string(pdf), "/Root 1 0 R", "/Root 1 0 R /Info 6 0 R", 1))
+
+	docx := zipFiles(t,
+		"word/document.xml", `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body><w:p><w:r><w:t>Body</w:t></w:r></w:p></w:body></w:document>`,
+		"docProps/core.xml", `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/">`+
+			`<dc:title>Plan</dc:title><dc:creator>Ada</dc:creator><cp:keywords>go, docx</cp:keywords><cp:lastModifiedBy>Bob</cp:lastModifiedBy>`+
+			`<dcterms:created>2024-01-31T12:00:00Z</dcterms:created></cp:coreProperties>`)
+
+	html := `<html lang="de"><head><title>Seite</title><meta name="author" content="Bob"><meta name="keywords" content="a, b">` +
+		`<meta property="article:published_time" content="2024-02-03T04:05:06Z"><meta name="description" content="Kurz.">` +
+		`<meta property="og:site_name" content="Site"></head><body><p>Hallo.</p></body></html>`
+
+	tests := []struct {
+		name     string
+		parser   Parser
+		input    []byte
+		meta     map[string]string
+		property [2]string
+	}{
+		{"pdf", NewPDFParser(), pdf, map[string]string{
+			"title": "Repö", "author": "Ada", "keywords": "go, pdf, parsing", "created": "2024-01-31T12:00:00+01:00",
+		}, [2]string{"Producer", "Writer"}},
+		{"docx", NewDocxParser(), docx, map[string]string{
+			"title": "Plan", "author": "Ada", "keywords": "go, docx", "created": "2024-01-31T12:00:00Z",
+		}, [2]string{"lastModifiedBy", "Bob"}},
+		{"html", NewHTMLParser(), []byte(html), map[string]string{
+			"title": "Seite", "author": "Bob", "keywords": "a, b", "language": "de", "created": "2024-02-03T04:05:06Z", "description": "Kurz.",
+		}, [2]string{"og:site_name", "Site"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := tt.parser.Parse(tt.input, "a."+tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.meta {
+				if doc.Metadata[k] != v {
+					t.Errorf("metadata %s = %q, want %q", k, doc.Metadata[k], v)
+				}
+			}
+			if got := doc.Info.Properties[tt.property[0]]; got != tt.property[1] {
+				t.Errorf("property %s = %q, want %q", tt.property[0], got, tt.property[1])
+			}
+			if doc.Info.IsZero() {
+				t.Error("Info is zero")
+			}
+		})
+	}
+}
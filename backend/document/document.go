@@ -0,0 +1,350 @@
+// Package document provides document processing utilities.
+package document
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Document represents a parsed document.
+type Document struct {
+	Content   string
+	Source    string
+	WordCount int
+	Pages     []Page
+	Headings  []Heading
+	// Sections is the tree of Headings with the range each one governs,
+	// set by parsers of structured formats. See BuildSections.
+	Sections []Section
+	Metadata map[string]string
+	// Info holds the title, author, dates and other properties recorded by
+	// the document format, for parsers that read them.
+	Info DocumentInfo
+	// Provenance records the source and parser of the document, as set by
+	// ParseWithProvenance.
+	Provenance Provenance
+	// Checksum is the hex SHA-256 of the parsed bytes, as set by
+	// ParseWithProvenance, for detecting changed sources.
+	Checksum string
+	// Version optionally labels the revision of the source, such as a
+	// commit hash or HTTP ETag, for incremental re-indexing.
+	Version string
+	// Attachments holds embedded files, such as email attachments, for the
+	// caller to parse recursively.
+	Attachments []Attachment
+	// Lines holds the byte offset at which each line starts, for
+	// line-oriented sources such as code.
+	Lines []int
+	// Segments marks spans of Content that carry their own metadata, such
+	// as subtitle passages and their timestamps.
+	Segments []Segment
+}
+
+// Segment is a byte range within Document.Content with span-specific
+// metadata that chunks overlapping it should inherit.
+type Segment struct {
+	Start    int
+	End      int
+	Metadata map[string]string
+}
+
+// Attachment is a file embedded in a parsed document.
+type Attachment struct {
+	Filename string
+	MimeType string
+	Data     []byte
+}
+
+// Page is the byte range of a single page within Document.Content.
+type Page struct {
+	Number int
+	Start  int
+	End    int
+}
+
+// Heading is a section heading and its byte offset within Document.Content.
+type Heading struct {
+	Level  int
+	Text   string
+	Offset int
+}
+
+// Section is a heading and the byte range of Content it governs, from the
+// heading to the next heading of the same or a higher level, with its
+// subsections.
+type Section struct {
+	Heading  string
+	Level    int
+	Start    int
+	End      int
+	Children []Section
+}
+
+// BuildSections returns the section tree described by headings, which are
+// in document order, for a content of the given length. Text before the
+// first heading belongs to no section.
+func BuildSections(headings []Heading, length int) []Section {
+	var out []Section
+	for i := 0; i < len(headings); {
+		h := headings[i]
+		j := i + 1
+		for j < len(headings) && headings[j].Level > h.Level {
+			j++
+		}
+		end := length
+		if j < len(headings) {
+			end = headings[j].Offset
+		}
+		out = append(out, Section{
+			Heading:  h.Text,
+			Level:    h.Level,
+			Start:    h.Offset,
+			End:      end,
+			Children: BuildSections(headings[i+1:j], end),
+		})
+		i = j
+	}
+	return out
+}
+
+// HeadingPath returns the breadcrumb of headings enclosing the given offset,
+// outermost first.
+func (d *Document) HeadingPath(offset int) []string {
+	var stack []Heading
+	for _, h := range d.Headings {
+		if h.Offset > offset {
+			break
+		}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= h.Level {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, h)
+	}
+	path := make([]string, len(stack))
+	for i, h := range stack {
+		path[i] = h.Text
+	}
+	return path
+}
+
+// LineAt returns the 1-based line number containing the given offset, or 0
+// when the document has no line offsets.
+func (d *Document) LineAt(offset int) int {
+	return sort.Search(len(d.Lines), func(i int) bool { return d.Lines[i] > offset })
+}
+
+// PageAt returns the number of the page containing the given offset, or 0
+// when the document has no pages or the offset lies between them.
+func (d *Document) PageAt(offset int) int {
+	i := sort.Search(len(d.Pages), func(i int) bool { return d.Pages[i].End > offset })
+	if i < len(d.Pages) && d.Pages[i].Start <= offset {
+		return d.Pages[i].Number
+	}
+	return 0
+}
+
+// Parser defines the interface for document parsers.
+type Parser interface {
+	Supports(mimeType string) bool
+	Parse(buffer []byte, filename string) (*Document, error)
+}
+
+// MultiParser is implemented by parsers whose input can hold several
+// independent documents, such as JSON Lines streams.
+type MultiParser interface {
+	Parser
+	ParseAll(buffer []byte, filename string) ([]*Document, error)
+}
+
+// TextParser handles plain text documents.
+type TextParser struct {
+	// MaxSize rejects inputs larger than this many bytes when positive.
+	MaxSize int
+	// Encoding names the charset of the input, such as "windows-1252",
+	// instead of detecting it with DetectCharset.
+	Encoding string
+	// KeepFrontmatter leaves a YAML or TOML frontmatter block in the content
+	// as well as copying its fields into the metadata.
+	KeepFrontmatter bool
+	// Normalizer, when set, normalizes the decoded text before it is
+	// parsed.
+	Normalizer *Normalizer
+}
+
+// TextParserOption configures a TextParser in NewTextParser.
+type TextParserOption func(*TextParser)
+
+// WithMaxSize rejects inputs larger than n bytes.
+func WithMaxSize(n int) TextParserOption {
+	return func(p *TextParser) { p.MaxSize = n }
+}
+
+// WithEncoding reads input in the named charset instead of detecting it.
+func WithEncoding(charset string) TextParserOption {
+	return func(p *TextParser) { p.Encoding = charset }
+}
+
+// WithNormalization normalizes the text with n.
+func WithNormalization(n *Normalizer) TextParserOption {
+	return func(p *TextParser) { p.Normalizer = n }
+}
+
+// WithFrontmatter keeps a frontmatter block in the content.
+func WithFrontmatter() TextParserOption {
+	return func(p *TextParser) { p.KeepFrontmatter = true }
+}
+
+// NewTextParser creates a new text parser instance configured by opts.
+func NewTextParser(opts ...TextParserOption) *TextParser {
+	p := &TextParser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *TextParser) Supports(mimeType string) bool {
+	return mimeType == "text/plain"
+}
+
+// Parse converts buffer to document content, transcoding legacy charsets
+// to UTF-8 and recording a non-UTF-8 source's "charset" in the metadata.
+// Text in a charset without a decoder keeps its original bytes and is
+// marked with "charset_unsupported" set to "true".
+func (p *TextParser) Parse(buffer []byte, filename string) (*Document, error) {
+	if p.MaxSize > 0 && len(buffer) > p.MaxSize {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, p.MaxSize)
+	}
+	if p.Encoding != "" {
+		return p.parseText(decodeTextAs(buffer, p.Encoding), canonicalCharset(p.Encoding), filename)
+	}
+	return p.parseText(decodeText(buffer), DetectCharset(buffer), filename)
+}
+
+// ParseReader works like Parse but reads the text from r as it comes.
+func (p *TextParser) ParseReader(r io.Reader, filename string) (*Document, error) {
+	if p.MaxSize > 0 {
+		r = &maxSizeReader{r: r, max: p.MaxSize}
+	}
+	if p.Encoding != "" {
+		buffer, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", filename, err)
+		}
+		return p.Parse(buffer, filename)
+	}
+	content, charset, err := readText(r)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", filename, err)
+	}
+	return p.parseText(content, charset, filename)
+}
+
+// parseText builds the document from content decoded from charset.
+func (p *TextParser) parseText(content, charset, filename string) (*Document, error) {
+	if p.Normalizer != nil {
+		content = p.Normalizer.String(content)
+	}
+	_, body, front, hasFront := splitFrontmatter(content)
+	if hasFront && !p.KeepFrontmatter {
+		content = strings.TrimLeft(body, "\r\n")
+	}
+	if content == "" {
+		return nil, ErrEmptyDocument
+	}
+
+	words := strings.Fields(content)
+	doc := &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(words),
+	}
+	if len(front) > 0 {
+		doc.Metadata = front
+	}
+	if charset != "utf-8" {
+		if doc.Metadata == nil {
+			doc.Metadata = map[string]string{}
+		}
+		doc.Metadata["charset"] = charset
+		if !hasCharset(charset) {
+			doc.Metadata["charset_unsupported"] = "true"
+		}
+	}
+	return doc, nil
+}
+
+// ChunkText splits text into chunks of approximately chunkSize characters,
+// ending chunks between sentences. Chunk offsets index into text.
+func ChunkText(text string, chunkSize int) []Chunk {
+	return ChunkTextTokens(text, chunkSize, CharacterTokenizer{})
+}
+
+// ChunkTextStrings works like ChunkText but returns only the chunk texts.
+func ChunkTextStrings(text string, chunkSize int) []string {
+	return chunkTexts(ChunkText(text, chunkSize))
+}
+
+// ChunkTextOverlap works like ChunkTextTokens, then extends each chunk
+// back over the last overlap tokens of the one before it.
+func ChunkTextOverlap(text string, chunkSize, overlap int, tok Tokenizer) []Chunk {
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	return spanChunks(text, overlapSpans(text, sentenceGroups(text, chunkSize, tok, defaultSegmenter), overlap, tok), tok)
+}
+
+// ChunkTextTokens splits text into chunks of approximately chunkSize tokens
+// as counted by tok, or by DefaultTokenizer when tok is nil.
+func ChunkTextTokens(text string, chunkSize int, tok Tokenizer) []Chunk {
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	return spanChunks(text, sentenceGroups(text, chunkSize, tok, defaultSegmenter), tok)
+}
+
+// sentenceGroups returns the spans of consecutive sentences of text that
+// fit in chunkSize tokens. A longer sentence forms a group on its own.
+// Sentences are taken as seg finds them, and groups are index
+// ranges of text, so nothing is copied.
+func sentenceGroups(text string, chunkSize int, tok Tokenizer, seg *SentenceSegmenter) []textSpan {
+	var out []textSpan
+	cur := textSpan{-1, -1}
+	n := 0
+	seg.eachSpan(text, func(sp textSpan) {
+		if cur.start >= 0 {
+			if grown := groupCount(tok, text, cur, sp.end, n); grown <= chunkSize {
+				cur.end, n = sp.end, grown
+				return
+			}
+			out = append(out, cur)
+		}
+		cur, n = sp, tok.Count(text[sp.start:sp.end])
+	})
+	if cur.start >= 0 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+// groupCount returns the tokens of text[group.start:end], given the n
+// tokens of the group. Tokenizers whose counts add up across whitespace
+// only count the new text, so growing a group is not quadratic.
+func groupCount(tok Tokenizer, text string, group textSpan, end, n int) int {
+	switch tok.(type) {
+	case CharacterTokenizer, ByteTokenizer:
+		return n + tok.Count(text[group.end:end])
+	case WhitespaceTokenizer:
+		// A word is only split between group and the new text at
+		// whitespace.
+		if r, _ := utf8.DecodeRuneInString(text[group.end:]); unicode.IsSpace(r) {
+			return n + tok.Count(text[group.end:end])
+		}
+	}
+	return tok.Count(text[group.start:end])
+}
@@ -0,0 +1,159 @@
+package document
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestChunkText(t *testing.T) {
+	text := "First one here. Second sentence is longer than that. Third."
+	chunks := ChunkText(text, 20)
+	want := []struct {
+		text       string
+		start, end int
+	}{
+		{"First one here.", 0, 15},
+		{"Second sentence is longer than that.", 16, 52},
+		{"Third.", 53, 59},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks, want %d", len(chunks), len(want))
+	}
+	for i, c := range chunks {
+		w := want[i]
+		if c.Index != i || c.Text != w.text || c.StartOffset != w.start || c.EndOffset != w.end || c.TokenCount != len(w.text) {
+			t.Errorf("chunk %d = %+v, want %q at %d-%d", i, c, w.text, w.start, w.end)
+		}
+	}
+	if got := ChunkTextStrings(text, 20); !reflect.DeepEqual(got, []string{want[0].text, want[1].text, want[2].text}) {
+		t.Errorf("ChunkTextStrings = %q", got)
+	}
+
+	// Overlap extends each chunk back into the one before it.
+	overlapped := ChunkTextOverlap(text, 4, 1, WhitespaceTokenizer{})
+	if len(overlapped) != 3 || overlapped[1].Text != "here. Second sentence is longer than that." || overlapped[1].StartOffset != 10 {
+		t.Errorf("ChunkTextOverlap = %+v", overlapped)
+	}
+}
+
+func TestBuildSections(t *testing.T) {
+	doc, err := NewMarkdownParser().Parse([]byte("# A\n\ntext\n\n## B\n\nmore\n\n# C\n\nend\n"), "a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Section{
+		{Heading: "A", Level: 1, Start: 0, End: 23, Children: []Section{{Heading: "B", Level: 2, Start: 11, End: 23}}},
+		{Heading: "C", Level: 1, Start: 23, End: 31},
+	}
+	if !reflect.DeepEqual(doc.Sections, want) {
+		t.Errorf("Sections = %+v, want %+v", doc.Sections, want)
+	}
+
+	// A deeper heading first and a skipped level still nest by level.
+	got := BuildSections([]Heading{{Level: 3, Text: "x", Offset: 5}, {Level: 1, Text: "y", Offset: 10}, {Level: 3, Text: "z", Offset: 15}}, 20)
+	want = []Section{
+		{Heading: "x", Level: 3, Start: 5, End: 10},
+		{Heading: "y", Level: 1, Start: 10, End: 20, Children: []Section{{Heading: "z", Level: 3, Start: 15, End: 20}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildSections = %+v, want %+v", got, want)
+	}
+}
+
+func TestTextParserOptions(t *testing.T) {
+	input := []byte("---\ntitle: Notes\n---\nBody \xb1")
+	tests := []struct {
+		name    string
+		parser  *TextParser
+		content string
+		charset string
+	}{
+		{"detected", NewTextParser(), "Body ±", "windows-1252"},
+		{"encoding and frontmatter", NewTextParser(WithEncoding("latin2"), WithFrontmatter()), "---\ntitle: Notes\n---\nBody ą", "iso-8859-2"},
+	}
+	for _, tt := range tests {
+		doc, err := tt.parser.Parse(input, "a.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc.Content != tt.content || doc.Metadata["charset"] != tt.charset || doc.Metadata["title"] != "Notes" {
+			t.Errorf("%s: Content %q, metadata %v", tt.name, doc.Content, doc.Metadata)
+		}
+	}
+
+	doc, err := NewTextParser(WithNormalization(NewNormalizer())).Parse([]byte("“ﬁne”"), "a.txt")
+	if err != nil || doc.Content != `"fine"` {
+		t.Errorf("normalized Content %q, %v", doc.Content, err)
+	}
+	small := NewTextParser(WithMaxSize(3))
+	if _, err := small.Parse([]byte("four"), "a.txt"); err == nil {
+		t.Error("Parse accepted more than MaxSize bytes")
+	}
+	if _, err := small.ParseReader(strings.NewReader("four"), "a.txt"); err == nil {
+		t.Error("ParseReader accepted more than MaxSize bytes")
+	}
+}
+
+// recountTokenizer counts words like WhitespaceTokenizer but is not one,
+// so groups are recounted in full.
+type recountTokenizer struct{ WhitespaceTokenizer }
+
+// sentenceGroupsRecount is sentenceGroups as it was before groups were
+// counted incrementally, recounting each group as it grows.
+func sentenceGroupsRecount(text string, chunkSize int, tok Tokenizer) []textSpan {
+	var out []textSpan
+	cur := textSpan{-1, -1}
+	for _, sp := range defaultSegmenter.spans(text) {
+		if cur.start >= 0 && tok.Count(text[cur.start:sp.end]) > chunkSize {
+			out = append(out, cur)
+			cur = textSpan{-1, -1}
+		}
+		if cur.start < 0 {
+			cur.start = sp.start
+		}
+		cur.end = sp.end
+	}
+	if cur.start >= 0 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+func TestSentenceGroupsMatchRecount(t *testing.T) {
+	texts := map[string]string{
+		"abbreviations": "Dr. Smith met J. R. R. Tolkien at 5 p.m. on Jan. 3. See fig. 2, e.g. the map.\n\nIt rained.Then it stopped!",
+		"wide":          "今日は晴れです。明日は雨でしょう。「本当？」と彼は聞いた。",
+		"spaces":        "  One.   Two words.\tThree whole words here.\n\n\nFour.  ",
+	}
+	for _, name := range []string{"test-txt.txt", "test-text.txt", "test-md.md", "test-markdown.md"} {
+		texts[name] = string(mustRead(t, fixture(name)))
+	}
+	tokenizers := map[string]Tokenizer{
+		"whitespace": WhitespaceTokenizer{},
+		"character":  CharacterTokenizer{},
+		"byte":       ByteTokenizer{},
+		"recount":    recountTokenizer{},
+	}
+	for name, text := range texts {
+		for tokName, tok := range tokenizers {
+			for _, size := range []int{1, 5, 20, 100, 1000} {
+				got := sentenceGroups(text, size, tok, defaultSegmenter)
+				want := sentenceGroupsRecount(text, size, tok)
+				if !slices.Equal(got, want) {
+					t.Errorf("%s, %s tokenizer, size %d: groups %v, want %v", name, tokName, size, got, want)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkChunkText(b *testing.B) {
+	text := strings.Repeat(string(mustRead(b, fixture("test-markdown.md")))+"\n\n", 20)
+	b.SetBytes(int64(len(text)))
+	b.ReportAllocs()
+	for range b.N {
+		ChunkText(text, 500)
+	}
+}
@@ -0,0 +1,552 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DocxParser extracts structured text from Word (.docx) documents.
+type DocxParser struct {
+	// MaxDecompressedBytes limits the bytes read from all the parts of a
+	// package together, so a ZIP bomb fails with ErrTooLarge. Defaults to
+	// 256 MB.
+	MaxDecompressedBytes int64
+}
+
+// NewDocxParser creates a new DOCX parser instance.
+func NewDocxParser() *DocxParser {
+	return &DocxParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *DocxParser) Supports(mimeType string) bool {
+	return mimeType == "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+}
+
+// Parse walks word/document.xml and renders headings with a Markdown "#"
+// prefix, recorded in Document.Headings and Document.Sections, list items
+// with "-" or "1." markers, and table rows as pipe-separated cells. Explicit and last-rendered page breaks split
+// Document.Pages, and the core properties fill Document.Info.
+func (p *DocxParser) Parse(buffer []byte, filename string) (*Document, error) {
+	zr, err := openZip(buffer, p.MaxDecompressedBytes)
+	if err != nil {
+		return nil, err
+	}
+	body, err := readZipEntry(zr, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	styles, _ := readZipEntry(zr, "word/styles.xml")
+	numbering, _ := readZipEntry(zr, "word/numbering.xml")
+
+	w := &docxWalker{
+		headingStyles: docxHeadingStyles(styles),
+		listFormats:   docxListFormats(numbering),
+		counters:      map[string]int{},
+	}
+	if err := w.walk(body); err != nil {
+		return nil, fmt.Errorf("parse word/document.xml: %w", err)
+	}
+
+	content := strings.TrimSpace(w.out.String())
+	if content == "" {
+		return nil, ErrEmptyDocument
+	}
+	doc := &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Pages:     docxPages(w.pageStarts, len(content)),
+		Headings:  w.headings,
+		Sections:  BuildSections(w.headings, len(content)),
+	}
+	info := ooxmlCoreInfo(zr)
+	if err := zr.err(); err != nil {
+		return nil, err
+	}
+	return doc.withInfo(info), nil
+}
+
+// docxPages turns the offsets at which pages after the first start into
+// page ranges, or nil for a document without page breaks.
+func docxPages(starts []int, length int) []Page {
+	var pages []Page
+	prev := 0
+	for _, s := range starts {
+		if s <= prev || s >= length {
+			continue
+		}
+		pages = append(pages, Page{Number: len(pages) + 1, Start: prev, End: s})
+		prev = s
+	}
+	if len(pages) == 0 {
+		return nil
+	}
+	return append(pages, Page{Number: len(pages) + 1, Start: prev, End: length})
+}
+
+// zipPackage is an in-memory ZIP container such as an OOXML package. Its
+// parts share one budget of decompressed bytes.
+type zipPackage struct {
+	*zip.Reader
+	max, remaining int64
+}
+
+// openZip opens buffer as a ZIP container whose parts may decompress to
+// max bytes together, or to 256 MB when max is not positive.
+func openZip(buffer []byte, max int64) (*zipPackage, error) {
+	zr, err := zip.NewReader(bytes.NewReader(buffer), int64(len(buffer)))
+	if err != nil {
+		return nil, fmt.Errorf("open zip container: %w", err)
+	}
+	if max <= 0 {
+		max = 256 << 20
+	}
+	return &zipPackage{Reader: zr, max: max, remaining: max}, nil
+}
+
+// readZipEntry returns the contents of the named file in the archive.
+func readZipEntry(zr *zipPackage, name string) ([]byte, error) {
+	rc, err := openZipEntry(zr, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// openZipEntry opens the named file in the archive for streaming. Reading
+// it fails with ErrTooLarge once the package's budget is spent.
+func openZipEntry(zr *zipPackage, name string) (io.ReadCloser, error) {
+	for _, f := range zr.File {
+		if f.Name == name {
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			return &zipPart{rc, zr}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s not found in archive", name)
+}
+
+// zipPart reads a part of a zipPackage, charging its budget.
+type zipPart struct {
+	io.ReadCloser
+	pkg *zipPackage
+}
+
+func (p *zipPart) Read(b []byte) (int, error) {
+	if int64(len(b)) > p.pkg.remaining+1 {
+		b = b[:p.pkg.remaining+1]
+	}
+	n, err := p.ReadCloser.Read(b)
+	p.pkg.remaining -= int64(n)
+	if err := p.pkg.err(); err != nil {
+		return 0, err
+	}
+	return n, err
+}
+
+// err reports whether the parts read so far ran over the budget, which
+// parsers check at the end, as optional parts are read ignoring errors.
+func (zr *zipPackage) err() error {
+	if zr.remaining < 0 {
+		return fmt.Errorf("%w: package inflates to more than %d bytes", ErrTooLarge, zr.max)
+	}
+	return nil
+}
+
+// ooxmlRels reads the relationships part for an OOXML package part and maps
+// relationship IDs to the package paths they target.
+func ooxmlRels(zr *zipPackage, part string) map[string]string {
+	dir, file := path.Split(part)
+	data, err := readZipEntry(zr, dir+"_rels/"+file+".rels")
+	if err != nil {
+		return nil
+	}
+	var rels struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+			Mode   string `xml:"TargetMode,attr"`
+		} `xml:"Relationship"`
+	}
+	if xml.Unmarshal(data, &rels) != nil {
+		return nil
+	}
+	targets := map[string]string{}
+	for _, r := range rels.Items {
+		switch {
+		case r.Mode == "External":
+			targets[r.ID] = r.Target
+		case strings.HasPrefix(r.Target, "/"):
+			targets[r.ID] = strings.TrimPrefix(r.Target, "/")
+		default:
+			targets[r.ID] = path.Clean(path.Join(dir, r.Target))
+		}
+	}
+	return targets
+}
+
+// ooxmlCoreInfo reads the core properties in docProps/core.xml. Properties
+// without a DocumentInfo field, such as "lastModifiedBy" and "revision",
+// go to Properties.
+func ooxmlCoreInfo(zr *zipPackage) DocumentInfo {
+	var info DocumentInfo
+	data, err := readZipEntry(zr, "docProps/core.xml")
+	if err != nil {
+		return info
+	}
+	var core struct {
+		Fields []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	}
+	if xml.Unmarshal(data, &core) != nil {
+		return info
+	}
+	for _, f := range core.Fields {
+		v := strings.TrimSpace(f.Value)
+		switch f.XMLName.Local {
+		case "title":
+			info.Title = v
+		case "creator":
+			info.Author = v
+		case "subject":
+			info.Subject = v
+		case "keywords":
+			info.Keywords = splitKeywords(v)
+		case "language":
+			info.Language = v
+		case "created":
+			info.Created = parseInfoDate(v)
+		case "modified":
+			info.Modified = parseInfoDate(v)
+		default:
+			info.setProperty(f.XMLName.Local, v)
+		}
+	}
+	return info
+}
+
+var docxHeadingName = regexp.MustCompile(`(?i)^heading\s*([1-9])$`)
+
+// docxHeadingStyles maps style IDs to heading levels using the style names
+// in styles.xml, since style IDs are localized ("berschrift1", "Titre1").
+func docxHeadingStyles(data []byte) map[string]int {
+	levels := map[string]int{"Title": 1}
+	for i := 1; i <= 9; i++ {
+		levels["Heading"+strconv.Itoa(i)] = i
+	}
+	if data == nil {
+		return levels
+	}
+	var doc struct {
+		Styles []struct {
+			ID   string `xml:"styleId,attr"`
+			Name struct {
+				Val string `xml:"val,attr"`
+			} `xml:"name"`
+			Outline *struct {
+				Val int `xml:"val,attr"`
+			} `xml:"pPr>outlineLvl"`
+		} `xml:"style"`
+	}
+	if xml.Unmarshal(data, &doc) != nil {
+		return levels
+	}
+	for _, s := range doc.Styles {
+		if m := docxHeadingName.FindStringSubmatch(s.Name.Val); m != nil {
+			levels[s.ID], _ = strconv.Atoi(m[1])
+		} else if strings.EqualFold(s.Name.Val, "title") {
+			levels[s.ID] = 1
+		} else if s.Outline != nil && s.Outline.Val < 9 {
+			levels[s.ID] = s.Outline.Val + 1
+		}
+	}
+	return levels
+}
+
+// docxListFormats maps "numId/ilvl" to the numbering format ("bullet",
+// "decimal", ...) declared in numbering.xml.
+func docxListFormats(data []byte) map[string]string {
+	formats := map[string]string{}
+	if data == nil {
+		return formats
+	}
+	var doc struct {
+		Abstract []struct {
+			ID     string `xml:"abstractNumId,attr"`
+			Levels []struct {
+				Ilvl   string `xml:"ilvl,attr"`
+				NumFmt struct {
+					Val string `xml:"val,attr"`
+				} `xml:"numFmt"`
+			} `xml:"lvl"`
+		} `xml:"abstractNum"`
+		Nums []struct {
+			ID       string `xml:"numId,attr"`
+			Abstract struct {
+				Val string `xml:"val,attr"`
+			} `xml:"abstractNumId"`
+		} `xml:"num"`
+	}
+	if xml.Unmarshal(data, &doc) != nil {
+		return formats
+	}
+	abstract := map[string]map[string]string{}
+	for _, a := range doc.Abstract {
+		abstract[a.ID] = map[string]string{}
+		for _, l := range a.Levels {
+			abstract[a.ID][l.Ilvl] = l.NumFmt.Val
+		}
+	}
+	for _, n := range doc.Nums {
+		for ilvl, f := range abstract[n.Abstract.Val] {
+			formats[n.ID+"/"+ilvl] = f
+		}
+	}
+	return formats
+}
+
+// docxParagraph collects the properties and text of a single w:p.
+type docxParagraph struct {
+	style string
+	numID string
+	ilvl  int
+	text  strings.Builder
+	// breakAfter is set by a page break after the paragraph's first text.
+	breakAfter bool
+}
+
+// docxWalker streams document.xml tokens and renders block-level output.
+type docxWalker struct {
+	headingStyles map[string]int
+	listFormats   map[string]string
+	counters      map[string]int
+
+	out        strings.Builder
+	para       *docxParagraph
+	inList     bool
+	tableDepth int
+	tableRows  [][]string
+	row        []string
+	cell       *strings.Builder
+	depth      int
+	// pageBreak is set when the next block starts a new page, whose
+	// offset in out is then appended to pageStarts.
+	pageBreak  bool
+	pageStarts []int
+	headings   []Heading
+}
+
+func (w *docxWalker) walk(data []byte) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			w.start(t)
+		case xml.EndElement:
+			w.end(t)
+		case xml.CharData:
+			if w.para != nil && w.depth > 0 {
+				w.para.text.Write(t)
+			}
+		}
+	}
+}
+
+func docxAttr(e xml.StartElement, local string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (w *docxWalker) start(e xml.StartElement) {
+	switch e.Name.Local {
+	case "p":
+		w.para = &docxParagraph{}
+	case "pStyle":
+		if w.para != nil {
+			w.para.style = docxAttr(e, "val")
+		}
+	case "numId":
+		if w.para != nil {
+			w.para.numID = docxAttr(e, "val")
+		}
+	case "ilvl":
+		if w.para != nil {
+			w.para.ilvl, _ = strconv.Atoi(docxAttr(e, "val"))
+		}
+	case "t":
+		w.depth++
+	case "tab":
+		if w.para != nil {
+			w.para.text.WriteByte('\t')
+		}
+	case "br", "cr":
+		if docxAttr(e, "type") == "page" {
+			w.breakPage()
+		} else if w.para != nil {
+			w.para.text.WriteByte('\n')
+		}
+	case "lastRenderedPageBreak":
+		w.breakPage()
+	case "pageBreakBefore":
+		if v := docxAttr(e, "val"); v != "0" && v != "false" {
+			w.pageBreak = true
+		}
+	case "tbl":
+		w.tableDepth++
+		if w.tableDepth == 1 {
+			w.flushList()
+			w.tableRows = nil
+		}
+	case "tr":
+		if w.tableDepth == 1 {
+			w.row = nil
+		}
+	case "tc":
+		if w.tableDepth == 1 {
+			w.cell = &strings.Builder{}
+		}
+	}
+}
+
+func (w *docxWalker) end(e xml.EndElement) {
+	switch e.Name.Local {
+	case "t":
+		w.depth--
+	case "p":
+		if w.para == nil {
+			return
+		}
+		text := strings.TrimSpace(w.para.text.String())
+		if w.cell != nil {
+			if text != "" {
+				if w.cell.Len() > 0 {
+					w.cell.WriteByte(' ')
+				}
+				w.cell.WriteString(text)
+			}
+		} else {
+			w.paragraph(w.para, text)
+		}
+		if w.para.breakAfter {
+			w.pageBreak = true
+		}
+		w.para = nil
+	case "tc":
+		if w.tableDepth == 1 && w.cell != nil {
+			w.row = append(w.row, strings.ReplaceAll(w.cell.String(), "|", "/"))
+			w.cell = nil
+		}
+	case "tr":
+		if w.tableDepth == 1 {
+			w.tableRows = append(w.tableRows, w.row)
+		}
+	case "tbl":
+		w.tableDepth--
+		if w.tableDepth == 0 {
+			w.table()
+		}
+	}
+}
+
+// paragraph renders a top-level paragraph as a heading, list item, or
+// plain block.
+func (w *docxWalker) paragraph(p *docxParagraph, text string) {
+	if text == "" {
+		return
+	}
+	if level, ok := w.headingStyles[p.style]; ok {
+		w.flushList()
+		w.block(strings.Repeat("#", level) + " " + text)
+		w.headings = append(w.headings, Heading{Level: level, Text: text, Offset: w.out.Len() - len(text) - level - 1})
+		return
+	}
+	if p.numID != "" && p.numID != "0" {
+		if !w.inList && w.out.Len() > 0 {
+			w.out.WriteString("\n\n")
+		} else if w.inList {
+			w.out.WriteByte('\n')
+		}
+		w.inList = true
+		key := p.numID + "/" + strconv.Itoa(p.ilvl)
+		marker := "-"
+		if f := w.listFormats[key]; f != "" && f != "bullet" && f != "none" {
+			w.counters[key]++
+			marker = strconv.Itoa(w.counters[key]) + "."
+		}
+		w.markPage()
+		w.out.WriteString(strings.Repeat("  ", p.ilvl) + marker + " " + text)
+		return
+	}
+	w.flushList()
+	w.block(text)
+}
+
+func (w *docxWalker) block(text string) {
+	if w.out.Len() > 0 {
+		w.out.WriteString("\n\n")
+	}
+	w.markPage()
+	w.out.WriteString(text)
+}
+
+// breakPage records a page break before the current paragraph when it has
+// no text yet, or after it otherwise.
+func (w *docxWalker) breakPage() {
+	if w.para != nil && strings.TrimSpace(w.para.text.String()) != "" {
+		w.para.breakAfter = true
+	} else {
+		w.pageBreak = true
+	}
+}
+
+// markPage starts a page at the current output offset after a break.
+func (w *docxWalker) markPage() {
+	if w.pageBreak {
+		w.pageStarts = append(w.pageStarts, w.out.Len())
+		w.pageBreak = false
+	}
+}
+
+func (w *docxWalker) flushList() {
+	w.inList = false
+}
+
+// table renders rows as "a | b | c" lines; nested tables are flattened
+// into the enclosing cell.
+func (w *docxWalker) table() {
+	var lines []string
+	for _, row := range w.tableRows {
+		line := strings.TrimSpace(strings.Join(row, " | "))
+		if strings.Trim(line, "| ") != "" {
+			lines = append(lines, line)
+		}
+	}
+	w.tableRows = nil
+	if len(lines) > 0 {
+		w.block(strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,111 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// zipFiles returns a ZIP archive of the named files, in the order given
+// as name, content pairs.
+func zipFiles(t testing.TB, pairs ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		w, err := zw.Create(pairs[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(pairs[i+1]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// docxBody returns a DOCX whose document body is body, in WordprocessingML.
+func docxBody(t testing.TB, body string) []byte {
+	return zipFiles(t, "word/document.xml",
+		`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`+body+`</w:body></w:document>`)
+}
+
+func TestDocxParser(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  []string
+	}{
+		{"fixture", mustRead(t, fixture("test-docx.docx")), []string{"# Lorem ipsum", "consectetur adipiscing elit"}},
+		{"heading", docxBody(t, `<w:p><w:pPr><w:pStyle w:val="Heading2"/></w:pPr><w:r><w:t>Setup</w:t></w:r></w:p><w:p><w:r><w:t>Body text.</w:t></w:r></w:p>`),
+			[]string{"## Setup", "Body text."}},
+		{"list", docxBody(t, `<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>First item</w:t></w:r></w:p>`),
+			[]string{"- First item"}},
+		{"table", docxBody(t, `<w:tbl><w:tr><w:tc><w:p><w:r><w:t>Name</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Role</w:t></w:r></w:p></w:tc></w:tr><w:tr><w:tc><w:p><w:r><w:t>Ada</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Engineer</w:t></w:r></w:p></w:tc></w:tr></w:tbl>`),
+			[]string{"Name | Role", "Ada | Engineer"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewDocxParser().Parse(tt.input, "a.docx")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(doc.Content, want) {
+					t.Errorf("content %q does not contain %q", doc.Content, want)
+				}
+			}
+		})
+	}
+	if _, err := NewDocxParser().Parse([]byte("not a zip"), "a.docx"); err == nil {
+		t.Error("parsed a non-ZIP buffer")
+	}
+}
+
+func TestDocxPages(t *testing.T) {
+	body := `<w:p><w:r><w:t>One.</w:t></w:r><w:r><w:br w:type="page"/></w:r></w:p>` +
+		`<w:p><w:r><w:t>Two.</w:t></w:r></w:p>` +
+		`<w:p><w:pPr><w:pageBreakBefore/></w:pPr><w:r><w:t>Three.</w:t></w:r></w:p>` +
+		`<w:p><w:r><w:lastRenderedPageBreak/><w:t>Four.</w:t></w:r></w:p>`
+	doc, err := NewDocxParser().Parse(docxBody(t, body), "pages.docx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Pages) != 4 {
+		t.Fatalf("pages = %+v, want 4", doc.Pages)
+	}
+	for i, want := range []string{"One.", "Two.", "Three.", "Four."} {
+		pg := doc.Pages[i]
+		if got := strings.TrimSpace(doc.Content[pg.Start:pg.End]); pg.Number != i+1 || got != want {
+			t.Errorf("page %d = %q, want %q", pg.Number, got, want)
+		}
+	}
+}
+
+// The parts of a DOCX share one budget, so a bomb split across parts
+// fails as well as one in a single part.
+func TestDocxParserBomb(t *testing.T) {
+	xml := `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body><w:p><w:r><w:t>Text</w:t></w:r></w:p></w:body></w:document>`
+	padding := "<!--" + strings.Repeat(" ", 600<<10) + "-->"
+	tests := []struct {
+		name  string
+		input []byte
+		fails bool
+	}{
+		{"small", zipFiles(t, "word/document.xml", xml), false},
+		{"one part", zipFiles(t, "word/document.xml", padding+padding+xml), true},
+		{"split across parts", zipFiles(t, "word/document.xml", padding+xml, "word/styles.xml", padding+padding), true},
+	}
+	for _, tt := range tests {
+		_, err := (&DocxParser{MaxDecompressedBytes: 1 << 20}).Parse(tt.input, "bomb.docx")
+		if tt.fails != errors.Is(err, ErrTooLarge) || !tt.fails && err != nil {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+		if tt.fails && len(tt.input) > 64<<10 {
+			t.Errorf("%s: bomb is %d bytes compressed", tt.name, len(tt.input))
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// driveFolder is the MIME type of Google Drive folders.
+const driveFolder = "application/vnd.google-apps.folder"
+
+// driveExports maps the Google Workspace types that have no file content
+// to the office format they are exported to, and its extension.
+var driveExports = map[string][2]string{
+	"application/vnd.google-apps.document":     {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", ".docx"},
+	"application/vnd.google-apps.spreadsheet":  {"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", ".xlsx"},
+	"application/vnd.google-apps.presentation": {"application/vnd.openxmlformats-officedocument.presentationml.presentation", ".pptx"},
+}
+
+// DriveLoader loads files from Google Drive, including shared drives,
+// over the Drive v3 API. Sources are file IDs. Docs, Sheets and Slides
+// are exported to DOCX, XLSX and PPTX so the office parsers read them;
+// other files are downloaded as they are.
+type DriveLoader struct {
+	// Token returns an OAuth 2.0 access token for each request, such as
+	// from golang.org/x/oauth2/google. When nil, access tokens are
+	// obtained from RefreshToken and refreshed as they expire.
+	Token func(ctx context.Context) (string, error)
+	// ClientID, ClientSecret and RefreshToken are the OAuth 2.0
+	// credentials used when Token is nil.
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+	// DriveID lists the files of a shared drive. Files of every drive the
+	// user can read are listed by default.
+	DriveID string
+	// Endpoint defaults to https://www.googleapis.com.
+	Endpoint string
+	// TokenURL defaults to https://oauth2.googleapis.com/token.
+	TokenURL string
+	// MaxRetries is the number of times a failed request is retried, with
+	// exponential backoff. Defaults to 3; negative turns retries off.
+	MaxRetries int
+	// Client defaults to an http.Client with a five minute timeout.
+	Client *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time
+}
+
+// NewDriveLoader creates a loader that authenticates with an OAuth 2.0
+// refresh token.
+func NewDriveLoader(clientID, clientSecret, refreshToken string) *DriveLoader {
+	return &DriveLoader{ClientID: clientID, ClientSecret: clientSecret, RefreshToken: refreshToken}
+}
+
+type driveFile struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	MimeType     string `json:"mimeType"`
+	Version      string `json:"version"`
+	ModifiedTime string `json:"modifiedTime"`
+	WebViewLink  string `json:"webViewLink"`
+	DriveID      string `json:"driveId"`
+}
+
+// List returns the IDs of the files in the folder with ID prefix and its
+// subfolders, or of every file when prefix is empty. Trashed files are
+// left out.
+func (l *DriveLoader) List(ctx context.Context, prefix string) ([]string, error) {
+	var ids []string
+	if prefix == "" {
+		files, err := l.listFiles(ctx, "trashed = false and mimeType != '"+driveFolder+"'")
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			ids = append(ids, f.ID)
+		}
+		return ids, nil
+	}
+	folders, seen := []string{prefix}, map[string]bool{prefix: true}
+	for len(folders) > 0 {
+		folder := folders[0]
+		folders = folders[1:]
+		files, err := l.listFiles(ctx, "trashed = false and '"+strings.ReplaceAll(folder, "'", `\'`)+"' in parents")
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			switch {
+			case f.MimeType != driveFolder:
+				ids = append(ids, f.ID)
+			case !seen[f.ID]:
+				seen[f.ID] = true
+				folders = append(folders, f.ID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// listFiles returns every file matching the search query q.
+func (l *DriveLoader) listFiles(ctx context.Context, q string) ([]driveFile, error) {
+	query := url.Values{
+		"q":                         {q},
+		"fields":                    {"files(id,mimeType),nextPageToken"},
+		"pageSize":                  {"1000"},
+		"supportsAllDrives":         {"true"},
+		"includeItemsFromAllDrives": {"true"},
+	}
+	if l.DriveID != "" {
+		query.Set("corpora", "drive")
+		query.Set("driveId", l.DriveID)
+	}
+	var files []driveFile
+	for {
+		data, err := l.get(ctx, "/drive/v3/files", query)
+		if err != nil {
+			return nil, fmt.Errorf("list drive files: %w", err)
+		}
+		var page struct {
+			Files         []driveFile `json:"files"`
+			NextPageToken string      `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("decode drive listing: %w", err)
+		}
+		files = append(files, page.Files...)
+		if page.NextPageToken == "" {
+			return files, nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+// Load downloads or exports the file with ID source.
+func (l *DriveLoader) Load(ctx context.Context, source string) ([]byte, string, error) {
+	f, err := l.Fetch(ctx, source)
+	if err != nil {
+		return nil, "", err
+	}
+	return f.Buffer, f.Filename, nil
+}
+
+// Fetch downloads or exports the file with ID source, recording its
+// Drive version and link. Workspace types other than Docs, Sheets and
+// Slides, such as Forms, fail with ErrUnsupportedType.
+func (l *DriveLoader) Fetch(ctx context.Context, source string) (*Fetched, error) {
+	path := "/drive/v3/files/" + url.PathEscape(source)
+	data, err := l.get(ctx, path, url.Values{
+		"fields":            {"id,name,mimeType,version,modifiedTime,webViewLink,driveId"},
+		"supportsAllDrives": {"true"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get drive file: %w", err)
+	}
+	var file driveFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decode drive file: %w", err)
+	}
+
+	filename, mimeType := file.Name, file.MimeType
+	var buffer []byte
+	if export, ok := driveExports[file.MimeType]; ok {
+		mimeType = export[0]
+		filename = strings.TrimSuffix(file.Name, export[1]) + export[1]
+		buffer, err = l.get(ctx, path+"/export", url.Values{"mimeType": {mimeType}})
+	} else if strings.HasPrefix(file.MimeType, "application/vnd.google-apps.") {
+		return nil, fmt.Errorf("%w: %s (%s)", ErrUnsupportedType, file.MimeType, file.Name)
+	} else {
+		buffer, err = l.get(ctx, path, url.Values{"alt": {"media"}, "supportsAllDrives": {"true"}})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("download drive file %s: %w", file.Name, err)
+	}
+	metadata := map[string]string{"title": file.Name, "drive_file_id": file.ID}
+	if file.DriveID != "" {
+		metadata["drive_id"] = file.DriveID
+	}
+	if file.ModifiedTime != "" {
+		metadata["modified"] = file.ModifiedTime
+	}
+	return &Fetched{
+		Buffer:    buffer,
+		Filename:  filename,
+		MIMEType:  mimeType,
+		URI:       file.WebViewLink,
+		FetchedAt: time.Now().UTC(),
+		Version:   file.Version,
+		Metadata:  metadata,
+	}, nil
+}
+
+// get sends a GET for path with query. A request rejected with 401 is
+// sent once more with a freshly refreshed token.
+func (l *DriveLoader) get(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	endpoint := strings.TrimSuffix(l.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://www.googleapis.com"
+	}
+	u := endpoint + path + "?" + query.Encode()
+	for attempt := 0; ; attempt++ {
+		token, err := l.token(ctx, attempt > 0)
+		if err != nil {
+			return nil, fmt.Errorf("get access token: %w", err)
+		}
+		header := http.Header{"Authorization": {"Bearer " + token}}
+		data, err := retryPolicy{maxRetries: l.MaxRetries}.send(ctx, httpClient(l.Client, 5*time.Minute), http.MethodGet, u, header, nil, "")
+		var he *httpError
+		if attempt == 0 && errors.As(err, &he) && he.code == http.StatusUnauthorized {
+			continue
+		}
+		return data, err
+	}
+}
+
+// token returns an access token from Token, or the cached one while it
+// has more than a minute left unless refresh is set.
+func (l *DriveLoader) token(ctx context.Context, refresh bool) (string, error) {
+	if l.Token != nil {
+		return l.Token(ctx)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !refresh && l.accessToken != "" && time.Until(l.expiry) > time.Minute {
+		return l.accessToken, nil
+	}
+	if l.RefreshToken == "" {
+		return "", errors.New("no refresh token")
+	}
+	tokenURL := l.TokenURL
+	if tokenURL == "" {
+		tokenURL = "https://oauth2.googleapis.com/token"
+	}
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {l.RefreshToken},
+		"client_id":     {l.ClientID},
+		"client_secret": {l.ClientSecret},
+	}
+	data, err := retryPolicy{maxRetries: l.MaxRetries}.send(ctx, httpClient(l.Client, time.Minute), http.MethodPost, tokenURL,
+		nil, []byte(form.Encode()), "application/x-www-form-urlencoded")
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return "", fmt.Errorf("decode token response: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return "", errors.New("token response has no access token")
+	}
+	l.accessToken = resp.AccessToken
+	l.expiry = time.Now().Add(time.Duration(resp.ExpiresIn) * time.Second)
+	return l.accessToken, nil
+}
@@ -0,0 +1,138 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDriveLoader(t *testing.T) {
+	docx := mustRead(t, fixture("test-docx.docx"))
+	var mu sync.Mutex
+	refreshes, valid := 0, ""
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "refresh" || r.Form.Get("client_id") != "id" {
+			http.Error(w, "bad grant", http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		refreshes++
+		valid = "access" + strings.Repeat("!", refreshes)
+		token := valid
+		mu.Unlock()
+		w.Write([]byte(`{"access_token": "` + token + `", "expires_in": 3600}`))
+	}))
+	defer tokens.Close()
+
+	files := map[string]string{
+		"plan":  `{"id": "plan", "name": "Plan", "mimeType": "application/vnd.google-apps.document", "version": "7", "modifiedTime": "2024-05-06T07:08:09Z", "webViewLink": "https://docs.google.com/document/d/plan", "driveId": "team"}`,
+		"notes": `{"id": "notes", "name": "notes.txt", "mimeType": "text/plain", "version": "2"}`,
+		"form":  `{"id": "form", "name": "Survey", "mimeType": "application/vnd.google-apps.form"}`,
+	}
+	drive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ok := r.Header.Get("Authorization") == "Bearer "+valid
+		mu.Unlock()
+		if !ok {
+			http.Error(w, "expired", http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/drive/v3/files/"), "/")
+		switch {
+		case r.URL.Path == "/drive/v3/files":
+			switch q.Get("q") {
+			case "trashed = false and 'root' in parents":
+				w.Write([]byte(`{"files": [{"id": "plan", "mimeType": "application/vnd.google-apps.document"}, {"id": "sub", "mimeType": "` + driveFolder + `"}]}`))
+			case "trashed = false and 'sub' in parents":
+				if q.Get("pageToken") == "" {
+					w.Write([]byte(`{"files": [{"id": "notes", "mimeType": "text/plain"}], "nextPageToken": "p2"}`))
+				} else {
+					w.Write([]byte(`{"files": [{"id": "root", "mimeType": "` + driveFolder + `"}]}`))
+				}
+			case "trashed = false and mimeType != '" + driveFolder + "'":
+				if q.Get("corpora") != "drive" || q.Get("driveId") != "team" {
+					http.Error(w, "not the shared drive", http.StatusBadRequest)
+					return
+				}
+				w.Write([]byte(`{"files": [{"id": "plan"}, {"id": "notes"}]}`))
+			default:
+				http.Error(w, "unexpected query "+q.Get("q"), http.StatusBadRequest)
+			}
+		case rest == "export":
+			if q.Get("mimeType") != driveExports["application/vnd.google-apps.document"][0] {
+				http.Error(w, "bad export type", http.StatusBadRequest)
+				return
+			}
+			w.Write(docx)
+		case q.Get("alt") == "media":
+			w.Write([]byte("Plain notes."))
+		case files[id] != "":
+			w.Write([]byte(files[id]))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer drive.Close()
+
+	l := NewDriveLoader("id", "secret", "refresh")
+	l.Endpoint, l.TokenURL = drive.URL, tokens.URL
+	ctx := context.Background()
+	ids, err := l.List(ctx, "root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"plan", "notes"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("folder files %q, want %q", ids, want)
+	}
+	if refreshes != 1 {
+		t.Errorf("refreshed the token %d times, want once", refreshes)
+	}
+
+	f, err := l.Fetch(ctx, "plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMeta := map[string]string{"title": "Plan", "drive_file_id": "plan", "drive_id": "team", "modified": "2024-05-06T07:08:09Z"}
+	if f.Filename != "Plan.docx" || f.MIMEType != driveExports["application/vnd.google-apps.document"][0] || f.Version != "7" ||
+		f.URI != "https://docs.google.com/document/d/plan" || !reflect.DeepEqual(f.Metadata, wantMeta) || len(f.Buffer) != len(docx) {
+		t.Errorf("exported %+v", f)
+	}
+
+	// An expired token is refreshed and the request sent again.
+	mu.Lock()
+	valid = "rotated"
+	mu.Unlock()
+	if f, err := l.Fetch(ctx, "notes"); err != nil || string(f.Buffer) != "Plain notes." || f.Filename != "notes.txt" {
+		t.Errorf("download after expiry: %+v, %v", f, err)
+	}
+	if refreshes != 2 {
+		t.Errorf("refreshed the token %d times, want twice", refreshes)
+	}
+	if _, err := l.Fetch(ctx, "form"); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("form: %v", err)
+	}
+
+	l.DriveID = "team"
+	if ids, err := l.List(ctx, ""); err != nil || !reflect.DeepEqual(ids, []string{"plan", "notes"}) {
+		t.Errorf("shared drive files %q, %v", ids, err)
+	}
+
+	calls := 0
+	fixed := &DriveLoader{Endpoint: drive.URL, Token: func(ctx context.Context) (string, error) {
+		calls++
+		return valid, nil
+	}}
+	if _, err := fixed.Fetch(ctx, "notes"); err != nil || calls != 2 {
+		t.Errorf("Token func called %d times: %v", calls, err)
+	}
+	if _, err := (&DriveLoader{Endpoint: drive.URL}).Fetch(ctx, "notes"); err == nil {
+		t.Error("loader without credentials returned no error")
+	}
+}
@@ -0,0 +1,141 @@
+package document
+
+import (
+	"context"
+	"fmt"
+)
+
+// Plan reports what a Pipeline would produce for a corpus, as computed by
+// DryRun.
+type Plan struct {
+	// Files lists each source in order.
+	Files []FilePlan
+	// Failed is the number of sources that could not be loaded or parsed.
+	Failed int
+	// Bytes is the size of the inputs read.
+	Bytes int64
+	// Chunks is the number of chunks across all sources.
+	Chunks int
+	// Tokens is the number of tokens that would be embedded, counted by
+	// the chunker's Tokenizer.
+	Tokens int
+	// Estimated is set when a strategy was approximated, because it needs
+	// an Embedder, so Chunks and Tokens are estimates.
+	Estimated bool
+}
+
+// FilePlan is the part of a Plan for one source.
+type FilePlan struct {
+	Source string
+	Chunks int
+	Tokens int
+	// Err is why the source could not be planned, if it could not.
+	Err error
+}
+
+// Cost returns the estimated embedding cost of the plan given a price per
+// million tokens, in the currency of the price.
+func (p *Plan) Cost(pricePerMillionTokens float64) float64 {
+	return float64(p.Tokens) * pricePerMillionTokens / 1e6
+}
+
+// DryRun loads, parses and transforms each source and computes its chunk
+// boundaries, without running chunk transformers or sinks, and reports
+// how many chunks and tokens the pipeline would produce. The built-in
+// strategies that split pages into spans, such as "recursive", only
+// compute the spans and count their tokens; others chunk without IDs or
+// provenance, as does any strategy with Options.Contextual. Strategies that
+// call an Embedder, such as "semantic", are approximated with "recursive"
+// at the same chunk size so that planning costs nothing. Sources that fail
+// are recorded in the plan rather than stopping it; only ctx being done
+// does.
+func (p *Pipeline) DryRun(ctx context.Context, sources ...string) (*Plan, error) {
+	strategy, opts := p.strategy, p.opts
+	loader, plan := p.sourceLoader(), &Plan{}
+	if strategy == "semantic" {
+		strategy, opts.Embedder = "recursive", nil
+		plan.Estimated = true
+	}
+	for _, source := range sources {
+		if err := ctx.Err(); err != nil {
+			return plan, err
+		}
+		fp := FilePlan{Source: source}
+		fp.Err = func() error {
+			src, err := fetch(ctx, loader, source)
+			if err != nil {
+				return fmt.Errorf("load %s: %w", source, err)
+			}
+			defer src.Close()
+			filename := src.Filename
+			plan.Bytes += int64(len(src.Buffer))
+			doc, err := p.parse(ctx, src)
+			if err != nil {
+				return err
+			}
+			fp.Chunks, fp.Tokens, err = planChunks(ctx, strategy, doc, opts)
+			if err != nil {
+				return fmt.Errorf("chunk %s: %w", filename, err)
+			}
+			return nil
+		}()
+		if fp.Err != nil {
+			plan.Failed++
+		}
+		plan.Chunks += fp.Chunks
+		plan.Tokens += fp.Tokens
+		plan.Files = append(plan.Files, fp)
+	}
+	return plan, nil
+}
+
+// planChunks returns the number of chunks strategy makes of doc and the
+// tokens they would embed.
+func planChunks(ctx context.Context, strategy string, doc *Document, opts Options) (chunks, tokens int, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, 0, err
+	}
+	tok := opts.tokenizer()
+	if split := lookupSpans(strategy, opts); split != nil && !opts.Contextual {
+		pages := doc.Pages
+		if len(pages) == 0 {
+			pages = []Page{{Start: 0, End: len(doc.Content)}}
+		}
+		for _, pg := range pages {
+			start, end := max(pg.Start, 0), min(pg.End, len(doc.Content))
+			if start >= end {
+				continue
+			}
+			text := doc.Content[start:end]
+			for _, sp := range split(text) {
+				chunks++
+				tokens += tok.Count(text[sp.start:sp.end])
+			}
+		}
+		return chunks, tokens, nil
+	}
+	c, err := LookupChunker(strategy)
+	if err != nil {
+		return 0, 0, err
+	}
+	opts.Context = ctx
+	list, err := c.Chunk(doc, opts)
+	if err != nil {
+		return 0, 0, err
+	}
+	if opts.Contextual {
+		contextualize(doc, list, opts.segmenter())
+	}
+	for _, c := range list {
+		tokens += chunkTokens(c, opts)
+	}
+	return len(list), tokens, nil
+}
+
+// chunkTokens returns the number of tokens of c that would be embedded.
+func chunkTokens(c Chunk, opts Options) int {
+	if c.EmbedText == "" && c.TokenCount > 0 {
+		return c.TokenCount
+	}
+	return opts.tokenizer().Count(c.EmbeddingText())
+}
@@ -0,0 +1,109 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	loader := mapLoader(map[string]string{
+		"a.txt": "One two three.\n\nFour five six.",
+		"b.bin": "\x00\x01\x02\xff",
+	})
+	written := 0
+	sink := SinkFunc(func(context.Context, *Document, []Chunk) error { written++; return nil })
+	p := NewPipeline().LoadWith(loader).Chunker("paragraph", WithChunkSize(3)).To(sink)
+	plan, err := p.DryRun(context.Background(), "a.txt", "b.bin", "missing.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != 0 {
+		t.Errorf("DryRun wrote %d documents to the sink", written)
+	}
+	if len(plan.Files) != 3 || plan.Failed != 2 || plan.Chunks != 2 || plan.Tokens != 6 || plan.Bytes != 34 || plan.Estimated {
+		t.Errorf("plan = %+v", plan)
+	}
+	if fp := plan.Files[0]; fp.Source != "a.txt" || fp.Chunks != 2 || fp.Tokens != 6 || fp.Err != nil {
+		t.Errorf("a.txt plan = %+v", fp)
+	}
+	if !errors.Is(plan.Files[1].Err, ErrUnsupportedType) || plan.Files[2].Err == nil {
+		t.Errorf("failures = %v, %v", plan.Files[1].Err, plan.Files[2].Err)
+	}
+	if got := plan.Cost(0.02); got != 6*0.02/1e6 {
+		t.Errorf("Cost = %v", got)
+	}
+
+	// Semantic chunking is approximated without calling the embedder.
+	plan, err = NewPipeline().LoadWith(loader).Chunker("semantic", WithChunkSize(3)).DryRun(context.Background(), "a.txt")
+	if err != nil || !plan.Estimated || plan.Chunks == 0 {
+		t.Errorf("semantic plan = %+v, %v", plan, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.DryRun(ctx, "a.txt"); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled DryRun: err = %v", err)
+	}
+}
+
+func TestPlanChunksMatchesChunking(t *testing.T) {
+	text := string(mustRead(t, fixture("test-markdown.md")))
+	paged := &Document{Content: text, Source: "paged.md", Pages: []Page{
+		{Number: 1, Start: 0, End: len(text) / 3},
+		{Number: 2, Start: len(text) / 3, End: len(text)},
+	}}
+	docs := []*Document{{Content: text, Source: "test-markdown.md"}, paged}
+	tests := []struct {
+		strategy string
+		opts     Options
+	}{
+		{"fixed", Options{ChunkSize: 80, Overlap: 10}},
+		{"recursive", Options{ChunkSize: 100, Overlap: 20}},
+		{"recursive", Options{ChunkSize: 300, Tokenizer: CharacterTokenizer{}}},
+		{"paragraph", Options{ChunkSize: 120}},
+		{"window", Options{ChunkSize: 64, Overlap: 16}},
+		{"markdown", Options{ChunkSize: 100}},
+		{"recursive", Options{ChunkSize: 100, Contextual: true}},
+	}
+	for _, tt := range tests {
+		for _, doc := range docs {
+			chunks, err := ChunkDocument(tt.strategy, doc, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tokens := 0
+			for _, c := range chunks {
+				tokens += chunkTokens(c, tt.opts)
+			}
+			gotChunks, gotTokens, err := planChunks(context.Background(), tt.strategy, doc, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotChunks != len(chunks) || gotTokens != tokens {
+				t.Errorf("%s %+v on %s: planned %d chunks, %d tokens; chunking gives %d, %d",
+					tt.strategy, tt.opts, doc.Source, gotChunks, gotTokens, len(chunks), tokens)
+			}
+		}
+	}
+}
+
+func TestDryRunFixtures(t *testing.T) {
+	sources := []string{fixture("test-txt.txt"), fixture("test-md.md"), fixture("missing.txt")}
+	plan, err := NewPipeline().Chunker("recursive", WithChunkSize(50)).DryRun(context.Background(), sources...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Files) != 3 || plan.Failed != 1 || plan.Files[2].Err == nil {
+		t.Fatalf("plan = %+v, want the missing file to fail alone", plan)
+	}
+	for _, fp := range plan.Files[:2] {
+		_, chunks, err := NewPipeline().Chunker("recursive", WithChunkSize(50)).Process(context.Background(), mustRead(t, fp.Source), fp.Source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fp.Chunks != len(chunks) {
+			t.Errorf("%s: planned %d chunks, processing gives %d", fp.Source, fp.Chunks, len(chunks))
+		}
+	}
+}
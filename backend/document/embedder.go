@@ -0,0 +1,48 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Embedder converts texts to embedding vectors, returning one vector per
+// input in the same order.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedChunks sets the Embedding of every chunk from its EmbeddingText,
+// in a single call to e.
+func EmbedChunks(ctx context.Context, e Embedder, chunks []Chunk) error {
+	texts := make([]string, len(chunks))
+	for i := range chunks {
+		texts[i] = chunks[i].EmbeddingText()
+	}
+	vectors, err := e.Embed(ctx, texts)
+	if err != nil {
+		return err
+	}
+	if len(vectors) != len(chunks) {
+		return fmt.Errorf("embedder returned %d vectors for %d chunks", len(vectors), len(chunks))
+	}
+	for i := range chunks {
+		chunks[i].Embedding = vectors[i]
+	}
+	return nil
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b, or 0
+// when either is a zero vector.
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := 0; i < len(a) && i < len(b); i++ {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
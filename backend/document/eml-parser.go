@@ -0,0 +1,294 @@
+package document
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// EMLParser extracts the headers, readable body and attachments of RFC 5322
+// email messages.
+type EMLParser struct{}
+
+// NewEMLParser creates a new EML parser instance.
+func NewEMLParser() *EMLParser {
+	return &EMLParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *EMLParser) Supports(mimeType string) bool {
+	return mimeType == "message/rfc822" || mimeType == "application/eml"
+}
+
+// emlMaxDepth bounds multipart nesting.
+const emlMaxDepth = 16
+
+// Parse prefixes the body with a Subject/From/To/Date block and copies those
+// headers into the metadata. Of multipart/alternative bodies the text/plain
+// version is preferred; HTML-only bodies are rendered like HTMLParser does.
+// Attachments, including forwarded messages, are returned undecoded in
+// Document.Attachments.
+func (p *EMLParser) Parse(buffer []byte, filename string) (*Document, error) {
+	msg, err := readEML(buffer)
+	if err != nil {
+		return nil, err
+	}
+	if msg.text == "" && len(msg.attachments) == 0 {
+		return nil, ErrEmptyDocument
+	}
+
+	var head []string
+	for _, h := range []struct{ label, key string }{
+		{"Subject", "subject"}, {"From", "from"}, {"To", "to"}, {"Cc", "cc"}, {"Date", "date"},
+	} {
+		if v := msg.meta[h.key]; v != "" {
+			head = append(head, h.label+": "+v)
+		}
+	}
+	content := strings.Join(head, "\n")
+	if msg.text != "" {
+		content += "\n\n" + msg.text
+	}
+	return &Document{
+		Content:     content,
+		Source:      filename,
+		WordCount:   len(strings.Fields(content)),
+		Metadata:    msg.meta,
+		Attachments: msg.attachments,
+	}, nil
+}
+
+// emlMessage is a decoded message: its header metadata, readable body text
+// and attachments.
+type emlMessage struct {
+	meta        map[string]string
+	text        string
+	attachments []Attachment
+}
+
+// readEML decodes a single RFC 5322 message.
+func readEML(buffer []byte) (*emlMessage, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(buffer))
+	if err != nil {
+		return nil, fmt.Errorf("read email message: %w", err)
+	}
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read email body: %w", err)
+	}
+	w := &emlWalker{}
+	w.part(textproto.MIMEHeader(msg.Header), body, 0)
+
+	m := &emlMessage{
+		meta:        emlHeaders(msg.Header),
+		text:        strings.TrimSpace(strings.Join(w.texts, "\n\n")),
+		attachments: w.attachments,
+	}
+	if len(w.attachments) > 0 {
+		names := make([]string, len(w.attachments))
+		for i, a := range w.attachments {
+			names[i] = a.Filename
+		}
+		m.meta["attachments"] = strings.Join(names, ", ")
+	}
+	return m, nil
+}
+
+// emlWordDecoder decodes RFC 2047 encoded words in headers.
+var emlWordDecoder = &mime.WordDecoder{
+	CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		return strings.NewReader(decodeCharset(data, charset)), nil
+	},
+}
+
+// emlHeaders extracts the addressing and threading headers as metadata.
+// Dates are normalized to RFC 3339.
+func emlHeaders(h mail.Header) map[string]string {
+	meta := map[string]string{}
+	decode := func(v string) string {
+		if d, err := emlWordDecoder.DecodeHeader(v); err == nil {
+			v = d
+		}
+		return strings.Join(strings.Fields(v), " ")
+	}
+	for key, name := range map[string]string{
+		"subject": "Subject", "message_id": "Message-Id",
+		"in_reply_to": "In-Reply-To", "references": "References",
+	} {
+		if v := decode(h.Get(name)); v != "" {
+			meta[key] = v
+		}
+	}
+	for key, name := range map[string]string{"from": "From", "to": "To", "cc": "Cc"} {
+		raw := h.Get(name)
+		if raw == "" {
+			continue
+		}
+		parser := mail.AddressParser{WordDecoder: emlWordDecoder}
+		if list, err := parser.ParseList(raw); err == nil {
+			addrs := make([]string, len(list))
+			for i, a := range list {
+				addrs[i] = a.Address
+				if a.Name != "" {
+					addrs[i] = a.Name + " <" + a.Address + ">"
+				}
+			}
+			meta[key] = strings.Join(addrs, ", ")
+		} else {
+			meta[key] = decode(raw)
+		}
+	}
+	if raw := h.Get("Date"); raw != "" {
+		if t, err := mail.ParseDate(raw); err == nil {
+			meta["date"] = t.Format(time.RFC3339)
+		} else {
+			meta["date"] = decode(raw)
+		}
+	}
+	return meta
+}
+
+// emlWalker collects readable text and attachments from a MIME tree.
+type emlWalker struct {
+	texts       []string
+	attachments []Attachment
+}
+
+func (w *emlWalker) part(h textproto.MIMEHeader, body []byte, depth int) {
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	body = emlTransferDecode(body, h.Get("Content-Transfer-Encoding"))
+
+	disposition, dparams, _ := mime.ParseMediaType(h.Get("Content-Disposition"))
+	name := dparams["filename"]
+	if name == "" {
+		name = params["name"]
+	}
+	if name != "" {
+		if d, err := emlWordDecoder.DecodeHeader(name); err == nil {
+			name = d
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/") && depth < emlMaxDepth:
+		parts := emlMultipart(body, params["boundary"])
+		if mediaType == "multipart/alternative" {
+			w.alternative(parts, depth)
+			return
+		}
+		for _, sp := range parts {
+			w.part(sp.header, sp.body, depth+1)
+		}
+	case disposition == "attachment" || mediaType == "message/rfc822" ||
+		(name != "" && !strings.HasPrefix(mediaType, "text/")):
+		if name == "" {
+			name = fmt.Sprintf("attachment-%d", len(w.attachments)+1)
+			if mediaType == "message/rfc822" {
+				name += ".eml"
+			}
+		}
+		w.attachments = append(w.attachments, Attachment{Filename: name, MimeType: mediaType, Data: body})
+	case mediaType == "text/plain":
+		text := strings.ReplaceAll(decodeCharset(body, params["charset"]), "\r\n", "\n")
+		if t := strings.TrimSpace(text); t != "" {
+			w.texts = append(w.texts, t)
+		}
+	case mediaType == "text/html":
+		text := renderHTML(htmlMainContent(parseHTML(decodeCharset(body, params["charset"]))))
+		if t := strings.TrimSpace(text); t != "" {
+			w.texts = append(w.texts, t)
+		}
+	}
+}
+
+// alternative keeps the text/plain rendering when one exists, otherwise the
+// last non-empty alternative, which RFC 2046 orders as the richest.
+func (w *emlWalker) alternative(parts []emlPart, depth int) {
+	var chosen *emlWalker
+	plain := false
+	for _, sp := range parts {
+		sub := &emlWalker{}
+		sub.part(sp.header, sp.body, depth+1)
+		w.attachments = append(w.attachments, sub.attachments...)
+		if len(sub.texts) == 0 || plain {
+			continue
+		}
+		chosen = sub
+		mediaType, _, _ := mime.ParseMediaType(sp.header.Get("Content-Type"))
+		plain = mediaType == "text/plain" || mediaType == ""
+	}
+	if chosen != nil {
+		w.texts = append(w.texts, chosen.texts...)
+	}
+}
+
+type emlPart struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// emlMultipart splits a multipart body, keeping the parts read before any
+// malformed boundary.
+func emlMultipart(body []byte, boundary string) []emlPart {
+	if boundary == "" {
+		return nil
+	}
+	r := multipart.NewReader(bytes.NewReader(body), boundary)
+	var parts []emlPart
+	for {
+		// NextRawPart leaves Content-Transfer-Encoding for part to decode.
+		p, err := r.NextRawPart()
+		if err != nil {
+			return parts
+		}
+		data, err := io.ReadAll(p)
+		if err != nil {
+			return parts
+		}
+		parts = append(parts, emlPart{header: p.Header, body: data})
+	}
+}
+
+// emlTransferDecode reverses base64 and quoted-printable transfer
+// encodings, returning the input unchanged if it does not decode.
+func emlTransferDecode(body []byte, encoding string) []byte {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		clean := bytes.Map(func(r rune) rune {
+			if r == '\r' || r == '\n' || r == ' ' || r == '\t' {
+				return -1
+			}
+			return r
+		}, body)
+		out := make([]byte, base64.StdEncoding.DecodedLen(len(clean)))
+		n, err := base64.StdEncoding.Decode(out, clean)
+		if err != nil {
+			if n, err = base64.RawStdEncoding.Decode(out, bytes.TrimRight(clean, "=")); err != nil {
+				return body
+			}
+		}
+		return out[:n]
+	case "quoted-printable":
+		out, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(body)))
+		if err != nil && len(out) == 0 {
+			return body
+		}
+		return out
+	}
+	return body
+}
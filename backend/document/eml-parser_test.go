@@ -0,0 +1,61 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+const emlSample = "From: =?UTF-8?Q?Ada_L=C3=B6vlace?= <ada@example.com>\r\n" +
+	"To: bob@example.com, Carl <carl@example.com>\r\n" +
+	"Subject: Quarterly report\r\n" +
+	"Date: Mon, 1 Jan 2024 10:00:00 +0100\r\n" +
+	"Message-Id: <1@example.com>\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=iso-8859-1\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Numbers are attached, caf=E9 at ten.\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html\r\n" +
+	"\r\n" +
+	"<p>HTML version</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf; name=q1.pdf\r\n" +
+	"Content-Disposition: attachment; filename=q1.pdf\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0=\r\n" +
+	"--outer--\r\n"
+
+func TestEMLParser(t *testing.T) {
+	doc, err := NewEMLParser().Parse([]byte(emlSample), "a.eml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Subject: Quarterly report\nFrom: Ada Lövlace <ada@example.com>\nTo: bob@example.com, Carl <carl@example.com>\n" +
+		"Date: 2024-01-01T10:00:00+01:00\n\nNumbers are attached, café at ten."
+	if doc.Content != want {
+		t.Errorf("content %q, want %q", doc.Content, want)
+	}
+	if doc.Metadata["message_id"] != "<1@example.com>" || doc.Metadata["attachments"] != "q1.pdf" {
+		t.Errorf("metadata %v", doc.Metadata)
+	}
+	if len(doc.Attachments) != 1 || doc.Attachments[0].MimeType != "application/pdf" || string(doc.Attachments[0].Data) != "%PDF-" {
+		t.Errorf("attachments %+v", doc.Attachments)
+	}
+
+	html := "Subject: Hi\r\nContent-Type: text/html\r\n\r\n<html><body><nav>Menu</nav><p>Only <b>HTML</b> here.</p></body></html>"
+	doc, err = NewEMLParser().Parse([]byte(html), "b.eml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(doc.Content, "Only HTML here.") || strings.Contains(doc.Content, "Menu") {
+		t.Errorf("HTML body rendered as %q", doc.Content)
+	}
+}
@@ -0,0 +1,232 @@
+package document
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// EPUBParser extracts chapter text from EPUB ebooks in spine order.
+type EPUBParser struct {
+	// MaxDecompressedBytes limits the bytes read from all the parts of a
+	// package together, so a ZIP bomb fails with ErrTooLarge. Defaults to
+	// 256 MB.
+	MaxDecompressedBytes int64
+}
+
+// NewEPUBParser creates a new EPUB parser instance.
+func NewEPUBParser() *EPUBParser {
+	return &EPUBParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *EPUBParser) Supports(mimeType string) bool {
+	return mimeType == "application/epub+zip"
+}
+
+// epubPackage is the subset of the OPF package document we need.
+type epubPackage struct {
+	Metadata struct {
+		Title    []string `xml:"title"`
+		Creator  []string `xml:"creator"`
+		Language []string `xml:"language"`
+	} `xml:"metadata"`
+	Manifest []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+	Spine struct {
+		Toc   string `xml:"toc,attr"`
+		Items []struct {
+			IDRef  string `xml:"idref,attr"`
+			Linear string `xml:"linear,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+// Parse renders each spine document through the HTML renderer. Chapter
+// titles come from the table of contents when present and are recorded in
+// Document.Headings at the start of each chapter.
+func (p *EPUBParser) Parse(buffer []byte, filename string) (*Document, error) {
+	zr, err := openZip(buffer, p.MaxDecompressedBytes)
+	if err != nil {
+		return nil, err
+	}
+	opfPath, err := epubRootFile(zr)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readZipEntry(zr, opfPath)
+	if err != nil {
+		return nil, err
+	}
+	var pkg epubPackage
+	if err := xml.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", opfPath, err)
+	}
+
+	base := path.Dir(opfPath)
+	hrefs := map[string]string{}
+	navPath, ncxPath := "", ""
+	for _, item := range pkg.Manifest {
+		full := epubResolve(base, item.Href)
+		hrefs[item.ID] = full
+		if strings.Contains(item.Properties, "nav") {
+			navPath = full
+		}
+		if item.ID == pkg.Spine.Toc || item.MediaType == "application/x-dtbncx+xml" {
+			ncxPath = full
+		}
+	}
+	titles := epubTOC(zr, navPath, ncxPath)
+
+	var b strings.Builder
+	var headings []Heading
+	for _, ref := range pkg.Spine.Items {
+		if ref.Linear == "no" {
+			continue
+		}
+		file := hrefs[ref.IDRef]
+		if file == "" || file == navPath {
+			continue
+		}
+		xhtml, err := readZipEntry(zr, file)
+		if err != nil {
+			continue
+		}
+		root := parseHTML(string(xhtml))
+		text := renderHTML(htmlMainContent(root))
+		if text == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		title := titles[file]
+		if title == "" {
+			if h := root.find("h1"); h != nil {
+				title = h.textContent()
+			} else if h := root.find("h2"); h != nil {
+				title = h.textContent()
+			}
+		}
+		if title != "" {
+			headings = append(headings, Heading{Level: 1, Text: title, Offset: b.Len()})
+		}
+		b.WriteString(text)
+	}
+
+	if err := zr.err(); err != nil {
+		return nil, err
+	}
+	content := b.String()
+	if strings.TrimSpace(content) == "" {
+		return nil, ErrEmptyDocument
+	}
+	meta := map[string]string{}
+	if len(pkg.Metadata.Title) > 0 {
+		meta["title"] = strings.TrimSpace(pkg.Metadata.Title[0])
+	}
+	if len(pkg.Metadata.Creator) > 0 {
+		meta["author"] = strings.TrimSpace(strings.Join(pkg.Metadata.Creator, ", "))
+	}
+	if len(pkg.Metadata.Language) > 0 {
+		meta["language"] = strings.TrimSpace(pkg.Metadata.Language[0])
+	}
+	return &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Headings:  headings,
+		Sections:  BuildSections(headings, len(content)),
+		Metadata:  meta,
+	}, nil
+}
+
+// epubRootFile reads META-INF/container.xml to locate the OPF package.
+func epubRootFile(zr *zipPackage) (string, error) {
+	data, err := readZipEntry(zr, "META-INF/container.xml")
+	if err != nil {
+		return "", err
+	}
+	var container struct {
+		RootFiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := xml.Unmarshal(data, &container); err != nil {
+		return "", fmt.Errorf("parse container.xml: %w", err)
+	}
+	if len(container.RootFiles) == 0 || container.RootFiles[0].FullPath == "" {
+		return "", errors.New("epub container has no root file")
+	}
+	return container.RootFiles[0].FullPath, nil
+}
+
+// epubResolve joins a manifest href to the package directory, dropping any
+// fragment.
+func epubResolve(base, href string) string {
+	if i := strings.IndexByte(href, '#'); i >= 0 {
+		href = href[:i]
+	}
+	if u, err := url.PathUnescape(href); err == nil {
+		href = u
+	}
+	return path.Clean(path.Join(base, href))
+}
+
+// epubTOC maps content file paths to chapter titles, preferring the EPUB 3
+// navigation document over the legacy NCX.
+func epubTOC(zr *zipPackage, navPath, ncxPath string) map[string]string {
+	titles := map[string]string{}
+	add := func(base, href, label string) {
+		file := epubResolve(base, href)
+		if _, seen := titles[file]; !seen && label != "" {
+			titles[file] = label
+		}
+	}
+	if navPath != "" {
+		if data, err := readZipEntry(zr, navPath); err == nil {
+			root := parseHTML(string(data))
+			for _, nav := range root.findAll("nav") {
+				if t := nav.attrs["epub:type"]; t != "" && t != "toc" {
+					continue
+				}
+				for _, a := range nav.findAll("a") {
+					add(path.Dir(navPath), a.attrs["href"], a.textContent())
+				}
+			}
+		}
+	}
+	if len(titles) == 0 && ncxPath != "" {
+		if data, err := readZipEntry(zr, ncxPath); err == nil {
+			var ncx struct {
+				Points []epubNavPoint `xml:"navMap>navPoint"`
+			}
+			if xml.Unmarshal(data, &ncx) == nil {
+				var walk func([]epubNavPoint)
+				walk = func(points []epubNavPoint) {
+					for _, np := range points {
+						add(path.Dir(ncxPath), np.Content.Src, strings.TrimSpace(np.Label))
+						walk(np.Children)
+					}
+				}
+				walk(ncx.Points)
+			}
+		}
+	}
+	return titles
+}
+
+type epubNavPoint struct {
+	Label   string `xml:"navLabel>text"`
+	Content struct {
+		Src string `xml:"src,attr"`
+	} `xml:"content"`
+	Children []epubNavPoint `xml:"navPoint"`
+}
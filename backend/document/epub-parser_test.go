@@ -0,0 +1,56 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+// epubBook returns an EPUB whose spine lists the second chapter first and
+// skips the appendix, with chapter titles in the navigation document.
+func epubBook(t testing.TB) []byte {
+	return zipFiles(t,
+		"mimetype", "application/epub+zip",
+		"META-INF/container.xml", `<container><rootfiles><rootfile full-path="OEBPS/content.opf"/></rootfiles></container>`,
+		"OEBPS/content.opf", `<package><metadata><dc:title xmlns:dc="http://purl.org/dc/elements/1.1/">Book</dc:title>
+<dc:creator xmlns:dc="http://purl.org/dc/elements/1.1/">Ada</dc:creator><dc:language xmlns:dc="http://purl.org/dc/elements/1.1/">en</dc:language></metadata>
+<manifest><item id="nav" href="nav.xhtml" properties="nav"/><item id="c1" href="text/one.xhtml"/><item id="c2" href="text/two.xhtml"/><item id="app" href="text/appendix.xhtml"/></manifest>
+<spine><itemref idref="c2"/><itemref idref="c1"/><itemref idref="app" linear="no"/></spine></package>`,
+		"OEBPS/nav.xhtml", `<html><body><nav><ol><li><a href="text/one.xhtml">Chapter One</a></li><li><a href="text/two.xhtml#start">Chapter Two</a></li></ol></nav></body></html>`,
+		"OEBPS/text/one.xhtml", `<html><body><p>First chapter text.</p></body></html>`,
+		"OEBPS/text/two.xhtml", `<html><body><p>Second chapter text.</p></body></html>`,
+		"OEBPS/text/appendix.xhtml", `<html><body><p>Appendix text.</p></body></html>`,
+	)
+}
+
+func TestEPUBParser(t *testing.T) {
+	doc, err := NewEPUBParser().Parse(epubBook(t), "book.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, second := strings.Index(doc.Content, "First chapter"), strings.Index(doc.Content, "Second chapter")
+	if first < 0 || second < 0 || second > first {
+		t.Errorf("content %q is not in spine order", doc.Content)
+	}
+	if strings.Contains(doc.Content, "Appendix") {
+		t.Error("non-linear spine item included")
+	}
+	if len(doc.Headings) != 2 || doc.Headings[0].Text != "Chapter Two" || doc.Headings[1].Text != "Chapter One" {
+		t.Fatalf("headings %+v", doc.Headings)
+	}
+	if !strings.HasPrefix(doc.Content[doc.Headings[1].Offset:], "First chapter") {
+		t.Errorf("chapter heading offset %d does not start its chapter", doc.Headings[1].Offset)
+	}
+	for k, v := range map[string]string{"title": "Book", "author": "Ada", "language": "en"} {
+		if doc.Metadata[k] != v {
+			t.Errorf("metadata %s = %q, want %q", k, doc.Metadata[k], v)
+		}
+	}
+
+	fx, err := NewEPUBParser().Parse(mustRead(t, fixture("test-epub.epub")), "test-epub.epub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(fx.Content, "consectetur adipiscing elit") || len(fx.Headings) == 0 {
+		t.Errorf("fixture parsed to %d headings", len(fx.Headings))
+	}
+}
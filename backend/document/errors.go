@@ -0,0 +1,80 @@
+package document
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Errors that callers can test for with errors.Is.
+var (
+	// ErrEmptyDocument is returned when a document yields no text.
+	ErrEmptyDocument = errors.New("document content cannot be empty")
+	// ErrUnsupportedType is returned when no parser handles a document's
+	// MIME type.
+	ErrUnsupportedType = errors.New("unsupported document type")
+	// ErrTooLarge is returned when a document exceeds a size limit.
+	ErrTooLarge = errors.New("document too large")
+	// ErrInvalid is matched by a *ValidationError.
+	ErrInvalid = errors.New("validation failed")
+	// ErrWebhookQueueFull is reported to Webhook.OnError for events
+	// dropped because too many deliveries are waiting.
+	ErrWebhookQueueFull = errors.New("webhook queue is full")
+)
+
+// ParseError reports a parse failure along with the file, the parser and,
+// when known, where in the input it happened.
+type ParseError struct {
+	// Filename is the name the document was parsed under.
+	Filename string
+	// Parser names the parser, such as "PDFParser".
+	Parser string
+	// Offset is the byte offset in the input where parsing failed, or -1
+	// when unknown.
+	Offset int64
+	Err    error
+}
+
+func (e *ParseError) Error() string {
+	msg := "parse " + e.Filename
+	if e.Parser != "" {
+		msg += " (" + e.Parser + ")"
+	}
+	if e.Offset >= 0 {
+		msg += " at offset " + strconv.FormatInt(e.Offset, 10)
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// parseError wraps err from p parsing filename in a ParseError, taking the
+// offset from decoder errors that carry one. Errors that already are a
+// ParseError, and nil, are returned unchanged.
+func parseError(p Parser, filename string, err error) error {
+	var pe *ParseError
+	if err == nil || errors.As(err, &pe) {
+		return err
+	}
+	pe = &ParseError{Filename: filename, Offset: -1, Err: err}
+	if p != nil {
+		pe.Parser = parserName(p)
+	}
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	var xmlSyntax *xml.SyntaxError
+	switch {
+	case errors.As(err, &syntax):
+		pe.Offset = syntax.Offset
+	case errors.As(err, &typ):
+		pe.Offset = typ.Offset
+	case errors.As(err, &xmlSyntax):
+		// XML reports a line rather than an offset.
+		pe.Err = fmt.Errorf("line %d: %w", xmlSyntax.Line, err)
+	}
+	return pe
+}
@@ -0,0 +1,58 @@
+package document
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input, file string
+		is          error
+		parser      string
+		offset      int64
+	}{
+		{"", "a.txt", ErrEmptyDocument, "TextParser", -1},
+		{"   \n", "a.md", ErrEmptyDocument, "MarkdownParser", -1},
+		{`{"a": tru}`, "a.json", nil, "JSONParser", 10},
+	}
+	for _, tt := range tests {
+		_, err := ParseAuto([]byte(tt.input), tt.file)
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("%s: err = %v, want a *ParseError", tt.file, err)
+			continue
+		}
+		if tt.is != nil && !errors.Is(err, tt.is) {
+			t.Errorf("%s: err = %v, want %v", tt.file, err, tt.is)
+		}
+		if pe.Filename != tt.file || pe.Parser != tt.parser || pe.Offset != tt.offset {
+			t.Errorf("%s: ParseError = %+v", tt.file, pe)
+		}
+	}
+
+	if _, err := ParseAuto([]byte("\x00\x01\x02\xff"), "a.bin"); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("binary: err = %v, want ErrUnsupportedType", err)
+	}
+	if _, err := NewTextParser(WithMaxSize(2)).Parse([]byte("abc"), "a.txt"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("MaxSize: err = %v, want ErrTooLarge", err)
+	}
+	if !errors.Is(ErrArchiveLimit, ErrTooLarge) {
+		t.Error("ErrArchiveLimit does not wrap ErrTooLarge")
+	}
+}
+
+func TestParseErrorMessage(t *testing.T) {
+	err := &ParseError{Filename: "a.json", Parser: "JSONParser", Offset: 10, Err: errors.New("bad value")}
+	if got := err.Error(); got != "parse a.json (JSONParser) at offset 10: bad value" {
+		t.Errorf("Error() = %q", got)
+	}
+	// Wrapping an existing ParseError keeps it as it is.
+	if wrapped := parseError(NewTextParser(), "b.txt", fmt.Errorf("outer: %w", err)); !errors.As(wrapped, new(*ParseError)) || wrapped.Error() != "outer: "+err.Error() {
+		t.Errorf("parseError = %v", wrapped)
+	}
+	if parseError(nil, "a", nil) != nil {
+		t.Error("parseError of nil is not nil")
+	}
+}
@@ -0,0 +1,24 @@
+package document
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fixtureDir holds the sample files of the chunking scripts, which the
+// tests share as inputs.
+const fixtureDir = "../scripts/chunking/files"
+
+func fixture(name string) string {
+	return filepath.Join(fixtureDir, name)
+}
+
+func mustRead(t testing.TB, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
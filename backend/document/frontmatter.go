@@ -0,0 +1,266 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+)
+
+// splitFrontmatter separates a leading YAML ("---") or TOML ("+++")
+// frontmatter block from src. It returns the block verbatim, the remaining
+// body and the parsed fields, with nested keys joined by dots ("author.name")
+// and lists joined by ", ". ok is false when src has no frontmatter.
+func splitFrontmatter(src string) (raw, body string, fields map[string]string, ok bool) {
+	src = strings.TrimPrefix(src, "\ufeff")
+	var fence string
+	switch {
+	case strings.HasPrefix(src, "---\n"), strings.HasPrefix(src, "---\r\n"):
+		fence = "---"
+	case strings.HasPrefix(src, "+++\n"), strings.HasPrefix(src, "+++\r\n"):
+		fence = "+++"
+	default:
+		return "", src, nil, false
+	}
+
+	lines := strings.SplitAfter(src, "\n")
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+		if line != fence && !(fence == "---" && line == "...") {
+			continue
+		}
+		block := lines[1:i]
+		raw = strings.Join(lines[:i+1], "")
+		body = strings.Join(lines[i+1:], "")
+		if fence == "---" {
+			fields = parseYAMLFrontmatter(block)
+		} else {
+			fields = parseTOMLFrontmatter(block)
+		}
+		return raw, body, fields, true
+	}
+	return "", src, nil, false
+}
+
+// parseYAMLFrontmatter reads the YAML subset used in frontmatter: nested
+// mappings, block and flow sequences, quoted scalars and "|" or ">" block
+// scalars.
+func parseYAMLFrontmatter(lines []string) map[string]string {
+	fields := map[string]string{}
+	type level struct {
+		indent int
+		key    string
+	}
+	var stack []level
+	lists := map[string][]string{}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent && !(strings.HasPrefix(trimmed, "- ") || trimmed == "-") {
+			stack = stack[:len(stack)-1]
+		}
+		parent := ""
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1].key
+		}
+
+		if strings.HasPrefix(trimmed, "- ") || trimmed == "-" {
+			for len(stack) > 0 && stack[len(stack)-1].indent > indent {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1].key
+			}
+			if parent != "" {
+				item := strings.TrimSpace(strings.TrimPrefix(trimmed, "-"))
+				// "- name: x" items are flattened to their values.
+				if k, v, found := yamlKeyValue(item); found && v != "" {
+					item = k + ": " + v
+				}
+				if item = yamlScalar(item); item != "" {
+					lists[parent] = append(lists[parent], item)
+					fields[parent] = strings.Join(lists[parent], ", ")
+				}
+			}
+			continue
+		}
+
+		key, value, found := yamlKeyValue(trimmed)
+		if !found {
+			continue
+		}
+		key = strings.ToLower(key)
+		if parent != "" {
+			key = parent + "." + key
+		}
+		switch {
+		case value == "":
+			stack = append(stack, level{indent: indent, key: key})
+		case value == "|" || value == ">" || value == "|-" || value == ">-" || value == "|+" || value == ">+":
+			var text []string
+			for i+1 < len(lines) {
+				next := strings.TrimRight(lines[i+1], "\r\n")
+				nextIndent := len(next) - len(strings.TrimLeft(next, " \t"))
+				if strings.TrimSpace(next) != "" && nextIndent <= indent {
+					break
+				}
+				text = append(text, strings.TrimSpace(next))
+				i++
+			}
+			sep := "\n"
+			if value[0] == '>' {
+				sep = " "
+			}
+			fields[key] = strings.TrimSpace(strings.Join(text, sep))
+		case strings.HasPrefix(value, "["):
+			fields[key] = strings.Join(flowList(value), ", ")
+		default:
+			fields[key] = yamlScalar(value)
+		}
+	}
+	for k, v := range fields {
+		if v == "" {
+			delete(fields, k)
+		}
+	}
+	return fields
+}
+
+// yamlKeyValue splits "key: value", ignoring colons inside quotes.
+func yamlKeyValue(s string) (key, value string, ok bool) {
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 {
+				quote = c
+			}
+		case c == ':' && (i+1 == len(s) || s[i+1] == ' ' || s[i+1] == '\t'):
+			key = strings.Trim(strings.TrimSpace(s[:i]), `"'`)
+			return key, strings.TrimSpace(s[i+1:]), key != ""
+		}
+	}
+	return "", "", false
+}
+
+// yamlScalar unquotes a scalar and drops trailing comments.
+func yamlScalar(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') {
+		if end := strings.LastIndexByte(s, s[0]); end > 0 {
+			if s[0] == '"' {
+				if u, err := strconv.Unquote(s[:end+1]); err == nil {
+					return u
+				}
+			}
+			return strings.ReplaceAll(s[1:end], "''", "'")
+		}
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	if s == "~" || s == "null" {
+		return ""
+	}
+	return s
+}
+
+// flowList splits an inline "[a, 'b', c]" list.
+func flowList(s string) []string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	var items []string
+	var cur strings.Builder
+	quote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			cur.WriteByte(c)
+		case c == '"' || c == '\'':
+			quote = c
+			cur.WriteByte(c)
+		case c == ',':
+			if v := yamlScalar(cur.String()); v != "" {
+				items = append(items, v)
+			}
+			cur.Reset()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	if v := yamlScalar(cur.String()); v != "" {
+		items = append(items, v)
+	}
+	return items
+}
+
+// parseTOMLFrontmatter reads "key = value" pairs, [table] headers and
+// single- or multi-line arrays.
+func parseTOMLFrontmatter(lines []string) map[string]string {
+	fields := map[string]string{}
+	prefix := ""
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && !strings.Contains(line, "=") {
+			name := strings.Trim(line, "[] ")
+			prefix = strings.ToLower(name) + "."
+			continue
+		}
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			continue
+		}
+		key := prefix + strings.ToLower(strings.Trim(strings.TrimSpace(line[:eq]), `"'`))
+		value := strings.TrimSpace(line[eq+1:])
+		if strings.HasPrefix(value, "[") {
+			for strings.Count(value, "[") > strings.Count(value, "]") && i+1 < len(lines) {
+				i++
+				value += " " + strings.TrimSpace(lines[i])
+			}
+			if v := strings.Join(flowList(value), ", "); v != "" {
+				fields[key] = v
+			}
+			continue
+		}
+		if strings.HasPrefix(value, `"""`) || strings.HasPrefix(value, "'''") {
+			delim := value[:3]
+			text := strings.TrimPrefix(value, delim)
+			for !strings.Contains(text, delim) && i+1 < len(lines) {
+				i++
+				text += "\n" + strings.TrimRight(lines[i], "\r\n")
+			}
+			text, _, _ = strings.Cut(text, delim)
+			if text = strings.TrimSpace(text); text != "" {
+				fields[key] = text
+			}
+			continue
+		}
+		if strings.HasPrefix(value, "'") {
+			// Literal strings keep backslashes.
+			if end := strings.LastIndexByte(value, '\''); end > 0 {
+				value = value[1:end]
+			}
+		} else {
+			value = yamlScalar(value)
+		}
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	return fields
+}
@@ -0,0 +1,71 @@
+package document
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitFrontmatter(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		body  string
+		want  map[string]string
+	}{
+		{"yaml", "---\ntitle: \"Guide: part 1\"\nauthor:\n  name: Ada\ntags:\n  - go\n  - docs\nlangs: [en, fr]\nsummary: >\n  Short\n  text\n---\nBody\n",
+			"Body\n", map[string]string{"title": "Guide: part 1", "author.name": "Ada", "tags": "go, docs", "langs": "en, fr", "summary": "Short text"}},
+		{"toml", "+++\ntitle = 'Guide'\ndraft = false\n[author]\nname = \"Ada\"\n+++\nBody", "Body",
+			map[string]string{"title": "Guide", "draft": "false", "author.name": "Ada"}},
+		{"none", "--- not a fence\n", "--- not a fence\n", nil},
+		{"unclosed", "---\ntitle: x\n", "---\ntitle: x\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, body, fields, ok := splitFrontmatter(tt.input)
+			if body != tt.body || ok != (tt.want != nil) {
+				t.Errorf("body %q, ok %v", body, ok)
+			}
+			if tt.want != nil && !reflect.DeepEqual(fields, tt.want) {
+				t.Errorf("fields %v, want %v", fields, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrontmatterParsers(t *testing.T) {
+	const src = "---\ntitle: From frontmatter\n---\n# Heading\n\nText.\n"
+	doc, err := NewMarkdownParser().Parse([]byte(src), "a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Metadata["title"] != "From frontmatter" || strings.Contains(doc.Content, "---") {
+		t.Errorf("markdown: metadata %v, content %q", doc.Metadata, doc.Content)
+	}
+	if len(doc.Headings) != 1 || !strings.HasPrefix(doc.Content[doc.Headings[0].Offset:], "# Heading") {
+		t.Errorf("markdown headings %+v", doc.Headings)
+	}
+
+	doc, err = (&MarkdownParser{KeepFrontmatter: true}).Parse([]byte(src), "a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(doc.Content, "---\ntitle") || !strings.HasPrefix(doc.Content[doc.Headings[0].Offset:], "# Heading") {
+		t.Errorf("markdown kept frontmatter: content %q, headings %+v", doc.Content, doc.Headings)
+	}
+
+	doc, err = NewTextParser().Parse([]byte(src), "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Metadata["title"] != "From frontmatter" || !strings.HasPrefix(doc.Content, "# Heading") {
+		t.Errorf("text: metadata %v, content %q", doc.Metadata, doc.Content)
+	}
+	doc, err = (&TextParser{KeepFrontmatter: true}).Parse([]byte(src), "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Content != src {
+		t.Errorf("text kept frontmatter: content %q", doc.Content)
+	}
+}
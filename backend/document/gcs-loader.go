@@ -0,0 +1,90 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// GCSLoader loads objects from a Google Cloud Storage bucket over its
+// JSON API. Sources are object names.
+type GCSLoader struct {
+	Bucket string
+	// Token returns an OAuth 2.0 access token for each request, such as
+	// from golang.org/x/oauth2/google. Requests are unauthenticated when
+	// Token is nil, for public buckets.
+	Token func(ctx context.Context) (string, error)
+	// Endpoint defaults to https://storage.googleapis.com.
+	Endpoint string
+	// MaxRetries is the number of times a failed request is retried, with
+	// exponential backoff. Defaults to 3; negative turns retries off.
+	MaxRetries int
+	// Client defaults to an http.Client with a five minute timeout.
+	Client *http.Client
+}
+
+// NewGCSLoader creates a loader for bucket that authenticates with token.
+func NewGCSLoader(bucket string, token func(ctx context.Context) (string, error)) *GCSLoader {
+	return &GCSLoader{Bucket: bucket, Token: token}
+}
+
+// Load downloads the object named source.
+func (l *GCSLoader) Load(ctx context.Context, source string) ([]byte, string, error) {
+	data, err := l.get(ctx, "/o/"+url.PathEscape(source), url.Values{"alt": {"media"}})
+	if err != nil {
+		return nil, "", fmt.Errorf("get gcs object: %w", err)
+	}
+	return data, source, nil
+}
+
+// List returns the names of the objects whose names start with prefix.
+func (l *GCSLoader) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	query := url.Values{"prefix": {prefix}, "fields": {"items(name),nextPageToken"}}
+	for {
+		data, err := l.get(ctx, "/o", query)
+		if err != nil {
+			return nil, fmt.Errorf("list gcs objects: %w", err)
+		}
+		var page struct {
+			Items []struct {
+				Name string `json:"name"`
+			} `json:"items"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("decode gcs listing: %w", err)
+		}
+		for _, item := range page.Items {
+			if !strings.HasSuffix(item.Name, "/") {
+				names = append(names, item.Name)
+			}
+		}
+		if page.NextPageToken == "" {
+			return names, nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+// get sends a GET for path, relative to the bucket, with query.
+func (l *GCSLoader) get(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	endpoint := strings.TrimSuffix(l.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://storage.googleapis.com"
+	}
+	header := http.Header{}
+	if l.Token != nil {
+		token, err := l.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("get access token: %w", err)
+		}
+		header.Set("Authorization", "Bearer "+token)
+	}
+	u := endpoint + "/storage/v1/b/" + url.PathEscape(l.Bucket) + path + "?" + query.Encode()
+	return retryPolicy{maxRetries: l.MaxRetries}.send(ctx, httpClient(l.Client, 5*time.Minute), http.MethodGet, u, header, nil, "")
+}
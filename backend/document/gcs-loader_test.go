@@ -0,0 +1,65 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGCSLoader(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/storage/v1/b/docs/o":
+			if r.URL.Query().Get("pageToken") == "" {
+				w.Write([]byte(`{"items": [{"name": "notes/"}, {"name": "notes/a.txt"}], "nextPageToken": "p2"}`))
+			} else {
+				w.Write([]byte(`{"items": [{"name": "notes/b.txt"}]}`))
+			}
+		case "/storage/v1/b/docs/o/notes%2Fa.txt":
+			if r.URL.Query().Get("alt") != "media" {
+				http.Error(w, "metadata requested", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte("A"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	l := NewGCSLoader("docs", func(ctx context.Context) (string, error) { return "tok", nil })
+	l.Endpoint = srv.URL
+	ctx := context.Background()
+	names, err := l.List(ctx, "notes/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"notes/a.txt", "notes/b.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names %q, want %q", names, want)
+	}
+	if len(requests) != 2 {
+		t.Errorf("listing took %d requests, want 2", len(requests))
+	}
+	data, name, err := l.Load(ctx, "notes/a.txt")
+	if err != nil || string(data) != "A" || name != "notes/a.txt" {
+		t.Errorf("Load = %q, %q, %v", data, name, err)
+	}
+
+	failing := NewGCSLoader("docs", func(ctx context.Context) (string, error) { return "", errors.New("no credentials") })
+	failing.Endpoint = srv.URL
+	if _, _, err := failing.Load(ctx, "notes/a.txt"); err == nil {
+		t.Error("token error returned no error")
+	}
+	if _, _, err := (&GCSLoader{Bucket: "docs", Endpoint: srv.URL}).Load(ctx, "notes/a.txt"); err == nil {
+		t.Error("unauthenticated request returned no error")
+	}
+}
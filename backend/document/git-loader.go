@@ -0,0 +1,244 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// GitLoader loads the files of a git repository for code search and
+// code-aware retrieval, using the git command. Sources are paths relative
+// to the repository root. Each document records the commit as its
+// Version and, in its metadata, as "commit", with the path as "path".
+type GitLoader struct {
+	// Repo is the path of a local repository or the URL of one to clone.
+	Repo string
+	// Ref is the branch or tag to clone. Defaults to the remote's default
+	// branch. Local repositories are read at their working tree.
+	Ref string
+	// Dir is where a remote repository is cloned. Defaults to a new
+	// temporary directory, which Close removes.
+	Dir string
+	// Include lists glob patterns, such as "**/*.go", of the files to
+	// list. Defaults to all files. "**" matches any number of directories.
+	Include []string
+	// Exclude lists glob patterns of files to leave out.
+	Exclude []string
+	// Command is the git executable. Empty means "git" on PATH.
+	Command string
+
+	mu     sync.Mutex
+	root   string
+	commit string
+	tmp    string
+}
+
+// NewGitLoader creates a loader for the repository at repo, a path or
+// URL.
+func NewGitLoader(repo string) *GitLoader {
+	return &GitLoader{Repo: repo}
+}
+
+// List returns the files under the directory prefix, or all files when it
+// is empty, that git tracks or would track: files ignored by .gitignore
+// are left out, as are those the Include and Exclude patterns reject.
+func (l *GitLoader) List(ctx context.Context, prefix string) ([]string, error) {
+	root, _, err := l.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"ls-files", "-z", "--cached", "--others", "--exclude-standard"}
+	if prefix != "" {
+		args = append(args, "--", prefix)
+	}
+	out, err := l.git(ctx, root, args...)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(out, "\x00") {
+		if name == "" || seen[name] || !l.selects(name) {
+			continue
+		}
+		seen[name] = true
+		if info, err := os.Lstat(filepath.Join(root, filepath.FromSlash(name))); err != nil || !info.Mode().IsRegular() {
+			// Deleted files, submodules and symlinks have no content.
+			continue
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+// Load reads the file at source, relative to the repository root.
+func (l *GitLoader) Load(ctx context.Context, source string) ([]byte, string, error) {
+	f, err := l.Fetch(ctx, source)
+	if err != nil {
+		return nil, "", err
+	}
+	return f.Buffer, f.Filename, nil
+}
+
+// Fetch reads the file at source with its commit and path.
+func (l *GitLoader) Fetch(ctx context.Context, source string) (*Fetched, error) {
+	root, commit, err := l.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clean := path.Clean(strings.TrimPrefix(filepath.ToSlash(source), "/"))
+	if clean == ".." || strings.HasPrefix(clean, "../") {
+		return nil, fmt.Errorf("%s is outside the repository", source)
+	}
+	buffer, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(clean)))
+	if err != nil {
+		return nil, err
+	}
+	return &Fetched{
+		Buffer:   buffer,
+		Filename: clean,
+		URI:      l.Repo + "@" + commit + ":" + clean,
+		Version:  commit,
+		Metadata: map[string]string{"commit": commit, "path": clean},
+	}, nil
+}
+
+// Close removes a temporary clone.
+func (l *GitLoader) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.tmp == "" {
+		return nil
+	}
+	err := os.RemoveAll(l.tmp)
+	l.tmp, l.root, l.commit = "", "", ""
+	return err
+}
+
+// open clones the repository if it is remote, once, and returns its root
+// and HEAD commit.
+func (l *GitLoader) open(ctx context.Context) (string, string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.root != "" {
+		return l.root, l.commit, nil
+	}
+	root := l.Repo
+	if isRemoteRepo(l.Repo) {
+		dir := l.Dir
+		if dir == "" {
+			tmp, err := os.MkdirTemp("", "git-loader-")
+			if err != nil {
+				return "", "", err
+			}
+			dir, l.tmp = tmp, tmp
+		}
+		args := []string{"clone", "--depth", "1", "--quiet"}
+		if l.Ref != "" {
+			args = append(args, "--branch", l.Ref)
+		}
+		if _, err := l.git(ctx, "", append(args, "--", l.Repo, dir)...); err != nil {
+			return "", "", err
+		}
+		root = dir
+	} else {
+		top, err := l.git(ctx, l.Repo, "rev-parse", "--show-toplevel")
+		if err != nil {
+			return "", "", err
+		}
+		root = strings.TrimSpace(top)
+	}
+	commit, err := l.git(ctx, root, "rev-parse", "HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	l.root, l.commit = root, strings.TrimSpace(commit)
+	return l.root, l.commit, nil
+}
+
+// git runs the git command in dir and returns its output.
+func (l *GitLoader) git(ctx context.Context, dir string, args ...string) (string, error) {
+	command := l.Command
+	if command == "" {
+		command = "git"
+	}
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// selects reports whether name passes the Include and Exclude patterns.
+func (l *GitLoader) selects(name string) bool {
+	for _, p := range l.Exclude {
+		if matchGlob(p, name) {
+			return false
+		}
+	}
+	if len(l.Include) == 0 {
+		return true
+	}
+	for _, p := range l.Include {
+		if matchGlob(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRemoteRepo reports whether repo is a URL or scp-style address rather
+// than a local path.
+func isRemoteRepo(repo string) bool {
+	if strings.Contains(repo, "://") {
+		return true
+	}
+	// user@host:path, as long as it is not a Windows drive letter.
+	i := strings.IndexByte(repo, ':')
+	return i > 1 && !strings.ContainsAny(repo[:i], `/\`)
+}
+
+// matchGlob reports whether the slash-separated name matches pattern,
+// where "**" matches any number of path segments and the other
+// wildcards are those of path.Match. A pattern without a slash matches
+// the base name in any directory, as in .gitignore.
+func matchGlob(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
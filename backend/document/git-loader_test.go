@@ -0,0 +1,157 @@
+package document
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// gitRepo creates a repository with files, committed, and returns its
+// path.
+func gitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "--quiet", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", args[0], err, out)
+		}
+	}
+	return dir
+}
+
+func TestGitLoader(t *testing.T) {
+	dir := gitRepo(t, map[string]string{
+		".gitignore":        "build/\n",
+		"README.md":         "# Project\n",
+		"cmd/main.go":       "package main\n",
+		"internal/a/a.go":   "package a\n",
+		"internal/a/a_test": "test data\n",
+		"docs/guide.md":     "# Guide\n",
+	})
+	// An untracked file is listed, an ignored one is not.
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o644)
+	os.MkdirAll(filepath.Join(dir, "build"), 0o755)
+	os.WriteFile(filepath.Join(dir, "build", "out.go"), []byte("package out"), 0o644)
+	ctx := context.Background()
+
+	l := NewGitLoader(dir)
+	files, err := l.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	want := []string{".gitignore", "README.md", "cmd/main.go", "docs/guide.md", "internal/a/a.go", "internal/a/a_test", "notes.txt"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("files %q, want %q", files, want)
+	}
+
+	filtered := &GitLoader{Repo: dir, Include: []string{"**/*.go", "*.md"}, Exclude: []string{"cmd/**"}}
+	if files, err := filtered.List(ctx, ""); err != nil || !reflect.DeepEqual(files, []string{"README.md", "docs/guide.md", "internal/a/a.go"}) {
+		t.Errorf("filtered files %q, %v", files, err)
+	}
+	if files, err := l.List(ctx, "internal"); err != nil || len(files) != 2 {
+		t.Errorf("files under internal %q, %v", files, err)
+	}
+
+	f, err := l.Fetch(ctx, "/docs/guide.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Version) != 40 || f.Filename != "docs/guide.md" || f.Metadata["commit"] != f.Version ||
+		f.Metadata["path"] != "docs/guide.md" || !strings.HasSuffix(f.URI, "@"+f.Version+":docs/guide.md") {
+		t.Errorf("fetched %+v", f)
+	}
+	if _, err := l.Fetch(ctx, "../secret"); err == nil {
+		t.Error("path outside the repository returned no error")
+	}
+
+	var docs []*Document
+	p := NewPipeline().LoadWith(filtered).To(SinkFunc(func(ctx context.Context, doc *Document, chunks []Chunk) error {
+		docs = append(docs, doc)
+		return nil
+	}))
+	if err := p.RunPrefix(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 3 || docs[0].Version != f.Version || docs[1].Metadata["path"] != "docs/guide.md" {
+		t.Errorf("documents %+v", docs)
+	}
+}
+
+func TestGitLoaderClone(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"a.txt": "A"})
+	l := NewGitLoader("file://" + filepath.ToSlash(dir))
+	files, err := l.List(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(files, []string{"a.txt"}) {
+		t.Errorf("files %q", files)
+	}
+	clone := l.tmp
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(clone); !os.IsNotExist(err) {
+		t.Errorf("clone %s left behind: %v", clone, err)
+	}
+	if _, err := NewGitLoader(filepath.Join(dir, "missing")).List(context.Background(), ""); err == nil {
+		t.Error("missing repository returned no error")
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.go", "a/b/c.go", true},
+		{"*.go", "a/b/c.md", false},
+		{"**/*.go", "c.go", true},
+		{"**/*.go", "a/b/c.go", true},
+		{"a/**", "a/b/c", true},
+		{"a/**/c", "a/c", true},
+		{"a/*/c", "a/b/b/c", false},
+		{"docs/*.md", "docs/x.md", true},
+		{"docs/*.md", "src/docs/x.md", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+	for repo, want := range map[string]bool{
+		"https://github.com/a/b": true,
+		"git@github.com:a/b":     true,
+		"C:/repos/b":             false,
+		"./repo":                 false,
+		"/srv/repo":              false,
+	} {
+		if got := isRemoteRepo(repo); got != want {
+			t.Errorf("isRemoteRepo(%q) = %v, want %v", repo, got, want)
+		}
+	}
+}
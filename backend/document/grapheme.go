@@ -0,0 +1,84 @@
+package document
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// graphemeStart moves offset i in text back to the start of the grapheme
+// cluster containing it, approximating the rules of Unicode UAX #29:
+// combining marks, variation selectors, emoji modifiers, zero-width
+// joiner sequences, regional indicator pairs, Hangul jamo and CRLF stay
+// together.
+func graphemeStart(text string, i int) int {
+	for i > 0 && i < len(text) && !graphemeBoundary(text, i) {
+		_, size := utf8.DecodeLastRuneInString(text[:i])
+		i -= size
+	}
+	return i
+}
+
+// graphemeBoundary reports whether a grapheme cluster may end at offset i.
+func graphemeBoundary(text string, i int) bool {
+	if i <= 0 || i >= len(text) {
+		return true
+	}
+	if !utf8.RuneStart(text[i]) {
+		return false
+	}
+	prev, _ := utf8.DecodeLastRuneInString(text[:i])
+	next, _ := utf8.DecodeRuneInString(text[i:])
+	switch {
+	case prev == '\r' && next == '\n':
+		return false
+	case prev == '\r' || prev == '\n' || next == '\r' || next == '\n':
+		return true
+	case graphemeExtend(next) || next == '\u200d':
+		return false
+	case prev == '\u200d' && (unicode.Is(unicode.So, next) || next >= 0x1f000):
+		return false
+	case isRegionalIndicator(prev) && isRegionalIndicator(next):
+		// Flags pair regional indicators from the start of the run.
+		n := 0
+		for j := i; j > 0; {
+			r, size := utf8.DecodeLastRuneInString(text[:j])
+			if !isRegionalIndicator(r) {
+				break
+			}
+			n++
+			j -= size
+		}
+		return n%2 == 0
+	}
+	return !hangulJoins(prev, next)
+}
+
+// graphemeExtend reports whether r attaches to the character before it.
+func graphemeExtend(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r >= 0xfe00 && r <= 0xfe0f || r >= 0xe0100 && r <= 0xe01ef ||
+		r >= 0x1f3fb && r <= 0x1f3ff || r >= 0xe0020 && r <= 0xe007f
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// hangulJoins reports whether two Hangul jamo or syllables form one
+// syllable block.
+func hangulJoins(prev, next rune) bool {
+	leading := func(r rune) bool { return r >= 0x1100 && r <= 0x115f || r >= 0xa960 && r <= 0xa97c }
+	vowel := func(r rune) bool { return r >= 0x1160 && r <= 0x11a7 || r >= 0xd7b0 && r <= 0xd7c6 }
+	trailing := func(r rune) bool { return r >= 0x11a8 && r <= 0x11ff || r >= 0xd7cb && r <= 0xd7fb }
+	syllable := prev >= 0xac00 && prev <= 0xd7a3
+	lv := syllable && (prev-0xac00)%28 == 0
+	switch {
+	case leading(prev):
+		return leading(next) || vowel(next) || next >= 0xac00 && next <= 0xd7a3
+	case vowel(prev) || lv:
+		return vowel(next) || trailing(next)
+	case trailing(prev) || syllable:
+		return trailing(next)
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package document
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGraphemeStart(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		at   int
+		want int
+	}{
+		{"combining accent", "ae\u0301", 2, 1},
+		{"skin tone", "ab\U0001F44D\U0001F3FD", 7, 2},
+		{"zero-width joiner", "\U0001F469\u200d\U0001F4BB", 8, 0},
+		{"second flag", "\U0001F1E9\U0001F1EA\U0001F1EB\U0001F1F7", 12, 8},
+		{"hangul jamo", "a\u1100\u1161\u11a8", 7, 1},
+		{"crlf", "a\r\n", 2, 1},
+		{"plain", "abc", 2, 2},
+	}
+	for _, tt := range tests {
+		if got := graphemeStart(tt.text, tt.at); got != tt.want {
+			t.Errorf("%s: graphemeStart(%q, %d) = %d, want %d", tt.name, tt.text, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestChunkBytes(t *testing.T) {
+	got := ChunkBytes("cafe\u0301 cafe\u0301", 5)
+	if want := []string{"caf", "e\u0301", "caf", "e\u0301"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ChunkBytes = %q, want %q", got, want)
+	}
+	flags := "\U0001F1E9\U0001F1EA\U0001F1EB\U0001F1F7\U0001F1EE\U0001F1F9"
+	if got := ChunkBytes(flags, 9); len(got) != 3 || got[1] != "\U0001F1EB\U0001F1F7" {
+		t.Errorf("flags split into %q", got)
+	}
+}
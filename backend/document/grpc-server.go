@@ -0,0 +1,291 @@
+package document
+
+//go:generate protoc --go_out=. --go_opt=module=github.com/ashish141199/agento.sh/backend/document --go-grpc_out=. --go-grpc_opt=module=github.com/ashish141199/agento.sh/backend/document document.proto
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/ashish141199/agento.sh/backend/document/documentpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/encoding/gzip" // accept gzip compressed requests
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServer implements the DocumentService of document.proto, so services
+// in other languages can parse, chunk and ingest with this package. Serve
+// it with Serve or ListenAndServeTLS, or register it on a grpc.Server of
+// your own with documentpb.RegisterDocumentServiceServer. Requests may be
+// gzip compressed. Ingest requests may name a uri for the pipeline's
+// loader only when AllowURIs is set.
+type GRPCServer struct {
+	documentpb.UnimplementedDocumentServiceServer
+
+	// Pipeline parses and chunks requests with its registry, transformers,
+	// chunking strategy and limits. Ingest runs the whole pipeline,
+	// including its embedder and sinks. Defaults to NewPipeline().
+	Pipeline *Pipeline
+	// Auth, when set, checks every call before it runs, such as
+	// GRPCBearerAuth. A call it fails ends with its error, which should
+	// carry a status such as codes.Unauthenticated.
+	Auth func(ctx context.Context) error
+	// MaxMessageSize limits request messages, in bytes, after
+	// decompression. Defaults to 100 MB.
+	MaxMessageSize int
+	// AllowURIs lets Ingest requests name sources for the pipeline's
+	// loader. It is off by default because the default FileLoader would
+	// let clients read any file the server can.
+	AllowURIs bool
+}
+
+// NewGRPCServer creates a server for p that authenticates calls with
+// auth, which may be nil.
+func NewGRPCServer(p *Pipeline, auth func(ctx context.Context) error) *GRPCServer {
+	return &GRPCServer{Pipeline: p, Auth: auth}
+}
+
+// GRPCBearerAuth returns a GRPCServer.Auth that rejects calls without
+// "authorization: Bearer" metadata holding one of tokens.
+func GRPCBearerAuth(tokens ...string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, v := range md.Get("authorization") {
+			if validBearer(v, tokens) {
+				return nil
+			}
+		}
+		return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+	}
+}
+
+// NewServer returns a grpc.Server with s registered on it, its message
+// size limit and Auth applied, and opts.
+func (s *GRPCServer) NewServer(opts ...grpc.ServerOption) *grpc.Server {
+	size := s.MaxMessageSize
+	if size <= 0 {
+		size = 100 << 20
+	}
+	opts = append([]grpc.ServerOption{grpc.MaxRecvMsgSize(size)}, opts...)
+	if s.Auth != nil {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				if err := s.Auth(ctx); err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}),
+			grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				if err := s.Auth(ss.Context()); err != nil {
+					return err
+				}
+				return handler(srv, ss)
+			}))
+	}
+	g := grpc.NewServer(opts...)
+	documentpb.RegisterDocumentServiceServer(g, s)
+	return g
+}
+
+// Serve serves gRPC on lis, with a server from NewServer, until it fails.
+// Connections are plaintext unless opts include grpc.Creds.
+func (s *GRPCServer) Serve(lis net.Listener, opts ...grpc.ServerOption) error {
+	return s.NewServer(opts...).Serve(lis)
+}
+
+// ListenAndServeTLS serves gRPC on the TCP address addr over TLS, with
+// the certificate and key in certFile and keyFile.
+func (s *GRPCServer) ListenAndServeTLS(addr, certFile, keyFile string) error {
+	creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(lis, grpc.Creds(creds))
+}
+
+func (s *GRPCServer) pipeline() *Pipeline {
+	if s.Pipeline == nil {
+		return NewPipeline()
+	}
+	return s.Pipeline
+}
+
+// Parse handles a ParseRequest.
+func (s *GRPCServer) Parse(ctx context.Context, req *documentpb.ParseRequest) (*documentpb.ParseResponse, error) {
+	src := &Fetched{Buffer: req.GetContent(), Filename: req.GetFilename(), MIMEType: req.GetMimeType()}
+	doc, err := s.pipeline().parse(ctx, src)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	return &documentpb.ParseResponse{Document: documentProto(doc)}, nil
+}
+
+// Chunk handles a ChunkRequest.
+func (s *GRPCServer) Chunk(ctx context.Context, req *documentpb.ChunkRequest) (*documentpb.ChunkResponse, error) {
+	p := s.pipeline()
+	strategy, opts := p.strategy, p.opts
+	if req.GetStrategy() != "" {
+		strategy = req.GetStrategy()
+	}
+	if req.GetChunkSize() != 0 {
+		opts.ChunkSize = int(req.GetChunkSize())
+	}
+	if req.GetOverlap() != 0 {
+		opts.Overlap = int(req.GetOverlap())
+	}
+	if _, err := LookupChunker(strategy); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var doc *Document
+	if req.GetDocument() != nil {
+		doc = documentFromProto(req.GetDocument())
+	} else {
+		src := &Fetched{Buffer: req.GetContent(), Filename: req.GetFilename(), MIMEType: req.GetMimeType()}
+		var err error
+		if doc, err = p.parse(ctx, src); err != nil {
+			return nil, grpcError(ctx, err)
+		}
+	}
+	chunks, err := ChunkContext(ctx, strategy, doc, opts)
+	if err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	if err := p.limits.checkChunks(doc.Source, len(chunks)); err != nil {
+		return nil, grpcError(ctx, err)
+	}
+	resp := &documentpb.ChunkResponse{Chunks: make([]*documentpb.Chunk, len(chunks))}
+	for i, c := range chunks {
+		resp.Chunks[i] = chunkProto(newJobChunk(doc, c))
+	}
+	return resp, nil
+}
+
+// Ingest handles the Ingest stream, answering each IngestRequest in turn
+// until the client closes its side. Its chunks are those Chunk returns,
+// after the pipeline's embedder has run.
+func (s *GRPCServer) Ingest(stream documentpb.DocumentService_IngestServer) error {
+	ctx := stream.Context()
+	p := s.pipeline()
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if req.GetUri() != "" && !s.AllowURIs {
+			return status.Error(codes.InvalidArgument, "uri is not allowed")
+		}
+		job := &Job{ID: req.GetId(), URI: req.GetUri(), Content: req.GetContent(), Filename: req.GetFilename(), Metadata: req.GetMetadata()}
+		result := p.runJob(ctx, job)
+		if ctx.Err() != nil {
+			return grpcError(ctx, ctx.Err())
+		}
+		resp := &documentpb.IngestResponse{
+			Id:      result.ID,
+			Source:  result.Source,
+			Version: result.Version,
+			Chunks:  make([]*documentpb.Chunk, len(result.Chunks)),
+			Error:   result.Error,
+		}
+		for i, c := range result.Chunks {
+			resp.Chunks[i] = chunkProto(c)
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// grpcError returns err as the status error a call ending with it fails
+// with.
+func grpcError(ctx context.Context, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	code := codes.Unknown
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	case errors.Is(err, ErrTooLarge):
+		code = codes.ResourceExhausted
+	case errors.Is(err, ErrUnsupportedType), errors.Is(err, ErrInvalid):
+		code = codes.InvalidArgument
+	}
+	return status.Error(code, err.Error())
+}
+
+// documentProto returns doc as a Document message.
+func documentProto(doc *Document) *documentpb.Document {
+	m := &documentpb.Document{
+		Content:   doc.Content,
+		Source:    storeSource(doc),
+		Metadata:  doc.Metadata,
+		Title:     doc.Info.Title,
+		Checksum:  doc.Checksum,
+		Version:   doc.Version,
+		WordCount: int32(doc.WordCount),
+		Parser:    doc.Provenance.Parser,
+	}
+	for _, h := range doc.Headings {
+		m.Headings = append(m.Headings, &documentpb.Heading{Level: int32(h.Level), Text: h.Text, Offset: int32(h.Offset)})
+	}
+	for _, p := range doc.Pages {
+		m.Pages = append(m.Pages, &documentpb.Page{Number: int32(p.Number), Start: int32(p.Start), End: int32(p.End)})
+	}
+	return m
+}
+
+// documentFromProto returns the document of a Document message.
+func documentFromProto(m *documentpb.Document) *Document {
+	doc := &Document{
+		Content:   m.GetContent(),
+		Source:    m.GetSource(),
+		Metadata:  m.GetMetadata(),
+		Checksum:  m.GetChecksum(),
+		Version:   m.GetVersion(),
+		WordCount: int(m.GetWordCount()),
+	}
+	doc.Info.Title = m.GetTitle()
+	doc.Provenance.Parser = m.GetParser()
+	for _, h := range m.GetHeadings() {
+		doc.Headings = append(doc.Headings, Heading{Level: int(h.GetLevel()), Text: h.GetText(), Offset: int(h.GetOffset())})
+	}
+	for _, p := range m.GetPages() {
+		doc.Pages = append(doc.Pages, Page{Number: int(p.GetNumber()), Start: int(p.GetStart()), End: int(p.GetEnd())})
+	}
+	return doc
+}
+
+// chunkProto returns c as a Chunk message.
+func chunkProto(c JobChunk) *documentpb.Chunk {
+	return &documentpb.Chunk{
+		Id:          c.ID,
+		Index:       int32(c.Index),
+		Text:        c.Text,
+		StartOffset: int32(c.StartOffset),
+		EndOffset:   int32(c.EndOffset),
+		StartLine:   int32(c.StartLine),
+		EndLine:     int32(c.EndLine),
+		TokenCount:  int32(c.TokenCount),
+		PageStart:   int32(c.PageStart),
+		PageEnd:     int32(c.PageEnd),
+		Section:     c.Section,
+		ParentId:    c.ParentID,
+		PrevId:      c.PrevID,
+		NextId:      c.NextID,
+		Embedding:   c.Embedding,
+		Metadata:    c.Metadata,
+	}
+}
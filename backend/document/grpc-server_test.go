@@ -0,0 +1,237 @@
+package document
+
+import (
+	"context"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ashish141199/agento.sh/backend/document/documentpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcClient serves s over an in-memory connection and returns a client
+// for it.
+func grpcClient(t *testing.T, s *GRPCServer) documentpb.DocumentServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := s.NewServer()
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return documentpb.NewDocumentServiceClient(conn)
+}
+
+// grpcIngest sends reqs on one Ingest stream and returns the responses.
+func grpcIngest(ctx context.Context, c documentpb.DocumentServiceClient, reqs ...*documentpb.IngestRequest) ([]*documentpb.IngestResponse, error) {
+	stream, err := c.Ingest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range reqs {
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	var out []*documentpb.IngestResponse
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+		out = append(out, resp)
+	}
+}
+
+func grpcChunkTexts(chunks []*documentpb.Chunk) []string {
+	var out []string
+	for _, c := range chunks {
+		out = append(out, c.GetText())
+	}
+	return out
+}
+
+func TestGRPCServer(t *testing.T) {
+	ctx := context.Background()
+	c := grpcClient(t, &GRPCServer{})
+	content := []byte("# Title\n\nFirst paragraph.\n\nSecond paragraph.\n")
+
+	parsed, err := c.Parse(ctx, &documentpb.ParseRequest{Content: content, Filename: "notes.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := parsed.GetDocument()
+	if doc.GetSource() != "notes.md" || !strings.Contains(doc.GetContent(), "Second paragraph.") || len(doc.GetHeadings()) != 1 ||
+		doc.GetChecksum() == "" || doc.GetParser() == "" {
+		t.Fatalf("parsed %v", doc)
+	}
+
+	chunked, err := c.Chunk(ctx, &documentpb.ChunkRequest{Document: doc, Strategy: "paragraph", ChunkSize: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if texts := grpcChunkTexts(chunked.GetChunks()); len(texts) < 2 || !strings.Contains(strings.Join(texts, " "), "First paragraph.") {
+		t.Errorf("chunks %q", texts)
+	}
+
+	resps, err := grpcIngest(ctx, c,
+		&documentpb.IngestRequest{Id: "one", Content: []byte("Uploaded text."), Filename: "a.txt"},
+		&documentpb.IngestRequest{Id: "two"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resps) != 2 || resps[0].GetId() != "one" || resps[0].GetError() != "" || resps[1].GetId() != "two" ||
+		resps[1].GetError() != "job has neither uri nor content" {
+		t.Fatalf("ingest responses %v", resps)
+	}
+	if texts := grpcChunkTexts(resps[0].GetChunks()); !reflect.DeepEqual(texts, []string{"Uploaded text."}) {
+		t.Errorf("ingested chunks %q", texts)
+	}
+
+	_, err = c.Chunk(ctx, &documentpb.ChunkRequest{Content: content, Strategy: "no-such-strategy"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unknown strategy: %v", err)
+	}
+	_, err = c.Parse(ctx, &documentpb.ParseRequest{Content: []byte{0, 1, 2, 3}, Filename: "a.bin"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unsupported type: %v", err)
+	}
+	_, err = grpcClient(t, &GRPCServer{MaxMessageSize: 10}).Parse(ctx, &documentpb.ParseRequest{Content: content, Filename: "notes.md"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("message over MaxMessageSize: %v", err)
+	}
+}
+
+// Ingest and Chunk describe the chunks of the same file alike, links and
+// sections included.
+func TestGRPCIngestMatchesChunk(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("# Guide\n\nIntro paragraph of the guide.\n\n## Setup\n\nFirst setup step.\n\nSecond setup step.\n")
+	c := grpcClient(t, &GRPCServer{Pipeline: NewPipeline().Chunker("hierarchical", WithChunkSize(8))})
+
+	chunked, err := c.Chunk(ctx, &documentpb.ChunkRequest{Content: content, Filename: "guide.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resps, err := grpcIngest(ctx, c, &documentpb.IngestRequest{Content: content, Filename: "guide.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ingested := resps[0].GetChunks()
+	if len(ingested) != len(chunked.GetChunks()) {
+		t.Fatalf("ingest returned %d chunks, chunk %d", len(ingested), len(chunked.GetChunks()))
+	}
+	var parents, linked, sections int
+	for i, want := range chunked.GetChunks() {
+		if !proto.Equal(ingested[i], want) {
+			t.Errorf("chunk %d: ingest %v, chunk %v", i, ingested[i], want)
+		}
+		if want.GetParentId() != "" {
+			parents++
+		}
+		if want.GetPrevId() != "" || want.GetNextId() != "" {
+			linked++
+		}
+		if want.GetSection() != "" {
+			sections++
+		}
+	}
+	if parents == 0 || linked == 0 || sections == 0 {
+		t.Errorf("%d chunks with parents, %d linked, %d with sections: %v", parents, linked, sections, chunked.GetChunks())
+	}
+}
+
+func TestProtoDocumentRoundTrip(t *testing.T) {
+	doc := &Document{
+		Content:   "Intro\nBody",
+		Source:    "a.pdf",
+		Metadata:  map[string]string{"lang": "en", "team": "docs"},
+		Checksum:  "abc",
+		Version:   "3",
+		Headings:  []Heading{{Level: 2, Text: "Intro", Offset: 0}},
+		Pages:     []Page{{Number: 1, Start: 0, End: 5}, {Number: 2, Start: 6, End: 10}},
+		WordCount: 2,
+	}
+	doc.Info.Title = "Report"
+	doc.Provenance.Parser = "pdf"
+	b, err := proto.Marshal(documentProto(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m documentpb.Document
+	if err := proto.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if got := documentFromProto(&m); !reflect.DeepEqual(got, doc) {
+		t.Errorf("decoded %+v\nwant %+v", got, doc)
+	}
+}
+
+func TestGRPCIngestURIs(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowURIs bool
+		req       *documentpb.IngestRequest
+		want      codes.Code
+	}{
+		{"uri rejected", false, &documentpb.IngestRequest{Uri: fixture("test-txt.txt")}, codes.InvalidArgument},
+		{"uri allowed", true, &documentpb.IngestRequest{Uri: fixture("test-txt.txt")}, codes.OK},
+		{"content", false, &documentpb.IngestRequest{Content: []byte("Uploaded text."), Filename: "upload.txt"}, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := grpcClient(t, &GRPCServer{AllowURIs: tt.allowURIs})
+			resps, err := grpcIngest(context.Background(), c, tt.req)
+			if status.Code(err) != tt.want {
+				t.Fatalf("Ingest = %v, want %v", err, tt.want)
+			}
+			if tt.want == codes.OK && (len(resps) != 1 || resps[0].GetError() != "" || len(resps[0].GetChunks()) == 0) {
+				t.Errorf("responses %v", resps)
+			}
+		})
+	}
+}
+
+func TestGRPCAuth(t *testing.T) {
+	c := grpcClient(t, NewGRPCServer(nil, GRPCBearerAuth("other", "token")))
+	req := &documentpb.ParseRequest{Content: []byte("Some text."), Filename: "a.txt"}
+	ctx := context.Background()
+
+	if _, err := c.Parse(ctx, req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("unauthenticated call: %v", err)
+	}
+	if _, err := c.Parse(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer wrong"), req); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("wrong token: %v", err)
+	}
+	if _, err := grpcIngest(ctx, c, &documentpb.IngestRequest{Content: []byte("text"), Filename: "a.txt"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("unauthenticated stream: %v", err)
+	}
+
+	authed := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer token")
+	if _, err := c.Parse(authed, req); err != nil {
+		t.Errorf("authenticated call: %v", err)
+	}
+	if _, err := grpcIngest(authed, c, &documentpb.IngestRequest{Content: []byte("text"), Filename: "a.txt"}); err != nil {
+		t.Errorf("authenticated stream: %v", err)
+	}
+}
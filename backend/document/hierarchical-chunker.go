@@ -0,0 +1,62 @@
+package document
+
+import "strconv"
+
+// HierarchicalChunker emits large parent chunks and the small child chunks
+// each one splits into, linked by Chunk.ParentID. Searching on children and
+// returning their parent gives precise matches with enough surrounding
+// context.
+type HierarchicalChunker struct {
+	// ParentSize is the maximum parent chunk size in tokens. Defaults to
+	// 1000.
+	ParentSize int
+	// ChildSize is the maximum child chunk size in tokens. Defaults to 200.
+	ChildSize int
+	// Tokenizer measures chunk sizes. Defaults to DefaultTokenizer.
+	Tokenizer Tokenizer
+}
+
+// NewHierarchicalChunker creates a new hierarchical chunker instance
+// configured by opts.
+func NewHierarchicalChunker(opts ...ChunkOption) *HierarchicalChunker {
+	o := NewOptions(opts...)
+	return &HierarchicalChunker{ChildSize: o.ChunkSize, ParentSize: 5 * o.ChunkSize, Tokenizer: o.Tokenizer}
+}
+
+// Chunk returns each parent chunk followed by its children. Parents have
+// IDs "p0", "p1", ... and children "p0.c0", "p0.c1", ...; metadata
+// "level" is "parent" or "child". A parent that fits in ChildSize has a
+// single child with the same text.
+func (c *HierarchicalChunker) Chunk(text string) []Chunk {
+	parentSize := c.ParentSize
+	if parentSize <= 0 {
+		parentSize = 1000
+	}
+	childSize := c.ChildSize
+	if childSize <= 0 {
+		childSize = 200
+	}
+	tok := c.Tokenizer
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	parents := &RecursiveSplitter{ChunkSize: parentSize, Tokenizer: tok}
+	children := &RecursiveSplitter{ChunkSize: childSize, Tokenizer: tok}
+
+	var out []Chunk
+	for i, p := range parents.spans(text) {
+		parentID := "p" + strconv.Itoa(i)
+		parent := spanChunks(text, []textSpan{p}, tok)[0]
+		parent.ID, parent.Index = parentID, len(out)
+		parent.Metadata = map[string]string{"level": "parent"}
+		out = append(out, parent)
+		for j, child := range spanChunks(text[p.start:p.end], children.spans(text[p.start:p.end]), tok) {
+			shiftChunk(&child, p.start)
+			child.ID = parentID + ".c" + strconv.Itoa(j)
+			child.ParentID, child.Index = parentID, len(out)
+			child.Metadata = map[string]string{"level": "child"}
+			out = append(out, child)
+		}
+	}
+	return out
+}
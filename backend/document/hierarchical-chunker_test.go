@@ -0,0 +1,23 @@
+package document
+
+import "testing"
+
+func TestHierarchicalChunker(t *testing.T) {
+	chunks := (&HierarchicalChunker{ParentSize: 6, ChildSize: 3}).Chunk("One two three. Four five six.\n\nSeven eight.")
+	want := []struct{ id, parent, text, level string }{
+		{"p0", "", "One two three. Four five six.", "parent"},
+		{"p0.c0", "p0", "One two three.", "child"},
+		{"p0.c1", "p0", "Four five six.", "child"},
+		{"p1", "", "Seven eight.", "parent"},
+		{"p1.c0", "p1", "Seven eight.", "child"},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks, want %d", len(chunks), len(want))
+	}
+	for i, c := range chunks {
+		w := want[i]
+		if c.ID != w.id || c.ParentID != w.parent || c.Text != w.text || c.Metadata["level"] != w.level {
+			t.Errorf("chunk %d = %s/%s %q %v, want %s/%s %q %s", i, c.ID, c.ParentID, c.Text, c.Metadata, w.id, w.parent, w.text, w.level)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+)
+
+// HTMLChunker splits an HTML page along its element tree. Headings,
+// paragraphs, list items, tables and preformatted blocks are never split;
+// neighbouring blocks share a chunk up to ChunkSize, and every heading
+// starts a new chunk.
+type HTMLChunker struct {
+	// ChunkSize is the maximum chunk size in tokens. A single element
+	// larger than this still becomes one chunk. Defaults to 200.
+	ChunkSize int
+	// Tokenizer measures chunk size. Defaults to DefaultTokenizer.
+	Tokenizer Tokenizer
+}
+
+// NewHTMLChunker creates a new HTML chunker instance configured by opts.
+func NewHTMLChunker(opts ...ChunkOption) *HTMLChunker {
+	o := NewOptions(opts...)
+	return &HTMLChunker{ChunkSize: o.ChunkSize, Tokenizer: o.Tokenizer}
+}
+
+// htmlAtomicTags are elements kept whole in one chunk.
+var htmlAtomicTags = map[string]bool{
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"p": true, "li": true, "table": true, "pre": true, "dt": true, "dd": true,
+	"blockquote": true, "figcaption": true, "caption": true, "address": true,
+}
+
+// htmlChunkBlock is one unsplittable piece of the page.
+type htmlChunkBlock struct {
+	text string
+	// path runs from the root element down to the block's element.
+	path []*htmlNode
+	// level is the heading level of h1-h6 blocks, or 0.
+	level   int
+	heading string
+}
+
+// Chunk parses buffer as HTML and returns the chunks of its main content,
+// as chosen by HTMLParser. Each chunk's metadata holds "path", the element
+// path shared by its blocks (such as "body > article > ul"), and, below a
+// heading, "heading_path" and "heading" as from MarkdownChunker.
+func (c *HTMLChunker) Chunk(buffer []byte) ([]Chunk, error) {
+	size := c.ChunkSize
+	if size <= 0 {
+		size = 200
+	}
+	tok := c.Tokenizer
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	root := parseHTML(decodeText(buffer))
+	var blocks []htmlChunkBlock
+	collectHTMLBlocks(htmlMainContent(root), &blocks)
+	if len(blocks) == 0 {
+		return nil, ErrEmptyDocument
+	}
+
+	var chunks []Chunk
+	var headings []Heading
+	var cur []htmlChunkBlock
+	// joined is the text of cur, and n its tokens.
+	var joined strings.Builder
+	n := 0
+	flush := func() {
+		if len(cur) == 0 {
+			return
+		}
+		path := cur[0].path
+		for _, b := range cur {
+			path = commonPath(path, b.path)
+		}
+		meta := map[string]string{"path": htmlPathString(path)}
+		if len(headings) > 0 {
+			names := make([]string, len(headings))
+			for i, h := range headings {
+				names[i] = h.Text
+			}
+			meta["heading_path"] = strings.Join(names, " > ")
+			meta["heading"] = names[len(names)-1]
+		}
+		text := joined.String()
+		chunks = append(chunks, Chunk{Index: len(chunks), Text: text, TokenCount: tok.Count(text), Metadata: meta})
+		cur, n = nil, 0
+		joined.Reset()
+	}
+	for _, b := range blocks {
+		if b.level > 0 {
+			flush()
+			for len(headings) > 0 && headings[len(headings)-1].Level >= b.level {
+				headings = headings[:len(headings)-1]
+			}
+			headings = append(headings, Heading{Level: b.level, Text: b.heading})
+		} else if len(cur) > 0 {
+			grown := joinedCount(tok, joined.String(), n, "\n\n", b.text)
+			if grown > size {
+				flush()
+			} else {
+				n = grown
+			}
+		}
+		if len(cur) == 0 {
+			n = tok.Count(b.text)
+		} else {
+			joined.WriteString("\n\n")
+		}
+		joined.WriteString(b.text)
+		cur = append(cur, b)
+	}
+	flush()
+	return chunks, nil
+}
+
+// collectHTMLBlocks appends the unsplittable blocks below n in document
+// order. Inline content directly inside a container becomes a block of its
+// own.
+func collectHTMLBlocks(n *htmlNode, blocks *[]htmlChunkBlock) {
+	var inline []*htmlNode
+	flushInline := func() {
+		if len(inline) == 0 {
+			return
+		}
+		if text := renderHTML(&htmlNode{tag: "p", children: inline}); text != "" {
+			if n.tag == "li" {
+				text = "- " + text
+			}
+			*blocks = append(*blocks, htmlChunkBlock{text: text, path: htmlElementPath(n)})
+		}
+		inline = nil
+	}
+	for _, c := range n.children {
+		switch {
+		case c.tag != "" && isHTMLBoilerplate(c):
+		case c.tag == "" || !htmlBlockTags[c.tag] && !htmlAtomicTags[c.tag] && c.tag != "ul" && c.tag != "ol":
+			inline = append(inline, c)
+		case htmlAtomicTags[c.tag] && (c.tag == "table" || c.tag == "pre" || !hasHTMLAtomic(c)):
+			flushInline()
+			text := renderHTML(c)
+			if c.tag == "li" && text != "" {
+				text = "- " + text
+			}
+			if text != "" {
+				b := htmlChunkBlock{text: text, path: htmlElementPath(c), level: htmlHeadingLevel(c.tag)}
+				if b.level > 0 {
+					b.heading = c.textContent()
+				}
+				*blocks = append(*blocks, b)
+			}
+		default:
+			flushInline()
+			collectHTMLBlocks(c, blocks)
+		}
+	}
+	flushInline()
+}
+
+// hasHTMLAtomic reports whether n contains an element kept whole on its
+// own, such as a list nested in a list item.
+func hasHTMLAtomic(n *htmlNode) bool {
+	for _, c := range n.children {
+		if c.tag != "" && (htmlAtomicTags[c.tag] || hasHTMLAtomic(c)) {
+			return true
+		}
+	}
+	return false
+}
+
+// htmlElementPath returns the elements from the root down to n.
+func htmlElementPath(n *htmlNode) []*htmlNode {
+	var path []*htmlNode
+	for ; n != nil && n.tag != "" && n.tag != "#document"; n = n.parent {
+		path = append(path, n)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// htmlPathString joins the tags of path with " > ", adding the id of
+// elements that have one, as in "div#content".
+func htmlPathString(path []*htmlNode) string {
+	names := make([]string, len(path))
+	for i, n := range path {
+		names[i] = n.tag
+		if id := n.attrs["id"]; id != "" {
+			names[i] += "#" + id
+		}
+	}
+	return strings.Join(names, " > ")
+}
+
+// commonPath returns the longest shared prefix of two element paths.
+func commonPath(a, b []*htmlNode) []*htmlNode {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
+
+// htmlHeadingLevel returns the level of an h1-h6 tag, or 0.
+func htmlHeadingLevel(tag string) int {
+	if len(tag) != 2 || tag[0] != 'h' {
+		return 0
+	}
+	level, _ := strconv.Atoi(tag[1:])
+	return level
+}
+
+// joinedCount returns the tokens of joined+sep+next, given the n tokens of
+// joined. Tokenizers whose counts add up across whitespace only count the
+// new text.
+func joinedCount(tok Tokenizer, joined string, n int, sep, next string) int {
+	switch tok.(type) {
+	case CharacterTokenizer, ByteTokenizer, WhitespaceTokenizer:
+		return n + tok.Count(sep) + tok.Count(next)
+	}
+	return tok.Count(joined + sep + next)
+}
@@ -0,0 +1,95 @@
+package document
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHTMLChunker(t *testing.T) {
+	page := "<html><body><nav><a href='/'>Home</a></nav><article><h1>Guide</h1><p>Intro text.</p>" +
+		"<h2>Steps</h2><ul><li>First step</li><li>Second step</li></ul><p>Done now.</p></article></body></html>"
+	type want struct{ text, path, headings string }
+	tests := []struct {
+		size int
+		want []want
+	}{
+		{0, []want{
+			{"# Guide\n\nIntro text.", "html > body > article", "Guide"},
+			{"## Steps\n\n- First step\n\n- Second step\n\nDone now.", "html > body > article", "Guide > Steps"},
+		}},
+		{3, []want{
+			{"# Guide", "html > body > article > h1", "Guide"},
+			{"Intro text.", "html > body > article > p", "Guide"},
+			{"## Steps", "html > body > article > h2", "Guide > Steps"},
+			{"- First step", "html > body > article > ul > li", "Guide > Steps"},
+			{"- Second step", "html > body > article > ul > li", "Guide > Steps"},
+			{"Done now.", "html > body > article > p", "Guide > Steps"},
+		}},
+	}
+	for _, tt := range tests {
+		chunks, err := (&HTMLChunker{ChunkSize: tt.size}).Chunk([]byte(page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunks) != len(tt.want) {
+			t.Fatalf("size %d: got %d chunks, want %d", tt.size, len(chunks), len(tt.want))
+		}
+		for i, c := range chunks {
+			w := tt.want[i]
+			if c.Text != w.text || c.Metadata["path"] != w.path || c.Metadata["heading_path"] != w.headings {
+				t.Errorf("size %d: chunk %d = %q %v, want %q at %q under %q", tt.size, i, c.Text, c.Metadata, w.text, w.path, w.headings)
+			}
+		}
+	}
+
+	if _, err := NewHTMLChunker().Chunk([]byte("<html><body></body></html>")); err == nil {
+		t.Error("empty page chunked")
+	}
+}
+
+func TestHTMLChunkerRepeatedBlocks(t *testing.T) {
+	page := "<html><body><h1>Items</h1>" +
+		"<h2>More</h2><p>Read more about this.</p>" +
+		"<h2>More</h2><p>Read more about this.</p></body></html>"
+	chunks, err := NewHTMLChunker(WithChunkSize(50)).Chunk([]byte(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssignChunkIDs("page.html", chunks)
+	seen := map[string]int{}
+	for _, c := range chunks {
+		if prev, ok := seen[c.ID]; ok {
+			t.Errorf("chunks %d and %d share ID %s", prev, c.Index, c.ID)
+		}
+		seen[c.ID] = c.Index
+	}
+}
+
+func TestHTMLChunkerFixture(t *testing.T) {
+	buffer, err := os.ReadFile(fixture("test-html.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{20, 100, 400} {
+		chunks, err := NewHTMLChunker(WithChunkSize(size)).Chunk(buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(chunks) == 0 {
+			t.Fatalf("size %d: no chunks", size)
+		}
+		for _, c := range chunks {
+			if c.TokenCount != DefaultTokenizer.Count(c.Text) {
+				t.Errorf("size %d: chunk %d counts %d tokens, has %d", size, c.Index, c.TokenCount, DefaultTokenizer.Count(c.Text))
+			}
+			// Only a chunk of one block may exceed the size.
+			if c.TokenCount > size && strings.Contains(c.Text, "\n\n") {
+				t.Errorf("size %d: chunk %d of several blocks has %d tokens", size, c.Index, c.TokenCount)
+			}
+			if c.Metadata["path"] == "" {
+				t.Errorf("size %d: chunk %d has no path", size, c.Index)
+			}
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// HTMLParser extracts readable text from HTML pages, dropping scripts,
+// styles and navigation boilerplate.
+type HTMLParser struct{}
+
+// NewHTMLParser creates a new HTML parser instance.
+func NewHTMLParser() *HTMLParser {
+	return &HTMLParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *HTMLParser) Supports(mimeType string) bool {
+	return mimeType == "text/html" || mimeType == "application/xhtml+xml"
+}
+
+// Parse renders the main content of the page as Markdown-flavoured text and
+// records the page title, language and meta tags in Document.Info.
+func (p *HTMLParser) Parse(buffer []byte, filename string) (*Document, error) {
+	root := parseHTML(decodeText(buffer))
+	content := renderHTML(htmlMainContent(root))
+	if content == "" {
+		return nil, ErrEmptyDocument
+	}
+
+	doc := &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+	}
+	info := htmlInfo(root)
+	if d := info.Properties["description"]; d != "" {
+		doc.Metadata = map[string]string{"description": d}
+	}
+	return doc.withInfo(info), nil
+}
+
+// htmlInfo reads the title, the <html lang> attribute and the <meta> tags.
+// Tags other than author, keywords and publication dates go to
+// Properties under their name or property, such as "description".
+func htmlInfo(root *htmlNode) DocumentInfo {
+	info := DocumentInfo{Title: htmlTitle(root)}
+	if h := root.find("html"); h != nil {
+		info.Language = strings.TrimSpace(h.attrs["lang"])
+	}
+	for _, m := range root.findAll("meta") {
+		name := strings.ToLower(m.attrs["name"])
+		if name == "" {
+			name = strings.ToLower(m.attrs["property"])
+		}
+		content := strings.TrimSpace(m.attrs["content"])
+		if name == "" || content == "" {
+			continue
+		}
+		switch name {
+		case "author", "dc.creator", "article:author":
+			if info.Author == "" {
+				info.Author = content
+			}
+		case "keywords":
+			info.Keywords = splitKeywords(content)
+		case "date", "dc.date", "dcterms.created", "article:published_time":
+			if info.Created.IsZero() {
+				info.Created = parseInfoDate(content)
+			}
+		case "last-modified", "dcterms.modified", "article:modified_time":
+			info.Modified = parseInfoDate(content)
+		default:
+			info.setProperty(name, content)
+		}
+	}
+	return info
+}
+
+// htmlTitle prefers <title>, then og:title, then the first <h1>.
+func htmlTitle(root *htmlNode) string {
+	if t := root.find("title"); t != nil {
+		if title := t.textContent(); title != "" {
+			return title
+		}
+	}
+	for _, m := range root.findAll("meta") {
+		if m.attrs["property"] == "og:title" || m.attrs["name"] == "twitter:title" {
+			if c := strings.TrimSpace(m.attrs["content"]); c != "" {
+				return c
+			}
+		}
+	}
+	if h := root.find("h1"); h != nil {
+		return h.textContent()
+	}
+	return ""
+}
+
+// htmlMainContent narrows the tree to <main> or a single <article> when the
+// page marks one up, falling back to <body>.
+func htmlMainContent(root *htmlNode) *htmlNode {
+	if m := root.find("main"); m != nil {
+		return m
+	}
+	if articles := root.findAll("article"); len(articles) == 1 {
+		return articles[0]
+	}
+	if b := root.find("body"); b != nil {
+		return b
+	}
+	return root
+}
+
+var htmlBoilerplateTags = map[string]bool{
+	"head": true, "script": true, "style": true, "noscript": true,
+	"iframe": true, "svg": true, "template": true, "nav": true,
+	"footer": true, "aside": true, "object": true, "embed": true,
+	"canvas": true, "select": true,
+}
+
+var htmlBoilerplateRoles = map[string]bool{
+	"navigation": true, "contentinfo": true, "complementary": true,
+	"search": true, "banner": true,
+}
+
+// isHTMLBoilerplate reports whether an element should be dropped entirely.
+func isHTMLBoilerplate(n *htmlNode) bool {
+	if htmlBoilerplateTags[n.tag] || htmlBoilerplateRoles[n.attrs["role"]] {
+		return true
+	}
+	_, hidden := n.attrs["hidden"]
+	return hidden || n.attrs["aria-hidden"] == "true"
+}
+
+var htmlBlockTags = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true,
+	"header": true, "blockquote": true, "figure": true, "figcaption": true,
+	"address": true, "details": true, "summary": true, "dl": true, "dt": true,
+	"dd": true, "form": true, "fieldset": true, "center": true, "body": true,
+	"hr": true, "tr": true, "caption": true, "legend": true,
+}
+
+// htmlBlock is one rendered block; consecutive tight blocks (list items)
+// are joined by a single newline instead of a blank line.
+type htmlBlock struct {
+	text  string
+	tight bool
+}
+
+// htmlRenderer flattens an element tree into text blocks.
+type htmlRenderer struct {
+	blocks []htmlBlock
+	inline strings.Builder
+	prefix string
+	tight  bool
+}
+
+// renderHTML converts an element tree into Markdown-flavoured text:
+// headings get "#" prefixes, list items "-" or "1." markers, tables
+// pipe-separated rows and preformatted blocks code fences.
+func renderHTML(n *htmlNode) string {
+	r := &htmlRenderer{}
+	r.node(n, 0)
+	r.flush()
+	var b strings.Builder
+	for i, blk := range r.blocks {
+		if i > 0 {
+			if blk.tight && r.blocks[i-1].tight {
+				b.WriteByte('\n')
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+		b.WriteString(blk.text)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// flush turns the pending inline text into a block.
+func (r *htmlRenderer) flush() {
+	var lines []string
+	for _, line := range strings.Split(r.inline.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	r.inline.Reset()
+	if len(lines) == 0 {
+		return
+	}
+	r.blocks = append(r.blocks, htmlBlock{text: r.prefix + strings.Join(lines, "\n"), tight: r.tight})
+	r.prefix = ""
+}
+
+func (r *htmlRenderer) block(text string, tight bool) {
+	r.flush()
+	if text != "" {
+		r.blocks = append(r.blocks, htmlBlock{text: text, tight: tight})
+	}
+}
+
+func (r *htmlRenderer) node(n *htmlNode, listDepth int) {
+	if n.tag == "" {
+		r.inline.WriteString(collapseSpace(n.text))
+		return
+	}
+	if isHTMLBoilerplate(n) {
+		return
+	}
+	switch n.tag {
+	case "br":
+		r.inline.WriteByte('\n')
+		return
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		if text := n.textContent(); text != "" {
+			level, _ := strconv.Atoi(n.tag[1:])
+			r.block(strings.Repeat("#", level)+" "+text, false)
+		}
+		return
+	case "pre":
+		if text := strings.Trim(preText(n), "\n"); strings.TrimSpace(text) != "" {
+			r.block("```\n"+text+"\n```", false)
+		}
+		return
+	case "table":
+		r.block(renderHTMLTable(n), false)
+		return
+	case "img":
+		if alt := strings.TrimSpace(n.attrs["alt"]); alt != "" {
+			r.inline.WriteString(" " + alt + " ")
+		}
+		return
+	case "ul", "ol":
+		r.flush()
+		ordered := n.tag == "ol"
+		num := 1
+		if start, err := strconv.Atoi(n.attrs["start"]); err == nil && ordered {
+			num = start
+		}
+		for _, c := range n.children {
+			if c.tag != "li" {
+				if c.tag != "" {
+					r.node(c, listDepth+1)
+				}
+				continue
+			}
+			marker := "- "
+			if ordered {
+				marker = strconv.Itoa(num) + ". "
+				num++
+			}
+			r.flush()
+			r.prefix = strings.Repeat("  ", listDepth) + marker
+			r.tight = true
+			for _, gc := range c.children {
+				r.node(gc, listDepth+1)
+			}
+			r.flush()
+			r.prefix = ""
+		}
+		r.tight = false
+		return
+	}
+
+	isBlock := htmlBlockTags[n.tag]
+	if isBlock {
+		r.flush()
+	}
+	for _, c := range n.children {
+		r.node(c, listDepth)
+	}
+	if isBlock {
+		r.flush()
+	}
+}
+
+// collapseSpace replaces each run of whitespace with a single space, keeping
+// a leading or trailing space so adjacent inline text stays separated.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// preText returns the verbatim text of a preformatted element.
+func preText(n *htmlNode) string {
+	var b strings.Builder
+	var walk func(*htmlNode)
+	walk = func(n *htmlNode) {
+		if n.tag == "" {
+			b.WriteString(n.text)
+			return
+		}
+		if n.tag == "br" {
+			b.WriteByte('\n')
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// renderHTMLTable renders each row as "a | b | c", ignoring nested table
+// structure beyond cell text.
+func renderHTMLTable(table *htmlNode) string {
+	var lines []string
+	var rows []*htmlNode
+	var collect func(*htmlNode)
+	collect = func(n *htmlNode) {
+		for _, c := range n.children {
+			switch c.tag {
+			case "tr":
+				rows = append(rows, c)
+			case "thead", "tbody", "tfoot":
+				collect(c)
+			}
+		}
+	}
+	collect(table)
+	if caption := table.find("caption"); caption != nil {
+		if text := caption.textContent(); text != "" {
+			lines = append(lines, text)
+		}
+	}
+	for _, row := range rows {
+		var cells []string
+		for _, c := range row.children {
+			if c.tag == "td" || c.tag == "th" {
+				cells = append(cells, strings.ReplaceAll(c.textContent(), "|", "/"))
+			}
+		}
+		if line := strings.TrimSpace(strings.Join(cells, " | ")); strings.Trim(line, "| ") != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
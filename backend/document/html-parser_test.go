@@ -0,0 +1,47 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLParser(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		title   string
+		want    []string
+		without []string
+	}{
+		{"boilerplate", `<html><head><title>Guide</title><style>p{color:red}</style><script>track()</script></head>
+<body><nav><a href="/">Home</a> | <a href="/about">About</a></nav>
+<main><h1>Install</h1><p>Run the installer.</p></main><footer>Copyright 2024</footer></body></html>`,
+			"Guide", []string{"Install", "Run the installer."}, []string{"track()", "color:red", "Copyright 2024", "About"}},
+		{"og title", `<html><head><meta property="og:title" content="Shared"></head><body><p>Text.</p></body></html>`,
+			"Shared", []string{"Text."}, nil},
+		{"h1 title", `<body><h1>Heading</h1><p>Text.</p></body>`, "Heading", []string{"Text."}, nil},
+		{"fixture", string(mustRead(t, fixture("test-html.html"))),
+			"Lorem ipsum dolor sit amet, consectetur adipiscing elit. Nunc ac faucibus odio.", []string{"consectetur adipiscing elit"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewHTMLParser().Parse([]byte(tt.input), "a.html")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.Metadata["title"] != tt.title {
+				t.Errorf("title = %q, want %q", doc.Metadata["title"], tt.title)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(doc.Content, want) {
+					t.Errorf("content %q does not contain %q", doc.Content, want)
+				}
+			}
+			for _, gone := range tt.without {
+				if strings.Contains(doc.Content, gone) {
+					t.Errorf("content %q keeps %q", doc.Content, gone)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package document
+
+import (
+	"html"
+	"strings"
+)
+
+// htmlNode is an element or text node of a parsed HTML tree. Text nodes
+// have an empty tag.
+type htmlNode struct {
+	tag      string
+	attrs    map[string]string
+	text     string
+	parent   *htmlNode
+	children []*htmlNode
+}
+
+var htmlVoidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+var htmlRawTextElements = map[string]bool{
+	"script": true, "style": true, "textarea": true, "title": true,
+	"noscript": true, "xmp": true, "iframe": true, "noembed": true,
+}
+
+// htmlImpliedEnd lists, for an opening tag, the open elements it closes
+// implicitly (e.g. a new <li> ends the previous one).
+var htmlImpliedEnd = map[string][]string{
+	"li":       {"li"},
+	"dt":       {"dt", "dd"},
+	"dd":       {"dt", "dd"},
+	"tr":       {"tr", "td", "th"},
+	"td":       {"td", "th"},
+	"th":       {"td", "th"},
+	"thead":    {"tbody", "tfoot"},
+	"tbody":    {"thead", "tbody", "tfoot"},
+	"tfoot":    {"thead", "tbody"},
+	"option":   {"option"},
+	"optgroup": {"optgroup", "option"},
+}
+
+// htmlClosesParagraph lists block elements that end an open <p>.
+var htmlClosesParagraph = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"div": true, "dl": true, "fieldset": true, "footer": true, "form": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "table": true, "ul": true,
+	"figure": true, "details": true,
+}
+
+// parseHTML builds a forgiving element tree from HTML source. It is not a
+// full HTML5 parser but copes with unclosed and misnested tags well enough
+// for text extraction.
+func parseHTML(src string) *htmlNode {
+	root := &htmlNode{tag: "#document"}
+	cur := root
+	lower := asciiLower(src)
+	i := 0
+	appendText := func(s string) {
+		if s == "" {
+			return
+		}
+		cur.children = append(cur.children, &htmlNode{text: html.UnescapeString(s), parent: cur})
+	}
+	for i < len(src) {
+		lt := strings.IndexByte(src[i:], '<')
+		if lt < 0 {
+			appendText(src[i:])
+			break
+		}
+		appendText(src[i : i+lt])
+		i += lt
+		rest := src[i:]
+
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				return root
+			}
+			i += 4 + end + 3
+			continue
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return root
+			}
+			i += end + 1
+			continue
+		case strings.HasPrefix(rest, "</"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return root
+			}
+			name := strings.ToLower(strings.TrimSpace(rest[2:end]))
+			if sp := strings.IndexAny(name, " \t\n\r/"); sp >= 0 {
+				name = name[:sp]
+			}
+			for n := cur; n != nil && n != root; n = n.parent {
+				if n.tag == name {
+					cur = n.parent
+					break
+				}
+			}
+			i += end + 1
+			continue
+		}
+
+		if len(rest) < 2 || !isASCIILetter(rest[1]) {
+			appendText("<")
+			i++
+			continue
+		}
+		name, attrs, selfClosing, n := readHTMLTag(rest)
+		i += n
+
+		for _, closed := range htmlImpliedEnd[name] {
+			if open := findOpenHTML(cur, closed); open != nil {
+				cur = open.parent
+				break
+			}
+		}
+		if htmlClosesParagraph[name] {
+			if open := findOpenHTML(cur, "p"); open != nil {
+				cur = open.parent
+			}
+		}
+
+		node := &htmlNode{tag: name, attrs: attrs, parent: cur}
+		cur.children = append(cur.children, node)
+		if htmlVoidElements[name] || selfClosing {
+			continue
+		}
+		if htmlRawTextElements[name] {
+			end := strings.Index(lower[i:], "</"+name)
+			if end < 0 {
+				end = len(src) - i
+			}
+			raw := src[i : i+end]
+			if name == "title" || name == "textarea" {
+				raw = html.UnescapeString(raw)
+			}
+			node.children = append(node.children, &htmlNode{text: raw, parent: node})
+			i += end
+			if gt := strings.IndexByte(src[i:], '>'); gt >= 0 {
+				i += gt + 1
+			}
+			continue
+		}
+		cur = node
+	}
+	return root
+}
+
+// htmlScopeBoundary lists, per tag, the ancestors past which an implied
+// end tag does not reach, so a nested list does not close its parent item.
+var htmlScopeBoundary = map[string]map[string]bool{
+	"li":       {"ul": true, "ol": true},
+	"dt":       {"dl": true},
+	"dd":       {"dl": true},
+	"tr":       {"table": true},
+	"td":       {"tr": true, "table": true},
+	"th":       {"tr": true, "table": true},
+	"thead":    {"table": true},
+	"tbody":    {"table": true},
+	"tfoot":    {"table": true},
+	"option":   {"select": true},
+	"optgroup": {"select": true},
+	"p":        {"td": true, "th": true, "table": true, "button": true, "li": true, "blockquote": true},
+}
+
+// findOpenHTML returns the nearest open ancestor with the given tag that is
+// within scope.
+func findOpenHTML(cur *htmlNode, tag string) *htmlNode {
+	for n := cur; n != nil && n.tag != "#document"; n = n.parent {
+		if n.tag == tag {
+			return n
+		}
+		if htmlScopeBoundary[tag][n.tag] {
+			return nil
+		}
+	}
+	return nil
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// asciiLower lower-cases ASCII letters only, so byte offsets are preserved.
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+// readHTMLTag parses "<name attr=value ...>" and returns the lower-cased
+// name, attributes, whether it was self-closing, and the bytes consumed.
+func readHTMLTag(s string) (string, map[string]string, bool, int) {
+	i := 1
+	start := i
+	for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' && s[i] != '/' {
+		i++
+	}
+	name := strings.ToLower(s[start:i])
+	attrs := map[string]string{}
+	selfClosing := false
+	for i < len(s) {
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		if s[i] == '>' {
+			i++
+			return name, attrs, selfClosing, i
+		}
+		if s[i] == '/' {
+			selfClosing = true
+			i++
+			continue
+		}
+		ks := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		key := strings.ToLower(s[ks:i])
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		val := ""
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isHTMLSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				q := s[i]
+				i++
+				vs := i
+				for i < len(s) && s[i] != q {
+					i++
+				}
+				val = s[vs:i]
+				if i < len(s) {
+					i++
+				}
+			} else {
+				vs := i
+				for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				val = s[vs:i]
+			}
+		}
+		if key != "" {
+			attrs[key] = html.UnescapeString(val)
+		}
+		selfClosing = false
+	}
+	return name, attrs, selfClosing, i
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// find returns the first descendant element with the given tag.
+func (n *htmlNode) find(tag string) *htmlNode {
+	for _, c := range n.children {
+		if c.tag == tag {
+			return c
+		}
+		if f := c.find(tag); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// findAll returns every descendant element with the given tag.
+func (n *htmlNode) findAll(tag string) []*htmlNode {
+	var out []*htmlNode
+	for _, c := range n.children {
+		if c.tag == tag {
+			out = append(out, c)
+		}
+		out = append(out, c.findAll(tag)...)
+	}
+	return out
+}
+
+// textContent concatenates all descendant text with whitespace collapsed.
+func (n *htmlNode) textContent() string {
+	var b strings.Builder
+	var walk func(*htmlNode)
+	walk = func(n *htmlNode) {
+		if n.tag == "" {
+			b.WriteString(n.text)
+			b.WriteByte(' ')
+			return
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,139 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// retryPolicy retries HTTP requests that fail with a network error, 429
+// or a 5xx status, with exponential backoff.
+type retryPolicy struct {
+	// maxRetries defaults to 3; a negative value turns retries off.
+	maxRetries int
+	// baseDelay is the first wait and doubles with each retry. Defaults to
+	// 500ms.
+	baseDelay time.Duration
+	// maxBody, when positive, fails responses whose body is larger than
+	// this many bytes with ErrTooLarge, without reading more of it.
+	maxBody int64
+	// prepare, when set, is called with each attempt's request before it
+	// is sent, such as to sign it.
+	prepare func(req *http.Request)
+}
+
+// httpError is returned by sendJSON for a non-2xx response.
+type httpError struct {
+	method, url, status, body string
+	code                      int
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("%s %s: %s: %s", e.method, e.url, e.status, e.body)
+}
+
+// sendJSON sends body as JSON with method to url, retrying as the policy
+// allows, and returns the body of the 2xx response. header is added to
+// every request.
+func (rp retryPolicy) sendJSON(ctx context.Context, client *http.Client, method, url string, header http.Header, body any) ([]byte, error) {
+	if body == nil {
+		return rp.send(ctx, client, method, url, header, nil, "")
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return rp.send(ctx, client, method, url, header, payload, "application/json")
+}
+
+// send works like sendJSON for a payload of the given content type, or no
+// body when payload is nil.
+func (rp retryPolicy) send(ctx context.Context, client *http.Client, method, url string, header http.Header, payload []byte, contentType string) ([]byte, error) {
+	maxRetries := rp.maxRetries
+	if maxRetries == 0 {
+		maxRetries = 3
+	}
+	maxRetries = max(maxRetries, 0)
+	delay := rp.baseDelay
+	if delay <= 0 {
+		delay = 500 * time.Millisecond
+	}
+	for attempt := 0; ; attempt++ {
+		data, wait, err := rp.sendOnce(ctx, client, method, url, header, payload, contentType)
+		if err == nil || wait < 0 || attempt == maxRetries {
+			return data, err
+		}
+		if wait == 0 {
+			wait = delay << attempt
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// sendOnce makes one request. On failure it also returns how long to wait
+// before retrying: 0 for the default backoff, or -1 when the failure is
+// not worth retrying.
+func (rp retryPolicy) sendOnce(ctx context.Context, client *http.Client, method, url string, header http.Header, payload []byte, contentType string) ([]byte, time.Duration, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, -1, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if rp.prepare != nil {
+		rp.prepare(req)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, -1, ctx.Err()
+		}
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 && rp.maxBody > 0 {
+		if resp.ContentLength > rp.maxBody {
+			return nil, -1, fmt.Errorf("%w: %s is %d bytes, over the limit of %d", ErrTooLarge, url, resp.ContentLength, rp.maxBody)
+		}
+		data, err := readAllLimited(resp.Body, rp.maxBody)
+		if errors.Is(err, ErrTooLarge) {
+			return nil, -1, fmt.Errorf("%w: %s is over the limit of %d bytes", ErrTooLarge, url, rp.maxBody)
+		}
+		return data, 0, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode/100 == 2 {
+		return data, 0, nil
+	}
+	err = &httpError{method: method, url: url, status: resp.Status, code: resp.StatusCode, body: strings.TrimSpace(string(data))}
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+		return nil, -1, err
+	}
+	var wait time.Duration
+	if s, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && s > 0 {
+		wait = time.Duration(s) * time.Second
+	}
+	return nil, wait, err
+}
@@ -0,0 +1,369 @@
+package document
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPService is an http.Handler serving the package over HTTP with JSON
+// responses:
+//
+//	POST /parse   parse the uploaded file and return the document
+//	POST /chunk   parse the uploaded file and return its chunks
+//	POST /ingest  run each uploaded file or uri through the pipeline
+//
+// Requests are multipart forms. Files are uploaded in "file" parts; the
+// part's Content-Type, unless application/octet-stream, picks the parser.
+// /chunk takes "strategy", "chunk_size" and "overlap" fields, defaulting
+// to the pipeline's, and /ingest takes a "metadata" field holding a JSON
+// object added to every document, and "uri" fields for sources the
+// pipeline's loader fetches when AllowURIs is set. Mount it under a
+// prefix with http.StripPrefix.
+type HTTPService struct {
+	// Pipeline parses and chunks requests with its registry, transformers,
+	// chunking strategy and limits. /ingest runs the whole pipeline,
+	// including its embedder and sinks. Defaults to NewPipeline().
+	Pipeline *Pipeline
+	// Auth, when set, wraps every handler, such as BearerAuth.
+	Auth func(http.Handler) http.Handler
+	// MaxUploadSize limits request bodies, in bytes. Defaults to 100 MB.
+	MaxUploadSize int64
+	// AllowURIs lets /ingest requests name sources for the pipeline's
+	// loader. It is off by default because the default FileLoader would
+	// let clients read any file the server can.
+	AllowURIs bool
+
+	once    sync.Once
+	handler http.Handler
+}
+
+// NewHTTPService creates a service for p that authenticates requests
+// with auth, which may be nil.
+func NewHTTPService(p *Pipeline, auth func(http.Handler) http.Handler) *HTTPService {
+	return &HTTPService{Pipeline: p, Auth: auth}
+}
+
+// BearerAuth returns middleware that rejects requests without an
+// "Authorization: Bearer" header holding one of tokens.
+func BearerAuth(tokens ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !validBearer(r.Header.Get("Authorization"), tokens) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeJSONError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// validBearer reports whether an Authorization value is "Bearer " and one
+// of tokens.
+func validBearer(authorization string, tokens []string) bool {
+	got, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		return false
+	}
+	ok = false
+	for _, t := range tokens {
+		// Compare every token, in constant time, so timing reveals
+		// nothing about them.
+		if subtle.ConstantTimeCompare([]byte(got), []byte(t)) == 1 {
+			ok = true
+		}
+	}
+	return ok
+}
+
+// ServeHTTP routes r to its handler.
+func (s *HTTPService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.once.Do(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("POST /parse", s.parse)
+		mux.HandleFunc("POST /chunk", s.chunk)
+		mux.HandleFunc("POST /ingest", s.ingest)
+		s.handler = mux
+		if s.Auth != nil {
+			s.handler = s.Auth(mux)
+		}
+	})
+	s.handler.ServeHTTP(w, r)
+}
+
+func (s *HTTPService) pipeline() *Pipeline {
+	if s.Pipeline == nil {
+		return NewPipeline()
+	}
+	return s.Pipeline
+}
+
+// uploadForm is a multipart request read into memory.
+type uploadForm struct {
+	files  []*Fetched
+	values map[string][]string
+}
+
+func (f *uploadForm) value(name string) string {
+	if v := f.values[name]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// readUpload reads the multipart form of r within MaxUploadSize.
+func (s *HTTPService) readUpload(w http.ResponseWriter, r *http.Request) (*uploadForm, error) {
+	maxSize := s.MaxUploadSize
+	if maxSize <= 0 {
+		maxSize = 100 << 20
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, &formError{err}
+	}
+	form := &uploadForm{values: map[string][]string{}}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			return nil, uploadError(err, maxSize)
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return nil, uploadError(err, maxSize)
+		}
+		if part.FormName() != "file" {
+			form.values[part.FormName()] = append(form.values[part.FormName()], string(data))
+			continue
+		}
+		f := &Fetched{Buffer: data, Filename: part.FileName(), FetchedAt: time.Now().UTC()}
+		if mediaType, _, err := mime.ParseMediaType(part.Header.Get("Content-Type")); err == nil &&
+			mediaType != "application/octet-stream" {
+			f.MIMEType = mediaType
+		}
+		form.files = append(form.files, f)
+	}
+}
+
+// uploadError reports a failure reading the form, as ErrTooLarge when the
+// body was over maxSize.
+func uploadError(err error, maxSize int64) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return fmt.Errorf("%w: request body is over the limit of %d bytes", ErrTooLarge, maxSize)
+	}
+	return &formError{err}
+}
+
+// formError is a request whose form could not be read.
+type formError struct {
+	err error
+}
+
+func (e *formError) Error() string {
+	return "read form: " + e.err.Error()
+}
+
+func (e *formError) Unwrap() error {
+	return e.err
+}
+
+// oneFile returns the only file of the request.
+func (s *HTTPService) oneFile(w http.ResponseWriter, r *http.Request) (*uploadForm, *Fetched, bool) {
+	form, err := s.readUpload(w, r)
+	if err != nil {
+		writeJSONError(w, httpStatus(err), err)
+		return nil, nil, false
+	}
+	if len(form.files) != 1 {
+		writeJSONError(w, http.StatusBadRequest, fmt.Errorf("expected one file part, got %d", len(form.files)))
+		return nil, nil, false
+	}
+	return form, form.files[0], true
+}
+
+// parse handles POST /parse.
+func (s *HTTPService) parse(w http.ResponseWriter, r *http.Request) {
+	_, src, ok := s.oneFile(w, r)
+	if !ok {
+		return
+	}
+	doc, err := s.pipeline().parse(r.Context(), src)
+	if err != nil {
+		writeJSONError(w, httpStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newHTTPDocument(doc))
+}
+
+// chunk handles POST /chunk.
+func (s *HTTPService) chunk(w http.ResponseWriter, r *http.Request) {
+	form, src, ok := s.oneFile(w, r)
+	if !ok {
+		return
+	}
+	p := s.pipeline()
+	strategy, opts := p.strategy, p.opts
+	if v := form.value("strategy"); v != "" {
+		strategy = v
+	}
+	for name, dst := range map[string]*int{"chunk_size": &opts.ChunkSize, "overlap": &opts.Overlap} {
+		if v := form.value(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid %s %q", name, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	if _, err := LookupChunker(strategy); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	doc, err := p.parse(r.Context(), src)
+	if err != nil {
+		writeJSONError(w, httpStatus(err), err)
+		return
+	}
+	chunks, err := ChunkContext(r.Context(), strategy, doc, opts)
+	if err == nil {
+		err = p.limits.checkChunks(src.Filename, len(chunks))
+	}
+	if err != nil {
+		writeJSONError(w, httpStatus(err), err)
+		return
+	}
+	resp := struct {
+		Source string     `json:"source"`
+		Chunks []JobChunk `json:"chunks"`
+	}{Source: storeSource(doc), Chunks: make([]JobChunk, len(chunks))}
+	for i, c := range chunks {
+		resp.Chunks[i] = newJobChunk(doc, c)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ingest handles POST /ingest. Each file or uri gets a result, in the
+// order sent, and one failing does not stop the others.
+func (s *HTTPService) ingest(w http.ResponseWriter, r *http.Request) {
+	form, err := s.readUpload(w, r)
+	if err != nil {
+		writeJSONError(w, httpStatus(err), err)
+		return
+	}
+	var metadata map[string]string
+	if v := form.value("metadata"); v != "" {
+		if err := json.Unmarshal([]byte(v), &metadata); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("invalid metadata: %w", err))
+			return
+		}
+	}
+	var jobs []Job
+	for _, f := range form.files {
+		jobs = append(jobs, Job{Content: f.Buffer, Filename: f.Filename, Metadata: metadata})
+	}
+	if len(form.values["uri"]) > 0 && !s.AllowURIs {
+		writeJSONError(w, http.StatusBadRequest, errors.New("uri fields are not allowed"))
+		return
+	}
+	for _, uri := range form.values["uri"] {
+		jobs = append(jobs, Job{URI: uri, Metadata: metadata})
+	}
+	if len(jobs) == 0 {
+		writeJSONError(w, http.StatusBadRequest, errors.New("no file or uri to ingest"))
+		return
+	}
+	p := s.pipeline()
+	resp := struct {
+		Results []*JobResult `json:"results"`
+	}{}
+	for i := range jobs {
+		if err := r.Context().Err(); err != nil {
+			return
+		}
+		resp.Results = append(resp.Results, p.runJob(r.Context(), &jobs[i]))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// httpDocument is the JSON form of a Document.
+type httpDocument struct {
+	Content   string            `json:"content"`
+	Source    string            `json:"source"`
+	Title     string            `json:"title,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Checksum  string            `json:"checksum,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	WordCount int               `json:"word_count"`
+	Parser    string            `json:"parser,omitempty"`
+	Headings  []httpHeading     `json:"headings,omitempty"`
+	Pages     []httpPage        `json:"pages,omitempty"`
+}
+
+type httpHeading struct {
+	Level  int    `json:"level"`
+	Text   string `json:"text"`
+	Offset int    `json:"offset"`
+}
+
+type httpPage struct {
+	Number int `json:"number"`
+	Start  int `json:"start"`
+	End    int `json:"end"`
+}
+
+func newHTTPDocument(doc *Document) *httpDocument {
+	out := &httpDocument{
+		Content:   doc.Content,
+		Source:    storeSource(doc),
+		Title:     doc.Info.Title,
+		Metadata:  doc.Metadata,
+		Checksum:  doc.Checksum,
+		Version:   doc.Version,
+		WordCount: doc.WordCount,
+		Parser:    doc.Provenance.Parser,
+	}
+	for _, h := range doc.Headings {
+		out.Headings = append(out.Headings, httpHeading{h.Level, h.Text, h.Offset})
+	}
+	for _, p := range doc.Pages {
+		out.Pages = append(out.Pages, httpPage{p.Number, p.Start, p.End})
+	}
+	return out
+}
+
+// httpStatus returns the response status for a failed request.
+func httpStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedType):
+		return http.StatusUnsupportedMediaType
+	case errors.As(err, new(*formError)):
+		return http.StatusBadRequest
+	}
+	return http.StatusUnprocessableEntity
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
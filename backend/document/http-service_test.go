@@ -0,0 +1,260 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// uploadPart is a part of a multipart request: a file when filename is
+// set, a field otherwise.
+type uploadPart struct {
+	name, filename, contentType, data string
+}
+
+// uploadRequest builds a multipart POST to path with parts.
+func uploadRequest(t *testing.T, path string, parts ...uploadPart) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, p := range parts {
+		header := textproto.MIMEHeader{}
+		if p.filename != "" {
+			header.Set("Content-Disposition", `form-data; name="`+p.name+`"; filename="`+p.filename+`"`)
+			contentType := p.contentType
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			header.Set("Content-Type", contentType)
+		} else {
+			header.Set("Content-Disposition", `form-data; name="`+p.name+`"`)
+		}
+		w, err := mw.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(p.data))
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, path, &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+// serveJSON serves r and decodes the JSON response.
+func serveJSON(t *testing.T, s http.Handler, r *http.Request) (int, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, r)
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("%s: Content-Type %q", r.URL.Path, ct)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("%s: %v: %s", r.URL.Path, err, rec.Body)
+	}
+	return rec.Code, out
+}
+
+func jsonKeys(m map[string]any) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+const uploadMarkdown = "# Guide\n\nFirst paragraph of the guide.\n\n## Setup\n\nSecond paragraph.\n"
+
+func TestHTTPServiceParse(t *testing.T) {
+	s := &HTTPService{}
+	code, doc := serveJSON(t, s, uploadRequest(t, "/parse", uploadPart{"file", "guide.md", "", uploadMarkdown}))
+	if code != http.StatusOK {
+		t.Fatalf("status %d: %v", code, doc)
+	}
+	if want := []string{"checksum", "content", "headings", "metadata", "parser", "source", "word_count"}; !reflect.DeepEqual(jsonKeys(doc), want) {
+		t.Errorf("document keys %q, want %q", jsonKeys(doc), want)
+	}
+	if doc["source"] != "guide.md" || doc["metadata"].(map[string]any)["title"] != "Guide" || !strings.Contains(doc["content"].(string), "Second paragraph.") {
+		t.Errorf("document %v", doc)
+	}
+	headings := doc["headings"].([]any)
+	if len(headings) != 2 || !reflect.DeepEqual(jsonKeys(headings[1].(map[string]any)), []string{"level", "offset", "text"}) ||
+		headings[1].(map[string]any)["text"] != "Setup" {
+		t.Errorf("headings %v", headings)
+	}
+
+	// The part's Content-Type picks the parser over the file name.
+	code, doc = serveJSON(t, s, uploadRequest(t, "/parse", uploadPart{"file", "page", "text/html", "<p>Hello <b>there</b></p>"}))
+	if code != http.StatusOK || !strings.Contains(doc["content"].(string), "Hello there") {
+		t.Errorf("HTML upload: %d %v", code, doc)
+	}
+}
+
+func TestHTTPServiceChunk(t *testing.T) {
+	s := &HTTPService{}
+	code, resp := serveJSON(t, s, uploadRequest(t, "/chunk",
+		uploadPart{"file", "guide.md", "", uploadMarkdown},
+		uploadPart{name: "strategy", data: "paragraph"},
+		uploadPart{name: "chunk_size", data: "8"},
+		uploadPart{name: "overlap", data: "0"}))
+	if code != http.StatusOK {
+		t.Fatalf("status %d: %v", code, resp)
+	}
+	if !reflect.DeepEqual(jsonKeys(resp), []string{"chunks", "source"}) || resp["source"] != "guide.md" {
+		t.Errorf("response %v", resp)
+	}
+	chunks := resp["chunks"].([]any)
+	if len(chunks) < 2 {
+		t.Fatalf("chunks %v", chunks)
+	}
+	for i, c := range chunks {
+		c := c.(map[string]any)
+		for _, key := range []string{"id", "index", "text", "start_offset", "end_offset", "token_count"} {
+			if _, ok := c[key]; !ok {
+				t.Errorf("chunk %d lacks %q: %v", i, key, c)
+			}
+		}
+		if c["index"] != float64(i) || c["id"] == "" {
+			t.Errorf("chunk %d: %v", i, c)
+		}
+		if _, ok := c["embedding"]; ok {
+			t.Errorf("chunk %d has an embedding", i)
+		}
+	}
+}
+
+func TestHTTPServiceIngest(t *testing.T) {
+	var stored []string
+	p := NewPipeline().To(SinkFunc(func(_ context.Context, doc *Document, chunks []Chunk) error {
+		stored = append(stored, doc.Source+" "+doc.Metadata["team"])
+		return nil
+	}))
+	s := NewHTTPService(p, nil)
+	code, resp := serveJSON(t, s, uploadRequest(t, "/ingest",
+		uploadPart{"file", "a.txt", "", "First upload."},
+		uploadPart{"file", "b.bin", "", "\x00\x01\x02\x03"},
+		uploadPart{"file", "c.md", "", "# C\n\nThird upload."},
+		uploadPart{name: "metadata", data: `{"team": "docs"}`}))
+	if code != http.StatusOK {
+		t.Fatalf("status %d: %v", code, resp)
+	}
+	results := resp["results"].([]any)
+	var sources []string
+	for _, r := range results {
+		sources = append(sources, r.(map[string]any)["source"].(string))
+	}
+	if !reflect.DeepEqual(sources, []string{"a.txt", "b.bin", "c.md"}) {
+		t.Errorf("results for %q", sources)
+	}
+	if failed := results[1].(map[string]any); failed["error"] == nil || failed["chunks"] != nil {
+		t.Errorf("unsupported upload result %v", failed)
+	}
+	if !reflect.DeepEqual(stored, []string{"a.txt docs", "c.md docs"}) {
+		t.Errorf("stored %q", stored)
+	}
+}
+
+func TestHTTPServiceErrors(t *testing.T) {
+	big := strings.Repeat("words ", 100)
+	tests := []struct {
+		name    string
+		service *HTTPService
+		req     func(t *testing.T) *http.Request
+		want    int
+	}{
+		{"too large for the limits", &HTTPService{Pipeline: NewPipeline().Limits(Limits{MaxInputBytes: 50})},
+			func(t *testing.T) *http.Request {
+				return uploadRequest(t, "/parse", uploadPart{"file", "a.txt", "", big})
+			},
+			http.StatusRequestEntityTooLarge},
+		{"too many chunks", &HTTPService{Pipeline: NewPipeline().Limits(Limits{MaxChunks: 1})},
+			func(t *testing.T) *http.Request {
+				return uploadRequest(t, "/chunk", uploadPart{"file", "a.md", "", uploadMarkdown}, uploadPart{name: "strategy", data: "paragraph"},
+					uploadPart{name: "chunk_size", data: "8"})
+			},
+			http.StatusRequestEntityTooLarge},
+		{"body over MaxUploadSize", &HTTPService{MaxUploadSize: 100},
+			func(t *testing.T) *http.Request {
+				return uploadRequest(t, "/parse", uploadPart{"file", "a.txt", "", big})
+			},
+			http.StatusRequestEntityTooLarge},
+		{"unsupported type", &HTTPService{},
+			func(t *testing.T) *http.Request {
+				return uploadRequest(t, "/parse", uploadPart{"file", "a.bin", "", "\x00\x01\x02\x03"})
+			},
+			http.StatusUnsupportedMediaType},
+		{"empty document", &HTTPService{},
+			func(t *testing.T) *http.Request {
+				return uploadRequest(t, "/chunk", uploadPart{"file", "a.html", "", "<html><body> </body></html>"})
+			},
+			http.StatusUnprocessableEntity},
+		{"no file", &HTTPService{},
+			func(t *testing.T) *http.Request {
+				return uploadRequest(t, "/parse", uploadPart{name: "strategy", data: "x"})
+			},
+			http.StatusBadRequest},
+		{"not multipart", &HTTPService{},
+			func(t *testing.T) *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/parse", strings.NewReader("{}"))
+			},
+			http.StatusBadRequest},
+		{"bad chunk size", &HTTPService{},
+			func(t *testing.T) *http.Request {
+				return uploadRequest(t, "/chunk", uploadPart{"file", "a.txt", "", "text"}, uploadPart{name: "chunk_size", data: "-1"})
+			},
+			http.StatusBadRequest},
+		{"unknown strategy", &HTTPService{},
+			func(t *testing.T) *http.Request {
+				return uploadRequest(t, "/chunk", uploadPart{"file", "a.txt", "", "text"}, uploadPart{name: "strategy", data: "nope"})
+			},
+			http.StatusBadRequest},
+		{"uri not allowed", &HTTPService{},
+			func(t *testing.T) *http.Request {
+				return uploadRequest(t, "/ingest", uploadPart{name: "uri", data: fixture("test-txt.txt")})
+			},
+			http.StatusBadRequest},
+		{"bad metadata", &HTTPService{},
+			func(t *testing.T) *http.Request {
+				return uploadRequest(t, "/ingest", uploadPart{"file", "a.txt", "", "text"}, uploadPart{name: "metadata", data: "[1]"})
+			},
+			http.StatusBadRequest},
+		{"unauthenticated", NewHTTPService(nil, BearerAuth("token")),
+			func(t *testing.T) *http.Request {
+				return uploadRequest(t, "/parse", uploadPart{"file", "a.txt", "", "text"})
+			},
+			http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, resp := serveJSON(t, tt.service, tt.req(t))
+			if code != tt.want {
+				t.Errorf("status %d, want %d: %v", code, tt.want, resp)
+			}
+			if msg, _ := resp["error"].(string); msg == "" {
+				t.Errorf("response without an error message: %v", resp)
+			}
+		})
+	}
+
+	r := uploadRequest(t, "/parse", uploadPart{"file", "a.txt", "", "Some text."})
+	r.Header.Set("Authorization", "Bearer token")
+	if code, resp := serveJSON(t, NewHTTPService(nil, BearerAuth("other", "token")), r); code != http.StatusOK {
+		t.Errorf("authenticated request: %d %v", code, resp)
+	}
+	r = uploadRequest(t, "/ingest", uploadPart{name: "uri", data: fixture("test-txt.txt")})
+	if code, resp := serveJSON(t, &HTTPService{AllowURIs: true}, r); code != http.StatusOK || resp["results"].([]any)[0].(map[string]any)["error"] != nil {
+		t.Errorf("allowed uri: %d %v", code, resp)
+	}
+}
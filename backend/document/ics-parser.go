@@ -0,0 +1,355 @@
+package document
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ICSParser reads iCalendar files and renders each event as a short text
+// block, keeping its start, end, location and attendees as metadata for
+// time-based retrieval.
+type ICSParser struct{}
+
+// NewICSParser creates a new iCalendar parser instance.
+func NewICSParser() *ICSParser {
+	return &ICSParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *ICSParser) Supports(mimeType string) bool {
+	return mimeType == "text/calendar" || mimeType == "application/ics"
+}
+
+// icsProperty is one content line, such as
+// "ATTENDEE;CN=Bob:mailto:bob@example.com".
+type icsProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// icsEvent is a rendered event and its metadata.
+type icsEvent struct {
+	start time.Time
+	text  string
+	meta  map[string]string
+}
+
+// Parse joins the events in start order, each under a "# summary" heading,
+// and records each in Document.Segments with its metadata.
+func (p *ICSParser) Parse(buffer []byte, filename string) (*Document, error) {
+	events, calMeta, err := parseICS(buffer)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	var headings []Heading
+	segments := make([]Segment, 0, len(events))
+	for _, e := range events {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		start := b.Len()
+		headings = append(headings, Heading{Level: 1, Text: e.meta["summary"], Offset: start})
+		b.WriteString(e.text)
+		segments = append(segments, Segment{Start: start, End: b.Len(), Metadata: e.meta})
+	}
+	content := b.String()
+	calMeta["events"] = strconv.Itoa(len(events))
+	return &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Headings:  headings,
+		Segments:  segments,
+		Metadata:  calMeta,
+	}, nil
+}
+
+// ParseAll returns one document per event. Metadata holds "summary",
+// "start" and "end" (RFC 3339, or a date for all-day events), "location",
+// "organizer", "attendees" (joined with "; "), "status", "uid" and
+// "rrule" when present.
+func (p *ICSParser) ParseAll(buffer []byte, filename string) ([]*Document, error) {
+	events, calMeta, err := parseICS(buffer)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]*Document, len(events))
+	for i, e := range events {
+		meta := map[string]string{}
+		for k, v := range calMeta {
+			meta[k] = v
+		}
+		for k, v := range e.meta {
+			meta[k] = v
+		}
+		docs[i] = &Document{
+			Content:   e.text,
+			Source:    filename,
+			WordCount: len(strings.Fields(e.text)),
+			Metadata:  meta,
+		}
+	}
+	return docs, nil
+}
+
+// parseICS reads the VEVENT and VTODO components of a calendar. Calendar
+// properties X-WR-CALNAME and X-WR-CALDESC are returned as "calendar" and
+// "description".
+func parseICS(buffer []byte) ([]icsEvent, map[string]string, error) {
+	src := decodeText(buffer)
+	// Long lines are folded onto lines starting with a space or tab.
+	src = strings.NewReplacer("\n ", "", "\n\t", "").Replace(src)
+
+	calMeta := map[string]string{}
+	defaultZone := ""
+	var events []icsEvent
+	var props []icsProperty
+	var stack []string
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		prop := parseICSLine(line)
+		switch prop.name {
+		case "BEGIN":
+			stack = append(stack, strings.ToUpper(prop.value))
+			if top := stack[len(stack)-1]; top == "VEVENT" || top == "VTODO" {
+				props = nil
+			}
+			continue
+		case "END":
+			if len(stack) == 0 {
+				continue
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if top == "VEVENT" || top == "VTODO" {
+				if e, ok := icsBuildEvent(props, defaultZone); ok {
+					events = append(events, e)
+				}
+			}
+			continue
+		}
+		if len(stack) == 0 {
+			continue
+		}
+		switch stack[len(stack)-1] {
+		case "VCALENDAR":
+			switch prop.name {
+			case "X-WR-CALNAME":
+				calMeta["calendar"] = prop.value
+			case "X-WR-CALDESC":
+				calMeta["description"] = prop.value
+			case "X-WR-TIMEZONE":
+				defaultZone = prop.value
+			}
+		case "VEVENT", "VTODO":
+			props = append(props, prop)
+		}
+	}
+	if len(events) == 0 {
+		return nil, nil, ErrEmptyDocument
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].start.Before(events[j].start) })
+	return events, calMeta, nil
+}
+
+// parseICSLine splits a content line into its name, parameters and
+// unescaped value.
+func parseICSLine(line string) icsProperty {
+	prop := icsProperty{params: map[string]string{}}
+	// The value starts at the first colon outside a quoted parameter.
+	quoted, colon := false, len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '"' {
+			quoted = !quoted
+		} else if line[i] == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	head := line[:colon]
+	if colon < len(line) {
+		prop.value = line[colon+1:]
+	}
+	parts := strings.Split(head, ";")
+	prop.name = strings.ToUpper(parts[0])
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			prop.params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	switch prop.name {
+	case "SUMMARY", "DESCRIPTION", "LOCATION", "COMMENT", "CATEGORIES", "X-WR-CALNAME", "X-WR-CALDESC":
+		prop.value = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";").Replace(prop.value)
+	}
+	return prop
+}
+
+func icsBuildEvent(props []icsProperty, defaultZone string) (icsEvent, bool) {
+	meta := map[string]string{}
+	var start, end time.Time
+	allDay := false
+	var duration string
+	var attendees []string
+	description := ""
+	for _, prop := range props {
+		switch prop.name {
+		case "SUMMARY":
+			meta["summary"] = strings.TrimSpace(prop.value)
+		case "DESCRIPTION":
+			description = strings.TrimSpace(prop.value)
+		case "LOCATION":
+			meta["location"] = strings.TrimSpace(prop.value)
+		case "UID":
+			meta["uid"] = prop.value
+		case "STATUS":
+			meta["status"] = strings.ToLower(prop.value)
+		case "RRULE":
+			meta["rrule"] = prop.value
+		case "CATEGORIES":
+			meta["categories"] = strings.Join(strings.Split(prop.value, ","), ", ")
+		case "URL":
+			meta["url"] = prop.value
+		case "ORGANIZER":
+			meta["organizer"] = icsPerson(prop)
+		case "ATTENDEE":
+			if a := icsPerson(prop); a != "" {
+				attendees = append(attendees, a)
+			}
+		case "DTSTART":
+			start, allDay = icsTime(prop, defaultZone)
+		case "DTEND", "DUE":
+			end, _ = icsTime(prop, defaultZone)
+		case "DURATION":
+			duration = prop.value
+		}
+	}
+	if end.IsZero() && !start.IsZero() {
+		if d, ok := icsDuration(duration); ok {
+			end = start.Add(d)
+		} else if allDay {
+			end = start.AddDate(0, 0, 1)
+		}
+	}
+	formatTime := func(t time.Time) string {
+		if allDay {
+			return t.Format("2006-01-02")
+		}
+		return t.Format(time.RFC3339)
+	}
+	if !start.IsZero() {
+		meta["start"] = formatTime(start)
+	}
+	if !end.IsZero() {
+		if allDay {
+			// DTEND is exclusive for all-day events.
+			end = end.AddDate(0, 0, -1)
+		}
+		meta["end"] = formatTime(end)
+	}
+	if allDay {
+		meta["all_day"] = "true"
+	}
+	if len(attendees) > 0 {
+		meta["attendees"] = strings.Join(attendees, "; ")
+	}
+	if meta["summary"] == "" && description == "" {
+		return icsEvent{}, false
+	}
+	if meta["summary"] == "" {
+		meta["summary"] = "Untitled event"
+	}
+
+	lines := []string{"# " + meta["summary"]}
+	if s := meta["start"]; s != "" {
+		when := s
+		if e := meta["end"]; e != "" && e != s {
+			when += " to " + e
+		}
+		lines = append(lines, "When: "+when)
+	}
+	for _, f := range []struct{ label, key string }{
+		{"Location", "location"}, {"Organizer", "organizer"}, {"Attendees", "attendees"},
+		{"Status", "status"}, {"Repeats", "rrule"}, {"Categories", "categories"},
+	} {
+		if v := meta[f.key]; v != "" {
+			lines = append(lines, f.label+": "+v)
+		}
+	}
+	text := strings.Join(lines, "\n")
+	if description != "" {
+		text += "\n\n" + description
+	}
+	return icsEvent{start: start, text: text, meta: meta}, true
+}
+
+// icsPerson formats an ORGANIZER or ATTENDEE as "Name <address>".
+func icsPerson(prop icsProperty) string {
+	addr := prop.value
+	if len(addr) >= 7 && strings.EqualFold(addr[:7], "mailto:") {
+		addr = addr[7:]
+	}
+	name := strings.TrimSpace(prop.params["CN"])
+	switch {
+	case name != "" && addr != "" && name != addr:
+		return name + " <" + addr + ">"
+	case name != "":
+		return name
+	}
+	return addr
+}
+
+// icsTime parses a DATE or DATE-TIME value in UTC ("Z"), the TZID zone or
+// the calendar's default zone. Zones the system does not know are read as
+// UTC.
+func icsTime(prop icsProperty, defaultZone string) (time.Time, bool) {
+	v := strings.TrimSpace(prop.value)
+	if prop.params["VALUE"] == "DATE" || len(v) == 8 {
+		t, err := time.Parse("20060102", v)
+		return t, err == nil
+	}
+	if strings.HasSuffix(v, "Z") {
+		t, _ := time.Parse("20060102T150405Z", v)
+		return t, false
+	}
+	loc := time.UTC
+	zone := prop.params["TZID"]
+	if zone == "" {
+		zone = defaultZone
+	}
+	if zone != "" {
+		if l, err := time.LoadLocation(strings.Trim(zone, "/")); err == nil {
+			loc = l
+		}
+	}
+	t, _ := time.ParseInLocation("20060102T150405", v, loc)
+	return t, false
+}
+
+var icsDurationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// icsDuration parses an RFC 5545 duration such as "PT1H30M" or "P1D".
+func icsDuration(s string) (time.Duration, bool) {
+	m := icsDurationPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil || s == "P" || s == "PT" {
+		return 0, false
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, u := range units {
+		if n, err := strconv.Atoi(m[i+2]); err == nil {
+			d += time.Duration(n) * u
+		}
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+	return d, true
+}
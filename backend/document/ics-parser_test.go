@@ -0,0 +1,57 @@
+package document
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const icsSample = "BEGIN:VCALENDAR\r\nX-WR-CALNAME:Team\r\n" +
+	"BEGIN:VEVENT\r\nUID:2@x\r\nSUMMARY:Retro\r\nDTSTART:20240105T150000Z\r\nDURATION:PT1H30M\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nUID:1@x\r\nSUMMARY:Kickoff\\, planning\r\nDESCRIPTION:Bring the\\nroadmap.\r\nLOCATION:Room 1\r\n" +
+	"DTSTART:20240102T100000Z\r\nDTEND:20240102T110000Z\r\nORGANIZER;CN=Ada:mailto:ada@example.com\r\n" +
+	"ATTENDEE;CN=Bob:mailto:bob@example.com\r\nATTENDEE:mailto:cy@exam\r\n ple.com\r\nRRULE:FREQ=WEEKLY\r\nEND:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\nSUMMARY:Holiday\r\nDTSTART;VALUE=DATE:20240110\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+func TestICSParser(t *testing.T) {
+	doc, err := NewICSParser().Parse([]byte(icsSample), "team.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Kickoff, planning\nWhen: 2024-01-02T10:00:00Z to 2024-01-02T11:00:00Z\nLocation: Room 1\n" +
+		"Organizer: Ada <ada@example.com>\nAttendees: Bob <bob@example.com>; cy@example.com\nRepeats: FREQ=WEEKLY\n\n" +
+		"Bring the\nroadmap.\n\n# Retro\nWhen: 2024-01-05T15:00:00Z to 2024-01-05T16:30:00Z\n\n# Holiday\nWhen: 2024-01-10"
+	if doc.Content != want {
+		t.Errorf("content %q, want %q", doc.Content, want)
+	}
+	if doc.Metadata["calendar"] != "Team" || doc.Metadata["events"] != "3" {
+		t.Errorf("metadata %v", doc.Metadata)
+	}
+	for i, seg := range doc.Segments {
+		if !strings.HasPrefix(doc.Content[seg.Start:seg.End], "# "+seg.Metadata["summary"]) || seg.Start != doc.Headings[i].Offset {
+			t.Errorf("segment %d does not start at its event", i)
+		}
+	}
+
+	docs, err := NewICSParser().ParseAll([]byte(icsSample), "team.ics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 3 {
+		t.Fatalf("ParseAll returned %d documents", len(docs))
+	}
+	kickoff := map[string]string{
+		"summary": "Kickoff, planning", "uid": "1@x", "location": "Room 1", "rrule": "FREQ=WEEKLY",
+		"start": "2024-01-02T10:00:00Z", "end": "2024-01-02T11:00:00Z",
+		"organizer": "Ada <ada@example.com>", "attendees": "Bob <bob@example.com>; cy@example.com",
+	}
+	for k, v := range kickoff {
+		if docs[0].Metadata[k] != v {
+			t.Errorf("%s = %q, want %q", k, docs[0].Metadata[k], v)
+		}
+	}
+	holiday := docs[2].Metadata
+	if !reflect.DeepEqual([]string{holiday["start"], holiday["end"], holiday["all_day"]}, []string{"2024-01-10", "2024-01-10", "true"}) {
+		t.Errorf("all-day event metadata %v", holiday)
+	}
+}
@@ -0,0 +1,365 @@
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// readImageMetadata collects the dimensions, EXIF, IPTC and PNG text
+// metadata of an image. Keys are "width", "height", "title",
+// "description", "keywords", "artist", "copyright", "camera", "taken",
+// "location" and "gps" ("lat,lon").
+func readImageMetadata(b []byte, mimeType string) map[string]string {
+	meta := map[string]string{}
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(b)); err == nil {
+		meta["width"] = fmt.Sprint(cfg.Width)
+		meta["height"] = fmt.Sprint(cfg.Height)
+	}
+	switch mimeType {
+	case "image/jpeg":
+		readJPEGMetadata(b, meta)
+	case "image/png":
+		readPNGMetadata(b, meta)
+	case "image/tiff":
+		readEXIF(b, meta)
+	case "image/webp":
+		readRIFFChunks(b[min(len(b), 12):], func(id string, data []byte) {
+			if id == "EXIF" {
+				readEXIF(bytes.TrimPrefix(data, []byte("Exif\x00\x00")), meta)
+			}
+		})
+	}
+	for k, v := range meta {
+		if v = strings.Join(strings.Fields(v), " "); v != "" {
+			meta[k] = v
+		} else {
+			delete(meta, k)
+		}
+	}
+	return meta
+}
+
+// setMeta stores v under key unless the key already has a value, so the
+// first source read wins.
+func setMeta(meta map[string]string, key, v string) {
+	if v = strings.TrimSpace(v); v != "" && meta[key] == "" {
+		meta[key] = v
+	}
+}
+
+// readJPEGMetadata reads the APP1 Exif and APP13 Photoshop (IPTC) segments
+// that precede the image data.
+func readJPEGMetadata(b []byte, meta map[string]string) {
+	for i := 2; i+4 <= len(b) && b[i] == 0xff; {
+		marker := b[i+1]
+		if marker == 0xd8 || marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			i += 2
+			continue
+		}
+		if marker == 0xda || marker == 0xd9 {
+			return
+		}
+		n := int(binary.BigEndian.Uint16(b[i+2:]))
+		if n < 2 || i+2+n > len(b) {
+			return
+		}
+		data := b[i+4 : i+2+n]
+		switch {
+		case marker == 0xe1 && bytes.HasPrefix(data, []byte("Exif\x00\x00")):
+			readEXIF(data[6:], meta)
+		case marker == 0xed && bytes.HasPrefix(data, []byte("Photoshop 3.0\x00")):
+			readPhotoshopResources(data[14:], meta)
+		case marker == 0xfe:
+			setMeta(meta, "description", string(data))
+		}
+		i += 2 + n
+	}
+}
+
+// readPNGMetadata reads the eXIf chunk and the tEXt, zTXt and iTXt text
+// chunks.
+func readPNGMetadata(b []byte, meta map[string]string) {
+	keys := map[string]string{
+		"title": "title", "description": "description", "comment": "description",
+		"author": "artist", "copyright": "copyright", "creation time": "taken",
+	}
+	for i := 8; i+12 <= len(b); {
+		n := int(binary.BigEndian.Uint32(b[i:]))
+		typ := string(b[i+4 : i+8])
+		if n < 0 || i+12+n > len(b) || typ == "IDAT" {
+			return
+		}
+		data := b[i+8 : i+8+n]
+		i += 12 + n
+
+		var key, text string
+		switch typ {
+		case "eXIf":
+			readEXIF(data, meta)
+			continue
+		case "tEXt":
+			key, text, _ = strings.Cut(string(data), "\x00")
+		case "zTXt":
+			k, rest, ok := bytes.Cut(data, []byte{0})
+			if !ok || len(rest) < 1 {
+				continue
+			}
+			key, text = string(k), inflateText(rest[1:])
+		case "iTXt":
+			k, rest, ok := bytes.Cut(data, []byte{0})
+			if !ok || len(rest) < 2 {
+				continue
+			}
+			compressed := rest[0] == 1
+			parts := bytes.SplitN(rest[2:], []byte{0}, 3)
+			if len(parts) < 3 {
+				continue
+			}
+			key, text = string(k), string(parts[2])
+			if compressed {
+				text = inflateText(parts[2])
+			}
+		default:
+			continue
+		}
+		if k := keys[strings.ToLower(key)]; k != "" {
+			setMeta(meta, k, text)
+		}
+	}
+}
+
+func inflateText(b []byte) string {
+	r, err := zlib.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return ""
+	}
+	defer r.Close()
+	out, _ := io.ReadAll(io.LimitReader(r, 1<<20))
+	return string(out)
+}
+
+// readRIFFChunks calls fn for each chunk of a RIFF body.
+func readRIFFChunks(b []byte, fn func(id string, data []byte)) {
+	for i := 0; i+8 <= len(b); {
+		n := int(binary.LittleEndian.Uint32(b[i+4:]))
+		if n < 0 || i+8+n > len(b) {
+			return
+		}
+		fn(string(b[i:i+4]), b[i+8:i+8+n])
+		i += 8 + n + n%2
+	}
+}
+
+// readPhotoshopResources finds the IPTC-NAA resource (0x0404) among the
+// 8BIM image resource blocks of a JPEG APP13 segment.
+func readPhotoshopResources(b []byte, meta map[string]string) {
+	for i := 0; i+12 <= len(b) && string(b[i:i+4]) == "8BIM"; {
+		id := binary.BigEndian.Uint16(b[i+4:])
+		// The resource name is a Pascal string padded to an even length.
+		nameLen := int(b[i+6]) + 1
+		nameLen += nameLen % 2
+		p := i + 6 + nameLen
+		if p+4 > len(b) {
+			return
+		}
+		size := int(binary.BigEndian.Uint32(b[p:]))
+		if size < 0 || p+4+size > len(b) {
+			return
+		}
+		if id == 0x0404 {
+			readIPTC(b[p+4:p+4+size], meta)
+		}
+		i = p + 4 + size + size%2
+	}
+}
+
+// readIPTC reads the application record (2) datasets of IPTC IIM data.
+func readIPTC(b []byte, meta map[string]string) {
+	var keywords []string
+	place := map[byte]string{}
+	for i := 0; i+5 <= len(b) && b[i] == 0x1c; {
+		record, dataset := b[i+1], b[i+2]
+		n := int(binary.BigEndian.Uint16(b[i+3:]))
+		if n&0x8000 != 0 || i+5+n > len(b) {
+			return
+		}
+		v := string(b[i+5 : i+5+n])
+		i += 5 + n
+		if record != 2 {
+			continue
+		}
+		switch dataset {
+		case 5, 105:
+			setMeta(meta, "title", v)
+		case 25:
+			keywords = append(keywords, strings.TrimSpace(v))
+		case 80:
+			setMeta(meta, "artist", v)
+		case 116:
+			setMeta(meta, "copyright", v)
+		case 120:
+			setMeta(meta, "description", v)
+		case 90, 95, 101:
+			place[dataset] = strings.TrimSpace(v)
+		}
+	}
+	if len(keywords) > 0 {
+		setMeta(meta, "keywords", strings.Join(keywords, ", "))
+	}
+	var parts []string
+	for _, ds := range []byte{90, 95, 101} {
+		if place[ds] != "" {
+			parts = append(parts, place[ds])
+		}
+	}
+	setMeta(meta, "location", strings.Join(parts, ", "))
+}
+
+// exifEntry is a decoded TIFF directory entry.
+type exifEntry struct {
+	typ   uint16
+	count int
+	data  []byte
+}
+
+// exifTypeSizes are the byte sizes of the TIFF field types.
+var exifTypeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+// readEXIF reads the descriptive tags of a TIFF structure: the first IFD
+// and its Exif and GPS sub-IFDs.
+func readEXIF(b []byte, meta map[string]string) {
+	if len(b) < 8 {
+		return
+	}
+	var bo binary.ByteOrder
+	switch string(b[:2]) {
+	case "II":
+		bo = binary.LittleEndian
+	case "MM":
+		bo = binary.BigEndian
+	default:
+		return
+	}
+	ifd := func(off uint32) map[uint16]exifEntry {
+		out := map[uint16]exifEntry{}
+		if off == 0 || int64(off)+2 > int64(len(b)) {
+			return out
+		}
+		n := int(bo.Uint16(b[off:]))
+		for j := 0; j < n; j++ {
+			p := int(off) + 2 + j*12
+			if p+12 > len(b) {
+				break
+			}
+			e := exifEntry{typ: bo.Uint16(b[p+2:]), count: int(bo.Uint32(b[p+4:]))}
+			size := exifTypeSizes[e.typ] * e.count
+			if size <= 0 || e.count > len(b) {
+				continue
+			}
+			start := p + 8
+			if size > 4 {
+				start = int(bo.Uint32(b[p+8:]))
+			}
+			if start < 0 || start+size > len(b) {
+				continue
+			}
+			e.data = b[start : start+size]
+			out[bo.Uint16(b[p:])] = e
+		}
+		return out
+	}
+	str := func(e exifEntry) string {
+		switch e.typ {
+		case 2:
+			s, _, _ := strings.Cut(string(e.data), "\x00")
+			return strings.TrimSpace(s)
+		case 1:
+			// Windows XP tags hold UTF-16LE text in BYTE fields.
+			units := make([]uint16, 0, len(e.data)/2)
+			for i := 0; i+1 < len(e.data); i += 2 {
+				if u := binary.LittleEndian.Uint16(e.data[i:]); u != 0 {
+					units = append(units, u)
+				}
+			}
+			return strings.TrimSpace(string(utf16.Decode(units)))
+		}
+		return ""
+	}
+	integer := func(e exifEntry) uint32 {
+		switch {
+		case e.typ == 3 && len(e.data) >= 2:
+			return uint32(bo.Uint16(e.data))
+		case (e.typ == 4 || e.typ == 9) && len(e.data) >= 4:
+			return bo.Uint32(e.data)
+		}
+		return 0
+	}
+	rational := func(e exifEntry, i int) float64 {
+		if e.typ != 5 || len(e.data) < (i+1)*8 {
+			return 0
+		}
+		num, den := bo.Uint32(e.data[i*8:]), bo.Uint32(e.data[i*8+4:])
+		if den == 0 {
+			return 0
+		}
+		return float64(num) / float64(den)
+	}
+
+	root := ifd(bo.Uint32(b[4:]))
+	setMeta(meta, "description", str(root[0x010e]))
+	setMeta(meta, "title", str(root[0x9c9b]))
+	setMeta(meta, "description", str(root[0x9c9c]))
+	setMeta(meta, "artist", str(root[0x013b]))
+	setMeta(meta, "artist", str(root[0x9c9d]))
+	setMeta(meta, "keywords", strings.ReplaceAll(str(root[0x9c9e]), ";", ", "))
+	setMeta(meta, "copyright", str(root[0x8298]))
+	camera := strings.TrimSpace(str(root[0x010f]) + " " + str(root[0x0110]))
+	if makeName := str(root[0x010f]); makeName != "" && strings.HasPrefix(str(root[0x0110]), makeName) {
+		camera = str(root[0x0110])
+	}
+	setMeta(meta, "camera", camera)
+	if w := integer(root[0x0100]); w > 0 {
+		setMeta(meta, "width", fmt.Sprint(w))
+		setMeta(meta, "height", fmt.Sprint(integer(root[0x0101])))
+	}
+
+	exif := ifd(integer(root[0x8769]))
+	taken := str(exif[0x9003])
+	if taken == "" {
+		taken = str(root[0x0132])
+	}
+	if t, err := time.Parse("2006:01:02 15:04:05", taken); err == nil {
+		taken = t.Format("2006-01-02T15:04:05")
+	}
+	setMeta(meta, "taken", taken)
+	if w := integer(exif[0xa002]); w > 0 {
+		setMeta(meta, "width", fmt.Sprint(w))
+		setMeta(meta, "height", fmt.Sprint(integer(exif[0xa003])))
+	}
+
+	gps := ifd(integer(root[0x8825]))
+	coord := func(e exifEntry, ref string, neg string) (float64, bool) {
+		if e.count < 3 {
+			return 0, false
+		}
+		v := rational(e, 0) + rational(e, 1)/60 + rational(e, 2)/3600
+		if strings.EqualFold(ref, neg) {
+			v = -v
+		}
+		return v, true
+	}
+	lat, ok1 := coord(gps[2], str(gps[1]), "S")
+	lon, ok2 := coord(gps[4], str(gps[3]), "W")
+	if ok1 && ok2 && (lat != 0 || lon != 0) {
+		setMeta(meta, "gps", fmt.Sprintf("%.6f,%.6f", lat, lon))
+	}
+}
@@ -0,0 +1,137 @@
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+)
+
+// exifTIFF returns a little-endian TIFF structure whose first IFD holds
+// the ASCII tags in root and points to an Exif IFD holding those in exif.
+func exifTIFF(root, exif map[uint16]string) []byte {
+	ifdSize := func(n int) int { return 2 + 12*n + 4 }
+	rootOff, exifOff := 8, 8+ifdSize(len(root)+1)
+	dataOff := exifOff + ifdSize(len(exif))
+	var data bytes.Buffer
+	ifd := func(tags map[uint16]string, pointer bool) []byte {
+		var b bytes.Buffer
+		n := len(tags)
+		if pointer {
+			n++
+		}
+		binary.Write(&b, binary.LittleEndian, uint16(n))
+		for id, v := range tags {
+			v += "\x00"
+			binary.Write(&b, binary.LittleEndian, []uint16{id, 2})
+			binary.Write(&b, binary.LittleEndian, uint32(len(v)))
+			binary.Write(&b, binary.LittleEndian, uint32(dataOff+data.Len()))
+			data.WriteString(v)
+		}
+		if pointer {
+			binary.Write(&b, binary.LittleEndian, []uint16{0x8769, 4})
+			binary.Write(&b, binary.LittleEndian, []uint32{1, uint32(exifOff)})
+		}
+		binary.Write(&b, binary.LittleEndian, uint32(0))
+		return b.Bytes()
+	}
+	out := []byte("II*\x00")
+	out = binary.LittleEndian.AppendUint32(out, uint32(rootOff))
+	out = append(out, ifd(root, true)...)
+	out = append(out, ifd(exif, false)...)
+	return append(out, data.Bytes()...)
+}
+
+// jpegSegment returns a JPEG marker segment.
+func jpegSegment(marker byte, data []byte) []byte {
+	return append([]byte{0xff, marker, byte((len(data) + 2) >> 8), byte(len(data) + 2)}, data...)
+}
+
+// pngChunk returns a PNG chunk with its CRC.
+func pngChunk(typ string, data []byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	b = append(append(b, typ...), data...)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b[4:]))
+}
+
+func TestReadImageMetadata(t *testing.T) {
+	var pngBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, image.NewGray(image.Rect(0, 0, 4, 3))); err != nil {
+		t.Fatal(err)
+	}
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write([]byte("Ada Lovelace"))
+	zw.Close()
+	encoded := pngBuf.Bytes()
+	// Text chunks go after the 8-byte signature and 25-byte IHDR chunk.
+	pngData := append(append([]byte{}, encoded[:33]...), pngChunk("tEXt", []byte("Title\x00Harbour at dawn"))...)
+	pngData = append(pngData, pngChunk("zTXt", append([]byte("Author\x00\x00"), z.Bytes()...))...)
+	pngData = append(pngData, encoded[33:]...)
+
+	iptc := []byte("\x1c\x02\x19\x00\x04boat\x1c\x02\x19\x00\x03sea\x1c\x02\x5a\x00\x04Kiel\x1c\x02\x65\x00\x07Germany")
+	resource := append([]byte("8BIM\x04\x04\x00\x00"), binary.BigEndian.AppendUint32(nil, uint32(len(iptc)))...)
+	resource = append(resource, iptc...)
+	jpegData := []byte{0xff, 0xd8}
+	jpegData = append(jpegData, jpegSegment(0xe1, append([]byte("Exif\x00\x00"), exifTIFF(
+		map[uint16]string{0x010f: "Canon", 0x0110: "Canon EOS 5D", 0x013b: "Ada Byron"},
+		map[uint16]string{0x9003: "2023:06:01 05:30:00"},
+	)...))...)
+	jpegData = append(jpegData, jpegSegment(0xed, append([]byte("Photoshop 3.0\x00"), resource...))...)
+	jpegData = append(jpegData, 0xff, 0xd9)
+
+	tests := []struct {
+		name, mimeType string
+		data           []byte
+		want           map[string]string
+	}{
+		{"png", "image/png", pngData, map[string]string{"width": "4", "height": "3", "title": "Harbour at dawn", "artist": "Ada Lovelace"}},
+		{"jpeg", "image/jpeg", jpegData, map[string]string{
+			"camera": "Canon EOS 5D", "artist": "Ada Byron", "taken": "2023-06-01T05:30:00",
+			"keywords": "boat, sea", "location": "Kiel, Germany",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := readImageMetadata(tt.data, tt.mimeType)
+			for k, v := range tt.want {
+				if meta[k] != v {
+					t.Errorf("%s = %q, want %q", k, meta[k], v)
+				}
+			}
+		})
+	}
+}
+
+// fakeCaptioner describes every image the same way.
+type fakeCaptioner string
+
+func (c fakeCaptioner) Caption(image []byte, mimeType string) (string, error) {
+	return string(c), nil
+}
+
+func TestImageParser(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 1))); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := NewImageParser().Parse(buf.Bytes(), "photos/blank.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Content != "Image: blank.png (2x1)" || doc.Metadata["format"] != "png" {
+		t.Errorf("content %q, metadata %v", doc.Content, doc.Metadata)
+	}
+
+	p := &ImageParser{Captioner: fakeCaptioner("A grey square."), OCR: &fakeOCR{text: "EXIT"}}
+	doc, err = p.Parse(buf.Bytes(), "blank.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Content != "A grey square.\n\nEXIT" || doc.Metadata["caption"] != "A grey square." || doc.Metadata["ocr"] != "true" {
+		t.Errorf("content %q, metadata %v", doc.Content, doc.Metadata)
+	}
+}
@@ -0,0 +1,135 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ImageParser turns images into searchable text from their caption, OCR
+// text and embedded EXIF, IPTC and PNG metadata.
+type ImageParser struct {
+	// OCR, when set, recognizes text in the image.
+	OCR OCRProvider
+	// Captioner, when set, describes the image contents.
+	Captioner Captioner
+}
+
+// Captioner describes what an image shows, typically by calling a vision
+// model. mimeType is the image format, such as "image/png".
+type Captioner interface {
+	Caption(image []byte, mimeType string) (string, error)
+}
+
+// NewImageParser creates a new image parser instance.
+func NewImageParser() *ImageParser {
+	return &ImageParser{}
+}
+
+// imageMimeTypes are the image formats the parser accepts.
+var imageMimeTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/jpg":  true,
+	"image/tiff": true,
+	"image/gif":  true,
+	"image/bmp":  true,
+	"image/webp": true,
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *ImageParser) Supports(mimeType string) bool {
+	return imageMimeTypes[mimeType]
+}
+
+// Parse writes the caption, then the OCR text, then a block of the
+// embedded metadata such as "Title:", "Keywords:" and "Camera:" lines. An
+// image with none of these is described by its file name and size, so
+// every image yields some text. The metadata keys of readImageMetadata
+// plus "format", "caption" and "ocr" are copied to Document.Metadata.
+func (p *ImageParser) Parse(buffer []byte, filename string) (*Document, error) {
+	return p.ParseContext(context.Background(), buffer, filename)
+}
+
+// ParseContext works like Parse but stops OCR when ctx is done.
+func (p *ImageParser) ParseContext(ctx context.Context, buffer []byte, filename string) (*Document, error) {
+	mimeType := detectImageType(buffer)
+	if mimeType == "" {
+		return nil, errors.New("unrecognized image format")
+	}
+	meta := readImageMetadata(buffer, mimeType)
+	meta["format"] = strings.TrimPrefix(mimeType, "image/")
+
+	var parts []string
+	if p.Captioner != nil {
+		caption, err := p.Captioner.Caption(buffer, mimeType)
+		if err != nil {
+			return nil, fmt.Errorf("caption image: %w", err)
+		}
+		if caption = strings.TrimSpace(caption); caption != "" {
+			meta["caption"] = caption
+			parts = append(parts, caption)
+		}
+	}
+	if p.OCR != nil {
+		text, err := recognize(ctx, p.OCR, buffer, mimeType)
+		if err != nil {
+			return nil, fmt.Errorf("ocr image: %w", err)
+		}
+		if text = cleanPDFText(text); text != "" {
+			meta["ocr"] = "true"
+			parts = append(parts, text)
+		}
+	}
+
+	var lines []string
+	for _, f := range []struct{ label, key string }{
+		{"Title", "title"}, {"Description", "description"}, {"Keywords", "keywords"},
+		{"Artist", "artist"}, {"Copyright", "copyright"}, {"Location", "location"},
+		{"Taken", "taken"}, {"Camera", "camera"},
+	} {
+		if v := meta[f.key]; v != "" {
+			lines = append(lines, f.label+": "+v)
+		}
+	}
+	if len(lines) > 0 {
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	if len(parts) == 0 {
+		desc := "Image: " + path.Base(strings.ReplaceAll(filename, `\`, "/"))
+		if meta["width"] != "" && meta["height"] != "" {
+			desc += " (" + meta["width"] + "x" + meta["height"] + ")"
+		}
+		parts = append(parts, desc)
+	}
+
+	content := strings.Join(parts, "\n\n")
+	return &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Metadata:  meta,
+	}, nil
+}
+
+// detectImageType identifies an image format by its magic bytes.
+func detectImageType(b []byte) string {
+	s := string(b[:min(len(b), 12)])
+	switch {
+	case strings.HasPrefix(s, "\x89PNG\r\n\x1a\n"):
+		return "image/png"
+	case strings.HasPrefix(s, "\xff\xd8\xff"):
+		return "image/jpeg"
+	case strings.HasPrefix(s, "GIF87a"), strings.HasPrefix(s, "GIF89a"):
+		return "image/gif"
+	case strings.HasPrefix(s, "II*\x00"), strings.HasPrefix(s, "MM\x00*"):
+		return "image/tiff"
+	case strings.HasPrefix(s, "BM"):
+		return "image/bmp"
+	case strings.HasPrefix(s, "RIFF") && len(s) == 12 && s[8:] == "WEBP":
+		return "image/webp"
+	}
+	return ""
+}
@@ -0,0 +1,186 @@
+package document
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IpynbParser extracts Jupyter notebooks, keeping markdown and code cells in
+// notebook order. Code cells are fenced and tagged with the kernel
+// language.
+type IpynbParser struct {
+	// Outputs includes the text outputs of code cells.
+	Outputs bool
+}
+
+// NewIpynbParser creates a new notebook parser instance.
+func NewIpynbParser() *IpynbParser {
+	return &IpynbParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *IpynbParser) Supports(mimeType string) bool {
+	return mimeType == "application/x-ipynb+json"
+}
+
+// ipynbText is notebook text, stored either as a string or as a list of
+// lines.
+type ipynbText string
+
+func (t *ipynbText) UnmarshalJSON(data []byte) error {
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err == nil {
+		*t = ipynbText(strings.Join(lines, ""))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*t = ipynbText(s)
+	return nil
+}
+
+type ipynbCell struct {
+	Type     string    `json:"cell_type"`
+	Source   ipynbText `json:"source"`
+	Input    ipynbText `json:"input"` // nbformat 3
+	Language string    `json:"language"`
+	Outputs  []struct {
+		Type   string                     `json:"output_type"`
+		Text   ipynbText                  `json:"text"`
+		Data   map[string]json.RawMessage `json:"data"`
+		Ename  string                     `json:"ename"`
+		Evalue string                     `json:"evalue"`
+	} `json:"outputs"`
+}
+
+type ipynbNotebook struct {
+	Cells      []ipynbCell `json:"cells"`
+	Worksheets []struct {
+		Cells []ipynbCell `json:"cells"`
+	} `json:"worksheets"`
+	Metadata struct {
+		Title      string `json:"title"`
+		Kernelspec struct {
+			Language string `json:"language"`
+		} `json:"kernelspec"`
+		LanguageInfo struct {
+			Name string `json:"name"`
+		} `json:"language_info"`
+	} `json:"metadata"`
+}
+
+// Parse renders markdown cells through MarkdownParser, so their headings
+// are recorded in Document.Headings, and code cells as fenced blocks. With
+// Outputs set, stream, result and error outputs follow each code cell in
+// an "output" fence.
+func (p *IpynbParser) Parse(buffer []byte, filename string) (*Document, error) {
+	var nb ipynbNotebook
+	if err := json.Unmarshal(buffer, &nb); err != nil {
+		return nil, fmt.Errorf("decode notebook: %w", err)
+	}
+	cells := nb.Cells
+	for _, ws := range nb.Worksheets {
+		cells = append(cells, ws.Cells...)
+	}
+	lang := nb.Metadata.LanguageInfo.Name
+	if lang == "" {
+		lang = nb.Metadata.Kernelspec.Language
+	}
+
+	var b strings.Builder
+	var headings []Heading
+	block := func(text string) {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString(text)
+	}
+	md := NewMarkdownParser()
+	codeCells := 0
+	for _, cell := range cells {
+		src := string(cell.Source)
+		if src == "" {
+			src = string(cell.Input)
+		}
+		switch cell.Type {
+		case "markdown", "heading":
+			doc, err := md.Parse([]byte(src), filename)
+			if err != nil {
+				continue
+			}
+			if b.Len() > 0 {
+				b.WriteString("\n\n")
+			}
+			for _, h := range doc.Headings {
+				h.Offset += b.Len()
+				headings = append(headings, h)
+			}
+			b.WriteString(doc.Content)
+		case "code":
+			codeCells++
+			cellLang := lang
+			if cell.Language != "" {
+				cellLang = cell.Language
+			}
+			if code := strings.Trim(src, "\n"); strings.TrimSpace(code) != "" {
+				block("```" + cellLang + "\n" + code + "\n```")
+			}
+			if !p.Outputs {
+				continue
+			}
+			var outs []string
+			for _, o := range cell.Outputs {
+				switch {
+				case o.Type == "error" || o.Type == "pyerr":
+					outs = append(outs, o.Ename+": "+o.Evalue)
+				case o.Text != "":
+					outs = append(outs, string(o.Text))
+				default:
+					var text ipynbText
+					if raw := o.Data["text/plain"]; raw != nil && json.Unmarshal(raw, &text) == nil {
+						outs = append(outs, string(text))
+					}
+				}
+			}
+			for i := range outs {
+				outs[i] = strings.TrimRight(outs[i], "\n")
+			}
+			if out := strings.Trim(strings.Join(outs, "\n"), "\n"); strings.TrimSpace(out) != "" {
+				block("```output\n" + out + "\n```")
+			}
+		case "raw":
+			if text := strings.TrimSpace(src); text != "" {
+				block(text)
+			}
+		}
+	}
+
+	content := b.String()
+	if strings.TrimSpace(content) == "" {
+		return nil, ErrEmptyDocument
+	}
+	meta := map[string]string{
+		"cells":      strconv.Itoa(len(cells)),
+		"code_cells": strconv.Itoa(codeCells),
+	}
+	if lang != "" {
+		meta["language"] = lang
+	}
+	if title := strings.TrimSpace(nb.Metadata.Title); title != "" {
+		meta["title"] = title
+	} else if len(headings) > 0 && headings[0].Level == 1 {
+		meta["title"] = headings[0].Text
+	}
+	return &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Headings:  headings,
+		Sections:  BuildSections(headings, len(content)),
+		Metadata:  meta,
+	}, nil
+}
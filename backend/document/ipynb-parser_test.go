@@ -0,0 +1,48 @@
+package document
+
+import "testing"
+
+const ipynbSample = `{
+ "metadata": {"kernelspec": {"language": "python"}},
+ "nbformat": 4,
+ "cells": [
+  {"cell_type": "markdown", "source": ["# Analysis\n", "Load the data."]},
+  {"cell_type": "code", "source": "x = 1\nprint(x)\n", "outputs": [
+   {"output_type": "stream", "text": ["1\n"]},
+   {"output_type": "execute_result", "data": {"text/plain": ["'done'"]}}
+  ]},
+  {"cell_type": "code", "source": ["1/0"], "outputs": [
+   {"output_type": "error", "ename": "ZeroDivisionError", "evalue": "division by zero"}
+  ]},
+  {"cell_type": "raw", "source": "raw note"}
+ ]
+}`
+
+func TestIpynbParser(t *testing.T) {
+	tests := []struct {
+		outputs bool
+		want    string
+	}{
+		{false, "# Analysis\nLoad the data.\n\n```python\nx = 1\nprint(x)\n```\n\n```python\n1/0\n```\n\nraw note"},
+		{true, "# Analysis\nLoad the data.\n\n```python\nx = 1\nprint(x)\n```\n\n```output\n1\n'done'\n```\n\n" +
+			"```python\n1/0\n```\n\n```output\nZeroDivisionError: division by zero\n```\n\nraw note"},
+	}
+	for _, tt := range tests {
+		doc, err := (&IpynbParser{Outputs: tt.outputs}).Parse([]byte(ipynbSample), "a.ipynb")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc.Content != tt.want {
+			t.Errorf("outputs %v: content %q, want %q", tt.outputs, doc.Content, tt.want)
+		}
+		if m := doc.Metadata; m["title"] != "Analysis" || m["language"] != "python" || m["cells"] != "4" || m["code_cells"] != "2" {
+			t.Errorf("metadata %v", m)
+		}
+		if len(doc.Headings) != 1 || doc.Headings[0].Offset != 0 {
+			t.Errorf("headings %+v", doc.Headings)
+		}
+	}
+	if _, err := NewIpynbParser().Parse([]byte(`{"cells": [`), "a.ipynb"); err == nil {
+		t.Error("truncated notebook parsed without error")
+	}
+}
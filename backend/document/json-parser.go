@@ -0,0 +1,202 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// JSONParser flattens JSON documents and JSON Lines streams into
+// "key.path: value" text. Each JSONL line, or each element of a top-level
+// array, is a separate record.
+type JSONParser struct{}
+
+// NewJSONParser creates a new JSON parser instance.
+func NewJSONParser() *JSONParser {
+	return &JSONParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *JSONParser) Supports(mimeType string) bool {
+	switch mimeType {
+	case "application/json", "application/x-ndjson", "application/jsonl",
+		"application/x-jsonlines", "application/ld+json":
+		return true
+	}
+	return false
+}
+
+// Parse returns all records in a single document, separated by blank lines.
+func (p *JSONParser) Parse(buffer []byte, filename string) (*Document, error) {
+	docs, err := p.ParseAll(buffer, filename)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(docs))
+	for i, d := range docs {
+		parts[i] = d.Content
+	}
+	content := strings.Join(parts, "\n\n")
+	return &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Metadata:  map[string]string{"records": strconv.Itoa(len(docs))},
+	}, nil
+}
+
+// ParseAll returns one document per record, numbered from 1 in the
+// "record" metadata key. Empty records are skipped.
+func (p *JSONParser) ParseAll(buffer []byte, filename string) ([]*Document, error) {
+	dec := json.NewDecoder(bytes.NewReader(buffer))
+	dec.UseNumber()
+	var values []jsonValue
+	for {
+		v, err := decodeJSONValue(dec)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decode json record %d: %w", len(values)+1, err)
+		}
+		values = append(values, v)
+	}
+	if len(values) == 1 && values[0].kind == 'a' {
+		values = values[0].array
+	}
+
+	var docs []*Document
+	for i, v := range values {
+		var lines []string
+		flattenJSON(v, "", &lines)
+		content := strings.Join(lines, "\n")
+		if strings.TrimSpace(content) == "" {
+			continue
+		}
+		docs = append(docs, &Document{
+			Content:   content,
+			Source:    filename,
+			WordCount: len(strings.Fields(content)),
+			Metadata:  map[string]string{"record": strconv.Itoa(i + 1)},
+		})
+	}
+	if len(docs) == 0 {
+		return nil, ErrEmptyDocument
+	}
+	return docs, nil
+}
+
+// jsonValue is a decoded JSON value that keeps object key order, which
+// encoding/json maps would lose.
+type jsonValue struct {
+	scalar string
+	isNull bool
+	object []jsonField
+	array  []jsonValue
+	kind   byte // 's' scalar, 'o' object, 'a' array
+}
+
+type jsonField struct {
+	key   string
+	value jsonValue
+}
+
+func decodeJSONValue(dec *json.Decoder) (jsonValue, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return jsonValue{}, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			v := jsonValue{kind: 'o', object: []jsonField{}}
+			for dec.More() {
+				k, err := dec.Token()
+				if err != nil {
+					return v, err
+				}
+				key, _ := k.(string)
+				child, err := decodeJSONValue(dec)
+				if err != nil {
+					return v, err
+				}
+				v.object = append(v.object, jsonField{key: key, value: child})
+			}
+			_, err := dec.Token()
+			return v, err
+		case '[':
+			v := jsonValue{kind: 'a', array: []jsonValue{}}
+			for dec.More() {
+				child, err := decodeJSONValue(dec)
+				if err != nil {
+					return v, err
+				}
+				v.array = append(v.array, child)
+			}
+			_, err := dec.Token()
+			return v, err
+		}
+		return jsonValue{}, fmt.Errorf("unexpected delimiter %q", t)
+	case string:
+		return jsonValue{kind: 's', scalar: t}, nil
+	case json.Number:
+		return jsonValue{kind: 's', scalar: t.String()}, nil
+	case bool:
+		return jsonValue{kind: 's', scalar: strconv.FormatBool(t)}, nil
+	case nil:
+		return jsonValue{kind: 's', isNull: true}, nil
+	}
+	return jsonValue{}, fmt.Errorf("unexpected token %v", tok)
+}
+
+// flattenJSON appends one "path: value" line per leaf. Arrays of scalars
+// are joined into a single comma-separated line; nulls are omitted.
+func flattenJSON(v jsonValue, path string, lines *[]string) {
+	switch v.kind {
+	case 's':
+		if v.isNull {
+			return
+		}
+		text := strings.Join(strings.Fields(v.scalar), " ")
+		if text == "" {
+			return
+		}
+		if path == "" {
+			*lines = append(*lines, text)
+		} else {
+			*lines = append(*lines, path+": "+text)
+		}
+	case 'o':
+		for _, f := range v.object {
+			key := f.key
+			if path != "" {
+				key = path + "." + f.key
+			}
+			flattenJSON(f.value, key, lines)
+		}
+	case 'a':
+		scalars := make([]string, 0, len(v.array))
+		for _, e := range v.array {
+			if e.kind != 's' {
+				scalars = nil
+				break
+			}
+			if !e.isNull && strings.TrimSpace(e.scalar) != "" {
+				scalars = append(scalars, strings.Join(strings.Fields(e.scalar), " "))
+			}
+		}
+		if scalars != nil {
+			if len(scalars) > 0 {
+				flattenJSON(jsonValue{kind: 's', scalar: strings.Join(scalars, ", ")}, path, lines)
+			}
+			return
+		}
+		for i, e := range v.array {
+			flattenJSON(e, path+"["+strconv.Itoa(i)+"]", lines)
+		}
+	}
+}
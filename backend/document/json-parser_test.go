@@ -0,0 +1,50 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestJSONParser(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		records int
+		want    []string
+	}{
+		{"object", `{"name":"Ada","skills":["math","code"],"jobs":[{"title":"Analyst"}],"address":{"city":"London"}}`, 1,
+			[]string{"name: Ada", "skills: math, code", "jobs[0].title: Analyst", "address.city: London"}},
+		{"array", `[{"id":1},{"id":2}]`, 2, []string{"id: 1", "id: 2"}},
+		{"jsonl", "{\"id\":1}\n\n{\"id\":2}\n{\"id\":3}\n", 3, []string{"id: 1", "id: 3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewJSONParser()
+			doc, err := p.Parse([]byte(tt.input), "a.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(doc.Content, want) {
+					t.Errorf("content %q does not contain %q", doc.Content, want)
+				}
+			}
+			docs, err := p.ParseAll([]byte(tt.input), "a.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(docs) != tt.records {
+				t.Fatalf("%d records, want %d", len(docs), tt.records)
+			}
+			for i, d := range docs {
+				if d.Metadata["record"] != strconv.Itoa(i+1) {
+					t.Errorf("record %d numbered %q", i, d.Metadata["record"])
+				}
+			}
+		})
+	}
+	if _, err := NewJSONParser().Parse([]byte(`{"broken":`), "a.json"); err == nil {
+		t.Error("parsed invalid JSON")
+	}
+}
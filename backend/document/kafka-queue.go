@@ -0,0 +1,181 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// kafkaJSON and kafkaBinary are the content types of the Kafka REST Proxy
+// v2 API, with record keys and values base64 encoded.
+const (
+	kafkaJSON   = "application/vnd.kafka.v2+json"
+	kafkaBinary = "application/vnd.kafka.binary.v2+json"
+)
+
+// KafkaQueue is a Queue over a Kafka REST Proxy, such as Confluent's,
+// using its v2 API. It joins Group as a consumer of Topics, so workers in
+// the same group share the topics' partitions, and commits a record's
+// offset when the record is acknowledged. A record a worker did not
+// acknowledge is delivered again after the group rebalances, unless a
+// later record of its partition was committed first, as can happen when
+// a QueueWorker processes several jobs at once.
+type KafkaQueue struct {
+	// Endpoint is the base URL of the REST Proxy.
+	Endpoint string
+	Group    string
+	Topics   []string
+	// Header is added to every request, such as for authentication.
+	Header http.Header
+	// PollInterval is the wait between fetches that return no records.
+	// Defaults to one second.
+	PollInterval time.Duration
+	// MaxRetries is the number of times a failed request is retried, with
+	// exponential backoff. Defaults to 3; negative turns retries off.
+	MaxRetries int
+	// Client defaults to an http.Client with a one minute timeout.
+	Client *http.Client
+
+	mu       sync.Mutex
+	instance string
+	records  []kafkaRecord
+}
+
+// NewKafkaQueue creates a queue that consumes topics as a member of group
+// through the REST Proxy at endpoint.
+func NewKafkaQueue(endpoint, group string, topics ...string) *KafkaQueue {
+	return &KafkaQueue{Endpoint: endpoint, Group: group, Topics: topics}
+}
+
+type kafkaRecord struct {
+	Topic     string `json:"topic"`
+	Value     []byte `json:"value"`
+	Partition int    `json:"partition"`
+	Offset    int64  `json:"offset"`
+}
+
+// Receive returns the next record of the topics.
+func (q *KafkaQueue) Receive(ctx context.Context) (*Message, error) {
+	for {
+		q.mu.Lock()
+		if len(q.records) > 0 {
+			rec := q.records[0]
+			q.records = q.records[1:]
+			q.mu.Unlock()
+			return &Message{Data: rec.Value, Ack: func(ctx context.Context) error { return q.commit(ctx, rec) }}, nil
+		}
+		err := q.fetch(ctx)
+		n := len(q.records)
+		q.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			continue
+		}
+		wait := q.PollInterval
+		if wait <= 0 {
+			wait = time.Second
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Publish produces data as a record of topic.
+func (q *KafkaQueue) Publish(ctx context.Context, topic string, data []byte) error {
+	body := map[string]any{"records": []map[string]any{{"value": data}}}
+	_, err := q.send(ctx, http.MethodPost, q.endpoint()+"/topics/"+url.PathEscape(topic), kafkaBinary, body)
+	if err != nil {
+		return fmt.Errorf("produce to kafka: %w", err)
+	}
+	return nil
+}
+
+// Close removes the consumer instance from the proxy, so the group
+// rebalances at once rather than when the instance times out.
+func (q *KafkaQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.instance == "" {
+		return nil
+	}
+	_, err := q.send(context.Background(), http.MethodDelete, q.instance, kafkaJSON, nil)
+	q.instance, q.records = "", nil
+	return err
+}
+
+// fetch polls the proxy for records, creating and subscribing the
+// consumer instance first if needed. q.mu must be held.
+func (q *KafkaQueue) fetch(ctx context.Context) error {
+	if q.instance == "" {
+		data, err := q.send(ctx, http.MethodPost, q.endpoint()+"/consumers/"+url.PathEscape(q.Group), kafkaJSON, map[string]any{
+			"format":             "binary",
+			"auto.offset.reset":  "earliest",
+			"auto.commit.enable": "false",
+		})
+		if err != nil {
+			return fmt.Errorf("create kafka consumer: %w", err)
+		}
+		var created struct {
+			BaseURI string `json:"base_uri"`
+		}
+		if err := json.Unmarshal(data, &created); err != nil || created.BaseURI == "" {
+			return fmt.Errorf("create kafka consumer: unexpected response %s", data)
+		}
+		if _, err := q.send(ctx, http.MethodPost, created.BaseURI+"/subscription", kafkaJSON, map[string]any{"topics": q.Topics}); err != nil {
+			return fmt.Errorf("subscribe to kafka topics: %w", err)
+		}
+		q.instance = created.BaseURI
+	}
+	data, err := q.send(ctx, http.MethodGet, q.instance+"/records", kafkaBinary, nil)
+	if err != nil {
+		return fmt.Errorf("fetch kafka records: %w", err)
+	}
+	if err := json.Unmarshal(data, &q.records); err != nil {
+		return fmt.Errorf("decode kafka records: %w", err)
+	}
+	return nil
+}
+
+// commit commits rec's offset for the group.
+func (q *KafkaQueue) commit(ctx context.Context, rec kafkaRecord) error {
+	q.mu.Lock()
+	instance := q.instance
+	q.mu.Unlock()
+	body := map[string]any{"offsets": []map[string]any{{"topic": rec.Topic, "partition": rec.Partition, "offset": rec.Offset}}}
+	if _, err := q.send(ctx, http.MethodPost, instance+"/offsets", kafkaJSON, body); err != nil {
+		return fmt.Errorf("commit kafka offset: %w", err)
+	}
+	return nil
+}
+
+// send sends body, when not nil, as JSON of contentType. Responses are
+// accepted as contentType too.
+func (q *KafkaQueue) send(ctx context.Context, method, u, contentType string, body any) ([]byte, error) {
+	header := http.Header{}
+	for k, v := range q.Header {
+		header[k] = v
+	}
+	header.Set("Accept", contentType)
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+	return retryPolicy{maxRetries: q.MaxRetries}.send(ctx, httpClient(q.Client, time.Minute), method, u, header, payload, contentType)
+}
+
+func (q *KafkaQueue) endpoint() string {
+	return strings.TrimSuffix(q.Endpoint, "/")
+}
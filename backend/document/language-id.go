@@ -0,0 +1,163 @@
+package document
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minLanguageLetters is the fewest letters IdentifyLanguage needs to tell
+// Latin-script languages apart.
+const minLanguageLetters = 20
+
+// languageTrigrams are the most frequent character trigrams of each
+// Latin-script language, most frequent first, with "_" marking a word
+// boundary.
+var languageTrigrams = map[string]string{
+	"en": "_th the he_ _an and nd_ ing ng_ _of of_ _to ion _in on_ tio er_ ed_ is_ re_ in_ at_ ent es_ _co for _be hat tha _re ati ter _wh _is _it it_ his _ha ere",
+	"de": "en_ er_ _de der ie_ _di die ich che sch ein _ei _un und nd_ ch_ den in_ cht _da gen ten te_ ung _ge es_ ine _ve ber _zu ent das _ni ist eit _au uch",
+	"fr": "es_ _de de_ le_ ent _le nt_ la_ _la _et et_ ion re_ _pa que _qu ue_ les _co on_ tio _re men _un des _po our ne_ air _en ait _da dan ans _au eme _pr",
+	"es": "_de de_ os_ la_ _la es_ _el el_ _qu que ue_ en_ as_ _co ent _en ion _y_ nte ado _lo los _se on_ par _pa ara del _po cio ien _ha _un na_ _es est _al",
+	"it": "_di di_ to_ la_ _la re_ _de ell lla ne_ _co che _ch he_ _il il_ no_ one ion ent er_ _in _pe per ato _e_ del zio _un _no non are _si ale gli _gl",
+	"pt": "_de de_ os_ _qu que ue_ do_ da_ _a_ _co ent es_ _o_ ão_ ção _do _da _pa _se em_ nte men as_ ra_ ar_ com _um um_ _em ado _ma _pr _no não _nã ões",
+	"nl": "en_ _de de_ an_ van _va et_ het _he ing _ee een _en er_ _in in_ oor ij_ _ge ver _ve cht ie_ te_ _da _zi aar _we ng_ ten _vo _me _ni nie _is of_ _op",
+}
+
+// languageProfiles maps each trigram to its rank per language.
+var languageProfiles = func() map[string]map[string]int {
+	out := map[string]map[string]int{}
+	for lang, list := range languageTrigrams {
+		ranks := map[string]int{}
+		for i, g := range strings.Fields(list) {
+			if _, ok := ranks[g]; !ok {
+				ranks[g] = i
+			}
+		}
+		out[lang] = ranks
+	}
+	return out
+}()
+
+// languageScripts are writing systems used by a single language, or whose
+// language is decided by other scripts present, checked in order.
+var languageScripts = []struct {
+	lang   string
+	script *unicode.RangeTable
+}{
+	{"ko", unicode.Hangul},
+	{"ja", unicode.Hiragana},
+	{"ja", unicode.Katakana},
+	{"zh", unicode.Han},
+	{"ar", unicode.Arabic},
+	{"he", unicode.Hebrew},
+	{"el", unicode.Greek},
+	{"hi", unicode.Devanagari},
+	{"th", unicode.Thai},
+	{"ru", unicode.Cyrillic},
+}
+
+// IdentifyLanguage returns the ISO 639-1 code of the language text is
+// written in, or "" when it cannot tell. Non-Latin scripts decide the
+// language directly; Latin-script text is compared with character trigram
+// profiles of English, German, French, Spanish, Italian, Portuguese and
+// Dutch. It makes no network calls.
+func IdentifyLanguage(text string) string {
+	counts := map[string]int{}
+	latin := 0
+	for _, r := range text {
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		for _, s := range languageScripts {
+			if unicode.Is(s.script, r) {
+				counts[s.lang]++
+				break
+			}
+		}
+	}
+	best, bestCount := "", 0
+	for _, s := range languageScripts {
+		if n := counts[s.lang]; n > bestCount {
+			best, bestCount = s.lang, n
+		}
+	}
+	// Japanese mixes kana with Han characters.
+	if counts["ja"] > 0 && best == "zh" {
+		best = "ja"
+	}
+	if bestCount > latin {
+		if best == "ru" && strings.ContainsAny(text, "іїєґІЇЄҐ") {
+			return "uk"
+		}
+		return best
+	}
+	if latin < minLanguageLetters {
+		return ""
+	}
+	return latinLanguage(text)
+}
+
+// latinLanguage scores the trigrams of text against each profile, weighting
+// frequent trigrams higher, and returns the best language when it is
+// clearly ahead of the runner-up.
+func latinLanguage(text string) string {
+	grams := map[string]int{}
+	total := 0
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		r := []rune("_" + w + "_")
+		for i := 0; i+3 <= len(r); i++ {
+			grams[string(r[i:i+3])]++
+			total++
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+	best, second := "", ""
+	scores := map[string]float64{}
+	for lang, ranks := range languageProfiles {
+		for g, n := range grams {
+			if rank, ok := ranks[g]; ok {
+				scores[lang] += float64(n) * (1 - float64(rank)/float64(len(ranks)+1))
+			}
+		}
+		switch {
+		case best == "" || scores[lang] > scores[best]:
+			best, second = lang, best
+		case second == "" || scores[lang] > scores[second]:
+			second = lang
+		}
+	}
+	if scores[best]/float64(total) < 0.05 || second != "" && scores[best] < 1.1*scores[second] {
+		return ""
+	}
+	return best
+}
+
+// TagLanguages stores the language of doc in its "lang" metadata, unless
+// already set, and that of each chunk in the chunk's "lang" metadata.
+// Chunks too short to identify inherit the document's language.
+func TagLanguages(doc *Document, chunks []Chunk) {
+	lang := doc.Metadata["lang"]
+	if lang == "" {
+		if lang = IdentifyLanguage(doc.Content); lang != "" {
+			if doc.Metadata == nil {
+				doc.Metadata = map[string]string{}
+			}
+			doc.Metadata["lang"] = lang
+		}
+	}
+	for i := range chunks {
+		l := IdentifyLanguage(chunks[i].Text)
+		if l == "" {
+			l = lang
+		}
+		if l == "" {
+			continue
+		}
+		if chunks[i].Metadata == nil {
+			chunks[i].Metadata = map[string]string{}
+		}
+		chunks[i].Metadata["lang"] = l
+	}
+}
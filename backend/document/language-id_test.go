@@ -0,0 +1,63 @@
+package document
+
+import "testing"
+
+func TestIdentifyLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"The quick brown fox jumps over the lazy dog and then it runs into the forest.", "en"},
+		{"Der schnelle braune Fuchs springt über den faulen Hund und läuft in den Wald.", "de"},
+		{"Le renard brun rapide saute par-dessus le chien paresseux et court dans la forêt.", "fr"},
+		{"El rápido zorro marrón salta sobre el perro perezoso y corre hacia el bosque.", "es"},
+		{"La volpe marrone veloce salta sopra il cane pigro e corre nella foresta del nord.", "it"},
+		{"A rápida raposa marrom pula sobre o cão preguiçoso e corre para a floresta do norte.", "pt"},
+		{"De snelle bruine vos springt over de luie hond en rent naar het bos van de stad.", "nl"},
+		{"Быстрая коричневая лиса", "ru"},
+		{"Швидка бура лисиця їсть", "uk"},
+		{"東京は日本の首都です", "ja"},
+		{"北京是中国的首都", "zh"},
+		{"서울은 한국의 수도입니다", "ko"},
+		{"Η γρήγορη αλεπού", "el"},
+		{"short text", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := IdentifyLanguage(tt.text); got != tt.want {
+			t.Errorf("IdentifyLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTagLanguages(t *testing.T) {
+	en := "The quick brown fox jumps over the lazy dog and then it runs into the forest."
+	de := "Der schnelle braune Fuchs springt über den faulen Hund."
+	doc := &Document{Source: "a.txt", Content: en + "\n\n" + en + "\n\n" + de}
+	chunks := []Chunk{{Text: en}, {Text: de}, {Text: "OK."}}
+	TagLanguages(doc, chunks)
+	want := []string{"en", "de", "en"}
+	if doc.Metadata["lang"] != "en" {
+		t.Errorf("document lang = %q", doc.Metadata["lang"])
+	}
+	for i, c := range chunks {
+		if c.Metadata["lang"] != want[i] {
+			t.Errorf("chunk %d lang = %q, want %q", i, c.Metadata["lang"], want[i])
+		}
+	}
+
+	// An existing document language is kept and inherited.
+	doc.Metadata["lang"] = "fr"
+	TagLanguages(doc, chunks[2:])
+	if doc.Metadata["lang"] != "fr" || chunks[2].Metadata["lang"] != "fr" {
+		t.Errorf("lang %q, short chunk %q", doc.Metadata["lang"], chunks[2].Metadata["lang"])
+	}
+
+	chunked, err := ChunkDocument("paragraph", &Document{Source: "b.txt", Content: de}, Options{DetectLanguage: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunked) != 1 || chunked[0].Metadata["lang"] != "de" {
+		t.Errorf("DetectLanguage chunks = %+v", chunked)
+	}
+}
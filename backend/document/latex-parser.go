@@ -0,0 +1,738 @@
+package document
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LaTeXParser converts LaTeX source to plain text. Sectioning commands
+// become "#" headings, lists and tables are rendered like the other
+// parsers, and math is kept verbatim between "$" delimiters.
+type LaTeXParser struct{}
+
+// NewLaTeXParser creates a new LaTeX parser instance.
+func NewLaTeXParser() *LaTeXParser {
+	return &LaTeXParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *LaTeXParser) Supports(mimeType string) bool {
+	switch mimeType {
+	case "application/x-latex", "application/x-tex", "text/x-tex", "text/x-latex":
+		return true
+	}
+	return false
+}
+
+// Parse converts the body of the document environment, or the whole input
+// when there is none. \title and \author populate the metadata.
+func (p *LaTeXParser) Parse(buffer []byte, filename string) (*Document, error) {
+	src := decodeText(buffer)
+	c := &latexConv{meta: map[string]string{}}
+	c.discard = strings.Contains(src, `\begin{document}`)
+	c.run(src)
+	c.flush()
+
+	top := 0
+	for _, b := range c.blocks {
+		if b.rank > 0 && (top == 0 || b.rank < top) {
+			top = b.rank
+		}
+	}
+	var out strings.Builder
+	var headings []Heading
+	for _, b := range c.blocks {
+		if out.Len() > 0 {
+			out.WriteString("\n\n")
+		}
+		if b.rank > 0 {
+			level := min(b.rank-top+1, 6)
+			headings = append(headings, Heading{Level: level, Text: b.text, Offset: out.Len()})
+			out.WriteString(strings.Repeat("#", level) + " ")
+		}
+		out.WriteString(b.text)
+	}
+
+	content := out.String()
+	if strings.TrimSpace(content) == "" {
+		return nil, ErrEmptyDocument
+	}
+	doc := &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Headings:  headings,
+		Sections:  BuildSections(headings, len(content)),
+	}
+	if len(c.meta) > 0 {
+		doc.Metadata = c.meta
+	}
+	return doc, nil
+}
+
+// latexSectionRank orders the sectioning commands; Parse maps the highest
+// rank used in a document to heading level 1.
+var latexSectionRank = map[string]int{
+	"part": 1, "chapter": 2, "section": 3, "subsection": 4,
+	"subsubsection": 5, "paragraph": 6, "subparagraph": 7,
+}
+
+// latexDropArgs lists commands whose arguments are not body text, with the
+// number of mandatory arguments to discard.
+var latexDropArgs = map[string]int{
+	"label": 1, "ref": 1, "eqref": 1, "pageref": 1, "autoref": 1, "cref": 1, "Cref": 1,
+	"includegraphics": 1, "usepackage": 1, "documentclass": 1, "vspace": 1, "hspace": 1,
+	"bibliographystyle": 1, "bibliography": 1, "pagestyle": 1, "thispagestyle": 1,
+	"input": 1, "include": 1, "graphicspath": 1, "hypersetup": 1, "geometry": 1,
+	"index": 1, "nocite": 1, "linespread": 1, "color": 1, "date": 1, "addbibresource": 1,
+	"setlength": 2, "addtolength": 2, "setcounter": 2, "addtocounter": 2,
+	"newcommand": 2, "renewcommand": 2, "providecommand": 2, "DeclareMathOperator": 2,
+	"definecolor": 3, "newenvironment": 3, "renewenvironment": 3, "newtheorem": 2,
+}
+
+// latexKeepArg lists formatting commands whose single argument is text.
+var latexKeepArg = map[string]bool{
+	"textbf": true, "textit": true, "emph": true, "texttt": true, "underline": true,
+	"textsc": true, "textrm": true, "textsf": true, "textup": true, "textsl": true,
+	"textmd": true, "textnormal": true, "mbox": true, "hbox": true, "text": true,
+	"uline": true, "mathrm": true, "title": true, "author": true, "thanks": true,
+}
+
+// latexSymbols maps argument-less commands to the text they produce.
+var latexSymbols = map[string]string{
+	"ldots": "…", "dots": "…", "LaTeX": "LaTeX", "TeX": "TeX", "LaTeXe": "LaTeX2e",
+	"S": "§", "P": "¶", "copyright": "©", "textbackslash": `\`, "textasciitilde": "~",
+	"textbar": "|", "textless": "<", "textgreater": ">", "textendash": "–",
+	"textemdash": "—", "ss": "ß", "ae": "æ", "AE": "Æ", "oe": "œ", "OE": "Œ",
+	"o": "ø", "O": "Ø", "l": "ł", "L": "Ł", "aa": "å", "AA": "Å", "i": "ı",
+	"newline": " ", "linebreak": " ", "quad": " ", "qquad": " ", "and": ", ",
+}
+
+// latexAccents maps an accent command and base letter to the precomposed
+// character.
+var latexAccents = map[byte]map[byte]string{
+	'\'': latexAccentTable("aáeéiíoóuúyýnńcćsśzźAÁEÉIÍOÓUÚYÝNŃCĆSŚZŹ"),
+	'`':  latexAccentTable("aàeèiìoòuùAÀEÈIÌOÒUÙ"),
+	'^':  latexAccentTable("aâeêiîoôuûAÂEÊIÎOÔUÛ"),
+	'"':  latexAccentTable("aäeëiïoöuüyÿAÄEËIÏOÖUÜ"),
+	'~':  latexAccentTable("aãoõnñAÃOÕNÑ"),
+	'c':  latexAccentTable("cçCÇsşSŞ"),
+	'v':  latexAccentTable("cčsšzžrřeěCČSŠZŽRŘEĚ"),
+	'=':  latexAccentTable("aāeēiīoōuūAĀEĒIĪOŌUŪ"),
+}
+
+func latexAccentTable(pairs string) map[byte]string {
+	m := map[byte]string{}
+	runes := []rune(pairs)
+	for i := 0; i+1 < len(runes); i += 2 {
+		m[byte(runes[i])] = string(runes[i+1])
+	}
+	return m
+}
+
+// latexBlock is a rendered paragraph, or a heading when rank is non-zero.
+type latexBlock struct {
+	rank int
+	text string
+}
+
+// latexConv converts LaTeX source into blocks. In inline mode, used for
+// command arguments and list items, block structure is flattened into the
+// running text.
+type latexConv struct {
+	blocks  []latexBlock
+	para    strings.Builder
+	meta    map[string]string
+	inline  bool
+	discard bool
+	done    bool
+}
+
+// latexInline converts a fragment to a single line of text.
+func latexInline(src string) string {
+	c := &latexConv{inline: true}
+	c.run(src)
+	return strings.Join(strings.Fields(c.para.String()), " ")
+}
+
+func (c *latexConv) flush() {
+	text := strings.Join(strings.Fields(c.para.String()), " ")
+	c.para.Reset()
+	if text != "" && !c.discard {
+		c.blocks = append(c.blocks, latexBlock{text: text})
+	}
+}
+
+func (c *latexConv) block(rank int, text string) {
+	if c.inline {
+		c.para.WriteString(" " + text + " ")
+		return
+	}
+	c.flush()
+	if text != "" && !c.discard {
+		c.blocks = append(c.blocks, latexBlock{rank: rank, text: text})
+	}
+}
+
+func (c *latexConv) run(src string) {
+	for i := 0; i < len(src) && !c.done; {
+		ch := src[i]
+		switch {
+		case ch == '%':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			// A comment also swallows the line break and leading indentation.
+			for i < len(src) && (src[i] == '\n' || src[i] == ' ' || src[i] == '\t') {
+				if src[i] == '\n' && i+1 < len(src) && src[i+1] == '\n' {
+					break
+				}
+				i++
+			}
+		case ch == '\n':
+			j := i + 1
+			for j < len(src) && (src[j] == ' ' || src[j] == '\t' || src[j] == '\r') {
+				j++
+			}
+			if j < len(src) && src[j] == '\n' && !c.inline {
+				c.flush()
+			} else {
+				c.para.WriteByte(' ')
+			}
+			i = j
+		case ch == '{' || ch == '}':
+			i++
+		case ch == '~':
+			c.para.WriteByte(' ')
+			i++
+		case ch == '&':
+			c.para.WriteByte(' ')
+			i++
+		case ch == '$':
+			i = c.math(src, i)
+		case ch == '-' && strings.HasPrefix(src[i:], "---"):
+			c.para.WriteString("—")
+			i += 3
+		case ch == '-' && strings.HasPrefix(src[i:], "--"):
+			c.para.WriteString("–")
+			i += 2
+		case ch == '`' && strings.HasPrefix(src[i:], "``"):
+			c.para.WriteString("“")
+			i += 2
+		case ch == '\'' && strings.HasPrefix(src[i:], "''"):
+			c.para.WriteString("”")
+			i += 2
+		case ch == '\\':
+			i = c.command(src, i)
+		default:
+			c.para.WriteByte(ch)
+			i++
+		}
+	}
+}
+
+// math copies inline ($...$) or display ($$...$$) math verbatim, collapsed
+// to one line, and returns the index after the closing delimiter.
+func (c *latexConv) math(src string, i int) int {
+	delim := "$"
+	if strings.HasPrefix(src[i:], "$$") {
+		delim = "$$"
+	}
+	start := i + len(delim)
+	end := start
+	for end < len(src) {
+		if src[end] == '\\' {
+			end += 2
+			continue
+		}
+		if strings.HasPrefix(src[end:], delim) {
+			break
+		}
+		end++
+	}
+	if end > len(src) {
+		end = len(src)
+	}
+	c.writeMath(delim, src[start:end])
+	return min(end+len(delim), len(src))
+}
+
+func (c *latexConv) writeMath(delim, body string) {
+	body = latexStripLabels(body)
+	if body = strings.Join(strings.Fields(body), " "); body != "" {
+		c.para.WriteString(delim + body + delim)
+	}
+}
+
+// latexStripLabels removes \label{...} from math so equation labels do not
+// end up in the text.
+func latexStripLabels(s string) string {
+	for {
+		i := strings.Index(s, `\label{`)
+		if i < 0 {
+			return s
+		}
+		_, n := latexGroup(s[i+len(`\label`):])
+		s = s[:i] + s[i+len(`\label`)+n:]
+	}
+}
+
+// latexGroup reads a braced argument at the start of s, skipping leading
+// whitespace. It returns the argument without braces and the number of
+// bytes consumed, or a single token when s does not start with a brace.
+func latexGroup(s string) (string, int) {
+	i := 0
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r') {
+		i++
+	}
+	if i >= len(s) {
+		return "", i
+	}
+	if s[i] != '{' {
+		if s[i] == '\\' {
+			j := i + 1
+			for j < len(s) && isASCIILetter(s[j]) {
+				j++
+			}
+			if j == i+1 && j < len(s) {
+				j++
+			}
+			return s[i:j], j
+		}
+		return s[i : i+1], i + 1
+	}
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return s[i+1 : j], j + 1
+			}
+		}
+	}
+	return s[i+1:], len(s)
+}
+
+// latexOptional skips a bracketed optional argument at the start of s and
+// returns it with the number of bytes consumed.
+func latexOptional(s string) (string, int) {
+	i := 0
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	if i >= len(s) || s[i] != '[' {
+		return "", 0
+	}
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ']':
+			if depth == 0 {
+				return s[i+1 : j], j + 1
+			}
+		}
+	}
+	return "", 0
+}
+
+// latexEnvBody returns the content of the environment starting at s (just
+// after \begin{name}) and the bytes consumed through the matching \end.
+func latexEnvBody(s, name string) (string, int) {
+	begin, end := `\begin{`+name+`}`, `\end{`+name+`}`
+	depth := 1
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], begin):
+			depth++
+			i += len(begin)
+		case strings.HasPrefix(s[i:], end):
+			depth--
+			if depth == 0 {
+				return s[:i], i + len(end)
+			}
+			i += len(end)
+		default:
+			i++
+		}
+	}
+	return s, len(s)
+}
+
+// command handles the control sequence at src[i] and returns the index
+// after it and any arguments it consumed.
+func (c *latexConv) command(src string, i int) int {
+	j := i + 1
+	if j >= len(src) {
+		return j
+	}
+	if !isASCIILetter(src[j]) {
+		sym := src[j]
+		j++
+		switch sym {
+		case '\\':
+			if c.inline {
+				c.para.WriteByte(' ')
+			} else {
+				c.para.WriteByte('\n')
+			}
+			if _, n := latexOptional(src[j:]); n > 0 {
+				j += n
+			}
+		case '&', '%', '$', '#', '_', '{', '}':
+			c.para.WriteByte(sym)
+		case ',', ';', ':', ' ', '!':
+			c.para.WriteByte(' ')
+		case '(', '[':
+			closer := `\)`
+			delim := "$"
+			if sym == '[' {
+				closer, delim = `\]`, "$$"
+			}
+			end := strings.Index(src[j:], closer)
+			if end < 0 {
+				end = len(src) - j
+			}
+			c.writeMath(delim, src[j:j+end])
+			return min(j+end+len(closer), len(src))
+		default:
+			if table := latexAccents[sym]; table != nil {
+				arg, n := latexGroup(src[j:])
+				j += n
+				if r, ok := table[firstByte(arg)]; ok && len(arg) == 1 {
+					c.para.WriteString(r)
+				} else {
+					c.para.WriteString(arg)
+				}
+			}
+		}
+		return j
+	}
+
+	for j < len(src) && isASCIILetter(src[j]) {
+		j++
+	}
+	name := src[i+1 : j]
+	if j < len(src) && src[j] == '*' {
+		j++
+	}
+	rest := src[j:]
+	if len(name) == 1 {
+		if table := latexAccents[name[0]]; table != nil {
+			arg, n := latexGroup(rest)
+			if r, ok := table[firstByte(arg)]; ok && len(arg) == 1 {
+				c.para.WriteString(r)
+			} else {
+				c.para.WriteString(arg)
+			}
+			return j + n
+		}
+	}
+
+	switch {
+	case latexSectionRank[name] > 0:
+		_, n := latexOptional(rest)
+		arg, m := latexGroup(rest[n:])
+		c.block(latexSectionRank[name], latexInline(arg))
+		return j + n + m
+	case name == "title" || name == "author":
+		_, n := latexOptional(rest)
+		arg, m := latexGroup(rest[n:])
+		if c.meta != nil {
+			if v := latexInline(latexStripThanks(arg)); v != "" {
+				c.meta[name] = strings.ReplaceAll(v, " ,", ",")
+			}
+		}
+		return j + n + m
+	case name == "begin":
+		arg, n := latexGroup(rest)
+		return j + n + c.environment(strings.TrimSpace(arg), rest[n:])
+	case name == "end":
+		arg, n := latexGroup(rest)
+		if strings.TrimSpace(arg) == "document" && !c.inline {
+			c.done = true
+		}
+		return j + n
+	case name == "par":
+		if !c.inline {
+			c.flush()
+		}
+	case name == "item":
+		label, n := latexOptional(rest)
+		if c.inline {
+			c.para.WriteString(" ")
+		} else {
+			c.flush()
+		}
+		c.para.WriteString("- ")
+		if label != "" {
+			c.para.WriteString(latexInline(label) + ": ")
+		}
+		return j + n
+	case name == "caption":
+		_, n := latexOptional(rest)
+		arg, m := latexGroup(rest[n:])
+		c.block(0, latexInline(arg))
+		return j + n + m
+	case name == "footnote":
+		_, n := latexOptional(rest)
+		arg, m := latexGroup(rest[n:])
+		c.para.WriteString(" (" + latexInline(arg) + ")")
+		return j + n + m
+	case strings.HasSuffix(name, "cite") || name == "citep" || name == "citet":
+		n := 0
+		for {
+			_, m := latexOptional(rest[n:])
+			if m == 0 {
+				break
+			}
+			n += m
+		}
+		arg, m := latexGroup(rest[n:])
+		keys := strings.Split(arg, ",")
+		for k := range keys {
+			keys[k] = strings.TrimSpace(keys[k])
+		}
+		c.para.WriteString("[" + strings.Join(keys, ", ") + "]")
+		return j + n + m
+	case name == "url":
+		arg, n := latexGroup(rest)
+		c.para.WriteString(arg)
+		return j + n
+	case name == "href" || name == "textcolor" || name == "colorbox":
+		_, n := latexGroup(rest)
+		arg, m := latexGroup(rest[n:])
+		c.para.WriteString(latexInline(arg))
+		return j + n + m
+	case name == "def":
+		_, n := latexGroup(rest)
+		for n < len(rest) && rest[n] != '{' {
+			n++
+		}
+		_, m := latexGroup(rest[n:])
+		return j + n + m
+	case latexDropArgs[name] > 0:
+		n := 0
+		for k := 0; k < latexDropArgs[name]; k++ {
+			for {
+				_, m := latexOptional(rest[n:])
+				if m == 0 {
+					break
+				}
+				n += m
+			}
+			_, m := latexGroup(rest[n:])
+			n += m
+		}
+		return j + n
+	case latexKeepArg[name]:
+		arg, n := latexGroup(rest)
+		c.para.WriteString(latexInline(arg))
+		return j + n
+	case latexSymbols[name] != "":
+		c.para.WriteString(latexSymbols[name])
+	}
+	// Swallow the space that terminates a control word.
+	if j < len(src) && src[j] == ' ' && latexSymbols[name] == "" {
+		j++
+	}
+	return j
+}
+
+// latexStripThanks drops \thanks footnotes from title and author values.
+func latexStripThanks(s string) string {
+	for {
+		i := strings.Index(s, `\thanks`)
+		if i < 0 {
+			return s
+		}
+		_, n := latexGroup(s[i+len(`\thanks`):])
+		s = s[:i] + s[i+len(`\thanks`)+n:]
+	}
+}
+
+func firstByte(s string) byte {
+	if s == "" {
+		return 0
+	}
+	return s[0]
+}
+
+// environment renders the environment whose body starts at rest and returns
+// the bytes consumed. Environments without special handling are transparent.
+func (c *latexConv) environment(name, rest string) int {
+	base := strings.TrimSuffix(name, "*")
+	switch base {
+	case "document":
+		c.discard = false
+		return 0
+	case "abstract":
+		c.block(latexSectionRank["section"], "Abstract")
+		return 0
+	case "equation", "align", "gather", "multline", "eqnarray", "displaymath",
+		"math", "flalign", "alignat", "split":
+		body, n := latexEnvBody(rest, name)
+		c.writeMath("$$", body)
+		return n
+	case "verbatim", "lstlisting", "minted", "Verbatim", "code":
+		body, n := latexEnvBody(rest, name)
+		_, skip := latexOptional(body)
+		body = body[skip:]
+		if base == "minted" {
+			_, m := latexGroup(body)
+			body = body[m:]
+		}
+		code := strings.Trim(body, "\n")
+		if c.inline {
+			c.para.WriteString(" " + code + " ")
+		} else {
+			c.flush()
+			if !c.discard && strings.TrimSpace(code) != "" {
+				c.blocks = append(c.blocks, latexBlock{text: "```\n" + code + "\n```"})
+			}
+		}
+		return n
+	case "itemize", "enumerate", "description":
+		body, n := latexEnvBody(rest, name)
+		c.block(0, strings.Join(latexList(base, body, 0), "\n"))
+		return n
+	case "tabular", "tabularx", "longtable", "tabu":
+		body, n := latexEnvBody(rest, name)
+		c.block(0, latexTable(body))
+		return n
+	case "comment", "tikzpicture", "pgfpicture", "filecontents":
+		_, n := latexEnvBody(rest, name)
+		return n
+	case "minipage", "wrapfigure":
+		_, n := latexOptional(rest)
+		_, m := latexGroup(rest[n:])
+		return n + m
+	}
+	// Placement options such as \begin{figure}[h].
+	_, n := latexOptional(rest)
+	return n
+}
+
+// latexList renders list items as "-" or "1." lines, indenting nested
+// lists by two spaces per level.
+func latexList(env, body string, depth int) []string {
+	var lines []string
+	for k, item := range latexItems(body) {
+		var text strings.Builder
+		var nested []string
+		rest := item
+		for {
+			i, name := latexNextList(rest)
+			if i < 0 {
+				text.WriteString(rest)
+				break
+			}
+			text.WriteString(rest[:i])
+			open := len(`\begin{` + name + `}`)
+			inner, n := latexEnvBody(rest[i+open:], name)
+			nested = append(nested, latexList(strings.TrimSuffix(name, "*"), inner, depth+1)...)
+			rest = rest[i+open+n:]
+		}
+		label, n := latexOptional(text.String())
+		line := latexInline(text.String()[n:])
+		if label != "" {
+			line = latexInline(label) + ": " + line
+		}
+		marker := "-"
+		if env == "enumerate" {
+			marker = strconv.Itoa(k+1) + "."
+		}
+		lines = append(lines, strings.Repeat("  ", depth)+marker+" "+line)
+		lines = append(lines, nested...)
+	}
+	return lines
+}
+
+// latexItems splits a list body at its own \item commands, ignoring those
+// of nested environments.
+func latexItems(body string) []string {
+	var items []string
+	depth, start := 0, -1
+	for i := 0; i < len(body); i++ {
+		switch {
+		case strings.HasPrefix(body[i:], `\begin{`):
+			depth++
+		case strings.HasPrefix(body[i:], `\end{`):
+			depth--
+		case depth == 0 && strings.HasPrefix(body[i:], `\item`) &&
+			(i+5 == len(body) || !isASCIILetter(body[i+5])):
+			if start >= 0 {
+				items = append(items, body[start:i])
+			}
+			start = i + 5
+			i += 4
+		case body[i] == '\\':
+			i++
+		}
+	}
+	if start >= 0 {
+		items = append(items, body[start:])
+	}
+	return items
+}
+
+// latexNextList finds the next nested list environment in s.
+func latexNextList(s string) (int, string) {
+	best, name := -1, ""
+	for _, env := range []string{"itemize", "enumerate", "description"} {
+		if i := strings.Index(s, `\begin{`+env+`}`); i >= 0 && (best < 0 || i < best) {
+			best, name = i, env
+		}
+	}
+	return best, name
+}
+
+// latexTable renders tabular rows as "a | b" lines.
+func latexTable(body string) string {
+	_, n := latexOptional(body)
+	body = body[n:]
+	_, n = latexGroup(body) // column specification
+	body = body[n:]
+
+	var lines []string
+	for _, row := range latexSplit(body, `\\`) {
+		var cells []string
+		for _, cell := range latexSplit(row, "&") {
+			cells = append(cells, strings.ReplaceAll(latexInline(cell), "|", "/"))
+		}
+		line := strings.TrimSpace(strings.Join(cells, " | "))
+		if strings.Trim(line, "| ") != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// latexSplit splits s at sep outside braces, ignoring escaped separators.
+func latexSplit(s, sep string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], sep) && depth == 0 && (sep == `\\` || i == 0 || s[i-1] != '\\'):
+			parts = append(parts, s[start:i])
+			i += len(sep) - 1
+			start = i + 1
+		case s[i] == '\\':
+			i++
+		case s[i] == '{':
+			depth++
+		case s[i] == '}':
+			depth--
+		}
+	}
+	return append(parts, s[start:])
+}
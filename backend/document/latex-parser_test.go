@@ -0,0 +1,55 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+const latexSample = `\documentclass{article}
+\usepackage{amsmath}
+\title{On \emph{Sums}}
+\author{Ada Lovelace}
+\begin{document}
+\maketitle
+\section{Intro}\label{sec:intro}
+We show that $a+b = b+a$ holds --- see Section~\ref{sec:intro}. % a comment
+\subsection{Details}
+\begin{itemize}
+\item First \textbf{point}
+\item Caf\'e
+\end{itemize}
+\begin{equation}
+x^2 \label{eq:sq}
+\end{equation}
+\begin{tabular}{ll}
+a & b \\
+c & d \\
+\end{tabular}
+\begin{comment}
+Hidden text
+\end{comment}
+\end{document}
+`
+
+func TestLaTeXParser(t *testing.T) {
+	doc, err := NewLaTeXParser().Parse([]byte(latexSample), "a.tex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# Intro", "## Details", "We show that $a+b = b+a$ holds — see Section", "- First point\n- Café", "$$x^2$$", "a | b\nc | d"} {
+		if !strings.Contains(doc.Content, want) {
+			t.Errorf("content %q does not contain %q", doc.Content, want)
+		}
+	}
+	for _, gone := range []string{"amsmath", "sec:intro", "eq:sq", "comment", "Hidden", `\`} {
+		if strings.Contains(doc.Content, gone) {
+			t.Errorf("content %q contains %q", doc.Content, gone)
+		}
+	}
+	if len(doc.Headings) != 2 || doc.Headings[0].Level != 1 || doc.Headings[1].Level != 2 {
+		t.Errorf("headings %+v", doc.Headings)
+	}
+	if doc.Metadata["title"] != "On Sums" || doc.Metadata["author"] != "Ada Lovelace" {
+		t.Errorf("metadata %v", doc.Metadata)
+	}
+}
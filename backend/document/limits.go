@@ -0,0 +1,104 @@
+package document
+
+import (
+	"fmt"
+	"io"
+)
+
+// Limits are hard caps on the resources one document may use, so that a
+// hostile upload cannot exhaust memory. Exceeding one fails with an error
+// wrapping ErrTooLarge. Zero fields are not enforced.
+type Limits struct {
+	// MaxInputBytes limits the size of the raw input.
+	MaxInputBytes int64
+	// MaxDecompressedBytes limits the bytes an archive, a ZIP based
+	// format such as DOCX or EPUB, or the streams of a PDF may expand to.
+	MaxDecompressedBytes int64
+	// MaxChunks limits the number of chunks per document.
+	MaxChunks int
+	// MaxArchiveDepth limits how many archives may be nested.
+	MaxArchiveDepth int
+}
+
+// DefaultLimits are the limits of a new Pipeline.
+var DefaultLimits = Limits{
+	MaxInputBytes:        100 << 20,
+	MaxDecompressedBytes: 100 << 20,
+	MaxChunks:            100000,
+	MaxArchiveDepth:      3,
+}
+
+// checkInput fails if an input of size bytes is too large.
+func (l Limits) checkInput(filename string, size int64) error {
+	if l.MaxInputBytes > 0 && size > l.MaxInputBytes {
+		return fmt.Errorf("%w: %s is %d bytes, over the limit of %d", ErrTooLarge, filename, size, l.MaxInputBytes)
+	}
+	return nil
+}
+
+// checkChunks fails if a document produced too many chunks.
+func (l Limits) checkChunks(filename string, n int) error {
+	if l.MaxChunks > 0 && n > l.MaxChunks {
+		return fmt.Errorf("%w: %s yields %d chunks, over the limit of %d", ErrTooLarge, filename, n, l.MaxChunks)
+	}
+	return nil
+}
+
+// parser returns p with its own limits tightened to l, for parsers that
+// expand their input.
+func (l Limits) parser(p Parser) Parser {
+	switch p := p.(type) {
+	case *ArchiveParser:
+		c := *p
+		c.MaxTotalSize = tighten(c.MaxTotalSize, l.MaxDecompressedBytes)
+		c.MaxDepth = int(tighten(int64(c.MaxDepth), int64(l.MaxArchiveDepth)))
+		return &c
+	case *PDFParser:
+		c := *p
+		c.MaxDecodedBytes = tighten(c.MaxDecodedBytes, l.MaxDecompressedBytes)
+		return &c
+	case *DocxParser:
+		c := *p
+		c.MaxDecompressedBytes = tighten(c.MaxDecompressedBytes, l.MaxDecompressedBytes)
+		return &c
+	case *XLSXParser:
+		c := *p
+		c.MaxDecompressedBytes = tighten(c.MaxDecompressedBytes, l.MaxDecompressedBytes)
+		return &c
+	case *PPTXParser:
+		c := *p
+		c.MaxDecompressedBytes = tighten(c.MaxDecompressedBytes, l.MaxDecompressedBytes)
+		return &c
+	case *ODFParser:
+		c := *p
+		c.MaxDecompressedBytes = tighten(c.MaxDecompressedBytes, l.MaxDecompressedBytes)
+		return &c
+	case *EPUBParser:
+		c := *p
+		c.MaxDecompressedBytes = tighten(c.MaxDecompressedBytes, l.MaxDecompressedBytes)
+		return &c
+	}
+	return p
+}
+
+// tighten returns limit lowered to max, where a limit or max that is not
+// positive is unset.
+func tighten(limit, max int64) int64 {
+	if max > 0 && (limit <= 0 || limit > max) {
+		return max
+	}
+	return limit
+}
+
+// readAllLimited reads r to the end, failing once it yields more than max
+// bytes.
+func readAllLimited(r io.Reader, max int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("%w: stream inflates to more than %d bytes", ErrTooLarge, max)
+	}
+	return data, nil
+}
@@ -0,0 +1,77 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPipelineLimits(t *testing.T) {
+	inner := zipFiles(t, "a.txt", "Deep text here.")
+	nested := zipFiles(t, "mid.zip", string(zipFiles(t, "inner.zip", string(inner))))
+	bomb := zipFiles(t, "[Content_Types].xml", "<Types/>", "word/document.xml",
+		`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body><w:p><w:r><w:t>`+
+			strings.Repeat(" ", 1<<20)+"bomb</w:t></w:r></w:p></w:body></w:document>")
+	tests := []struct {
+		name            string
+		limits          Limits
+		p               *Pipeline
+		input           string
+		file            string
+		fails, tooLarge bool
+	}{
+		{"input", Limits{MaxInputBytes: 3}, NewPipeline(), "abcd", "a.txt", true, true},
+		{"chunks", Limits{MaxChunks: 1}, NewPipeline().Chunker("paragraph", WithChunkSize(1)), "a\n\nb", "a.txt", true, true},
+		{"decompressed", Limits{MaxDecompressedBytes: 10}, NewPipeline(), string(nested), "outer.zip", true, true},
+		// Archives nested deeper than the limit are skipped, leaving
+		// nothing to parse.
+		{"depth", Limits{MaxArchiveDepth: 1}, NewPipeline(), string(nested), "outer.zip", true, false},
+		{"within limits", Limits{MaxArchiveDepth: 3}, NewPipeline(), string(nested), "outer.zip", false, false},
+		{"docx bomb", Limits{MaxDecompressedBytes: 64 << 10}, NewPipeline(), string(bomb), "bomb.docx", true, true},
+		{"docx within limits", Limits{MaxDecompressedBytes: 2 << 20}, NewPipeline(), string(bomb), "bomb.docx", false, false},
+	}
+	for _, tt := range tests {
+		_, _, err := tt.p.Limits(tt.limits).Process(context.Background(), []byte(tt.input), tt.file)
+		if (err != nil) != tt.fails || errors.Is(err, ErrTooLarge) != tt.tooLarge {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+	}
+}
+
+func TestLimitsParser(t *testing.T) {
+	l := Limits{MaxDecompressedBytes: 100, MaxArchiveDepth: 2}
+	tests := []struct {
+		parser Parser
+		limit  func(Parser) int64
+	}{
+		{&ArchiveParser{MaxTotalSize: 1000}, func(p Parser) int64 { return p.(*ArchiveParser).MaxTotalSize }},
+		{&PDFParser{}, func(p Parser) int64 { return p.(*PDFParser).MaxDecodedBytes }},
+		{&DocxParser{MaxDecompressedBytes: 1000}, func(p Parser) int64 { return p.(*DocxParser).MaxDecompressedBytes }},
+		{&XLSXParser{}, func(p Parser) int64 { return p.(*XLSXParser).MaxDecompressedBytes }},
+		{&PPTXParser{}, func(p Parser) int64 { return p.(*PPTXParser).MaxDecompressedBytes }},
+		{&ODFParser{}, func(p Parser) int64 { return p.(*ODFParser).MaxDecompressedBytes }},
+		{&EPUBParser{}, func(p Parser) int64 { return p.(*EPUBParser).MaxDecompressedBytes }},
+	}
+	for _, tt := range tests {
+		got := l.parser(tt.parser)
+		if got == tt.parser || tt.limit(got) != 100 {
+			t.Errorf("%T: limit %d, want a copy limited to 100", tt.parser, tt.limit(got))
+		}
+		if tt.limit(tt.parser) == 100 {
+			t.Errorf("%T: tightened the parser passed in", tt.parser)
+		}
+	}
+	if a := l.parser(&ArchiveParser{MaxTotalSize: 10, MaxDepth: 5}).(*ArchiveParser); a.MaxTotalSize != 10 || a.MaxDepth != 2 {
+		t.Errorf("archive limits %d, %d, want 10, 2", a.MaxTotalSize, a.MaxDepth)
+	}
+}
+
+func TestReadAllLimited(t *testing.T) {
+	if data, err := readAllLimited(strings.NewReader("abcde"), 5); err != nil || string(data) != "abcde" {
+		t.Errorf("at the limit: %q, %v", data, err)
+	}
+	if _, err := readAllLimited(strings.NewReader("abcdef"), 5); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("over the limit: err = %v", err)
+	}
+}
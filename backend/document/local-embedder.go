@@ -0,0 +1,93 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Local inference servers supported by LocalEmbedder.
+const (
+	// LlamaCppServer is llama.cpp's llama-server started with --embedding,
+	// which serves the OpenAI-compatible /v1/embeddings endpoint.
+	LlamaCppServer = "llama.cpp"
+	// TEIServer is Hugging Face text-embeddings-inference, which serves
+	// /embed.
+	TEIServer = "tei"
+)
+
+// LocalEmbedder embeds texts with an inference server on the local
+// machine or network, such as llama.cpp or text-embeddings-inference
+// running an ONNX or GGUF model, so documents never leave it.
+type LocalEmbedder struct {
+	// Endpoint is the base URL of the server. Defaults to
+	// http://localhost:8080.
+	Endpoint string
+	// Server is LlamaCppServer or TEIServer. Defaults to LlamaCppServer.
+	Server string
+	// Model is sent to servers that host several models.
+	Model string
+	// BatchSize is the number of texts sent per request. Defaults to 32.
+	BatchSize int
+	// MaxRetries is the number of times a failed request is retried.
+	// Defaults to 3; negative turns retries off.
+	MaxRetries int
+	// Client defaults to an http.Client with a five minute timeout, as
+	// local models on a CPU can be slow.
+	Client *http.Client
+}
+
+// NewLocalEmbedder creates an embedder for the server of the given kind
+// at endpoint.
+func NewLocalEmbedder(server, endpoint string) *LocalEmbedder {
+	return &LocalEmbedder{Server: server, Endpoint: endpoint}
+}
+
+// Embed returns the embedding of each text, in order.
+func (e *LocalEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	endpoint := strings.TrimSuffix(e.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "http://localhost:8080"
+	}
+	batchSize := e.BatchSize
+	if batchSize <= 0 {
+		batchSize = 32
+	}
+	client := httpClient(e.Client, 5*time.Minute)
+	switch e.Server {
+	case "", LlamaCppServer:
+		oa := &OpenAIEmbedder{
+			Endpoint:   endpoint + "/v1/embeddings",
+			Model:      e.Model,
+			BatchSize:  batchSize,
+			MaxRetries: e.MaxRetries,
+			Client:     client,
+		}
+		if oa.Model == "" {
+			// llama-server ignores the model, but OpenAIEmbedder would
+			// otherwise send its own default.
+			oa.Model = "default"
+		}
+		return oa.Embed(ctx, texts)
+	case TEIServer:
+		return embedBatches(texts, batchSize, func(batch []string) ([][]float32, error) {
+			body := map[string]any{"inputs": batch, "truncate": true}
+			data, err := retryPolicy{maxRetries: e.MaxRetries}.sendJSON(ctx, client, http.MethodPost, endpoint+"/embed", nil, body)
+			if err != nil {
+				return nil, fmt.Errorf("embedding request: %w", err)
+			}
+			var out [][]float32
+			if err := json.Unmarshal(data, &out); err != nil {
+				return nil, fmt.Errorf("decode embeddings: %w", err)
+			}
+			if len(out) != len(batch) {
+				return nil, fmt.Errorf("server returned %d embeddings for %d inputs", len(out), len(batch))
+			}
+			return out, nil
+		})
+	}
+	return nil, fmt.Errorf("unknown embedding server %q", e.Server)
+}
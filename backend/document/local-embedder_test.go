@@ -0,0 +1,87 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLocalEmbedder(t *testing.T) {
+	var paths, models []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/v1/embeddings":
+			var req struct {
+				Model string   `json:"model"`
+				Input []string `json:"input"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			models = append(models, req.Model)
+			var resp struct {
+				Data []map[string]any `json:"data"`
+			}
+			for i, s := range req.Input {
+				resp.Data = append(resp.Data, map[string]any{"index": i, "embedding": []float32{float32(len(s))}})
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "/embed":
+			var req struct {
+				Inputs   []string `json:"inputs"`
+				Truncate bool     `json:"truncate"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			if !req.Truncate {
+				http.Error(w, "truncate not set", http.StatusBadRequest)
+				return
+			}
+			out := [][]float32{}
+			for _, s := range req.Inputs {
+				out = append(out, []float32{float32(len(s)), 1})
+			}
+			if len(req.Inputs) == 3 {
+				out = out[:2]
+			}
+			json.NewEncoder(w).Encode(out)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+	texts := []string{"a", "bb", "ccc"}
+
+	got, err := NewLocalEmbedder("", srv.URL+"/").Embed(ctx, texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]float32{{1}, {2}, {3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("llama.cpp vectors %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(models, []string{"default"}) {
+		t.Errorf("llama.cpp models %q", models)
+	}
+
+	tei := &LocalEmbedder{Server: TEIServer, Endpoint: srv.URL, BatchSize: 2}
+	got, err = tei.Embed(ctx, texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]float32{{1, 1}, {2, 1}, {3, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TEI vectors %v, want %v", got, want)
+	}
+	if want := []string{"/v1/embeddings", "/embed", "/embed"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths %q, want %q", paths, want)
+	}
+
+	tei.BatchSize = 3
+	if _, err := tei.Embed(ctx, texts); err == nil {
+		t.Error("short TEI response returned no error")
+	}
+	if _, err := NewLocalEmbedder("ollama", srv.URL).Embed(ctx, texts); err == nil {
+		t.Error("unknown server returned no error")
+	}
+}
@@ -0,0 +1,291 @@
+package document
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogParser splits log files into records at lines that start with a
+// timestamp, so continuation lines such as stack traces stay with the entry
+// that produced them. Timestamps without a zone are taken as UTC.
+type LogParser struct {
+	// Window groups records into documents spanning at most this long in
+	// ParseAll. Defaults to one minute.
+	Window time.Duration
+	// Year completes syslog timestamps, which omit it. Defaults to the
+	// current year.
+	Year int
+}
+
+// NewLogParser creates a new log parser instance.
+func NewLogParser() *LogParser {
+	return &LogParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *LogParser) Supports(mimeType string) bool {
+	return mimeType == "text/x-log" || mimeType == "application/x-log"
+}
+
+// logRecord is one log entry and the lines that continue it.
+type logRecord struct {
+	time  time.Time
+	level string
+	text  string
+}
+
+// Parse keeps the log text and records each entry in Document.Segments
+// with its "timestamp" (RFC 3339) and "level" when present. Metadata
+// records the number of "records" and the "start" and "end" times.
+func (p *LogParser) Parse(buffer []byte, filename string) (*Document, error) {
+	records, err := p.records(buffer)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	segments := make([]Segment, 0, len(records))
+	for _, r := range records {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		start := b.Len()
+		b.WriteString(r.text)
+		segments = append(segments, Segment{Start: start, End: b.Len(), Metadata: logMetadata(r)})
+	}
+	content := b.String()
+	meta := map[string]string{"records": strconv.Itoa(len(records))}
+	logSpan(meta, records)
+	return &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Segments:  segments,
+		Metadata:  meta,
+	}, nil
+}
+
+// ParseAll returns one document per Window of log time. Each document's
+// metadata holds the "start" and "end" timestamps it covers, the number of
+// "records" and the most severe "level" seen.
+func (p *LogParser) ParseAll(buffer []byte, filename string) ([]*Document, error) {
+	records, err := p.records(buffer)
+	if err != nil {
+		return nil, err
+	}
+	window := p.Window
+	if window <= 0 {
+		window = time.Minute
+	}
+	var docs []*Document
+	flush := func(group []logRecord) {
+		if len(group) == 0 {
+			return
+		}
+		lines := make([]string, len(group))
+		level := ""
+		for i, r := range group {
+			lines[i] = r.text
+			if logSeverity(r.level) > logSeverity(level) {
+				level = r.level
+			}
+		}
+		content := strings.Join(lines, "\n")
+		meta := map[string]string{"records": strconv.Itoa(len(group))}
+		logSpan(meta, group)
+		if level != "" {
+			meta["level"] = level
+		}
+		docs = append(docs, &Document{
+			Content:   content,
+			Source:    filename,
+			WordCount: len(strings.Fields(content)),
+			Metadata:  meta,
+		})
+	}
+	var group []logRecord
+	var start time.Time
+	for _, r := range records {
+		if !r.time.IsZero() {
+			if start.IsZero() {
+				start = r.time
+			} else if r.time.Sub(start) >= window || r.time.Before(start) {
+				flush(group)
+				group, start = nil, r.time
+			}
+		}
+		group = append(group, r)
+	}
+	flush(group)
+	return docs, nil
+}
+
+func logMetadata(r logRecord) map[string]string {
+	meta := map[string]string{}
+	if !r.time.IsZero() {
+		meta["timestamp"] = r.time.Format(time.RFC3339Nano)
+	}
+	if r.level != "" {
+		meta["level"] = r.level
+	}
+	return meta
+}
+
+// logSpan records the first and last timestamps of records in meta.
+func logSpan(meta map[string]string, records []logRecord) {
+	for _, r := range records {
+		if !r.time.IsZero() {
+			meta["start"] = r.time.Format(time.RFC3339Nano)
+			break
+		}
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		if !records[i].time.IsZero() {
+			meta["end"] = records[i].time.Format(time.RFC3339Nano)
+			break
+		}
+	}
+}
+
+// records groups lines into entries. Lines before the first timestamp form
+// an entry of their own, without a time.
+func (p *LogParser) records(buffer []byte) ([]logRecord, error) {
+	year := p.Year
+	if year == 0 {
+		year = time.Now().Year()
+	}
+	src := decodeText(buffer)
+	var records []logRecord
+	var lines []string
+	var cur logRecord
+	flush := func() {
+		if text := strings.TrimRight(strings.Join(lines, "\n"), " \t\n"); strings.TrimSpace(text) != "" {
+			cur.text = text
+			records = append(records, cur)
+		}
+		lines = nil
+	}
+	for _, line := range strings.Split(src, "\n") {
+		if ts, ok := logTimestamp(line, year); ok {
+			flush()
+			cur = logRecord{time: ts, level: logLevel(line)}
+		} else if len(lines) == 0 && len(records) == 0 {
+			cur = logRecord{level: logLevel(line)}
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	if len(records) == 0 {
+		return nil, ErrEmptyDocument
+	}
+	return records, nil
+}
+
+// logFormats lists the recognised timestamp shapes and the layouts that
+// parse them. Each pattern is matched at the start of a line, allowing for
+// an opening bracket or a syslog priority.
+var logFormats = []struct {
+	pattern *regexp.Regexp
+	layouts []string
+}{
+	// ISO 8601, including the "2006-01-02 15:04:05,000" form of log4j and
+	// Python logging.
+	{regexp.MustCompile(`^(?:<\d+>\d? ?)?\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?: ?Z|[+-]\d{2}:?\d{2})?)`),
+		[]string{"2006-01-02T15:04:05Z07:00", "2006-01-02T15:04:05Z0700", "2006-01-02T15:04:05"}},
+	// Go's log package.
+	{regexp.MustCompile(`^\[?(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}(?:\.\d+)?)`),
+		[]string{"2006/01/02 15:04:05"}},
+	// Apache and nginx access logs.
+	{regexp.MustCompile(`^\S+ \S+ \S+ \[(\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4})\]`),
+		[]string{"02/Jan/2006:15:04:05 -0700"}},
+	// BSD syslog, without a year.
+	{regexp.MustCompile(`^(?:<\d+>)?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2})\b`),
+		[]string{"Jan _2 15:04:05"}},
+	// Unix seconds or milliseconds.
+	{regexp.MustCompile(`^\[?(\d{10}(?:\.\d{1,9})?|\d{13})\b`), nil},
+}
+
+// logTimestamp parses the timestamp a line starts with.
+func logTimestamp(line string, year int) (time.Time, bool) {
+	for _, f := range logFormats {
+		m := f.pattern.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		s := m[1]
+		if f.layouts == nil {
+			return logEpoch(s)
+		}
+		if strings.HasPrefix(f.layouts[0], "2006-") {
+			s = strings.Replace(strings.Replace(s, " ", "T", 1), ",", ".", 1)
+			s = strings.Replace(s, " Z", "Z", 1)
+		}
+		for _, layout := range f.layouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				if t.Year() == 0 {
+					t = t.AddDate(year, 0, 0)
+				}
+				return t.UTC(), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func logEpoch(s string) (time.Time, bool) {
+	if len(s) == 13 {
+		ms, err := strconv.ParseInt(s, 10, 64)
+		return time.UnixMilli(ms).UTC(), err == nil
+	}
+	sec, frac, _ := strings.Cut(s, ".")
+	n, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var nanos int64
+	if frac != "" {
+		nanos, _ = strconv.ParseInt((frac + "000000000")[:9], 10, 64)
+	}
+	return time.Unix(n, nanos).UTC(), true
+}
+
+var logLevelPattern = regexp.MustCompile(`\b(TRACE|DEBUG|INFO|NOTICE|WARN(?:ING)?|ERROR|ERR|CRIT(?:ICAL)?|FATAL|PANIC|[Tt]race|[Dd]ebug|[Ii]nfo|[Ww]arn(?:ing)?|[Ee]rror|[Ff]atal)\b|\blevel=(\w+)`)
+
+// logLevel finds the severity named on a record's first line and
+// normalises it to lower case ("warn", "error" and so on).
+func logLevel(line string) string {
+	m := logLevelPattern.FindStringSubmatch(line)
+	if m == nil {
+		return ""
+	}
+	level := strings.ToLower(m[1] + m[2])
+	switch level {
+	case "warning":
+		return "warn"
+	case "err":
+		return "error"
+	case "crit":
+		return "critical"
+	}
+	return level
+}
+
+// logSeverity orders levels from least to most severe.
+func logSeverity(level string) int {
+	switch level {
+	case "trace":
+		return 1
+	case "debug":
+		return 2
+	case "info", "notice":
+		return 3
+	case "warn":
+		return 4
+	case "error":
+		return 5
+	case "critical", "fatal", "panic":
+		return 6
+	}
+	return 0
+}
@@ -0,0 +1,65 @@
+package document
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLogTimestamp(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"2024-01-02T03:04:05Z INFO start", "2024-01-02T03:04:05Z"},
+		{"2024-01-02 03:04:05,250 WARNING disk", "2024-01-02T03:04:05.25Z"},
+		{"[2024-01-02T03:04:05+02:00] boot", "2024-01-02T01:04:05Z"},
+		{"2024/01/02 03:04:05 listening", "2024-01-02T03:04:05Z"},
+		{`127.0.0.1 - - [02/Jan/2024:03:04:05 -0700] "GET / HTTP/1.1" 200`, "2024-01-02T10:04:05Z"},
+		{"<34>Jan  2 03:04:05 host sshd[1]: accepted", "2023-01-02T03:04:05Z"},
+		{"1704164645.5 tick", "2024-01-02T03:04:05.5Z"},
+		{"1704164645500 tick", "2024-01-02T03:04:05.5Z"},
+	}
+	for _, tt := range tests {
+		ts, ok := logTimestamp(tt.line, 2023)
+		if got := ts.Format(time.RFC3339Nano); !ok || got != tt.want {
+			t.Errorf("logTimestamp(%q) = %s, %v, want %s", tt.line, got, ok, tt.want)
+		}
+	}
+	if _, ok := logTimestamp("\tat main.go:12", 2023); ok {
+		t.Error("continuation line parsed as a timestamp")
+	}
+}
+
+func TestLogParser(t *testing.T) {
+	const log = "starting up\n" +
+		"2024-01-02T03:04:05Z INFO ready\n" +
+		"2024-01-02T03:04:30Z ERROR request failed\n" +
+		"panic: nil map\n" +
+		"\tat main.go:12\n" +
+		"2024-01-02T03:06:00Z level=warn slow\n"
+	doc, err := NewLogParser().Parse([]byte(log), "app.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Segments) != 4 {
+		t.Fatalf("%d records, want 4", len(doc.Segments))
+	}
+	seg := doc.Segments[2]
+	if got := doc.Content[seg.Start:seg.End]; got != "2024-01-02T03:04:30Z ERROR request failed\npanic: nil map\n\tat main.go:12" {
+		t.Errorf("record with stack trace is %q", got)
+	}
+	if seg.Metadata["level"] != "error" || seg.Metadata["timestamp"] != "2024-01-02T03:04:30Z" {
+		t.Errorf("record metadata %v", seg.Metadata)
+	}
+	if m := doc.Metadata; m["start"] != "2024-01-02T03:04:05Z" || m["end"] != "2024-01-02T03:06:00Z" || m["records"] != "4" {
+		t.Errorf("metadata %v", m)
+	}
+
+	docs, err := NewLogParser().ParseAll([]byte(log), "app.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[0].Metadata["level"] != "error" || docs[0].Metadata["records"] != "3" || docs[1].Metadata["level"] != "warn" {
+		t.Errorf("ParseAll returned %d windows", len(docs))
+	}
+}
@@ -0,0 +1,89 @@
+package document
+
+import (
+	"sort"
+	"strings"
+)
+
+// MarkdownChunker splits a document at its headings, so each chunk holds
+// one section, and records the enclosing headings as a breadcrumb. It
+// works on any document with Document.Headings, such as the output of
+// MarkdownParser, AsciiDocParser or HTMLParser.
+type MarkdownChunker struct {
+	// ChunkSize is the maximum chunk size in tokens. Larger sections are
+	// split further by RecursiveSplitter. Defaults to 200.
+	ChunkSize int
+	// MaxLevel is the deepest heading level that starts a new chunk;
+	// deeper headings stay inside their parent's chunk. Defaults to 6.
+	MaxLevel int
+	// Tokenizer measures chunk size. Defaults to DefaultTokenizer.
+	Tokenizer Tokenizer
+	// Overlap is the number of trailing tokens of each chunk repeated at
+	// the start of the next chunk of the same section, within ChunkSize.
+	Overlap int
+}
+
+// NewMarkdownChunker creates a new Markdown chunker instance
+// configured by opts.
+func NewMarkdownChunker(opts ...ChunkOption) *MarkdownChunker {
+	o := NewOptions(opts...)
+	return &MarkdownChunker{ChunkSize: o.ChunkSize, Tokenizer: o.Tokenizer, Overlap: o.Overlap}
+}
+
+// Chunk returns the sections of doc in order. Each chunk's metadata holds
+// "heading_path", the breadcrumb of its headings joined with " > " (such
+// as "Guide > Install > Linux"), and "heading", the innermost one. Text
+// before the first heading has neither. Sections are also split at page
+// boundaries, and each chunk records the pages it covers.
+func (c *MarkdownChunker) Chunk(doc *Document) []Chunk {
+	maxLevel := c.MaxLevel
+	if maxLevel <= 0 {
+		maxLevel = 6
+	}
+	var bounds []int
+	for _, h := range doc.Headings {
+		if h.Level <= maxLevel && h.Offset > 0 && h.Offset < len(doc.Content) {
+			bounds = append(bounds, h.Offset)
+		}
+	}
+	for _, pg := range doc.Pages {
+		if pg.Start > 0 && pg.Start < len(doc.Content) {
+			bounds = append(bounds, pg.Start)
+		}
+	}
+	sort.Ints(bounds)
+	bounds = append(bounds, len(doc.Content))
+
+	tok := c.Tokenizer
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	splitter := &RecursiveSplitter{ChunkSize: c.ChunkSize, Tokenizer: tok, Overlap: c.Overlap}
+	var chunks []Chunk
+	start := 0
+	for _, end := range bounds {
+		if end <= start {
+			continue
+		}
+		path := doc.HeadingPath(start)
+		for _, sp := range splitter.spans(doc.Content[start:end]) {
+			meta := map[string]string{}
+			if len(path) > 0 {
+				meta["heading_path"] = strings.Join(path, " > ")
+				meta["heading"] = path[len(path)-1]
+			}
+			chunks = append(chunks, Chunk{
+				Index:       len(chunks),
+				Text:        doc.Content[start+sp.start : start+sp.end],
+				StartOffset: start + sp.start,
+				EndOffset:   start + sp.end,
+				TokenCount:  tok.Count(doc.Content[start+sp.start : start+sp.end]),
+				PageStart:   doc.PageAt(start + sp.start),
+				PageEnd:     doc.PageAt(start + sp.end - 1),
+				Metadata:    meta,
+			})
+		}
+		start = end
+	}
+	return chunks
+}
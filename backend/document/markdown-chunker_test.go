@@ -0,0 +1,36 @@
+package document
+
+import "testing"
+
+func TestMarkdownChunker(t *testing.T) {
+	src := "Intro text.\n\n# Guide\n\nWelcome.\n\n## Install\n\nRun it.\n\n### Linux\n\nUse apt.\n\n## Usage\n\nCall it.\n"
+	doc, err := NewMarkdownParser().Parse([]byte(src), "guide.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ text, path string }{
+		{"Intro text.", ""},
+		{"# Guide\n\nWelcome.", "Guide"},
+		{"## Install\n\nRun it.\n\n### Linux\n\nUse apt.", "Guide > Install"},
+		{"## Usage\n\nCall it.", "Guide > Usage"},
+	}
+	chunks := (&MarkdownChunker{MaxLevel: 2}).Chunk(doc)
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks, want %d", len(chunks), len(want))
+	}
+	for i, c := range chunks {
+		if c.Text != want[i].text || c.Metadata["heading_path"] != want[i].path {
+			t.Errorf("chunk %d = %q under %q, want %q under %q", i, c.Text, c.Metadata["heading_path"], want[i].text, want[i].path)
+		}
+	}
+	if chunks[2].Metadata["heading"] != "Install" {
+		t.Errorf("heading = %q", chunks[2].Metadata["heading"])
+	}
+
+	// Sections over ChunkSize are split but keep their breadcrumb.
+	for _, c := range (&MarkdownChunker{ChunkSize: 1}).Chunk(doc) {
+		if c.Text == "apt." && c.Metadata["heading_path"] != "Guide > Install > Linux" {
+			t.Errorf("split chunk %q under %q", c.Text, c.Metadata["heading_path"])
+		}
+	}
+}
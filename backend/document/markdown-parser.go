@@ -0,0 +1,154 @@
+package document
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MarkdownParser parses Markdown while keeping its structure: headings are
+// recorded in Document.Headings, code fences are kept verbatim and inline
+// links are reduced to their text.
+type MarkdownParser struct {
+	// KeepFrontmatter leaves a YAML or TOML frontmatter block in the content
+	// as well as copying its fields into the metadata.
+	KeepFrontmatter bool
+}
+
+// NewMarkdownParser creates a new Markdown parser instance.
+func NewMarkdownParser() *MarkdownParser {
+	return &MarkdownParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *MarkdownParser) Supports(mimeType string) bool {
+	return mimeType == "text/markdown" || mimeType == "text/x-markdown"
+}
+
+var (
+	mdATXHeading  = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	mdSetextUnder = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	mdFence       = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})")
+	mdListItem    = regexp.MustCompile(`^(\s*)([*+-]|\d{1,9}[.)])[ \t]+`)
+	mdImage       = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink        = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	mdRefLink     = regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	mdAutoLink    = regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`)
+	mdLinkDef     = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:\s+\S+`)
+	mdComment     = regexp.MustCompile(`(?s)<!--.*?-->`)
+	mdEmphasis    = regexp.MustCompile(`(\*\*|__|\*|_)(\S(?:.*?\S)?)(\*\*|__|\*|_)`)
+)
+
+// Parse normalizes setext headings to ATX form and list markers to "-",
+// then records every heading with its byte offset in the output content.
+// Frontmatter fields become metadata, and a frontmatter title takes
+// precedence over the first level 1 heading.
+func (p *MarkdownParser) Parse(buffer []byte, filename string) (*Document, error) {
+	src := decodeText(buffer)
+	raw, src, front, hasFront := splitFrontmatter(src)
+	src = mdComment.ReplaceAllString(src, "")
+	lines := strings.Split(src, "\n")
+
+	var b strings.Builder
+	var headings []Heading
+	fence := ""
+	writeLine := func(line string) {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	if hasFront && p.KeepFrontmatter {
+		b.WriteString(raw)
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if fence != "" {
+			writeLine(line)
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+			}
+			continue
+		}
+		if m := mdFence.FindStringSubmatch(line); m != nil {
+			fence = m[1]
+			writeLine(line)
+			continue
+		}
+		if mdLinkDef.MatchString(line) {
+			continue
+		}
+
+		if m := mdATXHeading.FindStringSubmatch(line); m != nil {
+			text := mdHeadingText(m[2])
+			headings = append(headings, Heading{Level: len(m[1]), Text: text, Offset: b.Len()})
+			writeLine(m[1] + " " + text)
+			continue
+		}
+		if i+1 < len(lines) && strings.TrimSpace(line) != "" && !mdListItem.MatchString(line) {
+			if m := mdSetextUnder.FindStringSubmatch(lines[i+1]); m != nil {
+				level := 1
+				if m[1][0] == '-' {
+					level = 2
+				}
+				text := mdHeadingText(line)
+				headings = append(headings, Heading{Level: level, Text: text, Offset: b.Len()})
+				writeLine(strings.Repeat("#", level) + " " + text)
+				i++
+				continue
+			}
+		}
+		if m := mdListItem.FindStringSubmatch(line); m != nil {
+			marker := m[2]
+			if marker == "*" || marker == "+" {
+				marker = "-"
+			}
+			line = m[1] + marker + " " + line[len(m[0]):]
+		}
+		writeLine(mdInline(line))
+	}
+
+	content := strings.TrimSpace(b.String())
+	if content == "" {
+		return nil, ErrEmptyDocument
+	}
+	doc := &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Headings:  headings,
+	}
+
+	// Offsets were measured before trimming leading whitespace.
+	shift := len(strings.TrimRight(b.String(), " \t\r\n")) - len(content)
+	for i := range doc.Headings {
+		doc.Headings[i].Offset -= shift
+	}
+	doc.Sections = BuildSections(doc.Headings, len(content))
+	if len(front) > 0 {
+		doc.Metadata = front
+	}
+	for _, h := range doc.Headings {
+		if h.Level == 1 {
+			if doc.Metadata == nil {
+				doc.Metadata = map[string]string{}
+			}
+			if doc.Metadata["title"] == "" {
+				doc.Metadata["title"] = h.Text
+			}
+			break
+		}
+	}
+	return doc, nil
+}
+
+// mdInline reduces links and images to their visible text.
+func mdInline(s string) string {
+	s = mdImage.ReplaceAllString(s, "$1")
+	s = mdLink.ReplaceAllString(s, "$1")
+	s = mdRefLink.ReplaceAllString(s, "$1")
+	return mdAutoLink.ReplaceAllString(s, "$1")
+}
+
+// mdHeadingText returns the plain text of a heading, without emphasis.
+func mdHeadingText(s string) string {
+	return mdEmphasis.ReplaceAllString(mdInline(strings.TrimSpace(s)), "$2")
+}
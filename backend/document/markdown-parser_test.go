@@ -0,0 +1,46 @@
+package document
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownParser(t *testing.T) {
+	input := "Guide\n=====\n\nIntro with a [link](https://example.com).\n\n## Install\n\n* one\n+ two\n\n```\n# not a heading\n```\n\n### Linux ###\n\nDone.\n"
+	doc, err := NewMarkdownParser().Parse([]byte(input), "a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Heading{{1, "Guide", 0}, {2, "Install", 0}, {3, "Linux", 0}}
+	if len(doc.Headings) != len(want) {
+		t.Fatalf("headings %+v, want %d", doc.Headings, len(want))
+	}
+	for i, h := range doc.Headings {
+		if h.Level != want[i].Level || h.Text != want[i].Text {
+			t.Errorf("heading %d = %+v, want level %d %q", i, h, want[i].Level, want[i].Text)
+		}
+		if !strings.HasPrefix(doc.Content[h.Offset:], strings.Repeat("#", h.Level)+" "+h.Text) {
+			t.Errorf("heading %q is not at offset %d", h.Text, h.Offset)
+		}
+	}
+	for _, s := range []string{"- one", "- two", "link", "# not a heading"} {
+		if !strings.Contains(doc.Content, s) {
+			t.Errorf("content %q does not contain %q", doc.Content, s)
+		}
+	}
+	if strings.Contains(doc.Content, "https://example.com") {
+		t.Error("link target kept in content")
+	}
+	if got := doc.HeadingPath(strings.Index(doc.Content, "Done.")); !slices.Equal(got, []string{"Guide", "Install", "Linux"}) {
+		t.Errorf("HeadingPath = %v", got)
+	}
+
+	md, err := NewMarkdownParser().Parse(mustRead(t, fixture("test-markdown.md")), "test-markdown.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(md.Headings) < 20 || md.Headings[0].Text != "AI Systems Expert" {
+		t.Errorf("fixture headings %v", md.Headings[:min(3, len(md.Headings))])
+	}
+}
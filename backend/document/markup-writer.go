@@ -0,0 +1,84 @@
+package document
+
+import (
+	"strings"
+)
+
+// markupWriter accumulates the Markdown-style output of the lightweight
+// markup parsers, recording headings at their output offsets and
+// collapsing runs of blank lines.
+type markupWriter struct {
+	b        strings.Builder
+	headings []Heading
+}
+
+// line writes s followed by a newline. Empty lines are only written
+// between paragraphs, never at the start or twice in a row.
+func (w *markupWriter) line(s string) {
+	if strings.TrimSpace(s) == "" {
+		s := w.b.String()
+		if len(s) == 0 || strings.HasSuffix(s, "\n\n") {
+			return
+		}
+		w.b.WriteByte('\n')
+		return
+	}
+	w.b.WriteString(s)
+	w.b.WriteByte('\n')
+}
+
+// heading writes a "#" heading on a paragraph of its own.
+func (w *markupWriter) heading(level int, text string) {
+	level = min(max(level, 1), 6)
+	w.line("")
+	w.headings = append(w.headings, Heading{Level: level, Text: text, Offset: w.b.Len()})
+	w.line(strings.Repeat("#", level) + " " + text)
+	w.line("")
+}
+
+// fence writes lines verbatim inside a fenced code block.
+func (w *markupWriter) fence(lang string, lines []string) {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return
+	}
+	w.line("")
+	w.b.WriteString("```" + lang + "\n" + strings.Join(lines, "\n") + "\n```\n")
+	w.line("")
+}
+
+// document builds the parsed document. The first level 1 heading becomes
+// the "title" unless meta already has one.
+func (w *markupWriter) document(filename string, meta map[string]string) (*Document, error) {
+	content := strings.TrimRight(w.b.String(), " \t\n")
+	if strings.TrimSpace(content) == "" {
+		return nil, ErrEmptyDocument
+	}
+	for _, h := range w.headings {
+		if h.Level == 1 {
+			if meta == nil {
+				meta = map[string]string{}
+			}
+			if meta["title"] == "" {
+				meta["title"] = h.Text
+			}
+			break
+		}
+	}
+	if len(meta) == 0 {
+		meta = nil
+	}
+	return &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Headings:  w.headings,
+		Sections:  BuildSections(w.headings, len(content)),
+		Metadata:  meta,
+	}, nil
+}
@@ -0,0 +1,280 @@
+package document
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MBOXParser splits mbox archives into messages and groups them into
+// conversation threads using Message-ID, In-Reply-To and References.
+type MBOXParser struct{}
+
+// NewMBOXParser creates a new MBOX parser instance.
+func NewMBOXParser() *MBOXParser {
+	return &MBOXParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *MBOXParser) Supports(mimeType string) bool {
+	return mimeType == "application/mbox"
+}
+
+// Parse returns every thread in a single document, separated by blank
+// lines.
+func (p *MBOXParser) Parse(buffer []byte, filename string) (*Document, error) {
+	threads, err := p.ParseAll(buffer, filename)
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	var headings []Heading
+	var attachments []Attachment
+	messages := 0
+	for _, t := range threads {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		for _, h := range t.Headings {
+			h.Offset += b.Len()
+			headings = append(headings, h)
+		}
+		b.WriteString(t.Content)
+		attachments = append(attachments, t.Attachments...)
+		n, _ := strconv.Atoi(t.Metadata["messages"])
+		messages += n
+	}
+	content := b.String()
+	return &Document{
+		Content:     content,
+		Source:      filename,
+		WordCount:   len(strings.Fields(content)),
+		Headings:    headings,
+		Attachments: attachments,
+		Metadata: map[string]string{
+			"threads":  strconv.Itoa(len(threads)),
+			"messages": strconv.Itoa(messages),
+		},
+	}, nil
+}
+
+// ParseAll returns one document per thread, oldest message first. The
+// thread subject is a level 1 heading and each message a level 2 heading
+// naming its sender and date. Metadata holds the root "message_id",
+// "subject", "participants" and the number of "messages".
+func (p *MBOXParser) ParseAll(buffer []byte, filename string) ([]*Document, error) {
+	var msgs []*mboxMessage
+	for _, raw := range splitMBOX(buffer) {
+		m, err := readEML(raw)
+		if err != nil {
+			continue
+		}
+		msgs = append(msgs, &mboxMessage{emlMessage: m, index: len(msgs)})
+	}
+	if len(msgs) == 0 {
+		return nil, ErrEmptyDocument
+	}
+
+	var docs []*Document
+	for _, thread := range threadMBOX(msgs) {
+		docs = append(docs, renderMBOXThread(thread, filename))
+	}
+	return docs, nil
+}
+
+type mboxMessage struct {
+	*emlMessage
+	index  int
+	date   time.Time
+	parent *mboxMessage
+}
+
+var mboxEscapedFrom = regexp.MustCompile(`(?m)^>(>*From )`)
+
+// splitMBOX cuts an archive at its "From " separator lines and reverses
+// the ">From " quoting applied to body lines.
+func splitMBOX(buffer []byte) [][]byte {
+	buffer = bytes.ReplaceAll(buffer, []byte("\r\n"), []byte("\n"))
+	var msgs [][]byte
+	var cur []byte
+	flush := func() {
+		if len(bytes.TrimSpace(cur)) > 0 {
+			msgs = append(msgs, mboxEscapedFrom.ReplaceAll(cur, []byte("$1")))
+		}
+		cur = nil
+	}
+	for len(buffer) > 0 {
+		line := buffer
+		if i := bytes.IndexByte(buffer, '\n'); i >= 0 {
+			line, buffer = buffer[:i+1], buffer[i+1:]
+		} else {
+			buffer = nil
+		}
+		if bytes.HasPrefix(line, []byte("From ")) {
+			flush()
+			continue
+		}
+		cur = append(cur, line...)
+	}
+	flush()
+	return msgs
+}
+
+var (
+	mboxMessageID = regexp.MustCompile(`<[^<>\s]+>`)
+	mboxReplyTag  = regexp.MustCompile(`(?i)^\s*((re|fwd?|aw|sv|wg)(\[\d+\])?\s*:\s*)+`)
+)
+
+// mboxSubject strips reply and forward prefixes so replies that lost their
+// threading headers can still be matched by subject.
+func mboxSubject(s string) string {
+	return strings.ToLower(strings.TrimSpace(mboxReplyTag.ReplaceAllString(s, "")))
+}
+
+// threadMBOX links each message to its nearest known ancestor, preferring
+// the last resolvable References entry over In-Reply-To, and groups
+// messages under their root. Messages without a known ancestor join an
+// earlier thread with the same normalized subject. Threads keep the
+// archive order of their roots.
+func threadMBOX(msgs []*mboxMessage) [][]*mboxMessage {
+	byID := map[string]*mboxMessage{}
+	for _, m := range msgs {
+		if t, err := time.Parse(time.RFC3339, m.meta["date"]); err == nil {
+			m.date = t
+		}
+		if id := mboxMessageID.FindString(m.meta["message_id"]); id != "" {
+			if _, dup := byID[id]; !dup {
+				byID[id] = m
+			}
+		}
+	}
+	for _, m := range msgs {
+		refs := mboxMessageID.FindAllString(m.meta["references"], -1)
+		refs = append(refs, mboxMessageID.FindAllString(m.meta["in_reply_to"], -1)...)
+		for i := len(refs) - 1; i >= 0; i-- {
+			if parent := byID[refs[i]]; parent != nil && parent != m && !mboxAncestor(parent, m) {
+				m.parent = parent
+				break
+			}
+		}
+	}
+
+	root := func(m *mboxMessage) *mboxMessage {
+		for m.parent != nil {
+			m = m.parent
+		}
+		return m
+	}
+	// Assign thread keys to roots first so replies that precede their root
+	// in the archive still land in the right thread.
+	key := map[*mboxMessage]*mboxMessage{}
+	bySubject := map[string]*mboxMessage{}
+	var roots []*mboxMessage
+	for _, m := range msgs {
+		if m.parent != nil {
+			continue
+		}
+		subject := mboxSubject(m.meta["subject"])
+		if existing := bySubject[subject]; subject != "" && existing != nil {
+			key[m] = existing
+			continue
+		}
+		if subject != "" {
+			bySubject[subject] = m
+		}
+		key[m] = m
+		roots = append(roots, m)
+	}
+	threads := map[*mboxMessage][]*mboxMessage{}
+	for _, m := range msgs {
+		k := key[root(m)]
+		threads[k] = append(threads[k], m)
+	}
+
+	out := make([][]*mboxMessage, 0, len(roots))
+	for _, r := range roots {
+		thread := threads[r]
+		sort.SliceStable(thread, func(i, j int) bool {
+			a, b := thread[i], thread[j]
+			if !a.date.IsZero() && !b.date.IsZero() && !a.date.Equal(b.date) {
+				return a.date.Before(b.date)
+			}
+			return a.index < b.index
+		})
+		out = append(out, thread)
+	}
+	return out
+}
+
+// mboxAncestor reports whether a descends from m, guarding against
+// reference cycles.
+func mboxAncestor(a, m *mboxMessage) bool {
+	for depth := 0; a != nil && depth < 1000; depth++ {
+		if a == m {
+			return true
+		}
+		a = a.parent
+	}
+	return false
+}
+
+// renderMBOXThread lays a thread out as "# subject" followed by one
+// "## sender, date" section per message.
+func renderMBOXThread(thread []*mboxMessage, filename string) *Document {
+	first := thread[0]
+	subject := first.meta["subject"]
+	if subject == "" {
+		subject = "(no subject)"
+	}
+	var b strings.Builder
+	headings := []Heading{{Level: 1, Text: subject}}
+	b.WriteString("# " + subject)
+
+	var participants []string
+	seen := map[string]bool{}
+	var attachments []Attachment
+	for _, m := range thread {
+		from := m.meta["from"]
+		if from != "" && !seen[from] {
+			seen[from] = true
+			participants = append(participants, from)
+		}
+		label := from
+		if label == "" {
+			label = "(unknown sender)"
+		}
+		if d := m.meta["date"]; d != "" {
+			label += ", " + d
+		}
+		b.WriteString("\n\n")
+		headings = append(headings, Heading{Level: 2, Text: label, Offset: b.Len()})
+		b.WriteString("## " + label)
+		if m.text != "" {
+			b.WriteString("\n\n" + m.text)
+		}
+		attachments = append(attachments, m.attachments...)
+	}
+
+	content := b.String()
+	meta := map[string]string{
+		"subject":  subject,
+		"messages": strconv.Itoa(len(thread)),
+	}
+	if id := first.meta["message_id"]; id != "" {
+		meta["message_id"] = id
+	}
+	if len(participants) > 0 {
+		meta["participants"] = strings.Join(participants, "; ")
+	}
+	return &Document{
+		Content:     content,
+		Source:      filename,
+		WordCount:   len(strings.Fields(content)),
+		Headings:    headings,
+		Metadata:    meta,
+		Attachments: attachments,
+	}
+}
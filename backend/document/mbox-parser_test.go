@@ -0,0 +1,80 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+// mboxArchive holds two threads. The reply to "Plans" comes before its
+// root, the second reply has lost its threading headers, and one body
+// line is ">From " quoted.
+const mboxArchive = `From bob@example.com Tue Jan  2 00:00:00 2024
+From: bob@example.com
+Subject: Re: Plans
+Date: Tue, 2 Jan 2024 09:00:00 +0000
+Message-Id: <2@example.com>
+In-Reply-To: <1@example.com>
+
+Sounds good.
+>From now on, Fridays.
+
+From ada@example.com Mon Jan  1 00:00:00 2024
+From: ada@example.com
+Subject: Plans
+Date: Mon, 1 Jan 2024 09:00:00 +0000
+Message-Id: <1@example.com>
+
+Meet on Friday?
+
+From carl@example.com Wed Jan  3 00:00:00 2024
+From: carl@example.com
+Subject: RE: plans
+Date: Wed, 3 Jan 2024 09:00:00 +0000
+
+Count me in.
+
+From dan@example.com Wed Jan  3 00:00:00 2024
+From: dan@example.com
+Subject: Lunch
+Date: Wed, 3 Jan 2024 12:00:00 +0000
+
+Noon?
+`
+
+func TestMBOXParser(t *testing.T) {
+	threads, err := NewMBOXParser().ParseAll([]byte(mboxArchive), "a.mbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(threads) != 2 {
+		t.Fatalf("%d threads, want 2", len(threads))
+	}
+	plans := threads[0]
+	if m := plans.Metadata; m["subject"] != "Plans" || m["messages"] != "3" || m["message_id"] != "<1@example.com>" ||
+		m["participants"] != "ada@example.com; bob@example.com; carl@example.com" {
+		t.Errorf("thread metadata %v", m)
+	}
+	first, reply := strings.Index(plans.Content, "Meet on Friday?"), strings.Index(plans.Content, "Sounds good.")
+	if first < 0 || reply < first || !strings.Contains(plans.Content, "\nFrom now on, Fridays.") {
+		t.Errorf("thread content %q", plans.Content)
+	}
+	if len(plans.Headings) != 4 || plans.Headings[1].Text != "ada@example.com, 2024-01-01T09:00:00Z" {
+		t.Errorf("headings %+v", plans.Headings)
+	}
+	for _, h := range plans.Headings[1:] {
+		if !strings.HasPrefix(plans.Content[h.Offset:], "## "+h.Text) {
+			t.Errorf("heading %q is not at offset %d", h.Text, h.Offset)
+		}
+	}
+
+	doc, err := NewMBOXParser().Parse([]byte(mboxArchive), "a.mbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Metadata["threads"] != "2" || doc.Metadata["messages"] != "4" || len(doc.Headings) != 6 {
+		t.Errorf("metadata %v, %d headings", doc.Metadata, len(doc.Headings))
+	}
+	if h := doc.Headings[5]; !strings.HasPrefix(doc.Content[h.Offset:], "## dan@example.com") {
+		t.Errorf("second thread heading at %d is not shifted", h.Offset)
+	}
+}
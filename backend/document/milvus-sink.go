@@ -0,0 +1,226 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Consistency levels of a Milvus collection, from strongest to weakest.
+const (
+	MilvusStrong     = "Strong"
+	MilvusBounded    = "Bounded"
+	MilvusSession    = "Session"
+	MilvusEventually = "Eventually"
+)
+
+// MilvusSink is a Sink that upserts chunks into a Milvus collection over
+// its v2 REST API, with the chunk text and metadata stored as dynamic
+// fields. Entity IDs are ChunkIDs, so writing an unchanged document again
+// overwrites its entities. The collection, and with PartitionPerSource
+// each source's partition, are created on first use if missing.
+type MilvusSink struct {
+	// Endpoint is the base URL of the server. Defaults to
+	// http://localhost:19530.
+	Endpoint string
+	// Token is sent as a bearer token when set, such as "user:password"
+	// or a Zilliz Cloud API key.
+	Token string
+	// Database defaults to the server's default database.
+	Database string
+	// Collection names the collection. Defaults to "chunks".
+	Collection string
+	// MetricType is the metric of a new collection: "COSINE", "IP" or
+	// "L2". Defaults to "COSINE".
+	MetricType string
+	// ConsistencyLevel is the consistency level of a new collection, one
+	// of the Milvus constants. Defaults to MilvusBounded.
+	ConsistencyLevel string
+	// PartitionPerSource writes the chunks of each source to a partition
+	// of their own, so a source can be searched or dropped on its own.
+	PartitionPerSource bool
+	// BatchSize is the number of entities sent per request. Defaults to
+	// 100.
+	BatchSize int
+	// MaxRetries is the number of times a failed request is retried, with
+	// exponential backoff. Defaults to 3; negative turns retries off.
+	MaxRetries int
+	// Client defaults to an http.Client with a one minute timeout.
+	Client *http.Client
+
+	mu         sync.Mutex
+	ready      bool
+	partitions map[string]bool
+}
+
+// NewMilvusSink creates a sink that writes to collection on the server at
+// endpoint.
+func NewMilvusSink(endpoint, collection string) *MilvusSink {
+	return &MilvusSink{Endpoint: endpoint, Collection: collection}
+}
+
+// MilvusPartition returns the name of the partition PartitionPerSource
+// writes the chunks of source to.
+func MilvusPartition(source string) string {
+	return "src_" + Checksum([]byte(source))[:16]
+}
+
+// Write upserts the chunks of doc, in batches. Every chunk must have an
+// Embedding; see Pipeline.Embed.
+func (s *MilvusSink) Write(ctx context.Context, doc *Document, chunks []Chunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	for i, c := range chunks {
+		if len(c.Embedding) == 0 {
+			return fmt.Errorf("chunk %d has no embedding", i)
+		}
+	}
+	source := storeSource(doc)
+	partition := ""
+	if s.PartitionPerSource {
+		partition = MilvusPartition(source)
+	}
+	if err := s.ensure(ctx, len(chunks[0].Embedding), partition); err != nil {
+		return err
+	}
+
+	rows := make([]map[string]any, len(chunks))
+	for i, c := range chunks {
+		rows[i] = map[string]any{
+			"id":          storeChunkID(source, c),
+			"vector":      c.Embedding,
+			"source":      source,
+			"chunk_index": c.Index,
+			"content":     c.Text,
+			"metadata":    chunkStoreMetadata(doc, c),
+		}
+	}
+	batchSize := s.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	for start := 0; start < len(rows); start += batchSize {
+		body := s.body(map[string]any{"data": rows[start:min(start+batchSize, len(rows))]})
+		if partition != "" {
+			body["partitionName"] = partition
+		}
+		if _, err := s.call(ctx, "/entities/upsert", body); err != nil {
+			return fmt.Errorf("upsert entities: %w", err)
+		}
+	}
+	return nil
+}
+
+// ensure creates the collection and partition, if not empty, unless they
+// exist.
+func (s *MilvusSink) ensure(ctx context.Context, dims int, partition string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ready {
+		if err := s.create(ctx, "/collections", nil, func() map[string]any {
+			metric := s.MetricType
+			if metric == "" {
+				metric = "COSINE"
+			}
+			consistency := s.ConsistencyLevel
+			if consistency == "" {
+				consistency = MilvusBounded
+			}
+			return map[string]any{
+				"dimension":        dims,
+				"metricType":       metric,
+				"idType":           "VarChar",
+				"primaryFieldName": "id",
+				"vectorFieldName":  "vector",
+				"params":           map[string]any{"max_length": 64, "consistencyLevel": consistency},
+			}
+		}); err != nil {
+			return fmt.Errorf("create collection: %w", err)
+		}
+		s.ready = true
+	}
+	if partition == "" || s.partitions[partition] {
+		return nil
+	}
+	if err := s.create(ctx, "/partitions", map[string]any{"partitionName": partition}, func() map[string]any {
+		return map[string]any{"partitionName": partition}
+	}); err != nil {
+		return fmt.Errorf("create partition: %w", err)
+	}
+	if s.partitions == nil {
+		s.partitions = map[string]bool{}
+	}
+	s.partitions[partition] = true
+	return nil
+}
+
+// create asks the resource API at path whether the resource named by key
+// exists and creates it with the fields of spec if not.
+func (s *MilvusSink) create(ctx context.Context, path string, key map[string]any, spec func() map[string]any) error {
+	data, err := s.call(ctx, path+"/has", s.body(key))
+	if err != nil {
+		return err
+	}
+	var has struct {
+		Has bool `json:"has"`
+	}
+	if err := json.Unmarshal(data, &has); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	if has.Has {
+		return nil
+	}
+	_, err = s.call(ctx, path+"/create", s.body(spec()))
+	return err
+}
+
+// body returns fields with the database and collection names added.
+func (s *MilvusSink) body(fields map[string]any) map[string]any {
+	body := map[string]any{}
+	for k, v := range fields {
+		body[k] = v
+	}
+	body["collectionName"] = s.Collection
+	if s.Collection == "" {
+		body["collectionName"] = "chunks"
+	}
+	if s.Database != "" {
+		body["dbName"] = s.Database
+	}
+	return body
+}
+
+// call posts body to the v2 API at path and returns the data of the
+// response. Milvus reports failures in the response code, with a 200
+// status.
+func (s *MilvusSink) call(ctx context.Context, path string, body map[string]any) (json.RawMessage, error) {
+	endpoint := strings.TrimSuffix(s.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "http://localhost:19530"
+	}
+	header := http.Header{}
+	if s.Token != "" {
+		header.Set("Authorization", "Bearer "+s.Token)
+	}
+	data, err := retryPolicy{maxRetries: s.MaxRetries}.sendJSON(ctx, httpClient(s.Client, time.Minute), http.MethodPost, endpoint+"/v2/vectordb"+path, header, body)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	if resp.Code != 0 {
+		return nil, fmt.Errorf("%s: code %d: %s", path, resp.Code, resp.Message)
+	}
+	return resp.Data, nil
+}
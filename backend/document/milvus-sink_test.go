@@ -0,0 +1,95 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMilvusSink(t *testing.T) {
+	var paths []string
+	var bodies []map[string]any
+	exists := map[string]bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer root:Milvus" {
+			w.Write([]byte(`{"code": 1800, "message": "unauthorized"}`))
+			return
+		}
+		path := strings.TrimPrefix(r.URL.Path, "/v2/vectordb")
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		paths = append(paths, path)
+		bodies = append(bodies, body)
+		resource := strings.Split(path, "/")[1]
+		switch {
+		case strings.HasSuffix(path, "/has"):
+			json.NewEncoder(w).Encode(map[string]any{"code": 0, "data": map[string]bool{"has": exists[resource]}})
+			return
+		case strings.HasSuffix(path, "/create"):
+			exists[resource] = true
+		}
+		w.Write([]byte(`{"code": 0, "data": {}}`))
+	}))
+	defer srv.Close()
+
+	sink := NewMilvusSink(srv.URL+"/", "docs")
+	sink.Token = "root:Milvus"
+	sink.Database = "kb"
+	sink.PartitionPerSource = true
+	sink.ConsistencyLevel = MilvusStrong
+	doc := &Document{Source: "a.md"}
+	chunks := []Chunk{
+		{Index: 0, Text: "one", Embedding: []float32{1, 0}},
+		{Index: 1, Text: "two", Embedding: []float32{0, 1}},
+	}
+	ctx := context.Background()
+	if err := sink.Write(ctx, doc, chunks); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(ctx, doc, chunks[:1]); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"/collections/has", "/collections/create",
+		"/partitions/has", "/partitions/create",
+		"/entities/upsert", "/entities/upsert",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths %q, want %q", paths, want)
+	}
+	partition := MilvusPartition("a.md")
+	if !strings.HasPrefix(partition, "src_") || len(partition) != 20 || partition == MilvusPartition("b.md") {
+		t.Errorf("partition %q", partition)
+	}
+	for i, body := range bodies {
+		if body["collectionName"] != "docs" || body["dbName"] != "kb" {
+			t.Errorf("%s body %v", paths[i], body)
+		}
+	}
+	create := bodies[1]
+	if create["dimension"] != 2.0 || create["metricType"] != "COSINE" || create["primaryFieldName"] != "id" ||
+		create["params"].(map[string]any)["consistencyLevel"] != MilvusStrong {
+		t.Errorf("collection spec %v", create)
+	}
+	if bodies[3]["partitionName"] != partition {
+		t.Errorf("partition spec %v", bodies[3])
+	}
+	upsert := bodies[4]
+	rows := upsert["data"].([]any)
+	row := rows[1].(map[string]any)
+	if upsert["partitionName"] != partition || len(rows) != 2 || row["id"] != ChunkID("a.md", chunks[1]) ||
+		row["content"] != "two" || row["source"] != "a.md" || row["chunk_index"] != 1.0 {
+		t.Errorf("upsert %v", upsert)
+	}
+
+	if err := NewMilvusSink(srv.URL, "").Write(ctx, doc, chunks); err == nil || !strings.Contains(err.Error(), "unauthorized") {
+		t.Errorf("error code in response: %v", err)
+	}
+	if err := sink.Write(ctx, doc, []Chunk{{Text: "one"}}); err == nil {
+		t.Error("chunk without embedding returned no error")
+	}
+}
@@ -0,0 +1,154 @@
+package document
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// mimeSignatures are magic-byte prefixes of binary formats.
+var mimeSignatures = []struct {
+	prefix   string
+	mimeType string
+}{
+	{"%PDF-", "application/pdf"},
+	{"{\\rtf", "application/rtf"},
+	{"\x89PNG\r\n\x1a\n", "image/png"},
+	{"\xff\xd8\xff", "image/jpeg"},
+	{"GIF87a", "image/gif"},
+	{"GIF89a", "image/gif"},
+	{"II*\x00", "image/tiff"},
+	{"MM\x00*", "image/tiff"},
+	{"\x1f\x8b", "application/gzip"},
+	{"PAR1", "application/vnd.apache.parquet"},
+	{"ID3", "audio/mpeg"},
+	{"\xff\xfb", "audio/mpeg"},
+	{"\xff\xf3", "audio/mpeg"},
+	{"\xff\xf2", "audio/mpeg"},
+}
+
+// DetectMIME returns the MIME type of buffer from its magic bytes, falling
+// back to the extension of filename as MimeTypeForFile does. ZIP packages
+// are told apart by their entries (OOXML, OpenDocument, EPUB and chat
+// exports). Text formats have no signature, so a known extension wins and
+// only files without one are sniffed for HTML, XML, JSON and the like.
+// Other valid UTF-8 text, and UTF-16 text with a byte order mark, is
+// "text/plain"; anything else is
+// "application/octet-stream".
+func DetectMIME(buffer []byte, filename string) string {
+	byExt := MimeTypeForFile(filename)
+	for _, s := range mimeSignatures {
+		if bytes.HasPrefix(buffer, []byte(s.prefix)) {
+			return s.mimeType
+		}
+	}
+	switch {
+	case len(buffer) >= 12 && string(buffer[:4]) == "RIFF" && string(buffer[8:12]) == "WEBP":
+		return "image/webp"
+	case len(buffer) >= 12 && string(buffer[:4]) == "RIFF" && string(buffer[8:12]) == "WAVE":
+		return "audio/wav"
+	case len(buffer) >= 12 && string(buffer[4:8]) == "ftyp" && strings.HasPrefix(string(buffer[8:12]), "M4A"):
+		return "audio/mp4"
+	case len(buffer) >= 262 && string(buffer[257:262]) == "ustar":
+		return "application/x-tar"
+	case bytes.HasPrefix(buffer, []byte("PK\x03\x04")):
+		return zipMIME(buffer)
+	case bytes.HasPrefix(buffer, []byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1")):
+		// Legacy Office files share the OLE container; only the name
+		// tells them apart.
+		if byExt == "application/msword" || byExt == "application/vnd.ms-excel" {
+			return byExt
+		}
+		return "application/x-ole-storage"
+	case bytes.HasPrefix(buffer, []byte("BM")) && byExt == "image/bmp":
+		return byExt
+	}
+
+	if byExt != "application/octet-stream" {
+		return byExt
+	}
+	head := strings.TrimLeft(string(buffer[:min(len(buffer), 512)]), "\ufeff \t\r\n")
+	lower := strings.ToLower(head)
+	switch {
+	case strings.HasPrefix(lower, "<!doctype html") || strings.HasPrefix(lower, "<html"):
+		return "text/html"
+	case strings.HasPrefix(lower, "<?xml"):
+		switch {
+		case strings.Contains(lower, "<html"):
+			return "application/xhtml+xml"
+		case strings.Contains(lower, "<rss"):
+			return "application/rss+xml"
+		case strings.Contains(lower, "<feed"):
+			return "application/atom+xml"
+		}
+		return "text/xml"
+	case strings.HasPrefix(head, "BEGIN:VCALENDAR"):
+		return "text/calendar"
+	case strings.HasPrefix(head, "WEBVTT"):
+		return "text/vtt"
+	case strings.HasPrefix(head, "From ") && strings.Contains(head, "\nFrom:"):
+		return "application/mbox"
+	case strings.HasPrefix(head, "{") || strings.HasPrefix(head, "["):
+		if mt := DetectChatExport(buffer); mt != "" {
+			return mt
+		}
+		return "application/json"
+	}
+	if utf8.Valid(buffer) && !bytes.ContainsRune(buffer, 0) {
+		return "text/plain"
+	}
+	if bytes.HasPrefix(buffer, []byte("\xff\xfe")) || bytes.HasPrefix(buffer, []byte("\xfe\xff")) {
+		// UTF-16 text with a byte order mark.
+		return "text/plain"
+	}
+	return byExt
+}
+
+// zipMIME identifies a ZIP package by its entries, or returns
+// "application/zip" for plain archives.
+func zipMIME(buffer []byte) string {
+	zr, err := openZip(buffer, 1<<20)
+	if err != nil {
+		return "application/zip"
+	}
+	// ODF and EPUB store their type in an uncompressed first entry.
+	if data, err := readZipEntry(zr, "mimetype"); err == nil {
+		if mt := strings.TrimSpace(string(data)); mt != "" {
+			return mt
+		}
+	}
+	if zipHas(zr, "[Content_Types].xml") {
+		switch {
+		case zipHas(zr, "word/document.xml"):
+			return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+		case zipHas(zr, "ppt/presentation.xml"):
+			return "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+		case zipHas(zr, "xl/workbook.xml"):
+			return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		}
+	}
+	if mt := DetectChatExport(buffer); mt != "" {
+		return mt
+	}
+	return "application/zip"
+}
+
+// zipHas reports whether the archive contains the named entry.
+func zipHas(zr *zipPackage, name string) bool {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parserFor returns the first parser supporting mimeType.
+func parserFor(parsers []Parser, mimeType string) Parser {
+	for _, p := range parsers {
+		if p.Supports(mimeType) {
+			return p
+		}
+	}
+	return nil
+}
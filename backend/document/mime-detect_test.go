@@ -0,0 +1,80 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectMIME(t *testing.T) {
+	const (
+		docx = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+		pptx = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	)
+	tests := []struct {
+		file string
+		want string
+		// unnamed is the type detected without a filename, from the bytes
+		// alone.
+		unnamed string
+	}{
+		{"test-pdf.pdf", "application/pdf", "application/pdf"},
+		{"test-rtf.rtf", "application/rtf", "application/rtf"},
+		{"test-docx.docx", docx, docx},
+		{"test-pptx.pptx", pptx, pptx},
+		{"test-epub.epub", "application/epub+zip", "application/epub+zip"},
+		{"test-zip.zip", "application/zip", "application/zip"},
+		{"test-doc.doc", "application/msword", "application/x-ole-storage"},
+		{"test-excel.xls", "application/vnd.ms-excel", "application/x-ole-storage"},
+		{"test-html.html", "text/html", "text/html"},
+		{"test-xml.xml", "text/xml", "text/xml"},
+		{"test-json.json", "application/json", "application/json"},
+		{"test-md.md", "text/markdown", "text/plain"},
+		{"test-csv.csv", "text/csv", "text/plain"},
+		{"test-go.go", "text/x-go", "text/plain"},
+		{"test-txt.txt", "text/plain", "text/plain"},
+	}
+	for _, tt := range tests {
+		buffer := mustRead(t, fixture(tt.file))
+		if got := DetectMIME(buffer, tt.file); got != tt.want {
+			t.Errorf("DetectMIME(%s) = %q, want %q", tt.file, got, tt.want)
+		}
+		if got := DetectMIME(buffer, ""); got != tt.unnamed {
+			t.Errorf("DetectMIME(%s, \"\") = %q, want %q", tt.file, got, tt.unnamed)
+		}
+	}
+
+	sniffed := []struct {
+		input string
+		want  string
+	}{
+		{"\x89PNG\r\n\x1a\n\x00\x00", "image/png"},
+		{"RIFF\x00\x00\x00\x00WAVEfmt ", "audio/wav"},
+		{"  <!DOCTYPE html><p>Hi</p>", "text/html"},
+		{`<?xml version="1.0"?><rss version="2.0"></rss>`, "application/rss+xml"},
+		{`<?xml version="1.0"?><feed xmlns="http://www.w3.org/2005/Atom"></feed>`, "application/atom+xml"},
+		{"BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n", "text/calendar"},
+		{"WEBVTT\n\n00:01.000 --> 00:02.000\nHi\n", "text/vtt"},
+		{"From a@example.com Mon Jan  1 00:00:00 2024\nFrom: a@example.com\n", "application/mbox"},
+		{"Just words.", "text/plain"},
+		{"\x00\x01\x02\xff", "application/octet-stream"},
+	}
+	for _, tt := range sniffed {
+		if got := DetectMIME([]byte(tt.input), "upload"); got != tt.want {
+			t.Errorf("DetectMIME(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseAutoDetects(t *testing.T) {
+	// The signature wins over a misleading extension.
+	doc, err := ParseAuto(mustRead(t, fixture("test-pdf.pdf")), "upload.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(doc.Content, "consectetur adipiscing elit") || len(doc.Pages) == 0 {
+		t.Errorf("PDF parsed as %d pages of %.40q", len(doc.Pages), doc.Content)
+	}
+	if _, err := ParseAuto([]byte("\x00\x01\x02\xff"), "upload"); err == nil {
+		t.Error("binary input without a parser parsed")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package document
+
+import "os"
+
+// mmapFile reads the file at name, since this system has no mmap.
+func mmapFile(name string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
@@ -0,0 +1,246 @@
+package document
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSQueue is a Queue over a NATS core connection, speaking the client
+// protocol directly. It receives jobs published to Subject through a
+// queue group, so each job goes to one of the workers subscribed. NATS
+// core has no acknowledgements: a job in flight when a worker stops is
+// lost.
+type NATSQueue struct {
+	// URL is the server, such as nats://localhost:4222. User and password
+	// or a token in the URL authenticate the connection.
+	URL     string
+	Subject string
+	// QueueGroup defaults to "agento".
+	QueueGroup string
+	// TLS configures the connection when the server requires TLS.
+	// Defaults to verifying the server's host name.
+	TLS *tls.Config
+
+	mu     sync.Mutex
+	wmu    sync.Mutex
+	conn   net.Conn
+	w      *bufio.Writer
+	msgs   chan []byte
+	stop   chan struct{}
+	done   chan struct{}
+	err    error
+	closed bool
+}
+
+// NewNATSQueue creates a queue that receives jobs from subject on the
+// server at url.
+func NewNATSQueue(url, subject string) *NATSQueue {
+	return &NATSQueue{URL: url, Subject: subject}
+}
+
+// Receive returns the next message published to Subject.
+func (q *NATSQueue) Receive(ctx context.Context) (*Message, error) {
+	if err := q.connect(ctx); err != nil {
+		return nil, err
+	}
+	select {
+	case data := <-q.msgs:
+		return &Message{Data: data}, nil
+	case <-q.done:
+		return nil, q.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Publish publishes data to the subject topic.
+func (q *NATSQueue) Publish(ctx context.Context, topic string, data []byte) error {
+	if err := q.connect(ctx); err != nil {
+		return err
+	}
+	return q.write("PUB "+topic+" "+strconv.Itoa(len(data))+"\r\n", data)
+}
+
+// Close closes the connection. Messages not yet received are dropped.
+func (q *NATSQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	if q.conn == nil {
+		return nil
+	}
+	close(q.stop)
+	return q.conn.Close()
+}
+
+// write sends a protocol line, followed by payload and CRLF when payload
+// is not nil, and flushes it.
+func (q *NATSQueue) write(line string, payload []byte) error {
+	q.wmu.Lock()
+	defer q.wmu.Unlock()
+	q.w.WriteString(line)
+	if payload != nil {
+		q.w.Write(payload)
+		q.w.WriteString("\r\n")
+	}
+	return q.w.Flush()
+}
+
+// connect dials the server, authenticates and subscribes, once.
+func (q *NATSQueue) connect(ctx context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.conn != nil {
+		return nil
+	}
+	if q.closed {
+		return errors.New("nats queue is closed")
+	}
+	u, err := url.Parse(q.URL)
+	if err != nil {
+		return fmt.Errorf("parse nats url: %w", err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	conn, err := (&net.Dialer{Timeout: 10 * time.Second}).DialContext(ctx, "tcp", host)
+	if err != nil {
+		return fmt.Errorf("connect to nats: %w", err)
+	}
+	fail := func(err error) error {
+		conn.Close()
+		return fmt.Errorf("connect to nats: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	r := bufio.NewReader(conn)
+	line, err := readNATSLine(r)
+	if err != nil {
+		return fail(err)
+	}
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	if verb, rest, _ := strings.Cut(line, " "); verb != "INFO" || json.Unmarshal([]byte(rest), &info) != nil {
+		return fail(fmt.Errorf("unexpected greeting %q", line))
+	}
+	if info.TLSRequired || u.Scheme == "tls" {
+		config := q.TLS
+		if config == nil {
+			config = &tls.Config{ServerName: u.Hostname()}
+		}
+		tc := tls.Client(conn, config)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			return fail(err)
+		}
+		conn, r = tc, bufio.NewReader(tc)
+	}
+
+	opts := map[string]any{"verbose": false, "pedantic": false, "name": "agento", "lang": "go", "protocol": 0}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			opts["user"], opts["pass"] = u.User.Username(), pass
+		} else {
+			opts["auth_token"] = u.User.Username()
+		}
+	}
+	connectOpts, _ := json.Marshal(opts)
+	group := q.QueueGroup
+	if group == "" {
+		group = "agento"
+	}
+	w := bufio.NewWriter(conn)
+	fmt.Fprintf(w, "CONNECT %s\r\nSUB %s %s 1\r\nPING\r\n", connectOpts, q.Subject, group)
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	// The server answers PING with PONG once it has taken CONNECT and SUB,
+	// or reports why it refused them.
+	for {
+		line, err := readNATSLine(r)
+		if err != nil {
+			return fail(err)
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			return fail(errors.New(strings.TrimSpace(strings.TrimPrefix(line, "-ERR"))))
+		}
+		if line == "PONG" {
+			break
+		}
+	}
+	conn.SetDeadline(time.Time{})
+	q.conn, q.w = conn, w
+	q.msgs, q.stop, q.done = make(chan []byte), make(chan struct{}), make(chan struct{})
+	go q.read(r)
+	return nil
+}
+
+// read delivers the messages from the server until the connection fails.
+func (q *NATSQueue) read(r *bufio.Reader) {
+	err := func() error {
+		for {
+			line, err := readNATSLine(r)
+			if err != nil {
+				return err
+			}
+			verb, args, _ := strings.Cut(line, " ")
+			switch verb {
+			case "MSG":
+				// MSG <subject> <sid> [reply-to] <#bytes>
+				fields := strings.Fields(args)
+				if len(fields) < 3 {
+					return fmt.Errorf("malformed message line %q", line)
+				}
+				size, err := strconv.Atoi(fields[len(fields)-1])
+				if err != nil || size < 0 {
+					return fmt.Errorf("malformed message line %q", line)
+				}
+				data := make([]byte, size+2)
+				if _, err := io.ReadFull(r, data); err != nil {
+					return err
+				}
+				select {
+				case q.msgs <- data[:size]:
+				case <-q.stop:
+					return nil
+				}
+			case "PING":
+				if err := q.write("PONG\r\n", nil); err != nil {
+					return err
+				}
+			case "-ERR":
+				return errors.New(strings.Trim(args, "' "))
+			}
+		}
+	}()
+	q.mu.Lock()
+	if q.closed {
+		err = errors.New("nats queue is closed")
+	}
+	q.mu.Unlock()
+	q.err = fmt.Errorf("nats connection: %w", err)
+	close(q.done)
+}
+
+// readNATSLine reads a protocol line without its CRLF.
+func readNATSLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
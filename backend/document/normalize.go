@@ -0,0 +1,165 @@
+package document
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizationForm selects the Unicode normalization a Normalizer applies.
+type NormalizationForm int
+
+const (
+	// NoNormalization leaves characters as they are.
+	NoNormalization NormalizationForm = iota
+	// NFC composes letters followed by combining diacritics into their
+	// precomposed form, so "e" + U+0301 and "é" compare equal.
+	NFC
+	// NFKC also replaces compatibility characters with their plain
+	// equivalents, such as ligatures, full-width forms and superscript
+	// digits, then composes as NFC does.
+	NFKC
+)
+
+// Normalizer cleans up text so that equal content has equal bytes, which
+// improves deduplication and embedding quality. The normalization forms
+// are those of golang.org/x/text/unicode/norm.
+type Normalizer struct {
+	// Form is the normalization form. Defaults to NoNormalization.
+	Form NormalizationForm
+	// Func, when set, normalizes instead of Form, for custom
+	// normalizations. It is only used by String, since it maps no offsets.
+	Func func(string) string
+	// FoldPunctuation replaces curly quotes with straight ones and dashes
+	// and the minus sign with "-".
+	FoldPunctuation bool
+	// RemoveZeroWidth deletes zero-width spaces, word joiners, stray byte
+	// order marks and soft hyphens. Zero-width joiners, which emoji and
+	// some scripts need, are kept.
+	RemoveZeroWidth bool
+}
+
+// NewNormalizer creates a normalizer that applies NFKC, folds punctuation
+// and removes zero-width characters.
+func NewNormalizer() *Normalizer {
+	return &Normalizer{Form: NFKC, FoldPunctuation: true, RemoveZeroWidth: true}
+}
+
+// String returns s normalized.
+func (n *Normalizer) String(s string) string {
+	if n.Func != nil {
+		s = n.Func(s)
+		m := *n
+		m.Form, m.Func = NoNormalization, nil
+		return m.String(s)
+	}
+	out, _ := n.normalize(s)
+	return out
+}
+
+// Document normalizes doc.Content in place and moves the offsets of its
+// pages, headings, sections, segments and lines to match. Func is not
+// used, since it cannot report how offsets move.
+func (n *Normalizer) Document(doc *Document) {
+	content, offsets := n.normalize(doc.Content)
+	if content != doc.Content {
+		replaceContent(doc, content, offsets)
+	}
+}
+
+// Transform normalizes doc as Document does, so a Normalizer can be used
+// as a Transformer.
+func (n *Normalizer) Transform(doc *Document) (*Document, error) {
+	n.Document(doc)
+	return doc, nil
+}
+
+// replaceContent sets doc.Content to content, where offsets maps every
+// byte offset of the old content up to its length to the new one, and
+// moves the offsets of the pages, headings, sections, segments and lines
+// of doc to match.
+func replaceContent(doc *Document, content string, offsets []int) {
+	at := func(old int) int {
+		return offsets[max(0, min(old, len(offsets)-1))]
+	}
+	for i := range doc.Pages {
+		doc.Pages[i].Start, doc.Pages[i].End = at(doc.Pages[i].Start), at(doc.Pages[i].End)
+	}
+	for i := range doc.Headings {
+		doc.Headings[i].Offset = at(doc.Headings[i].Offset)
+	}
+	var moveSections func(ss []Section)
+	moveSections = func(ss []Section) {
+		for i := range ss {
+			ss[i].Start, ss[i].End = at(ss[i].Start), at(ss[i].End)
+			moveSections(ss[i].Children)
+		}
+	}
+	moveSections(doc.Sections)
+	for i := range doc.Segments {
+		doc.Segments[i].Start, doc.Segments[i].End = at(doc.Segments[i].Start), at(doc.Segments[i].End)
+	}
+	for i := range doc.Lines {
+		doc.Lines[i] = at(doc.Lines[i])
+	}
+	doc.Content = content
+	doc.WordCount = len(strings.Fields(content))
+}
+
+// normalize returns s normalized and, for every byte offset of s up to
+// len(s), the offset in the result where that position ended up. The
+// input is taken a normalization segment at a time, a starter and the
+// marks that combine with it, so every offset within a segment moves to
+// the start of its normalized form.
+func (n *Normalizer) normalize(s string) (string, []int) {
+	out := make([]byte, 0, len(s))
+	offsets := make([]int, len(s)+1)
+	var it norm.Iter
+	switch n.Form {
+	case NFC:
+		it.InitString(norm.NFC, s)
+	case NFKC:
+		it.InitString(norm.NFKC, s)
+	}
+	for i := 0; i < len(s); {
+		end := i
+		var seg []byte
+		if n.Form == NoNormalization {
+			_, size := utf8.DecodeRuneInString(s[i:])
+			end += size
+			seg = []byte(s[i:end])
+		} else {
+			seg, end = it.Next(), it.Pos()
+		}
+		for j := i; j < end; j++ {
+			offsets[j] = len(out)
+		}
+		i = end
+
+		for _, r := range string(seg) {
+			if n.RemoveZeroWidth && zeroWidth[r] {
+				continue
+			}
+			if p, ok := foldedPunctuation[r]; ok && n.FoldPunctuation {
+				out = append(out, p...)
+				continue
+			}
+			out = utf8.AppendRune(out, r)
+		}
+	}
+	offsets[len(s)] = len(out)
+	return string(out), offsets
+}
+
+// zeroWidth are the invisible characters RemoveZeroWidth deletes.
+var zeroWidth = map[rune]bool{
+	'\u200b': true, '\u2060': true, '\ufeff': true, '\u00ad': true, '\u180e': true,
+}
+
+// foldedPunctuation maps typographic punctuation to ASCII.
+var foldedPunctuation = map[rune]string{
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'",
+	'“': `"`, '”': `"`, '„': `"`, '‟': `"`, '″': `"`,
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-", '−': "-",
+}
@@ -0,0 +1,64 @@
+package document
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizer(t *testing.T) {
+	tests := []struct {
+		name string
+		n    *Normalizer
+		in   string
+		want string
+	}{
+		{"compose", &Normalizer{Form: NFC}, "Cafe\u0301 A\u030a", "Café Å"},
+		{"nfc keeps ligatures", &Normalizer{Form: NFC}, "ﬁle", "ﬁle"},
+		{"combining marks reordered", &Normalizer{Form: NFC}, "e\u0302\u0323 Ha\u0308ngul \u1100\u1161", "ệ Hängul 가"},
+		{"compatibility", NewNormalizer(), "㎏ ｶﾀｶﾅ Ⅻ", "kg カタカナ XII"},
+		{"ligature", NewNormalizer(), "ﬁle", "file"},
+		{"full width and superscript", NewNormalizer(), "ＡＢＣ x²", "ABC x2"},
+		{"punctuation", NewNormalizer(), "“Hi” — it’s", `"Hi" - it's`},
+		{"zero width", NewNormalizer(), "zero\u200bwidth\u00adsoft", "zerowidthsoft"},
+		{"joiner kept", NewNormalizer(), "\U0001F469\u200d\U0001F4BB", "\U0001F469\u200d\U0001F4BB"},
+		{"off", &Normalizer{}, "Cafe\u0301 “x”", "Cafe\u0301 “x”"},
+	}
+	for _, tt := range tests {
+		if got := tt.n.String(tt.in); got != tt.want {
+			t.Errorf("%s: String(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizerDocument(t *testing.T) {
+	doc := &Document{
+		Content:  "Cafe\u0301\u200b\n\n# Menu\n\nTea",
+		Headings: []Heading{{Level: 1, Text: "Menu", Offset: 11}},
+		Pages:    []Page{{Number: 1, Start: 0, End: 22}},
+	}
+	NewNormalizer().Document(doc)
+	if doc.Content != "Café\n\n# Menu\n\nTea" || doc.WordCount != 4 {
+		t.Fatalf("Content = %q, %d words", doc.Content, doc.WordCount)
+	}
+	if want := []Heading{{Level: 1, Text: "Menu", Offset: 7}}; !reflect.DeepEqual(doc.Headings, want) {
+		t.Errorf("Headings = %+v, want %+v", doc.Headings, want)
+	}
+	if want := []Page{{Number: 1, Start: 0, End: 18}}; !reflect.DeepEqual(doc.Pages, want) {
+		t.Errorf("Pages = %+v, want %+v", doc.Pages, want)
+	}
+
+	// Offsets inside a composed segment move to its start, and those after
+	// a compatibility expansion move past it.
+	doc = &Document{
+		Content:  "\u1100\u1161 ﬃ x",
+		Headings: []Heading{{Level: 1, Text: "x", Offset: 11}},
+		Pages:    []Page{{Number: 1, Start: 3, End: 12}},
+	}
+	NewNormalizer().Document(doc)
+	if doc.Content != "가 ffi x" {
+		t.Fatalf("Content = %q", doc.Content)
+	}
+	if doc.Headings[0].Offset != 8 || doc.Pages[0] != (Page{Number: 1, Start: 0, End: 9}) {
+		t.Errorf("Headings = %+v, Pages = %+v", doc.Headings, doc.Pages)
+	}
+}
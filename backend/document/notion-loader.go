@@ -0,0 +1,378 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NotionLoader loads the pages of a Notion workspace through the Notion
+// API, converting their blocks to Markdown. Sources are page IDs. Each
+// document records the titles of the pages and databases above it in the
+// "notion_path" metadata, joined with " > ", its parent in
+// "notion_parent" and its last edit time as its Version.
+type NotionLoader struct {
+	// Token is the integration token, which needs read access to the
+	// pages.
+	Token string
+	// Endpoint defaults to https://api.notion.com.
+	Endpoint string
+	// MaxRetries is the number of times a failed request is retried, with
+	// exponential backoff. Notion rate limits are retried as well.
+	// Defaults to 3; negative turns retries off.
+	MaxRetries int
+	// Client defaults to an http.Client with a one minute timeout.
+	Client *http.Client
+
+	mu     sync.Mutex
+	titles map[string]notionParentInfo
+}
+
+// notionParentInfo caches the title and parent of a page or database.
+type notionParentInfo struct {
+	title  string
+	parent notionParent
+}
+
+// NewNotionLoader creates a loader that authenticates with token.
+func NewNotionLoader(token string) *NotionLoader {
+	return &NotionLoader{Token: token}
+}
+
+type notionParent struct {
+	Type       string `json:"type"`
+	PageID     string `json:"page_id"`
+	DatabaseID string `json:"database_id"`
+	BlockID    string `json:"block_id"`
+}
+
+func (p notionParent) id() string {
+	switch p.Type {
+	case "page_id":
+		return p.PageID
+	case "database_id":
+		return p.DatabaseID
+	case "block_id":
+		return p.BlockID
+	}
+	return ""
+}
+
+type notionPage struct {
+	ID             string                     `json:"id"`
+	URL            string                     `json:"url"`
+	LastEditedTime string                     `json:"last_edited_time"`
+	Parent         notionParent               `json:"parent"`
+	Properties     map[string]json.RawMessage `json:"properties"`
+	// Title is set for databases.
+	Title []notionRichText `json:"title"`
+}
+
+type notionRichText struct {
+	PlainText string `json:"plain_text"`
+	Href      string `json:"href"`
+}
+
+// title returns the text of the title property of a page, or the title of
+// a database.
+func (p notionPage) title() string {
+	if len(p.Title) > 0 {
+		return richText(p.Title)
+	}
+	for _, raw := range p.Properties {
+		var prop struct {
+			Type  string           `json:"type"`
+			Title []notionRichText `json:"title"`
+		}
+		if json.Unmarshal(raw, &prop) == nil && prop.Type == "title" {
+			return richText(prop.Title)
+		}
+	}
+	return ""
+}
+
+// List returns the IDs of the pages of the database whose ID is prefix,
+// or of every page shared with the integration when prefix is empty.
+func (l *NotionLoader) List(ctx context.Context, prefix string) ([]string, error) {
+	path, body := "/v1/search", map[string]any{"filter": map[string]any{"property": "object", "value": "page"}}
+	if prefix != "" {
+		path, body = "/v1/databases/"+url.PathEscape(prefix)+"/query", map[string]any{}
+	}
+	var ids []string
+	for {
+		data, err := l.call(ctx, http.MethodPost, path, body)
+		if err != nil {
+			return nil, fmt.Errorf("list notion pages: %w", err)
+		}
+		var page struct {
+			Results    []notionPage `json:"results"`
+			HasMore    bool         `json:"has_more"`
+			NextCursor string       `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("decode notion pages: %w", err)
+		}
+		for _, p := range page.Results {
+			ids = append(ids, p.ID)
+		}
+		if !page.HasMore || page.NextCursor == "" {
+			return ids, nil
+		}
+		body["start_cursor"] = page.NextCursor
+	}
+}
+
+// Load fetches the page with ID source as Markdown.
+func (l *NotionLoader) Load(ctx context.Context, source string) ([]byte, string, error) {
+	f, err := l.Fetch(ctx, source)
+	if err != nil {
+		return nil, "", err
+	}
+	return f.Buffer, f.Filename, nil
+}
+
+// Fetch fetches the page with ID source as Markdown, with its hierarchy
+// in the metadata.
+func (l *NotionLoader) Fetch(ctx context.Context, source string) (*Fetched, error) {
+	data, err := l.call(ctx, http.MethodGet, "/v1/pages/"+url.PathEscape(source), nil)
+	if err != nil {
+		return nil, fmt.Errorf("get notion page: %w", err)
+	}
+	var page notionPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, fmt.Errorf("decode notion page: %w", err)
+	}
+	title := page.title()
+	var b strings.Builder
+	if title != "" {
+		b.WriteString("# " + title + "\n\n")
+	}
+	if err := l.writeBlocks(ctx, &b, page.ID, 0); err != nil {
+		return nil, err
+	}
+
+	path := l.ancestors(ctx, page.Parent)
+	metadata := map[string]string{"notion_id": page.ID, "notion_parent": page.Parent.id()}
+	if title != "" {
+		path = append(path, title)
+		metadata["title"] = title
+	}
+	if len(path) > 0 {
+		metadata["notion_path"] = strings.Join(path, " > ")
+	}
+	name := title
+	if name == "" {
+		name = page.ID
+	}
+	return &Fetched{
+		Buffer:    []byte(b.String()),
+		Filename:  strings.ReplaceAll(name, "/", "-") + ".md",
+		MIMEType:  "text/markdown",
+		URI:       page.URL,
+		FetchedAt: time.Now().UTC(),
+		Version:   page.LastEditedTime,
+		Metadata:  metadata,
+	}, nil
+}
+
+// ancestors returns the titles of the pages and databases above parent,
+// outermost first. Parents the integration cannot read end the path.
+func (l *NotionLoader) ancestors(ctx context.Context, parent notionParent) []string {
+	var path []string
+	for depth := 0; depth < 32; depth++ {
+		id := parent.id()
+		if id == "" {
+			break
+		}
+		info, ok := l.parentInfo(ctx, parent)
+		if !ok {
+			break
+		}
+		if info.title != "" {
+			path = append([]string{info.title}, path...)
+		}
+		parent = info.parent
+	}
+	return path
+}
+
+// parentInfo returns the title and parent of the page, database or block
+// parent refers to, caching them.
+func (l *NotionLoader) parentInfo(ctx context.Context, parent notionParent) (notionParentInfo, bool) {
+	id := parent.id()
+	l.mu.Lock()
+	info, ok := l.titles[id]
+	l.mu.Unlock()
+	if ok {
+		return info, true
+	}
+	path := map[string]string{"page_id": "/v1/pages/", "database_id": "/v1/databases/", "block_id": "/v1/blocks/"}[parent.Type]
+	data, err := l.call(ctx, http.MethodGet, path+url.PathEscape(id), nil)
+	if err != nil {
+		return info, false
+	}
+	var p notionPage
+	if json.Unmarshal(data, &p) != nil {
+		return info, false
+	}
+	info = notionParentInfo{title: p.title(), parent: p.Parent}
+	l.mu.Lock()
+	if l.titles == nil {
+		l.titles = map[string]notionParentInfo{}
+	}
+	l.titles[id] = info
+	l.mu.Unlock()
+	return info, true
+}
+
+// notionBlockContent is the union of the type-specific fields of the
+// blocks writeNotionBlock converts.
+type notionBlockContent struct {
+	RichText   []notionRichText   `json:"rich_text"`
+	Checked    bool               `json:"checked"`
+	Language   string             `json:"language"`
+	Title      string             `json:"title"`
+	URL        string             `json:"url"`
+	Cells      [][]notionRichText `json:"cells"`
+	Expression string             `json:"expression"`
+}
+
+// writeBlocks writes the children of block id to b as Markdown, nested
+// depth levels deep, fetching every page of them.
+func (l *NotionLoader) writeBlocks(ctx context.Context, b *strings.Builder, id string, depth int) error {
+	cursor, prev, run := "", "", 0
+	for {
+		path := "/v1/blocks/" + url.PathEscape(id) + "/children?page_size=100"
+		if cursor != "" {
+			path += "&start_cursor=" + url.QueryEscape(cursor)
+		}
+		data, err := l.call(ctx, http.MethodGet, path, nil)
+		if err != nil {
+			return fmt.Errorf("get notion blocks: %w", err)
+		}
+		var page struct {
+			Results    []map[string]json.RawMessage `json:"results"`
+			HasMore    bool                         `json:"has_more"`
+			NextCursor string                       `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return fmt.Errorf("decode notion blocks: %w", err)
+		}
+		for _, raw := range page.Results {
+			var block struct {
+				ID          string `json:"id"`
+				Type        string `json:"type"`
+				HasChildren bool   `json:"has_children"`
+			}
+			var c notionBlockContent
+			if json.Unmarshal(raw["id"], &block.ID) != nil || json.Unmarshal(raw["type"], &block.Type) != nil {
+				continue
+			}
+			json.Unmarshal(raw["has_children"], &block.HasChildren)
+			json.Unmarshal(raw[block.Type], &c)
+			if block.Type != prev {
+				if notionListBlocks[prev] {
+					// End the list so the next block does not continue it.
+					b.WriteString("\n")
+				}
+				prev, run = block.Type, 0
+			}
+			run++
+			writeNotionBlock(b, block.Type, c, depth, run)
+			// Child pages and databases are documents of their own.
+			if block.HasChildren && block.Type != "child_page" && block.Type != "child_database" {
+				if err := l.writeBlocks(ctx, b, block.ID, depth+1); err != nil {
+					return err
+				}
+			}
+			if block.Type == "table" {
+				b.WriteString("\n")
+			}
+		}
+		if !page.HasMore || page.NextCursor == "" {
+			return nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// notionListBlocks are the blocks written as list items.
+var notionListBlocks = map[string]bool{
+	"bulleted_list_item": true, "numbered_list_item": true, "to_do": true,
+	"toggle": true, "child_page": true, "child_database": true,
+}
+
+// writeNotionBlock writes one block as a line or paragraph of Markdown.
+// n is the position of the block in a run of blocks of its kind, which
+// numbers list items and marks the header row of a table.
+func writeNotionBlock(b *strings.Builder, kind string, c notionBlockContent, depth, n int) {
+	indent := strings.Repeat("  ", depth)
+	text := richText(c.RichText)
+	switch kind {
+	case "heading_1", "heading_2", "heading_3":
+		b.WriteString("\n" + strings.Repeat("#", int(kind[len(kind)-1]-'0')+1) + " " + text + "\n\n")
+	case "paragraph":
+		if text != "" {
+			b.WriteString(indent + text + "\n\n")
+		}
+	case "bulleted_list_item", "toggle":
+		b.WriteString(indent + "- " + text + "\n")
+	case "numbered_list_item":
+		b.WriteString(fmt.Sprintf("%s%d. %s\n", indent, n, text))
+	case "to_do":
+		box := "[ ]"
+		if c.Checked {
+			box = "[x]"
+		}
+		b.WriteString(indent + "- " + box + " " + text + "\n")
+	case "quote", "callout":
+		b.WriteString(indent + "> " + text + "\n\n")
+	case "code":
+		b.WriteString("```" + c.Language + "\n" + text + "\n```\n\n")
+	case "equation":
+		b.WriteString("$$" + c.Expression + "$$\n\n")
+	case "divider":
+		b.WriteString("---\n\n")
+	case "table":
+		b.WriteString("\n")
+	case "table_row":
+		cells := make([]string, len(c.Cells))
+		for i, cell := range c.Cells {
+			cells[i] = strings.ReplaceAll(richText(cell), "|", `\|`)
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if n == 1 {
+			b.WriteString("|" + strings.Repeat(" --- |", len(cells)) + "\n")
+		}
+	case "child_page", "child_database":
+		b.WriteString(indent + "- " + c.Title + "\n")
+	case "bookmark", "embed", "link_preview":
+		b.WriteString(indent + c.URL + "\n\n")
+	}
+}
+
+// richText joins the plain text of Notion rich text.
+func richText(parts []notionRichText) string {
+	var b strings.Builder
+	for _, p := range parts {
+		b.WriteString(p.PlainText)
+	}
+	return b.String()
+}
+
+// call sends a request to the Notion API at path.
+func (l *NotionLoader) call(ctx context.Context, method, path string, body any) ([]byte, error) {
+	endpoint := strings.TrimSuffix(l.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "https://api.notion.com"
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+l.Token)
+	header.Set("Notion-Version", "2022-06-28")
+	return retryPolicy{maxRetries: l.MaxRetries}.sendJSON(ctx, httpClient(l.Client, time.Minute), method, endpoint+path, header, body)
+}
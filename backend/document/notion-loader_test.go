@@ -0,0 +1,128 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// notionServer serves the Notion API responses in routes, keyed by method
+// and path with query, such as "GET /v1/pages/p1".
+func notionServer(t *testing.T, routes map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Notion-Version") == "" {
+			http.Error(w, `{"code": "unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		key := r.Method + " " + r.URL.RequestURI()
+		if r.Method == http.MethodPost {
+			body, _ := io.ReadAll(r.Body)
+			var req map[string]any
+			json.Unmarshal(body, &req)
+			if c, ok := req["start_cursor"].(string); ok {
+				key += " " + c
+			}
+		}
+		resp, ok := routes[key]
+		if !ok {
+			http.Error(w, `{"code": "object_not_found"}`, http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(resp))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNotionLoader(t *testing.T) {
+	text := func(s string) string { return `{"rich_text": [{"plain_text": "` + s + `"}]}` }
+	block := func(id, kind, content string, children bool) string {
+		c, _ := json.Marshal(children)
+		return `{"id": "` + id + `", "type": "` + kind + `", "has_children": ` + string(c) + `, "` + kind + `": ` + content + `}`
+	}
+	srv := notionServer(t, map[string]string{
+		"GET /v1/databases/db1": `{"id": "db1", "title": [{"plain_text": "Wiki"}], "parent": {"type": "workspace"}}`,
+		"GET /v1/pages/p1": `{"id": "p1", "parent": {"type": "database_id", "database_id": "db1"},
+			"properties": {"Name": {"type": "title", "title": [{"plain_text": "Setup"}]}}}`,
+		"GET /v1/pages/p2": `{"id": "p2", "url": "https://www.notion.so/p2", "last_edited_time": "2024-05-06T07:08:00.000Z",
+			"parent": {"type": "page_id", "page_id": "p1"},
+			"properties": {"Tags": {"type": "multi_select"}, "title": {"type": "title", "title": [{"plain_text": "Install"}, {"plain_text": "/Build"}]}}}`,
+		"GET /v1/blocks/p2/children?page_size=100": `{"has_more": true, "next_cursor": "c2", "results": [` +
+			block("b1", "heading_1", text("Steps"), false) + `,` +
+			block("b2", "numbered_list_item", text("Download"), false) + `,` +
+			block("b3", "numbered_list_item", text("Unpack"), true) + `,` +
+			block("b4", "paragraph", text("Then run it."), false) + `]}`,
+		"GET /v1/blocks/b3/children?page_size=100": `{"results": [` + block("b5", "bulleted_list_item", text("quietly"), false) + `]}`,
+		"GET /v1/blocks/p2/children?page_size=100&start_cursor=c2": `{"results": [` +
+			block("b6", "to_do", `{"checked": true, "rich_text": [{"plain_text": "Done"}]}`, false) + `,` +
+			block("b7", "code", `{"language": "sh", "rich_text": [{"plain_text": "make"}]}`, false) + `,` +
+			block("b8", "table", `{}`, true) + `,` +
+			block("b9", "child_page", `{"title": "Advanced"}`, true) + `,` +
+			block("b10", "divider", `{}`, false) + `]}`,
+		"GET /v1/blocks/b8/children?page_size=100": `{"results": [` +
+			block("r1", "table_row", `{"cells": [[{"plain_text": "OS"}], [{"plain_text": "Command"}]]}`, false) + `,` +
+			block("r2", "table_row", `{"cells": [[{"plain_text": "Linux"}], [{"plain_text": "a | b"}]]}`, false) + `]}`,
+		"POST /v1/search":                          `{"results": [{"id": "p1"}], "has_more": true, "next_cursor": "n"}`,
+		"POST /v1/search n":                        `{"results": [{"id": "p2"}]}`,
+		"POST /v1/databases/db1/query":             `{"results": [{"id": "p1"}]}`,
+		"GET /v1/blocks/p1/children?page_size=100": `{"results": []}`,
+	})
+	l := &NotionLoader{Token: "secret", Endpoint: srv.URL}
+	ctx := context.Background()
+
+	f, err := l.Fetch(ctx, "p2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Install/Build\n\n" +
+		"\n## Steps\n\n" +
+		"1. Download\n2. Unpack\n  - quietly\n\n" +
+		"Then run it.\n\n" +
+		"- [x] Done\n\n" +
+		"```sh\nmake\n```\n\n" +
+		"\n| OS | Command |\n| --- | --- |\n| Linux | a \\| b |\n\n" +
+		"- Advanced\n\n" +
+		"---\n\n"
+	if string(f.Buffer) != want {
+		t.Errorf("markdown:\n%s\nwant:\n%s", f.Buffer, want)
+	}
+	if f.Filename != "Install-Build.md" || f.MIMEType != "text/markdown" || f.URI != "https://www.notion.so/p2" ||
+		f.Version != "2024-05-06T07:08:00.000Z" {
+		t.Errorf("fetched %+v", f)
+	}
+	wantMeta := map[string]string{"notion_id": "p2", "notion_parent": "p1", "title": "Install/Build", "notion_path": "Wiki > Setup > Install/Build"}
+	if !reflect.DeepEqual(f.Metadata, wantMeta) {
+		t.Errorf("metadata %v, want %v", f.Metadata, wantMeta)
+	}
+
+	ids, err := l.List(ctx, "")
+	if err != nil || !reflect.DeepEqual(ids, []string{"p1", "p2"}) {
+		t.Errorf("List = %q, %v", ids, err)
+	}
+	if ids, err := l.List(ctx, "db1"); err != nil || !reflect.DeepEqual(ids, []string{"p1"}) {
+		t.Errorf("database List = %q, %v", ids, err)
+	}
+
+	var docs []*Document
+	p := NewPipeline().LoadWith(l).To(SinkFunc(func(ctx context.Context, doc *Document, chunks []Chunk) error {
+		docs = append(docs, doc)
+		return nil
+	}))
+	if err := p.RunPrefix(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[1].Metadata["notion_path"] != "Wiki > Setup > Install/Build" || docs[1].Version == "" ||
+		!strings.Contains(docs[1].Content, "Linux") {
+		t.Errorf("documents %+v", docs)
+	}
+
+	if _, err := (&NotionLoader{Token: "wrong", Endpoint: srv.URL}).Fetch(ctx, "p2"); err == nil {
+		t.Error("unauthorized request returned no error")
+	}
+}
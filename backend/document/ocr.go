@@ -0,0 +1,92 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// OCRProvider recognizes the text in an image. mimeType is the image
+// format, such as "image/png" or "image/jpeg".
+type OCRProvider interface {
+	Recognize(image []byte, mimeType string) (string, error)
+}
+
+// TesseractOCR is an OCRProvider that runs the tesseract command line tool.
+type TesseractOCR struct {
+	// Command is the tesseract executable. Empty means "tesseract" on PATH.
+	Command string
+	// Languages are tesseract language codes such as "eng" or "deu". Empty
+	// uses tesseract's default.
+	Languages []string
+	// Timeout bounds a single recognition. Zero means one minute.
+	Timeout time.Duration
+}
+
+// NewTesseractOCR creates a tesseract OCR provider with default settings.
+func NewTesseractOCR() *TesseractOCR {
+	return &TesseractOCR{}
+}
+
+// ocrExtensions are the file extensions tesseract uses to pick a decoder.
+var ocrExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/tiff": ".tif",
+	"image/gif":  ".gif",
+	"image/bmp":  ".bmp",
+	"image/webp": ".webp",
+	"image/jp2":  ".jp2",
+}
+
+// Recognize writes the image to a temporary file and returns the text
+// tesseract prints for it.
+func (t *TesseractOCR) Recognize(image []byte, mimeType string) (string, error) {
+	return t.RecognizeContext(context.Background(), image, mimeType)
+}
+
+// RecognizeContext works like Recognize but kills tesseract when ctx is
+// done.
+func (t *TesseractOCR) RecognizeContext(ctx context.Context, image []byte, mimeType string) (string, error) {
+	f, err := os.CreateTemp("", "ocr-*"+ocrExtensions[mimeType])
+	if err != nil {
+		return "", fmt.Errorf("create OCR input: %w", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(image)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("write OCR input: %w", err)
+	}
+
+	command := t.Command
+	if command == "" {
+		command = "tesseract"
+	}
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	args := []string{f.Name(), "stdout"}
+	if len(t.Languages) > 0 {
+		args = append(args, "-l", strings.Join(t.Languages, "+"))
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("tesseract: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("tesseract: %w", err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,95 @@
+package document
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeOCR returns text for every image and records the formats it saw.
+type fakeOCR struct {
+	text  string
+	types []string
+}
+
+func (o *fakeOCR) Recognize(image []byte, mimeType string) (string, error) {
+	o.types = append(o.types, mimeType)
+	return o.text, nil
+}
+
+func TestTesseractOCR(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of tesseract")
+	}
+	dir := t.TempDir()
+	command := filepath.Join(dir, "tesseract")
+	script := "#!/bin/sh\ncase \"$1\" in *.png) ;; *) exit 2 ;; esac\necho \"args: $2 $3 $4\"\n"
+	if err := os.WriteFile(command, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ocr := &TesseractOCR{Command: command, Languages: []string{"eng", "deu"}}
+	text, err := ocr.Recognize([]byte("\x89PNG"), "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(text) != "args: stdout -l eng+deu" {
+		t.Errorf("tesseract printed %q", text)
+	}
+	if _, err := ocr.Recognize([]byte("GIF89a"), "image/gif"); err == nil {
+		t.Error("failing tesseract run returned no error")
+	}
+
+	slow := filepath.Join(dir, "slow")
+	if err := os.WriteFile(slow, []byte("#!/bin/sh\nexec sleep 5\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&TesseractOCR{Command: slow, Timeout: 50 * time.Millisecond}).Recognize(nil, "image/png"); err == nil {
+		t.Error("tesseract run past Timeout returned no error")
+	}
+}
+
+func TestImageParserOCR(t *testing.T) {
+	ocr := &fakeOCR{text: "  Scanned\n\n\ntext  "}
+	doc, err := (&ImageParser{OCR: ocr}).Parse([]byte("\xff\xd8\xff\xe0 jpeg"), "scan.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(doc.Content, "Scanned") || len(ocr.types) != 1 || ocr.types[0] != "image/jpeg" {
+		t.Errorf("content %q, formats %v", doc.Content, ocr.types)
+	}
+	if _, err := (&ImageParser{OCR: ocr}).Parse([]byte("not an image"), "a.png"); err == nil {
+		t.Error("unrecognized image format parsed")
+	}
+}
+
+func TestPDFParserOCR(t *testing.T) {
+	// Page 1 has a text layer, page 2 a JPEG scan and page 3 a raw gray
+	// image that is re-encoded as PNG.
+	pdf := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 6 0 R 9 0 R] /Count 3 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		pdfStreamObject("BT /F1 12 Tf 72 720 Td (Typed page.) Tj ET"),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 7 0 R /Resources << /XObject << /Im1 8 0 R >> >> >>",
+		pdfStreamObject("q 100 0 0 100 0 0 cm /Im1 Do Q"),
+		"<< /Type /XObject /Subtype /Image /Width 2 /Height 2 /Filter /DCTDecode /Length 4 >>\nstream\n\xff\xd8\xff\xd9\nendstream",
+		"<< /Type /Page /Parent 2 0 R /Contents 10 0 R /Resources << /XObject << /Im1 11 0 R >> >> >>",
+		pdfStreamObject("q 100 0 0 100 0 0 cm /Im1 Do Q"),
+		"<< /Type /XObject /Subtype /Image /Width 2 /Height 2 /ColorSpace /DeviceGray /BitsPerComponent 8 /Length 4 >>\nstream\n\x00\xff\xff\x00\nendstream",
+	)
+	ocr := &fakeOCR{text: "Scanned page."}
+	doc, err := (&PDFParser{OCR: ocr}).Parse(pdf, "scan.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(doc.Content, "Scanned page.") != 2 || !strings.Contains(doc.Content, "Typed page.") {
+		t.Errorf("content %q", doc.Content)
+	}
+	if doc.Metadata["ocr_pages"] != "2,3" || strings.Join(ocr.types, " ") != "image/jpeg image/png" {
+		t.Errorf("ocr_pages %q, formats %v", doc.Metadata["ocr_pages"], ocr.types)
+	}
+}
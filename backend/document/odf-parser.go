@@ -0,0 +1,325 @@
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ODFParser extracts text from OpenDocument text documents, spreadsheets and
+// presentations (ODT, ODS, ODP) by walking their content.xml.
+type ODFParser struct {
+	// MaxDecompressedBytes limits the bytes read from all the parts of a
+	// package together, so a ZIP bomb fails with ErrTooLarge. Defaults to
+	// 256 MB.
+	MaxDecompressedBytes int64
+}
+
+// NewODFParser creates a new OpenDocument parser instance.
+func NewODFParser() *ODFParser {
+	return &ODFParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *ODFParser) Supports(mimeType string) bool {
+	switch mimeType {
+	case "application/vnd.oasis.opendocument.text",
+		"application/vnd.oasis.opendocument.text-template",
+		"application/vnd.oasis.opendocument.spreadsheet",
+		"application/vnd.oasis.opendocument.spreadsheet-template",
+		"application/vnd.oasis.opendocument.presentation",
+		"application/vnd.oasis.opendocument.presentation-template":
+		return true
+	}
+	return false
+}
+
+// odfMaxRepeat caps table:number-*-repeated expansion; spreadsheets use it
+// to describe millions of trailing empty cells.
+const odfMaxRepeat = 100
+
+// Parse renders headings with "#" prefixes, list items with "-", each
+// spreadsheet as a "## sheet" section of pipe-separated rows, and records
+// each presentation slide in Document.Pages.
+func (p *ODFParser) Parse(buffer []byte, filename string) (*Document, error) {
+	zr, err := openZip(buffer, p.MaxDecompressedBytes)
+	if err != nil {
+		return nil, err
+	}
+	content, err := readZipEntry(zr, "content.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	w := &odfWalker{}
+	if err := w.walk(content); err != nil {
+		return nil, fmt.Errorf("parse content.xml: %w", err)
+	}
+	text := w.out.String()
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrEmptyDocument
+	}
+
+	doc := &Document{
+		Content:   text,
+		Source:    filename,
+		WordCount: len(strings.Fields(text)),
+		Pages:     w.pages,
+		Headings:  w.headings,
+		Sections:  BuildSections(w.headings, len(text)),
+	}
+	if meta, err := readZipEntry(zr, "meta.xml"); err == nil {
+		doc.Metadata = odfMetadata(meta)
+	}
+	if err := zr.err(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// odfMetadata reads title, author and subject from meta.xml.
+func odfMetadata(data []byte) map[string]string {
+	var m struct {
+		Meta struct {
+			Title   string `xml:"title"`
+			Subject string `xml:"subject"`
+			Creator string `xml:"creator"`
+			Initial string `xml:"initial-creator"`
+		} `xml:"meta"`
+	}
+	if xml.Unmarshal(data, &m) != nil {
+		return nil
+	}
+	meta := map[string]string{}
+	set := func(k, v string) {
+		if v = strings.TrimSpace(v); v != "" {
+			meta[k] = v
+		}
+	}
+	set("title", m.Meta.Title)
+	set("subject", m.Meta.Subject)
+	set("author", m.Meta.Initial)
+	if meta["author"] == "" {
+		set("author", m.Meta.Creator)
+	}
+	if len(meta) == 0 {
+		return nil
+	}
+	return meta
+}
+
+// odfWalker streams content.xml and renders block-level output.
+type odfWalker struct {
+	out      strings.Builder
+	pages    []Page
+	headings []Heading
+
+	para       *strings.Builder
+	headLevel  int
+	listDepth  int
+	tableDepth int
+	rows       []string
+	row        []string
+	rowRepeat  int
+	cell       *strings.Builder
+	cellRepeat int
+	skipDepth  int
+	inList     bool
+	sheets     bool
+}
+
+func odfAttr(e xml.StartElement, local string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func odfRepeat(e xml.StartElement, local string) int {
+	n, err := strconv.Atoi(odfAttr(e, local))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return min(n, odfMaxRepeat)
+}
+
+func (w *odfWalker) walk(data []byte) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			w.endPage()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			w.start(t)
+		case xml.EndElement:
+			w.end(t)
+		case xml.CharData:
+			if w.para != nil && w.skipDepth == 0 {
+				w.para.Write(t)
+			}
+		}
+	}
+}
+
+func (w *odfWalker) start(e xml.StartElement) {
+	if w.skipDepth > 0 {
+		w.skipDepth++
+		return
+	}
+	switch e.Name.Local {
+	case "annotation", "notes", "tracked-changes", "note":
+		w.skipDepth = 1
+	case "spreadsheet":
+		w.sheets = true
+	case "p", "h":
+		if w.para == nil {
+			w.para = &strings.Builder{}
+			w.headLevel = 0
+			if e.Name.Local == "h" {
+				w.headLevel = 1
+				if lvl, err := strconv.Atoi(odfAttr(e, "outline-level")); err == nil && lvl > 0 {
+					w.headLevel = lvl
+				}
+			}
+		} else {
+			w.para.WriteByte(' ')
+		}
+	case "s":
+		if w.para != nil {
+			n, err := strconv.Atoi(odfAttr(e, "c"))
+			if err != nil || n < 1 {
+				n = 1
+			}
+			w.para.WriteString(strings.Repeat(" ", min(n, 16)))
+		}
+	case "tab":
+		if w.para != nil {
+			w.para.WriteByte('\t')
+		}
+	case "line-break":
+		if w.para != nil {
+			w.para.WriteByte('\n')
+		}
+	case "list":
+		w.listDepth++
+	case "page":
+		w.endPage()
+		w.block("")
+		w.pages = append(w.pages, Page{Number: len(w.pages) + 1, Start: w.out.Len(), End: -1})
+	case "table":
+		w.tableDepth++
+		if w.tableDepth == 1 {
+			w.rows = nil
+			if name := odfAttr(e, "name"); w.sheets && name != "" {
+				w.block("## " + name)
+			}
+		}
+	case "table-row":
+		if w.tableDepth == 1 {
+			w.row = nil
+			w.rowRepeat = odfRepeat(e, "number-rows-repeated")
+		}
+	case "table-cell", "covered-table-cell":
+		if w.tableDepth == 1 {
+			w.cell = &strings.Builder{}
+			w.cellRepeat = odfRepeat(e, "number-columns-repeated")
+		}
+	}
+}
+
+func (w *odfWalker) end(e xml.EndElement) {
+	if w.skipDepth > 0 {
+		w.skipDepth--
+		return
+	}
+	switch e.Name.Local {
+	case "p", "h":
+		if w.para == nil {
+			return
+		}
+		text := strings.TrimSpace(strings.Join(strings.Fields(w.para.String()), " "))
+		w.para = nil
+		if text == "" {
+			return
+		}
+		switch {
+		case w.cell != nil:
+			if w.cell.Len() > 0 {
+				w.cell.WriteByte(' ')
+			}
+			w.cell.WriteString(text)
+		case w.headLevel > 0:
+			w.block("")
+			w.headings = append(w.headings, Heading{Level: w.headLevel, Text: text, Offset: w.out.Len()})
+			w.out.WriteString(strings.Repeat("#", w.headLevel) + " " + text)
+		case w.listDepth > 0:
+			if w.inList {
+				w.out.WriteByte('\n')
+			} else {
+				w.block("")
+			}
+			w.inList = true
+			w.out.WriteString(strings.Repeat("  ", w.listDepth-1) + "- " + text)
+			return
+		default:
+			w.block(text)
+		}
+		w.inList = false
+	case "list":
+		w.listDepth--
+		if w.listDepth == 0 {
+			w.inList = false
+		}
+	case "table-cell", "covered-table-cell":
+		if w.tableDepth == 1 && w.cell != nil {
+			value := strings.ReplaceAll(w.cell.String(), "|", "/")
+			for i := 0; i < w.cellRepeat; i++ {
+				w.row = append(w.row, value)
+			}
+			w.cell = nil
+		}
+	case "table-row":
+		if w.tableDepth == 1 {
+			line := strings.TrimSpace(strings.Join(w.row, " | "))
+			if strings.Trim(line, "| ") != "" {
+				line = strings.TrimRight(line, "| ")
+				for i := 0; i < w.rowRepeat; i++ {
+					w.rows = append(w.rows, line)
+				}
+			}
+		}
+	case "table":
+		w.tableDepth--
+		if w.tableDepth == 0 && len(w.rows) > 0 {
+			w.block(strings.Join(w.rows, "\n"))
+			w.rows = nil
+		}
+	}
+}
+
+// block starts a new paragraph and writes text, if any.
+func (w *odfWalker) block(text string) {
+	if w.out.Len() > 0 && !strings.HasSuffix(w.out.String(), "\n\n") {
+		w.out.WriteString("\n\n")
+	}
+	w.out.WriteString(text)
+	w.inList = false
+}
+
+// endPage closes the byte range of the current presentation slide.
+func (w *odfWalker) endPage() {
+	if n := len(w.pages); n > 0 && w.pages[n-1].End < 0 {
+		w.pages[n-1].End = max(len(strings.TrimRight(w.out.String(), "\n")), w.pages[n-1].Start)
+	}
+}
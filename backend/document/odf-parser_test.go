@@ -0,0 +1,60 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+func odfFile(t testing.TB, body string) []byte {
+	return zipFiles(t,
+		"content.xml", `<office:document-content xmlns:office="o" xmlns:text="t" xmlns:table="tb" xmlns:draw="d" xmlns:presentation="p"><office:body>`+body+`</office:body></office:document-content>`,
+		"meta.xml", `<office:document-meta xmlns:office="o" xmlns:dc="dc" xmlns:meta="m"><office:meta><dc:title>Report</dc:title><meta:initial-creator>Ada</meta:initial-creator></office:meta></office:document-meta>`,
+	)
+}
+
+func TestODFParser(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		want  []string
+		pages int
+	}{
+		{"text", `<office:text><text:h text:outline-level="2">Setup</text:h><text:p>Run<text:s text:c="2"/>it.</text:p>
+<text:list><text:list-item><text:p>First</text:p></text:list-item><text:list-item><text:p>Second</text:p></text:list-item></text:list>
+<office:annotation><text:p>Hidden comment</text:p></office:annotation></office:text>`,
+			[]string{"## Setup", "Run it.", "- First\n- Second"}, 0},
+		{"spreadsheet", `<office:spreadsheet><table:table table:name="Sales"><table:table-row><table:table-cell><text:p>Name</text:p></table:table-cell><table:table-cell><text:p>Total</text:p></table:table-cell></table:table-row>
+<table:table-row table:number-rows-repeated="2"><table:table-cell table:number-columns-repeated="2"><text:p>x</text:p></table:table-cell></table:table-row></table:table></office:spreadsheet>`,
+			[]string{"## Sales", "Name | Total\nx | x\nx | x"}, 0},
+		{"presentation", `<office:presentation><draw:page><draw:frame><draw:text-box><text:p>Slide one</text:p></draw:text-box></draw:frame><presentation:notes><text:p>Notes</text:p></presentation:notes></draw:page>
+<draw:page><draw:frame><draw:text-box><text:p>Slide two</text:p></draw:text-box></draw:frame></draw:page></office:presentation>`,
+			[]string{"Slide one", "Slide two"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewODFParser().Parse(odfFile(t, tt.body), "a.odt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(doc.Content, want) {
+					t.Errorf("content %q does not contain %q", doc.Content, want)
+				}
+			}
+			if strings.Contains(doc.Content, "Hidden") || strings.Contains(doc.Content, "Notes") {
+				t.Errorf("content %q has annotations or notes", doc.Content)
+			}
+			if len(doc.Pages) != tt.pages {
+				t.Fatalf("%d pages, want %d", len(doc.Pages), tt.pages)
+			}
+			for _, pg := range doc.Pages {
+				if s := doc.Content[pg.Start:pg.End]; !strings.HasPrefix(s, "Slide") {
+					t.Errorf("page %d is %q", pg.Number, s)
+				}
+			}
+			if doc.Metadata["title"] != "Report" || doc.Metadata["author"] != "Ada" {
+				t.Errorf("metadata %v", doc.Metadata)
+			}
+		})
+	}
+}
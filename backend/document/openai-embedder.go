@@ -0,0 +1,109 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// OpenAIEmbedder embeds texts with an OpenAI-compatible /embeddings
+// endpoint, in batches, retrying rate limits and server errors.
+type OpenAIEmbedder struct {
+	// APIKey is sent as a bearer token.
+	APIKey string
+	// Endpoint defaults to https://api.openai.com/v1/embeddings.
+	Endpoint string
+	// Model defaults to "text-embedding-3-small".
+	Model string
+	// Dimensions optionally shortens the vectors, for models that support
+	// it.
+	Dimensions int
+	// BatchSize is the number of texts sent per request. Defaults to 100.
+	BatchSize int
+	// MaxRetries is the number of times a failed request is retried.
+	// Defaults to 3; negative turns retries off.
+	MaxRetries int
+	// Client defaults to an http.Client with a one minute timeout.
+	Client *http.Client
+}
+
+// NewOpenAIEmbedder creates an embedder for the OpenAI API.
+func NewOpenAIEmbedder(apiKey string) *OpenAIEmbedder {
+	return &OpenAIEmbedder{APIKey: apiKey}
+}
+
+// Embed returns the embedding of each text, in order.
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	endpoint := e.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.openai.com/v1/embeddings"
+	}
+	model := e.Model
+	if model == "" {
+		model = "text-embedding-3-small"
+	}
+	header := http.Header{}
+	if e.APIKey != "" {
+		header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+	return embedBatches(texts, e.BatchSize, func(batch []string) ([][]float32, error) {
+		body := map[string]any{"model": model, "input": batch}
+		if e.Dimensions > 0 {
+			body["dimensions"] = e.Dimensions
+		}
+		data, err := retryPolicy{maxRetries: e.MaxRetries}.sendJSON(ctx, httpClient(e.Client, time.Minute), http.MethodPost, endpoint, header, body)
+		if err != nil {
+			return nil, fmt.Errorf("embedding request: %w", err)
+		}
+		var resp struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return nil, fmt.Errorf("decode embeddings: %w", err)
+		}
+		out := make([][]float32, len(batch))
+		for _, d := range resp.Data {
+			if d.Index < 0 || d.Index >= len(out) {
+				return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+			}
+			out[d.Index] = d.Embedding
+		}
+		for i, v := range out {
+			if v == nil {
+				return nil, fmt.Errorf("no embedding returned for input %d", i)
+			}
+		}
+		return out, nil
+	})
+}
+
+// embedBatches calls embed on consecutive batches of at most size texts,
+// 100 when size is not positive, and concatenates the results.
+func embedBatches(texts []string, size int, embed func(batch []string) ([][]float32, error)) ([][]float32, error) {
+	if size <= 0 {
+		size = 100
+	}
+	out := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += size {
+		vectors, err := embed(texts[start:min(start+size, len(texts))])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, vectors...)
+	}
+	return out, nil
+}
+
+// httpClient returns client, or a new client with the given timeout when
+// client is nil.
+func httpClient(client *http.Client, timeout time.Duration) *http.Client {
+	if client != nil {
+		return client
+	}
+	return &http.Client{Timeout: timeout}
+}
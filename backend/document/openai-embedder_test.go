@@ -0,0 +1,142 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// embeddingServer answers OpenAI embedding requests with the vector
+// {len(input), position} for each input, listed in reverse order.
+func embeddingServer(batches *[][]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "bad key", http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Model      string   `json:"model"`
+			Input      []string `json:"input"`
+			Dimensions int      `json:"dimensions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Model != "text-embedding-3-small" || req.Dimensions != 2 {
+			http.Error(w, "unexpected model", http.StatusBadRequest)
+			return
+		}
+		*batches = append(*batches, req.Input)
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		var resp struct {
+			Data []item `json:"data"`
+		}
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			resp.Data = append(resp.Data, item{i, []float32{float32(len(req.Input[i])), float32(i)}})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestOpenAIEmbedder(t *testing.T) {
+	var batches [][]string
+	srv := embeddingServer(&batches)
+	defer srv.Close()
+
+	e := &OpenAIEmbedder{APIKey: "key", Endpoint: srv.URL, Dimensions: 2, BatchSize: 2}
+	got, err := e.Embed(context.Background(), []string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := [][]float32{{1, 0}, {2, 1}, {3, 0}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("vectors %v, want %v", got, want)
+	}
+	if want := [][]string{{"a", "bb"}, {"ccc"}}; !reflect.DeepEqual(batches, want) {
+		t.Errorf("batches %q, want %q", batches, want)
+	}
+
+	if _, err := (&OpenAIEmbedder{Endpoint: srv.URL, Dimensions: 2}).Embed(context.Background(), []string{"a"}); err == nil {
+		t.Error("unauthorized request returned no error")
+	}
+}
+
+func TestEmbedChunks(t *testing.T) {
+	var batches [][]string
+	srv := embeddingServer(&batches)
+	defer srv.Close()
+	e := &OpenAIEmbedder{APIKey: "key", Endpoint: srv.URL, Dimensions: 2}
+
+	chunks := []Chunk{{Text: "one"}, {Text: "two", EmbedText: "doc: two"}}
+	if err := EmbedChunks(context.Background(), e, chunks); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(chunks[0].Embedding, []float32{3, 0}) || !reflect.DeepEqual(chunks[1].Embedding, []float32{8, 1}) {
+		t.Errorf("embeddings %v, %v", chunks[0].Embedding, chunks[1].Embedding)
+	}
+
+	var sunk []Chunk
+	p := NewPipeline().Embed(e).To(SinkFunc(func(ctx context.Context, doc *Document, chunks []Chunk) error {
+		sunk = chunks
+		return nil
+	}))
+	if err := p.Run(context.Background(), fixture("test-txt.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if len(sunk) == 0 {
+		t.Fatal("pipeline wrote no chunks")
+	}
+	for i, c := range sunk {
+		if len(c.Embedding) != 2 {
+			t.Errorf("chunk %d embedding %v", i, c.Embedding)
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch n := calls.Add(1); {
+		case r.URL.Path == "/bad":
+			http.Error(w, "bad request", http.StatusBadRequest)
+		case n == 1:
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		case n == 2:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte(`{"ok": true}`))
+		}
+	}))
+	defer srv.Close()
+	rp := retryPolicy{maxRetries: 2, baseDelay: time.Millisecond}
+	ctx := context.Background()
+
+	data, err := rp.sendJSON(ctx, srv.Client(), http.MethodPost, srv.URL, nil, map[string]int{"n": 1})
+	if err != nil || string(data) != `{"ok": true}` || calls.Load() != 3 {
+		t.Errorf("after %d calls: %q, %v", calls.Load(), data, err)
+	}
+
+	calls.Store(0)
+	if _, err := (retryPolicy{maxRetries: 1, baseDelay: time.Millisecond}).sendJSON(ctx, srv.Client(), http.MethodGet, srv.URL, nil, nil); err == nil || calls.Load() != 2 {
+		t.Errorf("exhausted retries after %d calls: %v", calls.Load(), err)
+	}
+
+	calls.Store(0)
+	if _, err := rp.sendJSON(ctx, srv.Client(), http.MethodGet, srv.URL+"/bad", nil, nil); err == nil || calls.Load() != 1 {
+		t.Errorf("client error after %d calls: %v", calls.Load(), err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := rp.sendJSON(cancelled, srv.Client(), http.MethodGet, srv.URL, nil, nil); err != context.Canceled {
+		t.Errorf("cancelled request: %v", err)
+	}
+}
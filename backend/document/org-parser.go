@@ -0,0 +1,258 @@
+package document
+
+import (
+	"regexp"
+	"strings"
+)
+
+// OrgParser converts Emacs org-mode files to Markdown-style text. Outline
+// headings become "#" headings recorded in Document.Headings, and each
+// heading's TODO state, priority, tags, planning dates and PROPERTIES
+// drawer are kept as metadata on a Document.Segments entry covering its
+// section.
+type OrgParser struct{}
+
+// NewOrgParser creates a new org-mode parser instance.
+func NewOrgParser() *OrgParser {
+	return &OrgParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *OrgParser) Supports(mimeType string) bool {
+	return mimeType == "text/org" || mimeType == "text/x-org"
+}
+
+var (
+	orgHeading  = regexp.MustCompile(`^(\*+)\s+(.*?)\s*$`)
+	orgPriority = regexp.MustCompile(`^\[#([A-Za-z0-9])\]\s*`)
+	orgTags     = regexp.MustCompile(`\s+(:[\w@#%:]+:)$`)
+	orgKeyword  = regexp.MustCompile(`^#\+(\w+):\s*(.*)$`)
+	orgBlock    = regexp.MustCompile(`(?i)^\s*#\+begin_(\w+)(?:\s+(\S+))?`)
+	orgDrawer   = regexp.MustCompile(`^\s*:([\w-]+):\s*$`)
+	orgProperty = regexp.MustCompile(`^\s*:([\w-]+?)\+?:\s*(.*)$`)
+	orgPlanning = regexp.MustCompile(`\b(SCHEDULED|DEADLINE|CLOSED):\s*([<\[][^>\]]*[>\]])`)
+	orgListItem = regexp.MustCompile(`^(\s*)([-+]|\s\*|\d+[.)])\s+(.*)$`)
+	orgLink     = regexp.MustCompile(`\[\[([^\]]+)\](?:\[([^\]]+)\])?\]`)
+	orgCode     = regexp.MustCompile(`(^|[\s(])[=~]([^\s=~](?:[^=~]*[^\s=~])?)[=~]([\s.,;:!?)]|$)`)
+	orgFootRef  = regexp.MustCompile(`\[fn:[\w-]*(?::[^\]]*)?\]`)
+)
+
+// orgFileKeys maps in-buffer settings to document metadata keys.
+var orgFileKeys = map[string]string{
+	"title": "title", "author": "author", "date": "date", "email": "email",
+	"description": "description", "keywords": "keywords", "filetags": "tags",
+	"language": "language", "subtitle": "subtitle",
+}
+
+// orgSection is a heading's metadata and where its section starts.
+type orgSection struct {
+	offset int
+	meta   map[string]string
+}
+
+// Parse converts the outline. "#+TITLE:" and similar settings become
+// document metadata, and "#+TODO:" lines extend the TODO keywords beyond
+// TODO and DONE. Drawers other than PROPERTIES and comments are dropped.
+func (p *OrgParser) Parse(buffer []byte, filename string) (*Document, error) {
+	src := decodeText(buffer)
+	lines := strings.Split(src, "\n")
+	w := &markupWriter{}
+	meta := map[string]string{}
+	todo := map[string]bool{"TODO": true, "DONE": true}
+	for _, line := range lines {
+		if m := orgKeyword.FindStringSubmatch(line); m != nil {
+			switch strings.ToLower(m[1]) {
+			case "todo", "seq_todo", "typ_todo":
+				for _, k := range strings.Fields(m[2]) {
+					if k != "|" {
+						// "WAIT(w@/!)" declares WAIT with a fast-access key.
+						k, _, _ = strings.Cut(k, "(")
+						todo[k] = true
+					}
+				}
+			}
+		}
+	}
+
+	var sections []orgSection
+	var cur *orgSection
+	drawer := ""
+	listBase := -1
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		trimmed := strings.TrimSpace(line)
+
+		if drawer != "" {
+			if strings.EqualFold(trimmed, ":END:") {
+				drawer = ""
+			} else if m := orgProperty.FindStringSubmatch(trimmed); m != nil && drawer == "PROPERTIES" && cur != nil {
+				if v := strings.TrimSpace(m[2]); v != "" {
+					cur.meta[strings.ToLower(m[1])] = v
+				}
+			}
+			continue
+		}
+
+		if m := orgHeading.FindStringSubmatch(line); m != nil {
+			title := m[2]
+			section := orgSection{meta: map[string]string{}}
+			if kw, rest, _ := strings.Cut(title, " "); todo[kw] {
+				section.meta["todo"] = kw
+				title = rest
+			} else if todo[title] {
+				section.meta["todo"] = title
+				title = ""
+			}
+			if pm := orgPriority.FindStringSubmatch(title); pm != nil {
+				section.meta["priority"] = pm[1]
+				title = title[len(pm[0]):]
+			}
+			if tm := orgTags.FindStringSubmatch(title); tm != nil {
+				tags := strings.Split(strings.Trim(tm[1], ":"), ":")
+				section.meta["tags"] = strings.Join(tags, ", ")
+				title = strings.TrimSuffix(title, tm[0])
+			}
+			title = orgInline(strings.TrimSpace(title))
+			w.heading(len(m[1]), title)
+			section.offset = w.headings[len(w.headings)-1].Offset
+			section.meta["heading"] = title
+			sections = append(sections, section)
+			cur = &sections[len(sections)-1]
+			continue
+		}
+
+		if m := orgDrawer.FindStringSubmatch(line); m != nil && !strings.EqualFold(m[1], "END") {
+			drawer = strings.ToUpper(m[1])
+			continue
+		}
+		if cur != nil && orgPlanning.MatchString(line) && strings.TrimSpace(orgPlanning.ReplaceAllString(line, "")) == "" {
+			for _, pm := range orgPlanning.FindAllStringSubmatch(line, -1) {
+				cur.meta[strings.ToLower(pm[1])] = strings.Trim(pm[2], "<>[]")
+			}
+			continue
+		}
+
+		if m := orgBlock.FindStringSubmatch(line); m != nil {
+			kind := strings.ToLower(m[1])
+			end := i + 1
+			for end < len(lines) && !strings.EqualFold(strings.TrimSpace(lines[end]), "#+end_"+kind) {
+				end++
+			}
+			body := lines[i+1 : min(end, len(lines))]
+			switch kind {
+			case "src":
+				w.fence(m[2], orgUnescape(body))
+			case "example":
+				w.fence("", orgUnescape(body))
+			case "quote":
+				w.line("")
+				for _, l := range body {
+					w.line(strings.TrimRight("> "+orgInline(strings.TrimSpace(l)), " "))
+				}
+				w.line("")
+			case "comment", "export":
+			default:
+				for _, l := range body {
+					w.line(orgInline(strings.TrimSpace(l)))
+				}
+			}
+			i = end
+			continue
+		}
+		if m := orgKeyword.FindStringSubmatch(line); m != nil {
+			key := strings.ToLower(m[1])
+			if k := orgFileKeys[key]; k != "" && m[2] != "" {
+				if k == "tags" {
+					m[2] = strings.Join(strings.FieldsFunc(m[2], func(r rune) bool { return r == ':' || r == ' ' }), ", ")
+				}
+				meta[k] = orgInline(m[2])
+			} else if key == "caption" {
+				w.line(orgInline(m[2]))
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "#") && (len(line) == 1 || line[1] == ' ') {
+			continue // comment
+		}
+
+		switch {
+		case trimmed == "":
+			w.line("")
+		case strings.HasPrefix(trimmed, "|"):
+			if strings.HasPrefix(trimmed, "|-") {
+				continue
+			}
+			cells := strings.Split(strings.Trim(trimmed, "|"), "|")
+			for j, c := range cells {
+				cells[j] = orgInline(strings.TrimSpace(c))
+			}
+			w.line(strings.Join(cells, " | "))
+		case strings.HasPrefix(trimmed, ": ") || trimmed == ":":
+			w.line(strings.TrimPrefix(strings.TrimPrefix(trimmed, ":"), " "))
+		case strings.HasPrefix(trimmed, "-----"):
+		default:
+			if m := orgListItem.FindStringSubmatch(line); m != nil {
+				marker := strings.TrimSpace(m[2])
+				if marker == "+" || marker == "*" {
+					marker = "-"
+				}
+				text := m[3]
+				if term, def, ok := strings.Cut(text, " :: "); ok {
+					text = term + ": " + def
+				}
+				// Indentation is kept relative to the first item, since
+				// body text under a heading is often indented.
+				if listBase < 0 {
+					listBase = len(m[1])
+				}
+				indent := strings.Repeat(" ", max(len(m[1])-listBase, 0))
+				w.line(indent + marker + " " + orgInline(text))
+				continue
+			}
+			listBase = -1
+			w.line(orgInline(trimmed))
+		}
+	}
+
+	doc, err := w.document(filename, meta)
+	if err != nil {
+		return nil, err
+	}
+	for i, s := range sections {
+		end := len(doc.Content)
+		if i+1 < len(sections) {
+			end = sections[i+1].offset
+		}
+		end = min(end, len(doc.Content))
+		doc.Segments = append(doc.Segments, Segment{Start: s.offset, End: max(end, s.offset), Metadata: s.meta})
+	}
+	return doc, nil
+}
+
+// orgUnescape removes the comma org adds before "*" and "#+" lines in
+// source and example blocks.
+func orgUnescape(lines []string) []string {
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		t := strings.TrimLeft(l, " \t")
+		if strings.HasPrefix(t, ",*") || strings.HasPrefix(t, ",#+") {
+			l = l[:len(l)-len(t)] + t[1:]
+		}
+		out[i] = l
+	}
+	return out
+}
+
+// orgInline reduces links to their description and verbatim and code
+// markup to backticks, and drops footnote references.
+func orgInline(s string) string {
+	s = orgLink.ReplaceAllStringFunc(s, func(m string) string {
+		sub := orgLink.FindStringSubmatch(m)
+		if sub[2] != "" {
+			return sub[2]
+		}
+		return strings.TrimPrefix(sub[1], "file:")
+	})
+	s = orgCode.ReplaceAllString(s, "$1`$2`$3")
+	return orgFootRef.ReplaceAllString(s, "")
+}
@@ -0,0 +1,42 @@
+package document
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOrgParser(t *testing.T) {
+	const src = "#+TITLE: Plan\n#+AUTHOR: Ada\n#+TODO: TODO WAIT | DONE\n\n" +
+		"* TODO [#A] Ship release :work:urgent:\n  SCHEDULED: <2024-01-02 Tue>\n  :PROPERTIES:\n  :OWNER: Ada\n  :END:\n" +
+		"  See [[https://example.com][the tracker]] and =make=.\n  :LOGBOOK:\n  - note\n  :END:\n" +
+		"** WAIT Review\n#+BEGIN_SRC go\nfmt.Println(1)\n#+END_SRC\n# a comment\n* Notes\n- item\n"
+	doc, err := NewOrgParser().Parse([]byte(src), "a.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Ship release\n\nSee the tracker and `make`.\n\n## Review\n\n```go\nfmt.Println(1)\n```\n\n# Notes\n\n- item"
+	if doc.Content != want {
+		t.Errorf("content %q, want %q", doc.Content, want)
+	}
+	if doc.Metadata["title"] != "Plan" || doc.Metadata["author"] != "Ada" {
+		t.Errorf("metadata %v", doc.Metadata)
+	}
+	if len(doc.Segments) != 3 || len(doc.Headings) != 3 {
+		t.Fatalf("%d segments, %d headings", len(doc.Segments), len(doc.Headings))
+	}
+	first := map[string]string{
+		"heading": "Ship release", "todo": "TODO", "priority": "A", "tags": "work, urgent",
+		"scheduled": "2024-01-02 Tue", "owner": "Ada",
+	}
+	if !reflect.DeepEqual(doc.Segments[0].Metadata, first) {
+		t.Errorf("first section metadata %v, want %v", doc.Segments[0].Metadata, first)
+	}
+	if got := doc.Segments[1].Metadata["todo"]; got != "WAIT" {
+		t.Errorf("custom TODO keyword is %q", got)
+	}
+	for i, seg := range doc.Segments {
+		if seg.Start != doc.Headings[i].Offset {
+			t.Errorf("section %d starts at %d, heading at %d", i, seg.Start, doc.Headings[i].Offset)
+		}
+	}
+}
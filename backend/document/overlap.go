@@ -0,0 +1,61 @@
+package document
+
+import (
+	"strings"
+	"unicode"
+)
+
+// overlapStart returns the offset in s where its last n tokens begin,
+// moved back to the start of a grapheme cluster and past leading space.
+func overlapStart(s string, n int, tok Tokenizer) int {
+	offsets := tok.Offsets(s)
+	if n <= 0 || len(offsets) == 0 {
+		return len(s)
+	}
+	if n >= len(offsets) {
+		return 0
+	}
+	cut := graphemeStart(s, offsets[len(offsets)-n])
+	return len(s) - len(strings.TrimLeftFunc(s[cut:], unicode.IsSpace))
+}
+
+// overlapSpans extends each span after the first back over the last n
+// tokens of the span before it, so neighbouring chunks share context.
+func overlapSpans(text string, spans []textSpan, n int, tok Tokenizer) []textSpan {
+	if n <= 0 || len(spans) < 2 {
+		return spans
+	}
+	out := make([]textSpan, len(spans))
+	copy(out, spans)
+	for i := 1; i < len(spans); i++ {
+		prev := spans[i-1]
+		if start := prev.start + overlapStart(text[prev.start:prev.end], n, tok); start < out[i].start {
+			out[i].start = start
+		}
+	}
+	return out
+}
+
+// fitOverlap moves the start of each span of overlapped forward, a token
+// at a time, until it fits in size tokens. The text between spans can add
+// to the count, as with CharacterTokenizer. spans are the spans before
+// overlapping, which fit.
+func fitOverlap(text string, spans, overlapped []textSpan, size int, tok Tokenizer) []textSpan {
+	for i := range overlapped {
+		sp, orig := &overlapped[i], spans[i]
+		if sp.start >= orig.start || tok.Count(text[sp.start:sp.end]) <= size {
+			continue
+		}
+		start := orig.start
+		for _, off := range tok.Offsets(text[sp.start:orig.start]) {
+			cut := graphemeStart(text, sp.start+off)
+			cut += len(text[cut:orig.start]) - len(strings.TrimLeftFunc(text[cut:orig.start], unicode.IsSpace))
+			if cut > sp.start && tok.Count(text[cut:sp.end]) <= size {
+				start = cut
+				break
+			}
+		}
+		sp.start = start
+	}
+	return overlapped
+}
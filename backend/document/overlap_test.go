@@ -0,0 +1,40 @@
+package document
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOverlapStart(t *testing.T) {
+	w := WhitespaceTokenizer{}
+	tests := []struct {
+		s    string
+		n    int
+		tok  Tokenizer
+		want int
+	}{
+		{"alpha beta  gamma", 2, w, 6},
+		{"alpha beta", 0, w, 10},
+		{"alpha beta", 5, w, 0},
+		{"héllo wörld", 3, CharacterTokenizer{}, 10},
+	}
+	for _, tt := range tests {
+		if got := overlapStart(tt.s, tt.n, tt.tok); got != tt.want {
+			t.Errorf("overlapStart(%q, %d) = %d, want %d", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestOverlapSpans(t *testing.T) {
+	text := "one two three. four five six."
+	got := overlapSpans(text, []textSpan{{0, 14}, {15, 29}}, 1, WhitespaceTokenizer{})
+	if want := []textSpan{{0, 14}, {8, 29}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("overlapSpans = %v, want %v", got, want)
+	}
+
+	c := &ParagraphChunker{ChunkSize: 3, Overlap: 1, Tokenizer: WhitespaceTokenizer{}}
+	want := []string{"a b c", "c\n\nd e f", "f\n\ng h"}
+	if got := c.Split("a b c\n\nd e f\n\ng h"); !reflect.DeepEqual(got, want) {
+		t.Errorf("paragraph overlap = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+package document
+
+import "regexp"
+
+// ParagraphChunker packs whole blank-line separated paragraphs into chunks
+// of at most ChunkSize tokens. A paragraph that exceeds ChunkSize on its
+// own is split into sentences, and a sentence that still exceeds it is cut
+// at token boundaries.
+type ParagraphChunker struct {
+	// ChunkSize is the maximum chunk size in tokens. Defaults to 200.
+	ChunkSize int
+	// Tokenizer measures chunk size. Defaults to DefaultTokenizer.
+	Tokenizer Tokenizer
+	// Overlap is the number of trailing tokens of each chunk repeated at
+	// the start of the next one, on top of ChunkSize.
+	Overlap int
+	// Segmenter splits oversized paragraphs into sentences. Defaults to
+	// English rules.
+	Segmenter *SentenceSegmenter
+}
+
+// NewParagraphChunker creates a new paragraph chunker instance
+// configured by opts.
+func NewParagraphChunker(opts ...ChunkOption) *ParagraphChunker {
+	o := NewOptions(opts...)
+	return &ParagraphChunker{ChunkSize: o.ChunkSize, Tokenizer: o.Tokenizer, Overlap: o.Overlap, Segmenter: o.segmenter()}
+}
+
+var paragraphBreak = regexp.MustCompile(`\n[ \t\r]*\n`)
+
+// Split returns the chunks of text with surrounding whitespace trimmed.
+func (c *ParagraphChunker) Split(text string) []string {
+	spans := c.spans(text)
+	out := make([]string, len(spans))
+	for i, sp := range spans {
+		out[i] = text[sp.start:sp.end]
+	}
+	return out
+}
+
+func (c *ParagraphChunker) spans(text string) []textSpan {
+	size := c.ChunkSize
+	if size <= 0 {
+		size = 200
+	}
+	tok := c.Tokenizer
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	seg := c.Segmenter
+	if seg == nil {
+		seg = defaultSegmenter
+	}
+
+	var out []textSpan
+	cur := textSpan{-1, -1}
+	flush := func() {
+		if cur.start >= 0 {
+			out = append(out, cur)
+		}
+		cur = textSpan{-1, -1}
+	}
+	// add appends sp to the current chunk, or starts a new chunk when the
+	// combined text would exceed size.
+	add := func(sp textSpan) {
+		if cur.start >= 0 && tok.Count(text[cur.start:sp.end]) <= size {
+			cur.end = sp.end
+			return
+		}
+		flush()
+		cur = sp
+	}
+
+	for _, para := range paragraphSpans(text) {
+		if tok.Count(text[para.start:para.end]) <= size {
+			add(para)
+			continue
+		}
+		// Sentences of an oversized paragraph are packed on their own.
+		flush()
+		for _, s := range seg.spans(text[para.start:para.end]) {
+			s = textSpan{para.start + s.start, para.start + s.end}
+			if tok.Count(text[s.start:s.end]) <= size {
+				add(s)
+				continue
+			}
+			flush()
+			for _, piece := range splitByTokens(text, s.start, s.end, size, tok) {
+				if piece = trimSpan(text, piece); piece.end > piece.start {
+					out = append(out, piece)
+				}
+			}
+		}
+		flush()
+	}
+	flush()
+	return overlapSpans(text, out, c.Overlap, tok)
+}
+
+// paragraphSpans returns the trimmed, non-empty paragraphs of text.
+func paragraphSpans(text string) []textSpan {
+	var out []textSpan
+	start := 0
+	for _, m := range paragraphBreak.FindAllStringIndex(text, -1) {
+		if sp := trimSpan(text, textSpan{start, m[0]}); sp.end > sp.start {
+			out = append(out, sp)
+		}
+		start = m[1]
+	}
+	if sp := trimSpan(text, textSpan{start, len(text)}); sp.end > sp.start {
+		out = append(out, sp)
+	}
+	return out
+}
@@ -0,0 +1,28 @@
+package document
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParagraphChunker(t *testing.T) {
+	c := &ParagraphChunker{ChunkSize: 6, Tokenizer: WhitespaceTokenizer{}}
+	text := "One two.\n\nThree four.\n  \nFive six seven eight nine. Ten eleven.\n\n" +
+		"Twelve thirteen fourteen fifteen sixteen seventeen eighteen."
+	want := []string{
+		// Small paragraphs are packed together.
+		"One two.\n\nThree four.",
+		// An oversized paragraph falls back to sentences...
+		"Five six seven eight nine.",
+		"Ten eleven.",
+		// ...and an oversized sentence to tokens.
+		"Twelve thirteen fourteen fifteen sixteen seventeen",
+		"eighteen.",
+	}
+	if got := c.Split(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("Split = %q, want %q", got, want)
+	}
+	if got := NewParagraphChunker().Split("\n\n  \n"); len(got) != 0 {
+		t.Errorf("blank text split into %q", got)
+	}
+}
@@ -0,0 +1,738 @@
+package document
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// This file decodes the subset of the Parquet format needed to read flat
+// tables: the Thrift compact footer, PLAIN and dictionary encoded pages,
+// RLE/bit-packed definition levels and uncompressed, Snappy or gzip pages.
+
+var errParquetTruncated = errors.New("parquet data is truncated")
+
+// thriftStruct is a decoded Thrift struct keyed by field id. Integers are
+// stored as int64, strings as []byte, lists as []any and structs as
+// thriftStruct.
+type thriftStruct map[int16]any
+
+func (s thriftStruct) int(id int16) int64 {
+	v, _ := s[id].(int64)
+	return v
+}
+
+func (s thriftStruct) has(id int16) bool {
+	_, ok := s[id]
+	return ok
+}
+
+func (s thriftStruct) str(id int16) string {
+	v, _ := s[id].([]byte)
+	return string(v)
+}
+
+func (s thriftStruct) list(id int16) []any {
+	v, _ := s[id].([]any)
+	return v
+}
+
+func (s thriftStruct) st(id int16) thriftStruct {
+	v, _ := s[id].(thriftStruct)
+	return v
+}
+
+// thriftReader decodes the Thrift compact protocol.
+type thriftReader struct {
+	buf   []byte
+	pos   int
+	depth int
+}
+
+func (r *thriftReader) byte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, errParquetTruncated
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *thriftReader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, errParquetTruncated
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *thriftReader) varint() (int64, error) {
+	v, err := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+func (r *thriftReader) readStruct() (thriftStruct, error) {
+	if r.depth++; r.depth > 32 {
+		return nil, errors.New("parquet metadata is nested too deeply")
+	}
+	defer func() { r.depth-- }()
+	s := thriftStruct{}
+	var id int16
+	for {
+		h, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		typ := h & 0x0f
+		if typ == 0 {
+			return s, nil
+		}
+		if delta := h >> 4; delta != 0 {
+			id += int16(delta)
+		} else {
+			v, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		switch typ {
+		case 1, 2:
+			s[id] = typ == 1
+			continue
+		}
+		v, err := r.value(typ)
+		if err != nil {
+			return nil, err
+		}
+		s[id] = v
+	}
+}
+
+func (r *thriftReader) value(typ byte) (any, error) {
+	switch typ {
+	case 1, 2: // booleans inside lists take a byte
+		b, err := r.byte()
+		return b == 1, err
+	case 3:
+		b, err := r.byte()
+		return int64(int8(b)), err
+	case 4, 5, 6:
+		return r.varint()
+	case 7:
+		if r.pos+8 > len(r.buf) {
+			return nil, errParquetTruncated
+		}
+		r.pos += 8
+		return math.Float64frombits(binary.LittleEndian.Uint64(r.buf[r.pos-8:])), nil
+	case 8:
+		n, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(r.buf)-r.pos) {
+			return nil, errParquetTruncated
+		}
+		r.pos += int(n)
+		return r.buf[r.pos-int(n) : r.pos], nil
+	case 9, 10:
+		h, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		n := uint64(h >> 4)
+		if n == 15 {
+			if n, err = r.uvarint(); err != nil {
+				return nil, err
+			}
+		}
+		if n > uint64(len(r.buf)-r.pos) {
+			return nil, errParquetTruncated
+		}
+		items := make([]any, 0, n)
+		for i := uint64(0); i < n; i++ {
+			v, err := r.value(h & 0x0f)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, v)
+		}
+		return items, nil
+	case 11:
+		n, err := r.uvarint()
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		kv, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(r.buf)-r.pos) {
+			return nil, errParquetTruncated
+		}
+		for i := uint64(0); i < 2*n; i++ {
+			typ := kv >> 4
+			if i%2 == 1 {
+				typ = kv & 0x0f
+			}
+			if _, err := r.value(typ); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case 12:
+		return r.readStruct()
+	}
+	return nil, fmt.Errorf("unknown thrift type %d", typ)
+}
+
+// Parquet physical types.
+const (
+	parquetBoolean = iota
+	parquetInt32
+	parquetInt64
+	parquetInt96
+	parquetFloat
+	parquetDouble
+	parquetByteArray
+	parquetFixedLenByteArray
+)
+
+// parquetColumn describes a top-level primitive column.
+type parquetColumn struct {
+	name       string
+	physical   int
+	typeLength int
+	optional   bool
+	// kind is the logical type that decides rendering: "string", "decimal",
+	// "date", "time", "timestamp", "uuid", "uint" or "".
+	kind  string
+	scale int
+	unit  time.Duration // time and timestamp precision
+	index int           // position among the leaf columns
+}
+
+// parquetColumns lists the flat leaf columns of a schema. Nested groups and
+// repeated fields are skipped, as they cannot be shown as a single cell.
+func parquetColumns(schema []any) []parquetColumn {
+	var cols []parquetColumn
+	leaf := 0
+	// skip returns the index after the subtree rooted at i.
+	var skip func(i int) int
+	skip = func(i int) int {
+		el, _ := schema[i].(thriftStruct)
+		next := i + 1
+		for c := int64(0); c < el.int(5) && next < len(schema); c++ {
+			next = skip(next)
+		}
+		if el.int(5) == 0 {
+			leaf++
+		}
+		return next
+	}
+	if len(schema) == 0 {
+		return nil
+	}
+	root, _ := schema[0].(thriftStruct)
+	i := 1
+	for c := int64(0); c < root.int(5) && i < len(schema); c++ {
+		el, _ := schema[i].(thriftStruct)
+		if el.int(5) > 0 || el.int(3) == 2 {
+			i = skip(i)
+			continue
+		}
+		col := parquetColumn{
+			name:       el.str(4),
+			physical:   int(el.int(1)),
+			typeLength: int(el.int(2)),
+			optional:   el.int(3) == 1,
+			scale:      int(el.int(7)),
+			index:      leaf,
+		}
+		col.kind, col.unit = parquetKind(el)
+		cols = append(cols, col)
+		leaf++
+		i++
+	}
+	return cols
+}
+
+// parquetKind maps the logical type, or the legacy converted type, of a
+// schema element to a rendering kind.
+func parquetKind(el thriftStruct) (string, time.Duration) {
+	unit := func(u thriftStruct) time.Duration {
+		switch {
+		case u.has(2):
+			return time.Microsecond
+		case u.has(3):
+			return time.Nanosecond
+		}
+		return time.Millisecond
+	}
+	if lt := el.st(10); lt != nil {
+		switch {
+		case lt.has(1), lt.has(4), lt.has(12):
+			return "string", 0
+		case lt.has(5):
+			return "decimal", 0
+		case lt.has(6):
+			return "date", 0
+		case lt.has(7):
+			return "time", unit(lt.st(7).st(2))
+		case lt.has(8):
+			return "timestamp", unit(lt.st(8).st(2))
+		case lt.has(10):
+			if signed, _ := lt.st(10)[2].(bool); !signed {
+				return "uint", 0
+			}
+			return "", 0
+		case lt.has(14):
+			return "uuid", 0
+		}
+	}
+	if !el.has(6) {
+		return "", 0
+	}
+	switch el.int(6) {
+	case 0, 4, 19: // UTF8, ENUM, JSON
+		return "string", 0
+	case 5:
+		return "decimal", 0
+	case 6:
+		return "date", 0
+	case 7:
+		return "time", time.Millisecond
+	case 8:
+		return "time", time.Microsecond
+	case 9:
+		return "timestamp", time.Millisecond
+	case 10:
+		return "timestamp", time.Microsecond
+	case 11, 12, 13, 14:
+		return "uint", 0
+	}
+	return "", 0
+}
+
+// parquetDecompress expands a page body compressed with the given codec.
+func parquetDecompress(codec int64, data []byte, size int) ([]byte, error) {
+	switch codec {
+	case 0:
+		return data, nil
+	case 1:
+		return snappyDecode(data, size)
+	case 2:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		return io.ReadAll(io.LimitReader(zr, int64(size)))
+	}
+	names := []string{3: "LZO", 4: "BROTLI", 5: "LZ4", 6: "ZSTD", 7: "LZ4_RAW"}
+	if codec > 0 && int(codec) < len(names) {
+		return nil, fmt.Errorf("unsupported parquet compression %s", names[codec])
+	}
+	return nil, fmt.Errorf("unsupported parquet compression %d", codec)
+}
+
+// snappyDecode decodes a raw Snappy block, the framing Parquet uses, of at
+// most limit bytes.
+func snappyDecode(src []byte, limit int) ([]byte, error) {
+	n, k := binary.Uvarint(src)
+	if k <= 0 || n > uint64(limit) {
+		return nil, errors.New("invalid snappy block")
+	}
+	dst := make([]byte, 0, n)
+	for i := k; i < len(src); {
+		tag := src[i]
+		i++
+		var length, offset int
+		switch tag & 3 {
+		case 0:
+			length = int(tag>>2) + 1
+			if extra := length - 60; extra > 0 {
+				if i+extra > len(src) {
+					return nil, errors.New("invalid snappy block")
+				}
+				length = 0
+				for j := extra - 1; j >= 0; j-- {
+					length = length<<8 | int(src[i+j])
+				}
+				length++
+				i += extra
+			}
+			if length > len(src)-i || len(dst)+length > int(n) {
+				return nil, errors.New("invalid snappy block")
+			}
+			dst = append(dst, src[i:i+length]...)
+			i += length
+			continue
+		case 1:
+			if i >= len(src) {
+				return nil, errors.New("invalid snappy block")
+			}
+			length = 4 + int(tag>>2&7)
+			offset = int(tag&0xe0)<<3 | int(src[i])
+			i++
+		case 2:
+			if i+2 > len(src) {
+				return nil, errors.New("invalid snappy block")
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[i:]))
+			i += 2
+		case 3:
+			if i+4 > len(src) {
+				return nil, errors.New("invalid snappy block")
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[i:]))
+			i += 4
+		}
+		if offset <= 0 || offset > len(dst) || len(dst)+length > int(n) {
+			return nil, errors.New("invalid snappy block")
+		}
+		// Copies may overlap their own output.
+		for j := 0; j < length; j++ {
+			dst = append(dst, dst[len(dst)-offset])
+		}
+	}
+	if len(dst) != int(n) {
+		return nil, errors.New("invalid snappy block")
+	}
+	return dst, nil
+}
+
+// parquetHybrid decodes n values of the RLE/bit-packed hybrid encoding.
+func parquetHybrid(data []byte, width, n int) ([]int, error) {
+	if width > 32 {
+		return nil, fmt.Errorf("invalid parquet bit width %d", width)
+	}
+	out := make([]int, 0, n)
+	bytesWide := (width + 7) / 8
+	for i := 0; len(out) < n; {
+		h, k := binary.Uvarint(data[i:])
+		if k <= 0 {
+			return nil, errParquetTruncated
+		}
+		i += k
+		if h&1 == 0 {
+			count := int(h >> 1)
+			if i+bytesWide > len(data) {
+				return nil, errParquetTruncated
+			}
+			v := 0
+			for j := bytesWide - 1; j >= 0; j-- {
+				v = v<<8 | int(data[i+j])
+			}
+			i += bytesWide
+			for ; count > 0 && len(out) < n; count-- {
+				out = append(out, v)
+			}
+			continue
+		}
+		groups := int(h >> 1)
+		if width == 0 {
+			for count := groups * 8; count > 0 && len(out) < n; count-- {
+				out = append(out, 0)
+			}
+			continue
+		}
+		end := i + groups*width
+		if end > len(data) || end < i {
+			return nil, errParquetTruncated
+		}
+		for bit := 0; bit < groups*8*width && len(out) < n; bit += width {
+			v := 0
+			for j := 0; j < width; j++ {
+				b := bit + j
+				if data[i+b/8]>>(b%8)&1 != 0 {
+					v |= 1 << j
+				}
+			}
+			out = append(out, v)
+		}
+		i = end
+	}
+	return out, nil
+}
+
+// parquetPlain decodes n PLAIN encoded values of col and renders each one.
+func parquetPlain(col *parquetColumn, data []byte, n int) ([]string, error) {
+	out := make([]string, 0, n)
+	width := map[int]int{parquetInt32: 4, parquetInt64: 8, parquetInt96: 12,
+		parquetFloat: 4, parquetDouble: 8, parquetFixedLenByteArray: col.typeLength}[col.physical]
+	for i, pos := 0, 0; i < n; i++ {
+		switch col.physical {
+		case parquetBoolean:
+			if pos/8 >= len(data) {
+				return nil, errParquetTruncated
+			}
+			out = append(out, strconv.FormatBool(data[pos/8]>>(pos%8)&1 == 1))
+			pos++
+		case parquetByteArray:
+			if pos+4 > len(data) {
+				return nil, errParquetTruncated
+			}
+			size := int(binary.LittleEndian.Uint32(data[pos:]))
+			pos += 4
+			if size < 0 || size > len(data)-pos {
+				return nil, errParquetTruncated
+			}
+			out = append(out, col.format(data[pos:pos+size]))
+			pos += size
+		default:
+			if width <= 0 || pos+width > len(data) {
+				return nil, errParquetTruncated
+			}
+			out = append(out, col.format(data[pos:pos+width]))
+			pos += width
+		}
+	}
+	return out, nil
+}
+
+// format renders one non-boolean PLAIN value.
+func (col *parquetColumn) format(b []byte) string {
+	var n int64
+	switch col.physical {
+	case parquetInt32:
+		n = int64(int32(binary.LittleEndian.Uint32(b)))
+	case parquetInt64:
+		n = int64(binary.LittleEndian.Uint64(b))
+	case parquetInt96:
+		nanos := int64(binary.LittleEndian.Uint64(b))
+		days := int64(binary.LittleEndian.Uint32(b[8:]))
+		// Julian day 2440588 is the Unix epoch.
+		return time.Unix((days-2440588)*86400, nanos).UTC().Format(time.RFC3339Nano)
+	case parquetFloat:
+		return strconv.FormatFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(b))), 'g', -1, 32)
+	case parquetDouble:
+		return strconv.FormatFloat(math.Float64frombits(binary.LittleEndian.Uint64(b)), 'g', -1, 64)
+	default:
+		switch col.kind {
+		case "decimal":
+			v := new(big.Int).SetBytes(b)
+			if len(b) > 0 && b[0]&0x80 != 0 {
+				v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+			}
+			return parquetDecimal(v.String(), col.scale)
+		case "uuid":
+			if len(b) == 16 {
+				h := hex.EncodeToString(b)
+				return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+			}
+		}
+		if col.kind == "string" || utf8.Valid(b) {
+			return string(b)
+		}
+		return hex.EncodeToString(b)
+	}
+
+	switch col.kind {
+	case "decimal":
+		return parquetDecimal(strconv.FormatInt(n, 10), col.scale)
+	case "date":
+		return time.Unix(n*86400, 0).UTC().Format("2006-01-02")
+	case "time":
+		return time.Unix(0, n*int64(col.unit)).UTC().Format("15:04:05.999999999")
+	case "timestamp":
+		return time.Unix(0, 0).Add(time.Duration(n) * col.unit).UTC().Format(time.RFC3339Nano)
+	case "uint":
+		if col.physical == parquetInt32 {
+			return strconv.FormatUint(uint64(uint32(n)), 10)
+		}
+		return strconv.FormatUint(uint64(n), 10)
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// parquetDecimal places the decimal point scale digits from the right of
+// an integer string.
+func parquetDecimal(digits string, scale int) string {
+	if scale <= 0 {
+		return digits
+	}
+	neg := strings.HasPrefix(digits, "-")
+	digits = strings.TrimPrefix(digits, "-")
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	s := digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// parquetChunk decodes up to limit values of one column chunk, returning ""
+// for nulls. data is the whole file and meta the ColumnMetaData.
+func parquetChunk(data []byte, col *parquetColumn, meta thriftStruct, limit int) ([]string, error) {
+	start := meta.int(9)
+	if dict := meta.int(11); dict > 0 && dict < start {
+		start = dict
+	}
+	end := start + meta.int(7)
+	if start < 4 || end > int64(len(data)) || end < start {
+		return nil, errParquetTruncated
+	}
+	total := int(min(meta.int(5), int64(limit)))
+	codec := meta.int(4)
+
+	var dict []string
+	values := make([]string, 0, total)
+	r := &thriftReader{buf: data[:end], pos: int(start)}
+	for r.pos < int(end) && len(values) < total {
+		header, err := r.readStruct()
+		if err != nil {
+			return nil, err
+		}
+		size := int(header.int(3))
+		if size < 0 || size > int(end)-r.pos {
+			return nil, errParquetTruncated
+		}
+		body := data[r.pos : r.pos+size]
+		r.pos += size
+		rawSize := int(header.int(2))
+
+		switch header.int(1) {
+		case 2: // dictionary page
+			page, err := parquetDecompress(codec, body, rawSize)
+			if err != nil {
+				return nil, err
+			}
+			if dict, err = parquetPlain(col, page, int(header.st(7).int(1))); err != nil {
+				return nil, err
+			}
+		case 0: // data page
+			page, err := parquetDecompress(codec, body, rawSize)
+			if err != nil {
+				return nil, err
+			}
+			h := header.st(5)
+			n := int(h.int(1))
+			var defs []int
+			if col.optional {
+				if len(page) < 4 {
+					return nil, errParquetTruncated
+				}
+				size := int(binary.LittleEndian.Uint32(page))
+				if size < 0 || size > len(page)-4 {
+					return nil, errParquetTruncated
+				}
+				if defs, err = parquetHybrid(page[4:4+size], 1, n); err != nil {
+					return nil, err
+				}
+				page = page[4+size:]
+			}
+			if values, err = parquetAppend(values, col, page, h.int(2), n, defs, dict); err != nil {
+				return nil, err
+			}
+		case 3: // data page v2, whose levels are never compressed
+			h := header.st(8)
+			n := int(h.int(1))
+			repLen, defLen := int(h.int(6)), int(h.int(5))
+			if repLen < 0 || defLen < 0 || repLen+defLen > len(body) {
+				return nil, errParquetTruncated
+			}
+			var defs []int
+			if col.optional {
+				if defs, err = parquetHybrid(body[repLen:repLen+defLen], 1, n); err != nil {
+					return nil, err
+				}
+			}
+			page := body[repLen+defLen:]
+			if compressed, ok := h[7].(bool); !ok || compressed {
+				if page, err = parquetDecompress(codec, page, rawSize-repLen-defLen); err != nil {
+					return nil, err
+				}
+			}
+			if values, err = parquetAppend(values, col, page, h.int(4), n, defs, dict); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(values) > total {
+		values = values[:total]
+	}
+	return values, nil
+}
+
+// parquetAppend decodes the values section of a data page and appends n
+// entries to values, using definition levels to place nulls.
+func parquetAppend(values []string, col *parquetColumn, page []byte, encoding int64, n int, defs []int, dict []string) ([]string, error) {
+	present := n
+	if defs != nil {
+		present = 0
+		for _, d := range defs {
+			if d > 0 {
+				present++
+			}
+		}
+	}
+	var decoded []string
+	var err error
+	switch encoding {
+	case 0: // PLAIN
+		decoded, err = parquetPlain(col, page, present)
+	case 2, 8: // PLAIN_DICTIONARY, RLE_DICTIONARY
+		if len(page) == 0 {
+			if present > 0 {
+				return nil, errParquetTruncated
+			}
+			break
+		}
+		var idx []int
+		if idx, err = parquetHybrid(page[1:], int(page[0]), present); err != nil {
+			return nil, err
+		}
+		decoded = make([]string, len(idx))
+		for i, j := range idx {
+			if j >= len(dict) {
+				return nil, errors.New("parquet dictionary index out of range")
+			}
+			decoded[i] = dict[j]
+		}
+	case 3: // RLE, used for booleans
+		if len(page) < 4 {
+			return nil, errParquetTruncated
+		}
+		var bits []int
+		if bits, err = parquetHybrid(page[4:], 1, present); err != nil {
+			return nil, err
+		}
+		decoded = make([]string, len(bits))
+		for i, b := range bits {
+			decoded[i] = strconv.FormatBool(b == 1)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported parquet encoding %d in column %q", encoding, col.name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if defs == nil {
+		return append(values, decoded...), nil
+	}
+	for i, next := 0, 0; i < n; i++ {
+		if defs[i] == 0 {
+			values = append(values, "")
+			continue
+		}
+		values = append(values, decoded[next])
+		next++
+	}
+	return values, nil
+}
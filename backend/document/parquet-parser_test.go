@@ -0,0 +1,171 @@
+package document
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// thriftFields is a Thrift struct to encode with the compact protocol.
+// Values are int, string, thriftFields or []thriftFields.
+type thriftFields []struct {
+	id int16
+	v  any
+}
+
+func (s thriftFields) encode(b *bytes.Buffer) {
+	var last int16
+	for _, f := range s {
+		var typ byte
+		switch f.v.(type) {
+		case int:
+			typ = 5
+		case string:
+			typ = 8
+		case thriftFields:
+			typ = 12
+		case []thriftFields:
+			typ = 9
+		}
+		if d := f.id - last; d > 0 && d <= 15 {
+			b.WriteByte(byte(d)<<4 | typ)
+		} else {
+			b.WriteByte(typ)
+			b.Write(binary.AppendVarint(nil, int64(f.id)))
+		}
+		last = f.id
+		switch v := f.v.(type) {
+		case int:
+			b.Write(binary.AppendVarint(nil, int64(v)))
+		case string:
+			b.Write(binary.AppendUvarint(nil, uint64(len(v))))
+			b.WriteString(v)
+		case thriftFields:
+			v.encode(b)
+		case []thriftFields:
+			b.WriteByte(byte(len(v))<<4 | 12)
+			for _, item := range v {
+				item.encode(b)
+			}
+		}
+	}
+	b.WriteByte(0)
+}
+
+func tf(pairs ...any) thriftFields {
+	var s thriftFields
+	for i := 0; i+1 < len(pairs); i += 2 {
+		s = append(s, struct {
+			id int16
+			v  any
+		}{int16(pairs[i].(int)), pairs[i+1]})
+	}
+	return s
+}
+
+// parquetPeople returns an uncompressed Parquet file with a required UTF8
+// "name" column and an optional INT32 "age" column, where age -1 is null.
+// Each argument is one row group.
+func parquetPeople(groups ...map[string]int) []byte {
+	var file bytes.Buffer
+	file.WriteString("PAR1")
+	var rowGroups []thriftFields
+	total := 0
+	for _, group := range groups {
+		names := make([]string, 0, len(group))
+		for name := range group {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var nameData, ageData, defs bytes.Buffer
+		var bits byte
+		for i, name := range names {
+			binary.Write(&nameData, binary.LittleEndian, uint32(len(name)))
+			nameData.WriteString(name)
+			if age := group[name]; age >= 0 {
+				bits |= 1 << i
+				binary.Write(&ageData, binary.LittleEndian, int32(age))
+			}
+		}
+		// One bit-packed group of eight definition levels.
+		defs.Write([]byte{3, bits})
+		var agePage bytes.Buffer
+		binary.Write(&agePage, binary.LittleEndian, uint32(defs.Len()))
+		agePage.Write(defs.Bytes())
+		agePage.Write(ageData.Bytes())
+
+		var chunks []thriftFields
+		for _, body := range [][]byte{nameData.Bytes(), agePage.Bytes()} {
+			var page bytes.Buffer
+			tf(1, 0, 2, len(body), 3, len(body), 5, tf(1, len(names), 2, 0)).encode(&page)
+			page.Write(body)
+			offset := file.Len()
+			file.Write(page.Bytes())
+			chunks = append(chunks, tf(2, offset, 3, tf(4, 0, 5, len(names), 7, page.Len(), 9, offset)))
+		}
+		rowGroups = append(rowGroups, tf(1, chunks, 3, len(names)))
+		total += len(names)
+	}
+	schema := []thriftFields{
+		tf(4, "schema", 5, 2),
+		tf(1, parquetByteArray, 3, 0, 4, "name", 6, 0),
+		tf(1, parquetInt32, 3, 1, 4, "age"),
+	}
+	var footer bytes.Buffer
+	tf(1, 1, 2, schema, 3, total, 4, rowGroups, 6, "test").encode(&footer)
+	file.Write(footer.Bytes())
+	binary.Write(&file, binary.LittleEndian, uint32(footer.Len()))
+	file.WriteString("PAR1")
+	return file.Bytes()
+}
+
+func TestParquetParser(t *testing.T) {
+	data := parquetPeople(map[string]int{"Ada": 36, "Bob": -1}, map[string]int{"Cy": 7})
+	doc, err := NewParquetParser().Parse(data, "people.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "name: Ada, age: 36\nname: Bob\nname: Cy, age: 7"; doc.Content != want {
+		t.Errorf("content %q, want %q", doc.Content, want)
+	}
+	if m := doc.Metadata; m["columns"] != "name, age" || m["total_rows"] != "3" || m["row_groups"] != "2" || m["created_by"] != "test" {
+		t.Errorf("metadata %v", m)
+	}
+
+	doc, err = (&ParquetParser{Columns: []string{"AGE"}, MaxRows: 2}).Parse(data, "people.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Content != "age: 36" || doc.Metadata["rows"] != "1" {
+		t.Errorf("projected content %q, metadata %v", doc.Content, doc.Metadata)
+	}
+
+	docs, err := (&ParquetParser{RowsPerDocument: 1}).ParseAll(data, "people.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 3 || docs[2].Metadata["row_group"] != "2" || docs[2].Metadata["rows"] != "3-3" {
+		t.Fatalf("ParseAll returned %d documents", len(docs))
+	}
+
+	for _, bad := range [][]byte{[]byte("PAR1 not parquet"), data[:len(data)-1]} {
+		if _, err := NewParquetParser().Parse(bad, "bad.parquet"); err == nil || !strings.Contains(err.Error(), "parquet") {
+			t.Errorf("truncated file: err = %v", err)
+		}
+	}
+	if _, err := (&ParquetParser{Columns: []string{"email"}}).Parse(data, "people.parquet"); err == nil {
+		t.Error("unknown column projected without error")
+	}
+}
+
+func TestSnappyDecode(t *testing.T) {
+	got, err := snappyDecode([]byte{9, 0x08, 'a', 'b', 'c', 0x09, 3}, 16)
+	if err != nil || string(got) != "abcabcabc" {
+		t.Errorf("snappyDecode = %q, %v", got, err)
+	}
+	if _, err := snappyDecode([]byte{9, 0x08, 'a', 'b', 'c', 0x09, 4}, 16); err == nil {
+		t.Error("copy before the start of the output decoded")
+	}
+}
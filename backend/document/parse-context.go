@@ -0,0 +1,96 @@
+package document
+
+import "context"
+
+// ContextParser is implemented by parsers whose work can take long, such
+// as those calling OCR or transcription or expanding archives, so it can
+// be cancelled or bounded with a deadline.
+type ContextParser interface {
+	Parser
+	ParseContext(ctx context.Context, buffer []byte, filename string) (*Document, error)
+}
+
+// ContextOCRProvider is implemented by OCR providers that can stop when
+// ctx is done.
+type ContextOCRProvider interface {
+	OCRProvider
+	RecognizeContext(ctx context.Context, image []byte, mimeType string) (string, error)
+}
+
+// ContextTranscriber is implemented by transcribers that can stop when
+// ctx is done.
+type ContextTranscriber interface {
+	Transcriber
+	TranscribeContext(ctx context.Context, audio []byte, mimeType string) ([]TranscriptSegment, error)
+}
+
+// ParseContext parses buffer with p until ctx is done. A ContextParser
+// stops its own work; for other parsers ParseContext returns ctx.Err()
+// as soon as ctx is done, while the parse finishes in the background and
+// its result is dropped. Failures are a *ParseError wrapping ctx.Err() or
+// the parser's error.
+func ParseContext(ctx context.Context, p Parser, buffer []byte, filename string) (*Document, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if cp, ok := p.(ContextParser); ok {
+		doc, err := cp.ParseContext(ctx, buffer, filename)
+		return doc, parseError(p, filename, err)
+	}
+	doc, err := runContext(ctx, func() (*Document, error) { return p.Parse(buffer, filename) })
+	return doc, parseError(p, filename, err)
+}
+
+// ChunkContext works like ChunkDocument but returns ctx.Err() once ctx is
+// done. ctx also bounds the Embedder calls of semantic chunking, in place
+// of opts.Context.
+func ChunkContext(ctx context.Context, name string, doc *Document, opts Options) ([]Chunk, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	opts.Context = ctx
+	return runContext(ctx, func() ([]Chunk, error) { return ChunkDocument(name, doc, opts) })
+}
+
+// runContext runs f and returns its result, or ctx.Err() if ctx is done
+// first.
+func runContext[T any](ctx context.Context, f func() (T, error)) (T, error) {
+	type result struct {
+		v   T
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		v, err := f()
+		done <- result{v, err}
+	}()
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// recognize runs ocr on image, with ctx when the provider supports it.
+func recognize(ctx context.Context, ocr OCRProvider, image []byte, mimeType string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if c, ok := ocr.(ContextOCRProvider); ok {
+		return c.RecognizeContext(ctx, image, mimeType)
+	}
+	return runContext(ctx, func() (string, error) { return ocr.Recognize(image, mimeType) })
+}
+
+// transcribe runs t on audio, with ctx when the transcriber supports it.
+func transcribe(ctx context.Context, t Transcriber, audio []byte, mimeType string) ([]TranscriptSegment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c, ok := t.(ContextTranscriber); ok {
+		return c.TranscribeContext(ctx, audio, mimeType)
+	}
+	return runContext(ctx, func() ([]TranscriptSegment, error) { return t.Transcribe(audio, mimeType) })
+}
@@ -0,0 +1,78 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+)
+
+// blockingParser never finishes parsing on its own.
+type blockingParser struct{ release chan struct{} }
+
+func (p blockingParser) Parse(buffer []byte, filename string) (*Document, error) {
+	<-p.release
+	return &Document{Source: filename}, nil
+}
+
+func (blockingParser) Supports(string) bool { return true }
+
+// waitingOCR blocks until its context is done.
+type waitingOCR struct{}
+
+func (waitingOCR) Recognize([]byte, string) (string, error) { return "", errors.New("no context") }
+
+func (waitingOCR) RecognizeContext(ctx context.Context, _ []byte, _ string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestParseContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ParseContext(canceled, NewTextParser(), []byte("Hello."), "a.txt"); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled parse: err = %v", err)
+	}
+	doc, err := ParseContext(context.Background(), NewTextParser(), []byte("Hello."), "a.txt")
+	if err != nil || doc.Content != "Hello." {
+		t.Errorf("ParseContext = %+v, %v", doc, err)
+	}
+
+	// A parser without ParseContext is abandoned at the deadline.
+	p := blockingParser{make(chan struct{})}
+	defer close(p.release)
+	ctx, cancelTimeout := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelTimeout()
+	if _, err := ParseContext(ctx, p, nil, "a.bin"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("blocking parse: err = %v", err)
+	}
+
+	// An OCR provider with RecognizeContext gets the context.
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 2, 1))); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancelOCR := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelOCR()
+	images := NewImageParser()
+	images.OCR = waitingOCR{}
+	if _, err := ParseContext(ctx, images, buf.Bytes(), "a.png"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("image parse: err = %v", err)
+	}
+}
+
+func TestChunkContext(t *testing.T) {
+	doc := &Document{Source: "a.txt", Content: "One two three.\n\nFour five six."}
+	chunks, err := ChunkContext(context.Background(), "paragraph", doc, Options{ChunkSize: 3})
+	if err != nil || len(chunks) != 2 {
+		t.Errorf("ChunkContext = %d chunks, %v", len(chunks), err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ChunkContext(ctx, "paragraph", doc, Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled chunking: err = %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Config describes a parser type, *T, whose exported fields are its
+// options. Each parser keeps its own strongly typed options struct, while
+// Build and Registry.Configure construct parsers from declarative
+// configuration such as a JSON or YAML file.
+type Config[T any] struct {
+	// Name is the type name used in ParserSpec.Type, such as "csv".
+	Name string
+	// New returns a parser with its defaults set, onto which options are
+	// decoded. Defaults to new(T).
+	New func() *T
+}
+
+// Build returns a new parser with options, a JSON object of the exported
+// fields of T, decoded onto it. Unknown fields are an error.
+func (c Config[T]) Build(options json.RawMessage) (*T, error) {
+	var p *T
+	if c.New != nil {
+		p = c.New()
+	} else {
+		p = new(T)
+	}
+	if len(bytes.TrimSpace(options)) == 0 {
+		return p, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(options))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(p); err != nil {
+		return nil, fmt.Errorf("configure %s parser: %w", c.Name, err)
+	}
+	return p, nil
+}
+
+// parserBuilder builds a parser of one registered type from options.
+type parserBuilder func(options json.RawMessage) (Parser, error)
+
+var (
+	parserConfigsMu sync.RWMutex
+	parserConfigs   = map[string]parserBuilder{}
+)
+
+// RegisterConfig makes the parser type described by c available to
+// Registry.Configure under c.Name, replacing any type already registered
+// with that name.
+func RegisterConfig[T any, P interface {
+	*T
+	Parser
+}](c Config[T]) {
+	parserConfigsMu.Lock()
+	defer parserConfigsMu.Unlock()
+	parserConfigs[c.Name] = func(options json.RawMessage) (Parser, error) {
+		p, err := c.Build(options)
+		if err != nil {
+			return nil, err
+		}
+		return P(p), nil
+	}
+}
+
+// ParserConfigNames returns the registered parser type names in sorted
+// order.
+func ParserConfigNames() []string {
+	parserConfigsMu.RLock()
+	defer parserConfigsMu.RUnlock()
+	names := make([]string, 0, len(parserConfigs))
+	for name := range parserConfigs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParserSpec declares one parser of a registry configuration, such as
+// {"type": "csv", "priority": 100, "options": {"MaxRows": 1000}}.
+type ParserSpec struct {
+	// Type is a name registered with RegisterConfig.
+	Type string `json:"type"`
+	// Priority is passed to Registry.Register.
+	Priority int `json:"priority"`
+	// Options is a JSON object of the parser's exported fields.
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+// Configure builds the parser of every spec and registers it, failing
+// before registering any if a spec is invalid.
+func (r *Registry) Configure(specs ...ParserSpec) error {
+	parsers := make([]Parser, len(specs))
+	for i, spec := range specs {
+		parserConfigsMu.RLock()
+		build, ok := parserConfigs[spec.Type]
+		parserConfigsMu.RUnlock()
+		if !ok {
+			return fmt.Errorf("unknown parser type %q", spec.Type)
+		}
+		p, err := build(spec.Options)
+		if err != nil {
+			return err
+		}
+		parsers[i] = p
+	}
+	for i, p := range parsers {
+		r.Register(p, specs[i].Priority)
+	}
+	return nil
+}
+
+// LoadRegistry creates a registry from a JSON array of ParserSpec.
+func LoadRegistry(data []byte) (*Registry, error) {
+	var specs []ParserSpec
+	if err := json.Unmarshal(data, &specs); err != nil {
+		return nil, fmt.Errorf("decode parser specs: %w", err)
+	}
+	r := NewRegistry()
+	if err := r.Configure(specs...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func init() {
+	RegisterConfig(Config[PDFParser]{Name: "pdf", New: NewPDFParser})
+	RegisterConfig(Config[DocxParser]{Name: "docx", New: NewDocxParser})
+	RegisterConfig(Config[PPTXParser]{Name: "pptx", New: NewPPTXParser})
+	RegisterConfig(Config[XLSXParser]{Name: "xlsx", New: NewXLSXParser})
+	RegisterConfig(Config[ODFParser]{Name: "odf", New: NewODFParser})
+	RegisterConfig(Config[EPUBParser]{Name: "epub", New: NewEPUBParser})
+	RegisterConfig(Config[RTFParser]{Name: "rtf", New: NewRTFParser})
+	RegisterConfig(Config[HTMLParser]{Name: "html", New: NewHTMLParser})
+	RegisterConfig(Config[MarkdownParser]{Name: "markdown", New: NewMarkdownParser})
+	RegisterConfig(Config[AsciiDocParser]{Name: "asciidoc", New: NewAsciiDocParser})
+	RegisterConfig(Config[RSTParser]{Name: "rst", New: NewRSTParser})
+	RegisterConfig(Config[OrgParser]{Name: "org", New: NewOrgParser})
+	RegisterConfig(Config[LaTeXParser]{Name: "latex", New: NewLaTeXParser})
+	RegisterConfig(Config[IpynbParser]{Name: "ipynb", New: NewIpynbParser})
+	RegisterConfig(Config[ChatExportParser]{Name: "chat", New: NewChatExportParser})
+	RegisterConfig(Config[JSONParser]{Name: "json", New: NewJSONParser})
+	RegisterConfig(Config[XMLParser]{Name: "xml", New: NewXMLParser})
+	RegisterConfig(Config[CSVParser]{Name: "csv", New: NewCSVParser})
+	RegisterConfig(Config[ParquetParser]{Name: "parquet", New: NewParquetParser})
+	RegisterConfig(Config[EMLParser]{Name: "eml", New: NewEMLParser})
+	RegisterConfig(Config[MBOXParser]{Name: "mbox", New: NewMBOXParser})
+	RegisterConfig(Config[SubtitleParser]{Name: "subtitle", New: NewSubtitleParser})
+	RegisterConfig(Config[LogParser]{Name: "log", New: NewLogParser})
+	RegisterConfig(Config[ICSParser]{Name: "ics", New: NewICSParser})
+	RegisterConfig(Config[SQLParser]{Name: "sql", New: NewSQLParser})
+	RegisterConfig(Config[ArchiveParser]{Name: "archive", New: NewArchiveParser})
+	RegisterConfig(Config[ImageParser]{Name: "image", New: NewImageParser})
+	RegisterConfig(Config[AudioParser]{Name: "audio", New: NewAudioParser})
+	RegisterConfig(Config[CodeParser]{Name: "code", New: NewCodeParser})
+	RegisterConfig(Config[TextParser]{Name: "text", New: func() *TextParser { return NewTextParser() }})
+}
@@ -0,0 +1,60 @@
+package document
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestLoadRegistry(t *testing.T) {
+	r, err := LoadRegistry([]byte(`[
+		{"type": "text", "options": {"Encoding": "latin2"}},
+		{"type": "csv", "priority": 100, "options": {"Columns": ["city"], "MaxRows": 1}}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, ok := r.ParserFor("text/csv").(*CSVParser)
+	if !ok || p.MaxRows != 1 || len(p.Columns) != 1 {
+		t.Fatalf("csv parser = %+v", r.ParserFor("text/csv"))
+	}
+	doc, err := r.Parse([]byte("name,city\nAda,London\nBob,Paris\n"), "a.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(doc.Content, "Ada") || !strings.Contains(doc.Content, "London") || strings.Contains(doc.Content, "Paris") {
+		t.Errorf("Content = %q", doc.Content)
+	}
+	if text, ok := r.ParserFor("text/plain").(*TextParser); !ok || text.Encoding != "latin2" {
+		t.Errorf("text parser = %+v", r.ParserFor("text/plain"))
+	}
+
+	for _, bad := range []string{
+		`{"type": "csv"}`,
+		`[{"type": "no-such-type"}]`,
+		`[{"type": "csv", "options": {"NoSuchField": 1}}]`,
+		`[{"type": "csv", "options": {"MaxRows": "many"}}]`,
+	} {
+		if _, err := LoadRegistry([]byte(bad)); err == nil {
+			t.Errorf("LoadRegistry(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestRegistryConfigure(t *testing.T) {
+	r := NewRegistry()
+	err := r.Configure(ParserSpec{Type: "markdown"}, ParserSpec{Type: "no-such-type"})
+	if err == nil || len(r.Parsers()) != 0 {
+		t.Errorf("Configure registered %d parsers before failing with %v", len(r.Parsers()), err)
+	}
+
+	names := ParserConfigNames()
+	if !sort.StringsAreSorted(names) {
+		t.Errorf("ParserConfigNames() = %q, not sorted", names)
+	}
+	for _, want := range []string{"csv", "pdf", "text"} {
+		if i := sort.SearchStrings(names, want); i == len(names) || names[i] != want {
+			t.Errorf("ParserConfigNames() lacks %q", want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package document
+
+import (
+	"path"
+	"strings"
+)
+
+// DefaultParsers returns an instance of every built-in parser, ordered so
+// that format-specific parsers come before the plain text fallback.
+func DefaultParsers() []Parser {
+	return []Parser{
+		NewPDFParser(),
+		NewDocxParser(),
+		NewPPTXParser(),
+		NewXLSXParser(),
+		NewODFParser(),
+		NewEPUBParser(),
+		NewRTFParser(),
+		NewHTMLParser(),
+		NewMarkdownParser(),
+		NewAsciiDocParser(),
+		NewRSTParser(),
+		NewOrgParser(),
+		NewLaTeXParser(),
+		NewIpynbParser(),
+		NewChatExportParser(),
+		NewJSONParser(),
+		NewXMLParser(),
+		NewCSVParser(),
+		NewParquetParser(),
+		NewEMLParser(),
+		NewMBOXParser(),
+		NewSubtitleParser(),
+		NewLogParser(),
+		NewICSParser(),
+		NewSQLParser(),
+		NewArchiveParser(),
+		NewImageParser(),
+		NewAudioParser(),
+		NewCodeParser(),
+		NewTextParser(),
+	}
+}
+
+// fileMimeTypes maps file extensions to MIME types. It mirrors
+// FILE_UPLOAD_DEFAULTS.extensionToMimeType in knowledge.defaults.ts and adds
+// the formats only the Go parsers handle.
+var fileMimeTypes = map[string]string{
+	".pdf":      "application/pdf",
+	".xlsx":     "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".xls":      "application/vnd.ms-excel",
+	".docx":     "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".doc":      "application/msword",
+	".pptx":     "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".zip":      "application/zip",
+	".rtf":      "application/rtf",
+	".epub":     "application/epub+zip",
+	".txt":      "text/plain",
+	".csv":      "text/csv",
+	".md":       "text/markdown",
+	".html":     "text/html",
+	".htm":      "text/html",
+	".xml":      "text/xml",
+	".yaml":     "text/yaml",
+	".yml":      "text/yaml",
+	".ts":       "text/typescript",
+	".tsx":      "text/typescript",
+	".js":       "text/javascript",
+	".jsx":      "text/javascript",
+	".json":     "application/json",
+	".py":       "text/x-python",
+	".java":     "text/x-java",
+	".go":       "text/x-go",
+	".rb":       "text/x-ruby",
+	".php":      "text/x-php",
+	".c":        "text/x-c",
+	".h":        "text/x-c",
+	".cpp":      "text/x-cpp",
+	".cc":       "text/x-cpp",
+	".cxx":      "text/x-cpp",
+	".hpp":      "text/x-cpp",
+	".rs":       "text/x-rust",
+	".tsv":      "text/tab-separated-values",
+	".jsonl":    "application/jsonl",
+	".ndjson":   "application/x-ndjson",
+	".xhtml":    "application/xhtml+xml",
+	".rss":      "application/rss+xml",
+	".atom":     "application/atom+xml",
+	".dita":     "application/dita+xml",
+	".markdown": "text/markdown",
+	".odt":      "application/vnd.oasis.opendocument.text",
+	".ott":      "application/vnd.oasis.opendocument.text-template",
+	".ods":      "application/vnd.oasis.opendocument.spreadsheet",
+	".ots":      "application/vnd.oasis.opendocument.spreadsheet-template",
+	".odp":      "application/vnd.oasis.opendocument.presentation",
+	".otp":      "application/vnd.oasis.opendocument.presentation-template",
+	".eml":      "message/rfc822",
+	".mbox":     "application/mbox",
+	".tex":      "application/x-tex",
+	".latex":    "application/x-latex",
+	".ipynb":    "application/x-ipynb+json",
+	".srt":      "application/x-subrip",
+	".vtt":      "text/vtt",
+	".tar":      "application/x-tar",
+	".gz":       "application/gzip",
+	".tgz":      "application/gzip",
+	".parquet":  "application/vnd.apache.parquet",
+	".log":      "text/x-log",
+	".adoc":     "text/asciidoc",
+	".asciidoc": "text/asciidoc",
+	".rst":      "text/x-rst",
+	".org":      "text/org",
+	".ics":      "text/calendar",
+	".png":      "image/png",
+	".jpg":      "image/jpeg",
+	".jpeg":     "image/jpeg",
+	".gif":      "image/gif",
+	".bmp":      "image/bmp",
+	".tif":      "image/tiff",
+	".tiff":     "image/tiff",
+	".webp":     "image/webp",
+	".mp3":      "audio/mpeg",
+	".wav":      "audio/wav",
+	".m4a":      "audio/mp4",
+}
+
+// MimeTypeForFile returns the MIME type for a file name based on its
+// extension. Source files in other languages known to DetectLanguage map to
+// "text/x-<language>"; anything else is "application/octet-stream".
+func MimeTypeForFile(filename string) string {
+	base := strings.ToLower(path.Base(strings.ReplaceAll(filename, `\`, "/")))
+	if mt, ok := fileMimeTypes[path.Ext(base)]; ok {
+		return mt
+	}
+	if lang := DetectLanguage(base, nil); lang != "" {
+		return "text/x-" + lang
+	}
+	return "application/octet-stream"
+}
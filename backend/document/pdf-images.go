@@ -0,0 +1,182 @@
+package document
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// pageImage returns the largest image drawn on a page, for pages of a
+// scanned document that carry no text. JPEG and JPEG 2000 data is returned
+// as stored; other images are re-encoded as PNG when their color space is
+// one pdfImage understands.
+func (f *pdfFile) pageImage(page pdfPage) ([]byte, string, bool) {
+	var best *pdfStream
+	bestArea := 0
+	for _, v := range f.dict(page.resources["XObject"]) {
+		s, ok := f.resolve(v).(*pdfStream)
+		if !ok || s.dict["Subtype"] != pdfName("Image") {
+			continue
+		}
+		if area := f.integer(s.dict["Width"]) * f.integer(s.dict["Height"]); area > bestArea {
+			best, bestArea = s, area
+		}
+	}
+	if best == nil {
+		return nil, "", false
+	}
+
+	var filters []any
+	switch v := f.resolve(best.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []any{v}
+	case []any:
+		filters = v
+	}
+	if len(filters) == 1 {
+		switch f.resolve(filters[0]) {
+		case pdfName("DCTDecode"), pdfName("DCT"):
+			return best.data, "image/jpeg", true
+		case pdfName("JPXDecode"):
+			return best.data, "image/jp2", true
+		}
+	}
+	data, err := f.decodeStream(best)
+	if err != nil {
+		return nil, "", false
+	}
+	img, ok := f.pdfImage(best.dict, data)
+	if !ok {
+		return nil, "", false
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", false
+	}
+	return buf.Bytes(), "image/png", true
+}
+
+// pdfImage converts decoded image samples in a gray, RGB, CMYK, ICC based
+// or indexed color space to an image.
+func (f *pdfFile) pdfImage(dict pdfDict, data []byte) (image.Image, bool) {
+	width, height := f.integer(dict["Width"]), f.integer(dict["Height"])
+	bpc := f.integer(dict["BitsPerComponent"])
+	mask := f.resolve(dict["ImageMask"]) == true
+	if mask || bpc == 0 {
+		bpc = 1
+	}
+	if width <= 0 || height <= 0 || width*height > 1<<28 {
+		return nil, false
+	}
+
+	components := 1
+	var palette []byte
+	var paletteComponents int
+	var colorSpace func(v any) int
+	colorSpace = func(v any) int {
+		switch cs := f.resolve(v).(type) {
+		case pdfName:
+			switch cs {
+			case "DeviceGray", "CalGray", "G":
+				return 1
+			case "DeviceRGB", "CalRGB", "RGB":
+				return 3
+			case "DeviceCMYK", "CMYK":
+				return 4
+			}
+		case []any:
+			if len(cs) < 2 {
+				return 0
+			}
+			switch f.resolve(cs[0]) {
+			case pdfName("ICCBased"):
+				if s, ok := f.resolve(cs[1]).(*pdfStream); ok {
+					return f.integer(s.dict["N"])
+				}
+			case pdfName("CalGray"):
+				return 1
+			case pdfName("CalRGB"):
+				return 3
+			case pdfName("Indexed"), pdfName("I"):
+				if len(cs) < 4 {
+					return 0
+				}
+				paletteComponents = colorSpace(cs[1])
+				switch lookup := f.resolve(cs[3]).(type) {
+				case []byte:
+					palette = lookup
+				case *pdfStream:
+					palette, _ = f.decodeStream(lookup)
+				}
+				return 1
+			}
+		}
+		return 0
+	}
+	if !mask {
+		components = colorSpace(dict["ColorSpace"])
+	}
+	if components != 1 && components != 3 && components != 4 {
+		return nil, false
+	}
+	if palette != nil && paletteComponents != 1 && paletteComponents != 3 && paletteComponents != 4 {
+		return nil, false
+	}
+	if components > 1 && bpc != 8 {
+		return nil, false
+	}
+	stride := (width*components*bpc + 7) / 8
+	if len(data) < stride*height {
+		return nil, false
+	}
+
+	// sample reads the n-th sample of a row, scaled to 0-255 unless it is
+	// a palette index.
+	sample := func(row []byte, n int) int {
+		if bpc == 8 {
+			return int(row[n])
+		}
+		bit := n * bpc
+		v := int(row[bit/8]>>(8-bpc-bit%8)) & (1<<bpc - 1)
+		if palette != nil {
+			return v
+		}
+		return v * 255 / (1<<bpc - 1)
+	}
+	toRGB := func(c []byte, n int) color.RGBA {
+		switch n {
+		case 1:
+			return color.RGBA{c[0], c[0], c[0], 255}
+		case 3:
+			return color.RGBA{c[0], c[1], c[2], 255}
+		}
+		k := 255 - int(c[3])
+		return color.RGBA{uint8((255 - int(c[0])) * k / 255), uint8((255 - int(c[1])) * k / 255), uint8((255 - int(c[2])) * k / 255), 255}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	px := make([]byte, 4)
+	for y := 0; y < height; y++ {
+		row := data[y*stride : (y+1)*stride]
+		for x := 0; x < width; x++ {
+			var c color.RGBA
+			switch {
+			case palette != nil:
+				i := sample(row, x) * paletteComponents
+				if i+paletteComponents > len(palette) {
+					c = color.RGBA{255, 255, 255, 255}
+				} else {
+					c = toRGB(palette[i:i+paletteComponents], paletteComponents)
+				}
+			case components == 1:
+				px[0] = uint8(sample(row, x))
+				c = toRGB(px, 1)
+			default:
+				c = toRGB(row[x*components:(x+1)*components], components)
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img, true
+}
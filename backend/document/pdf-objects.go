@@ -0,0 +1,568 @@
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// pdfName is a PDF name object such as /Type.
+type pdfName string
+
+// pdfKeyword is a bare PDF token such as obj, R or a content stream operator.
+type pdfKeyword string
+
+// pdfRef is an indirect object reference ("12 0 R").
+type pdfRef struct {
+	num int
+	gen int
+}
+
+// pdfDict is a PDF dictionary keyed by name.
+type pdfDict map[pdfName]any
+
+// pdfStream is a stream object with its still-encoded data.
+type pdfStream struct {
+	dict pdfDict
+	data []byte
+}
+
+var errPDFEndOfData = errors.New("unexpected end of PDF data")
+
+// pdfLexer tokenizes PDF object syntax and content streams.
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+func isPDFWhitespace(c byte) bool {
+	switch c {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFWhitespace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// next returns the next object, or a pdfKeyword for delimiters that close
+// a container (">>", "]") and for bare operators.
+func (l *pdfLexer) next() (any, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, errPDFEndOfData
+	}
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		return l.readName(), nil
+	case c == '(':
+		return l.readLiteral(), nil
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return l.readDict()
+		}
+		return l.readHex(), nil
+	case c == '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return pdfKeyword(">>"), nil
+		}
+		l.pos++
+		return l.next()
+	case c == '[':
+		l.pos++
+		return l.readArray()
+	case c == ']':
+		l.pos++
+		return pdfKeyword("]"), nil
+	case c == '{' || c == '}' || c == ')':
+		l.pos++
+		return pdfKeyword(string(c)), nil
+	}
+	return l.readRegular()
+}
+
+func (l *pdfLexer) readName() pdfName {
+	l.pos++
+	var b []byte
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFWhitespace(c) || isPDFDelimiter(c) {
+			break
+		}
+		if c == '#' && l.pos+2 < len(l.data) {
+			if v, err := strconv.ParseUint(string(l.data[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				b = append(b, byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		b = append(b, c)
+		l.pos++
+	}
+	return pdfName(b)
+}
+
+func (l *pdfLexer) readLiteral() []byte {
+	l.pos++
+	var b []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return b
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return b
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				b = append(b, '\n')
+			case 'r':
+				b = append(b, '\r')
+			case 't':
+				b = append(b, '\t')
+			case 'b':
+				b = append(b, '\b')
+			case 'f':
+				b = append(b, '\f')
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					b = append(b, byte(v))
+				} else {
+					b = append(b, e)
+				}
+			}
+			continue
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+func (l *pdfLexer) readHex() []byte {
+	l.pos++
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		c := l.data[l.pos]
+		if !isPDFWhitespace(c) {
+			digits = append(digits, c)
+		}
+		l.pos++
+	}
+	l.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, hex.DecodedLen(len(digits)))
+	n, _ := hex.Decode(out, digits)
+	return out[:n]
+}
+
+func (l *pdfLexer) readArray() ([]any, error) {
+	var arr []any
+	for {
+		v, err := l.next()
+		if err != nil {
+			return arr, err
+		}
+		if k, ok := v.(pdfKeyword); ok {
+			if k == "]" {
+				return arr, nil
+			}
+			if k == "R" && len(arr) >= 2 {
+				if ref, ok := makePDFRef(arr[len(arr)-2], arr[len(arr)-1]); ok {
+					arr = append(arr[:len(arr)-2], ref)
+					continue
+				}
+			}
+		}
+		arr = append(arr, v)
+	}
+}
+
+func (l *pdfLexer) readDict() (pdfDict, error) {
+	d := pdfDict{}
+	for {
+		k, err := l.next()
+		if err != nil {
+			return d, err
+		}
+		if k == pdfKeyword(">>") {
+			return d, nil
+		}
+		name, ok := k.(pdfName)
+		if !ok {
+			continue
+		}
+		v, err := l.value()
+		if err != nil {
+			return d, err
+		}
+		d[name] = v
+	}
+}
+
+// value reads a single object, folding "num gen R" into a pdfRef.
+func (l *pdfLexer) value() (any, error) {
+	v, err := l.next()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.(float64); !ok {
+		return v, nil
+	}
+	save := l.pos
+	gen, err := l.next()
+	if err == nil {
+		if r, err := l.next(); err == nil && r == pdfKeyword("R") {
+			if ref, ok := makePDFRef(v, gen); ok {
+				return ref, nil
+			}
+		}
+	}
+	l.pos = save
+	return v, nil
+}
+
+func (l *pdfLexer) readRegular() (any, error) {
+	start := l.pos
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFWhitespace(c) || isPDFDelimiter(c) {
+			break
+		}
+		l.pos++
+	}
+	tok := string(l.data[start:l.pos])
+	switch tok {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if f, err := strconv.ParseFloat(tok, 64); err == nil {
+		return f, nil
+	}
+	return pdfKeyword(tok), nil
+}
+
+func makePDFRef(num, gen any) (pdfRef, bool) {
+	n, ok1 := num.(float64)
+	g, ok2 := gen.(float64)
+	if !ok1 || !ok2 {
+		return pdfRef{}, false
+	}
+	return pdfRef{num: int(n), gen: int(g)}, true
+}
+
+// pdfFile is an index of every object found in a PDF buffer.
+type pdfFile struct {
+	objects  map[int]any
+	trailers []pdfDict
+
+	// decoded and ops count the stream bytes decoded and the content
+	// operators run across the whole document, against maxDecoded and
+	// maxOps. err holds the first of those limits exceeded.
+	decoded, maxDecoded int64
+	ops, maxOps         int
+	err                 error
+}
+
+var pdfObjHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// readPDF scans the buffer for object definitions rather than trusting the
+// cross-reference table, which is frequently damaged in real-world files.
+// Streams decoded from the file may total at most maxDecoded bytes.
+func readPDF(data []byte, maxDecoded int64, maxOps int) (*pdfFile, error) {
+	f := &pdfFile{objects: map[int]any{}, maxDecoded: maxDecoded, maxOps: maxOps}
+	pos := 0
+	for {
+		loc := pdfObjHeader.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		l := &pdfLexer{data: data, pos: pos + loc[1]}
+		v, err := l.value()
+		if err != nil {
+			break
+		}
+		if d, ok := v.(pdfDict); ok {
+			save := l.pos
+			if k, err := l.next(); err == nil && k == pdfKeyword("stream") {
+				s := &pdfStream{dict: d}
+				s.data, l.pos = readPDFStreamData(data, l.pos, d)
+				v = s
+			} else {
+				l.pos = save
+			}
+		}
+		f.objects[num] = v
+		pos = l.pos
+	}
+	for _, m := range regexp.MustCompile(`trailer\s*<<`).FindAllIndex(data, -1) {
+		l := &pdfLexer{data: data, pos: m[1] - 2}
+		if d, err := l.next(); err == nil {
+			if dict, ok := d.(pdfDict); ok {
+				f.trailers = append(f.trailers, dict)
+			}
+		}
+	}
+	for _, v := range f.objects {
+		if s, ok := v.(*pdfStream); ok && s.dict["Type"] == pdfName("XRef") {
+			f.trailers = append(f.trailers, s.dict)
+		}
+	}
+	if len(f.objects) == 0 {
+		return nil, errors.New("no objects found in PDF")
+	}
+	f.expandObjectStreams()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f, nil
+}
+
+// readPDFStreamData returns the raw stream bytes starting just after the
+// "stream" keyword and the position following "endstream".
+func readPDFStreamData(data []byte, pos int, d pdfDict) ([]byte, int) {
+	if pos < len(data) && data[pos] == '\r' {
+		pos++
+	}
+	if pos < len(data) && data[pos] == '\n' {
+		pos++
+	}
+	if n, ok := d["Length"].(float64); ok {
+		end := pos + int(n)
+		if end <= len(data) && end >= pos {
+			rest := bytes.TrimLeft(data[end:min(end+16, len(data))], "\r\n ")
+			if bytes.HasPrefix(rest, []byte("endstream")) {
+				return data[pos:end], end + bytes.Index(data[end:], []byte("endstream")) + len("endstream")
+			}
+		}
+	}
+	idx := bytes.Index(data[pos:], []byte("endstream"))
+	if idx < 0 {
+		return data[pos:], len(data)
+	}
+	raw := bytes.TrimRight(data[pos:pos+idx], "\r\n")
+	return raw, pos + idx + len("endstream")
+}
+
+// expandObjectStreams unpacks compressed object streams (PDF 1.5+). Objects
+// defined directly in the file take precedence.
+func (f *pdfFile) expandObjectStreams() {
+	for _, v := range f.objects {
+		s, ok := v.(*pdfStream)
+		if !ok || s.dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		data, err := f.decodeStream(s)
+		if err != nil {
+			continue
+		}
+		n := f.integer(s.dict["N"])
+		first := f.integer(s.dict["First"])
+		header := &pdfLexer{data: data}
+		for i := 0; i < n; i++ {
+			num, err1 := header.next()
+			off, err2 := header.next()
+			if err1 != nil || err2 != nil {
+				break
+			}
+			objNum, ok1 := num.(float64)
+			objOff, ok2 := off.(float64)
+			if !ok1 || !ok2 {
+				break
+			}
+			if _, exists := f.objects[int(objNum)]; exists {
+				continue
+			}
+			body := &pdfLexer{data: data, pos: first + int(objOff)}
+			if body.pos >= len(data) {
+				continue
+			}
+			if obj, err := body.value(); err == nil {
+				f.objects[int(objNum)] = obj
+			}
+		}
+	}
+}
+
+// resolve follows indirect references until it reaches a direct object.
+func (f *pdfFile) resolve(v any) any {
+	for i := 0; i < 32; i++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = f.objects[ref.num]
+	}
+	return nil
+}
+
+func (f *pdfFile) dict(v any) pdfDict {
+	switch d := f.resolve(v).(type) {
+	case pdfDict:
+		return d
+	case *pdfStream:
+		return d.dict
+	}
+	return nil
+}
+
+func (f *pdfFile) integer(v any) int {
+	if n, ok := f.resolve(v).(float64); ok {
+		return int(n)
+	}
+	return 0
+}
+
+func (f *pdfFile) trailerValue(key pdfName) any {
+	for i := len(f.trailers) - 1; i >= 0; i-- {
+		if v, ok := f.trailers[i][key]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// catalog returns the document catalog, falling back to a scan for
+// /Type /Catalog when no trailer is found.
+func (f *pdfFile) catalog() pdfDict {
+	if root := f.dict(f.trailerValue("Root")); root != nil {
+		return root
+	}
+	for _, v := range f.objects {
+		if d, ok := v.(pdfDict); ok && d["Type"] == pdfName("Catalog") {
+			return d
+		}
+	}
+	return nil
+}
+
+// decodeStream applies the stream's filters in order. The decoded bytes
+// count against the document's budget, so that streams drawn many times
+// cannot expand without bound.
+func (f *pdfFile) decodeStream(s *pdfStream) ([]byte, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	data := s.data
+	var filters []any
+	switch v := f.resolve(s.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []any{v}
+	case []any:
+		filters = v
+	}
+	for _, filter := range filters {
+		name, _ := f.resolve(filter).(pdfName)
+		var err error
+		switch name {
+		case "FlateDecode", "Fl":
+			data, err = inflatePDF(data, f.maxDecoded-f.decoded)
+		case "ASCIIHexDecode", "AHx":
+			l := &pdfLexer{data: append(append([]byte("<"), bytes.TrimSuffix(bytes.TrimSpace(data), []byte(">"))...), '>')}
+			data = l.readHex()
+		case "ASCII85Decode", "A85":
+			data, err = decodeASCII85(data)
+		default:
+			return nil, fmt.Errorf("unsupported PDF filter %q", name)
+		}
+		if errors.Is(err, ErrTooLarge) {
+			return nil, f.overDecoded()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if f.decoded += int64(len(data)); f.decoded > f.maxDecoded {
+		return nil, f.overDecoded()
+	}
+	return data, nil
+}
+
+// overDecoded records that the document's streams went over maxDecoded.
+func (f *pdfFile) overDecoded() error {
+	f.err = fmt.Errorf("%w: PDF streams decode to more than %d bytes", ErrTooLarge, f.maxDecoded)
+	return f.err
+}
+
+// inflatePDF inflates a FlateDecode stream of at most max bytes.
+func inflatePDF(data []byte, max int64) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	out, err := readAllLimited(r, max)
+	if len(out) > 0 {
+		// Truncated streams are common; keep whatever inflated cleanly.
+		return out, nil
+	}
+	return out, err
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i >= 0 {
+		data = data[:i]
+	}
+	out := make([]byte, len(data))
+	n, _, err := ascii85.Decode(out, data, true)
+	return out[:n], err
+}
@@ -0,0 +1,705 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// PDFParser extracts text from PDF documents, keeping page boundaries.
+type PDFParser struct {
+	// OCR, when set, recognizes the text of pages that have no text layer
+	// from the largest image drawn on them.
+	OCR OCRProvider
+	// MaxDecodedBytes limits the bytes decoded from all of a document's
+	// streams together. Defaults to 256 MB.
+	MaxDecodedBytes int64
+	// MaxOperators limits the content stream operators run across the
+	// document, counting each drawing of a form again. Defaults to 10
+	// million.
+	MaxOperators int
+}
+
+// NewPDFParser creates a new PDF parser instance.
+func NewPDFParser() *PDFParser {
+	return &PDFParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *PDFParser) Supports(mimeType string) bool {
+	return mimeType == "application/pdf"
+}
+
+// Parse extracts the text of every page. Pages are separated by a form
+// feed in Content and their byte ranges are recorded in Document.Pages.
+// The numbers of pages read by OCR are listed in the "ocr_pages" metadata,
+// and the information dictionary fills Document.Info.
+func (p *PDFParser) Parse(buffer []byte, filename string) (*Document, error) {
+	return p.ParseContext(context.Background(), buffer, filename)
+}
+
+// ParseContext works like Parse but stops when ctx is done, checking it
+// while reading page content and during OCR.
+func (p *PDFParser) ParseContext(ctx context.Context, buffer []byte, filename string) (*Document, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(buffer, "\x00\t\r\n "), []byte("%PDF-")) {
+		return nil, errors.New("not a PDF document")
+	}
+	maxDecoded, maxOps := p.MaxDecodedBytes, p.MaxOperators
+	if maxDecoded <= 0 {
+		maxDecoded = 256 << 20
+	}
+	if maxOps <= 0 {
+		maxOps = 10_000_000
+	}
+	f, err := readPDF(buffer, maxDecoded, maxOps)
+	if err != nil {
+		return nil, err
+	}
+	if f.trailerValue("Encrypt") != nil {
+		return nil, errors.New("encrypted PDF documents are not supported")
+	}
+
+	var b strings.Builder
+	var pages []Page
+	var ocrPages []string
+	for i, page := range f.pages() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			b.WriteString("\f")
+		}
+		start := b.Len()
+		text := cleanPDFText(f.pageText(ctx, page))
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if f.err != nil {
+			return nil, f.err
+		}
+		if text == "" && p.OCR != nil {
+			if img, mimeType, ok := f.pageImage(page); ok {
+				// A page that fails to OCR is left empty rather than
+				// failing the whole document.
+				ocr, err := recognize(ctx, p.OCR, img, mimeType)
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				if err == nil {
+					if text = cleanPDFText(ocr); text != "" {
+						ocrPages = append(ocrPages, strconv.Itoa(i+1))
+					}
+				}
+			}
+		}
+		b.WriteString(text)
+		pages = append(pages, Page{Number: i + 1, Start: start, End: b.Len()})
+	}
+
+	content := b.String()
+	if strings.TrimSpace(content) == "" {
+		return nil, ErrEmptyDocument
+	}
+	var meta map[string]string
+	if len(ocrPages) > 0 {
+		meta = map[string]string{"ocr_pages": strings.Join(ocrPages, ",")}
+	}
+	doc := &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Pages:     pages,
+		Metadata:  meta,
+	}
+	return doc.withInfo(f.info()), nil
+}
+
+// info reads the document information dictionary. Entries other than the
+// standard title, author, subject, keywords and dates, such as "Producer"
+// and "Creator", go to Properties.
+func (f *pdfFile) info() DocumentInfo {
+	var info DocumentInfo
+	for key, v := range f.dict(f.trailerValue("Info")) {
+		b, ok := f.resolve(v).([]byte)
+		if !ok {
+			continue
+		}
+		s := strings.TrimSpace(pdfTextString(b))
+		switch key {
+		case "Title":
+			info.Title = s
+		case "Author":
+			info.Author = s
+		case "Subject":
+			info.Subject = s
+		case "Keywords":
+			info.Keywords = splitKeywords(s)
+		case "CreationDate":
+			info.Created = parsePDFDate(s)
+		case "ModDate":
+			info.Modified = parsePDFDate(s)
+		default:
+			info.setProperty(string(key), s)
+		}
+	}
+	return info
+}
+
+// pdfTextString decodes a PDF text string, which is UTF-16BE or UTF-8 when
+// it starts with a byte order mark and PDFDocEncoding otherwise. The
+// Latin-1 range of PDFDocEncoding is taken as is.
+func pdfTextString(b []byte) string {
+	switch {
+	case bytes.HasPrefix(b, []byte{0xfe, 0xff}):
+		return utf16BytesToString(b[2:])
+	case bytes.HasPrefix(b, []byte{0xef, 0xbb, 0xbf}):
+		return string(b[3:])
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// pdfPage is a leaf of the page tree with its inherited resources.
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pages walks the page tree in document order.
+func (f *pdfFile) pages() []pdfPage {
+	var out []pdfPage
+	// Each node is visited once. Damaged or hostile files may list a node
+	// among its own descendants, and walking every path through a tree
+	// that refers back to itself from several kids is exponential.
+	visited := map[int]bool{}
+	var walk func(v any, resources pdfDict, depth int)
+	walk = func(v any, resources pdfDict, depth int) {
+		if ref, ok := v.(pdfRef); ok {
+			if visited[ref.num] {
+				return
+			}
+			visited[ref.num] = true
+		}
+		node := f.dict(v)
+		if node == nil || depth > 64 {
+			return
+		}
+		if r := f.dict(node["Resources"]); r != nil {
+			resources = r
+		}
+		kids, isTree := f.resolve(node["Kids"]).([]any)
+		if node["Type"] == pdfName("Page") || !isTree {
+			out = append(out, pdfPage{dict: node, resources: resources})
+			return
+		}
+		for _, kid := range kids {
+			walk(kid, resources, depth+1)
+		}
+	}
+	if cat := f.catalog(); cat != nil {
+		walk(cat["Pages"], nil, 0)
+	}
+	if len(out) > 0 {
+		return out
+	}
+
+	// No usable page tree: fall back to every /Type /Page object in
+	// object-number order.
+	var nums []int
+	for num, v := range f.objects {
+		if d, ok := v.(pdfDict); ok && d["Type"] == pdfName("Page") {
+			nums = append(nums, num)
+		}
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		d := f.objects[num].(pdfDict)
+		out = append(out, pdfPage{dict: d, resources: f.dict(d["Resources"])})
+	}
+	return out
+}
+
+// pageText runs the page's content streams through the text extractor.
+func (f *pdfFile) pageText(ctx context.Context, page pdfPage) string {
+	var content []byte
+	contents := f.resolve(page.dict["Contents"])
+	streams, ok := contents.([]any)
+	if !ok {
+		streams = []any{page.dict["Contents"]}
+	}
+	for _, ref := range streams {
+		s, ok := f.resolve(ref).(*pdfStream)
+		if !ok {
+			continue
+		}
+		data, err := f.decodeStream(s)
+		if err != nil {
+			continue
+		}
+		content = append(content, data...)
+		content = append(content, '\n')
+	}
+	w := &pdfTextWriter{}
+	f.extractText(ctx, content, page.resources, w, map[*pdfStream]bool{})
+	return w.String()
+}
+
+// pdfTextWriter accumulates extracted text, avoiding runs of separators.
+type pdfTextWriter struct {
+	strings.Builder
+	last byte
+}
+
+func (w *pdfTextWriter) text(s string) {
+	if s == "" {
+		return
+	}
+	w.WriteString(s)
+	w.last = s[len(s)-1]
+}
+
+func (w *pdfTextWriter) space() {
+	if w.Len() > 0 && w.last != ' ' && w.last != '\n' {
+		w.text(" ")
+	}
+}
+
+func (w *pdfTextWriter) newline() {
+	if w.Len() > 0 && w.last != '\n' {
+		w.text("\n")
+	}
+}
+
+// pdfTextState tracks enough of the text matrix to decide where word and
+// line breaks fall when glyphs are positioned individually.
+type pdfTextState struct {
+	font         *pdfFont
+	size         float64
+	leading      float64
+	lineX, lineY float64
+	x, y         float64
+	endX, endY   float64
+	shown        bool
+}
+
+func (s *pdfTextState) moveLine(tx, ty float64) {
+	s.lineX += tx
+	s.lineY += ty
+	s.x, s.y = s.lineX, s.lineY
+}
+
+// show writes a string at the current position, inserting a space or
+// newline when it does not continue the previously shown text.
+func (s *pdfTextState) show(w *pdfTextWriter, str []byte) {
+	size := s.size
+	if size <= 0 {
+		size = 1
+	}
+	if s.shown {
+		switch {
+		case abs(s.y-s.endY) > size*0.5:
+			w.newline()
+		case s.x-s.endX > size*0.15 || s.endX-s.x > size:
+			w.space()
+		}
+	}
+	text, advance := s.font.decode(str)
+	w.text(text)
+	s.x += advance * s.size / 1000
+	s.endX, s.endY, s.shown = s.x, s.y, true
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+func numberOperand(operands []any, i int) float64 {
+	if i < len(operands) {
+		if f, ok := operands[i].(float64); ok {
+			return f
+		}
+	}
+	return 0
+}
+
+// extractText interprets the text-showing operators of a content stream,
+// recursing into form XObjects. forms holds the forms being drawn, which
+// are not entered again. It stops early when ctx is done or the document
+// runs over its operator budget.
+func (f *pdfFile) extractText(ctx context.Context, content []byte, resources pdfDict, w *pdfTextWriter, forms map[*pdfStream]bool) {
+	if len(forms) > 8 || f.err != nil || ctx.Err() != nil {
+		return
+	}
+	fonts := f.dict(resources["Font"])
+	loaded := map[pdfName]*pdfFont{}
+	st := &pdfTextState{}
+	var operands []any
+
+	l := &pdfLexer{data: content}
+	for {
+		tok, err := l.next()
+		if err != nil {
+			break
+		}
+		op, isOp := tok.(pdfKeyword)
+		if !isOp {
+			operands = append(operands, tok)
+			continue
+		}
+		if f.ops++; f.ops > f.maxOps {
+			f.err = fmt.Errorf("%w: PDF content runs more than %d operators", ErrTooLarge, f.maxOps)
+			return
+		}
+		if f.ops%1024 == 0 && ctx.Err() != nil {
+			return
+		}
+		switch op {
+		case "BI":
+			// Skip inline image data up to the EI operator.
+			if i := bytes.Index(content[l.pos:], []byte("EI")); i >= 0 {
+				l.pos += i + 2
+			}
+		case "BT":
+			st.lineX, st.lineY, st.x, st.y = 0, 0, 0, 0
+		case "Tf":
+			if name, ok := lastOperandAt(operands, 2).(pdfName); ok {
+				if _, cached := loaded[name]; !cached {
+					loaded[name] = f.loadFont(f.dict(fonts[name]))
+				}
+				st.font = loaded[name]
+				st.size = numberOperand(operands, 1)
+			}
+		case "TL":
+			st.leading = numberOperand(operands, 0)
+		case "Td":
+			st.moveLine(numberOperand(operands, 0), numberOperand(operands, 1))
+		case "TD":
+			st.leading = -numberOperand(operands, 1)
+			st.moveLine(numberOperand(operands, 0), numberOperand(operands, 1))
+		case "Tm":
+			st.lineX, st.lineY = numberOperand(operands, 4), numberOperand(operands, 5)
+			st.x, st.y = st.lineX, st.lineY
+		case "T*":
+			st.moveLine(0, -st.leading)
+		case "Tj":
+			if s, ok := lastOperand(operands).([]byte); ok {
+				st.show(w, s)
+			}
+		case "'", "\"":
+			st.moveLine(0, -st.leading)
+			if s, ok := lastOperand(operands).([]byte); ok {
+				st.show(w, s)
+			}
+		case "TJ":
+			arr, _ := lastOperand(operands).([]any)
+			for _, item := range arr {
+				switch v := item.(type) {
+				case []byte:
+					st.show(w, v)
+				case float64:
+					st.x -= v / 1000 * st.size
+				}
+			}
+		case "Do":
+			if name, ok := lastOperand(operands).(pdfName); ok {
+				xobj, ok := f.resolve(f.dict(resources["XObject"])[name]).(*pdfStream)
+				if ok && xobj.dict["Subtype"] == pdfName("Form") && !forms[xobj] {
+					if data, err := f.decodeStream(xobj); err == nil {
+						res := f.dict(xobj.dict["Resources"])
+						if res == nil {
+							res = resources
+						}
+						w.newline()
+						forms[xobj] = true
+						f.extractText(ctx, data, res, w, forms)
+						delete(forms, xobj)
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+func lastOperand(operands []any) any {
+	if len(operands) == 0 {
+		return nil
+	}
+	return operands[len(operands)-1]
+}
+
+// lastOperandAt returns the operand n places from the end of the stack.
+func lastOperandAt(operands []any, n int) any {
+	if len(operands) < n {
+		return nil
+	}
+	return operands[len(operands)-n]
+}
+
+// pdfFont holds what is needed to turn shown strings into text: the
+// ToUnicode mapping and glyph widths used to track the pen position.
+type pdfFont struct {
+	cmap         *pdfCMap
+	codeLen      int
+	widths       map[uint32]float64
+	defaultWidth float64
+}
+
+// loadFont reads a font dictionary. Simple fonts without a ToUnicode map
+// are decoded as single-byte Latin text.
+func (f *pdfFile) loadFont(font pdfDict) *pdfFont {
+	pf := &pdfFont{codeLen: 1, widths: map[uint32]float64{}, defaultWidth: 500}
+	if font == nil {
+		return pf
+	}
+	if s, ok := f.resolve(font["ToUnicode"]).(*pdfStream); ok {
+		if data, err := f.decodeStream(s); err == nil {
+			pf.cmap = parsePDFCMap(data)
+		}
+	}
+
+	if font["Subtype"] == pdfName("Type0") {
+		pf.codeLen = 2
+		pf.defaultWidth = 1000
+		descendants, _ := f.resolve(font["DescendantFonts"]).([]any)
+		if len(descendants) == 0 {
+			return pf
+		}
+		cid := f.dict(descendants[0])
+		if dw, ok := f.resolve(cid["DW"]).(float64); ok {
+			pf.defaultWidth = dw
+		}
+		w, _ := f.resolve(cid["W"]).([]any)
+		for i := 0; i < len(w); {
+			first, ok := f.resolve(w[i]).(float64)
+			if !ok || i+1 >= len(w) {
+				break
+			}
+			if list, ok := f.resolve(w[i+1]).([]any); ok {
+				for j, v := range list {
+					if width, ok := f.resolve(v).(float64); ok {
+						pf.widths[uint32(first)+uint32(j)] = width
+					}
+				}
+				i += 2
+				continue
+			}
+			if i+2 >= len(w) {
+				break
+			}
+			last, _ := f.resolve(w[i+1]).(float64)
+			width, _ := f.resolve(w[i+2]).(float64)
+			for c := first; c <= last && c-first < 65536; c++ {
+				pf.widths[uint32(c)] = width
+			}
+			i += 3
+		}
+		return pf
+	}
+
+	firstChar := f.integer(font["FirstChar"])
+	if widths, ok := f.resolve(font["Widths"]).([]any); ok {
+		for i, v := range widths {
+			if width, ok := f.resolve(v).(float64); ok {
+				pf.widths[uint32(firstChar+i)] = width
+			}
+		}
+	}
+	return pf
+}
+
+// decode converts a shown string to text and returns its advance in
+// thousandths of a text space unit.
+func (pf *pdfFont) decode(s []byte) (string, float64) {
+	if pf == nil {
+		pf = &pdfFont{codeLen: 1, defaultWidth: 500}
+	}
+	var b strings.Builder
+	var advance float64
+	for i := 0; i < len(s); {
+		n := pf.codeLen
+		var text string
+		mapped := false
+		if pf.cmap != nil {
+			for _, l := range pf.cmap.codeLens {
+				if i+l > len(s) {
+					break
+				}
+				if t, ok := pf.cmap.codes[string(s[i:i+l])]; ok {
+					text, n, mapped = t, l, true
+					break
+				}
+			}
+		}
+		if i+n > len(s) {
+			n = len(s) - i
+		}
+		code := bytesToUint(s[i : i+n])
+		if !mapped && pf.cmap == nil && n == 1 && (code >= 0x20 || code == '\t') {
+			text = string(rune(code))
+		}
+		b.WriteString(text)
+		if width, ok := pf.widths[code]; ok {
+			advance += width
+		} else {
+			advance += pf.defaultWidth
+		}
+		i += n
+	}
+	return b.String(), advance
+}
+
+// pdfCMap maps character codes to Unicode text for a single font.
+type pdfCMap struct {
+	codeLens []int
+	codes    map[string]string
+}
+
+func parsePDFCMap(data []byte) *pdfCMap {
+	cm := &pdfCMap{codes: map[string]string{}}
+	lens := map[int]bool{}
+	l := &pdfLexer{data: data}
+	var operands []any
+	for {
+		tok, err := l.next()
+		if err != nil {
+			break
+		}
+		op, isOp := tok.(pdfKeyword)
+		if !isOp {
+			operands = append(operands, tok)
+			continue
+		}
+		switch op {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				if lo, ok := operands[i].([]byte); ok && len(lo) > 0 {
+					lens[len(lo)] = true
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].([]byte)
+				dst, ok2 := operands[i+1].([]byte)
+				if ok1 && ok2 {
+					cm.codes[string(src)] = utf16BytesToString(dst)
+					lens[len(src)] = true
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].([]byte)
+				hi, ok2 := operands[i+1].([]byte)
+				if !ok1 || !ok2 || len(lo) != len(hi) || len(lo) == 0 || len(lo) > 4 {
+					continue
+				}
+				lens[len(lo)] = true
+				from, to := bytesToUint(lo), bytesToUint(hi)
+				for c := from; c <= to && c-from < 65536; c++ {
+					code := uintToBytes(c, len(lo))
+					switch dst := operands[i+2].(type) {
+					case []byte:
+						base := []rune(utf16BytesToString(dst))
+						if len(base) > 0 {
+							base[len(base)-1] += rune(c - from)
+						}
+						cm.codes[string(code)] = string(base)
+					case []any:
+						if int(c-from) < len(dst) {
+							if d, ok := dst[c-from].([]byte); ok {
+								cm.codes[string(code)] = utf16BytesToString(d)
+							}
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	for n := range lens {
+		cm.codeLens = append(cm.codeLens, n)
+	}
+	sort.Ints(cm.codeLens)
+	if len(cm.codeLens) == 0 {
+		cm.codeLens = []int{1}
+	}
+	return cm
+}
+
+func utf16BytesToString(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+func bytesToUint(b []byte) uint32 {
+	var v uint32
+	for _, c := range b {
+		v = v<<8 | uint32(c)
+	}
+	return v
+}
+
+func uintToBytes(v uint32, n int) []byte {
+	b := make([]byte, n)
+	for i := n - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+	return b
+}
+
+var (
+	pdfSentenceEnd  = regexp.MustCompile(`[.!?:]["']?$`)
+	pdfTrailingStop = regexp.MustCompile(`[.!?,]$`)
+	pdfBulletStart  = regexp.MustCompile(`^[-•*\d]`)
+)
+
+// cleanPDFText collapses whitespace and rejoins lines that PDF layout
+// wrapped mid-sentence, separating paragraphs with blank lines.
+func cleanPDFText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	startsUpper := func(s string) bool { return s != "" && s[0] >= 'A' && s[0] <= 'Z' }
+	var paragraphs []string
+	var current strings.Builder
+	for i, line := range lines {
+		if current.Len() > 0 {
+			current.WriteByte(' ')
+		}
+		current.WriteString(line)
+
+		next := ""
+		if i+1 < len(lines) {
+			next = lines[i+1]
+		}
+		if next == "" ||
+			(pdfSentenceEnd.MatchString(line) && startsUpper(next)) ||
+			(len(next) < 80 && startsUpper(next) && !pdfTrailingStop.MatchString(next)) ||
+			pdfBulletStart.MatchString(next) {
+			paragraphs = append(paragraphs, current.String())
+			current.Reset()
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
@@ -0,0 +1,169 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildPDF returns a PDF whose objects are the given bodies, numbered
+// from 1, with a cross-reference table and object 1 as the catalog.
+func buildPDF(objects ...string) []byte {
+	var b strings.Builder
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return []byte(b.String())
+}
+
+// pdfStreamObject returns a stream object holding data.
+func pdfStreamObject(data string) string {
+	return fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(data), data)
+}
+
+// textPDF returns a PDF with one page per text.
+func textPDF(texts ...string) []byte {
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>", ""}
+	var kids []string
+	for _, text := range texts {
+		page := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /Contents %d 0 R /Resources << /Font << /F1 %d 0 R >> >> >>", page+1, page+2),
+			pdfStreamObject("BT /F1 12 Tf 72 720 Td ("+text+") Tj ET"),
+			"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(texts))
+	return buildPDF(objects...)
+}
+
+func TestPDFParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		pages    int
+		contains []string
+	}{
+		{"fixture", mustRead(t, fixture("test-pdf.pdf")), 5, []string{"Lorem ipsum dolor sit amet"}},
+		{"built", textPDF("First page text.", "Second page text."), 2, []string{"First page text.", "Second page text."}},
+		{"cyclic kids", buildPDF(
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [2 0 R 3 0 R 6 0 R] /Count 1 >>",
+			"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+			pdfStreamObject("BT /F1 12 Tf 72 720 Td (Only page.) Tj ET"),
+			"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+			"<< /Type /Pages /Kids [6 0 R 2 0 R 6 0 R 2 0 R] /Count 0 >>"), 1, []string{"Only page."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewPDFParser().Parse(tt.input, "a.pdf")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(doc.Pages) != tt.pages {
+				t.Fatalf("%d pages, want %d", len(doc.Pages), tt.pages)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(doc.Content, want) {
+					t.Errorf("content does not contain %q", want)
+				}
+			}
+			end := 0
+			for i, pg := range doc.Pages {
+				if pg.Number != i+1 || pg.Start < end || pg.End < pg.Start || pg.End > len(doc.Content) {
+					t.Errorf("page %+v is out of order or outside the content", pg)
+				}
+				end = pg.End
+			}
+		})
+	}
+	// Each page's range holds its own text.
+	doc, err := NewPDFParser().Parse(textPDF("Alpha.", "Beta."), "a.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := doc.Content[doc.Pages[1].Start:doc.Pages[1].End]; !strings.Contains(got, "Beta.") || strings.Contains(got, "Alpha.") {
+		t.Errorf("page 2 holds %q", got)
+	}
+}
+
+func TestPDFParserRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"not a pdf", []byte("plain text")},
+		{"no text", textPDF()},
+	}
+	for _, tt := range tests {
+		if _, err := NewPDFParser().Parse(tt.input, "a.pdf"); err == nil {
+			t.Errorf("%s: parsed without error", tt.name)
+		}
+	}
+}
+
+// formPDF returns a one-page PDF that draws form XObject 6. Each of the
+// forms shows text and then draws the form that follows it, or itself for
+// the last, the given number of times.
+func formPDF(forms, draws int) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> /XObject << /X 6 0 R >> >> >>",
+		pdfStreamObject("/X Do"),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	for i := 0; i < forms; i++ {
+		next := 7 + i
+		if i == forms-1 {
+			next = 6 + i
+		}
+		data := fmt.Sprintf("BT /F1 12 Tf (Form %d.) Tj ET", i) + strings.Repeat(" /X Do", draws)
+		objects = append(objects, fmt.Sprintf("<< /Type /XObject /Subtype /Form /Length %d /Resources << /Font << /F1 5 0 R >> /XObject << /X %d 0 R >> >> >>\nstream\n%s\nendstream",
+			len(data), next, data))
+	}
+	return buildPDF(objects...)
+}
+
+func TestPDFParserForms(t *testing.T) {
+	// A form that draws itself is shown once.
+	doc, err := NewPDFParser().Parse(formPDF(2, 3), "a.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(doc.Content, "Form 0.") != 1 || strings.Count(doc.Content, "Form 1.") != 3 {
+		t.Errorf("content %q", doc.Content)
+	}
+
+	// Forms drawing each other many times run out of operators.
+	bomb := formPDF(9, 10)
+	if _, err := (&PDFParser{MaxOperators: 10000}).Parse(bomb, "a.pdf"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("form bomb: %v", err)
+	}
+	if _, err := (&PDFParser{MaxDecodedBytes: 10000}).Parse(bomb, "a.pdf"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("form bomb decoding: %v", err)
+	}
+
+	// A deadline stops the page part way through.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := NewPDFParser().ParseContext(ctx, bomb, "a.pdf"); !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, ErrTooLarge) {
+		t.Errorf("form bomb with a deadline: %v", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("form bomb took %v", d)
+	}
+}
@@ -0,0 +1,265 @@
+package document
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// sqlIdentifier matches the table names PgVectorSink accepts.
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// metadataColumn matches the metadata column names PgVectorSink accepts:
+// lower case, so they need no quoting in queries against the table.
+var metadataColumn = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// pgVectorColumns are the columns of every PgVectorSink table, which
+// metadata columns may not replace.
+var pgVectorColumns = []string{"id", "source", "chunk_index", "content", "metadata", "embedding"}
+
+// PgVectorSink is a Sink that upserts chunks and their embeddings into a
+// Postgres table with the pgvector extension. Chunks are keyed by
+// ChunkID, so writing an unchanged document again updates its rows in
+// place. The table, its vector index and the extension are created on
+// the first write if missing.
+//
+// DB must use a Postgres driver, such as pgx's stdlib adapter,
+// registered by the caller.
+type PgVectorSink struct {
+	DB *sql.DB
+	// Table defaults to "chunks".
+	Table string
+	// Dimensions is the length of the vectors. Defaults to the length of
+	// the first embedding written.
+	Dimensions int
+	// MetadataColumns are metadata keys stored in their own indexed text
+	// columns, for filtering searches, as well as in the metadata JSON.
+	// Chunk metadata takes precedence over document metadata. Names must
+	// be lower case identifiers other than the table's own columns, such
+	// as "id" or "content", or Write fails before running any SQL.
+	MetadataColumns []string
+	// ReplaceDocument deletes the rows of a source before writing its
+	// chunks, so chunks that no longer exist do not linger.
+	ReplaceDocument bool
+
+	mu    sync.Mutex
+	ready bool
+}
+
+// NewPgVectorSink creates a sink that writes to table in db.
+func NewPgVectorSink(db *sql.DB, table string) *PgVectorSink {
+	return &PgVectorSink{DB: db, Table: table}
+}
+
+// Write upserts the chunks of doc in one transaction. Every chunk must
+// have an Embedding; see Pipeline.Embed.
+func (s *PgVectorSink) Write(ctx context.Context, doc *Document, chunks []Chunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	for i, c := range chunks {
+		if len(c.Embedding) == 0 {
+			return fmt.Errorf("chunk %d has no embedding", i)
+		}
+	}
+	if err := s.checkMetadataColumns(); err != nil {
+		return err
+	}
+	if err := s.ensureSchema(ctx, len(chunks[0].Embedding)); err != nil {
+		return err
+	}
+	table, err := s.table()
+	if err != nil {
+		return err
+	}
+	source := storeSource(doc)
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if s.ReplaceDocument {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE source = $1", source); err != nil {
+			return fmt.Errorf("delete old chunks: %w", err)
+		}
+	}
+	columns := slices.Clone(pgVectorColumns)
+	for _, col := range s.MetadataColumns {
+		columns = append(columns, quoteIdent(col))
+	}
+	placeholders := make([]string, len(columns))
+	updates := make([]string, 0, len(columns)-1)
+	for i, col := range columns {
+		placeholders[i] = "$" + strconv.Itoa(i+1)
+		if i > 0 {
+			updates = append(updates, col+" = EXCLUDED."+col)
+		}
+	}
+	placeholders[5] += "::vector"
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO "+table+" ("+strings.Join(columns, ", ")+") VALUES ("+
+		strings.Join(placeholders, ", ")+") ON CONFLICT (id) DO UPDATE SET "+strings.Join(updates, ", "))
+	if err != nil {
+		return fmt.Errorf("prepare upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, c := range chunks {
+		metadata := chunkStoreMetadata(doc, c)
+		metaJSON, err := json.Marshal(metadata)
+		if err != nil {
+			return err
+		}
+		args := []any{storeChunkID(source, c), source, c.Index, c.Text, string(metaJSON), vectorLiteral(c.Embedding)}
+		for _, col := range s.MetadataColumns {
+			if v, ok := metadata[col]; ok {
+				args = append(args, v)
+			} else {
+				args = append(args, nil)
+			}
+		}
+		if _, err := stmt.ExecContext(ctx, args...); err != nil {
+			return fmt.Errorf("upsert chunk %d: %w", c.Index, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// table returns the quoted table name.
+func (s *PgVectorSink) table() (string, error) {
+	table := s.Table
+	if table == "" {
+		table = "chunks"
+	}
+	if !sqlIdentifier.MatchString(table) {
+		return "", fmt.Errorf("invalid table name %q", table)
+	}
+	return quoteIdent(table), nil
+}
+
+// checkMetadataColumns rejects MetadataColumns that are not lower case
+// identifiers, repeat, or name one of the table's own columns.
+func (s *PgVectorSink) checkMetadataColumns() error {
+	for i, col := range s.MetadataColumns {
+		if !metadataColumn.MatchString(col) {
+			return fmt.Errorf("invalid metadata column %q", col)
+		}
+		if slices.Contains(pgVectorColumns, col) || slices.Contains(s.MetadataColumns[:i], col) {
+			return fmt.Errorf("metadata column %q is already a column", col)
+		}
+	}
+	return nil
+}
+
+// ensureSchema creates the extension, table and indexes once.
+func (s *PgVectorSink) ensureSchema(ctx context.Context, dims int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ready {
+		return nil
+	}
+	if s.Dimensions > 0 {
+		dims = s.Dimensions
+	}
+	table, err := s.table()
+	if err != nil {
+		return err
+	}
+	name := strings.Trim(table, `"`)
+	columns := []string{
+		"id text PRIMARY KEY",
+		"source text NOT NULL",
+		"chunk_index integer NOT NULL",
+		"content text NOT NULL",
+		"metadata jsonb",
+		"embedding vector(" + strconv.Itoa(dims) + ") NOT NULL",
+	}
+	stmts := []string{"CREATE EXTENSION IF NOT EXISTS vector"}
+	for _, col := range s.MetadataColumns {
+		columns = append(columns, quoteIdent(col)+" text")
+	}
+	stmts = append(stmts,
+		"CREATE TABLE IF NOT EXISTS "+table+" ("+strings.Join(columns, ", ")+")",
+		"CREATE INDEX IF NOT EXISTS "+quoteIdent(name+"_source_idx")+" ON "+table+" (source)",
+		"CREATE INDEX IF NOT EXISTS "+quoteIdent(name+"_embedding_idx")+" ON "+table+" USING hnsw (embedding vector_cosine_ops)",
+	)
+	for _, col := range s.MetadataColumns {
+		stmts = append(stmts, "CREATE INDEX IF NOT EXISTS "+quoteIdent(name+"_"+col+"_idx")+" ON "+table+" ("+quoteIdent(col)+")")
+	}
+	for _, stmt := range stmts {
+		if _, err := s.DB.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("create schema: %w", err)
+		}
+	}
+	s.ready = true
+	return nil
+}
+
+// quoteIdent quotes name as a Postgres identifier, doubling its quotes
+// and dropping NUL bytes as pgx.Identifier.Sanitize does.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(name, "\x00", ""), `"`, `""`) + `"`
+}
+
+// vectorLiteral formats v as pgvector text input, such as "[1,0.5]".
+func vectorLiteral(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+// storeSource returns the source a document's chunks are stored under.
+func storeSource(doc *Document) string {
+	if doc.Provenance.SourceURI != "" {
+		return doc.Provenance.SourceURI
+	}
+	return doc.Source
+}
+
+// storeChunkID returns the ID c is stored under: c.ID, as ChunkDocument
+// assigned it and as ParentID, PrevID and NextID refer to it, or
+// ChunkID(source, c) for chunks without one.
+func storeChunkID(source string, c Chunk) string {
+	if c.ID != "" {
+		return c.ID
+	}
+	return ChunkID(source, c)
+}
+
+// chunkStoreMetadata merges the metadata of doc and c, c winning, with the
+// chunk's section, pages and links to its parent and neighbours, for
+// storing alongside its vector.
+func chunkStoreMetadata(doc *Document, c Chunk) map[string]string {
+	out := make(map[string]string, len(doc.Metadata)+len(c.Metadata)+5)
+	for k, v := range doc.Metadata {
+		out[k] = v
+	}
+	for k, v := range c.Metadata {
+		out[k] = v
+	}
+	if c.Provenance.Section != "" {
+		out["section"] = c.Provenance.Section
+	}
+	if c.Provenance.Pages != "" {
+		out["pages"] = c.Provenance.Pages
+	}
+	for k, v := range map[string]string{"parent_id": c.ParentID, "prev_id": c.PrevID, "next_id": c.NextID} {
+		if v != "" {
+			out[k] = v
+		}
+	}
+	return out
+}
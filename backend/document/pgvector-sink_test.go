@@ -0,0 +1,359 @@
+package document
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingDriver is a database/sql driver that accepts every statement
+// and records it with its arguments, for testing SQL sinks without a
+// database.
+type recordingDriver struct {
+	mu    sync.Mutex
+	execs []recordedExec
+}
+
+type recordedExec struct {
+	query string
+	args  []any
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) { return recordingConn{d}, nil }
+
+func (d *recordingDriver) queries() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var out []string
+	for _, e := range d.execs {
+		out = append(out, e.query)
+	}
+	return out
+}
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return recordingStmt{c.d, query}, nil
+}
+func (c recordingConn) Close() error              { return nil }
+func (c recordingConn) Begin() (driver.Tx, error) { return recordingTx{c.d}, nil }
+
+type recordingTx struct{ d *recordingDriver }
+
+func (tx recordingTx) Commit() error   { tx.d.record("COMMIT", nil); return nil }
+func (tx recordingTx) Rollback() error { return nil }
+
+type recordingStmt struct {
+	d     *recordingDriver
+	query string
+}
+
+func (s recordingStmt) Close() error  { return nil }
+func (s recordingStmt) NumInput() int { return -1 }
+func (s recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.record(s.query, args)
+	return driver.RowsAffected(1), nil
+}
+func (s recordingStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.record(s.query, args)
+	return emptyRows{}, nil
+}
+
+func (d *recordingDriver) record(query string, args []driver.Value) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e := recordedExec{query: query}
+	for _, a := range args {
+		e.args = append(e.args, a)
+	}
+	d.execs = append(d.execs, e)
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+var recordingDrivers = map[string]*recordingDriver{}
+
+// openRecordingDB returns a database backed by a new recordingDriver.
+func openRecordingDB(t *testing.T) (*sql.DB, *recordingDriver) {
+	t.Helper()
+	name := fmt.Sprintf("recording-%s", t.Name())
+	d, ok := recordingDrivers[name]
+	if !ok {
+		d = &recordingDriver{}
+		recordingDrivers[name] = d
+		sql.Register(name, d)
+	}
+	d.execs = nil
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, d
+}
+
+func TestPgVectorSink(t *testing.T) {
+	db, rec := openRecordingDB(t)
+	sink := NewPgVectorSink(db, "docs")
+	sink.MetadataColumns = []string{"lang"}
+	sink.ReplaceDocument = true
+	doc := &Document{Source: "a.md", Metadata: map[string]string{"lang": "en", "title": "A"}}
+	chunks := []Chunk{
+		{Index: 0, Text: "one", Embedding: []float32{1, 0.5}, Provenance: Provenance{Section: "Intro", Pages: "1"}},
+		{Index: 1, Text: "two", Embedding: []float32{0, -2}, Metadata: map[string]string{"lang": "de"}},
+	}
+	ctx := context.Background()
+	if err := sink.Write(ctx, doc, chunks); err != nil {
+		t.Fatal(err)
+	}
+	queries := rec.queries()
+	want := []string{
+		"CREATE EXTENSION IF NOT EXISTS vector",
+		`CREATE TABLE IF NOT EXISTS "docs" (id text PRIMARY KEY, source text NOT NULL, chunk_index integer NOT NULL, content text NOT NULL, metadata jsonb, embedding vector(2) NOT NULL, "lang" text)`,
+		`CREATE INDEX IF NOT EXISTS "docs_source_idx" ON "docs" (source)`,
+		`CREATE INDEX IF NOT EXISTS "docs_embedding_idx" ON "docs" USING hnsw (embedding vector_cosine_ops)`,
+		`CREATE INDEX IF NOT EXISTS "docs_lang_idx" ON "docs" ("lang")`,
+		`DELETE FROM "docs" WHERE source = $1`,
+	}
+	if len(queries) != len(want)+3 || !reflect.DeepEqual(queries[:len(want)], want) {
+		t.Fatalf("queries:\n%s", strings.Join(queries, "\n"))
+	}
+	if !strings.HasPrefix(queries[len(want)], `INSERT INTO "docs" (id, source, chunk_index, content, metadata, embedding, "lang") VALUES ($1, $2, $3, $4, $5, $6::vector, $7) ON CONFLICT (id) DO UPDATE SET `) {
+		t.Errorf("upsert %s", queries[len(want)])
+	}
+	if queries[len(queries)-1] != "COMMIT" {
+		t.Errorf("last statement %s, want COMMIT", queries[len(queries)-1])
+	}
+
+	first := rec.execs[len(want)].args
+	if first[0] != ChunkID("a.md", chunks[0]) || first[1] != "a.md" || first[3] != "one" || first[5] != "[1,0.5]" || first[6] != "en" {
+		t.Errorf("first row %v", first)
+	}
+	if !strings.Contains(first[4].(string), `"section":"Intro"`) || !strings.Contains(first[4].(string), `"pages":"1"`) {
+		t.Errorf("first row metadata %v", first[4])
+	}
+	if second := rec.execs[len(want)+1].args; second[5] != "[0,-2]" || second[6] != "de" {
+		t.Errorf("second row %v", second)
+	}
+
+	// The schema is created once.
+	rec.execs = nil
+	if err := sink.Write(ctx, doc, chunks[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if q := rec.queries(); len(q) != 3 || !strings.HasPrefix(q[0], "DELETE") {
+		t.Errorf("second write:\n%s", strings.Join(q, "\n"))
+	}
+}
+
+func TestPgVectorSinkErrors(t *testing.T) {
+	db, _ := openRecordingDB(t)
+	ctx := context.Background()
+	doc := &Document{Source: "a.md"}
+	chunk := Chunk{Text: "one", Embedding: []float32{1}}
+
+	if err := NewPgVectorSink(db, "").Write(ctx, doc, nil); err != nil {
+		t.Errorf("no chunks: %v", err)
+	}
+	if err := NewPgVectorSink(db, "").Write(ctx, doc, []Chunk{{Text: "one"}}); err == nil {
+		t.Error("chunk without embedding returned no error")
+	}
+	if err := NewPgVectorSink(db, `docs"; DROP TABLE x; --`).Write(ctx, doc, []Chunk{chunk}); err == nil {
+		t.Error("invalid table name returned no error")
+	}
+}
+
+func TestPgVectorSinkMetadataColumns(t *testing.T) {
+	ctx := context.Background()
+	doc := &Document{Source: "a.md"}
+	chunk := Chunk{Text: "one", Embedding: []float32{1}}
+	for _, cols := range [][]string{
+		{"lang-code"},
+		{"Lang"},
+		{"1lang"},
+		{`lang" text); DROP TABLE x; --`},
+		{"id"},
+		{"source"},
+		{"content"},
+		{"embedding"},
+		{"lang", "team", "lang"},
+	} {
+		db, rec := openRecordingDB(t)
+		sink := NewPgVectorSink(db, "docs")
+		sink.MetadataColumns = cols
+		if err := sink.Write(ctx, doc, []Chunk{chunk}); err == nil {
+			t.Errorf("metadata columns %q returned no error", cols)
+		}
+		if q := rec.queries(); len(q) != 0 {
+			t.Errorf("metadata columns %q ran:\n%s", cols, strings.Join(q, "\n"))
+		}
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	for name, want := range map[string]string{
+		"docs":        `"docs"`,
+		`a"b`:         `"a""b"`,
+		"a\x00b":      `"ab"`,
+		`x"; DROP --`: `"x""; DROP --"`,
+	} {
+		if got := quoteIdent(name); got != want {
+			t.Errorf("quoteIdent(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestVectorLiteral(t *testing.T) {
+	if got := vectorLiteral([]float32{0.25, -1, 3e-8}); got != "[0.25,-1,3e-08]" {
+		t.Errorf("vectorLiteral = %s", got)
+	}
+	if got := vectorLiteral(nil); got != "[]" {
+		t.Errorf("empty vectorLiteral = %s", got)
+	}
+}
+
+// linkedChunks returns hierarchical chunks of a document whose provenance
+// URI differs from its source, as loaders such as NotionLoader set it.
+func linkedChunks(t *testing.T) (*Document, []Chunk) {
+	t.Helper()
+	doc := &Document{
+		Content:    strings.Repeat("A sentence about parents and children. ", 60),
+		Source:     "notes.md",
+		Provenance: Provenance{SourceURI: "notion://workspace/notes"},
+	}
+	chunks, err := ChunkDocument("hierarchical", doc, Options{ChunkSize: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range chunks {
+		chunks[i].Embedding = []float32{1, 0}
+	}
+	return doc, chunks
+}
+
+func TestStoreMetadataLinks(t *testing.T) {
+	doc, chunks := linkedChunks(t)
+	children := 0
+	for _, c := range chunks {
+		if id := storeChunkID(storeSource(doc), c); id != c.ID {
+			t.Fatalf("chunk %d stored as %s, assigned %s", c.Index, id, c.ID)
+		}
+		if id := newJobChunk(doc, c).ID; id != c.ID {
+			t.Fatalf("chunk %d emitted as %s, assigned %s", c.Index, id, c.ID)
+		}
+		meta := chunkStoreMetadata(doc, c)
+		for key, want := range map[string]string{"parent_id": c.ParentID, "prev_id": c.PrevID, "next_id": c.NextID} {
+			if meta[key] != want {
+				t.Errorf("chunk %d: %s = %q, want %q", c.Index, key, meta[key], want)
+			}
+		}
+		if c.ParentID != "" {
+			children++
+		}
+	}
+	if children == 0 {
+		t.Fatal("no child chunks to check links on")
+	}
+	if id := storeChunkID("a.txt", Chunk{Text: "x"}); id != ChunkID("a.txt", Chunk{Text: "x"}) {
+		t.Errorf("chunk without ID stored as %s", id)
+	}
+}
+
+func TestSinksStoreAssignedIDs(t *testing.T) {
+	doc, chunks := linkedChunks(t)
+	want := map[string]bool{}
+	for _, c := range chunks {
+		want[c.ID] = true
+	}
+
+	var mu sync.Mutex
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{}`)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		sink Sink
+		ids  func(body string) []string
+	}{
+		{"pinecone", NewPineconeSink(srv.URL, "key"), func(body string) []string {
+			var req struct {
+				Vectors []struct{ ID string } `json:"vectors"`
+			}
+			json.Unmarshal([]byte(body), &req)
+			var ids []string
+			for _, v := range req.Vectors {
+				ids = append(ids, v.ID)
+			}
+			return ids
+		}},
+		{"qdrant", NewQdrantSink(srv.URL, "docs"), func(body string) []string {
+			var req struct {
+				Points []struct {
+					ID      string
+					Payload struct {
+						ChunkID string `json:"chunk_id"`
+					}
+				} `json:"points"`
+			}
+			json.Unmarshal([]byte(body), &req)
+			var ids []string
+			for _, p := range req.Points {
+				if p.ID != chunkUUID(p.Payload.ChunkID) {
+					t.Errorf("qdrant point %s does not match its chunk_id %s", p.ID, p.Payload.ChunkID)
+				}
+				ids = append(ids, p.Payload.ChunkID)
+			}
+			return ids
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bodies = nil
+			if err := tt.sink.Write(context.Background(), doc, chunks); err != nil {
+				t.Fatal(err)
+			}
+			got := 0
+			for _, body := range bodies {
+				for _, id := range tt.ids(body) {
+					if !want[id] {
+						t.Errorf("stored ID %s is not a chunk ID", id)
+					}
+					got++
+				}
+			}
+			if got != len(chunks) {
+				t.Errorf("stored %d chunks, want %d", got, len(chunks))
+			}
+		})
+	}
+}
+
+func TestChunkUUIDOfOtherIDs(t *testing.T) {
+	for _, id := range []string{"p0", "c-1", strings.Repeat("z", 40)} {
+		if u := chunkUUID(id); len(u) != 36 {
+			t.Errorf("chunkUUID(%q) = %q", id, u)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// pineconeMetadataLimit is the largest metadata Pinecone stores per
+// vector, in bytes of JSON.
+const pineconeMetadataLimit = 40 * 1024
+
+// PineconeSink is a Sink that upserts chunks into a Pinecone index, with
+// the chunk text and flattened metadata as the vector's metadata. Vector
+// IDs are ChunkIDs, so writing an unchanged document again overwrites its
+// vectors.
+type PineconeSink struct {
+	// Host is the index host shown in the Pinecone console, such as
+	// https://docs-abc123.svc.us-east-1.pinecone.io.
+	Host string
+	// APIKey is sent in the Api-Key header.
+	APIKey string
+	// Namespace partitions the index. Defaults to the default namespace.
+	Namespace string
+	// MaxMetadataBytes limits the metadata of each vector. Defaults to
+	// Pinecone's limit of 40 KB.
+	MaxMetadataBytes int
+	// Spill, when set, receives the full metadata of a chunk whose
+	// metadata is too large, for storing elsewhere; the vector then keeps
+	// only its source, index and a "spilled" flag. When Spill is nil the
+	// text and then the longest values are truncated to fit instead.
+	Spill func(ctx context.Context, id string, metadata map[string]any) error
+	// BatchSize is the number of vectors sent per request. Defaults to
+	// 100.
+	BatchSize int
+	// MaxRetries is the number of times a failed request is retried, with
+	// exponential backoff. Defaults to 3; negative turns retries off.
+	MaxRetries int
+	// Client defaults to an http.Client with a one minute timeout.
+	Client *http.Client
+}
+
+// NewPineconeSink creates a sink that writes to the index at host.
+func NewPineconeSink(host, apiKey string) *PineconeSink {
+	return &PineconeSink{Host: host, APIKey: apiKey}
+}
+
+type pineconeVector struct {
+	ID       string         `json:"id"`
+	Values   []float32      `json:"values"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+// Write upserts the chunks of doc, in batches. Every chunk must have an
+// Embedding; see Pipeline.Embed.
+func (s *PineconeSink) Write(ctx context.Context, doc *Document, chunks []Chunk) error {
+	limit := s.MaxMetadataBytes
+	if limit <= 0 {
+		limit = pineconeMetadataLimit
+	}
+	source := storeSource(doc)
+	vectors := make([]pineconeVector, len(chunks))
+	for i, c := range chunks {
+		if len(c.Embedding) == 0 {
+			return fmt.Errorf("chunk %d has no embedding", i)
+		}
+		id := storeChunkID(source, c)
+		metadata := map[string]any{}
+		for k, v := range chunkStoreMetadata(doc, c) {
+			metadata[k] = v
+		}
+		metadata["source"] = source
+		metadata["chunk_index"] = c.Index
+		metadata["text"] = c.Text
+		if metadataSize(metadata) > limit {
+			if s.Spill != nil {
+				if err := s.Spill(ctx, id, metadata); err != nil {
+					return fmt.Errorf("spill metadata of chunk %d: %w", c.Index, err)
+				}
+				metadata = map[string]any{"source": source, "chunk_index": c.Index, "spilled": true}
+			} else {
+				fitMetadata(metadata, limit)
+			}
+		}
+		vectors[i] = pineconeVector{ID: id, Values: c.Embedding, Metadata: metadata}
+	}
+
+	batchSize := s.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	header := http.Header{}
+	header.Set("Api-Key", s.APIKey)
+	header.Set("X-Pinecone-API-Version", "2024-07")
+	rp := retryPolicy{maxRetries: s.MaxRetries}
+	endpoint := strings.TrimSuffix(s.Host, "/") + "/vectors/upsert"
+	for start := 0; start < len(vectors); start += batchSize {
+		body := map[string]any{"vectors": vectors[start:min(start+batchSize, len(vectors))]}
+		if s.Namespace != "" {
+			body["namespace"] = s.Namespace
+		}
+		if _, err := rp.sendJSON(ctx, httpClient(s.Client, time.Minute), http.MethodPost, endpoint, header, body); err != nil {
+			return fmt.Errorf("upsert vectors: %w", err)
+		}
+	}
+	return nil
+}
+
+// metadataSize returns the length of metadata as JSON.
+func metadataSize(metadata map[string]any) int {
+	data, _ := json.Marshal(metadata)
+	return len(data)
+}
+
+// fitMetadata shrinks metadata to at most limit bytes of JSON: first the
+// text, then other string values, longest first, are cut short, keeping
+// the source and chunk index.
+func fitMetadata(metadata map[string]any, limit int) {
+	keys := make([]string, 0, len(metadata))
+	for k, v := range metadata {
+		if _, ok := v.(string); ok && k != "source" && k != "text" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return len(metadata[keys[i]].(string)) > len(metadata[keys[j]].(string))
+	})
+	for _, k := range append([]string{"text"}, keys...) {
+		v, _ := metadata[k].(string)
+		// Escaping makes JSON longer than the string, so cut again until
+		// it fits.
+		for over := metadataSize(metadata) - limit; over > 0 && v != ""; over = metadataSize(metadata) - limit {
+			v = truncateUTF8(v, len(v)-over)
+			metadata[k] = v
+		}
+	}
+}
+
+// truncateUTF8 returns the longest prefix of s of at most n bytes that
+// does not split a character.
+func truncateUTF8(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if n >= len(s) {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
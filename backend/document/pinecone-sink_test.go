@@ -0,0 +1,102 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPineconeSink(t *testing.T) {
+	type upsert struct {
+		Namespace string           `json:"namespace"`
+		Vectors   []pineconeVector `json:"vectors"`
+	}
+	var upserts []upsert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/vectors/upsert" || r.Header.Get("Api-Key") != "key" || r.Header.Get("X-Pinecone-API-Version") == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		var req upsert
+		json.NewDecoder(r.Body).Decode(&req)
+		upserts = append(upserts, req)
+		w.Write([]byte(`{"upsertedCount": 1}`))
+	}))
+	defer srv.Close()
+
+	doc := &Document{Source: "a.md", Metadata: map[string]string{"lang": "en"}}
+	long := strings.Repeat("é", 200)
+	chunks := []Chunk{
+		{Index: 0, Text: "one", Embedding: []float32{1, 0}},
+		{Index: 1, Text: long, Embedding: []float32{0, 1}, Metadata: map[string]string{"note": long}},
+	}
+	sink := NewPineconeSink(srv.URL+"/", "key")
+	sink.Namespace = "docs"
+	sink.BatchSize = 1
+	sink.MaxMetadataBytes = 300
+	ctx := context.Background()
+	if err := sink.Write(ctx, doc, chunks); err != nil {
+		t.Fatal(err)
+	}
+	if len(upserts) != 2 || upserts[0].Namespace != "docs" || len(upserts[0].Vectors) != 1 {
+		t.Fatalf("upserts %+v", upserts)
+	}
+	v := upserts[0].Vectors[0]
+	if v.ID != ChunkID("a.md", chunks[0]) || v.Metadata["text"] != "one" || v.Metadata["source"] != "a.md" ||
+		v.Metadata["chunk_index"] != 0.0 || v.Metadata["lang"] != "en" {
+		t.Errorf("vector %+v", v)
+	}
+	fitted := upserts[1].Vectors[0].Metadata
+	if metadataSize(fitted) > 300 || fitted["source"] != "a.md" || fitted["lang"] != "en" {
+		t.Errorf("fitted metadata of %d bytes: %v", metadataSize(fitted), fitted)
+	}
+	if text := fitted["text"].(string); text != "" || !utf8.ValidString(fitted["note"].(string)) {
+		t.Errorf("fitted text %q, note %q", text, fitted["note"])
+	}
+
+	upserts = nil
+	spilled := map[string]map[string]any{}
+	sink.Spill = func(ctx context.Context, id string, metadata map[string]any) error {
+		spilled[id] = metadata
+		return nil
+	}
+	if err := sink.Write(ctx, doc, chunks); err != nil {
+		t.Fatal(err)
+	}
+	id := ChunkID("a.md", chunks[1])
+	if spilled[id]["text"] != long || len(spilled) != 1 {
+		t.Errorf("spilled %v", spilled)
+	}
+	if m := upserts[1].Vectors[0].Metadata; m["spilled"] != true || m["text"] != nil || m["source"] != "a.md" {
+		t.Errorf("spilled vector metadata %v", m)
+	}
+
+	if err := sink.Write(ctx, doc, []Chunk{{Text: "one"}}); err == nil {
+		t.Error("chunk without embedding returned no error")
+	}
+	if err := NewPineconeSink(srv.URL, "wrong").Write(ctx, doc, chunks[:1]); err == nil {
+		t.Error("rejected request returned no error")
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"héllo", 2, "h"},
+		{"héllo", 3, "hé"},
+		{"héllo", 10, "héllo"},
+		{"héllo", 0, ""},
+		{"héllo", -1, ""},
+	} {
+		if got := truncateUTF8(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncateUTF8(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,401 @@
+package document
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+// Loader fetches the bytes of a source, such as a file path or URL, and
+// the file name to parse them under.
+type Loader interface {
+	Load(ctx context.Context, source string) (buffer []byte, filename string, err error)
+}
+
+// LoaderFunc adapts a function to the Loader interface.
+type LoaderFunc func(ctx context.Context, source string) ([]byte, string, error)
+
+// Load calls f(ctx, source).
+func (f LoaderFunc) Load(ctx context.Context, source string) ([]byte, string, error) {
+	return f(ctx, source)
+}
+
+// Fetched is a source loaded along with what its loader knows about it.
+type Fetched struct {
+	Buffer   []byte
+	Filename string
+	// MIMEType, when set, picks the parser instead of DetectMIME, such as
+	// from the Content-Type of an HTTP response.
+	MIMEType string
+	// URI is recorded as the provenance SourceURI when set, and FetchedAt
+	// as the provenance FetchedAt.
+	URI       string
+	FetchedAt time.Time
+	// Version is recorded as Document.Version, such as a commit hash.
+	Version string
+	// Metadata is added to Document.Metadata, overriding parser values.
+	Metadata map[string]string
+
+	// release unmaps a Buffer mapped by FileLoader.
+	release func() error
+}
+
+// Close releases Buffer when it is a memory-mapped file, after which
+// neither it nor the bytes of documents parsed from it, such as
+// Attachment.Data, may be used. It does nothing for other sources.
+func (f *Fetched) Close() error {
+	if f.release == nil {
+		return nil
+	}
+	release := f.release
+	f.release, f.Buffer = nil, nil
+	return release()
+}
+
+// Fetcher is implemented by loaders that know more about a source than
+// its bytes. Pipeline calls Fetch instead of Load when it is available.
+type Fetcher interface {
+	Fetch(ctx context.Context, source string) (*Fetched, error)
+}
+
+// fetch loads source with l, through Fetch when l implements Fetcher.
+func fetch(ctx context.Context, l Loader, source string) (*Fetched, error) {
+	if f, ok := l.(Fetcher); ok {
+		return f.Fetch(ctx, source)
+	}
+	buffer, filename, err := l.Load(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	return &Fetched{Buffer: buffer, Filename: filename}, nil
+}
+
+// Lister is implemented by loaders that can enumerate their sources, such
+// as the object stores, for Pipeline.RunPrefix.
+type Lister interface {
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// FileLoader loads sources as paths on the local file system.
+type FileLoader struct {
+	// MaxSize rejects files larger than this many bytes, before reading
+	// them, when positive.
+	MaxSize int64
+	// Mmap makes Fetch map files into memory instead of reading them, so
+	// that multi-GB files are parsed from the page cache rather than a
+	// copy on the heap. The file must not change while it is mapped. On
+	// systems without mmap, and for Load, files are read as usual.
+	Mmap bool
+}
+
+// Load reads the file at source.
+func (l FileLoader) Load(ctx context.Context, source string) ([]byte, string, error) {
+	if err := l.checkSize(source); err != nil {
+		return nil, "", err
+	}
+	buffer, err := os.ReadFile(source)
+	if err != nil {
+		return nil, "", err
+	}
+	return buffer, source, nil
+}
+
+// Fetch loads the file at source like Load, or maps it when Mmap is set.
+// Call Close on the result to unmap it once it is processed; Pipeline
+// does so itself.
+func (l FileLoader) Fetch(ctx context.Context, source string) (*Fetched, error) {
+	if !l.Mmap {
+		buffer, filename, err := l.Load(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		return &Fetched{Buffer: buffer, Filename: filename}, nil
+	}
+	if err := l.checkSize(source); err != nil {
+		return nil, err
+	}
+	buffer, release, err := mmapFile(source)
+	if err != nil {
+		return nil, err
+	}
+	return &Fetched{Buffer: buffer, Filename: source, release: release}, nil
+}
+
+// withMaxSize returns l limited to maxSize bytes, unless it has a limit
+// of its own.
+func (l FileLoader) withMaxSize(maxSize int64) Loader {
+	if l.MaxSize <= 0 {
+		l.MaxSize = maxSize
+	}
+	return l
+}
+
+// checkSize rejects source when it is over MaxSize.
+func (l FileLoader) checkSize(source string) error {
+	if l.MaxSize <= 0 {
+		return nil
+	}
+	info, err := os.Stat(source)
+	if err != nil {
+		return err
+	}
+	return (Limits{MaxInputBytes: l.MaxSize}).checkInput(source, info.Size())
+}
+
+// Sink receives the chunks of each document a Pipeline processes, for
+// example to write them and their embeddings to a vector store.
+type Sink interface {
+	Write(ctx context.Context, doc *Document, chunks []Chunk) error
+}
+
+// SinkFunc adapts a function to the Sink interface.
+type SinkFunc func(ctx context.Context, doc *Document, chunks []Chunk) error
+
+// Write calls f(ctx, doc, chunks).
+func (f SinkFunc) Write(ctx context.Context, doc *Document, chunks []Chunk) error {
+	return f(ctx, doc, chunks)
+}
+
+// Pipeline is an ingestion flow that loads each source, parses it, passes
+// the document through transformers, chunks it, passes the chunks through
+// chunk transformers, optionally embeds them and hands them to its sinks.
+// Build one with NewPipeline and the chained setters:
+//
+//	p := NewPipeline().
+//		Transform(NewNormalizer(), NewRedactor()).
+//		TransformChunks(QualityFilter{}).
+//		Chunker("markdown", WithChunkSize(300)).
+//		Embed(NewOpenAIEmbedder(key)).
+//		To(store)
+//	err := p.Run(ctx, "a.md", "b.pdf")
+type Pipeline struct {
+	loader     Loader
+	registry   *Registry
+	parser     Parser
+	transforms []Transformer
+	chunkSteps []ChunkTransformer
+	strategy   string
+	opts       Options
+	sinks      []Sink
+	progress   ProgressFunc
+	limits     Limits
+	embedder   Embedder
+	webhooks   []*Webhook
+}
+
+// NewPipeline creates a pipeline that loads files with FileLoader, parses
+// them with DefaultRegistry and chunks them with the "recursive" strategy,
+// within DefaultLimits.
+func NewPipeline() *Pipeline {
+	return &Pipeline{loader: FileLoader{}, registry: DefaultRegistry, strategy: "recursive", limits: DefaultLimits}
+}
+
+// LoadWith sets the loader of sources.
+func (p *Pipeline) LoadWith(l Loader) *Pipeline {
+	p.loader = l
+	return p
+}
+
+// ParseWith parses every source with parser instead of the registry.
+func (p *Pipeline) ParseWith(parser Parser) *Pipeline {
+	p.parser = parser
+	return p
+}
+
+// Registry picks parsers for sources from r by MIME type.
+func (p *Pipeline) Registry(r *Registry) *Pipeline {
+	p.registry, p.parser = r, nil
+	return p
+}
+
+// Transform appends transformers that edit or replace each parsed
+// document before chunking. Transformers run in the order added.
+func (p *Pipeline) Transform(t ...Transformer) *Pipeline {
+	p.transforms = append(p.transforms, t...)
+	return p
+}
+
+// TransformChunks appends transformers that edit or filter the chunks of
+// each document before they reach the sinks. They run in the order added.
+func (p *Pipeline) TransformChunks(t ...ChunkTransformer) *Pipeline {
+	p.chunkSteps = append(p.chunkSteps, t...)
+	return p
+}
+
+// Chunker sets the chunking strategy and its options.
+func (p *Pipeline) Chunker(strategy string, opts ...ChunkOption) *Pipeline {
+	p.strategy, p.opts = strategy, NewOptions(opts...)
+	return p
+}
+
+// Embed sets an embedder that fills in the Embedding of every chunk after
+// the chunk transformers run and before the sinks.
+func (p *Pipeline) Embed(e Embedder) *Pipeline {
+	p.embedder = e
+	return p
+}
+
+// Limits sets the resource limits of each document.
+func (p *Pipeline) Limits(l Limits) *Pipeline {
+	p.limits = l
+	return p
+}
+
+// To appends a sink for the chunks. Sinks run in the order added.
+func (p *Pipeline) To(s Sink) *Pipeline {
+	p.sinks = append(p.sinks, s)
+	return p
+}
+
+// OnProgress sets a function Run calls as each source starts and
+// finishes.
+func (p *Pipeline) OnProgress(f ProgressFunc) *Pipeline {
+	p.progress = f
+	return p
+}
+
+// Run loads and processes every source in turn, stopping at the first
+// failure.
+func (p *Pipeline) Run(ctx context.Context, sources ...string) error {
+	progress := newProgressTracker(p.progress, len(sources))
+	loader := p.sourceLoader()
+	start, files, total := time.Now(), 0, 0
+	for _, source := range sources {
+		files++
+		src, err := fetch(ctx, loader, source)
+		if err != nil {
+			err = fmt.Errorf("load %s: %w", source, err)
+			progress.done(0, err)
+			p.notifyDocument(ctx, source, nil, 0, err)
+			p.notifyBatch(ctx, files, 1, total, start)
+			return err
+		}
+		progress.begin(src.Filename, len(src.Buffer))
+		doc, chunks, err := p.process(ctx, src)
+		src.Close()
+		progress.done(len(chunks), err)
+		p.notifyDocument(ctx, source, doc, len(chunks), err)
+		if err != nil {
+			p.notifyBatch(ctx, files, 1, total, start)
+			return err
+		}
+		total += len(chunks)
+	}
+	p.notifyBatch(ctx, files, 0, total, start)
+	return nil
+}
+
+// RunPrefix processes every source the loader lists under prefix, such as
+// the objects of a bucket whose keys start with it. The loader must
+// implement Lister.
+func (p *Pipeline) RunPrefix(ctx context.Context, prefix string) error {
+	l, ok := p.loader.(Lister)
+	if !ok {
+		return fmt.Errorf("loader %T cannot list sources", p.loader)
+	}
+	sources, err := l.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	return p.Run(ctx, sources...)
+}
+
+// sizeLimiter is implemented by loaders that can reject sources over a
+// size before reading them into memory.
+type sizeLimiter interface {
+	withMaxSize(maxSize int64) Loader
+}
+
+// sourceLoader returns the loader, made to check source sizes against the
+// limits before reading them into memory.
+func (p *Pipeline) sourceLoader() Loader {
+	if l, ok := p.loader.(sizeLimiter); ok && p.limits.MaxInputBytes > 0 {
+		return l.withMaxSize(p.limits.MaxInputBytes)
+	}
+	return p.loader
+}
+
+// Process runs buffer, loaded as filename, through the pipeline after the
+// loader, and returns the document and chunks it gave the sinks.
+func (p *Pipeline) Process(ctx context.Context, buffer []byte, filename string) (*Document, []Chunk, error) {
+	return p.process(ctx, &Fetched{Buffer: buffer, Filename: filename})
+}
+
+// process does the work of Process for a fetched source.
+func (p *Pipeline) process(ctx context.Context, src *Fetched) (*Document, []Chunk, error) {
+	filename := src.Filename
+	doc, err := p.parse(ctx, src)
+	if err != nil {
+		return nil, nil, err
+	}
+	chunks, err := ChunkContext(ctx, p.strategy, doc, p.opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("chunk %s: %w", filename, err)
+	}
+	if err := p.limits.checkChunks(filename, len(chunks)); err != nil {
+		return nil, nil, err
+	}
+	for _, t := range p.chunkSteps {
+		if chunks, err = t.TransformChunks(doc, chunks); err != nil {
+			return nil, nil, fmt.Errorf("transform chunks of %s: %w", filename, err)
+		}
+	}
+	if p.embedder != nil && len(chunks) > 0 {
+		if err := EmbedChunks(ctx, p.embedder, chunks); err != nil {
+			return nil, nil, fmt.Errorf("embed %s: %w", filename, err)
+		}
+	}
+	for _, s := range p.sinks {
+		if err := s.Write(ctx, doc, chunks); err != nil {
+			return nil, nil, fmt.Errorf("write %s: %w", filename, err)
+		}
+	}
+	return doc, chunks, nil
+}
+
+// parse parses and transforms buffer, the stages of Process before
+// chunking.
+func (p *Pipeline) parse(ctx context.Context, src *Fetched) (*Document, error) {
+	buffer, filename := src.Buffer, src.Filename
+	if err := p.limits.checkInput(filename, int64(len(buffer))); err != nil {
+		return nil, err
+	}
+	parser := p.parser
+	if parser == nil {
+		mimeType := src.MIMEType
+		if mimeType == "" || p.registry.ParserFor(mimeType) == nil {
+			mimeType = DetectMIME(buffer, filename)
+		}
+		if parser = p.registry.ParserFor(mimeType); parser == nil {
+			return nil, fmt.Errorf("%w: %s (%s)", ErrUnsupportedType, mimeType, path.Base(filename))
+		}
+	}
+	parser = p.limits.parser(parser)
+	doc, err := ParseContext(ctx, parser, buffer, filename)
+	if err != nil {
+		return nil, err
+	}
+	doc.Provenance = newProvenance(parser, filename, src.URI)
+	doc.Provenance.FetchedAt = src.FetchedAt
+	if src.Version != "" {
+		doc.Version = src.Version
+	}
+	if len(src.Metadata) > 0 {
+		if doc.Metadata == nil {
+			doc.Metadata = map[string]string{}
+		}
+		for k, v := range src.Metadata {
+			doc.Metadata[k] = v
+		}
+	}
+	doc.Checksum = Checksum(buffer)
+
+	for _, t := range p.transforms {
+		if doc, err = t.Transform(doc); err != nil {
+			return nil, fmt.Errorf("transform %s: %w", filename, err)
+		}
+	}
+	return doc, nil
+}
@@ -0,0 +1,144 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// mapLoader loads sources from a map of file contents.
+func mapLoader(files map[string]string) Loader {
+	return LoaderFunc(func(ctx context.Context, source string) ([]byte, string, error) {
+		content, ok := files[source]
+		if !ok {
+			return nil, "", fmt.Errorf("%s not found", source)
+		}
+		return []byte(content), source, nil
+	})
+}
+
+// collectSink records what it is given.
+type collectSink struct {
+	docs   []*Document
+	chunks [][]Chunk
+}
+
+func (s *collectSink) Write(ctx context.Context, doc *Document, chunks []Chunk) error {
+	s.docs = append(s.docs, doc)
+	s.chunks = append(s.chunks, chunks)
+	return nil
+}
+
+func TestPipeline(t *testing.T) {
+	loader := mapLoader(map[string]string{
+		"a.md":  "# Title\n\nOne two three.\n\nFour five six.",
+		"b.txt": "Seven eight nine.",
+	})
+	sink := &collectSink{}
+	err := NewPipeline().
+		LoadWith(loader).
+		Chunker("paragraph", WithChunkSize(4)).
+		To(sink).
+		Run(context.Background(), "a.md", "b.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.docs) != 2 {
+		t.Fatalf("sink got %d documents", len(sink.docs))
+	}
+	md := sink.docs[0]
+	if md.Provenance.Parser != "MarkdownParser" || md.Provenance.SourceURI != "a.md" || md.Checksum != Checksum([]byte("# Title\n\nOne two three.\n\nFour five six.")) {
+		t.Errorf("markdown Provenance %+v, Checksum %q", md.Provenance, md.Checksum)
+	}
+	if got := chunkTexts(sink.chunks[0]); len(got) != 3 || got[2] != "Four five six." {
+		t.Errorf("markdown chunks = %q", got)
+	}
+	if sink.docs[1].Provenance.Parser != "TextParser" || len(sink.chunks[1]) != 1 || sink.chunks[1][0].ID == "" {
+		t.Errorf("text document %+v, chunks %+v", sink.docs[1].Provenance, sink.chunks[1])
+	}
+
+	// ParseWith overrides the registry.
+	doc, _, err := NewPipeline().ParseWith(NewTextParser()).Process(context.Background(), []byte("# Not a heading"), "a.md")
+	if err != nil || doc.Provenance.Parser != "TextParser" || len(doc.Headings) != 0 {
+		t.Errorf("ParseWith: %+v, %v", doc, err)
+	}
+
+	if err := NewPipeline().Run(context.Background(), fixture("test-md.md")); err != nil {
+		t.Errorf("FileLoader: %v", err)
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	loader := mapLoader(map[string]string{"a.txt": "Hello.", "b.bin": "\x00\x01\x02\xff"})
+	failing := SinkFunc(func(ctx context.Context, doc *Document, chunks []Chunk) error { return errors.New("disk full") })
+	tests := []struct {
+		name string
+		p    *Pipeline
+		src  string
+		want string
+		is   error
+	}{
+		{"load", NewPipeline().LoadWith(loader), "missing.txt", "load missing.txt: missing.txt not found", nil},
+		{"unsupported", NewPipeline().LoadWith(loader), "b.bin", "", ErrUnsupportedType},
+		{"strategy", NewPipeline().LoadWith(loader).Chunker("no-such-strategy"), "a.txt", "", nil},
+		{"sink", NewPipeline().LoadWith(loader).To(failing), "a.txt", "write a.txt: disk full", nil},
+	}
+	for _, tt := range tests {
+		err := tt.p.Run(context.Background(), tt.src)
+		switch {
+		case err == nil:
+			t.Errorf("%s: no error", tt.name)
+		case tt.want != "" && err.Error() != tt.want:
+			t.Errorf("%s: err = %q, want %q", tt.name, err, tt.want)
+		case tt.is != nil && !errors.Is(err, tt.is):
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.is)
+		}
+	}
+}
+
+func TestFileLoaderMmap(t *testing.T) {
+	ctx := context.Background()
+	name := fixture("test-txt.txt")
+	want := mustRead(t, name)
+	for _, mmap := range []bool{false, true} {
+		f, err := (FileLoader{Mmap: mmap}).Fetch(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(f.Buffer) != string(want) || f.Filename != name {
+			t.Errorf("Mmap %v: fetched %d bytes as %s", mmap, len(f.Buffer), f.Filename)
+		}
+		if err := f.Close(); err != nil || (mmap && f.Buffer != nil) {
+			t.Errorf("Mmap %v: Close = %v, %d bytes left", mmap, err, len(f.Buffer))
+		}
+		if err := f.Close(); err != nil {
+			t.Errorf("Mmap %v: second Close = %v", mmap, err)
+		}
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty.txt")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := (FileLoader{Mmap: true}).Fetch(ctx, empty); err != nil || len(f.Buffer) != 0 {
+		t.Errorf("empty file: %v", err)
+	}
+	if _, err := (FileLoader{Mmap: true, MaxSize: 10}).Fetch(ctx, name); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized file: %v", err)
+	}
+	if _, err := (FileLoader{Mmap: true}).Fetch(ctx, filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("missing file returned no error")
+	}
+
+	var chunks int
+	p := NewPipeline().LoadWith(FileLoader{Mmap: true}).To(SinkFunc(func(ctx context.Context, doc *Document, c []Chunk) error {
+		chunks += len(c)
+		return nil
+	}))
+	if err := p.Run(ctx, name); err != nil || chunks == 0 {
+		t.Errorf("mapped run: %v, %d chunks", err, chunks)
+	}
+}
@@ -0,0 +1,380 @@
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PPTXParser extracts slide titles, body text and speaker notes from
+// PowerPoint (.pptx) presentations.
+type PPTXParser struct {
+	// SkipNotes leaves speaker notes out of the extracted text.
+	SkipNotes bool
+	// MaxDecompressedBytes limits the bytes read from all the parts of a
+	// package together, so a ZIP bomb fails with ErrTooLarge. Defaults to
+	// 256 MB.
+	MaxDecompressedBytes int64
+}
+
+// NewPPTXParser creates a new PPTX parser instance.
+func NewPPTXParser() *PPTXParser {
+	return &PPTXParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *PPTXParser) Supports(mimeType string) bool {
+	return mimeType == "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+}
+
+// pptxSlide is the text extracted from one slide and its notes page.
+type pptxSlide struct {
+	number int
+	title  string
+	body   []string
+	notes  []string
+}
+
+// render lays out a slide as "# Title", the body lines, and a trailing
+// "Notes:" block.
+func (s *pptxSlide) render() string {
+	var parts []string
+	if s.title != "" {
+		parts = append(parts, "# "+s.title)
+	}
+	if len(s.body) > 0 {
+		parts = append(parts, strings.Join(s.body, "\n"))
+	}
+	if len(s.notes) > 0 {
+		parts = append(parts, "Notes:\n"+strings.Join(s.notes, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Parse returns all slides in a single document. Each slide is recorded in
+// Document.Pages and its title in Document.Headings.
+func (p *PPTXParser) Parse(buffer []byte, filename string) (*Document, error) {
+	zr, err := openZip(buffer, p.MaxDecompressedBytes)
+	if err != nil {
+		return nil, err
+	}
+	slides, err := p.slides(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	var pages []Page
+	var headings []Heading
+	for _, s := range slides {
+		text := s.render()
+		if text == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		start := b.Len()
+		if s.title != "" {
+			headings = append(headings, Heading{Level: 1, Text: s.title, Offset: start})
+		}
+		b.WriteString(text)
+		pages = append(pages, Page{Number: s.number, Start: start, End: b.Len()})
+	}
+
+	content := b.String()
+	if content == "" {
+		return nil, ErrEmptyDocument
+	}
+	doc := &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Pages:     pages,
+		Headings:  headings,
+		Metadata:  map[string]string{"slides": strconv.Itoa(len(slides))},
+	}
+	info := ooxmlCoreInfo(zr)
+	if err := zr.err(); err != nil {
+		return nil, err
+	}
+	return doc.withInfo(info), nil
+}
+
+// ParseAll returns one document per non-empty slide, with the 1-based slide
+// number in the "slide" metadata key so answers can cite it.
+func (p *PPTXParser) ParseAll(buffer []byte, filename string) ([]*Document, error) {
+	zr, err := openZip(buffer, p.MaxDecompressedBytes)
+	if err != nil {
+		return nil, err
+	}
+	slides, err := p.slides(zr)
+	if err != nil {
+		return nil, err
+	}
+	var docs []*Document
+	for _, s := range slides {
+		text := s.render()
+		if text == "" {
+			continue
+		}
+		doc := &Document{
+			Content:   text,
+			Source:    filename,
+			WordCount: len(strings.Fields(text)),
+			Pages:     []Page{{Number: s.number, Start: 0, End: len(text)}},
+			Metadata:  map[string]string{"slide": strconv.Itoa(s.number)},
+		}
+		if s.title != "" {
+			doc.Headings = []Heading{{Level: 1, Text: s.title}}
+			doc.Metadata["title"] = s.title
+		}
+		docs = append(docs, doc)
+	}
+	if err := zr.err(); err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrEmptyDocument
+	}
+	return docs, nil
+}
+
+// slides extracts every slide in presentation order.
+func (p *PPTXParser) slides(zr *zipPackage) ([]*pptxSlide, error) {
+	paths := pptxSlidePaths(zr)
+	if len(paths) == 0 {
+		return nil, errors.New("presentation has no slides")
+	}
+	slides := make([]*pptxSlide, 0, len(paths))
+	for i, part := range paths {
+		data, err := readZipEntry(zr, part)
+		if err != nil {
+			return nil, err
+		}
+		w := &pptxWalker{}
+		if err := w.walk(data); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", part, err)
+		}
+		s := &pptxSlide{number: i + 1, title: w.title, body: w.body}
+		if !p.SkipNotes {
+			for _, target := range ooxmlRels(zr, part) {
+				if !strings.Contains(target, "notesSlides/") {
+					continue
+				}
+				if notes, err := readZipEntry(zr, target); err == nil {
+					nw := &pptxWalker{notes: true}
+					if nw.walk(notes) == nil {
+						s.notes = nw.body
+					}
+				}
+			}
+		}
+		slides = append(slides, s)
+	}
+	return slides, nil
+}
+
+var pptxSlideName = regexp.MustCompile(`^ppt/slides/slide(\d+)\.xml$`)
+
+// pptxSlidePaths lists slide parts in the order of the presentation's slide
+// ID list, falling back to the slide file numbering.
+func pptxSlidePaths(zr *zipPackage) []string {
+	if data, err := readZipEntry(zr, "ppt/presentation.xml"); err == nil {
+		var pres struct {
+			IDs []struct {
+				Rel string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+			} `xml:"sldIdLst>sldId"`
+		}
+		rels := ooxmlRels(zr, "ppt/presentation.xml")
+		if xml.Unmarshal(data, &pres) == nil && rels != nil {
+			var paths []string
+			for _, id := range pres.IDs {
+				if target := rels[id.Rel]; target != "" {
+					paths = append(paths, target)
+				}
+			}
+			if len(paths) > 0 {
+				return paths
+			}
+		}
+	}
+	type numbered struct {
+		n    int
+		path string
+	}
+	var found []numbered
+	for _, f := range zr.File {
+		if m := pptxSlideName.FindStringSubmatch(f.Name); m != nil {
+			n, _ := strconv.Atoi(m[1])
+			found = append(found, numbered{n, f.Name})
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].n < found[j].n })
+	paths := make([]string, len(found))
+	for i, f := range found {
+		paths[i] = f.path
+	}
+	return paths
+}
+
+// pptxShape is a p:sp being streamed.
+type pptxShape struct {
+	placeholder string // ph type, "" when the shape is not a placeholder
+	isPH        bool
+	lines       []string
+}
+
+// pptxWalker streams a slide or notes part. Title placeholders become the
+// slide title; other shapes contribute body lines, with bulleted
+// paragraphs rendered as "-" list items. On notes pages only the body
+// placeholder is kept, which skips the slide thumbnail and page number.
+type pptxWalker struct {
+	notes bool
+	title string
+	body  []string
+
+	shape  *pptxShape
+	para   *strings.Builder
+	level  int
+	bullet int // 1 bulleted, -1 buNone, 0 inherited
+	inText int
+	cell   *strings.Builder
+	row    []string
+	rows   []string
+}
+
+func (w *pptxWalker) walk(data []byte) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			w.start(t)
+		case xml.EndElement:
+			w.end(t)
+		case xml.CharData:
+			if w.para != nil && w.inText > 0 {
+				w.para.Write(t)
+			}
+		}
+	}
+}
+
+func (w *pptxWalker) start(e xml.StartElement) {
+	switch e.Name.Local {
+	case "sp":
+		w.shape = &pptxShape{}
+	case "ph":
+		if w.shape != nil {
+			w.shape.isPH = true
+			w.shape.placeholder = docxAttr(e, "type")
+		}
+	case "p":
+		w.para = &strings.Builder{}
+		w.level, w.bullet = 0, 0
+	case "pPr":
+		w.level, _ = strconv.Atoi(docxAttr(e, "lvl"))
+	case "buNone":
+		w.bullet = -1
+	case "buChar", "buAutoNum", "buBlip":
+		w.bullet = 1
+	case "t":
+		w.inText++
+	case "br":
+		if w.para != nil {
+			w.para.WriteByte(' ')
+		}
+	case "tab":
+		if w.para != nil {
+			w.para.WriteByte('\t')
+		}
+	case "tr":
+		w.row = nil
+	case "tc":
+		w.cell = &strings.Builder{}
+	}
+}
+
+func (w *pptxWalker) end(e xml.EndElement) {
+	switch e.Name.Local {
+	case "t":
+		w.inText--
+	case "p":
+		if w.para == nil {
+			return
+		}
+		text := strings.Join(strings.Fields(w.para.String()), " ")
+		w.para = nil
+		if text == "" {
+			return
+		}
+		switch {
+		case w.cell != nil:
+			if w.cell.Len() > 0 {
+				w.cell.WriteByte(' ')
+			}
+			w.cell.WriteString(text)
+		case w.shape != nil:
+			ph := w.shape.placeholder
+			bulleted := w.bullet > 0 || (w.bullet == 0 && w.shape.isPH && (ph == "" || ph == "body" || ph == "obj"))
+			if bulleted && !w.notes {
+				text = strings.Repeat("  ", w.level) + "- " + text
+			}
+			w.shape.lines = append(w.shape.lines, text)
+		default:
+			w.body = append(w.body, text)
+		}
+	case "sp":
+		if w.shape == nil {
+			return
+		}
+		s := w.shape
+		w.shape = nil
+		switch {
+		case len(s.lines) == 0:
+		case w.notes:
+			if s.isPH && (s.placeholder == "" || s.placeholder == "body") {
+				w.body = append(w.body, s.lines...)
+			}
+		case pptxTitlePlaceholder(s.placeholder):
+			if w.title == "" {
+				w.title = strings.Join(s.lines, " ")
+			} else {
+				w.body = append(w.body, s.lines...)
+			}
+		case s.placeholder == "sldNum" || s.placeholder == "dt" || s.placeholder == "ftr" || s.placeholder == "hdr":
+		default:
+			w.body = append(w.body, s.lines...)
+		}
+	case "tc":
+		if w.cell != nil {
+			w.row = append(w.row, strings.ReplaceAll(w.cell.String(), "|", "/"))
+			w.cell = nil
+		}
+	case "tr":
+		line := strings.TrimSpace(strings.Join(w.row, " | "))
+		if strings.Trim(line, "| ") != "" {
+			w.rows = append(w.rows, line)
+		}
+	case "tbl":
+		w.body = append(w.body, w.rows...)
+		w.rows = nil
+	}
+}
+
+func pptxTitlePlaceholder(kind string) bool {
+	return kind == "title" || kind == "ctrTitle"
+}
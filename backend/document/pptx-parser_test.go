@@ -0,0 +1,67 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+const pptxNS = `xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+
+func pptxPlaceholder(ph, text string) string {
+	return `<p:sp><p:nvSpPr><p:nvPr><p:ph type="` + ph + `"/></p:nvPr></p:nvSpPr><p:txBody><a:p><a:r><a:t>` + text + `</a:t></a:r></a:p></p:txBody></p:sp>`
+}
+
+// pptxDeck returns a two-slide deck whose slide ID list puts slide2.xml
+// first; the other slide has speaker notes.
+func pptxDeck(t testing.TB) []byte {
+	slide := func(shapes ...string) string {
+		return `<p:sld ` + pptxNS + `><p:cSld><p:spTree>` + strings.Join(shapes, "") + `</p:spTree></p:cSld></p:sld>`
+	}
+	return zipFiles(t,
+		"ppt/presentation.xml", `<p:presentation `+pptxNS+`><p:sldIdLst><p:sldId r:id="rId2"/><p:sldId r:id="rId1"/></p:sldIdLst></p:presentation>`,
+		"ppt/_rels/presentation.xml.rels", `<Relationships><Relationship Id="rId1" Target="slides/slide1.xml"/><Relationship Id="rId2" Target="slides/slide2.xml"/></Relationships>`,
+		"ppt/slides/slide1.xml", slide(pptxPlaceholder("title", "Results"), pptxPlaceholder("body", "Revenue grew"), pptxPlaceholder("sldNum", "7")),
+		"ppt/slides/_rels/slide1.xml.rels", `<Relationships><Relationship Id="rId1" Target="../notesSlides/notesSlide1.xml"/></Relationships>`,
+		"ppt/notesSlides/notesSlide1.xml", `<p:notes `+pptxNS+`><p:cSld><p:spTree>`+pptxPlaceholder("sldImg", "")+pptxPlaceholder("body", "Mention the forecast")+`</p:spTree></p:cSld></p:notes>`,
+		"ppt/slides/slide2.xml", slide(pptxPlaceholder("ctrTitle", "Agenda"), pptxPlaceholder("body", "Results")),
+		"docProps/core.xml", `<cp:coreProperties xmlns:cp="cp" xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Quarterly</dc:title></cp:coreProperties>`,
+	)
+}
+
+func TestPPTXParser(t *testing.T) {
+	doc, err := NewPPTXParser().Parse(pptxDeck(t), "deck.pptx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Agenda\n\n- Results\n\n# Results\n\n- Revenue grew\n\nNotes:\nMention the forecast"
+	if doc.Content != want {
+		t.Errorf("content %q, want %q", doc.Content, want)
+	}
+	if len(doc.Pages) != 2 || len(doc.Headings) != 2 || doc.Headings[1].Offset != doc.Pages[1].Start {
+		t.Errorf("pages %+v, headings %+v", doc.Pages, doc.Headings)
+	}
+	if doc.Metadata["title"] != "Quarterly" || doc.Metadata["slides"] != "2" {
+		t.Errorf("metadata %v", doc.Metadata)
+	}
+
+	p := NewPPTXParser()
+	p.SkipNotes = true
+	docs, err := p.ParseAll(pptxDeck(t), "deck.pptx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[1].Metadata["slide"] != "2" || docs[1].Metadata["title"] != "Results" {
+		t.Fatalf("ParseAll returned %d documents", len(docs))
+	}
+	if strings.Contains(docs[1].Content, "Notes:") {
+		t.Errorf("SkipNotes kept notes: %q", docs[1].Content)
+	}
+
+	fx, err := NewPPTXParser().Parse(mustRead(t, fixture("test-pptx.pptx")), "test-pptx.pptx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(fx.Content, "Building Smarter Systems") || len(fx.Pages) < 25 {
+		t.Errorf("fixture parsed to %d slides", len(fx.Pages))
+	}
+}
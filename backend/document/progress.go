@@ -0,0 +1,84 @@
+package document
+
+import (
+	"sync"
+	"time"
+)
+
+// Progress is a snapshot of a long ingestion job, as passed to a
+// ProgressFunc.
+type Progress struct {
+	// Files is the number of files finished, including Failed.
+	Files int
+	// TotalFiles is the number of files in the job, or 0 when unknown.
+	TotalFiles int
+	// Failed is the number of files that failed.
+	Failed int
+	// Bytes is the number of input bytes read so far.
+	Bytes int64
+	// Chunks is the number of chunks emitted so far.
+	Chunks int
+	// CurrentFile is the file most recently started.
+	CurrentFile string
+	// Elapsed is the time since the job started.
+	Elapsed time.Duration
+}
+
+// ETA estimates the time left from the average time per finished file, or
+// returns 0 when TotalFiles is unknown or no file has finished.
+func (p Progress) ETA() time.Duration {
+	if p.TotalFiles == 0 || p.Files == 0 {
+		return 0
+	}
+	return p.Elapsed / time.Duration(p.Files) * time.Duration(p.TotalFiles-p.Files)
+}
+
+// ProgressFunc is called as each file of a job starts and finishes. Calls
+// are never concurrent, but come from the goroutines doing the work, so
+// the function should return quickly.
+type ProgressFunc func(Progress)
+
+// progressTracker accumulates Progress and reports it to fn, which may be
+// nil.
+type progressTracker struct {
+	mu    sync.Mutex
+	fn    ProgressFunc
+	start time.Time
+	p     Progress
+}
+
+func newProgressTracker(fn ProgressFunc, totalFiles int) *progressTracker {
+	return &progressTracker{fn: fn, start: time.Now(), p: Progress{TotalFiles: totalFiles}}
+}
+
+// begin reports that filename, of size bytes, has been read.
+func (t *progressTracker) begin(filename string, size int) {
+	if t.fn == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.p.CurrentFile = filename
+	t.p.Bytes += int64(size)
+	t.report()
+}
+
+// done reports that a file finished with chunks, or failed with err.
+func (t *progressTracker) done(chunks int, err error) {
+	if t.fn == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.p.Files++
+	t.p.Chunks += chunks
+	if err != nil {
+		t.p.Failed++
+	}
+	t.report()
+}
+
+func (t *progressTracker) report() {
+	t.p.Elapsed = time.Since(t.start)
+	t.fn(t.p)
+}
@@ -0,0 +1,67 @@
+package document
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProgressETA(t *testing.T) {
+	tests := []struct {
+		p    Progress
+		want time.Duration
+	}{
+		{Progress{Files: 2, TotalFiles: 10, Elapsed: 4 * time.Second}, 16 * time.Second},
+		{Progress{Files: 0, TotalFiles: 10, Elapsed: time.Second}, 0},
+		{Progress{Files: 3, Elapsed: time.Second}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.p.ETA(); got != tt.want {
+			t.Errorf("%+v.ETA() = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestPipelineProgress(t *testing.T) {
+	loader := mapLoader(map[string]string{"a.txt": "One.\n\nTwo.", "b.txt": ""})
+	var reports []Progress
+	p := NewPipeline().LoadWith(loader).Chunker("paragraph", WithChunkSize(1)).OnProgress(func(p Progress) { reports = append(reports, p) })
+	if err := p.Run(context.Background(), "a.txt", "b.txt"); err == nil {
+		t.Fatal("empty b.txt did not fail")
+	}
+	want := []struct {
+		files, failed, chunks int
+		bytes                 int64
+		current               string
+	}{
+		{0, 0, 0, 10, "a.txt"},
+		{1, 0, 2, 10, "a.txt"},
+		{1, 0, 2, 10, "b.txt"},
+		{2, 1, 2, 10, "b.txt"},
+	}
+	if len(reports) != len(want) {
+		t.Fatalf("%d reports: %+v", len(reports), reports)
+	}
+	for i, w := range want {
+		r := reports[i]
+		if r.Files != w.files || r.Failed != w.failed || r.Chunks != w.chunks || r.Bytes != w.bytes || r.CurrentFile != w.current || r.TotalFiles != 2 {
+			t.Errorf("report %d = %+v, want %+v", i, r, w)
+		}
+	}
+}
+
+func TestBatchProgress(t *testing.T) {
+	inputs := []BatchInput{{"a.txt", []byte("One.")}, {"b.txt", []byte("Two.")}, {"c.txt", []byte("Three.")}}
+	var last Progress
+	calls := 0
+	_, err := ProcessBatch(context.Background(), inputs, BatchOptions{Workers: 2, Progress: func(p Progress) {
+		calls++
+		last = p
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 6 || last.Files != 3 || last.Chunks != 3 || last.Bytes != 14 || last.ETA() != 0 {
+		t.Errorf("%d calls, last %+v", calls, last)
+	}
+}
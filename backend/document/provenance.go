@@ -0,0 +1,118 @@
+package document
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParserVersion is the version recorded in Provenance for the built-in
+// parsers. Bump it when a parser change alters extracted text, so stored
+// chunks can be traced to the code that produced them.
+const ParserVersion = "1.0.0"
+
+// VersionedParser is implemented by parsers that report their own version
+// for Provenance instead of ParserVersion.
+type VersionedParser interface {
+	Parser
+	Version() string
+}
+
+// Provenance records where a document or chunk came from, so answers
+// built on it can cite the source verifiably.
+type Provenance struct {
+	// SourceURI identifies the original file, such as its path or the URL
+	// it was fetched from.
+	SourceURI string
+	// Parser names the parser that extracted the text, such as
+	// "PDFParser", and ParserVersion its version.
+	Parser        string
+	ParserVersion string
+	// ExtractedAt is when the text was extracted.
+	ExtractedAt time.Time
+	// FetchedAt is when the source was downloaded, for sources fetched
+	// over the network, or zero.
+	FetchedAt time.Time
+	// Section is the heading path of a chunk, joined with " > ".
+	Section string
+	// Pages is the page range of a chunk, such as "3" or "3-5".
+	Pages string
+}
+
+// ParseWithProvenance parses buffer with p and records the parser, its
+// version, the extraction time and sourceURI, or filename when empty, in
+// Document.Provenance, and the Checksum of buffer. Parse failures are a
+// *ParseError.
+func ParseWithProvenance(p Parser, buffer []byte, filename, sourceURI string) (*Document, error) {
+	doc, err := p.Parse(buffer, filename)
+	if err != nil {
+		return nil, parseError(p, filename, err)
+	}
+	doc.Provenance = newProvenance(p, filename, sourceURI)
+	doc.Checksum = Checksum(buffer)
+	return doc, nil
+}
+
+// Checksum returns the hex SHA-256 of buffer, the form stored in
+// Document.Checksum. Comparing it with a stored checksum tells whether a
+// source changed without parsing it again.
+func Checksum(buffer []byte) string {
+	sum := sha256.Sum256(buffer)
+	return hex.EncodeToString(sum[:])
+}
+
+// newProvenance describes a parse of filename by p.
+func newProvenance(p Parser, filename, sourceURI string) Provenance {
+	if sourceURI == "" {
+		sourceURI = filename
+	}
+	version := ParserVersion
+	if v, ok := p.(VersionedParser); ok {
+		version = v.Version()
+	}
+	return Provenance{
+		SourceURI:     sourceURI,
+		Parser:        parserName(p),
+		ParserVersion: version,
+		ExtractedAt:   time.Now().UTC(),
+	}
+}
+
+// parserName returns the type name of p without package or pointer, such
+// as "PDFParser".
+func parserName(p Parser) string {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", p), "*")
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// SetProvenance gives every chunk of doc a copy of doc.Provenance, with
+// SourceURI defaulting to doc.Source, plus the chunk's section and pages.
+// The section is the "heading_path" metadata or, for chunks with offsets,
+// the headings enclosing the chunk's start.
+func SetProvenance(doc *Document, chunks []Chunk) {
+	base := doc.Provenance
+	if base.SourceURI == "" {
+		base.SourceURI = doc.Source
+	}
+	for i := range chunks {
+		c := &chunks[i]
+		p := base
+		if p.Section = c.Metadata["heading_path"]; p.Section == "" && c.EndOffset > 0 {
+			p.Section = strings.Join(doc.HeadingPath(c.StartOffset), " > ")
+		}
+		switch {
+		case c.PageStart == 0:
+		case c.PageEnd > c.PageStart:
+			p.Pages = strconv.Itoa(c.PageStart) + "-" + strconv.Itoa(c.PageEnd)
+		default:
+			p.Pages = strconv.Itoa(c.PageStart)
+		}
+		c.Provenance = p
+	}
+}
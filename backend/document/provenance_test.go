@@ -0,0 +1,66 @@
+package document
+
+import (
+	"testing"
+	"time"
+)
+
+type versionedParser struct{ *TextParser }
+
+func (versionedParser) Version() string { return "2.1.0" }
+
+func TestParseWithProvenance(t *testing.T) {
+	before := time.Now().UTC()
+	doc, err := ParseWithProvenance(NewTextParser(), []byte("Hello."), "a.txt", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := doc.Provenance
+	if p.SourceURI != "a.txt" || p.Parser != "TextParser" || p.ParserVersion != ParserVersion || p.ExtractedAt.Before(before) {
+		t.Errorf("Provenance = %+v", p)
+	}
+	if doc.Checksum != Checksum([]byte("Hello.")) || doc.Checksum == Checksum([]byte("Hello!")) {
+		t.Errorf("Checksum = %q", doc.Checksum)
+	}
+
+	doc, err = ParseWithProvenance(versionedParser{NewTextParser()}, []byte("Hello."), "a.txt", "https://example.com/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := doc.Provenance; p.SourceURI != "https://example.com/a.txt" || p.Parser != "versionedParser" || p.ParserVersion != "2.1.0" {
+		t.Errorf("Provenance = %+v", p)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	// The SHA-256 of the empty input.
+	if got := Checksum(nil); got != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("Checksum(nil) = %s", got)
+	}
+}
+
+func TestSetProvenance(t *testing.T) {
+	doc := &Document{
+		Source:   "guide.md",
+		Content:  "# Guide\n\nIntro.\n\n## Setup\n\nSteps.",
+		Headings: []Heading{{Level: 1, Text: "Guide", Offset: 0}, {Level: 2, Text: "Setup", Offset: 17}},
+	}
+	doc.Provenance.Parser = "MarkdownParser"
+	chunks := []Chunk{
+		{StartOffset: 9, EndOffset: 15, PageStart: 1, PageEnd: 1},
+		{StartOffset: 26, EndOffset: 32, PageStart: 1, PageEnd: 2},
+		{Text: "cell", Metadata: map[string]string{"heading_path": "Guide > Table"}},
+	}
+	SetProvenance(doc, chunks)
+	want := []struct{ section, pages string }{
+		{"Guide", "1"},
+		{"Guide > Setup", "1-2"},
+		{"Guide > Table", ""},
+	}
+	for i, c := range chunks {
+		p := c.Provenance
+		if p.SourceURI != "guide.md" || p.Parser != "MarkdownParser" || p.Section != want[i].section || p.Pages != want[i].pages {
+			t.Errorf("chunk %d Provenance = %+v, want section %q pages %q", i, p, want[i].section, want[i].pages)
+		}
+	}
+}
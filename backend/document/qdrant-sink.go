@@ -0,0 +1,150 @@
+package document
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QdrantSink is a Sink that upserts chunks into a Qdrant collection over
+// its REST API, with the chunk text and metadata as the payload. Point IDs
+// are derived from ChunkID, so writing an unchanged document again
+// overwrites its points. The collection is created on the first write if
+// missing.
+type QdrantSink struct {
+	// Endpoint is the base URL of the server. Defaults to
+	// http://localhost:6333.
+	Endpoint string
+	// APIKey is sent in the api-key header when set.
+	APIKey string
+	// Collection names the collection. Defaults to "chunks".
+	Collection string
+	// Distance is the metric of a new collection: "Cosine", "Dot",
+	// "Euclid" or "Manhattan". Defaults to "Cosine".
+	Distance string
+	// BatchSize is the number of points sent per request. Defaults to 64.
+	BatchSize int
+	// MaxRetries is the number of times a failed request is retried, with
+	// exponential backoff. Defaults to 3; negative turns retries off.
+	MaxRetries int
+	// Client defaults to an http.Client with a one minute timeout.
+	Client *http.Client
+
+	mu    sync.Mutex
+	ready bool
+}
+
+// NewQdrantSink creates a sink that writes to collection on the server at
+// endpoint.
+func NewQdrantSink(endpoint, collection string) *QdrantSink {
+	return &QdrantSink{Endpoint: endpoint, Collection: collection}
+}
+
+// qdrantPoint is a point of an upsert request.
+type qdrantPoint struct {
+	ID      string         `json:"id"`
+	Vector  []float32      `json:"vector"`
+	Payload map[string]any `json:"payload"`
+}
+
+// Write upserts the chunks of doc, in batches. Every chunk must have an
+// Embedding; see Pipeline.Embed.
+func (s *QdrantSink) Write(ctx context.Context, doc *Document, chunks []Chunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	for i, c := range chunks {
+		if len(c.Embedding) == 0 {
+			return fmt.Errorf("chunk %d has no embedding", i)
+		}
+	}
+	if err := s.ensureCollection(ctx, len(chunks[0].Embedding)); err != nil {
+		return err
+	}
+	source := storeSource(doc)
+	points := make([]qdrantPoint, len(chunks))
+	for i, c := range chunks {
+		id := storeChunkID(source, c)
+		points[i] = qdrantPoint{
+			ID:     chunkUUID(id),
+			Vector: c.Embedding,
+			Payload: map[string]any{
+				"chunk_id":    id,
+				"source":      source,
+				"chunk_index": c.Index,
+				"content":     c.Text,
+				"metadata":    chunkStoreMetadata(doc, c),
+			},
+		}
+	}
+	batchSize := s.BatchSize
+	if batchSize <= 0 {
+		batchSize = 64
+	}
+	for start := 0; start < len(points); start += batchSize {
+		batch := points[start:min(start+batchSize, len(points))]
+		if _, err := s.send(ctx, http.MethodPut, "/points?wait=true", map[string]any{"points": batch}); err != nil {
+			return fmt.Errorf("upsert points: %w", err)
+		}
+	}
+	return nil
+}
+
+// ensureCollection creates the collection once, if it does not exist.
+func (s *QdrantSink) ensureCollection(ctx context.Context, dims int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ready {
+		return nil
+	}
+	_, err := s.send(ctx, http.MethodGet, "", nil)
+	var he *httpError
+	if errors.As(err, &he) && he.code == http.StatusNotFound {
+		distance := s.Distance
+		if distance == "" {
+			distance = "Cosine"
+		}
+		body := map[string]any{"vectors": map[string]any{"size": dims, "distance": distance}}
+		_, err = s.send(ctx, http.MethodPut, "", body)
+	}
+	if err != nil {
+		return fmt.Errorf("create collection: %w", err)
+	}
+	s.ready = true
+	return nil
+}
+
+// send makes a request to the collection's URL with suffix appended.
+func (s *QdrantSink) send(ctx context.Context, method, suffix string, body any) ([]byte, error) {
+	endpoint := strings.TrimSuffix(s.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "http://localhost:6333"
+	}
+	collection := s.Collection
+	if collection == "" {
+		collection = "chunks"
+	}
+	header := http.Header{}
+	if s.APIKey != "" {
+		header.Set("api-key", s.APIKey)
+	}
+	u := endpoint + "/collections/" + url.PathEscape(collection) + suffix
+	return retryPolicy{maxRetries: s.MaxRetries}.sendJSON(ctx, httpClient(s.Client, time.Minute), method, u, header, body)
+}
+
+// chunkUUID formats the first 128 bits of a hex ChunkID as a UUID, the
+// form of string ID Qdrant accepts. Other IDs are hashed first.
+func chunkUUID(id string) string {
+	if _, err := hex.DecodeString(id); err != nil || len(id) < 32 {
+		sum := sha256.Sum256([]byte(id))
+		id = hex.EncodeToString(sum[:])
+	}
+	return id[0:8] + "-" + id[8:12] + "-" + id[12:16] + "-" + id[16:20] + "-" + id[20:32]
+}
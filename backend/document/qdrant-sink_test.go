@@ -0,0 +1,93 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestQdrantSink(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	var created map[string]any
+	var points []qdrantPoint
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		if r.Header.Get("api-key") != "key" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && created == nil:
+			http.Error(w, "not found", http.StatusNotFound)
+		case r.Method == http.MethodPut && r.URL.RawQuery == "":
+			json.NewDecoder(r.Body).Decode(&created)
+		case r.Method == http.MethodPut:
+			var req struct {
+				Points []qdrantPoint `json:"points"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			points = append(points, req.Points...)
+		}
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer srv.Close()
+
+	sink := NewQdrantSink(srv.URL+"/", "my docs")
+	sink.APIKey = "key"
+	sink.Distance = "Dot"
+	sink.BatchSize = 2
+	doc := &Document{Source: "a.md", Metadata: map[string]string{"lang": "en"}}
+	chunks := []Chunk{
+		{Index: 0, Text: "one", Embedding: []float32{1, 0}},
+		{Index: 1, Text: "two", Embedding: []float32{0, 1}},
+		{Index: 2, Text: "three", Embedding: []float32{1, 1}},
+	}
+	ctx := context.Background()
+	if err := sink.Write(ctx, doc, chunks); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(ctx, doc, chunks[:1]); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"GET /collections/my%20docs",
+		"PUT /collections/my%20docs",
+		"PUT /collections/my%20docs/points?wait=true",
+		"PUT /collections/my%20docs/points?wait=true",
+		"PUT /collections/my%20docs/points?wait=true",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests %q, want %q", requests, want)
+	}
+	if want := map[string]any{"vectors": map[string]any{"size": 2.0, "distance": "Dot"}}; !reflect.DeepEqual(created, want) {
+		t.Errorf("collection %v, want %v", created, want)
+	}
+	if len(points) != 4 {
+		t.Fatalf("upserted %d points, want 4", len(points))
+	}
+	p := points[2]
+	if p.ID != chunkUUID(ChunkID("a.md", chunks[2])) || len(p.ID) != 36 || !reflect.DeepEqual(p.Vector, []float32{1, 1}) {
+		t.Errorf("point %+v", p)
+	}
+	if p.Payload["source"] != "a.md" || p.Payload["content"] != "three" || p.Payload["chunk_index"] != 2.0 ||
+		!reflect.DeepEqual(p.Payload["metadata"], map[string]any{"lang": "en"}) {
+		t.Errorf("payload %v", p.Payload)
+	}
+	if points[3].ID != points[0].ID {
+		t.Error("rewriting a chunk gave it a new point ID")
+	}
+
+	if err := NewQdrantSink(srv.URL, "").Write(ctx, doc, chunks); err == nil {
+		t.Error("rejected request returned no error")
+	}
+	if err := sink.Write(ctx, doc, []Chunk{{Text: "one"}}); err == nil {
+		t.Error("chunk without embedding returned no error")
+	}
+}
@@ -0,0 +1,286 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job is an ingestion request read from a queue, encoded as JSON. It names
+// a source for the pipeline's loader, or carries the file inline.
+type Job struct {
+	// ID is copied to the job's result, for matching them up.
+	ID string `json:"id,omitempty"`
+	// URI is the source to load, such as a path or URL.
+	URI string `json:"uri,omitempty"`
+	// Content is the file itself, base64 in JSON, parsed as Filename. It
+	// is used instead of URI when set.
+	Content  []byte `json:"content,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	// Metadata is added to the document's metadata.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// JobResult is published, as JSON, for each job a QueueWorker processes.
+type JobResult struct {
+	ID      string     `json:"id,omitempty"`
+	Source  string     `json:"source"`
+	Version string     `json:"version,omitempty"`
+	Chunks  []JobChunk `json:"chunks,omitempty"`
+	// Error says why the job failed, when it did.
+	Error string `json:"error,omitempty"`
+}
+
+// JobChunk is a chunk of a JobResult.
+type JobChunk struct {
+	ID string `json:"id"`
+	// ParentID, PrevID and NextID are the IDs of the chunk's parent and
+	// neighbours, as in Chunk.
+	ParentID    string `json:"parent_id,omitempty"`
+	PrevID      string `json:"prev_id,omitempty"`
+	NextID      string `json:"next_id,omitempty"`
+	Index       int    `json:"index"`
+	Text        string `json:"text"`
+	StartOffset int    `json:"start_offset"`
+	EndOffset   int    `json:"end_offset"`
+	StartLine   int    `json:"start_line,omitempty"`
+	EndLine     int    `json:"end_line,omitempty"`
+	PageStart   int    `json:"page_start,omitempty"`
+	PageEnd     int    `json:"page_end,omitempty"`
+	// Section is the heading path of the chunk, joined with " > ".
+	Section    string            `json:"section,omitempty"`
+	TokenCount int               `json:"token_count"`
+	Embedding  []float32         `json:"embedding,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// Message is a message received from a Queue.
+type Message struct {
+	Data []byte
+	// Ack, when set, acknowledges the message once it has been handled,
+	// such as by committing its offset, so it is not delivered again.
+	Ack func(ctx context.Context) error
+}
+
+// Queue is a message broker connection a QueueWorker reads jobs from and
+// publishes results to. NATSQueue and KafkaQueue implement it; other
+// clients can be adapted. A Queue must be safe for concurrent use.
+type Queue interface {
+	// Receive blocks until a message arrives or ctx is done.
+	Receive(ctx context.Context) (*Message, error)
+	// Publish sends data to topic.
+	Publish(ctx context.Context, topic string, data []byte) error
+}
+
+// QueueWorker reads Jobs from a Queue, runs them through a Pipeline and
+// publishes a JobResult for each. Workers reading the same queue through
+// a consumer group or queue group share its jobs, so ingestion scales by
+// running more of them.
+type QueueWorker struct {
+	Queue    Queue
+	Pipeline *Pipeline
+	// Output is the topic results are published to. Results are not
+	// published when it is empty, such as when the pipeline's sinks store
+	// the chunks.
+	Output string
+	// Concurrency is the number of jobs processed at once. Defaults to 1.
+	Concurrency int
+	// OmitEmbeddings leaves embeddings out of published results.
+	OmitEmbeddings bool
+	// AllowURIs lists the prefixes of the sources jobs may name for the
+	// pipeline's loader, such as "s3://uploads/" or "/srv/docs/". Jobs
+	// naming any other uri, or one with ".." elements, fail. With no
+	// prefixes only jobs carrying their content are run, because the
+	// default FileLoader would let producers read any file the worker can.
+	AllowURIs []string
+}
+
+// NewQueueWorker creates a worker that runs jobs from q through p and
+// publishes results to output.
+func NewQueueWorker(q Queue, p *Pipeline, output string) *QueueWorker {
+	return &QueueWorker{Queue: q, Pipeline: p, Output: output}
+}
+
+// Run processes jobs until ctx is done, returning ctx's error, or until
+// receiving, publishing or acknowledging fails. A job that fails is
+// reported in its result and acknowledged, as retrying it would fail the
+// same way; a message is only left unacknowledged when its result could
+// not be published.
+func (w *QueueWorker) Run(ctx context.Context) error {
+	n := w.Concurrency
+	if n <= 0 {
+		n = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var once sync.Once
+	var runErr error
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				err := w.step(ctx)
+				if err == nil {
+					continue
+				}
+				once.Do(func() { runErr = err })
+				cancel()
+				return
+			}
+		}()
+	}
+	wg.Wait()
+	return runErr
+}
+
+// step receives one message and handles it.
+func (w *QueueWorker) step(ctx context.Context) error {
+	msg, err := w.Queue.Receive(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("receive job: %w", err)
+	}
+	result := w.handle(ctx, msg.Data)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if w.Output != "" {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		if err := w.Queue.Publish(ctx, w.Output, data); err != nil {
+			return fmt.Errorf("publish result: %w", err)
+		}
+	}
+	if msg.Ack != nil {
+		if err := msg.Ack(ctx); err != nil {
+			return fmt.Errorf("acknowledge job: %w", err)
+		}
+	}
+	return nil
+}
+
+// handle processes the job encoded in data.
+func (w *QueueWorker) handle(ctx context.Context, data []byte) *JobResult {
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return &JobResult{Error: fmt.Sprintf("decode job: %v", err)}
+	}
+	if job.Content == nil && job.URI != "" && !uriAllowed(job.URI, w.AllowURIs) {
+		return &JobResult{ID: job.ID, Source: job.URI, Error: "uri is not allowed"}
+	}
+	p := w.Pipeline
+	if p == nil {
+		p = NewPipeline()
+	}
+	result := p.runJob(ctx, &job)
+	if w.OmitEmbeddings {
+		for i := range result.Chunks {
+			result.Chunks[i].Embedding = nil
+		}
+	}
+	return result
+}
+
+// uriAllowed reports whether uri starts with one of prefixes and has no
+// ".." elements, escaped or not, that could climb out of it.
+func uriAllowed(uri string, prefixes []string) bool {
+	forms := []string{uri}
+	if unescaped, err := url.PathUnescape(uri); err == nil {
+		forms = append(forms, unescaped)
+	}
+	for _, form := range forms {
+		for _, elem := range strings.FieldsFunc(form, func(r rune) bool { return r == '/' || r == '\\' }) {
+			if elem == ".." {
+				return false
+			}
+		}
+	}
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(uri, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// runJob loads and processes job, reporting a failure in the result.
+func (p *Pipeline) runJob(ctx context.Context, job *Job) *JobResult {
+	result := &JobResult{ID: job.ID, Source: job.URI}
+	var src *Fetched
+	switch {
+	case job.Content != nil:
+		src = &Fetched{Buffer: job.Content, Filename: job.Filename, URI: job.URI, FetchedAt: time.Now().UTC()}
+		if result.Source == "" {
+			result.Source = job.Filename
+		}
+	case job.URI != "":
+		var err error
+		if src, err = fetch(ctx, p.sourceLoader(), job.URI); err != nil {
+			err = fmt.Errorf("load %s: %w", job.URI, err)
+			p.notifyDocument(ctx, job.URI, nil, 0, err)
+			result.Error = err.Error()
+			return result
+		}
+	default:
+		result.Error = "job has neither uri nor content"
+		return result
+	}
+	defer src.Close()
+	if len(job.Metadata) > 0 {
+		metadata := make(map[string]string, len(src.Metadata)+len(job.Metadata))
+		for k, v := range src.Metadata {
+			metadata[k] = v
+		}
+		for k, v := range job.Metadata {
+			metadata[k] = v
+		}
+		src.Metadata = metadata
+	}
+
+	doc, chunks, err := p.process(ctx, src)
+	p.notifyDocument(ctx, result.Source, doc, len(chunks), err)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	source := storeSource(doc)
+	result.Source, result.Version = source, doc.Version
+	result.Chunks = make([]JobChunk, len(chunks))
+	for i, c := range chunks {
+		result.Chunks[i] = newJobChunk(doc, c)
+	}
+	return result
+}
+
+// newJobChunk returns c of doc as a JobChunk, under the ID the sinks
+// store it with.
+func newJobChunk(doc *Document, c Chunk) JobChunk {
+	return JobChunk{
+		ID:          storeChunkID(storeSource(doc), c),
+		ParentID:    c.ParentID,
+		PrevID:      c.PrevID,
+		NextID:      c.NextID,
+		Index:       c.Index,
+		Text:        c.Text,
+		StartOffset: c.StartOffset,
+		EndOffset:   c.EndOffset,
+		StartLine:   c.StartLine,
+		EndLine:     c.EndLine,
+		PageStart:   c.PageStart,
+		PageEnd:     c.PageEnd,
+		Section:     c.Provenance.Section,
+		TokenCount:  c.TokenCount,
+		Embedding:   c.Embedding,
+		Metadata:    chunkStoreMetadata(doc, c),
+	}
+}
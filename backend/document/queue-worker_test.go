@@ -0,0 +1,380 @@
+package document
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memQueue is a Queue over a slice of jobs. Once the jobs run out and
+// those received are acknowledged, Receive fails with io.EOF, which ends
+// QueueWorker.Run.
+type memQueue struct {
+	mu         sync.Mutex
+	jobs       []string
+	published  []string
+	topics     []string
+	inflight   int
+	acked      int
+	publishErr error
+}
+
+func (q *memQueue) Receive(ctx context.Context) (*Message, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.jobs) == 0 {
+		if q.inflight == 0 {
+			return nil, io.EOF
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		q.mu.Unlock()
+		time.Sleep(time.Millisecond)
+		q.mu.Lock()
+	}
+	job := q.jobs[0]
+	q.jobs = q.jobs[1:]
+	q.inflight++
+	return &Message{Data: []byte(job), Ack: func(ctx context.Context) error {
+		q.mu.Lock()
+		q.acked++
+		q.inflight--
+		q.mu.Unlock()
+		return nil
+	}}, nil
+}
+
+func (q *memQueue) Publish(ctx context.Context, topic string, data []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.publishErr != nil {
+		return q.publishErr
+	}
+	q.topics = append(q.topics, topic)
+	q.published = append(q.published, string(data))
+	return nil
+}
+
+func TestQueueWorker(t *testing.T) {
+	q := &memQueue{jobs: []string{
+		`{"id": "a", "content": "IyBOb3RlcwoKU29tZSB0ZXh0Lgo=", "filename": "notes.md", "metadata": {"team": "docs"}}`,
+		`{"id": "b"}`,
+		`{"id": "c", "uri": "/missing/file.txt"}`,
+		`{`,
+		`{"id": "e", "uri": "/etc/passwd"}`,
+		`{"id": "f", "uri": "/missing/../etc/passwd"}`,
+		`{"id": "g", "uri": "/missing/%2e%2e/etc/passwd"}`,
+	}}
+	w := NewQueueWorker(q, NewPipeline().Embed(topicEmbedder{}), "results")
+	w.AllowURIs = []string{"/missing/"}
+	if err := w.Run(context.Background()); !errors.Is(err, io.EOF) {
+		t.Fatalf("Run = %v, want the end of the jobs", err)
+	}
+	// Every job is acknowledged once its result is published, failed ones
+	// too, since retrying them would fail the same way.
+	if q.acked != 7 || len(q.published) != 7 {
+		t.Fatalf("acked %d and published %d of 7 jobs", q.acked, len(q.published))
+	}
+	for _, topic := range q.topics {
+		if topic != "results" {
+			t.Errorf("published to %q", topic)
+		}
+	}
+
+	var results []JobResult
+	for _, data := range q.published {
+		var r JobResult
+		if err := json.Unmarshal([]byte(data), &r); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, r)
+	}
+	ok := results[0]
+	if ok.ID != "a" || ok.Source != "notes.md" || ok.Error != "" || len(ok.Chunks) == 0 {
+		t.Fatalf("result %+v", ok)
+	}
+	for i, c := range ok.Chunks {
+		if c.ID == "" || c.Index != i || c.Text == "" || c.TokenCount == 0 || c.EndOffset <= c.StartOffset ||
+			len(c.Embedding) == 0 || c.Metadata["team"] != "docs" {
+			t.Errorf("chunk %+v", c)
+		}
+	}
+	if results[1].ID != "b" || results[1].Error != "job has neither uri nor content" {
+		t.Errorf("empty job result %+v", results[1])
+	}
+	if results[2].ID != "c" || results[2].Error == "" || len(results[2].Chunks) != 0 {
+		t.Errorf("missing file result %+v", results[2])
+	}
+	if !strings.HasPrefix(results[3].Error, "decode job") {
+		t.Errorf("malformed job result %+v", results[3])
+	}
+	for _, r := range results[4:] {
+		if r.Error != "uri is not allowed" || len(r.Chunks) != 0 {
+			t.Errorf("uri outside AllowURIs: result %+v", r)
+		}
+	}
+
+	// Results are snake_case JSON with empty fields left out.
+	var shape map[string]any
+	json.Unmarshal([]byte(q.published[0]), &shape)
+	chunk := shape["chunks"].([]any)[0].(map[string]any)
+	for _, key := range []string{"id", "index", "text", "start_offset", "end_offset", "token_count", "embedding", "metadata"} {
+		if _, ok := chunk[key]; !ok {
+			t.Errorf("chunk JSON lacks %q: %v", key, chunk)
+		}
+	}
+	if _, ok := shape["error"]; ok {
+		t.Errorf("successful result has an error: %v", shape)
+	}
+	if want := `{"id":"b","source":"","error":"job has neither uri nor content"}`; q.published[1] != want {
+		t.Errorf("failed result %s, want %s", q.published[1], want)
+	}
+
+	// Without AllowURIs only jobs carrying their content run.
+	q = &memQueue{jobs: []string{`{"id": "c", "uri": "/missing/file.txt"}`}}
+	if err := NewQueueWorker(q, nil, "results").Run(context.Background()); !errors.Is(err, io.EOF) ||
+		!strings.Contains(q.published[0], `"error":"uri is not allowed"`) {
+		t.Errorf("uri without AllowURIs: %v, published %q", err, q.published)
+	}
+}
+
+func TestJobChunkJSON(t *testing.T) {
+	doc := &Document{Source: "a.md"}
+	linked := Chunk{ID: "c2", ParentID: "c1", PrevID: "c0", NextID: "c3", Index: 2, Text: "Body.", StartOffset: 4, EndOffset: 9, TokenCount: 1}
+	linked.Provenance.Section = "Guide > Setup"
+	data, err := json.Marshal(newJobChunk(doc, linked))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var shape map[string]any
+	json.Unmarshal(data, &shape)
+	for key, want := range map[string]any{"id": "c2", "parent_id": "c1", "prev_id": "c0", "next_id": "c3", "section": "Guide > Setup", "index": 2.0} {
+		if shape[key] != want {
+			t.Errorf("%s = %v, want %v in %s", key, shape[key], want, data)
+		}
+	}
+
+	data, _ = json.Marshal(newJobChunk(doc, Chunk{Text: "Alone."}))
+	shape = nil
+	json.Unmarshal(data, &shape)
+	for _, key := range []string{"parent_id", "prev_id", "next_id", "section", "embedding"} {
+		if _, ok := shape[key]; ok {
+			t.Errorf("unlinked chunk JSON has %q: %s", key, data)
+		}
+	}
+}
+
+func TestQueueWorkerOptions(t *testing.T) {
+	job := `{"content": "c29tZSB3b3Jkcw==", "filename": "a.txt"}`
+	q := &memQueue{jobs: []string{job, job, job}}
+	w := &QueueWorker{Queue: q, Pipeline: NewPipeline().Embed(topicEmbedder{}), Output: "out", OmitEmbeddings: true, Concurrency: 2}
+	if err := w.Run(context.Background()); !errors.Is(err, io.EOF) {
+		t.Fatal(err)
+	}
+	if q.acked != 3 || len(q.published) != 3 || strings.Contains(q.published[0], "embedding") {
+		t.Errorf("acked %d, published %q", q.acked, q.published)
+	}
+
+	// Without an output the job is only acknowledged.
+	q = &memQueue{jobs: []string{job}}
+	if err := NewQueueWorker(q, nil, "").Run(context.Background()); !errors.Is(err, io.EOF) || q.acked != 1 || len(q.published) != 0 {
+		t.Errorf("no output: %v, acked %d, published %d", err, q.acked, len(q.published))
+	}
+
+	// A result that cannot be published leaves its job unacknowledged.
+	q = &memQueue{jobs: []string{job, job}, publishErr: errors.New("broker down")}
+	err := NewQueueWorker(q, nil, "out").Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "broker down") || q.acked != 0 || len(q.jobs) != 1 {
+		t.Errorf("publish failure: %v, acked %d, %d jobs left", err, q.acked, len(q.jobs))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := NewQueueWorker(&memQueue{jobs: []string{job}}, nil, "out").Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled Run = %v", err)
+	}
+}
+
+func TestNATSQueue(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		conn.Write([]byte("INFO {\"server_id\": \"test\"}\r\n"))
+		for {
+			line, err := readNATSLine(r)
+			if err != nil {
+				close(lines)
+				return
+			}
+			switch {
+			case line == "PING":
+				conn.Write([]byte("PONG\r\nPING\r\nMSG jobs 1 _INBOX.x 7\r\n{\"a\":1}\r\n"))
+			case strings.HasPrefix(line, "PUB "):
+				payload, _ := readNATSLine(r)
+				line += " " + payload
+			}
+			lines <- line
+		}
+	}()
+
+	q := NewNATSQueue("nats://secret@"+ln.Addr().String(), "jobs")
+	ctx := context.Background()
+	msg, err := q.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg.Data) != `{"a":1}` || msg.Ack != nil {
+		t.Errorf("received %q", msg.Data)
+	}
+	if err := q.Publish(ctx, "results", []byte("done")); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for line := range lines {
+		got = append(got, line)
+	}
+	if len(got) != 5 || !strings.HasPrefix(got[0], "CONNECT ") || got[1] != "SUB jobs agento 1" || got[2] != "PING" ||
+		got[3] != "PONG" || got[4] != "PUB results 4 done" {
+		t.Fatalf("server read %q", got)
+	}
+	var opts map[string]any
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(got[0], "CONNECT ")), &opts); err != nil || opts["auth_token"] != "secret" {
+		t.Errorf("CONNECT options %v, %v", opts, err)
+	}
+	if _, err := q.Receive(ctx); err == nil {
+		t.Error("Receive after Close returned no error")
+	}
+}
+
+func TestNATSQueueRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("INFO {}\r\n-ERR 'Authorization Violation'\r\n"))
+		io.Copy(io.Discard, conn)
+	}()
+	q := NewNATSQueue("nats://u:wrong@"+ln.Addr().String(), "jobs")
+	if _, err := q.Receive(context.Background()); err == nil || !strings.Contains(err.Error(), "Authorization Violation") {
+		t.Errorf("refused connection: %v", err)
+	}
+}
+
+func TestKafkaQueue(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	fetches := 0
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		call := r.Method + " " + r.URL.Path
+		if len(body) > 0 {
+			call += " " + string(body)
+		}
+		calls = append(calls, call)
+		if r.Header.Get("X-Key") != "k" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		instance := "/consumers/workers/instances/w1"
+		switch r.Method + " " + r.URL.Path {
+		case "POST /consumers/workers":
+			w.Write([]byte(`{"instance_id": "w1", "base_uri": "` + srv.URL + instance + `"}`))
+		case "GET " + instance + "/records":
+			if r.Header.Get("Accept") != kafkaBinary {
+				http.Error(w, "not acceptable", http.StatusNotAcceptable)
+				return
+			}
+			fetches++
+			if fetches == 1 {
+				w.Write([]byte(`[]`))
+				return
+			}
+			w.Write([]byte(`[{"topic": "jobs", "value": "am9iMQ==", "partition": 2, "offset": 7},
+				{"topic": "jobs", "value": "am9iMg==", "partition": 2, "offset": 8}]`))
+		case "POST " + instance + "/subscription", "POST " + instance + "/offsets", "POST /topics/results":
+			w.Write([]byte(`{}`))
+		case "DELETE " + instance:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	q := NewKafkaQueue(srv.URL+"/", "workers", "jobs")
+	q.Header = http.Header{"X-Key": {"k"}}
+	q.PollInterval = time.Millisecond
+	ctx := context.Background()
+	var got []string
+	for range 2 {
+		msg, err := q.Receive(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(msg.Data))
+		if err := msg.Ack(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(got, []string{"job1", "job2"}) {
+		t.Errorf("received %q", got)
+	}
+	if err := q.Publish(ctx, "results", []byte("r")); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`POST /consumers/workers {"auto.commit.enable":"false","auto.offset.reset":"earliest","format":"binary"}`,
+		`POST /consumers/workers/instances/w1/subscription {"topics":["jobs"]}`,
+		`GET /consumers/workers/instances/w1/records`,
+		`GET /consumers/workers/instances/w1/records`,
+		`POST /consumers/workers/instances/w1/offsets {"offsets":[{"offset":7,"partition":2,"topic":"jobs"}]}`,
+		`POST /consumers/workers/instances/w1/offsets {"offsets":[{"offset":8,"partition":2,"topic":"jobs"}]}`,
+		`POST /topics/results {"records":[{"value":"cg=="}]}`,
+		`DELETE /consumers/workers/instances/w1`,
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("requests:\n%s\nwant:\n%s", strings.Join(calls, "\n"), strings.Join(want, "\n"))
+	}
+
+	q = NewKafkaQueue(srv.URL, "workers", "jobs")
+	if _, err := q.Receive(ctx); err == nil {
+		t.Error("unauthorized consumer returned no error")
+	}
+}
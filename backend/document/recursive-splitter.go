@@ -0,0 +1,174 @@
+package document
+
+import (
+	"strings"
+	"unicode"
+)
+
+// RecursiveSplitter splits text at the highest priority separator that
+// yields pieces within ChunkSize, recursing into oversized pieces with the
+// next separator. Pieces that no separator can reduce are cut at token
+// boundaries, so no chunk exceeds ChunkSize.
+type RecursiveSplitter struct {
+	// ChunkSize is the maximum chunk size in tokens. Defaults to 200.
+	ChunkSize int
+	// Separators are tried in order. An empty string cuts at any token.
+	// Defaults to DefaultSeparators.
+	Separators []string
+	// Tokenizer measures chunk size. Defaults to DefaultTokenizer.
+	Tokenizer Tokenizer
+	// Overlap is the number of trailing tokens of each chunk repeated at
+	// the start of the next one. It is taken out of ChunkSize, so chunks
+	// with their overlap still fit, and is capped at ChunkSize-1.
+	Overlap int
+	// Segmenter finds the sentences SentenceSeparator splits at. Defaults
+	// to English rules.
+	Segmenter *SentenceSegmenter
+}
+
+// SentenceSeparator is a separator that splits at the sentence boundaries
+// found by the splitter's SentenceSegmenter rather than at a literal string.
+const SentenceSeparator = "\x00sentence"
+
+// DefaultSeparators splits by paragraph, line, sentence, word and finally
+// character.
+var DefaultSeparators = []string{"\n\n", "\n", SentenceSeparator, " ", ""}
+
+// NewRecursiveSplitter creates a new recursive splitter instance
+// configured by opts.
+func NewRecursiveSplitter(opts ...ChunkOption) *RecursiveSplitter {
+	o := NewOptions(opts...)
+	return &RecursiveSplitter{ChunkSize: o.ChunkSize, Tokenizer: o.Tokenizer, Overlap: o.Overlap, Segmenter: o.segmenter()}
+}
+
+// textSpan is the byte range [start, end) of a chunk within its text.
+type textSpan struct {
+	start, end int
+}
+
+// Split returns the chunks of text with surrounding whitespace trimmed.
+func (s *RecursiveSplitter) Split(text string) []string {
+	spans := s.spans(text)
+	out := make([]string, len(spans))
+	for i, sp := range spans {
+		out[i] = text[sp.start:sp.end]
+	}
+	return out
+}
+
+func (s *RecursiveSplitter) spans(text string) []textSpan {
+	size := s.ChunkSize
+	if size <= 0 {
+		size = 200
+	}
+	tok := s.Tokenizer
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	seps := s.Separators
+	if seps == nil {
+		seps = DefaultSeparators
+	}
+	seg := s.Segmenter
+	if seg == nil {
+		seg = defaultSegmenter
+	}
+	overlap := min(max(s.Overlap, 0), size-1)
+	var out []textSpan
+	for _, sp := range recursiveSplit(text, 0, len(text), seps, size-overlap, tok, seg) {
+		if sp = trimSpan(text, sp); sp.end > sp.start {
+			out = append(out, sp)
+		}
+	}
+	return fitOverlap(text, out, overlapSpans(text, out, overlap, tok), size, tok)
+}
+
+// recursiveSplit splits text[start:end] into spans of at most size tokens.
+// Separators stay attached to the end of the piece before them, so the
+// spans cover the range without gaps.
+func recursiveSplit(text string, start, end int, seps []string, size int, tok Tokenizer, seg *SentenceSegmenter) []textSpan {
+	if tok.Count(text[start:end]) <= size {
+		return []textSpan{{start, end}}
+	}
+	if len(seps) == 0 || seps[0] == "" {
+		return splitByTokens(text, start, end, size, tok)
+	}
+	sep, rest := seps[0], seps[1:]
+	pieces := separatorPieces(text, start, end, sep, seg)
+	if len(pieces) == 1 {
+		return recursiveSplit(text, start, end, rest, size, tok, seg)
+	}
+
+	var out []textSpan
+	cur := textSpan{start, start}
+	for _, p := range pieces {
+		if tok.Count(text[cur.start:p.end]) <= size {
+			cur.end = p.end
+			continue
+		}
+		if cur.end > cur.start {
+			out = append(out, cur)
+		}
+		if tok.Count(text[p.start:p.end]) > size {
+			out = append(out, recursiveSplit(text, p.start, p.end, rest, size, tok, seg)...)
+			cur = textSpan{p.end, p.end}
+		} else {
+			cur = p
+		}
+	}
+	if cur.end > cur.start {
+		out = append(out, cur)
+	}
+	return out
+}
+
+// separatorPieces splits text[start:end] after each occurrence of sep,
+// finding sentences with seg.
+func separatorPieces(text string, start, end int, sep string, seg *SentenceSegmenter) []textSpan {
+	var pieces []textSpan
+	if sep == SentenceSeparator {
+		// Each sentence runs up to the start of the next one.
+		from := start
+		for i, sp := range seg.spans(text[start:end]) {
+			if i > 0 {
+				pieces = append(pieces, textSpan{from, start + sp.start})
+				from = start + sp.start
+			}
+		}
+		return append(pieces, textSpan{from, end})
+	}
+	for i := start; i < end; {
+		j := strings.Index(text[i:end], sep)
+		if j < 0 {
+			pieces = append(pieces, textSpan{i, end})
+			break
+		}
+		pieces = append(pieces, textSpan{i, i + j + len(sep)})
+		i += j + len(sep)
+	}
+	return pieces
+}
+
+// splitByTokens cuts text[start:end] every size tokens, moving each cut
+// back to the start of a grapheme cluster.
+func splitByTokens(text string, start, end int, size int, tok Tokenizer) []textSpan {
+	offsets := tok.Offsets(text[start:end])
+	var out []textSpan
+	from := start
+	for i := size; i < len(offsets); i += size {
+		if cut := graphemeStart(text, start+offsets[i]); cut > from {
+			out = append(out, textSpan{from, cut})
+			from = cut
+		}
+	}
+	return append(out, textSpan{from, end})
+}
+
+// trimSpan shrinks sp to exclude leading and trailing whitespace.
+func trimSpan(text string, sp textSpan) textSpan {
+	s := text[sp.start:sp.end]
+	trimmed := strings.TrimLeftFunc(s, unicode.IsSpace)
+	sp.start += len(s) - len(trimmed)
+	sp.end = sp.start + len(strings.TrimRightFunc(trimmed, unicode.IsSpace))
+	return sp
+}
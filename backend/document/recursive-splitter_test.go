@@ -0,0 +1,91 @@
+package document
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecursiveSplitter(t *testing.T) {
+	tests := []struct {
+		name string
+		s    *RecursiveSplitter
+		text string
+		want []string
+	}{
+		{
+			"separator priority",
+			&RecursiveSplitter{ChunkSize: 6, Tokenizer: WhitespaceTokenizer{}},
+			"One two three four.\n\nFive six seven eight nine ten eleven twelve thirteen.\nFourteen fifteen.",
+			[]string{"One two three four.", "Five six seven eight nine ten", "eleven twelve thirteen.", "Fourteen fifteen."},
+		},
+		{
+			"token cut",
+			&RecursiveSplitter{ChunkSize: 4, Tokenizer: CharacterTokenizer{}},
+			"abcdefghij",
+			[]string{"abcd", "efgh", "ij"},
+		},
+		{"blank", NewRecursiveSplitter(), "  \n\n ", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.s.Split(tt.text)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecursiveOverlapWithinChunkSize(t *testing.T) {
+	text := "The quick brown fox jumps over the lazy dog.\n\nPack my box with five dozen liquor jugs. " +
+		"How vexingly quick daft zebras jump!\nSphinx of black quartz, judge my vow."
+	tests := []struct {
+		name    string
+		size    int
+		overlap int
+		tok     Tokenizer
+	}{
+		{"characters", 5, 2, CharacterTokenizer{}},
+		{"characters wide", 40, 10, CharacterTokenizer{}},
+		{"words", 5, 2, WhitespaceTokenizer{}},
+		{"words overlap over size", 3, 5, WhitespaceTokenizer{}},
+		{"bytes", 16, 4, ByteTokenizer{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &Document{Content: text, Source: "a.txt"}
+			chunks, err := ChunkDocument("recursive", doc, Options{ChunkSize: tt.size, Overlap: tt.overlap, Tokenizer: tt.tok})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(chunks) < 2 {
+				t.Fatalf("got %d chunks, want several", len(chunks))
+			}
+			for _, c := range chunks {
+				if n := tt.tok.Count(c.Text); n > tt.size {
+					t.Errorf("chunk %d %q has %d tokens, over %d", c.Index, c.Text, n, tt.size)
+				}
+				if c.Text != strings.TrimSpace(c.Text) {
+					t.Errorf("chunk %d %q has surrounding space", c.Index, c.Text)
+				}
+				if text[c.StartOffset:c.EndOffset] != c.Text {
+					t.Errorf("chunk %d offsets do not match its text", c.Index)
+				}
+			}
+			// Chunks after the first start before the previous one ends.
+			overlapped := 0
+			for i := 1; i < len(chunks); i++ {
+				if chunks[i].StartOffset < chunks[i-1].EndOffset {
+					overlapped++
+				}
+			}
+			if overlapped == 0 {
+				t.Error("no chunk overlaps the one before it")
+			}
+		})
+	}
+}
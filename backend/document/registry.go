@@ -0,0 +1,107 @@
+package document
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+)
+
+// Priorities for Registry.Register. Parsers with a higher priority are
+// tried first; parsers of equal priority in the order they registered.
+const (
+	// PriorityFallback suits catch-all parsers that should only handle a
+	// type no other parser supports.
+	PriorityFallback = -100
+	// PriorityDefault is the priority of the built-in parsers.
+	PriorityDefault = 0
+	// PriorityOverride lets a parser take over types a built-in parser
+	// already handles.
+	PriorityOverride = 100
+)
+
+// Registry dispatches documents to the parser registered for their MIME
+// type. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	entries []registryEntry
+}
+
+type registryEntry struct {
+	parser   Parser
+	priority int
+}
+
+// NewRegistry creates a registry with no parsers.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// DefaultRegistry holds DefaultParsers and whatever parsers other packages
+// register with Register, such as from their init functions. ParseAuto
+// dispatches through it.
+var DefaultRegistry = newDefaultRegistry()
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	for _, p := range DefaultParsers() {
+		priority := PriorityDefault
+		if _, ok := p.(*TextParser); ok {
+			priority = PriorityFallback
+		}
+		r.Register(p, priority)
+	}
+	return r
+}
+
+// Register adds p to DefaultRegistry with the given priority.
+func Register(p Parser, priority int) {
+	DefaultRegistry.Register(p, priority)
+}
+
+// Register adds p with the given priority. When several parsers support a
+// MIME type, the one with the highest priority wins, and among equals the
+// one registered first.
+func (r *Registry) Register(p Parser, priority int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, registryEntry{parser: p, priority: priority})
+	// A stable sort keeps registration order among equal priorities.
+	sort.SliceStable(r.entries, func(i, j int) bool {
+		return r.entries[i].priority > r.entries[j].priority
+	})
+}
+
+// Parsers returns the registered parsers in the order they are tried.
+func (r *Registry) Parsers() []Parser {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Parser, len(r.entries))
+	for i, e := range r.entries {
+		out[i] = e.parser
+	}
+	return out
+}
+
+// ParserFor returns the parser that handles mimeType, or nil.
+func (r *Registry) ParserFor(mimeType string) Parser {
+	return parserFor(r.Parsers(), mimeType)
+}
+
+// Parse detects the MIME type of buffer with DetectMIME and parses it with
+// the parser registered for that type. Parse failures are a *ParseError.
+func (r *Registry) Parse(buffer []byte, filename string) (*Document, error) {
+	mimeType := DetectMIME(buffer, filename)
+	p := r.ParserFor(mimeType)
+	if p == nil {
+		return nil, fmt.Errorf("%w: %s (%s)", ErrUnsupportedType, mimeType, path.Base(filename))
+	}
+	doc, err := p.Parse(buffer, filename)
+	return doc, parseError(p, filename, err)
+}
+
+// ParseAuto parses buffer with the DefaultRegistry parser for its detected
+// MIME type.
+func ParseAuto(buffer []byte, filename string) (*Document, error) {
+	return DefaultRegistry.Parse(buffer, filename)
+}
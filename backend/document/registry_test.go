@@ -0,0 +1,194 @@
+package document
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// namedParser is a text parser that marks its documents with its name.
+type namedParser struct {
+	name  string
+	types []string
+}
+
+func (p namedParser) Parse(buffer []byte, filename string) (*Document, error) {
+	return &Document{Source: filename, Content: string(buffer), Metadata: map[string]string{"parser": p.name}}, nil
+}
+
+func (p namedParser) Supports(mimeType string) bool {
+	for _, t := range p.types {
+		if t == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.Register(namedParser{"fallback", []string{"text/plain", "text/markdown"}}, PriorityFallback)
+	r.Register(namedParser{"first", []string{"text/plain"}}, PriorityDefault)
+	r.Register(namedParser{"second", []string{"text/plain"}}, PriorityDefault)
+	r.Register(namedParser{"override", []string{"text/csv"}}, PriorityOverride)
+
+	tests := []struct{ mimeType, want string }{
+		{"text/plain", "first"},
+		{"text/markdown", "fallback"},
+		{"text/csv", "override"},
+		{"image/png", ""},
+	}
+	for _, tt := range tests {
+		p := r.ParserFor(tt.mimeType)
+		got := ""
+		if p != nil {
+			got = p.(namedParser).name
+		}
+		if got != tt.want {
+			t.Errorf("ParserFor(%s) = %q, want %q", tt.mimeType, got, tt.want)
+		}
+	}
+	if n := len(r.Parsers()); n != 4 {
+		t.Errorf("%d parsers, want 4", n)
+	}
+
+	doc, err := r.Parse([]byte("a,b\n1,2\n"), "a.csv")
+	if err != nil || doc.Metadata["parser"] != "override" {
+		t.Errorf("Parse = %+v, %v", doc, err)
+	}
+	if _, err := r.Parse([]byte("\x89PNG\r\n\x1a\n"), "a.png"); err == nil {
+		t.Error("Parse of a type without a parser succeeded")
+	}
+}
+
+func TestParseFixtures(t *testing.T) {
+	tests := []struct {
+		file     string
+		parser   string
+		contains string
+		// pages and headings are the least the document must have.
+		pages, headings int
+	}{
+		{"test-txt.txt", "TextParser", "Lorem ipsum dolor sit amet", 0, 0},
+		{"test-text.txt", "TextParser", "Sample Text Document for Chunking Test", 0, 0},
+		{"test-md.md", "MarkdownParser", "Lorem ipsum", 0, 1},
+		{"test-markdown.md", "MarkdownParser", "THINK HARD", 0, 20},
+		{"test-csv.csv", "CSVParser", "Scope Item: Opening Hours changes", 0, 0},
+		{"test-json.json", "JSONParser", "name: @ai-sdk/gateway", 0, 0},
+		{"test-xml.xml", "XMLParser", "XML Developer's Guide", 0, 0},
+		{"test-yaml.yaml", "CodeParser", "Test YAML Configuration File", 0, 0},
+		{"test-html.html", "HTMLParser", "consectetur adipiscing elit", 0, 0},
+		{"test-pdf.pdf", "PDFParser", "consectetur adipiscing elit", 5, 0},
+		{"test-docx.docx", "DocxParser", "# Lorem ipsum", 0, 8},
+		{"test-docx-2.docx", "DocxParser", "Just to clarify my intent", 2, 0},
+		{"test-pptx.pptx", "PPTXParser", "Building Smarter Systems", 25, 0},
+		{"test-epub.epub", "EPUBParser", "consectetur adipiscing elit", 0, 1},
+		{"test-rtf.rtf", "RTFParser", "consectetur adipiscing elit", 0, 0},
+		{"test-zip.zip", "ArchiveParser", "# testdocx.html", 0, 3},
+		{"test-c.c", "CodeParser", "Document processing utilities in C", 0, 0},
+		{"test-cpp.cpp", "CodeParser", "namespace knowledge", 0, 0},
+		{"test-go.go", "CodeParser", "package document", 0, 0},
+		{"test-java.java", "CodeParser", "package com.knowledge.document;", 0, 0},
+		{"test-php.php", "CodeParser", "namespace Knowledge\\Document;", 0, 0},
+		{"test-python.py", "CodeParser", "Test Python module for knowledge system.", 0, 0},
+		{"test-ruby.rb", "CodeParser", "frozen_string_literal", 0, 0},
+		{"test-rust.rs", "CodeParser", "Document processing utilities in Rust", 0, 0},
+		{"test-ts.ts", "CodeParser", "Knowledge service", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			buffer := mustRead(t, fixture(tt.file))
+			p := DefaultRegistry.ParserFor(DetectMIME(buffer, tt.file))
+			if p == nil || parserName(p) != tt.parser {
+				t.Fatalf("parser %v, want %s", p, tt.parser)
+			}
+			doc, err := ParseAuto(buffer, tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(doc.Content, tt.contains) {
+				t.Errorf("content does not contain %q", tt.contains)
+			}
+			if doc.WordCount == 0 {
+				t.Error("word count is 0")
+			}
+			if len(doc.Pages) < tt.pages || len(doc.Headings) < tt.headings {
+				t.Errorf("%d pages and %d headings, want at least %d and %d",
+					len(doc.Pages), len(doc.Headings), tt.pages, tt.headings)
+			}
+			end := 0
+			for _, pg := range doc.Pages {
+				if pg.Start < end || pg.End < pg.Start || pg.End > len(doc.Content) {
+					t.Errorf("page %+v is out of order or outside the content", pg)
+				}
+				end = pg.End
+			}
+			for _, h := range doc.Headings {
+				if h.Offset < 0 || h.Offset > len(doc.Content) || h.Text == "" {
+					t.Errorf("heading %+v is empty or outside the content", h)
+				}
+			}
+		})
+	}
+}
+
+func TestParseUnsupportedFixtures(t *testing.T) {
+	for _, file := range []string{"test-doc.doc", "test-excel.xls"} {
+		if _, err := ParseAuto(mustRead(t, fixture(file)), file); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("%s: err = %v, want ErrUnsupportedType", file, err)
+		}
+	}
+}
+
+// TestParseFormats covers the parsers that have no fixture file.
+func TestParseFormats(t *testing.T) {
+	tests := []struct {
+		file, input, parser string
+		want                []string
+		headings            int
+	}{
+		{"a.adoc", "= Guide\n\n== Install\n\nRun the *installer*.\n", "AsciiDocParser",
+			[]string{"# Guide", "## Install", "Run the *installer*."}, 2},
+		{"a.rst", "Guide\n=====\n\nInstall\n-------\n\nRun the **installer**.\n", "RSTParser",
+			[]string{"# Guide", "## Install", "Run the **installer**."}, 2},
+		{"a.org", "#+TITLE: Guide\n* Install\nRun the /installer/.\n", "OrgParser",
+			[]string{"# Install", "Run the /installer/."}, 1},
+		{"a.tex", `\documentclass{article}\begin{document}\section{Install}Run the \emph{installer}.\end{document}`, "LaTeXParser",
+			[]string{"# Install", "Run the installer."}, 1},
+		{"a.ipynb", `{"cells":[{"cell_type":"markdown","source":["# Install\n","Run the installer."]},{"cell_type":"code","source":["print(1)"],"outputs":[]}],"metadata":{},"nbformat":4,"nbformat_minor":5}`, "IpynbParser",
+			[]string{"# Install", "```\nprint(1)\n```"}, 1},
+		{"a.eml", "From: a@example.com\r\nTo: b@example.com\r\nSubject: Install\r\nContent-Type: text/plain\r\n\r\nRun the installer.\r\n", "EMLParser",
+			[]string{"Subject: Install", "From: a@example.com", "Run the installer."}, 0},
+		{"a.mbox", "From a@example.com Mon Jan  1 00:00:00 2024\nFrom: a@example.com\nSubject: Install\n\nRun the installer.\n", "MBOXParser",
+			[]string{"# Install", "Run the installer."}, 2},
+		{"a.srt", "1\n00:00:01,000 --> 00:00:02,000\nRun the installer.\n\n2\n00:00:03,000 --> 00:00:04,000\nThen reboot.\n", "SubtitleParser",
+			[]string{"Run the installer. Then reboot."}, 0},
+		{"a.vtt", "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nRun the installer.\n", "SubtitleParser",
+			[]string{"Run the installer."}, 0},
+		{"a.log", "2024-01-01T00:00:00Z INFO Run the installer\n2024-01-01T00:00:01Z ERROR reboot failed\n", "LogParser",
+			[]string{"ERROR reboot failed"}, 0},
+		{"a.ics", "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:Install\r\nDESCRIPTION:Run the installer.\r\nDTSTART:20240101T100000Z\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n", "ICSParser",
+			[]string{"# Install", "When: 2024-01-01T10:00:00Z", "Run the installer."}, 1},
+		{"a.sql", "CREATE TABLE installs (id INT, note TEXT);\nINSERT INTO installs VALUES (1, 'Run the installer');\n", "SQLParser",
+			[]string{"# Table installs", "- note: TEXT", "id: 1, note: Run the installer"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if p := DefaultRegistry.ParserFor(DetectMIME([]byte(tt.input), tt.file)); p == nil || parserName(p) != tt.parser {
+				t.Fatalf("parser %v, want %s", p, tt.parser)
+			}
+			doc, err := ParseAuto([]byte(tt.input), tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(doc.Content, want) {
+					t.Errorf("content %q does not contain %q", doc.Content, want)
+				}
+			}
+			if len(doc.Headings) != tt.headings {
+				t.Errorf("%d headings, want %d", len(doc.Headings), tt.headings)
+			}
+		})
+	}
+}
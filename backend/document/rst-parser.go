@@ -0,0 +1,554 @@
+package document
+
+import (
+	"encoding/csv"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// RSTParser converts reStructuredText to Markdown-style text: section
+// titles become "#" headings recorded in Document.Headings, literal blocks
+// and code directives become code fences, tables become pipe rows and
+// admonition directives keep their label.
+type RSTParser struct{}
+
+// NewRSTParser creates a new reStructuredText parser instance.
+func NewRSTParser() *RSTParser {
+	return &RSTParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *RSTParser) Supports(mimeType string) bool {
+	return mimeType == "text/x-rst" || mimeType == "text/prs.fallenstein.rst"
+}
+
+var (
+	rstDirective    = regexp.MustCompile(`^(\s*)\.\.\s+([\w:.+-]+)::(?:\s+(.*))?$`)
+	rstSubstDef     = regexp.MustCompile(`^\.\.\s+\|([^|]+)\|\s+([\w-]+)::\s*(.*)$`)
+	rstField        = regexp.MustCompile(`^:([^:\s][^:]*):(?:\s+(.*))?$`)
+	rstOption       = regexp.MustCompile(`^\s*:[\w-]+:`)
+	rstListItem     = regexp.MustCompile(`^(\s*)([-*+•‣]|#\.|\d+[.)]|\(\d+\)|[a-zA-Z][.)]|\([a-zA-Z]\))\s+(.*)$`)
+	rstSimpleBorder = regexp.MustCompile(`^=+( +=+)+\s*$`)
+
+	rstRole     = regexp.MustCompile(":([\\w:.+-]+):`([^`]+)`")
+	rstLink     = regexp.MustCompile("`([^`<]+?)\\s*<([^>]+)>`__?")
+	rstRef      = regexp.MustCompile("`([^`]+)`(?:__?)?")
+	rstFootRef  = regexp.MustCompile(`\s?\[(?:#[\w-]*|\*|\d+)\]_`)
+	rstCiteRef  = regexp.MustCompile(`\[([A-Za-z][\w.-]*)\]_`)
+	rstWordRef  = regexp.MustCompile(`\b([A-Za-z][\w-]*[A-Za-z0-9])__?(\s|[.,;:!?)]|$)`)
+	rstSubstRef = regexp.MustCompile(`\|([^|\s][^|]*)\|_{0,2}`)
+)
+
+// rstAdmonitions are directives rendered as a "LABEL:" paragraph.
+var rstAdmonitions = map[string]bool{
+	"note": true, "tip": true, "hint": true, "important": true, "warning": true,
+	"caution": true, "danger": true, "error": true, "attention": true, "seealso": true,
+	"versionadded": true, "versionchanged": true, "deprecated": true,
+}
+
+// rstSkipped are directives with no readable content.
+var rstSkipped = map[string]bool{
+	"toctree": true, "contents": true, "index": true, "include": true, "raw": true,
+	"meta": true, "sectnum": true, "highlight": true, "role": true, "default-role": true,
+	"literalinclude": true, "target-notes": true, "header": true, "footer": true,
+	"autosummary": true, "currentmodule": true, "module": true, "tabularcolumns": true,
+}
+
+// rstConv converts reST lines. Section adornment styles are assigned
+// levels in the order they first appear, as docutils does.
+type rstConv struct {
+	w      *markupWriter
+	styles []string
+	subs   map[string]string
+	meta   map[string]string
+	body   bool // whether body text has been written, ending the docinfo
+}
+
+// Parse converts the document. A field list before the body, such as
+// ":Author: Jane", becomes metadata with lower-cased keys.
+func (p *RSTParser) Parse(buffer []byte, filename string) (*Document, error) {
+	src := decodeText(buffer)
+	src = strings.ReplaceAll(src, "\t", "        ")
+	lines := strings.Split(src, "\n")
+	c := &rstConv{w: &markupWriter{}, subs: map[string]string{}, meta: map[string]string{}}
+	// Substitutions can be defined after use.
+	for _, line := range lines {
+		if m := rstSubstDef.FindStringSubmatch(line); m != nil && m[2] == "replace" {
+			c.subs[m[1]] = m[3]
+		}
+	}
+	c.convert(lines)
+	return c.w.document(filename, c.meta)
+}
+
+// rstAdornment reports whether line is a run of one punctuation character
+// usable as a section adornment or transition.
+func rstAdornment(line string) bool {
+	line = strings.TrimRight(line, " ")
+	if len(line) < 2 {
+		return false
+	}
+	ch := line[0]
+	if !strings.ContainsRune("=-`:'\"~^_*+#<>.!$%&(),/;?@[\\]{|}", rune(ch)) {
+		return false
+	}
+	return strings.Count(line, string(ch)) == len(line)
+}
+
+func rstIndent(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// rstBlock returns the end of the indented block starting at i, made of
+// blank lines and lines indented deeper than indent.
+func rstBlock(lines []string, i, indent int) int {
+	end := i
+	for j := i; j < len(lines); j++ {
+		if strings.TrimSpace(lines[j]) == "" {
+			continue
+		}
+		if rstIndent(lines[j]) <= indent {
+			break
+		}
+		end = j + 1
+	}
+	return end
+}
+
+// rstDedent removes the common indentation of lines.
+func rstDedent(lines []string) []string {
+	common := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		if n := rstIndent(l); common < 0 || n < common {
+			common = n
+		}
+	}
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		if len(l) >= common && common > 0 {
+			out[i] = l[common:]
+		} else {
+			out[i] = strings.TrimLeft(l, " ")
+		}
+	}
+	return out
+}
+
+func (c *rstConv) heading(style, text string) {
+	level := 0
+	for i, s := range c.styles {
+		if s == style {
+			level = i + 1
+		}
+	}
+	if level == 0 {
+		c.styles = append(c.styles, style)
+		level = len(c.styles)
+	}
+	c.w.heading(level, c.inline(text))
+}
+
+// convert writes a run of block-level lines.
+func (c *rstConv) convert(lines []string) {
+	w := c.w
+	literal := -1 // indent of a paragraph ending in "::"
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " ")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			w.line("")
+			continue
+		}
+		indent := rstIndent(line)
+		prevBlank := i == 0 || strings.TrimSpace(lines[i-1]) == ""
+		next := ""
+		if i+1 < len(lines) {
+			next = strings.TrimRight(lines[i+1], " ")
+		}
+
+		// A literal block follows a paragraph ending in "::".
+		if literal >= 0 {
+			if indent > literal {
+				end := rstBlock(lines, i, literal)
+				w.fence("", rstDedent(lines[i:end]))
+				i = end - 1
+				literal = -1
+				continue
+			}
+			literal = -1
+		}
+
+		// Sections: an optional overline, the title and an underline at
+		// least as long as the title.
+		if rstAdornment(line) && indent == 0 && i+2 < len(lines) &&
+			strings.TrimRight(lines[i+2], " ") == line && strings.TrimSpace(next) != "" && !rstAdornment(next) {
+			c.heading(line[:1]+"o", strings.TrimSpace(next))
+			i += 2
+			continue
+		}
+		if indent == 0 && !rstAdornment(line) && rstAdornment(next) && rstIndent(next) == 0 &&
+			len(strings.TrimRight(next, " ")) >= utf8.RuneCountInString(trimmed) && !rstDirective.MatchString(line) {
+			c.heading(next[:1], trimmed)
+			i++
+			continue
+		}
+		if rstAdornment(line) && prevBlank && len(trimmed) >= 4 && strings.TrimSpace(next) == "" {
+			continue // transition
+		}
+
+		if strings.HasPrefix(trimmed, "..") && (trimmed == ".." || strings.HasPrefix(trimmed, ".. ")) {
+			i = c.explicit(lines, i, indent) - 1
+			continue
+		}
+
+		if strings.HasPrefix(line, "+-") || strings.HasPrefix(line, "+=") {
+			end := i
+			for end < len(lines) && (strings.HasPrefix(strings.TrimSpace(lines[end]), "+") || strings.HasPrefix(strings.TrimSpace(lines[end]), "|")) {
+				end++
+			}
+			c.gridTable(lines[i:end])
+			i = end - 1
+			continue
+		}
+		if rstSimpleBorder.MatchString(line) {
+			i = c.simpleTable(lines, i) - 1
+			continue
+		}
+
+		if m := rstField.FindStringSubmatch(trimmed); m != nil && indent == 0 {
+			value := strings.TrimSpace(m[2])
+			// Field bodies may continue on indented lines.
+			end := rstBlock(lines, i+1, 0)
+			for _, l := range lines[i+1 : end] {
+				value = strings.TrimSpace(value + " " + strings.TrimSpace(l))
+			}
+			if !c.body {
+				if value != "" {
+					c.meta[strings.ToLower(m[1])] = c.inline(value)
+				}
+			} else {
+				w.line(c.inline(m[1] + ": " + value))
+			}
+			i = max(i, end-1)
+			continue
+		}
+		c.body = true
+
+		if strings.HasPrefix(trimmed, ">>> ") {
+			end := i
+			for end < len(lines) && strings.TrimSpace(lines[end]) != "" {
+				end++
+			}
+			w.fence("python", rstDedent(lines[i:end]))
+			i = end - 1
+			continue
+		}
+		if strings.HasPrefix(trimmed, "| ") || trimmed == "|" {
+			w.line(c.inline(strings.TrimSpace(strings.TrimPrefix(trimmed, "|"))))
+			continue
+		}
+
+		if strings.HasSuffix(trimmed, "::") {
+			literal = indent
+			switch {
+			case trimmed == "::":
+				continue
+			case strings.HasSuffix(trimmed, " ::"):
+				trimmed = strings.TrimSuffix(trimmed, " ::")
+			default:
+				trimmed = strings.TrimSuffix(trimmed, ":")
+			}
+		}
+		if m := rstListItem.FindStringSubmatch(line); m != nil && (prevBlank || rstListItem.MatchString(lines[i-1]) || rstIndent(lines[i-1]) > 0) {
+			marker := m[2]
+			switch {
+			case strings.ContainsAny(marker, "-*+•‣"):
+				marker = "-"
+			case marker == "#.":
+				marker = "1."
+			}
+			text := m[3]
+			if literal >= 0 {
+				text = strings.TrimSuffix(text, ":")
+			}
+			w.line(m[1] + marker + " " + c.inline(text))
+			continue
+		}
+		w.line(c.inline(trimmed))
+	}
+}
+
+// explicit handles an explicit markup block starting at lines[i]:
+// directives, substitution definitions, targets, footnotes and comments.
+// It returns the index after the block.
+func (c *rstConv) explicit(lines []string, i, indent int) int {
+	line := strings.TrimRight(lines[i], " ")
+	end := rstBlock(lines, i+1, indent)
+	body := lines[i+1 : end]
+	trimmed := strings.TrimSpace(line)
+
+	if m := rstSubstDef.FindStringSubmatch(trimmed); m != nil {
+		if m[2] == "image" {
+			for _, l := range body {
+				if name, val, ok := strings.Cut(strings.TrimSpace(l), ":alt:"); ok && name == "" {
+					c.subs[m[1]] = strings.TrimSpace(val)
+				}
+			}
+		}
+		return end
+	}
+	if strings.HasPrefix(trimmed, ".. [") {
+		label, text, _ := strings.Cut(strings.TrimPrefix(trimmed, ".. ["), "]")
+		c.w.line(c.inline("[" + label + "] " + strings.TrimSpace(text)))
+		c.convert(rstDedent(body))
+		return end
+	}
+	m := rstDirective.FindStringSubmatch(line)
+	if m == nil {
+		return end // comment or hyperlink target
+	}
+	name := strings.ToLower(m[2])
+	if _, local, ok := strings.Cut(name, ":"); ok && !strings.Contains(local, ":") {
+		name = local
+	}
+	args := strings.TrimSpace(m[3])
+
+	// Options come first in the body, up to the first blank line.
+	options := map[string]string{}
+	start := 0
+	for start < len(body) && rstOption.MatchString(body[start]) {
+		opt := strings.TrimSpace(body[start])
+		key, val, _ := strings.Cut(opt[1:], ":")
+		options[key] = strings.TrimSpace(val)
+		start++
+	}
+	content := rstDedent(body[start:])
+	w := c.w
+
+	switch {
+	case rstSkipped[name]:
+	case name == "code" || name == "code-block" || name == "sourcecode" || name == "parsed-literal":
+		w.fence(args, content)
+	case name == "math":
+		text := strings.TrimSpace(strings.Join(content, "\n"))
+		if text == "" {
+			text = args
+		}
+		if text != "" {
+			w.line("")
+			w.line("$$" + text + "$$")
+			w.line("")
+		}
+	case rstAdmonitions[name]:
+		w.line("")
+		label := strings.ToUpper(name) + ":"
+		if args != "" {
+			label += " " + c.inline(args)
+		}
+		w.line(label)
+		c.convert(content)
+		w.line("")
+	case name == "admonition" || name == "topic" || name == "sidebar" || name == "rubric":
+		w.line("")
+		if args != "" {
+			w.line(c.inline(args))
+		}
+		c.convert(content)
+		w.line("")
+	case name == "image":
+		if alt := options["alt"]; alt != "" {
+			w.line(c.inline(alt))
+		}
+	case name == "figure":
+		if alt := options["alt"]; alt != "" {
+			w.line(c.inline(alt))
+		}
+		c.convert(content)
+	case name == "csv-table":
+		c.csvTable(args, options, content)
+	case name == "list-table":
+		c.listTable(args, content)
+	default:
+		// table, container, only and unknown or domain directives such as
+		// "py:function" keep their argument and body.
+		w.line("")
+		if args != "" && name != "table" && name != "container" && name != "only" {
+			w.line("`" + args + "`")
+		} else if args != "" && name == "table" {
+			w.line(c.inline(args))
+		}
+		c.convert(content)
+		w.line("")
+	}
+	return end
+}
+
+// rows writes table rows separated by " | ".
+func (c *rstConv) rows(title string, rows [][]string) {
+	c.w.line("")
+	if title != "" {
+		c.w.line(c.inline(title))
+	}
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = c.inline(strings.Join(strings.Fields(cell), " "))
+		}
+		c.w.line(strings.Join(cells, " | "))
+	}
+	c.w.line("")
+}
+
+func (c *rstConv) csvTable(title string, options map[string]string, content []string) {
+	var rows [][]string
+	if h := options["header"]; h != "" {
+		if r, err := csv.NewReader(strings.NewReader(h)).Read(); err == nil {
+			rows = append(rows, r)
+		}
+	}
+	r := csv.NewReader(strings.NewReader(strings.Join(content, "\n")))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	records, _ := r.ReadAll()
+	c.rows(title, append(rows, records...))
+}
+
+// listTable reads a two-level bullet list, one outer item per row.
+func (c *rstConv) listTable(title string, content []string) {
+	var rows [][]string
+	for _, l := range content {
+		t := strings.TrimSpace(l)
+		switch {
+		case strings.HasPrefix(t, "* - "):
+			rows = append(rows, []string{strings.TrimPrefix(t, "* - ")})
+		case strings.HasPrefix(t, "- ") && len(rows) > 0:
+			rows[len(rows)-1] = append(rows[len(rows)-1], strings.TrimPrefix(t, "- "))
+		case t != "" && len(rows) > 0:
+			row := rows[len(rows)-1]
+			row[len(row)-1] += " " + t
+		}
+	}
+	c.rows(title, rows)
+}
+
+// gridTable reads a "+---+" table. Cells spanning several lines are
+// joined.
+func (c *rstConv) gridTable(lines []string) {
+	var rows [][]string
+	var cur []string
+	for _, l := range lines {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, "+") {
+			if cur != nil {
+				rows = append(rows, cur)
+			}
+			cur = nil
+			continue
+		}
+		parts := strings.Split(strings.Trim(l, "|"), "|")
+		if cur == nil {
+			cur = make([]string, len(parts))
+		}
+		for j, p := range parts {
+			if j < len(cur) {
+				cur[j] = strings.TrimSpace(cur[j] + " " + strings.TrimSpace(p))
+			}
+		}
+	}
+	if cur != nil {
+		rows = append(rows, cur)
+	}
+	c.rows("", rows)
+}
+
+// simpleTable reads a table framed by "=== ===" borders, splitting rows at
+// the column positions of the border. It returns the index after the
+// table.
+func (c *rstConv) simpleTable(lines []string, i int) int {
+	border := lines[i]
+	var starts []int
+	for j := 0; j < len(border); j++ {
+		if border[j] == '=' && (j == 0 || border[j-1] == ' ') {
+			starts = append(starts, j)
+		}
+	}
+	var rows [][]string
+	j := i + 1
+	for ; j < len(lines); j++ {
+		l := strings.TrimRight(lines[j], " ")
+		if rstSimpleBorder.MatchString(l) {
+			// The final border is followed by a blank line.
+			if j+1 >= len(lines) || strings.TrimSpace(lines[j+1]) == "" {
+				j++
+				break
+			}
+			continue
+		}
+		if l == "" || rstAdornment(l) {
+			continue
+		}
+		row := make([]string, len(starts))
+		for k, s := range starts {
+			if s >= len(l) {
+				break
+			}
+			e := len(l)
+			if k+1 < len(starts) && starts[k+1] < len(l) {
+				e = starts[k+1]
+			}
+			row[k] = strings.TrimSpace(l[s:e])
+		}
+		// A blank first column continues the previous row.
+		if row[0] == "" && len(rows) > 0 {
+			prev := rows[len(rows)-1]
+			for k := range row {
+				prev[k] = strings.TrimSpace(prev[k] + " " + row[k])
+			}
+			continue
+		}
+		rows = append(rows, row)
+	}
+	c.rows("", rows)
+	return j
+}
+
+// inline reduces roles, hyperlink references and substitutions to their
+// text. Inline literals are kept as `code` and left untouched.
+func (c *rstConv) inline(s string) string {
+	parts := strings.Split(s, "``")
+	for i := range parts {
+		if i%2 == 1 && i < len(parts)-1 {
+			parts[i] = "`" + parts[i] + "`"
+			continue
+		}
+		p := rstRole.ReplaceAllStringFunc(parts[i], func(m string) string {
+			sub := rstRole.FindStringSubmatch(m)
+			text := sub[2]
+			if k := strings.LastIndex(text, " <"); k > 0 && strings.HasSuffix(text, ">") {
+				text = text[:k]
+			}
+			text = strings.TrimPrefix(text, "~")
+			if sub[1] == "math" {
+				return "$" + text + "$"
+			}
+			return text
+		})
+		p = rstLink.ReplaceAllString(p, "$1")
+		p = rstRef.ReplaceAllString(p, "$1")
+		p = rstFootRef.ReplaceAllString(p, "")
+		p = rstCiteRef.ReplaceAllString(p, "[$1]")
+		p = rstSubstRef.ReplaceAllStringFunc(p, func(m string) string {
+			name := strings.TrimRight(m, "_")
+			if v, ok := c.subs[name[1:len(name)-1]]; ok {
+				return v
+			}
+			return m
+		})
+		parts[i] = rstWordRef.ReplaceAllString(p, "$1$2")
+	}
+	return strings.Join(parts, "")
+}
@@ -0,0 +1,36 @@
+package document
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRSTParser(t *testing.T) {
+	const src = ":Author: Ada\n:Version: 1.2\n\n=====\nGuide\n=====\n\nInstall\n-------\n\n" +
+		"Run ``make`` then see `the docs <https://example.com>`_.\n\n" +
+		".. code-block:: go\n\n   fmt.Println(1)\n\n.. note:: Back up first.\n\n.. image:: logo.png\n\n" +
+		".. This is a comment.\n\n=====  =====\nName   Role\n=====  =====\nAda    Author\n=====  =====\n\n" +
+		"Details\n~~~~~~~\n\n- one\n- two\n\n::\n\n   literal text\n"
+	doc, err := NewRSTParser().Parse([]byte(src), "a.rst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Guide\n\n## Install\n\nRun `make` then see the docs.\n\n```go\nfmt.Println(1)\n```\n\n" +
+		"NOTE: Back up first.\n\nName | Role\nAda | Author\n\n### Details\n\n- one\n- two\n\n```\nliteral text\n```"
+	if doc.Content != want {
+		t.Errorf("content %q, want %q", doc.Content, want)
+	}
+	meta := map[string]string{"title": "Guide", "author": "Ada", "version": "1.2"}
+	if !reflect.DeepEqual(doc.Metadata, meta) {
+		t.Errorf("metadata %v, want %v", doc.Metadata, meta)
+	}
+	for _, h := range doc.Headings {
+		if !strings.HasPrefix(doc.Content[h.Offset:], strings.Repeat("#", h.Level)+" "+h.Text) {
+			t.Errorf("heading %+v is not at its offset", h)
+		}
+	}
+	if len(doc.Headings) != 3 {
+		t.Errorf("%d headings, want 3", len(doc.Headings))
+	}
+}
@@ -0,0 +1,216 @@
+package document
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// RTFParser extracts plain text from Rich Text Format documents.
+type RTFParser struct{}
+
+// NewRTFParser creates a new RTF parser instance.
+func NewRTFParser() *RTFParser {
+	return &RTFParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *RTFParser) Supports(mimeType string) bool {
+	return mimeType == "application/rtf" || mimeType == "text/rtf"
+}
+
+// rtfSkipDestinations are groups whose content is never body text.
+var rtfSkipDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "stylesheet": true, "listtable": true,
+	"listoverridetable": true, "revtbl": true, "rsidtbl": true, "generator": true,
+	"pict": true, "object": true, "themedata": true, "colorschememapping": true,
+	"datastore": true, "latentstyles": true, "xmlnstbl": true, "filetbl": true,
+	"header": true, "headerl": true, "headerr": true, "headerf": true,
+	"footer": true, "footerl": true, "footerr": true, "footerf": true,
+	"fldinst": true, "bkmkstart": true, "bkmkend": true, "pgdsctbl": true,
+}
+
+// rtfSymbols maps control words to the text they stand for.
+var rtfSymbols = map[string]string{
+	"par": "\n", "line": "\n", "sect": "\n\n", "page": "\n\n", "tab": "\t",
+	"cell": " | ", "row": "\n", "emdash": "—", "endash": "–", "bullet": "•",
+	"lquote": "‘", "rquote": "’", "ldblquote": "“", "rdblquote": "”",
+	"emspace": " ", "enspace": " ", "qmspace": " ",
+}
+
+// rtfState is the formatting state saved and restored with each group.
+type rtfState struct {
+	skip   bool
+	info   string
+	ucSkip int
+}
+
+// Parse walks the control word stream, honouring group nesting, Unicode
+// escapes (\uN) and code page escapes (\'hh, decoded as Windows-1252).
+// \title and \author from the info group populate the metadata.
+func (p *RTFParser) Parse(buffer []byte, filename string) (*Document, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(buffer), []byte(`{\rtf`)) {
+		return nil, errors.New("not an RTF document")
+	}
+
+	var out strings.Builder
+	info := map[string]*strings.Builder{}
+	state := rtfState{ucSkip: 1}
+	var stack []rtfState
+	pendingSkip := 0
+
+	write := func(s string) {
+		switch {
+		case state.info != "":
+			if info[state.info] == nil {
+				info[state.info] = &strings.Builder{}
+			}
+			info[state.info].WriteString(s)
+		case !state.skip:
+			out.WriteString(s)
+		}
+	}
+
+	data := buffer
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch c {
+		case '{':
+			stack = append(stack, state)
+			i++
+			if bytes.HasPrefix(data[i:], []byte(`\*`)) {
+				state.skip = true
+				i += 2
+			}
+			continue
+		case '}':
+			if n := len(stack); n > 0 {
+				state = stack[n-1]
+				stack = stack[:n-1]
+			}
+			i++
+			continue
+		case '\r', '\n':
+			i++
+			continue
+		case '\\':
+		default:
+			if pendingSkip > 0 {
+				pendingSkip--
+			} else {
+				write(string(rune(c)))
+			}
+			i++
+			continue
+		}
+
+		// Control symbol or control word.
+		i++
+		if i >= len(data) {
+			break
+		}
+		c = data[i]
+		if !isASCIILetter(c) {
+			i++
+			switch c {
+			case '\\', '{', '}':
+				write(string(rune(c)))
+			case '~':
+				write(" ")
+			case '_':
+				write("-")
+			case '\'':
+				if i+2 <= len(data) {
+					if v, err := strconv.ParseUint(string(data[i:i+2]), 16, 8); err == nil {
+						if pendingSkip > 0 {
+							pendingSkip--
+						} else {
+							write(string(windows1252Rune(byte(v))))
+						}
+					}
+					i += 2
+				}
+			case '\n', '\r':
+				write("\n")
+			}
+			continue
+		}
+		start := i
+		for i < len(data) && isASCIILetter(data[i]) {
+			i++
+		}
+		word := string(data[start:i])
+		numStart := i
+		if i < len(data) && data[i] == '-' {
+			i++
+		}
+		for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+			i++
+		}
+		param, hasParam := 0, i > numStart
+		if hasParam {
+			param, _ = strconv.Atoi(string(data[numStart:i]))
+		}
+		if i < len(data) && data[i] == ' ' {
+			i++
+		}
+
+		switch {
+		case word == "u" && hasParam:
+			if param < 0 {
+				param += 65536
+			}
+			write(string(rune(param)))
+			pendingSkip = state.ucSkip
+		case word == "uc" && hasParam:
+			state.ucSkip = param
+		case word == "info":
+			state.skip = true
+		case word == "title" || word == "author" || word == "subject" || word == "keywords":
+			state.info = word
+		case rtfSkipDestinations[word]:
+			state.skip = true
+		case rtfSymbols[word] != "":
+			write(rtfSymbols[word])
+		}
+	}
+
+	content := cleanRTFText(out.String())
+	if content == "" {
+		return nil, ErrEmptyDocument
+	}
+	doc := &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+	}
+	for key, b := range info {
+		if v := strings.TrimSpace(b.String()); v != "" {
+			if doc.Metadata == nil {
+				doc.Metadata = map[string]string{}
+			}
+			doc.Metadata[key] = v
+		}
+	}
+	return doc, nil
+}
+
+// cleanRTFText trims each line and collapses runs of blank lines.
+func cleanRTFText(text string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.Join(strings.Fields(line), " "))
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
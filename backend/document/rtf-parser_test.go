@@ -0,0 +1,54 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRTFParser(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		without []string
+	}{
+		{"plain", `{\rtf1\ansi{\fonttbl{\f0 Times;}}{\colortbl;\red0\green0\blue0;}\f0\fs24 Hello \b world\b0.\par Next line.}`,
+			[]string{"Hello world.", "Next line."}, []string{"Times", "red0"}},
+		{"code page", `{\rtf1\ansi caf\'e9 \'93quoted\'94}`, []string{"café", "“quoted”"}, nil},
+		{"unicode", `{\rtf1\ansi\uc1 Stra\u223?e}`, []string{"Straße"}, []string{"?"}},
+		{"hidden groups", `{\rtf1{\*\generator Writer;}Body{\pict 0a0b}.}`, []string{"Body."}, []string{"Writer", "0a0b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := NewRTFParser().Parse([]byte(tt.input), "a.rtf")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(doc.Content, want) {
+					t.Errorf("content %q does not contain %q", doc.Content, want)
+				}
+			}
+			for _, gone := range tt.without {
+				if strings.Contains(doc.Content, gone) {
+					t.Errorf("content %q contains %q", doc.Content, gone)
+				}
+			}
+		})
+	}
+
+	doc, err := NewRTFParser().Parse([]byte(`{\rtf1{\info{\title Report}{\author Ada}}Text.}`), "a.rtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Metadata["title"] != "Report" || doc.Metadata["author"] != "Ada" || strings.Contains(doc.Content, "Report") {
+		t.Errorf("info group: metadata %v, content %q", doc.Metadata, doc.Content)
+	}
+	fx, err := NewRTFParser().Parse(mustRead(t, fixture("test-rtf.rtf")), "test-rtf.rtf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(fx.Content, "consectetur adipiscing elit") {
+		t.Errorf("fixture content %q", fx.Content[:min(80, len(fx.Content))])
+	}
+}
@@ -0,0 +1,205 @@
+package document
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Loader loads objects from an S3 bucket, or an S3-compatible store
+// such as MinIO or R2, with requests signed by AWS Signature Version 4.
+// Sources are object keys.
+type S3Loader struct {
+	Bucket string
+	// Region defaults to "us-east-1".
+	Region string
+	// Endpoint is the base URL of an S3-compatible store. Defaults to
+	// https://s3.<Region>.amazonaws.com.
+	Endpoint string
+	// PathStyle puts the bucket in the path rather than the host name, as
+	// most S3-compatible stores require.
+	PathStyle bool
+	// AccessKeyID and SecretAccessKey sign requests. SessionToken is set
+	// for temporary credentials. Requests are unsigned when AccessKeyID is
+	// empty, for public buckets.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// MaxRetries is the number of times a failed request is retried, with
+	// exponential backoff. Defaults to 3; negative turns retries off.
+	MaxRetries int
+	// MaxSize rejects objects larger than this many bytes, when positive,
+	// from their Content-Length or once that much is read, so a huge
+	// object is never held in memory. Pipeline sets it from its
+	// MaxInputBytes limit when unset.
+	MaxSize int64
+	// Client defaults to an http.Client with a five minute timeout.
+	Client *http.Client
+}
+
+// NewS3Loader creates a loader for bucket in region with the given
+// credentials.
+func NewS3Loader(bucket, region, accessKeyID, secretAccessKey string) *S3Loader {
+	return &S3Loader{Bucket: bucket, Region: region, AccessKeyID: accessKeyID, SecretAccessKey: secretAccessKey}
+}
+
+// Load downloads the object with key source.
+func (l *S3Loader) Load(ctx context.Context, source string) ([]byte, string, error) {
+	data, err := l.get(ctx, "/"+source, nil, l.MaxSize)
+	if err != nil {
+		return nil, "", fmt.Errorf("get s3 object: %w", err)
+	}
+	return data, source, nil
+}
+
+// withMaxSize returns a copy of l limited to maxSize bytes, unless it has
+// a limit of its own.
+func (l *S3Loader) withMaxSize(maxSize int64) Loader {
+	if l.MaxSize > 0 {
+		return l
+	}
+	c := *l
+	c.MaxSize = maxSize
+	return &c
+}
+
+// List returns the keys of the objects whose keys start with prefix.
+func (l *S3Loader) List(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		query := map[string]string{"list-type": "2", "prefix": prefix}
+		if token != "" {
+			query["continuation-token"] = token
+		}
+		data, err := l.get(ctx, "/", query, 0)
+		if err != nil {
+			return nil, fmt.Errorf("list s3 objects: %w", err)
+		}
+		var page struct {
+			Contents []struct {
+				Key string
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		if err := xml.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("decode s3 listing: %w", err)
+		}
+		for _, c := range page.Contents {
+			if !strings.HasSuffix(c.Key, "/") {
+				keys = append(keys, c.Key)
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// get sends a signed GET for path, relative to the bucket, with query,
+// reading at most maxSize bytes of the response when positive.
+func (l *S3Loader) get(ctx context.Context, path string, query map[string]string, maxSize int64) ([]byte, error) {
+	region := l.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	base := strings.TrimSuffix(l.Endpoint, "/")
+	if base == "" {
+		base = "https://s3." + region + ".amazonaws.com"
+	}
+	scheme, host, _ := strings.Cut(base, "://")
+	if l.PathStyle {
+		path = "/" + l.Bucket + path
+	} else {
+		host = l.Bucket + "." + host
+	}
+	canonicalURI := awsEscape(path, true)
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	params := make([]string, len(keys))
+	for i, k := range keys {
+		params[i] = awsEscape(k, false) + "=" + awsEscape(query[k], false)
+	}
+	canonicalQuery := strings.Join(params, "&")
+
+	header := http.Header{}
+	if l.AccessKeyID != "" {
+		// S3 accepts an unsigned payload, and a GET has none anyway.
+		l.sign(header, host, canonicalURI, canonicalQuery, region, "UNSIGNED-PAYLOAD", time.Now().UTC())
+	}
+	u := scheme + "://" + host + canonicalURI
+	if canonicalQuery != "" {
+		u += "?" + canonicalQuery
+	}
+	rp := retryPolicy{maxRetries: l.MaxRetries, maxBody: maxSize}
+	return rp.send(ctx, httpClient(l.Client, 5*time.Minute), http.MethodGet, u, header, nil, "")
+}
+
+// sign adds the Signature Version 4 headers of a GET request to header.
+func (l *S3Loader) sign(header http.Header, host, canonicalURI, canonicalQuery, region, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	header.Set("X-Amz-Date", amzDate)
+	header.Set("X-Amz-Content-Sha256", payloadHash)
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	values := map[string]string{"host": host, "x-amz-content-sha256": payloadHash, "x-amz-date": amzDate}
+	if l.SessionToken != "" {
+		header.Set("X-Amz-Security-Token", l.SessionToken)
+		signed = append(signed, "x-amz-security-token")
+		values["x-amz-security-token"] = l.SessionToken
+	}
+	var canonicalHeaders strings.Builder
+	for _, h := range signed {
+		canonicalHeaders.WriteString(h + ":" + values[h] + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet, canonicalURI, canonicalQuery, canonicalHeaders.String(), signedHeaders, payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := []byte("AWS4" + l.SecretAccessKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+l.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsEscape percent-encodes s as Signature Version 4 requires: every byte
+// but unreserved characters, and slashes when path is set.
+func awsEscape(s string, path bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', path && c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
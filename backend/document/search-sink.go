@@ -0,0 +1,209 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Search engines supported by SearchSink.
+const (
+	Elasticsearch = "elasticsearch"
+	OpenSearch    = "opensearch"
+)
+
+// SearchSink is a Sink that indexes chunks into Elasticsearch or
+// OpenSearch for hybrid retrieval: the text in an analyzed field for BM25
+// and the embedding in a vector field for kNN, written together in one
+// bulk request per document. Document IDs are ChunkIDs, so writing an
+// unchanged document again overwrites its chunks. The index and its
+// mapping are created on the first write if missing.
+type SearchSink struct {
+	// Endpoint is the base URL of the cluster. Defaults to
+	// http://localhost:9200.
+	Endpoint string
+	// Engine is Elasticsearch or OpenSearch, which map vectors
+	// differently. Defaults to Elasticsearch.
+	Engine string
+	// Index names the index. Defaults to "chunks".
+	Index string
+	// Username and Password are sent with basic auth when set.
+	Username, Password string
+	// APIKey is sent as an Elasticsearch API key when set.
+	APIKey string
+	// Similarity is the vector similarity of a new index: "cosine",
+	// "dot_product" or "l2_norm". Defaults to "cosine".
+	Similarity string
+	// MaxRetries is the number of times a failed request is retried, with
+	// exponential backoff. Defaults to 3; negative turns retries off.
+	MaxRetries int
+	// Client defaults to an http.Client with a one minute timeout.
+	Client *http.Client
+
+	mu    sync.Mutex
+	ready bool
+}
+
+// NewSearchSink creates a sink that writes to index on the engine's
+// cluster at endpoint.
+func NewSearchSink(engine, endpoint, index string) *SearchSink {
+	return &SearchSink{Engine: engine, Endpoint: endpoint, Index: index}
+}
+
+// Write indexes the chunks of doc in a single bulk request. Every chunk
+// must have an Embedding; see Pipeline.Embed.
+func (s *SearchSink) Write(ctx context.Context, doc *Document, chunks []Chunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	for i, c := range chunks {
+		if len(c.Embedding) == 0 {
+			return fmt.Errorf("chunk %d has no embedding", i)
+		}
+	}
+	if err := s.ensureIndex(ctx, len(chunks[0].Embedding)); err != nil {
+		return err
+	}
+
+	source := storeSource(doc)
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, c := range chunks {
+		action := map[string]any{"index": map[string]any{"_index": s.index(), "_id": storeChunkID(source, c)}}
+		fields := map[string]any{
+			"content":     c.Text,
+			"source":      source,
+			"chunk_index": c.Index,
+			"metadata":    chunkStoreMetadata(doc, c),
+			"embedding":   c.Embedding,
+		}
+		if err := enc.Encode(action); err != nil {
+			return err
+		}
+		if err := enc.Encode(fields); err != nil {
+			return err
+		}
+	}
+	data, err := s.request(ctx, http.MethodPost, "/_bulk", body.Bytes(), "application/x-ndjson")
+	if err != nil {
+		return fmt.Errorf("bulk index: %w", err)
+	}
+	return bulkErrors(data)
+}
+
+// ensureIndex creates the index once, if it does not exist.
+func (s *SearchSink) ensureIndex(ctx context.Context, dims int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ready {
+		return nil
+	}
+	path := "/" + url.PathEscape(s.index())
+	_, err := s.request(ctx, http.MethodHead, path, nil, "")
+	var he *httpError
+	if errors.As(err, &he) && he.code == http.StatusNotFound {
+		var mapping []byte
+		if mapping, err = json.Marshal(s.mapping(dims)); err == nil {
+			_, err = s.request(ctx, http.MethodPut, path, mapping, "application/json")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("create index: %w", err)
+	}
+	s.ready = true
+	return nil
+}
+
+// mapping returns the settings and mappings of a new index.
+func (s *SearchSink) mapping(dims int) map[string]any {
+	similarity := s.Similarity
+	if similarity == "" {
+		similarity = "cosine"
+	}
+	properties := map[string]any{
+		"content":     map[string]any{"type": "text"},
+		"source":      map[string]any{"type": "keyword"},
+		"chunk_index": map[string]any{"type": "integer"},
+		"metadata":    map[string]any{"type": "object"},
+	}
+	// Metadata values are filters, not prose, so index them as keywords.
+	dynamic := []map[string]any{{"metadata_keywords": map[string]any{
+		"path_match": "metadata.*",
+		"mapping":    map[string]any{"type": "keyword"},
+	}}}
+	out := map[string]any{"mappings": map[string]any{"dynamic_templates": dynamic, "properties": properties}}
+	if s.Engine == OpenSearch {
+		spaces := map[string]string{"cosine": "cosinesimil", "dot_product": "innerproduct", "l2_norm": "l2"}
+		properties["embedding"] = map[string]any{
+			"type":      "knn_vector",
+			"dimension": dims,
+			"method":    map[string]any{"name": "hnsw", "engine": "lucene", "space_type": spaces[similarity]},
+		}
+		out["settings"] = map[string]any{"index": map[string]any{"knn": true}}
+	} else {
+		properties["embedding"] = map[string]any{
+			"type":       "dense_vector",
+			"dims":       dims,
+			"index":      true,
+			"similarity": similarity,
+		}
+	}
+	return out
+}
+
+func (s *SearchSink) index() string {
+	if s.Index == "" {
+		return "chunks"
+	}
+	return s.Index
+}
+
+// request sends payload to the cluster at path.
+func (s *SearchSink) request(ctx context.Context, method, path string, payload []byte, contentType string) ([]byte, error) {
+	endpoint := strings.TrimSuffix(s.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "http://localhost:9200"
+	}
+	header := http.Header{}
+	switch {
+	case s.APIKey != "":
+		header.Set("Authorization", "ApiKey "+s.APIKey)
+	case s.Username != "":
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(s.Username+":"+s.Password)))
+	}
+	return retryPolicy{maxRetries: s.MaxRetries}.send(ctx, httpClient(s.Client, time.Minute), method, endpoint+path, header, payload, contentType)
+}
+
+// bulkErrors returns the first item error of a bulk response, which the
+// engines report with a 200 status.
+func bulkErrors(data []byte) error {
+	var resp struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID    string          `json:"_id"`
+			Error json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("decode bulk response: %w", err)
+	}
+	if !resp.Errors {
+		return nil
+	}
+	for _, item := range resp.Items {
+		for _, result := range item {
+			if len(result.Error) > 0 {
+				return fmt.Errorf("bulk index %s: %s", result.ID, result.Error)
+			}
+		}
+	}
+	return errors.New("bulk index failed")
+}
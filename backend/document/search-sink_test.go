@@ -0,0 +1,119 @@
+package document
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSearchSink(t *testing.T) {
+	for _, engine := range []string{Elasticsearch, OpenSearch} {
+		t.Run(engine, func(t *testing.T) {
+			var requests []string
+			var mapping map[string]any
+			var lines []map[string]any
+			bulkResponse := `{"errors": false, "items": []}`
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				if user, pass, ok := r.BasicAuth(); !ok || user != "elastic" || pass != "secret" {
+					http.Error(w, "unauthorized", http.StatusUnauthorized)
+					return
+				}
+				switch r.Method {
+				case http.MethodHead:
+					if mapping == nil {
+						w.WriteHeader(http.StatusNotFound)
+					}
+				case http.MethodPut:
+					json.NewDecoder(r.Body).Decode(&mapping)
+					w.Write([]byte(`{"acknowledged": true}`))
+				case http.MethodPost:
+					if r.Header.Get("Content-Type") != "application/x-ndjson" {
+						http.Error(w, "not ndjson", http.StatusBadRequest)
+						return
+					}
+					sc := bufio.NewScanner(r.Body)
+					for sc.Scan() {
+						var line map[string]any
+						json.Unmarshal(sc.Bytes(), &line)
+						lines = append(lines, line)
+					}
+					w.Write([]byte(bulkResponse))
+				}
+			}))
+			defer srv.Close()
+
+			sink := NewSearchSink(engine, srv.URL+"/", "kb")
+			sink.Username, sink.Password = "elastic", "secret"
+			sink.Similarity = "dot_product"
+			doc := &Document{Source: "a.md", Metadata: map[string]string{"lang": "en"}}
+			chunks := []Chunk{
+				{Index: 0, Text: "one", Embedding: []float32{1, 0}},
+				{Index: 1, Text: "two", Embedding: []float32{0, 1}},
+			}
+			ctx := context.Background()
+			if err := sink.Write(ctx, doc, chunks); err != nil {
+				t.Fatal(err)
+			}
+			if err := sink.Write(ctx, doc, chunks[:1]); err != nil {
+				t.Fatal(err)
+			}
+			want := []string{"HEAD /kb", "PUT /kb", "POST /_bulk", "POST /_bulk"}
+			if !reflect.DeepEqual(requests, want) {
+				t.Errorf("requests %q, want %q", requests, want)
+			}
+
+			embedding := mapping["mappings"].(map[string]any)["properties"].(map[string]any)["embedding"].(map[string]any)
+			if engine == OpenSearch {
+				method := embedding["method"].(map[string]any)
+				if embedding["type"] != "knn_vector" || embedding["dimension"] != 2.0 || method["space_type"] != "innerproduct" ||
+					mapping["settings"] == nil {
+					t.Errorf("mapping %v", mapping)
+				}
+			} else if embedding["type"] != "dense_vector" || embedding["dims"] != 2.0 || embedding["similarity"] != "dot_product" {
+				t.Errorf("mapping %v", mapping)
+			}
+
+			if len(lines) != 6 {
+				t.Fatalf("bulk lines %v", lines)
+			}
+			action := lines[2]["index"].(map[string]any)
+			if action["_index"] != "kb" || action["_id"] != ChunkID("a.md", chunks[1]) {
+				t.Errorf("action %v", lines[2])
+			}
+			fields := lines[3]
+			if fields["content"] != "two" || fields["source"] != "a.md" || fields["chunk_index"] != 1.0 ||
+				!reflect.DeepEqual(fields["embedding"], []any{0.0, 1.0}) || fields["metadata"].(map[string]any)["lang"] != "en" {
+				t.Errorf("fields %v", fields)
+			}
+
+			bulkResponse = `{"errors": true, "items": [{"index": {"_id": "x", "error": {"type": "mapper_parsing_exception"}}}]}`
+			if err := sink.Write(ctx, doc, chunks[:1]); err == nil {
+				t.Error("item error returned no error")
+			}
+			if err := sink.Write(ctx, doc, []Chunk{{Text: "one"}}); err == nil {
+				t.Error("chunk without embedding returned no error")
+			}
+		})
+	}
+}
+
+func TestSearchSinkAuth(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"errors": false}`))
+	}))
+	defer srv.Close()
+	sink := &SearchSink{Endpoint: srv.URL, APIKey: "abc", Username: "ignored"}
+	if err := sink.Write(context.Background(), &Document{Source: "a"}, []Chunk{{Text: "x", Embedding: []float32{1}}}); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "ApiKey abc" {
+		t.Errorf("Authorization %q", auth)
+	}
+}
@@ -0,0 +1,156 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// SemanticChunker groups sentences into chunks, starting a new chunk where
+// the embeddings of neighbouring sentences drift apart, which usually
+// marks a change of topic.
+type SemanticChunker struct {
+	// Embedder embeds the sentences. Required.
+	Embedder Embedder
+	// Threshold is the cosine distance between neighbouring sentences
+	// above which a chunk ends. Zero uses BreakpointPercentile instead.
+	Threshold float64
+	// BreakpointPercentile places a boundary at distances above this
+	// percentile of all distances in the text. Defaults to 95.
+	BreakpointPercentile float64
+	// Window is the number of neighbouring sentences on each side that are
+	// embedded with a sentence to smooth out short ones. Defaults to 1;
+	// negative embeds sentences alone.
+	Window int
+	// MaxChunkSize ends a chunk before it would exceed this many tokens
+	// when positive.
+	MaxChunkSize int
+	// Tokenizer measures MaxChunkSize and Overlap. Defaults to
+	// DefaultTokenizer.
+	Tokenizer Tokenizer
+	// Overlap is the number of trailing tokens of each chunk repeated at
+	// the start of the next one, on top of MaxChunkSize.
+	Overlap int
+	// BatchSize is the number of texts sent to the Embedder per call.
+	// Defaults to 64.
+	BatchSize int
+	// Segmenter splits the text into sentences. Defaults to English rules.
+	Segmenter *SentenceSegmenter
+}
+
+// NewSemanticChunker creates a semantic chunker using embedder, configured
+// by opts.
+func NewSemanticChunker(embedder Embedder, opts ...ChunkOption) *SemanticChunker {
+	o := NewOptions(opts...)
+	return &SemanticChunker{Embedder: embedder, MaxChunkSize: o.ChunkSize, Tokenizer: o.Tokenizer, Overlap: o.Overlap, Segmenter: o.segmenter()}
+}
+
+// Split returns the chunks of text.
+func (c *SemanticChunker) Split(ctx context.Context, text string) ([]string, error) {
+	spans, err := c.spans(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(spans))
+	for i, sp := range spans {
+		out[i] = text[sp.start:sp.end]
+	}
+	return out, nil
+}
+
+func (c *SemanticChunker) spans(ctx context.Context, text string) ([]textSpan, error) {
+	if c.Embedder == nil {
+		return nil, errors.New("semantic chunker requires an embedder")
+	}
+	seg := c.Segmenter
+	if seg == nil {
+		seg = defaultSegmenter
+	}
+	sentences := seg.spans(text)
+	if len(sentences) <= 1 {
+		return sentences, nil
+	}
+
+	window := c.Window
+	if window == 0 {
+		window = 1
+	}
+	window = max(window, 0)
+	inputs := make([]string, len(sentences))
+	for i := range sentences {
+		lo, hi := max(i-window, 0), min(i+window, len(sentences)-1)
+		inputs[i] = text[sentences[lo].start:sentences[hi].end]
+	}
+	vectors, err := c.embed(ctx, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	distances := make([]float64, len(sentences)-1)
+	for i := range distances {
+		distances[i] = 1 - cosineSimilarity(vectors[i], vectors[i+1])
+	}
+	threshold := c.Threshold
+	if threshold <= 0 {
+		p := c.BreakpointPercentile
+		if p <= 0 || p > 100 {
+			p = 95
+		}
+		threshold = percentile(distances, p)
+	}
+
+	tok := c.Tokenizer
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	var out []textSpan
+	cur := sentences[0]
+	for i := 1; i < len(sentences); i++ {
+		next := sentences[i]
+		tooBig := c.MaxChunkSize > 0 && tok.Count(text[cur.start:next.end]) > c.MaxChunkSize
+		if distances[i-1] > threshold || tooBig {
+			out = append(out, cur)
+			cur = next
+			continue
+		}
+		cur.end = next.end
+	}
+	return overlapSpans(text, append(out, cur), c.Overlap, tok), nil
+}
+
+// embed calls the Embedder in batches.
+func (c *SemanticChunker) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	batch := c.BatchSize
+	if batch <= 0 {
+		batch = 64
+	}
+	out := make([][]float32, 0, len(texts))
+	for i := 0; i < len(texts); i += batch {
+		vecs, err := c.Embedder.Embed(ctx, texts[i:min(i+batch, len(texts))])
+		if err != nil {
+			return nil, fmt.Errorf("embed sentences: %w", err)
+		}
+		if len(vecs) != min(batch, len(texts)-i) {
+			return nil, fmt.Errorf("embed sentences: got %d vectors for %d texts", len(vecs), min(batch, len(texts)-i))
+		}
+		out = append(out, vecs...)
+	}
+	return out, nil
+}
+
+// percentile returns the p-th percentile of values by linear
+// interpolation.
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	pos := p / 100 * float64(len(sorted)-1)
+	lo := int(pos)
+	if lo >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	return sorted[lo] + (pos-float64(lo))*(sorted[lo+1]-sorted[lo])
+}
@@ -0,0 +1,72 @@
+package document
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// topicEmbedder embeds texts as one of two directions by whether they
+// mention "ipsum", so the semantic strategy breaks between topics.
+type topicEmbedder struct{}
+
+func (topicEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		if strings.Contains(text, "ipsum") {
+			out[i] = []float32{1, 0}
+		} else {
+			out[i] = []float32{0, 1}
+		}
+	}
+	return out, nil
+}
+
+// batchEmbedder records the size of every batch and returns short
+// results when short is set.
+type batchEmbedder struct {
+	batches []int
+	short   bool
+}
+
+func (e *batchEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	e.batches = append(e.batches, len(texts))
+	if e.short {
+		texts = texts[1:]
+	}
+	return topicEmbedder{}.Embed(ctx, texts)
+}
+
+func TestSemanticChunker(t *testing.T) {
+	text := "Lorem ipsum dolor. More ipsum here! Cats purr softly. Dogs bark loudly? Final ipsum words."
+	c := &SemanticChunker{Embedder: topicEmbedder{}, Threshold: 0.5, Window: -1}
+	got, err := c.Split(context.Background(), text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"Lorem ipsum dolor. More ipsum here!", "Cats purr softly. Dogs bark loudly?", "Final ipsum words."}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Split = %q, want %q", got, want)
+	}
+
+	c.MaxChunkSize = 3
+	got, _ = c.Split(context.Background(), text)
+	if len(got) != 5 {
+		t.Errorf("MaxChunkSize split into %q, want one sentence per chunk", got)
+	}
+
+	e := &batchEmbedder{}
+	if _, err := (&SemanticChunker{Embedder: e, BatchSize: 2}).Split(context.Background(), text); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(e.batches, []int{2, 2, 1}) {
+		t.Errorf("batches = %v", e.batches)
+	}
+	if _, err := (&SemanticChunker{Embedder: &batchEmbedder{short: true}}).Split(context.Background(), text); err == nil {
+		t.Error("short embedding batch accepted")
+	}
+	if _, err := (&SemanticChunker{}).Split(context.Background(), text); err == nil {
+		t.Error("chunker without an embedder succeeded")
+	}
+}
@@ -0,0 +1,68 @@
+package document
+
+import "strconv"
+
+// SentenceChunker emits one chunk per sentence, or per run of consecutive
+// sentences that fits in ChunkSize, for fine-grained retrieval. A sentence
+// longer than ChunkSize becomes a chunk on its own.
+type SentenceChunker struct {
+	// ChunkSize is the token budget of a group. Defaults to 50.
+	ChunkSize int
+	// MaxSentences caps the sentences per chunk when positive; 1 gives
+	// one chunk per sentence.
+	MaxSentences int
+	// Tokenizer measures chunk size. Defaults to DefaultTokenizer.
+	Tokenizer Tokenizer
+	// Segmenter finds the sentences. Defaults to English rules.
+	Segmenter *SentenceSegmenter
+}
+
+// NewSentenceChunker creates a new sentence chunker instance
+// configured by opts.
+func NewSentenceChunker(opts ...ChunkOption) *SentenceChunker {
+	o := NewOptions(opts...)
+	return &SentenceChunker{ChunkSize: o.ChunkSize, Tokenizer: o.Tokenizer, Segmenter: o.segmenter()}
+}
+
+// Chunk returns the sentence groups of text. Metadata "sentence_start"
+// and "sentence_end" hold the 0-based indexes of the first and last
+// sentence in the chunk.
+func (c *SentenceChunker) Chunk(text string) []Chunk {
+	size := c.ChunkSize
+	if size <= 0 {
+		size = 50
+	}
+	tok := c.Tokenizer
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	seg := c.Segmenter
+	if seg == nil {
+		seg = defaultSegmenter
+	}
+
+	var out []Chunk
+	sentences := seg.spans(text)
+	for first := 0; first < len(sentences); {
+		last := first
+		for last+1 < len(sentences) &&
+			(c.MaxSentences <= 0 || last+1-first < c.MaxSentences) &&
+			tok.Count(text[sentences[first].start:sentences[last+1].end]) <= size {
+			last++
+		}
+		sp := textSpan{sentences[first].start, sentences[last].end}
+		out = append(out, Chunk{
+			Index:       len(out),
+			Text:        text[sp.start:sp.end],
+			StartOffset: sp.start,
+			EndOffset:   sp.end,
+			TokenCount:  tok.Count(text[sp.start:sp.end]),
+			Metadata: map[string]string{
+				"sentence_start": strconv.Itoa(first),
+				"sentence_end":   strconv.Itoa(last),
+			},
+		})
+		first = last + 1
+	}
+	return out
+}
@@ -0,0 +1,35 @@
+package document
+
+import "testing"
+
+func TestSentenceChunker(t *testing.T) {
+	text := "One two. Three four five. Six. Seven eight nine ten eleven."
+	tests := []struct {
+		name string
+		c    *SentenceChunker
+		want []string // text and sentence range
+	}{
+		{"grouped", &SentenceChunker{ChunkSize: 5}, []string{
+			"One two. Three four five.", "0-1",
+			"Six.", "2-2",
+			"Seven eight nine ten eleven.", "3-3",
+		}},
+		{"one per chunk", &SentenceChunker{MaxSentences: 1}, []string{
+			"One two.", "0-0", "Three four five.", "1-1", "Six.", "2-2", "Seven eight nine ten eleven.", "3-3",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := tt.c.Chunk(text)
+			if len(chunks)*2 != len(tt.want) {
+				t.Fatalf("got %d chunks, want %d", len(chunks), len(tt.want)/2)
+			}
+			for i, c := range chunks {
+				span := c.Metadata["sentence_start"] + "-" + c.Metadata["sentence_end"]
+				if c.Text != tt.want[2*i] || span != tt.want[2*i+1] {
+					t.Errorf("chunk %d = %q sentences %s, want %q %s", i, c.Text, span, tt.want[2*i], tt.want[2*i+1])
+				}
+			}
+		})
+	}
+}
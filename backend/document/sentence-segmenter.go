@@ -0,0 +1,283 @@
+package document
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SentenceSegmenter finds sentence boundaries with punctuation rules that
+// skip abbreviations ("e.g.", "Dr."), initials, decimals and ellipses
+// inside a sentence. Paragraph breaks always end a sentence.
+type SentenceSegmenter struct {
+	// Language selects the abbreviation list, as an ISO 639-1 code such as
+	// "en", "de" or "fr". Unknown or empty languages use English.
+	Language string
+	// Abbreviations are extra abbreviations, without their final period,
+	// that do not end a sentence.
+	Abbreviations []string
+}
+
+// NewSentenceSegmenter creates a sentence segmenter for English text.
+func NewSentenceSegmenter() *SentenceSegmenter {
+	return &SentenceSegmenter{}
+}
+
+// defaultSegmenter is used by the chunkers.
+var defaultSegmenter = &SentenceSegmenter{}
+
+// sentenceAbbreviations lists common abbreviations per language, lower
+// case and without the final period.
+var sentenceAbbreviations = map[string][]string{
+	"en": {
+		"mr", "mrs", "ms", "dr", "prof", "sr", "jr", "st", "mt", "ft", "gov", "capt", "lt", "sgt",
+		"rev", "inc", "ltd", "co", "corp", "bros", "dept", "univ",
+		"e.g", "i.e", "vs", "cf", "al", "approx", "misc", "jan", "feb", "apr", "jun", "jul", "aug",
+		"sep", "sept", "oct", "nov", "dec", "tue", "thu", "ave", "blvd", "rd", "u.s", "u.k", "a.m",
+		"p.m", "ph.d",
+	},
+	"de": {
+		"hr", "fr", "frl", "dr", "prof", "z.b", "bzw", "usw", "ca", "vgl", "evtl", "d.h", "u.a",
+		"o.ä", "s.o", "s.u", "u.u", "ggf", "inkl", "zzgl", "bspw", "sog", "str", "tel", "abs",
+		"jh", "mio", "mrd", "allg", "gem", "lt", "geb", "gest", "v.a", "z.t", "u.ä", "usf",
+	},
+	"fr": {
+		"m", "mm", "mme", "mmes", "mlle", "mlles", "dr", "pr", "me", "st", "ste", "p.ex", "c.-à-d",
+		"cf", "env", "av", "bd", "vol", "éd", "chap", "fig", "janv", "févr", "avr", "juil", "sept",
+		"oct", "nov", "déc",
+	},
+	"es": {
+		"sr", "sra", "srta", "dr", "dra", "lic", "ing", "ud", "uds", "vd", "vds", "pág", "págs",
+		"art", "cap", "av", "avda", "ej", "aprox", "ee.uu", "dto", "núm", "tel", "admón",
+	},
+	"it": {
+		"sig", "sigg", "sig.ra", "dott", "dr", "prof", "ing", "avv", "arch", "on", "ecc", "pag",
+		"ca", "cfr", "es", "tel", "vol",
+	},
+	"pt": {
+		"sr", "sra", "srta", "dr", "dra", "prof", "eng", "av", "pág", "págs", "ex", "aprox",
+		"tel", "vol", "cap", "séc",
+	},
+	"nl": {
+		"dhr", "mevr", "mw", "dr", "prof", "ir", "ing", "drs", "mr", "o.a", "m.a.w", "d.w.z",
+		"bijv", "bv", "ca", "enz", "evt", "nl", "resp", "t.a.v", "i.p.v", "z.g.a",
+	},
+}
+
+// numberAbbreviations only count as abbreviations before a number, as in
+// "No. 5" or "fig. 3", since they are also ordinary words.
+var numberAbbreviations = map[string]bool{
+	"no": true, "nos": true, "nr": true, "vol": true, "fig": true, "figs": true, "p": true,
+	"pp": true, "ch": true, "sec": true, "art": true, "op": true, "eq": true, "ref": true,
+}
+
+// abbreviationSets caches sentenceAbbreviations as sets.
+var abbreviationSets = func() map[string]map[string]bool {
+	sets := map[string]map[string]bool{}
+	for lang, list := range sentenceAbbreviations {
+		set := map[string]bool{}
+		for _, a := range list {
+			set[a] = true
+		}
+		sets[lang] = set
+	}
+	return sets
+}()
+
+// Split returns the sentences of text with surrounding whitespace
+// trimmed.
+func (s *SentenceSegmenter) Split(text string) []string {
+	spans := s.spans(text)
+	out := make([]string, len(spans))
+	for i, sp := range spans {
+		out[i] = text[sp.start:sp.end]
+	}
+	return out
+}
+
+// isSentenceTerminator reports whether r ends sentences. wide reports
+// whether it is a full-width CJK form, which needs no following space.
+func isSentenceTerminator(r rune) (ok, wide bool) {
+	switch r {
+	case '.', '!', '?', '…', '‼', '⁇', '⁈', '⁉', '؟', '।', '॥', '።', '‽':
+		return true, false
+	case '。', '！', '？', '．', '｡':
+		return true, true
+	}
+	return false, false
+}
+
+// isSentenceCloser reports whether r is a quote or bracket that may follow
+// the terminator of the sentence it closes.
+func isSentenceCloser(r rune) bool {
+	return strings.ContainsRune("\"')]}”’»›」』）】〉》", r)
+}
+
+// abbreviation reports whether the lower-case word is an abbreviation. It
+// takes bytes so that callers can lower words without allocating.
+func (s *SentenceSegmenter) abbreviation(word []byte) bool {
+	lang := strings.ToLower(s.Language)
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	set := abbreviationSets[lang]
+	if set == nil {
+		set = abbreviationSets["en"]
+	}
+	if set[string(word)] {
+		return true
+	}
+	for _, a := range s.Abbreviations {
+		if strings.EqualFold(strings.TrimSuffix(a, "."), string(word)) {
+			return true
+		}
+	}
+	return false
+}
+
+// appendLower appends the lower case of s to b.
+func appendLower(b []byte, s string) []byte {
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			if 'A' <= r && r <= 'Z' {
+				r += 'a' - 'A'
+			}
+			b = append(b, byte(r))
+			continue
+		}
+		b = utf8.AppendRune(b, unicode.ToLower(r))
+	}
+	return b
+}
+
+func (s *SentenceSegmenter) spans(text string) []textSpan {
+	var out []textSpan
+	s.eachSpan(text, func(sp textSpan) {
+		out = append(out, sp)
+	})
+	return out
+}
+
+// eachSpan calls fn with the span of each sentence of text in order,
+// without collecting them.
+func (s *SentenceSegmenter) eachSpan(text string, fn func(textSpan)) {
+	start := 0
+	emit := func(end int) {
+		if sp := trimSpan(text, textSpan{start, end}); sp.end > sp.start {
+			fn(sp)
+		}
+		start = end
+	}
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if r == '\n' {
+			// A blank line ends the sentence even without punctuation.
+			j := i + size
+			for j < len(text) && (text[j] == ' ' || text[j] == '\t' || text[j] == '\r') {
+				j++
+			}
+			if j < len(text) && text[j] == '\n' {
+				emit(i)
+			}
+			i += size
+			continue
+		}
+		ok, wide := isSentenceTerminator(r)
+		if !ok {
+			i += size
+			continue
+		}
+
+		// Take the whole run of terminators, as in "?!" or "...", and any
+		// closing quotes or brackets.
+		end := i
+		dots := true
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if ok, w := isSentenceTerminator(r); !ok {
+				break
+			} else {
+				wide = wide || w
+			}
+			dots = dots && (r == '.' || r == '…')
+			end += size
+		}
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !isSentenceCloser(r) {
+				break
+			}
+			end += size
+		}
+		if s.boundary(text, i, end, wide, dots) {
+			emit(end)
+		}
+		i = end
+	}
+	emit(len(text))
+}
+
+// boundary decides whether the terminator run text[at:end] ends a
+// sentence.
+func (s *SentenceSegmenter) boundary(text string, at, end int, wide, dots bool) bool {
+	if wide {
+		return true
+	}
+	if end == len(text) {
+		return true
+	}
+	next, _ := utf8.DecodeRuneInString(text[end:])
+	if !unicode.IsSpace(next) {
+		// "3.14", "example.com" and "e.g.," continue the sentence.
+		return false
+	}
+	rest := strings.TrimLeftFunc(text[end:], unicode.IsSpace)
+	if rest == "" {
+		return true
+	}
+	if strings.Contains(text[end:len(text)-len(rest)], "\n\n") {
+		return true
+	}
+	if !dots {
+		return true
+	}
+	following, _ := utf8.DecodeRuneInString(rest)
+	if unicode.IsLower(following) {
+		// Sentences rarely start in lower case; this catches unlisted
+		// abbreviations and ellipses inside a sentence.
+		return false
+	}
+	if text[at] != '.' || end-at > 1 && text[at+1] == '.' {
+		return true
+	}
+
+	// The word before the period, including inner periods as in "e.g",
+	// lowered into buf: this runs at most periods of the text, and most
+	// words fit, so they need no allocation.
+	wordStart := at
+	for wordStart > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:wordStart])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '-' {
+			break
+		}
+		wordStart -= size
+	}
+	var buf [32]byte
+	word := appendLower(buf[:0], strings.Trim(text[wordStart:at], ".-"))
+	if len(word) == 0 {
+		return true
+	}
+	if s.abbreviation(word) {
+		return false
+	}
+	if numberAbbreviations[string(word)] && unicode.IsDigit(following) {
+		return false
+	}
+	// Initials such as "J. R. R. Tolkien".
+	if first, size := utf8.DecodeRune(word); size == len(word) && unicode.IsLetter(first) {
+		if r, _ := utf8.DecodeRuneInString(text[wordStart:]); unicode.IsUpper(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package document
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSentenceSegmenter(t *testing.T) {
+	tests := []struct {
+		name string
+		seg  *SentenceSegmenter
+		text string
+		want []string
+	}{
+		{"abbreviations and times", NewSentenceSegmenter(),
+			"Dr. Smith arrived at 3.30 p.m. on Monday. He said hi! Did you see it?! Yes.",
+			[]string{"Dr. Smith arrived at 3.30 p.m. on Monday.", "He said hi!", "Did you see it?!", "Yes."}},
+		{"ellipsis and number abbreviation", NewSentenceSegmenter(),
+			"See e.g. the docs. Prices rose by 2.5 percent... then fell. No. 5 is next.",
+			[]string{"See e.g. the docs.", "Prices rose by 2.5 percent... then fell.", "No. 5 is next."}},
+		{"quotes and paragraphs", NewSentenceSegmenter(),
+			"He wrote \"Stop.\" Then he left.\n\nNew paragraph without a period\n\nAnother",
+			[]string{"He wrote \"Stop.\"", "Then he left.", "New paragraph without a period", "Another"}},
+		{"initials and CJK", NewSentenceSegmenter(),
+			"J. R. R. Tolkien wrote it. 東京に行きました。明日帰ります。",
+			[]string{"J. R. R. Tolkien wrote it.", "東京に行きました。", "明日帰ります。"}},
+		{"domain", NewSentenceSegmenter(), "Visit example.com today. Ok", []string{"Visit example.com today.", "Ok"}},
+		{"German", &SentenceSegmenter{Language: "de-DE"},
+			"Das ist z.B. gut. Wir gehen bzw. Fahren morgen.",
+			[]string{"Das ist z.B. gut.", "Wir gehen bzw. Fahren morgen."}},
+		{"English rules on German", NewSentenceSegmenter(),
+			"Das ist z.B. gut. Wir gehen bzw. Fahren morgen.",
+			[]string{"Das ist z.B. gut.", "Wir gehen bzw.", "Fahren morgen."}},
+		{"extra abbreviations", &SentenceSegmenter{Abbreviations: []string{"Approx."}},
+			"We ran approx. Ten miles. The Acme Corp. Team won.",
+			[]string{"We ran approx. Ten miles.", "The Acme Corp. Team won."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.seg.Split(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecursiveSplitterSentences(t *testing.T) {
+	s := &RecursiveSplitter{ChunkSize: 5, Tokenizer: WhitespaceTokenizer{}}
+	got := s.Split("Dr. Smith went home early. It rained hard all day.")
+	want := []string{"Dr. Smith went home early.", "It rained hard all day."}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Split = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,499 @@
+package document
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SQLParser reads SQL dumps from mysqldump, pg_dump, sqlite3 .dump and
+// similar tools, rendering each table's schema and data as readable text.
+// Scripts without CREATE TABLE or INSERT statements are parsed as source
+// code by CodeParser.
+type SQLParser struct {
+	// MaxRows limits the rows rendered per table when positive. Rows past
+	// the limit are still counted.
+	MaxRows int
+}
+
+// NewSQLParser creates a new SQL dump parser instance.
+func NewSQLParser() *SQLParser {
+	return &SQLParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *SQLParser) Supports(mimeType string) bool {
+	switch mimeType {
+	case "application/sql", "text/x-sql", "application/x-sql":
+		return true
+	}
+	return false
+}
+
+// sqlTable is the schema and data collected for one table.
+type sqlTable struct {
+	name        string
+	columns     []string
+	defs        []string
+	constraints []string
+	rows        []string
+	rowCount    int
+}
+
+// Parse renders every table under a "# Table name" heading, listing its
+// columns and constraints followed by one "column: value" line per row.
+// Each table's section is recorded in Document.Segments with "table",
+// "columns" and "rows" metadata, and "tables" lists every table name.
+func (p *SQLParser) Parse(buffer []byte, filename string) (*Document, error) {
+	tables := p.tables(buffer)
+	if len(tables) == 0 {
+		return NewCodeParser().Parse(buffer, filename)
+	}
+	var b strings.Builder
+	var headings []Heading
+	var segments []Segment
+	names := make([]string, len(tables))
+	total := 0
+	for i, t := range tables {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		start := b.Len()
+		headings = append(headings, Heading{Level: 1, Text: "Table " + t.name, Offset: start})
+		b.WriteString(t.text())
+		segments = append(segments, Segment{Start: start, End: b.Len(), Metadata: t.metadata()})
+		names[i] = t.name
+		total += t.rowCount
+	}
+	content := b.String()
+	return &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Headings:  headings,
+		Segments:  segments,
+		Metadata: map[string]string{
+			"tables": strings.Join(names, ", "),
+			"rows":   strconv.Itoa(total),
+		},
+	}, nil
+}
+
+// ParseAll returns one document per table with "table", "columns" and
+// "rows" metadata.
+func (p *SQLParser) ParseAll(buffer []byte, filename string) ([]*Document, error) {
+	tables := p.tables(buffer)
+	if len(tables) == 0 {
+		doc, err := NewCodeParser().Parse(buffer, filename)
+		if err != nil {
+			return nil, err
+		}
+		return []*Document{doc}, nil
+	}
+	docs := make([]*Document, len(tables))
+	for i, t := range tables {
+		text := t.text()
+		docs[i] = &Document{
+			Content:   text,
+			Source:    filename,
+			WordCount: len(strings.Fields(text)),
+			Metadata:  t.metadata(),
+		}
+	}
+	return docs, nil
+}
+
+func (t *sqlTable) text() string {
+	lines := []string{"# Table " + t.name}
+	if len(t.defs) > 0 {
+		lines = append(lines, "", "Columns:")
+		for _, d := range t.defs {
+			lines = append(lines, "- "+d)
+		}
+	}
+	if len(t.constraints) > 0 {
+		lines = append(lines, "", "Constraints:")
+		for _, c := range t.constraints {
+			lines = append(lines, "- "+c)
+		}
+	}
+	if len(t.rows) > 0 {
+		label := "Rows:"
+		if len(t.rows) < t.rowCount {
+			label = "Rows (first " + strconv.Itoa(len(t.rows)) + " of " + strconv.Itoa(t.rowCount) + "):"
+		}
+		lines = append(lines, "", label)
+		lines = append(lines, t.rows...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (t *sqlTable) metadata() map[string]string {
+	meta := map[string]string{"table": t.name, "rows": strconv.Itoa(t.rowCount)}
+	if len(t.columns) > 0 {
+		meta["columns"] = strings.Join(t.columns, ", ")
+	}
+	return meta
+}
+
+var (
+	sqlCreateTable = regexp.MustCompile(`(?is)^CREATE\s+(?:OR\s+REPLACE\s+)?(?:(?:GLOBAL|LOCAL|TEMP|TEMPORARY|UNLOGGED|VIRTUAL)\s+)*TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?`)
+	sqlInsert      = regexp.MustCompile(`(?is)^(?:INSERT|REPLACE)\s+(?:(?:LOW_PRIORITY|DELAYED|HIGH_PRIORITY|IGNORE|OR\s+\w+)\s+)*INTO\s+`)
+	sqlValues      = regexp.MustCompile(`(?is)^VALUES\s*`)
+	sqlCopy        = regexp.MustCompile(`(?is)^COPY\s+`)
+	sqlAlterAdd    = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:ONLY\s+|IF\s+EXISTS\s+)*`)
+	sqlConstraint  = regexp.MustCompile(`(?i)^(?:CONSTRAINT|PRIMARY\s+KEY|FOREIGN\s+KEY|UNIQUE|KEY|INDEX|CHECK|FULLTEXT|SPATIAL|EXCLUDE)\b`)
+)
+
+// tables collects the tables in order of first appearance.
+func (p *SQLParser) tables(buffer []byte) []*sqlTable {
+	var tables []*sqlTable
+	byName := map[string]*sqlTable{}
+	table := func(name string) *sqlTable {
+		key := strings.ToLower(name)
+		if t := byName[key]; t != nil {
+			return t
+		}
+		t := &sqlTable{name: name}
+		byName[key] = t
+		tables = append(tables, t)
+		return t
+	}
+	addRow := func(t *sqlTable, columns []string, values []*string) {
+		t.rowCount++
+		if p.MaxRows > 0 && len(t.rows) >= p.MaxRows {
+			return
+		}
+		if columns == nil {
+			columns = t.columns
+		}
+		var fields []string
+		for i, v := range values {
+			if v == nil {
+				continue
+			}
+			s := strings.Join(strings.Fields(*v), " ")
+			if s == "" {
+				continue
+			}
+			name := "column " + strconv.Itoa(i+1)
+			if i < len(columns) {
+				name = columns[i]
+			}
+			fields = append(fields, name+": "+s)
+		}
+		if len(fields) > 0 {
+			t.rows = append(t.rows, strings.Join(fields, ", "))
+		}
+	}
+
+	for _, st := range splitSQLStatements(decodeText(buffer)) {
+		stmt := st.text
+		switch {
+		case sqlCreateTable.MatchString(stmt):
+			rest := stmt[len(sqlCreateTable.FindString(stmt)):]
+			name, rest := readSQLIdent(rest)
+			body, ok := sqlParenthesized(strings.TrimSpace(rest))
+			if name == "" || !ok {
+				continue
+			}
+			t := table(name)
+			for _, item := range splitSQLTopLevel(body) {
+				item = strings.Join(strings.Fields(item), " ")
+				if item == "" {
+					continue
+				}
+				if sqlConstraint.MatchString(item) {
+					t.constraints = append(t.constraints, item)
+					continue
+				}
+				col, def := readSQLIdent(item)
+				t.columns = append(t.columns, col)
+				if def = strings.TrimSpace(def); def != "" {
+					col += ": " + def
+				}
+				t.defs = append(t.defs, col)
+			}
+		case sqlInsert.MatchString(stmt):
+			rest := stmt[len(sqlInsert.FindString(stmt)):]
+			name, rest := readSQLIdent(rest)
+			rest = strings.TrimSpace(rest)
+			var columns []string
+			if list, ok := sqlParenthesized(rest); ok {
+				for _, c := range splitSQLTopLevel(list) {
+					c, _ = readSQLIdent(strings.TrimSpace(c))
+					columns = append(columns, c)
+				}
+				rest = strings.TrimSpace(rest[len(list)+2:])
+			}
+			m := sqlValues.FindString(rest)
+			if name == "" || m == "" {
+				continue
+			}
+			t := table(name)
+			rest = rest[len(m):]
+			for {
+				rest = strings.TrimLeft(rest, " \t\r\n,")
+				tuple, ok := sqlParenthesized(rest)
+				if !ok {
+					break
+				}
+				rest = rest[len(tuple)+2:]
+				var values []*string
+				for _, v := range splitSQLTopLevel(tuple) {
+					values = append(values, sqlValue(v))
+				}
+				addRow(t, columns, values)
+			}
+		case sqlCopy.MatchString(stmt) && st.copyData != nil:
+			name, rest := readSQLIdent(stmt[len(sqlCopy.FindString(stmt)):])
+			if name == "" {
+				continue
+			}
+			t := table(name)
+			var columns []string
+			if list, ok := sqlParenthesized(strings.TrimSpace(rest)); ok {
+				for _, c := range splitSQLTopLevel(list) {
+					c, _ = readSQLIdent(strings.TrimSpace(c))
+					columns = append(columns, c)
+				}
+			}
+			for _, line := range st.copyData {
+				var values []*string
+				for _, v := range strings.Split(line, "\t") {
+					if v == `\N` {
+						values = append(values, nil)
+						continue
+					}
+					v = sqlCopyUnescape.Replace(v)
+					values = append(values, &v)
+				}
+				addRow(t, columns, values)
+			}
+		case sqlAlterAdd.MatchString(stmt):
+			name, rest := readSQLIdent(stmt[len(sqlAlterAdd.FindString(stmt)):])
+			rest = strings.TrimSpace(rest)
+			if t := byName[strings.ToLower(name)]; t != nil && len(rest) > 4 && strings.EqualFold(rest[:4], "ADD ") {
+				if c := strings.Join(strings.Fields(rest[4:]), " "); sqlConstraint.MatchString(c) {
+					t.constraints = append(t.constraints, c)
+				}
+			}
+		}
+	}
+	return tables
+}
+
+var sqlCopyUnescape = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r")
+
+// sqlStatement is one statement with the data lines that follow a
+// "COPY ... FROM stdin" statement.
+type sqlStatement struct {
+	text     string
+	copyData []string
+}
+
+var sqlCopyFromStdin = regexp.MustCompile(`(?is)^COPY\s.*\bFROM\s+stdin\b`)
+
+// splitSQLStatements splits a script at semicolons outside strings, quoted
+// identifiers, dollar-quoted bodies and comments, which are removed.
+func splitSQLStatements(src string) []sqlStatement {
+	src = strings.TrimPrefix(strings.ReplaceAll(src, "\r\n", "\n"), "\ufeff")
+	var out []sqlStatement
+	var cur strings.Builder
+	flush := func() bool {
+		s := strings.TrimSpace(cur.String())
+		cur.Reset()
+		if s == "" {
+			return false
+		}
+		out = append(out, sqlStatement{text: s})
+		return true
+	}
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '-' && strings.HasPrefix(src[i:], "--"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			cur.WriteByte('\n')
+		case c == '/' && strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				i = len(src)
+			} else {
+				i += end + 3
+			}
+			cur.WriteByte(' ')
+		case c == '\'' || c == '"' || c == '`':
+			j := sqlQuoteEnd(src, i)
+			cur.WriteString(src[i:j])
+			i = j - 1
+		case c == '$':
+			// Dollar quoting: $$...$$ or $tag$...$tag$.
+			if m := sqlDollarTag.FindString(src[i:]); m != "" {
+				end := strings.Index(src[i+len(m):], m)
+				if end >= 0 {
+					cur.WriteString(src[i : i+len(m)+end+len(m)])
+					i += len(m) + end + len(m) - 1
+					continue
+				}
+			}
+			cur.WriteByte(c)
+		case c == ';':
+			if flush() && sqlCopyFromStdin.MatchString(out[len(out)-1].text) {
+				// The data rows follow on the next lines up to "\.".
+				if nl := strings.IndexByte(src[i:], '\n'); nl >= 0 {
+					i += nl + 1
+					data := []string{}
+					for i < len(src) {
+						end := strings.IndexByte(src[i:], '\n')
+						if end < 0 {
+							end = len(src) - i
+						}
+						line := src[i : i+end]
+						i += end
+						if line == `\.` {
+							break
+						}
+						data = append(data, line)
+						i++
+					}
+					out[len(out)-1].copyData = data
+				}
+			}
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+	return out
+}
+
+var sqlDollarTag = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// sqlQuoteEnd returns the index just past the quoted string starting at
+// i. Quotes are escaped by doubling them or, in MySQL dumps, with a
+// backslash.
+func sqlQuoteEnd(s string, i int) int {
+	q := s[i]
+	for j := i + 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			if q == '\'' || q == '"' {
+				j++
+			}
+		case q:
+			if j+1 < len(s) && s[j+1] == q {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+// readSQLIdent reads a possibly quoted and schema-qualified identifier,
+// returning it unquoted along with the rest of s.
+func readSQLIdent(s string) (string, string) {
+	s = strings.TrimLeft(s, " \t\n")
+	var parts []string
+	for {
+		if s == "" {
+			break
+		}
+		var part string
+		switch s[0] {
+		case '`', '"', '[':
+			end := byte(s[0])
+			if end == '[' {
+				end = ']'
+			}
+			j := strings.IndexByte(s[1:], end)
+			if j < 0 {
+				return "", s
+			}
+			part, s = s[1:j+1], s[j+2:]
+		default:
+			j := 0
+			for j < len(s) && (s[j] == '_' || s[j] == '$' || s[j] >= 0x80 ||
+				s[j] >= 'a' && s[j] <= 'z' || s[j] >= 'A' && s[j] <= 'Z' || s[j] >= '0' && s[j] <= '9') {
+				j++
+			}
+			if j == 0 {
+				return strings.Join(parts, "."), s
+			}
+			part, s = s[:j], s[j:]
+		}
+		parts = append(parts, part)
+		if !strings.HasPrefix(s, ".") {
+			break
+		}
+		s = s[1:]
+	}
+	return strings.Join(parts, "."), s
+}
+
+// sqlParenthesized returns the text inside the parentheses that s starts
+// with.
+func sqlParenthesized(s string) (string, bool) {
+	if !strings.HasPrefix(s, "(") {
+		return "", false
+	}
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'', '"', '`':
+			i = sqlQuoteEnd(s, i) - 1
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s[1:i], true
+			}
+		}
+	}
+	return "", false
+}
+
+// splitSQLTopLevel splits s at commas outside parentheses and quotes.
+func splitSQLTopLevel(s string) []string {
+	var out []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\'', '"', '`':
+			i = sqlQuoteEnd(s, i) - 1
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				out = append(out, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(out, s[start:])
+}
+
+var sqlStringUnescape = strings.NewReplacer(`\\`, `\`, `\'`, "'", `\"`, `"`, `\n`, "\n", `\r`, "\r", `\t`, "\t", `\0`, "", `''`, "'")
+
+// sqlValue converts a literal to its text, or nil for NULL. String
+// prefixes such as N'...' and E'...' are dropped.
+func sqlValue(v string) *string {
+	v = strings.TrimSpace(v)
+	if strings.EqualFold(v, "NULL") {
+		return nil
+	}
+	if len(v) >= 3 && v[1] == '\'' && strings.ContainsRune("NnEe", rune(v[0])) {
+		v = v[1:]
+	}
+	if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+		v = sqlStringUnescape.Replace(v[1 : len(v)-1])
+	}
+	return &v
+}
@@ -0,0 +1,49 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+const sqlDump = "-- MySQL dump\n/*!40101 SET NAMES utf8 */;\n" +
+	"CREATE TABLE `users` (\n  `id` int NOT NULL,\n  `name` varchar(50) DEFAULT NULL,\n  PRIMARY KEY (`id`)\n);\n" +
+	"INSERT INTO `users` VALUES (1,'Ada'),(2,'O\\'Brien; Jr'),(3,NULL);\n" +
+	"COPY public.orders (id, item) FROM stdin;\n1\tbook\n2\t\\N\n\\.\n" +
+	"ALTER TABLE ONLY public.orders ADD CONSTRAINT orders_pkey PRIMARY KEY (id);\n"
+
+func TestSQLParser(t *testing.T) {
+	doc, err := NewSQLParser().Parse([]byte(sqlDump), "dump.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	users := "# Table users\n\nColumns:\n- id: int NOT NULL\n- name: varchar(50) DEFAULT NULL\n\n" +
+		"Constraints:\n- PRIMARY KEY (`id`)\n\nRows:\nid: 1, name: Ada\nid: 2, name: O'Brien; Jr\nid: 3"
+	orders := "# Table public.orders\n\nConstraints:\n- CONSTRAINT orders_pkey PRIMARY KEY (id)\n\nRows:\nid: 1, item: book\nid: 2"
+	if want := users + "\n\n" + orders; doc.Content != want {
+		t.Errorf("content = %q, want %q", doc.Content, want)
+	}
+	if doc.Metadata["tables"] != "users, public.orders" || doc.Metadata["rows"] != "5" {
+		t.Errorf("metadata = %v", doc.Metadata)
+	}
+	if len(doc.Segments) != 2 || doc.Segments[1].Start != len(users)+2 || doc.Segments[0].Metadata["columns"] != "id, name" {
+		t.Errorf("segments = %+v", doc.Segments)
+	}
+
+	docs, err := (&SQLParser{MaxRows: 1}).ParseAll([]byte(sqlDump), "dump.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || !strings.HasSuffix(docs[0].Content, "Rows (first 1 of 3):\nid: 1, name: Ada") || docs[1].Metadata["rows"] != "2" {
+		t.Errorf("ParseAll with MaxRows = %+v", docs)
+	}
+}
+
+func TestSQLParserScript(t *testing.T) {
+	doc, err := NewSQLParser().Parse([]byte("SELECT 1;\n"), "query.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Content != "SELECT 1;\n" || len(doc.Headings) != 0 {
+		t.Errorf("script parsed as %+v", doc)
+	}
+}
@@ -0,0 +1,152 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// StreamChunker splits UTF-8 text read from an io.Reader as it arrives and
+// sends each chunk on a channel once it is complete, so chunks can be
+// embedded while the rest of a very large input is still being read. It
+// splits like RecursiveSplitter, holding only a few chunks' worth of text
+// at a time. Chunk offsets index into the whole stream, with invalid
+// UTF-8 replaced by U+FFFD.
+type StreamChunker struct {
+	// ChunkSize is the maximum chunk size in tokens. Defaults to 200.
+	ChunkSize int
+	// Separators are tried in order, as by RecursiveSplitter. Defaults to
+	// DefaultSeparators.
+	Separators []string
+	// Tokenizer measures chunk size. Defaults to DefaultTokenizer.
+	Tokenizer Tokenizer
+	// Buffer is the capacity of the chunk channel. Defaults to 16.
+	Buffer int
+	// ReadSize is the number of bytes read at a time. Defaults to 64 KB.
+	ReadSize int
+}
+
+// NewStreamChunker creates a new streaming chunker instance configured by
+// opts.
+func NewStreamChunker(opts ...ChunkOption) *StreamChunker {
+	o := NewOptions(opts...)
+	return &StreamChunker{ChunkSize: o.ChunkSize, Tokenizer: o.Tokenizer}
+}
+
+// Stream reads r in the background and sends its chunks, numbered in
+// order, on the returned chunk channel, which is closed at the end of the
+// input. A read failure, or ctx being done, stops the stream and is sent
+// on the error channel, which is closed after the chunk channel.
+func (c *StreamChunker) Stream(ctx context.Context, r io.Reader) (<-chan Chunk, <-chan error) {
+	buffer := c.Buffer
+	if buffer <= 0 {
+		buffer = 16
+	}
+	chunks := make(chan Chunk, buffer)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(chunks)
+		if err := c.stream(ctx, r, chunks); err != nil {
+			errs <- err
+		}
+	}()
+	return chunks, errs
+}
+
+// stream does the work of Stream.
+func (c *StreamChunker) stream(ctx context.Context, r io.Reader, out chan<- Chunk) error {
+	readSize := c.ReadSize
+	if readSize <= 0 {
+		readSize = 64 * 1024
+	}
+	size := c.ChunkSize
+	if size <= 0 {
+		size = 200
+	}
+	tok := c.Tokenizer
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	s := &RecursiveSplitter{ChunkSize: size, Separators: c.Separators, Tokenizer: tok}
+
+	var pending []byte
+	// base is the stream offset of pending[0].
+	base, index := 0, 0
+	send := func(text string, sp textSpan) error {
+		ch := Chunk{
+			Index:       index,
+			Text:        text[sp.start:sp.end],
+			StartOffset: base + sp.start,
+			EndOffset:   base + sp.end,
+			TokenCount:  tok.Count(text[sp.start:sp.end]),
+		}
+		select {
+		case out <- ch:
+			index++
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	buf := make([]byte, readSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, readErr := r.Read(buf)
+		pending = append(pending, buf[:n]...)
+		eof := errors.Is(readErr, io.EOF)
+		if readErr != nil && !eof {
+			return readErr
+		}
+
+		// Hold back a character cut off by the read.
+		complete := len(pending)
+		if !eof {
+			complete = completeUTF8(pending)
+		}
+		if !utf8.Valid(pending[:complete]) {
+			valid := strings.ToValidUTF8(string(pending[:complete]), "\ufffd")
+			pending = append([]byte(valid), pending[complete:]...)
+			complete = len(valid)
+		}
+		text := string(pending[:complete])
+		if eof || tok.Count(text) >= 4*size {
+			spans := s.spans(text)
+			keep := len(text)
+			if !eof && len(spans) > 0 {
+				// The last chunk may continue in text not read yet.
+				keep = spans[len(spans)-1].start
+				spans = spans[:len(spans)-1]
+			}
+			for _, sp := range spans {
+				if err := send(text, sp); err != nil {
+					return err
+				}
+			}
+			pending = append(pending[:0], pending[keep:]...)
+			base += keep
+		}
+		if eof {
+			return nil
+		}
+	}
+}
+
+// completeUTF8 returns the length of the prefix of b that does not end
+// inside a multi-byte character.
+func completeUTF8(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(b)
+}
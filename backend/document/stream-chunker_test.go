@@ -0,0 +1,66 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestStreamChunker(t *testing.T) {
+	text := string(mustRead(t, fixture("test-markdown.md"))) + "\n\nÜber Größe: 東京タワー."
+	c := &StreamChunker{ChunkSize: 40, ReadSize: 7, Buffer: 1}
+	chunks, errs := c.Stream(context.Background(), iotest.HalfReader(strings.NewReader(text)))
+	var words []string
+	index, end := 0, 0
+	for ch := range chunks {
+		if ch.Index != index {
+			t.Errorf("chunk %d has Index %d", index, ch.Index)
+		}
+		index++
+		if ch.StartOffset < end || ch.EndOffset > len(text) || text[ch.StartOffset:ch.EndOffset] != ch.Text {
+			t.Fatalf("chunk %d at %d-%d does not match the stream", ch.Index, ch.StartOffset, ch.EndOffset)
+		}
+		end = ch.EndOffset
+		if ch.TokenCount > 40 || ch.TokenCount != DefaultTokenizer.Count(ch.Text) {
+			t.Errorf("chunk %d has %d tokens", ch.Index, ch.TokenCount)
+		}
+		words = append(words, strings.Fields(ch.Text)...)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Fields(text); strings.Join(words, " ") != strings.Join(want, " ") || index < 10 {
+		t.Errorf("%d chunks lost or reordered words", index)
+	}
+}
+
+func TestStreamChunkerErrors(t *testing.T) {
+	broken := errors.New("broken pipe")
+	chunks, errs := NewStreamChunker(WithChunkSize(5)).Stream(context.Background(), iotest.ErrReader(broken))
+	for range chunks {
+	}
+	if err := <-errs; !errors.Is(err, broken) {
+		t.Errorf("err = %v, want the read error", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	chunks, errs = NewStreamChunker().Stream(ctx, bytes.NewReader([]byte("one two")))
+	for range chunks {
+	}
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+
+	// Invalid UTF-8 becomes U+FFFD in the chunks and their offsets.
+	chunks, errs = NewStreamChunker().Stream(context.Background(), strings.NewReader("caf\xe9 ok"))
+	if ch := <-chunks; ch.Text != "caf� ok" || ch.EndOffset != 9 {
+		t.Errorf("chunk %+v", ch)
+	}
+	if err := <-errs; err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,95 @@
+package document
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// streamPeekSize is how much of a stream is examined to detect its charset
+// and, for CSV, its delimiter.
+const streamPeekSize = 64 * 1024
+
+// StreamParser is implemented by parsers that can read a document from an
+// io.Reader as they go, so large files need not be buffered into a []byte
+// first. Formats that need random access, such as PDF and ZIP packages,
+// are not stream parsers.
+type StreamParser interface {
+	Parser
+	ParseReader(r io.Reader, filename string) (*Document, error)
+}
+
+// ParseReader parses the document read from r with p, streaming when p is
+// a StreamParser and reading r fully first otherwise. Failures are a
+// *ParseError.
+func ParseReader(p Parser, r io.Reader, filename string) (*Document, error) {
+	if sp, ok := p.(StreamParser); ok {
+		doc, err := sp.ParseReader(r, filename)
+		return doc, parseError(p, filename, err)
+	}
+	buffer, err := io.ReadAll(r)
+	if err != nil {
+		return nil, parseError(p, filename, err)
+	}
+	doc, err := p.Parse(buffer, filename)
+	return doc, parseError(p, filename, err)
+}
+
+// peekText wraps r in a buffered reader, skips a UTF-8 byte order mark
+// and reports whether the start of the stream is UTF-8 text that can be
+// read as it comes.
+func peekText(r io.Reader) (*bufio.Reader, bool) {
+	br := bufio.NewReaderSize(r, streamPeekSize)
+	head, _ := br.Peek(streamPeekSize)
+	bom := bytes.HasPrefix(head, []byte("\xef\xbb\xbf"))
+	// The peek may end inside a character.
+	head = head[:completeUTF8(head)]
+	isUTF8 := DetectCharset(head) == "utf-8"
+	if bom {
+		br.Discard(3)
+	}
+	return br, isUTF8
+}
+
+// readText reads all of r as decodeText would decode it, without holding
+// the raw bytes as well as the text when the stream is UTF-8. It returns
+// the text and its source charset.
+func readText(r io.Reader) (string, string, error) {
+	br, isUTF8 := peekText(r)
+	if !isUTF8 {
+		buffer, err := io.ReadAll(br)
+		if err != nil {
+			return "", "", err
+		}
+		return decodeText(buffer), DetectCharset(buffer), nil
+	}
+	var b strings.Builder
+	if _, err := io.Copy(&b, br); err != nil {
+		return "", "", err
+	}
+	text := b.String()
+	if !utf8.ValidString(text) {
+		// Only the start was UTF-8; detect the charset of the whole.
+		return decodeText([]byte(text)), DetectCharset([]byte(text)), nil
+	}
+	return normalizeNewlines(text), "utf-8", nil
+}
+
+// maxSizeReader fails once more than max bytes have been read from r.
+type maxSizeReader struct {
+	r    io.Reader
+	max  int
+	read int
+}
+
+func (m *maxSizeReader) Read(b []byte) (int, error) {
+	n, err := m.r.Read(b)
+	m.read += n
+	if m.read > m.max {
+		return n, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, m.max)
+	}
+	return n, err
+}
@@ -0,0 +1,50 @@
+package document
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"testing/iotest"
+)
+
+func TestParseReader(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser Parser
+		file   string
+		input  []byte
+	}{
+		{"text fixture", NewTextParser(), "test-text.txt", mustRead(t, fixture("test-text.txt"))},
+		{"text bom", NewTextParser(), "a.txt", []byte("\xef\xbb\xbfone\r\ntwo")},
+		{"text cp1252", NewTextParser(), "a.txt", []byte("caf\xe9 \x93ok\x94")},
+		{"csv fixture", NewCSVParser(), "test-csv.csv", mustRead(t, fixture("test-csv.csv"))},
+		{"csv semicolons", NewCSVParser(), "a.csv", []byte("name;city\nAda;London\n")},
+		{"csv latin-1", NewCSVParser(), "a.csv", []byte("name,city\nZo\xeb,K\xf6ln\n")},
+		{"not a stream parser", NewMarkdownParser(), "a.md", []byte("# Title\n\nBody.")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := tt.parser.Parse(tt.input, tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseReader(tt.parser, iotest.OneByteReader(bytes.NewReader(tt.input)), tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Content != want.Content || !reflect.DeepEqual(got.Metadata, want.Metadata) {
+				t.Errorf("ParseReader = %q %v, Parse = %q %v", got.Content, got.Metadata, want.Content, want.Metadata)
+			}
+		})
+	}
+}
+
+func TestParseReaderError(t *testing.T) {
+	broken := errors.New("broken pipe")
+	for _, p := range []Parser{NewTextParser(), NewCSVParser(), NewMarkdownParser()} {
+		if _, err := ParseReader(p, iotest.ErrReader(broken), "a.txt"); !errors.Is(err, broken) {
+			t.Errorf("%T: err = %v, want the read error", p, err)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package document
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SubtitleParser reads SRT and WebVTT captions and merges consecutive cues
+// into passages, keeping each passage's start and end time so answers can
+// link to the moment in the video.
+type SubtitleParser struct {
+	// MaxDuration caps the length of a passage. Defaults to 30 seconds.
+	MaxDuration time.Duration
+	// MaxGap starts a new passage when the silence between cues exceeds it.
+	// Defaults to 2 seconds.
+	MaxGap time.Duration
+}
+
+// NewSubtitleParser creates a new subtitle parser instance.
+func NewSubtitleParser() *SubtitleParser {
+	return &SubtitleParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *SubtitleParser) Supports(mimeType string) bool {
+	switch mimeType {
+	case "application/x-subrip", "text/srt", "text/vtt":
+		return true
+	}
+	return false
+}
+
+// subtitleCue is a single timed caption.
+type subtitleCue struct {
+	start, end time.Duration
+	text       string
+}
+
+// Parse joins passages with blank lines and records each one in
+// Document.Segments with "start" and "end" timestamps (HH:MM:SS.mmm) and
+// "start_seconds" for building deep links.
+func (p *SubtitleParser) Parse(buffer []byte, filename string) (*Document, error) {
+	passages, err := p.passages(buffer)
+	if err != nil {
+		return nil, err
+	}
+	return subtitleDocument(passages, filename), nil
+}
+
+// ParseAll returns one document per passage, with the timestamps in its
+// metadata.
+func (p *SubtitleParser) ParseAll(buffer []byte, filename string) ([]*Document, error) {
+	passages, err := p.passages(buffer)
+	if err != nil {
+		return nil, err
+	}
+	return subtitleDocuments(passages, filename), nil
+}
+
+// subtitleDocument joins timed passages into a single document.
+func subtitleDocument(passages []subtitleCue, filename string) *Document {
+	var b strings.Builder
+	segments := make([]Segment, 0, len(passages))
+	for _, ps := range passages {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		start := b.Len()
+		b.WriteString(ps.text)
+		segments = append(segments, Segment{Start: start, End: b.Len(), Metadata: subtitleMetadata(ps)})
+	}
+	content := b.String()
+	return &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Segments:  segments,
+		Metadata: map[string]string{
+			"duration": formatSubtitleTime(passages[len(passages)-1].end),
+		},
+	}
+}
+
+// subtitleDocuments returns a document per timed passage.
+func subtitleDocuments(passages []subtitleCue, filename string) []*Document {
+	docs := make([]*Document, len(passages))
+	for i, ps := range passages {
+		docs[i] = &Document{
+			Content:   ps.text,
+			Source:    filename,
+			WordCount: len(strings.Fields(ps.text)),
+			Metadata:  subtitleMetadata(ps),
+		}
+	}
+	return docs
+}
+
+func subtitleMetadata(c subtitleCue) map[string]string {
+	return map[string]string{
+		"start":         formatSubtitleTime(c.start),
+		"end":           formatSubtitleTime(c.end),
+		"start_seconds": strconv.FormatFloat(c.start.Seconds(), 'f', -1, 64),
+	}
+}
+
+func (p *SubtitleParser) passages(buffer []byte) ([]subtitleCue, error) {
+	cues, err := parseSubtitleCues(decodeText(buffer))
+	if err != nil {
+		return nil, err
+	}
+	return mergeSubtitleCues(cues, p.MaxDuration, p.MaxGap)
+}
+
+// mergeSubtitleCues merges cues until the passage would exceed maxDur or
+// the next cue starts after more than maxGap of silence, with defaults of
+// 30 and 2 seconds. Repeated lines, as produced by roll-up captions, are
+// dropped.
+func mergeSubtitleCues(cues []subtitleCue, maxDur, maxGap time.Duration) ([]subtitleCue, error) {
+	if maxDur <= 0 {
+		maxDur = 30 * time.Second
+	}
+	if maxGap <= 0 {
+		maxGap = 2 * time.Second
+	}
+
+	var out []subtitleCue
+	var cur *subtitleCue
+	var lines []string
+	last := ""
+	flush := func() {
+		if cur != nil && len(lines) > 0 {
+			cur.text = strings.Join(lines, " ")
+			out = append(out, *cur)
+		}
+		cur, lines = nil, nil
+	}
+	for _, c := range cues {
+		if cur != nil && (c.start-cur.end > maxGap || c.end-cur.start > maxDur) {
+			flush()
+		}
+		for _, line := range strings.Split(c.text, "\n") {
+			if line == "" || line == last {
+				continue
+			}
+			last = line
+			if cur == nil {
+				cur = &subtitleCue{start: c.start}
+			}
+			lines = append(lines, line)
+		}
+		if cur != nil {
+			cur.end = max(cur.end, c.end)
+		}
+	}
+	flush()
+	if len(out) == 0 {
+		return nil, ErrEmptyDocument
+	}
+	return out, nil
+}
+
+var (
+	subtitleTiming = regexp.MustCompile(`^\s*((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})\s*-->\s*((?:\d+:)?\d{1,2}:\d{2}[,.]\d{1,3})`)
+	subtitleVoice  = regexp.MustCompile(`<v(?:\.[^\s>]*)?\s+([^>]+)>`)
+	subtitleTag    = regexp.MustCompile(`</?[a-zA-Z][^>]*>|<\d[\d:.]*>|\{\\[^}]*\}`)
+)
+
+// parseSubtitleCues reads SRT or WebVTT cue blocks. Blocks without a timing
+// line, such as the WEBVTT header and NOTE, STYLE or REGION blocks, are
+// skipped. Voice spans (<v Name>) become "Name: " prefixes and other markup
+// is removed.
+func parseSubtitleCues(src string) ([]subtitleCue, error) {
+	src = strings.TrimPrefix(strings.ReplaceAll(src, "\r\n", "\n"), "\ufeff")
+	var cues []subtitleCue
+	for _, block := range strings.Split(src, "\n\n") {
+		lines := strings.Split(strings.Trim(block, "\n"), "\n")
+		timing := -1
+		for i, line := range lines {
+			if subtitleTiming.MatchString(line) {
+				timing = i
+				break
+			}
+			if i >= 1 {
+				break
+			}
+		}
+		if timing < 0 {
+			continue
+		}
+		m := subtitleTiming.FindStringSubmatch(lines[timing])
+		start, err := parseSubtitleTime(m[1])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseSubtitleTime(m[2])
+		if err != nil {
+			return nil, err
+		}
+		var text []string
+		for _, line := range lines[timing+1:] {
+			line = subtitleVoice.ReplaceAllString(line, "$1: ")
+			line = subtitleTag.ReplaceAllString(line, "")
+			line = strings.Join(strings.Fields(decodeSubtitleEntities(line)), " ")
+			if line != "" {
+				text = append(text, line)
+			}
+		}
+		if len(text) > 0 {
+			cues = append(cues, subtitleCue{start: start, end: end, text: strings.Join(text, "\n")})
+		}
+	}
+	return cues, nil
+}
+
+var subtitleEntities = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&nbsp;", " ", "&lrm;", "", "&rlm;", "")
+
+func decodeSubtitleEntities(s string) string {
+	return subtitleEntities.Replace(s)
+}
+
+// parseSubtitleTime parses "HH:MM:SS,mmm", "HH:MM:SS.mmm" or "MM:SS.mmm".
+func parseSubtitleTime(s string) (time.Duration, error) {
+	s = strings.Replace(s, ",", ".", 1)
+	parts := strings.Split(s, ":")
+	var h, m int
+	var err error
+	switch len(parts) {
+	case 3:
+		if h, err = strconv.Atoi(parts[0]); err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		parts = parts[1:]
+		fallthrough
+	case 2:
+		if m, err = strconv.Atoi(parts[0]); err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+	default:
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	sec, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+		time.Duration(sec*float64(time.Second)+0.5), nil
+}
+
+func formatSubtitleTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
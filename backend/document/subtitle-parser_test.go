@@ -0,0 +1,56 @@
+package document
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubtitleParser(t *testing.T) {
+	const srt = "1\r\n00:00:01,000 --> 00:00:02,500\r\n<i>Welcome</i> back.\r\n\r\n" +
+		"2\r\n00:00:02,600 --> 00:00:04,000\r\nToday: R&amp;D.\r\n\r\n" +
+		"3\r\n00:00:10,000 --> 00:00:12,000\r\nAfter the break.\r\n"
+	const vtt = "WEBVTT\n\nNOTE a comment\n\n00:01.000 --> 00:03.000\n<v Ada>Hello there\n\n" +
+		"00:03.000 --> 00:05.000\n<v Ada>Hello there\nHow are you?\n"
+	tests := []struct {
+		name     string
+		parser   *SubtitleParser
+		input    string
+		want     []string
+		firstEnd string
+	}{
+		{"srt gap", NewSubtitleParser(), srt, []string{"Welcome back. Today: R&D.", "After the break."}, "00:00:04.000"},
+		{"srt duration", &SubtitleParser{MaxDuration: 2 * time.Second, MaxGap: time.Minute}, srt,
+			[]string{"Welcome back.", "Today: R&D.", "After the break."}, "00:00:02.500"},
+		{"vtt roll-up", NewSubtitleParser(), vtt, []string{"Ada: Hello there How are you?"}, "00:00:05.000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := tt.parser.Parse([]byte(tt.input), "a.srt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(doc.Segments) != len(tt.want) {
+				t.Fatalf("%d segments in %q, want %d", len(doc.Segments), doc.Content, len(tt.want))
+			}
+			for i, seg := range doc.Segments {
+				if got := doc.Content[seg.Start:seg.End]; got != tt.want[i] {
+					t.Errorf("segment %d is %q, want %q", i, got, tt.want[i])
+				}
+			}
+			if got := doc.Segments[0].Metadata["end"]; got != tt.firstEnd {
+				t.Errorf("first passage ends at %s, want %s", got, tt.firstEnd)
+			}
+		})
+	}
+
+	docs, err := NewSubtitleParser().ParseAll([]byte(srt), "a.srt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[1].Metadata["start"] != "00:00:10.000" || docs[1].Metadata["start_seconds"] != "10" {
+		t.Errorf("ParseAll metadata %v", docs[len(docs)-1].Metadata)
+	}
+	if _, err := NewSubtitleParser().Parse([]byte("WEBVTT\n"), "a.vtt"); err == nil {
+		t.Error("captions without cues parsed without error")
+	}
+}
@@ -0,0 +1,264 @@
+package document
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TableChunker keeps tables in Markdown, HTML, CSV or "a | b | c" row form
+// whole when they fit in ChunkSize, and otherwise splits them between rows
+// with the header repeated at the top of every piece. Text around the
+// tables is split by RecursiveSplitter.
+type TableChunker struct {
+	// ChunkSize is the maximum chunk size in tokens. A single row larger
+	// than this still becomes one chunk with its header. Defaults to 200.
+	ChunkSize int
+	// Tokenizer measures chunk size. Defaults to DefaultTokenizer.
+	Tokenizer Tokenizer
+}
+
+// NewTableChunker creates a new table-aware chunker instance
+// configured by opts.
+func NewTableChunker(opts ...ChunkOption) *TableChunker {
+	o := NewOptions(opts...)
+	return &TableChunker{ChunkSize: o.ChunkSize, Tokenizer: o.Tokenizer}
+}
+
+// textTable is a table found in text. header and footer are repeated
+// around every piece of a split table.
+type textTable struct {
+	span           textSpan
+	header, footer string
+	rows           []textSpan
+}
+
+var (
+	markdownTableRule = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	htmlTableOpen     = regexp.MustCompile(`(?i)<table\b`)
+	htmlTableTag      = regexp.MustCompile(`(?i)</?(table|tr)\b[^>]*>`)
+)
+
+// Split returns the chunks of text with surrounding whitespace trimmed.
+func (c *TableChunker) Split(text string) []string {
+	return chunkTexts(c.chunks(text))
+}
+
+// chunks returns the chunks of text. A piece of a split table covers the
+// source range of its rows.
+func (c *TableChunker) chunks(text string) []Chunk {
+	size := c.ChunkSize
+	if size <= 0 {
+		size = 200
+	}
+	tok := c.Tokenizer
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	splitter := &RecursiveSplitter{ChunkSize: size, Tokenizer: tok}
+
+	var out []Chunk
+	add := func(s string, start, end int) {
+		out = append(out, Chunk{Index: len(out), Text: s, StartOffset: start, EndOffset: end, TokenCount: tok.Count(s)})
+	}
+	prose := func(start, end int) {
+		for _, sp := range splitter.spans(text[start:end]) {
+			add(text[start+sp.start:start+sp.end], start+sp.start, start+sp.end)
+		}
+	}
+	pos := 0
+	for _, t := range findTables(text) {
+		prose(pos, t.span.start)
+		pos = t.span.end
+		if sp := trimSpan(text, t.span); tok.Count(text[sp.start:sp.end]) <= size || len(t.rows) < 2 {
+			add(text[sp.start:sp.end], sp.start, sp.end)
+			continue
+		}
+		var rows []string
+		var first, last textSpan
+		flush := func() {
+			if len(rows) > 0 {
+				add(strings.TrimSpace(t.header+strings.Join(rows, "\n")+t.footer), first.start, last.end)
+			}
+			rows = nil
+		}
+		for _, r := range t.rows {
+			row := strings.TrimSpace(text[r.start:r.end])
+			if len(rows) > 0 && tok.Count(t.header+strings.Join(rows, "\n")+"\n"+row+t.footer) > size {
+				flush()
+			}
+			if len(rows) == 0 {
+				first = trimSpan(text, r)
+			}
+			last = trimSpan(text, r)
+			rows = append(rows, row)
+		}
+		flush()
+	}
+	prose(pos, len(text))
+	return out
+}
+
+// findTables returns the tables in text in order.
+func findTables(text string) []textTable {
+	var tables []textTable
+	lines := lineSpans(text)
+	for i := 0; i < len(lines); {
+		line := text[lines[i].start:lines[i].end]
+		if htmlTableOpen.MatchString(line) {
+			if t, ok := htmlTable(text, lines[i].start+htmlTableOpen.FindStringIndex(line)[0]); ok {
+				tables = append(tables, t)
+				for i < len(lines) && lines[i].start < t.span.end {
+					i++
+				}
+				continue
+			}
+		}
+		if n := textTableRows(text, lines[i:]); n > 0 {
+			t := lineTable(text, lines[i:i+n])
+			tables = append(tables, t)
+			i += n
+			continue
+		}
+		i++
+	}
+	return tables
+}
+
+// lineSpans returns the span of every line of text without its newline.
+func lineSpans(text string) []textSpan {
+	var out []textSpan
+	for start := 0; start < len(text); {
+		end := strings.IndexByte(text[start:], '\n')
+		if end < 0 {
+			out = append(out, textSpan{start, len(text)})
+			break
+		}
+		out = append(out, textSpan{start, start + end})
+		start += end + 1
+	}
+	return out
+}
+
+// textTableRows returns how many lines at the start of lines form a
+// Markdown, pipe-separated or CSV table, or 0 when they do not.
+func textTableRows(text string, lines []textSpan) int {
+	at := func(i int) string { return strings.TrimRight(text[lines[i].start:lines[i].end], "\r") }
+	if len(lines) < 2 {
+		return 0
+	}
+	first := at(0)
+
+	// Markdown: a header row, a rule such as "|---|:--:|", then rows.
+	if strings.Contains(first, "|") && strings.Contains(at(1), "-") && markdownTableRule.MatchString(at(1)) {
+		n := 2
+		for n < len(lines) && strings.Contains(at(n), "|") && strings.TrimSpace(at(n)) != "" {
+			n++
+		}
+		return n
+	}
+
+	// Rendered rows such as "a | b | c" with a constant cell count.
+	if cells := strings.Count(first, " | "); cells > 0 {
+		n := 1
+		for n < len(lines) && strings.Count(at(n), " | ") == cells {
+			n++
+		}
+		if n >= 2 {
+			return n
+		}
+	}
+
+	// Delimited rows with a constant number of separators.
+	for _, delim := range []string{"\t", ",", ";"} {
+		fields := csvFieldCount(first, delim)
+		if fields < 2 {
+			continue
+		}
+		n := 1
+		for n < len(lines) && csvFieldCount(at(n), delim) == fields {
+			n++
+		}
+		if n >= 3 {
+			return n
+		}
+	}
+	return 0
+}
+
+// csvFieldCount counts the fields of a delimited line, ignoring
+// delimiters inside double quotes. It returns 0 for blank lines.
+func csvFieldCount(line, delim string) int {
+	if strings.TrimSpace(line) == "" {
+		return 0
+	}
+	fields := 1
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '"':
+			quoted = !quoted
+		case !quoted && strings.HasPrefix(line[i:], delim):
+			fields++
+		}
+	}
+	return fields
+}
+
+// lineTable builds a table from lines whose first line is the header,
+// followed by a Markdown rule when there is one.
+func lineTable(text string, lines []textSpan) textTable {
+	headerLines := 1
+	if len(lines) > 1 && markdownTableRule.MatchString(strings.TrimRight(text[lines[1].start:lines[1].end], "\r")) {
+		headerLines = 2
+	}
+	return textTable{
+		span:   textSpan{lines[0].start, lines[len(lines)-1].end},
+		header: text[lines[0].start:lines[headerLines-1].end] + "\n",
+		rows:   lines[headerLines:],
+	}
+}
+
+// htmlTable finds the <table> element starting at start and splits it
+// into its rows, leaving nested tables inside the row that holds them. A
+// first row of <th> cells is the header.
+func htmlTable(text string, start int) (textTable, bool) {
+	depth := 0
+	var opens []int
+	lastClose := -1
+	for _, m := range htmlTableTag.FindAllStringSubmatchIndex(text[start:], -1) {
+		closing := text[start+m[0]+1] == '/'
+		isTable := strings.EqualFold(text[start+m[2]:start+m[3]], "table")
+		switch {
+		case isTable && closing:
+			depth--
+		case isTable:
+			depth++
+		case depth == 1 && closing:
+			lastClose = start + m[1]
+		case depth == 1:
+			opens = append(opens, start+m[0])
+		}
+		if depth > 0 {
+			continue
+		}
+		t := textTable{span: textSpan{start, start + m[1]}}
+		if len(opens) == 0 || lastClose < opens[0] {
+			return t, true
+		}
+		for i, o := range opens {
+			e := lastClose
+			if i+1 < len(opens) {
+				e = opens[i+1]
+			}
+			t.rows = append(t.rows, textSpan{o, e})
+		}
+		t.header = text[start:opens[0]]
+		if first := text[t.rows[0].start:t.rows[0].end]; strings.Contains(strings.ToLower(first), "<th") {
+			t.header += first
+			t.rows = t.rows[1:]
+		}
+		t.footer = text[lastClose : start+m[1]]
+		return t, true
+	}
+	return textTable{}, false
+}
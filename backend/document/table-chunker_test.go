@@ -0,0 +1,36 @@
+package document
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTableChunker(t *testing.T) {
+	md := "Intro words here.\n\n| Name | Age |\n| --- | --- |\n| Ada | 36 |\n| Bob | 41 |\n| Cy | 29 |\n\nAfter the table."
+	const header = "| Name | Age |\n| --- | --- |\n"
+	tests := []struct {
+		name string
+		size int
+		text string
+		want []string
+	}{
+		{"markdown whole", 100, md, []string{"Intro words here.", header + "| Ada | 36 |\n| Bob | 41 |\n| Cy | 29 |", "After the table."}},
+		{"markdown split", 12, md, []string{
+			"Intro words here.", header + "| Ada | 36 |", header + "| Bob | 41 |", header + "| Cy | 29 |", "After the table.",
+		}},
+		{"html split", 5, "<table>\n<tr><th>A</th></tr>\n<tr><td>one two</td></tr>\n<tr><td>three four</td></tr>\n</table>", []string{
+			"<table>\n<tr><th>A</th></tr>\n<tr><td>one two</td></tr>\n</table>",
+			"<table>\n<tr><th>A</th></tr>\n<tr><td>three four</td></tr>\n</table>",
+		}},
+		{"csv split", 3, "name,city\nAda,London\nBob,Paris\nCy,Rome", []string{
+			"name,city\nAda,London\nBob,Paris", "name,city\nCy,Rome",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (&TableChunker{ChunkSize: tt.size}).Split(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package document
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BPETokenizer is a byte-level BPE tokenizer compatible with tiktoken
+// encodings such as cl100k_base. Text is pre-split with the cl100k_base
+// pattern and each piece is merged by rank.
+type BPETokenizer struct {
+	ranks map[string]int
+}
+
+// NewBPETokenizer creates a tokenizer from mergeable ranks, mapping each
+// token's bytes to its rank and id.
+func NewBPETokenizer(ranks map[string]int) *BPETokenizer {
+	return &BPETokenizer{ranks: ranks}
+}
+
+// LoadTiktoken reads a .tiktoken file, in which each line is a base64
+// encoded token and its rank, such as the published cl100k_base.tiktoken.
+func LoadTiktoken(r io.Reader) (*BPETokenizer, error) {
+	ranks := map[string]int{}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		tok, rank, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("tiktoken line %d: missing rank", n)
+		}
+		b, err := base64.StdEncoding.DecodeString(tok)
+		if err != nil {
+			return nil, fmt.Errorf("tiktoken line %d: %w", n, err)
+		}
+		id, err := strconv.Atoi(rank)
+		if err != nil {
+			return nil, fmt.Errorf("tiktoken line %d: %w", n, err)
+		}
+		ranks[string(b)] = id
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read tiktoken: %w", err)
+	}
+	return NewBPETokenizer(ranks), nil
+}
+
+// Encode returns the token ids of text. Bytes missing from the ranks are
+// encoded as -1.
+func (t *BPETokenizer) Encode(text string) []int {
+	var ids []int
+	t.encode(text, func(_ int, id int) { ids = append(ids, id) })
+	return ids
+}
+
+// Count returns the number of tokens in text.
+func (t *BPETokenizer) Count(text string) int {
+	n := 0
+	t.encode(text, func(int, int) { n++ })
+	return n
+}
+
+// Offsets returns the byte offset at which each token starts.
+func (t *BPETokenizer) Offsets(text string) []int {
+	var out []int
+	t.encode(text, func(off, _ int) { out = append(out, off) })
+	return out
+}
+
+func (t *BPETokenizer) encode(text string, emit func(offset, id int)) {
+	for start := 0; start < len(text); {
+		end := start + bpePieceLen(text[start:])
+		piece := text[start:end]
+		if id, ok := t.ranks[piece]; ok {
+			emit(start, id)
+		} else {
+			t.merge(piece, func(off, id int) { emit(start+off, id) })
+		}
+		start = end
+	}
+}
+
+// merge applies byte pair merges to piece, always joining the adjacent
+// pair whose concatenation has the lowest rank.
+func (t *BPETokenizer) merge(piece string, emit func(offset, id int)) {
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, bestRank := -1, 0
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := t.ranks[piece[bounds[i]:bounds[i+2]]]; ok && (best < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+	for i := 0; i+1 < len(bounds); i++ {
+		id, ok := t.ranks[piece[bounds[i]:bounds[i+1]]]
+		if !ok {
+			id = -1
+		}
+		emit(bounds[i], id)
+	}
+}
+
+// bpePieceLen returns the length of the first piece of s under the
+// cl100k_base pre-tokenization pattern:
+//
+//	(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}|
+//	 ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+func bpePieceLen(s string) int {
+	r, size := utf8.DecodeRuneInString(s)
+	isNewline := func(r rune) bool { return r == '\r' || r == '\n' }
+	isLN := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }
+	// span returns the length of the prefix of s[from:] whose runes satisfy
+	// ok, stopping after max runes when max is positive.
+	span := func(from int, ok func(rune) bool, max int) int {
+		n, count := from, 0
+		for n < len(s) && (max <= 0 || count < max) {
+			r, size := utf8.DecodeRuneInString(s[n:])
+			if !ok(r) {
+				break
+			}
+			n += size
+			count++
+		}
+		return n
+	}
+
+	if r == '\'' && len(s) > 1 {
+		lower := strings.ToLower(s[1:min(len(s), 3)])
+		for _, c := range []string{"re", "ve", "ll"} {
+			if strings.HasPrefix(lower, c) {
+				return 1 + len(c)
+			}
+		}
+		if strings.ContainsRune("stmd", rune(lower[0])) {
+			return 2
+		}
+	}
+	if unicode.IsLetter(r) {
+		return span(0, unicode.IsLetter, 0)
+	}
+	if !isNewline(r) && !isLN(r) {
+		if next, _ := utf8.DecodeRuneInString(s[size:]); size < len(s) && unicode.IsLetter(next) {
+			return span(size, unicode.IsLetter, 0)
+		}
+	}
+	if unicode.IsNumber(r) {
+		return span(0, unicode.IsNumber, 3)
+	}
+	punct := func(r rune) bool { return !unicode.IsSpace(r) && !isLN(r) }
+	from := 0
+	if r == ' ' {
+		from = 1
+	}
+	if n := span(from, punct, 0); n > from {
+		return span(n, isNewline, 0)
+	}
+
+	ws := span(0, unicode.IsSpace, 0)
+	if ws == 0 {
+		// Unreachable for valid patterns; consume one rune to make progress.
+		return size
+	}
+	// \s*[\r\n]+ ends after the last newline in the whitespace run.
+	for i := ws; i > 0; {
+		r, size := utf8.DecodeLastRuneInString(s[:i])
+		if isNewline(r) {
+			return i
+		}
+		i -= size
+	}
+	// \s+(?!\S) leaves the last space for the following word.
+	if ws < len(s) {
+		_, last := utf8.DecodeLastRuneInString(s[:ws])
+		if ws-last > 0 {
+			return ws - last
+		}
+	}
+	return ws
+}
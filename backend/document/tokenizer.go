@@ -0,0 +1,94 @@
+package document
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer measures text in the unit chunk sizes are expressed in, such as
+// words or the tokens of an embedding model.
+type Tokenizer interface {
+	// Count returns the number of tokens in text.
+	Count(text string) int
+	// Offsets returns the byte offset at which each token of text starts.
+	Offsets(text string) []int
+}
+
+// DefaultTokenizer is used by chunking functions given a nil Tokenizer.
+var DefaultTokenizer Tokenizer = WhitespaceTokenizer{}
+
+// WhitespaceTokenizer counts runs of non-space characters, so sizes are
+// in words.
+type WhitespaceTokenizer struct{}
+
+// Count returns the number of words in text.
+func (WhitespaceTokenizer) Count(text string) int {
+	n := 0
+	inWord := false
+	for _, r := range text {
+		space := unicode.IsSpace(r)
+		if !space && !inWord {
+			n++
+		}
+		inWord = !space
+	}
+	return n
+}
+
+// Offsets returns the byte offset at which each word starts.
+func (WhitespaceTokenizer) Offsets(text string) []int {
+	var out []int
+	inWord := false
+	for i, r := range text {
+		space := unicode.IsSpace(r)
+		if !space && !inWord {
+			out = append(out, i)
+		}
+		inWord = !space
+	}
+	return out
+}
+
+// CharacterTokenizer counts Unicode code points, so sizes are in
+// characters.
+type CharacterTokenizer struct{}
+
+// Count returns the number of characters in text.
+func (CharacterTokenizer) Count(text string) int {
+	return utf8.RuneCountInString(text)
+}
+
+// Offsets returns the byte offset of each character.
+func (CharacterTokenizer) Offsets(text string) []int {
+	out := make([]int, 0, len(text))
+	for i := range text {
+		out = append(out, i)
+	}
+	return out
+}
+
+// ByteTokenizer counts bytes, so sizes are in bytes of UTF-8. Chunkers cut
+// only at grapheme cluster boundaries, so a byte budget never splits a
+// multi-byte character, a combining accent or an emoji sequence; a chunk
+// can only exceed the budget when a single cluster does.
+type ByteTokenizer struct{}
+
+// Count returns the length of text in bytes.
+func (ByteTokenizer) Count(text string) int {
+	return len(text)
+}
+
+// Offsets returns the offset of every byte.
+func (ByteTokenizer) Offsets(text string) []int {
+	out := make([]int, len(text))
+	for i := range out {
+		out[i] = i
+	}
+	return out
+}
+
+// ChunkBytes splits text into chunks of at most maxBytes bytes, preferring
+// paragraph, line, sentence and word boundaries as RecursiveSplitter does.
+func ChunkBytes(text string, maxBytes int) []string {
+	return (&RecursiveSplitter{ChunkSize: maxBytes, Tokenizer: ByteTokenizer{}}).Split(text)
+}
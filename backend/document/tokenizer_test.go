@@ -0,0 +1,55 @@
+package document
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWhitespaceAndCharacterTokenizers(t *testing.T) {
+	text := "  héllo  wörld\tagain\n"
+	if n := (WhitespaceTokenizer{}).Count(text); n != 3 {
+		t.Errorf("whitespace count = %d", n)
+	}
+	if got := (WhitespaceTokenizer{}).Offsets(text); !reflect.DeepEqual(got, []int{2, 10, 17}) {
+		t.Errorf("whitespace offsets = %v", got)
+	}
+	if n := (CharacterTokenizer{}).Count("héllo"); n != 5 {
+		t.Errorf("character count = %d", n)
+	}
+	if got := (CharacterTokenizer{}).Offsets("hé!"); !reflect.DeepEqual(got, []int{0, 1, 3}) {
+		t.Errorf("character offsets = %v", got)
+	}
+}
+
+func TestBPETokenizer(t *testing.T) {
+	var file strings.Builder
+	for rank, tok := range []string{"a", "b", "c", " ", "ab", "abc"} {
+		file.WriteString(base64.StdEncoding.EncodeToString([]byte(tok)) + " " + string(rune('0'+rank)) + "\n")
+	}
+	tok, err := LoadTiktoken(strings.NewReader(file.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "abc" is a token of its own; " cab" merges only the "ab" pair.
+	if got := tok.Encode("abc cab"); !reflect.DeepEqual(got, []int{5, 3, 2, 4}) {
+		t.Errorf("Encode = %v", got)
+	}
+	if got := tok.Offsets("abc cab"); !reflect.DeepEqual(got, []int{0, 3, 4, 5}) {
+		t.Errorf("Offsets = %v", got)
+	}
+	if got := tok.Encode("az"); !reflect.DeepEqual(got, []int{0, -1}) {
+		t.Errorf("unknown byte encoded as %v", got)
+	}
+	if n := tok.Count("abc cab"); n != 4 {
+		t.Errorf("Count = %d", n)
+	}
+
+	if _, err := LoadTiktoken(strings.NewReader("YQ==\n")); err == nil {
+		t.Error("line without rank accepted")
+	}
+	if _, err := LoadTiktoken(strings.NewReader("!!! 1\n")); err == nil {
+		t.Error("bad base64 accepted")
+	}
+}
@@ -0,0 +1,236 @@
+package document
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TranscriptSegment is a span of transcribed speech.
+type TranscriptSegment struct {
+	Start, End time.Duration
+	Text       string
+	// Speaker is set by transcribers that diarize.
+	Speaker string
+}
+
+// Transcriber converts speech to timed text. mimeType is the audio format,
+// such as "audio/mpeg" or "audio/wav".
+type Transcriber interface {
+	Transcribe(audio []byte, mimeType string) ([]TranscriptSegment, error)
+}
+
+// audioExtensions are the file names transcribers use to pick a decoder.
+var audioExtensions = map[string]string{
+	"audio/mpeg": ".mp3",
+	"audio/wav":  ".wav",
+	"audio/mp4":  ".m4a",
+	"audio/ogg":  ".ogg",
+	"audio/flac": ".flac",
+	"audio/webm": ".webm",
+}
+
+// maxTranscriptSize bounds the transcription responses read, far above
+// the verbose JSON of hours of speech.
+const maxTranscriptSize = 64 << 20
+
+// WhisperAPITranscriber sends audio to an OpenAI-compatible
+// /audio/transcriptions endpoint.
+type WhisperAPITranscriber struct {
+	// APIKey is sent as a bearer token.
+	APIKey string
+	// Endpoint defaults to https://api.openai.com/v1/audio/transcriptions.
+	Endpoint string
+	// Model defaults to "whisper-1".
+	Model string
+	// Language is an optional ISO-639-1 hint such as "en".
+	Language string
+	// Client defaults to an http.Client with a five minute timeout.
+	Client *http.Client
+}
+
+// NewWhisperAPITranscriber creates a transcriber for the OpenAI API.
+func NewWhisperAPITranscriber(apiKey string) *WhisperAPITranscriber {
+	return &WhisperAPITranscriber{APIKey: apiKey}
+}
+
+// Transcribe uploads the audio and reads the segments of the verbose_json
+// response.
+func (t *WhisperAPITranscriber) Transcribe(audio []byte, mimeType string) ([]TranscriptSegment, error) {
+	return t.TranscribeContext(context.Background(), audio, mimeType)
+}
+
+// TranscribeContext works like Transcribe but cancels the request when
+// ctx is done.
+func (t *WhisperAPITranscriber) TranscribeContext(ctx context.Context, audio []byte, mimeType string) ([]TranscriptSegment, error) {
+	endpoint := t.Endpoint
+	if endpoint == "" {
+		endpoint = "https://api.openai.com/v1/audio/transcriptions"
+	}
+	model := t.Model
+	if model == "" {
+		model = "whisper-1"
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "audio"+audioExtensions[mimeType])
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(audio); err != nil {
+		return nil, err
+	}
+	fields := [][2]string{{"model", model}, {"response_format", "verbose_json"}}
+	if t.Language != "" {
+		fields = append(fields, [2]string{"language", t.Language})
+	}
+	for _, f := range fields {
+		if err := mw.WriteField(f[0], f[1]); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if t.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.APIKey)
+	}
+	client := t.Client
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("transcription request: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := readAllLimited(resp.Body, maxTranscriptSize)
+	if err != nil {
+		return nil, fmt.Errorf("read transcription: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("transcription request: %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return parseWhisperJSON(data)
+}
+
+// WhisperCLITranscriber runs a local openai-whisper compatible command
+// that writes a JSON transcript to its output directory.
+type WhisperCLITranscriber struct {
+	// Command is the executable. Empty means "whisper" on PATH.
+	Command string
+	// Model defaults to "base".
+	Model string
+	// Language is an optional language hint such as "en".
+	Language string
+	// Timeout bounds a single transcription. Zero means 30 minutes.
+	Timeout time.Duration
+}
+
+// NewWhisperCLITranscriber creates a transcriber for the local whisper
+// command.
+func NewWhisperCLITranscriber() *WhisperCLITranscriber {
+	return &WhisperCLITranscriber{}
+}
+
+// Transcribe writes the audio to a temporary directory, runs the command
+// on it and reads the JSON transcript it produces.
+func (t *WhisperCLITranscriber) Transcribe(audio []byte, mimeType string) ([]TranscriptSegment, error) {
+	return t.TranscribeContext(context.Background(), audio, mimeType)
+}
+
+// TranscribeContext works like Transcribe but kills the command when ctx
+// is done.
+func (t *WhisperCLITranscriber) TranscribeContext(ctx context.Context, audio []byte, mimeType string) ([]TranscriptSegment, error) {
+	dir, err := os.MkdirTemp("", "whisper-*")
+	if err != nil {
+		return nil, fmt.Errorf("create transcription dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	input := filepath.Join(dir, "audio"+audioExtensions[mimeType])
+	if err := os.WriteFile(input, audio, 0o600); err != nil {
+		return nil, fmt.Errorf("write transcription input: %w", err)
+	}
+
+	command := t.Command
+	if command == "" {
+		command = "whisper"
+	}
+	model := t.Model
+	if model == "" {
+		model = "base"
+	}
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Minute
+	}
+	args := []string{input, "--model", model, "--output_format", "json", "--output_dir", dir}
+	if t.Language != "" {
+		args = append(args, "--language", t.Language)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("whisper: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("whisper: %w", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "audio.json"))
+	if err != nil {
+		return nil, fmt.Errorf("read transcription: %w", err)
+	}
+	return parseWhisperJSON(data)
+}
+
+// parseWhisperJSON reads the segments of a Whisper verbose JSON
+// transcript. A transcript with only "text" becomes a single segment.
+func parseWhisperJSON(data []byte) ([]TranscriptSegment, error) {
+	var resp struct {
+		Text     string  `json:"text"`
+		Duration float64 `json:"duration"`
+		Segments []struct {
+			Start   float64 `json:"start"`
+			End     float64 `json:"end"`
+			Text    string  `json:"text"`
+			Speaker string  `json:"speaker"`
+		} `json:"segments"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("decode transcription: %w", err)
+	}
+	seconds := func(s float64) time.Duration { return time.Duration(s*float64(time.Second) + 0.5) }
+	var out []TranscriptSegment
+	for _, s := range resp.Segments {
+		if text := strings.TrimSpace(s.Text); text != "" {
+			out = append(out, TranscriptSegment{Start: seconds(s.Start), End: seconds(s.End), Text: text, Speaker: s.Speaker})
+		}
+	}
+	if len(out) == 0 {
+		if text := strings.TrimSpace(resp.Text); text != "" {
+			out = append(out, TranscriptSegment{End: seconds(resp.Duration), Text: text})
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("transcription is empty")
+	}
+	return out, nil
+}
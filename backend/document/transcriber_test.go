@@ -0,0 +1,92 @@
+package document
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWhisperAPITranscriber(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "bad key", http.StatusUnauthorized)
+			return
+		}
+		f, h, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		audio, _ := io.ReadAll(f)
+		if h.Filename != "audio.mp3" || string(audio) != "ID3 audio" || r.FormValue("model") != "whisper-1" ||
+			r.FormValue("response_format") != "verbose_json" || r.FormValue("language") != "en" {
+			http.Error(w, "unexpected form", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"text": "Hello there. Bye.", "segments": [
+			{"start": 0, "end": 1.5, "text": " Hello there."},
+			{"start": 1.5, "end": 2.25, "text": " Bye.", "speaker": "A"}]}`))
+	}))
+	defer srv.Close()
+
+	tr := &WhisperAPITranscriber{APIKey: "key", Endpoint: srv.URL, Language: "en"}
+	got, err := tr.Transcribe([]byte("ID3 audio"), "audio/mpeg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TranscriptSegment{
+		{Start: 0, End: 1500 * time.Millisecond, Text: "Hello there."},
+		{Start: 1500 * time.Millisecond, End: 2250 * time.Millisecond, Text: "Bye.", Speaker: "A"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("segments %+v, want %+v", got, want)
+	}
+	if _, err := (&WhisperAPITranscriber{Endpoint: srv.URL}).Transcribe([]byte("ID3"), "audio/mpeg"); err == nil {
+		t.Error("unauthorized request returned no error")
+	}
+}
+
+func TestParseWhisperJSON(t *testing.T) {
+	got, err := parseWhisperJSON([]byte(`{"text": " Only text. ", "duration": 3}`))
+	if err != nil || len(got) != 1 || got[0].Text != "Only text." || got[0].End != 3*time.Second {
+		t.Errorf("text-only transcript: %+v, %v", got, err)
+	}
+	if _, err := parseWhisperJSON([]byte(`{"text": "", "segments": []}`)); err == nil {
+		t.Error("empty transcript returned no error")
+	}
+}
+
+// fakeTranscriber returns fixed segments.
+type fakeTranscriber []TranscriptSegment
+
+func (f fakeTranscriber) Transcribe(audio []byte, mimeType string) ([]TranscriptSegment, error) {
+	return f, nil
+}
+
+func TestAudioParser(t *testing.T) {
+	p := &AudioParser{Transcriber: fakeTranscriber{
+		{Start: 0, End: time.Second, Text: "Welcome", Speaker: "Ada"},
+		{Start: time.Second, End: 2 * time.Second, Text: "to the show."},
+		{Start: 10 * time.Second, End: 11 * time.Second, Text: "Later."},
+	}}
+	wav := []byte("RIFF\x00\x00\x00\x00WAVEfmt ")
+	doc, err := p.Parse(wav, "a.wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Content != "Ada: Welcome to the show.\n\nLater." || len(doc.Segments) != 2 {
+		t.Errorf("content %q, %d segments", doc.Content, len(doc.Segments))
+	}
+	if got := doc.Segments[1].Metadata["start_seconds"]; got != "10" {
+		t.Errorf("second passage starts at %s", got)
+	}
+	if _, err := p.Parse([]byte("not audio"), "a.wav"); err == nil {
+		t.Error("unrecognized audio parsed")
+	}
+	if _, err := NewAudioParser().Parse(wav, "a.wav"); err == nil {
+		t.Error("audio parsed without a transcriber")
+	}
+}
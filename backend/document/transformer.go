@@ -0,0 +1,142 @@
+package document
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Transformer edits or replaces a parsed document before it is chunked,
+// for cleaning, redaction, enrichment and the like. Transformers that
+// change Content should move the offsets of pages, headings and the rest
+// to match, as Normalizer and Redactor do.
+type Transformer interface {
+	Transform(doc *Document) (*Document, error)
+}
+
+// TransformerFunc adapts a function to the Transformer interface.
+type TransformerFunc func(doc *Document) (*Document, error)
+
+// Transform calls f(doc).
+func (f TransformerFunc) Transform(doc *Document) (*Document, error) {
+	return f(doc)
+}
+
+// ChunkTransformer edits, filters or adds to the chunks of a document
+// after chunking.
+type ChunkTransformer interface {
+	TransformChunks(doc *Document, chunks []Chunk) ([]Chunk, error)
+}
+
+// ChunkTransformerFunc adapts a function to the ChunkTransformer
+// interface.
+type ChunkTransformerFunc func(doc *Document, chunks []Chunk) ([]Chunk, error)
+
+// TransformChunks calls f(doc, chunks).
+func (f ChunkTransformerFunc) TransformChunks(doc *Document, chunks []Chunk) ([]Chunk, error) {
+	return f(doc, chunks)
+}
+
+// QualityFilter is a ChunkTransformer that drops chunks ScoreChunks rates
+// below MinScore.
+type QualityFilter struct {
+	// MinScore is the lowest score kept. Defaults to 0.3.
+	MinScore float64
+}
+
+// TransformChunks returns the chunks that pass the filter.
+func (f QualityFilter) TransformChunks(doc *Document, chunks []Chunk) ([]Chunk, error) {
+	minScore := f.MinScore
+	if minScore <= 0 {
+		minScore = 0.3
+	}
+	return FilterChunks(chunks, minScore), nil
+}
+
+// RedactionRule replaces every match of Pattern with "[" + Label + "]".
+type RedactionRule struct {
+	Label   string
+	Pattern *regexp.Regexp
+}
+
+// DefaultRedactionRules finds email addresses, phone numbers, payment card
+// numbers and IPv4 addresses.
+var DefaultRedactionRules = []RedactionRule{
+	{"EMAIL", regexp.MustCompile(`[\w.+-]+@[\w-]+(?:\.[\w-]+)+`)},
+	{"CARD", regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)},
+	{"PHONE", regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)[ .-]?|\b\d{2,4}[ .-])\d{3,4}[ .-]\d{3,4}\b`)},
+	{"IP", regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)},
+}
+
+// Redactor is a Transformer that masks personal data in Content, moving
+// offsets to match.
+type Redactor struct {
+	// Rules are applied together; where matches overlap, the earliest
+	// wins, then the rule listed first. Defaults to DefaultRedactionRules.
+	Rules []RedactionRule
+}
+
+// NewRedactor creates a redactor with the default rules.
+func NewRedactor() *Redactor {
+	return &Redactor{}
+}
+
+// Transform redacts doc in place and records the number of redactions
+// in the "redactions" metadata.
+func (r *Redactor) Transform(doc *Document) (*Document, error) {
+	rules := r.Rules
+	if rules == nil {
+		rules = DefaultRedactionRules
+	}
+	type match struct {
+		start, end, rule int
+	}
+	var matches []match
+	for i, rule := range rules {
+		for _, m := range rule.Pattern.FindAllStringIndex(doc.Content, -1) {
+			matches = append(matches, match{m[0], m[1], i})
+		}
+	}
+	if len(matches) == 0 {
+		return doc, nil
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].start != matches[j].start {
+			return matches[i].start < matches[j].start
+		}
+		return matches[i].rule < matches[j].rule
+	})
+
+	var b strings.Builder
+	offsets := make([]int, len(doc.Content)+1)
+	pos, count := 0, 0
+	copyTo := func(end int) {
+		for i := pos; i < end; i++ {
+			offsets[i] = b.Len() + i - pos
+		}
+		b.WriteString(doc.Content[pos:end])
+		pos = end
+	}
+	for _, m := range matches {
+		if m.start < pos {
+			continue
+		}
+		copyTo(m.start)
+		for i := m.start; i < m.end; i++ {
+			offsets[i] = b.Len()
+		}
+		b.WriteString("[" + rules[m.rule].Label + "]")
+		pos = m.end
+		count++
+	}
+	copyTo(len(doc.Content))
+	offsets[len(doc.Content)] = b.Len()
+
+	replaceContent(doc, b.String(), offsets)
+	if doc.Metadata == nil {
+		doc.Metadata = map[string]string{}
+	}
+	doc.Metadata["redactions"] = strconv.Itoa(count)
+	return doc, nil
+}
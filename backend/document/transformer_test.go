@@ -0,0 +1,72 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	doc := &Document{
+		Content:  "Mail ada@example.com or call +1 555-123-4567.\n\n# Card\n\nPay 4111 1111 1111 1111 from 10.0.0.1.",
+		Headings: []Heading{{Level: 1, Text: "Card", Offset: 47}},
+	}
+	got, err := NewRedactor().Transform(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Content != "Mail [EMAIL] or call [PHONE].\n\n# Card\n\nPay [CARD] from [IP]." || got.Metadata["redactions"] != "4" {
+		t.Errorf("Content %q, metadata %v", got.Content, got.Metadata)
+	}
+	if want := []Heading{{Level: 1, Text: "Card", Offset: 31}}; !reflect.DeepEqual(got.Headings, want) {
+		t.Errorf("Headings = %+v, want %+v", got.Headings, want)
+	}
+
+	custom := &Redactor{Rules: []RedactionRule{{"ID", regexp.MustCompile(`EMP-\d+`)}}}
+	if got, _ := custom.Transform(&Document{Content: "Ask EMP-42 at ada@example.com."}); got.Content != "Ask [ID] at ada@example.com." {
+		t.Errorf("custom rules gave %q", got.Content)
+	}
+}
+
+func TestPipelineTransforms(t *testing.T) {
+	loader := mapLoader(map[string]string{"a.txt": "Mail “ada@example.com”.\n\nHome\nAbout us\nBlog\nContact\nPrivacy\n\nThe committee met to review the budget."})
+	sink := &collectSink{}
+	var seen []string
+	mark := ChunkTransformerFunc(func(doc *Document, chunks []Chunk) ([]Chunk, error) {
+		for i := range chunks {
+			seen = append(seen, chunks[i].Text)
+		}
+		return chunks, nil
+	})
+	err := NewPipeline().
+		LoadWith(loader).
+		Transform(NewNormalizer(), NewRedactor()).
+		Chunker("paragraph", WithChunkSize(7)).
+		TransformChunks(QualityFilter{MinScore: 0.5}, mark).
+		To(sink).
+		Run(context.Background(), "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc := sink.docs[0]; !strings.HasPrefix(doc.Content, `Mail "[EMAIL]".`) {
+		t.Errorf("transformed Content %q", doc.Content)
+	}
+	// The navigation menu is dropped before the later transformer and
+	// the sink see the chunks.
+	want := []string{`Mail "[EMAIL]".`, "The committee met to review the budget."}
+	if got := chunkTexts(sink.chunks[0]); !reflect.DeepEqual(got, want) || !reflect.DeepEqual(seen, want) {
+		t.Errorf("chunks %q, seen %q, want %q", got, seen, want)
+	}
+
+	fail := TransformerFunc(func(*Document) (*Document, error) { return nil, errors.New("bad") })
+	if err := NewPipeline().LoadWith(loader).Transform(fail).Run(context.Background(), "a.txt"); err == nil || err.Error() != "transform a.txt: bad" {
+		t.Errorf("failing transformer: err = %v", err)
+	}
+	failChunks := ChunkTransformerFunc(func(*Document, []Chunk) ([]Chunk, error) { return nil, errors.New("bad") })
+	if err := NewPipeline().LoadWith(loader).TransformChunks(failChunks).Run(context.Background(), "a.txt"); err == nil || err.Error() != "transform chunks of a.txt: bad" {
+		t.Errorf("failing chunk transformer: err = %v", err)
+	}
+}
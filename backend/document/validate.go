@@ -0,0 +1,185 @@
+package document
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Violation is one broken invariant found by Validate.
+type Violation struct {
+	// Field is the path of the offending field, such as "Pages[2].End" or
+	// "[3].Text" for the fourth chunk.
+	Field string
+	// Message describes the problem.
+	Message string
+}
+
+func (v Violation) String() string {
+	return v.Field + ": " + v.Message
+}
+
+// ValidationError lists every violation found by a Validate method. It
+// matches ErrInvalid with errors.Is.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+	return "invalid: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// validator collects violations.
+type validator struct {
+	violations []Violation
+}
+
+func (v *validator) add(field, format string, args ...any) {
+	v.violations = append(v.violations, Violation{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// span checks that start and end are an ordered range within [0, size].
+func (v *validator) span(field string, start, end, size int) {
+	switch {
+	case start < 0 || start > size:
+		v.add(field+".Start", "offset %d out of range [0, %d]", start, size)
+	case end < start || end > size:
+		v.add(field+".End", "offset %d out of range [%d, %d]", end, start, size)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: v.violations}
+}
+
+// Validate checks that d has non-empty, valid UTF-8 content and that the
+// offsets of its pages, headings, sections, segments and lines fall
+// within it. It returns nil or a *ValidationError listing every problem.
+func (d *Document) Validate() error {
+	var v validator
+	size := len(d.Content)
+	if strings.TrimSpace(d.Content) == "" {
+		v.add("Content", "is empty")
+	} else if !utf8.ValidString(d.Content) {
+		v.add("Content", "is not valid UTF-8")
+	}
+	if d.WordCount < 0 {
+		v.add("WordCount", "is negative")
+	}
+	for i, p := range d.Pages {
+		field := "Pages[" + strconv.Itoa(i) + "]"
+		v.span(field, p.Start, p.End, size)
+		if i > 0 && p.Start < d.Pages[i-1].End {
+			v.add(field+".Start", "overlaps the previous page")
+		}
+	}
+	for i, h := range d.Headings {
+		if h.Offset < 0 || h.Offset > size {
+			v.add("Headings["+strconv.Itoa(i)+"].Offset", "offset %d out of range [0, %d]", h.Offset, size)
+		}
+	}
+	var sections func(prefix string, ss []Section)
+	sections = func(prefix string, ss []Section) {
+		for i, s := range ss {
+			field := prefix + "[" + strconv.Itoa(i) + "]"
+			v.span(field, s.Start, s.End, size)
+			sections(field+".Children", s.Children)
+		}
+	}
+	sections("Sections", d.Sections)
+	for i, s := range d.Segments {
+		v.span("Segments["+strconv.Itoa(i)+"]", s.Start, s.End, size)
+	}
+	for i, off := range d.Lines {
+		if off < 0 || off > size || (i > 0 && off <= d.Lines[i-1]) {
+			v.add("Lines["+strconv.Itoa(i)+"]", "offset %d out of order or range", off)
+		}
+	}
+	return v.err()
+}
+
+// ChunkLimits bounds the size of chunks checked by Validate, such as to
+// the input limit of an embedding model. Zero fields are not checked.
+type ChunkLimits struct {
+	// MaxTokens is the largest TokenCount allowed.
+	MaxTokens int
+	// MaxBytes is the largest length of the embedding text allowed.
+	MaxBytes int
+}
+
+// DefaultChunkLimits fits the 8191-token input of common embedding
+// models.
+var DefaultChunkLimits = ChunkLimits{MaxTokens: 8191}
+
+// Validate checks c against DefaultChunkLimits; see ChunkLimits.Validate.
+func (c Chunk) Validate() error {
+	var v validator
+	DefaultChunkLimits.chunk(&v, "", c, -1)
+	return v.err()
+}
+
+// Validate checks that every chunk has non-empty, valid UTF-8 text within
+// the limits and ordered offsets within doc.Content, for use before
+// writing chunks to a vector store. doc may be nil to skip the bounds
+// check. Fields of the violations are prefixed with the chunk's position,
+// as in "[3].Text". It returns nil or a *ValidationError.
+func (l ChunkLimits) Validate(doc *Document, chunks []Chunk) error {
+	size := -1
+	if doc != nil {
+		size = len(doc.Content)
+	}
+	var v validator
+	for i, c := range chunks {
+		l.chunk(&v, "["+strconv.Itoa(i)+"].", c, size)
+	}
+	return v.err()
+}
+
+// chunk adds the violations of c to v. size is the length of the source
+// content, or -1 when unknown.
+func (l ChunkLimits) chunk(v *validator, prefix string, c Chunk, size int) {
+	if strings.TrimSpace(c.Text) == "" {
+		v.add(prefix+"Text", "is empty")
+	} else if !utf8.ValidString(c.Text) {
+		v.add(prefix+"Text", "is not valid UTF-8")
+	}
+	if c.EmbedText != "" && !utf8.ValidString(c.EmbedText) {
+		v.add(prefix+"EmbedText", "is not valid UTF-8")
+	}
+	switch {
+	case c.StartOffset < 0:
+		v.add(prefix+"StartOffset", "is negative")
+	case c.EndOffset < c.StartOffset:
+		v.add(prefix+"EndOffset", "offset %d before StartOffset %d", c.EndOffset, c.StartOffset)
+	case size >= 0 && c.EndOffset > size:
+		v.add(prefix+"EndOffset", "offset %d out of range [0, %d]", c.EndOffset, size)
+	}
+	if c.EndLine < c.StartLine {
+		v.add(prefix+"EndLine", "line %d before StartLine %d", c.EndLine, c.StartLine)
+	}
+	if c.PageEnd < c.PageStart {
+		v.add(prefix+"PageEnd", "page %d before PageStart %d", c.PageEnd, c.PageStart)
+	}
+	tokens := c.TokenCount
+	if tokens <= 0 {
+		tokens = DefaultTokenizer.Count(c.EmbeddingText())
+	}
+	if l.MaxTokens > 0 && tokens > l.MaxTokens {
+		v.add(prefix+"TokenCount", "%d tokens exceeds the limit of %d", tokens, l.MaxTokens)
+	}
+	if n := len(c.EmbeddingText()); l.MaxBytes > 0 && n > l.MaxBytes {
+		v.add(prefix+"Text", "%d bytes exceeds the limit of %d", n, l.MaxBytes)
+	}
+}
@@ -0,0 +1,80 @@
+package document
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// violationFields returns the fields of the violations in err.
+func violationFields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var ve *ValidationError
+	if !errors.As(err, &ve) || !errors.Is(err, ErrInvalid) {
+		t.Fatalf("err = %v, want a *ValidationError", err)
+	}
+	fields := make([]string, len(ve.Violations))
+	for i, v := range ve.Violations {
+		fields[i] = v.Field
+	}
+	return fields
+}
+
+func TestDocumentValidate(t *testing.T) {
+	doc, err := NewMarkdownParser().Parse([]byte("# A\n\ntext\n\n## B\n\nmore"), "a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Validate(); err != nil {
+		t.Errorf("parsed document: %v", err)
+	}
+
+	bad := &Document{
+		Content:  "abc\xff",
+		Pages:    []Page{{Number: 1, Start: 0, End: 3}, {Number: 2, Start: 2, End: 9}},
+		Headings: []Heading{{Level: 1, Text: "x", Offset: 7}},
+		Sections: []Section{{Heading: "x", Start: 0, End: 4, Children: []Section{{Heading: "y", Start: 3, End: 2}}}},
+		Lines:    []int{0, 2, 2},
+	}
+	want := []string{"Content", "Pages[1].End", "Pages[1].Start", "Headings[0].Offset", "Sections[0].Children[0].End", "Lines[2]"}
+	if got := violationFields(t, bad.Validate()); !reflect.DeepEqual(got, want) {
+		t.Errorf("violations = %q, want %q", got, want)
+	}
+	if got := violationFields(t, (&Document{Content: " \n"}).Validate()); !reflect.DeepEqual(got, []string{"Content"}) {
+		t.Errorf("empty document violations = %q", got)
+	}
+}
+
+func TestChunkValidate(t *testing.T) {
+	doc := &Document{Content: "one two three"}
+	good := []Chunk{{Text: "one two", StartOffset: 0, EndOffset: 7, TokenCount: 2}, {Text: "three", StartOffset: 8, EndOffset: 13, TokenCount: 1}}
+	if err := (ChunkLimits{MaxTokens: 2}).Validate(doc, good); err != nil {
+		t.Errorf("good chunks: %v", err)
+	}
+
+	bad := []Chunk{
+		{Text: " "},
+		{Text: "one", StartOffset: 5, EndOffset: 2, StartLine: 3, EndLine: 1},
+		{Text: "three", StartOffset: 8, EndOffset: 20, PageStart: 2, PageEnd: 1},
+		{Text: strings.Repeat("word ", 5)},
+	}
+	want := []string{"[0].Text", "[1].EndOffset", "[1].EndLine", "[2].EndOffset", "[2].PageEnd", "[3].TokenCount", "[3].Text"}
+	if got := violationFields(t, (ChunkLimits{MaxTokens: 4, MaxBytes: 20}).Validate(doc, bad)); !reflect.DeepEqual(got, want) {
+		t.Errorf("violations = %q, want %q", got, want)
+	}
+	// Without a document the bounds are not checked.
+	if got := violationFields(t, (ChunkLimits{}).Validate(nil, bad[2:3])); !reflect.DeepEqual(got, []string{"[0].PageEnd"}) {
+		t.Errorf("violations without a document = %q", got)
+	}
+
+	if err := (Chunk{Text: "fine"}).Validate(); err != nil {
+		t.Errorf("Chunk.Validate: %v", err)
+	}
+	if got := violationFields(t, (Chunk{Text: "x", EmbedText: "\xff"}).Validate()); !reflect.DeepEqual(got, []string{"EmbedText"}) {
+		t.Errorf("Chunk.Validate violations = %q", got)
+	}
+}
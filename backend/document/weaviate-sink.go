@@ -0,0 +1,211 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WeaviateSink is a Sink that writes chunks as objects of a Weaviate
+// class, with each metadata key mapped to a text property, which the
+// server's auto-schema adds to the class. Object IDs are
+// derived from ChunkID, so writing an unchanged document again replaces
+// its objects. The class is created on the first write if missing.
+//
+// With Vectorizer empty, chunks bring their own vectors and must have an
+// Embedding. Otherwise Weaviate vectorizes the content with that module
+// and embeddings are not sent.
+type WeaviateSink struct {
+	// Endpoint is the base URL of the server. Defaults to
+	// http://localhost:8080.
+	Endpoint string
+	// APIKey is sent as a bearer token when set.
+	APIKey string
+	// Class names the class. Defaults to "Chunk".
+	Class string
+	// Vectorizer is the module that embeds content on the server, such as
+	// "text2vec-openai", or empty to send Chunk.Embedding.
+	Vectorizer string
+	// BatchSize is the number of objects sent per request. Defaults to
+	// 100.
+	BatchSize int
+	// MaxRetries is the number of times a failed request is retried, with
+	// exponential backoff. Defaults to 3; negative turns retries off.
+	MaxRetries int
+	// Client defaults to an http.Client with a one minute timeout.
+	Client *http.Client
+
+	mu    sync.Mutex
+	ready bool
+}
+
+// NewWeaviateSink creates a sink that writes to class on the server at
+// endpoint, with vectors from the pipeline.
+func NewWeaviateSink(endpoint, class string) *WeaviateSink {
+	return &WeaviateSink{Endpoint: endpoint, Class: class}
+}
+
+type weaviateObject struct {
+	Class      string         `json:"class"`
+	ID         string         `json:"id"`
+	Properties map[string]any `json:"properties"`
+	Vector     []float32      `json:"vector,omitempty"`
+}
+
+// Write sends the chunks of doc in batches.
+func (s *WeaviateSink) Write(ctx context.Context, doc *Document, chunks []Chunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	class := s.class()
+	source := storeSource(doc)
+	objects := make([]weaviateObject, len(chunks))
+	for i, c := range chunks {
+		if s.Vectorizer == "" && len(c.Embedding) == 0 {
+			return fmt.Errorf("chunk %d has no embedding", i)
+		}
+		props := map[string]any{}
+		for k, v := range chunkStoreMetadata(doc, c) {
+			props[weaviateProperty(k)] = v
+		}
+		id := storeChunkID(source, c)
+		props["chunkId"] = id
+		props["source"] = source
+		props["chunkIndex"] = c.Index
+		props["content"] = c.Text
+		objects[i] = weaviateObject{Class: class, ID: chunkUUID(id), Properties: props}
+		if s.Vectorizer == "" {
+			objects[i].Vector = c.Embedding
+		}
+	}
+	if err := s.ensureClass(ctx); err != nil {
+		return err
+	}
+
+	batchSize := s.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	for start := 0; start < len(objects); start += batchSize {
+		batch := objects[start:min(start+batchSize, len(objects))]
+		data, err := s.send(ctx, http.MethodPost, "/v1/batch/objects", map[string]any{"objects": batch})
+		if err != nil {
+			return fmt.Errorf("write objects: %w", err)
+		}
+		if err := weaviateBatchErrors(data); err != nil {
+			return fmt.Errorf("write objects: %w", err)
+		}
+	}
+	return nil
+}
+
+// ensureClass creates the class once, if it does not exist.
+func (s *WeaviateSink) ensureClass(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ready {
+		return nil
+	}
+	class := s.class()
+	_, err := s.send(ctx, http.MethodGet, "/v1/schema/"+url.PathEscape(class), nil)
+	var he *httpError
+	if errors.As(err, &he) && he.code == http.StatusNotFound {
+		vectorizer := s.Vectorizer
+		if vectorizer == "" {
+			vectorizer = "none"
+		}
+		sourceProp := map[string]any{"name": "source", "dataType": []string{"text"}}
+		idProp := map[string]any{"name": "chunkId", "dataType": []string{"text"}}
+		if vectorizer != "none" {
+			// Keep the file name and ID out of the text the server embeds.
+			skip := map[string]any{vectorizer: map[string]any{"skip": true}}
+			sourceProp["moduleConfig"], idProp["moduleConfig"] = skip, skip
+		}
+		body := map[string]any{
+			"class":      class,
+			"vectorizer": vectorizer,
+			"properties": []map[string]any{
+				{"name": "content", "dataType": []string{"text"}},
+				sourceProp,
+				idProp,
+				{"name": "chunkIndex", "dataType": []string{"int"}},
+			},
+		}
+		_, err = s.send(ctx, http.MethodPost, "/v1/schema", body)
+	}
+	if err != nil {
+		return fmt.Errorf("create class: %w", err)
+	}
+	s.ready = true
+	return nil
+}
+
+func (s *WeaviateSink) class() string {
+	if s.Class == "" {
+		return "Chunk"
+	}
+	return s.Class
+}
+
+// send makes a request to the server at path.
+func (s *WeaviateSink) send(ctx context.Context, method, path string, body any) ([]byte, error) {
+	endpoint := strings.TrimSuffix(s.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = "http://localhost:8080"
+	}
+	header := http.Header{}
+	if s.APIKey != "" {
+		header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+	return retryPolicy{maxRetries: s.MaxRetries}.sendJSON(ctx, httpClient(s.Client, time.Minute), method, endpoint+path, header, body)
+}
+
+// weaviateBatchErrors returns the first per-object error of a batch
+// response, which Weaviate reports with a 200 status.
+func weaviateBatchErrors(data []byte) error {
+	var results []struct {
+		ID     string `json:"id"`
+		Result struct {
+			Errors struct {
+				Error []struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			} `json:"errors"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return fmt.Errorf("decode batch response: %w", err)
+	}
+	for _, r := range results {
+		if errs := r.Result.Errors.Error; len(errs) > 0 {
+			return fmt.Errorf("object %s: %s", r.ID, errs[0].Message)
+		}
+	}
+	return nil
+}
+
+// weaviateProperty maps a metadata key to a valid property name by
+// replacing characters other than letters, digits and underscores.
+func weaviateProperty(key string) string {
+	var b strings.Builder
+	for i, r := range key {
+		switch {
+		case r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestWeaviateSink(t *testing.T) {
+	var requests []string
+	var schema struct {
+		Class      string           `json:"class"`
+		Vectorizer string           `json:"vectorizer"`
+		Properties []map[string]any `json:"properties"`
+	}
+	var objects []weaviateObject
+	batchResponse := `[]`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/schema/Note":
+			if schema.Class == "" {
+				http.Error(w, "not found", http.StatusNotFound)
+				return
+			}
+		case "POST /v1/schema":
+			json.NewDecoder(r.Body).Decode(&schema)
+		case "POST /v1/batch/objects":
+			var req struct {
+				Objects []weaviateObject `json:"objects"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			objects = append(objects, req.Objects...)
+			w.Write([]byte(batchResponse))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	doc := &Document{Source: "a.md", Metadata: map[string]string{"heading-path": "Intro", "2col": "x"}}
+	chunks := []Chunk{
+		{Index: 0, Text: "one", Embedding: []float32{1, 0}},
+		{Index: 1, Text: "two"},
+	}
+	sink := &WeaviateSink{Endpoint: srv.URL, APIKey: "key", Class: "Note", Vectorizer: "text2vec-openai", BatchSize: 1}
+	ctx := context.Background()
+	if err := sink.Write(ctx, doc, chunks); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"GET /v1/schema/Note", "POST /v1/schema", "POST /v1/batch/objects", "POST /v1/batch/objects"}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests %q, want %q", requests, want)
+	}
+	if schema.Class != "Note" || schema.Vectorizer != "text2vec-openai" {
+		t.Errorf("schema %+v", schema)
+	}
+	for _, p := range schema.Properties {
+		if p["name"] == "source" && p["moduleConfig"] == nil {
+			t.Error("source property is vectorized")
+		}
+	}
+	if len(objects) != 2 {
+		t.Fatalf("wrote %d objects, want 2", len(objects))
+	}
+	o := objects[0]
+	if o.Class != "Note" || o.ID != chunkUUID(ChunkID("a.md", chunks[0])) || o.Vector != nil {
+		t.Errorf("object %+v", o)
+	}
+	if o.Properties["content"] != "one" || o.Properties["source"] != "a.md" || o.Properties["chunkIndex"] != 0.0 ||
+		o.Properties["heading_path"] != "Intro" || o.Properties["_2col"] != "x" {
+		t.Errorf("properties %v", o.Properties)
+	}
+
+	// Without a vectorizer, chunks bring their own vectors.
+	requests, objects = nil, nil
+	own := &WeaviateSink{Endpoint: srv.URL, APIKey: "key", Class: "Note"}
+	if err := own.Write(ctx, doc, chunks); err == nil {
+		t.Error("chunk without embedding returned no error")
+	}
+	if err := own.Write(ctx, doc, chunks[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 || !reflect.DeepEqual(objects[0].Vector, []float32{1, 0}) {
+		t.Errorf("objects %+v", objects)
+	}
+
+	batchResponse = `[{"id": "x", "result": {"errors": {"error": [{"message": "bad property"}]}}}]`
+	if err := own.Write(ctx, doc, chunks[:1]); err == nil {
+		t.Error("per-object error returned no error")
+	}
+}
+
+func TestWeaviateProperty(t *testing.T) {
+	for key, want := range map[string]string{
+		"lang":         "lang",
+		"heading-path": "heading_path",
+		"9lives":       "_9lives",
+		"a9":           "a9",
+		"día":          "d_a",
+	} {
+		if got := weaviateProperty(key); got != want {
+			t.Errorf("weaviateProperty(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
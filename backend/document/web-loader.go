@@ -0,0 +1,479 @@
+package document
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultUserAgent identifies HTTPLoader and Crawler requests.
+const defaultUserAgent = "agento-crawler/1.0"
+
+// HTTPLoader fetches sources given as http or https URLs. The parser is
+// picked by the response's Content-Type, and the final URL after
+// redirects and the fetch time are recorded as provenance.
+type HTTPLoader struct {
+	// UserAgent defaults to "agento-crawler/1.0".
+	UserAgent string
+	// Header is added to every request, such as for authentication.
+	Header http.Header
+	// MaxSize rejects responses larger than this many bytes. Defaults to
+	// 100 MB.
+	MaxSize int64
+	// Client defaults to an http.Client with a one minute timeout.
+	Client *http.Client
+}
+
+// NewHTTPLoader creates a loader for URLs.
+func NewHTTPLoader() *HTTPLoader {
+	return &HTTPLoader{}
+}
+
+// Load downloads the URL source.
+func (l *HTTPLoader) Load(ctx context.Context, source string) ([]byte, string, error) {
+	f, err := l.Fetch(ctx, source)
+	if err != nil {
+		return nil, "", err
+	}
+	return f.Buffer, f.Filename, nil
+}
+
+// Fetch downloads the URL source. Responses other than 200 OK fail.
+func (l *HTTPLoader) Fetch(ctx context.Context, source string) (*Fetched, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range l.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("User-Agent", l.userAgent())
+	resp, err := httpClient(l.Client, time.Minute).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s: %s", source, resp.Status)
+	}
+	maxSize := l.MaxSize
+	if maxSize <= 0 {
+		maxSize = 100 << 20
+	}
+	if resp.ContentLength > maxSize {
+		return nil, fmt.Errorf("%w: %s is %d bytes, over the limit of %d", ErrTooLarge, source, resp.ContentLength, maxSize)
+	}
+	buffer, err := readAllLimited(resp.Body, maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", source, err)
+	}
+
+	final := resp.Request.URL.String()
+	f := &Fetched{Buffer: buffer, Filename: final, URI: final, FetchedAt: time.Now().UTC()}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil &&
+		mediaType != "application/octet-stream" {
+		f.MIMEType = mediaType
+	}
+	return f, nil
+}
+
+func (l *HTTPLoader) userAgent() string {
+	if l.UserAgent == "" {
+		return defaultUserAgent
+	}
+	return l.UserAgent
+}
+
+// Crawler is a loader that lists the pages of a site by following links
+// from a start URL, breadth first, for Pipeline.RunPrefix. Pages fetched
+// while crawling are kept until the pipeline loads them, up to
+// MaxCacheBytes, so most pages are downloaded once. Redirects are only
+// followed to URLs the crawler could list itself.
+type Crawler struct {
+	// Loader fetches pages. Defaults to NewHTTPLoader().
+	Loader *HTTPLoader
+	// MaxDepth is how many links away from the start URL to follow.
+	// Defaults to 2.
+	MaxDepth int
+	// MaxPages limits the pages listed. Defaults to 100.
+	MaxPages int
+	// AllowExternal follows links to other hosts than the start URL's.
+	AllowExternal bool
+	// IgnoreRobots skips checking robots.txt, which is obeyed by default.
+	IgnoreRobots bool
+	// Delay is the pause between requests. Defaults to none.
+	Delay time.Duration
+	// MaxCacheBytes limits the crawled pages kept for the pipeline to load.
+	// Pages beyond it are downloaded again when loaded. Defaults to 32 MB.
+	MaxCacheBytes int64
+
+	mu     sync.Mutex
+	pages  map[string]*Fetched
+	cached int64
+	robots map[string]*robotsRules
+	// host is the host of the crawl's start URL.
+	host string
+}
+
+// NewCrawler creates a crawler that follows links up to maxDepth away.
+func NewCrawler(maxDepth int) *Crawler {
+	return &Crawler{MaxDepth: maxDepth}
+}
+
+// Load returns a crawled page, or downloads source.
+func (c *Crawler) Load(ctx context.Context, source string) ([]byte, string, error) {
+	f, err := c.Fetch(ctx, source)
+	if err != nil {
+		return nil, "", err
+	}
+	return f.Buffer, f.Filename, nil
+}
+
+// Fetch returns a crawled page, or downloads source.
+func (c *Crawler) Fetch(ctx context.Context, source string) (*Fetched, error) {
+	c.mu.Lock()
+	f, ok := c.pages[source]
+	if ok {
+		delete(c.pages, source)
+		c.cached -= int64(len(f.Buffer))
+	}
+	host := c.host
+	c.mu.Unlock()
+	if ok {
+		return f, nil
+	}
+	if host == "" {
+		u, err := url.Parse(source)
+		if err != nil {
+			return nil, err
+		}
+		host = u.Host
+	}
+	return c.fetch(ctx, source, host)
+}
+
+// errRedirectBlocked stops a redirect the crawler may not follow.
+var errRedirectBlocked = errors.New("redirect to a URL outside the crawl")
+
+// fetch downloads source, following redirects only to URLs on host,
+// unless AllowExternal is set, that robots.txt allows.
+func (c *Crawler) fetch(ctx context.Context, source, host string) (*Fetched, error) {
+	l := *c.loader()
+	client := *httpClient(l.Client, time.Minute)
+	check := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if !c.AllowExternal && req.URL.Host != host ||
+			!c.IgnoreRobots && !c.allowed(req.Context(), req.URL) {
+			return fmt.Errorf("%w: %s", errRedirectBlocked, req.URL)
+		}
+		if check != nil {
+			return check(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	l.Client = &client
+	return l.Fetch(ctx, source)
+}
+
+// List crawls from the URL start and returns the URLs of the pages
+// fetched, in the order found. Pages that fail to load are skipped.
+func (c *Crawler) List(ctx context.Context, start string) ([]string, error) {
+	root, err := url.Parse(start)
+	if err != nil {
+		return nil, err
+	}
+	maxDepth, maxPages, maxCache := c.MaxDepth, c.MaxPages, c.MaxCacheBytes
+	if maxDepth <= 0 {
+		maxDepth = 2
+	}
+	if maxPages <= 0 {
+		maxPages = 100
+	}
+	if maxCache <= 0 {
+		maxCache = 32 << 20
+	}
+	c.mu.Lock()
+	c.host = root.Host
+	c.mu.Unlock()
+	type queued struct {
+		u     *url.URL
+		depth int
+	}
+	queue := []queued{{root, 0}}
+	seen := map[string]bool{crawlKey(root): true}
+	var listed []string
+	for len(queue) > 0 && len(listed) < maxPages {
+		if err := ctx.Err(); err != nil {
+			return listed, err
+		}
+		q := queue[0]
+		queue = queue[1:]
+		if !c.IgnoreRobots && !c.allowed(ctx, q.u) {
+			continue
+		}
+		if c.Delay > 0 && len(listed) > 0 {
+			select {
+			case <-time.After(c.Delay):
+			case <-ctx.Done():
+				return listed, ctx.Err()
+			}
+		}
+		f, err := c.fetch(ctx, q.u.String(), root.Host)
+		if err != nil {
+			continue
+		}
+		// Key the page by the URL it was listed under, which Fetch is
+		// called with.
+		c.mu.Lock()
+		if c.cached+int64(len(f.Buffer)) <= maxCache {
+			if c.pages == nil {
+				c.pages = map[string]*Fetched{}
+			}
+			c.pages[q.u.String()] = f
+			c.cached += int64(len(f.Buffer))
+		}
+		c.mu.Unlock()
+		listed = append(listed, q.u.String())
+
+		if q.depth >= maxDepth || !strings.Contains(f.MIMEType, "html") && DetectMIME(f.Buffer, f.Filename) != "text/html" {
+			continue
+		}
+		base, err := url.Parse(f.URI)
+		if err != nil {
+			continue
+		}
+		for _, link := range htmlLinks(f.Buffer, base) {
+			if !c.AllowExternal && link.Host != root.Host {
+				continue
+			}
+			if key := crawlKey(link); !seen[key] {
+				seen[key] = true
+				queue = append(queue, queued{link, q.depth + 1})
+			}
+		}
+	}
+	return listed, nil
+}
+
+func (c *Crawler) loader() *HTTPLoader {
+	if c.Loader == nil {
+		return NewHTTPLoader()
+	}
+	return c.Loader
+}
+
+// crawlKey identifies a URL for de-duplication, ignoring its fragment.
+func crawlKey(u *url.URL) string {
+	v := *u
+	v.Fragment = ""
+	return v.String()
+}
+
+// htmlLinks returns the http and https links of the page, resolved
+// against base and without fragments.
+func htmlLinks(page []byte, base *url.URL) []*url.URL {
+	root := parseHTML(string(page))
+	if b := root.find("base"); b != nil {
+		if u, err := base.Parse(b.attrs["href"]); err == nil {
+			base = u
+		}
+	}
+	var links []*url.URL
+	for _, a := range root.findAll("a") {
+		href := strings.TrimSpace(a.attrs["href"])
+		if href == "" || strings.Contains(a.attrs["rel"], "nofollow") {
+			continue
+		}
+		u, err := base.Parse(href)
+		if err != nil || u.Scheme != "http" && u.Scheme != "https" {
+			continue
+		}
+		u.Fragment = ""
+		links = append(links, u)
+	}
+	return links
+}
+
+// allowed reports whether robots.txt of u's host lets the crawler fetch
+// u. Its rules are fetched once per host with fetchRobots.
+func (c *Crawler) allowed(ctx context.Context, u *url.URL) bool {
+	hostKey := u.Scheme + "://" + u.Host
+	c.mu.Lock()
+	rules, ok := c.robots[hostKey]
+	c.mu.Unlock()
+	if !ok {
+		rules = c.fetchRobots(ctx, u)
+		c.mu.Lock()
+		if c.robots == nil {
+			c.robots = map[string]*robotsRules{}
+		}
+		c.robots[hostKey] = rules
+		c.mu.Unlock()
+	}
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return rules.allows(path)
+}
+
+// maxRobotsSize is how much of a robots.txt is parsed, the minimum RFC
+// 9309 asks crawlers to read.
+const maxRobotsSize = 500 << 10
+
+// fetchRobots downloads and parses robots.txt of u's host following RFC
+// 9309: a file that is missing (4xx) allows everything, and a server error
+// (5xx) or an unreachable host disallows everything. Redirects are followed
+// up to five hops on the same host; a robots.txt behind more, or behind a
+// redirect to another host, is taken as missing.
+func (c *Crawler) fetchRobots(ctx context.Context, u *url.URL) *robotsRules {
+	disallowAll := &robotsRules{disallow: []string{"/"}}
+	l := c.loader()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.Scheme+"://"+u.Host+"/robots.txt", nil)
+	if err != nil {
+		return disallowAll
+	}
+	for k, v := range l.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("User-Agent", l.userAgent())
+	client := *httpClient(l.Client, time.Minute)
+	client.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+		if len(via) > 5 || next.URL.Host != u.Host {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return disallowAll
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxRobotsSize))
+		if err != nil {
+			return disallowAll
+		}
+		return parseRobots(data, l.userAgent())
+	case resp.StatusCode >= 500:
+		return disallowAll
+	default:
+		return &robotsRules{}
+	}
+}
+
+// robotsRules are the Allow and Disallow patterns of robots.txt that
+// apply to one user agent.
+type robotsRules struct {
+	allow, disallow []string
+}
+
+// allows applies the longest matching rule to path, Allow winning ties.
+func (r *robotsRules) allows(path string) bool {
+	if path == "" || path[0] == '?' {
+		path = "/" + path
+	}
+	best, ok := -1, true
+	for _, p := range r.allow {
+		if len(p) >= best && robotsMatch(p, path) {
+			best, ok = len(p), true
+		}
+	}
+	for _, p := range r.disallow {
+		if len(p) > best && robotsMatch(p, path) {
+			best, ok = len(p), false
+		}
+	}
+	return ok
+}
+
+// robotsMatch reports whether the robots.txt pattern matches the start of
+// path. In patterns "*" matches any run of characters and a final "$"
+// anchors the pattern at the end of path.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			// The last part must end the path, found as late as possible.
+			return strings.HasSuffix(rest, part)
+		}
+		j := strings.Index(rest, part)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(part):]
+	}
+	return !anchored || rest == ""
+}
+
+// parseRobots reads the rules of robots.txt for userAgent: those of the
+// groups naming its product token, or of the "*" groups when none do.
+func parseRobots(data []byte, userAgent string) *robotsRules {
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexByte(token, '/'); i >= 0 {
+		token = token[:i]
+	}
+	var specific, wildcard robotsRules
+	var agents []string
+	inRules, named := false, false
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			if inRules {
+				agents, inRules = nil, false
+			}
+			a := strings.ToLower(value)
+			agents = append(agents, a)
+			named = named || a != "*" && strings.Contains(token, a)
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue
+			}
+			for _, a := range agents {
+				target := &wildcard
+				if a != "*" {
+					if !strings.Contains(token, a) {
+						continue
+					}
+					target = &specific
+				}
+				if key == "allow" {
+					target.allow = append(target.allow, value)
+				} else {
+					target.disallow = append(target.disallow, value)
+				}
+			}
+		}
+	}
+	if named {
+		return &specific
+	}
+	return &wildcard
+}
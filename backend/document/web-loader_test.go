@@ -0,0 +1,375 @@
+package document
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHTTPLoader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != defaultUserAgent || r.Header.Get("X-Token") != "t" {
+			http.Error(w, "unexpected headers", http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/notes", http.StatusMovedPermanently)
+		case "/notes":
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			w.Write([]byte("# Notes\n\nSome text."))
+		case "/blob":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte("plain words"))
+		case "/big":
+			w.Write([]byte(strings.Repeat("x", 100)))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	l := &HTTPLoader{Header: http.Header{"X-Token": {"t"}}}
+	ctx := context.Background()
+
+	f, err := l.Fetch(ctx, srv.URL+"/old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.URI != srv.URL+"/notes" || f.Filename != f.URI || f.MIMEType != "text/markdown" || f.FetchedAt.IsZero() ||
+		string(f.Buffer) != "# Notes\n\nSome text." {
+		t.Errorf("fetched %+v", f)
+	}
+	if f, err := l.Fetch(ctx, srv.URL+"/blob"); err != nil || f.MIMEType != "" {
+		t.Errorf("octet-stream fetch: %+v, %v", f, err)
+	}
+	if _, _, err := l.Load(ctx, srv.URL+"/missing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("missing page: %v", err)
+	}
+	small := &HTTPLoader{Header: l.Header, MaxSize: 10}
+	if _, err := small.Fetch(ctx, srv.URL+"/big"); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("oversized page: %v", err)
+	}
+
+	var doc *Document
+	p := NewPipeline().LoadWith(l).To(SinkFunc(func(ctx context.Context, d *Document, chunks []Chunk) error {
+		doc = d
+		return nil
+	}))
+	if err := p.Run(ctx, srv.URL+"/old"); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Provenance.SourceURI != srv.URL+"/notes" || doc.Provenance.FetchedAt.IsZero() || len(doc.Sections) == 0 {
+		t.Errorf("document provenance %+v, sections %d", doc.Provenance, len(doc.Sections))
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	data := []byte(`# rules
+User-agent: *
+Disallow: /private
+Allow: /private/open
+
+User-agent: other-bot
+User-agent: Agento-Crawler
+Disallow: /
+Allow: /public
+`)
+	tests := []struct {
+		agent, path string
+		want        bool
+	}{
+		{"someone/2.0", "/", true},
+		{"someone/2.0", "/private/x", false},
+		{"someone/2.0", "/private/open/x", true},
+		{defaultUserAgent, "/private/open/x", false},
+		{defaultUserAgent, "/public/a", true},
+		{defaultUserAgent, "", false},
+	}
+	for _, tt := range tests {
+		if got := parseRobots(data, tt.agent).allows(tt.path); got != tt.want {
+			t.Errorf("%s allows(%q) = %v, want %v", tt.agent, tt.path, got, tt.want)
+		}
+	}
+	if !parseRobots([]byte("User-agent: *\nDisallow:\n"), defaultUserAgent).allows("/a") {
+		t.Error("an empty Disallow disallowed a path")
+	}
+}
+
+func TestHTMLLinks(t *testing.T) {
+	base, _ := url.Parse("https://example.com/docs/index.html")
+	page := []byte(`<html><head><base href="/guide/"></head><body>
+		<a href="intro.html#top">Intro</a>
+		<a href="https://other.org/x">Other</a>
+		<a href="mailto:a@example.com">Mail</a>
+		<a href="/skip" rel="nofollow">Skip</a>
+		<a href="">Empty</a>
+	</body></html>`)
+	var got []string
+	for _, u := range htmlLinks(page, base) {
+		got = append(got, u.String())
+	}
+	if want := []string{"https://example.com/guide/intro.html", "https://other.org/x"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("links %q, want %q", got, want)
+	}
+}
+
+func TestCrawler(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("User-agent: *\nDisallow: /private\n"))
+		case "/":
+			w.Write([]byte(`<a href="/a">A</a> <a href="/a#part">A again</a> <a href="/private/x">P</a>
+				<a href="/broken">B</a> <a href="https://elsewhere.invalid/">E</a>`))
+		case "/a":
+			w.Write([]byte(`<a href="/b">B</a>`))
+		case "/b":
+			w.Write([]byte(`<a href="/c">C</a>`))
+		case "/private/x":
+			w.Write([]byte("private"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	c := NewCrawler(2)
+	listed, err := c.List(ctx, srv.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{srv.URL + "/", srv.URL + "/a", srv.URL + "/b"}; !reflect.DeepEqual(listed, want) {
+		t.Errorf("listed %q, want %q", listed, want)
+	}
+	for _, p := range requests {
+		if p == "/private/x" {
+			t.Error("fetched a page robots.txt disallows")
+		}
+	}
+
+	// Listed pages are served from the crawl, once.
+	before := len(requests)
+	f, err := c.Fetch(ctx, srv.URL+"/a")
+	if err != nil || !strings.Contains(string(f.Buffer), "/b") || len(requests) != before {
+		t.Errorf("cached fetch: %v, %d new requests", err, len(requests)-before)
+	}
+	if _, err := c.Fetch(ctx, srv.URL+"/a"); err != nil || len(requests) != before+1 {
+		t.Errorf("second fetch: %v, %d new requests", err, len(requests)-before)
+	}
+
+	limited := &Crawler{MaxDepth: 2, MaxPages: 2, IgnoreRobots: true}
+	if listed, err := limited.List(ctx, srv.URL+"/"); err != nil || len(listed) != 2 {
+		t.Errorf("MaxPages 2 listed %q, %v", listed, err)
+	}
+	ignoring := &Crawler{MaxDepth: 1, IgnoreRobots: true}
+	if listed, err := ignoring.List(ctx, srv.URL+"/"); err != nil || len(listed) != 3 || listed[2] != srv.URL+"/private/x" {
+		t.Errorf("IgnoreRobots listed %q, %v", listed, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := NewCrawler(1).List(cancelled, srv.URL+"/"); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled crawl: %v", err)
+	}
+}
+
+func TestRobotsRules(t *testing.T) {
+	rules := parseRobots([]byte(`User-agent: *
+Disallow: /private
+Disallow: /*.pdf$
+Disallow: /*?session=
+Allow: /private/open$
+Disallow: /tmp/*/cache
+`), defaultUserAgent)
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/private/x", false},
+		{"/private/open", true},
+		{"/private/open/more", false},
+		{"/docs/a.pdf", false},
+		{"/docs/a.pdf.html", true},
+		{"/search?session=1", false},
+		{"/search?q=1", true},
+		{"/tmp/a/b/cache/x", false},
+		{"/tmp/cache", true},
+	}
+	for _, tt := range tests {
+		if got := rules.allows(tt.path); got != tt.want {
+			t.Errorf("allows(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+// RFC 9309: a missing robots.txt allows everything, a server error or an
+// unreachable host disallows everything, and redirects are followed up to
+// five hops on the same host.
+func TestCrawlerRobotsStatus(t *testing.T) {
+	var externalHits atomic.Int32
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		externalHits.Add(1)
+		fmt.Fprint(w, "User-agent: *\nDisallow: /\n")
+	}))
+	defer external.Close()
+
+	disallowSecret := func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /secret\n")
+	}
+	tests := []struct {
+		name   string
+		robots http.HandlerFunc
+		want   []string
+	}{
+		{"found", disallowSecret, []string{"/", "/open"}},
+		{"not found", http.NotFound, []string{"/", "/open", "/secret"}},
+		{"gone", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusGone) }, []string{"/", "/open", "/secret"}},
+		{"server error", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) }, nil},
+		{"unreachable", func(w http.ResponseWriter, r *http.Request) { panic(http.ErrAbortHandler) }, nil},
+		{"redirect", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/robots/1", http.StatusMovedPermanently)
+		}, []string{"/", "/open"}},
+		{"redirect loop", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "/robots.txt?again", http.StatusFound)
+		}, []string{"/", "/open", "/secret"}},
+		{"redirect to other host", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, external.URL+"/robots.txt", http.StatusFound)
+		}, []string{"/", "/open", "/secret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/robots.txt":
+					tt.robots(w, r)
+				case "/robots/1":
+					disallowSecret(w, r)
+				default:
+					w.Header().Set("Content-Type", "text/html")
+					fmt.Fprint(w, `<a href="/open">open</a> <a href="/secret">secret</a>`)
+				}
+			}))
+			defer srv.Close()
+			listed, err := NewCrawler(1).List(context.Background(), srv.URL+"/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, u := range listed {
+				got = append(got, strings.TrimPrefix(u, srv.URL))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listed %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if n := externalHits.Load(); n != 0 {
+		t.Errorf("followed a robots.txt redirect to another host %d times", n)
+	}
+}
+
+// crawlSite serves a site of n linked pages of size bytes, a page that
+// redirects to target, and a robots.txt disallowing /secret.
+func crawlSite(t *testing.T, n, size int, target string, fetches *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /secret\n")
+			return
+		case "/moved":
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		var links strings.Builder
+		if r.URL.Path == "/" {
+			for i := range n {
+				fmt.Fprintf(&links, `<a href="/p%d">page</a>`, i)
+			}
+			links.WriteString(`<a href="/moved">moved</a>`)
+		}
+		fmt.Fprintf(w, "<html><body>%s<p>%s</p></body></html>", links.String(), strings.Repeat("x", size))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCrawlerCacheLimit(t *testing.T) {
+	var fetches atomic.Int32
+	srv := crawlSite(t, 4, 1000, "/p0", &fetches)
+	c := &Crawler{MaxDepth: 1, MaxCacheBytes: 3000}
+	listed, err := c.List(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crawled := fetches.Load()
+	if c.cached > 3000 {
+		t.Errorf("cached %d bytes, over the limit of 3000", c.cached)
+	}
+	for _, u := range listed {
+		if _, err := c.Fetch(context.Background(), u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if refetched := fetches.Load() - crawled; refetched == 0 || int(refetched) == len(listed) {
+		t.Errorf("refetched %d of %d pages, want only those over the cache limit", refetched, len(listed))
+	}
+	if c.cached != 0 || len(c.pages) != 0 {
+		t.Errorf("%d pages, %d bytes left cached after loading", len(c.pages), c.cached)
+	}
+}
+
+func TestCrawlerRedirectChecks(t *testing.T) {
+	var fetches atomic.Int32
+	external := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		fmt.Fprint(w, "<html><body>elsewhere</body></html>")
+	}))
+	defer external.Close()
+
+	tests := []struct {
+		name, target string
+		external     bool
+		want         bool
+	}{
+		{"same host", "/p0", false, true},
+		{"disallowed by robots", "/secret", false, false},
+		{"other host", external.URL + "/", false, false},
+		{"other host allowed", external.URL + "/", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := crawlSite(t, 0, 10, tt.target, &fetches)
+			c := &Crawler{MaxDepth: 1, AllowExternal: tt.external}
+			listed, err := c.List(context.Background(), srv.URL+"/")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := false
+			for _, u := range listed {
+				got = got || strings.HasSuffix(u, "/moved")
+			}
+			if got != tt.want {
+				t.Errorf("listed %v, want the redirect followed %v", listed, tt.want)
+			}
+			if _, err := c.Fetch(context.Background(), srv.URL+"/moved"); (err == nil) != tt.want {
+				t.Errorf("Fetch of the redirect = %v, want success %v", err, tt.want)
+			}
+		})
+	}
+}
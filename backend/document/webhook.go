@@ -0,0 +1,289 @@
+package document
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebhookEvent names a pipeline event a Webhook can be notified of.
+type WebhookEvent string
+
+const (
+	// DocumentProcessed fires when a source has been chunked and written
+	// to the sinks.
+	DocumentProcessed WebhookEvent = "document.processed"
+	// DocumentFailed fires when a source could not be loaded or processed.
+	DocumentFailed WebhookEvent = "document.failed"
+	// BatchCompleted fires when Pipeline.Run or ProcessBatch returns.
+	BatchCompleted WebhookEvent = "batch.completed"
+)
+
+// Headers set on webhook deliveries. The signature is "sha256=" and the
+// hex HMAC-SHA256, keyed with the webhook's secret, of the timestamp, a
+// ".", and the body. See VerifyWebhook.
+const (
+	WebhookEventHeader     = "X-Agento-Event"
+	WebhookTimestampHeader = "X-Agento-Timestamp"
+	WebhookSignatureHeader = "X-Agento-Signature"
+)
+
+// WebhookPayload is the JSON body of a webhook delivery.
+type WebhookPayload struct {
+	Event WebhookEvent `json:"event"`
+	Time  time.Time    `json:"time"`
+	// Source, Version and Error describe the document of a document
+	// event. Error is set for DocumentFailed.
+	Source  string `json:"source,omitempty"`
+	Version string `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// Chunks is the number of chunks of the document, or of the batch for
+	// BatchCompleted.
+	Chunks int `json:"chunks"`
+	// Files, Failed and ElapsedMS summarize a batch for BatchCompleted.
+	Files     int   `json:"files,omitempty"`
+	Failed    int   `json:"failed,omitempty"`
+	ElapsedMS int64 `json:"elapsed_ms,omitempty"`
+}
+
+// Webhook posts signed pipeline events to a URL. Add webhooks to a
+// pipeline with Pipeline.Notify. Events are queued and delivered in the
+// background, in order, so a slow receiver does not hold up the pipeline;
+// call Flush to wait for them. A delivery that fails, after retries, never
+// fails the pipeline; it is reported to OnError.
+type Webhook struct {
+	URL string
+	// Secret signs each delivery. Deliveries are unsigned when it is empty.
+	Secret string
+	// Events are the events delivered. Defaults to all of them.
+	Events []WebhookEvent
+	// Header is added to every delivery.
+	Header http.Header
+	// OnError, when set, is called with each delivery that fails.
+	OnError func(payload *WebhookPayload, err error)
+	// MaxRetries is the number of times a failed delivery is retried, with
+	// exponential backoff. Defaults to 3; negative turns retries off.
+	MaxRetries int
+	// Client defaults to an http.Client with a 30 second timeout.
+	Client *http.Client
+	// QueueSize limits the events waiting to be delivered. Events beyond
+	// it are dropped and reported to OnError with ErrWebhookQueueFull.
+	// Defaults to 100.
+	QueueSize int
+	// Timeout bounds each queued delivery, retries included. Defaults to
+	// two minutes.
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	queue   chan webhookDelivery
+	running bool
+	// pending counts the events queued or being delivered. idle is closed,
+	// and cleared, when it drops to zero.
+	pending int
+	idle    chan struct{}
+}
+
+// webhookDelivery is a queued event and the context it was raised in.
+type webhookDelivery struct {
+	ctx     context.Context
+	payload *WebhookPayload
+}
+
+// NewWebhook creates a webhook that posts events, or every event when
+// none are given, to url, signed with secret.
+func NewWebhook(url, secret string, events ...WebhookEvent) *Webhook {
+	return &Webhook{URL: url, Secret: secret, Events: events}
+}
+
+// Wants reports whether the webhook delivers event.
+func (w *Webhook) Wants(event WebhookEvent) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
+
+// Send posts payload to the webhook's URL now, whatever its Events. Each
+// attempt is signed with its own timestamp, so retries are not rejected
+// as stale by VerifyWebhook.
+func (w *Webhook) Send(ctx context.Context, payload *WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	for k, v := range w.Header {
+		header[k] = v
+	}
+	header.Set(WebhookEventHeader, string(payload.Event))
+	rp := retryPolicy{maxRetries: w.MaxRetries, prepare: func(req *http.Request) {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(WebhookTimestampHeader, timestamp)
+		if w.Secret != "" {
+			req.Header.Set(WebhookSignatureHeader, webhookSignature(w.Secret, timestamp, body))
+		}
+	}}
+	_, err = rp.send(ctx, httpClient(w.Client, 30*time.Second), http.MethodPost, w.URL, header, body, "application/json")
+	if err != nil {
+		return fmt.Errorf("deliver %s webhook: %w", payload.Event, err)
+	}
+	return nil
+}
+
+// enqueue queues payload for delivery, starting a delivery goroutine when
+// none is running, or reports it to OnError when the queue is full.
+func (w *Webhook) enqueue(ctx context.Context, payload *WebhookPayload) {
+	w.mu.Lock()
+	if w.queue == nil {
+		size := w.QueueSize
+		if size <= 0 {
+			size = 100
+		}
+		w.queue = make(chan webhookDelivery, size)
+	}
+	select {
+	case w.queue <- webhookDelivery{context.WithoutCancel(ctx), payload}:
+		if w.pending++; w.idle == nil {
+			w.idle = make(chan struct{})
+		}
+		if !w.running {
+			w.running = true
+			go w.deliver()
+		}
+		w.mu.Unlock()
+	default:
+		w.mu.Unlock()
+		if w.OnError != nil {
+			w.OnError(payload, fmt.Errorf("deliver %s webhook: %w", payload.Event, ErrWebhookQueueFull))
+		}
+	}
+}
+
+// deliver sends queued events until the queue is empty.
+func (w *Webhook) deliver() {
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	for {
+		w.mu.Lock()
+		var d webhookDelivery
+		select {
+		case d = <-w.queue:
+		default:
+			w.running = false
+			w.mu.Unlock()
+			return
+		}
+		w.mu.Unlock()
+		ctx, cancel := context.WithTimeout(d.ctx, timeout)
+		if err := w.Send(ctx, d.payload); err != nil && w.OnError != nil {
+			w.OnError(d.payload, err)
+		}
+		cancel()
+		w.mu.Lock()
+		if w.pending--; w.pending == 0 {
+			close(w.idle)
+			w.idle = nil
+		}
+		w.mu.Unlock()
+	}
+}
+
+// Flush waits until the queued events have been delivered, or have
+// failed, or ctx is done.
+func (w *Webhook) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	idle := w.idle
+	w.mu.Unlock()
+	if idle == nil {
+		return nil
+	}
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// VerifyWebhook checks the signature of a delivery received with header
+// and body against secret, for receivers. Deliveries timestamped more than
+// maxAge from now are rejected, against replays, unless maxAge is 0.
+func VerifyWebhook(secret string, header http.Header, body []byte, maxAge time.Duration) error {
+	timestamp := header.Get(WebhookTimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("webhook timestamp is missing or invalid")
+	}
+	if age := time.Since(time.Unix(unix, 0)); maxAge > 0 && (age > maxAge || age < -maxAge) {
+		return fmt.Errorf("webhook timestamp is %s old", age.Round(time.Second))
+	}
+	got := header.Get(WebhookSignatureHeader)
+	if !strings.HasPrefix(got, "sha256=") ||
+		!hmac.Equal([]byte(got), []byte(webhookSignature(secret, timestamp, body))) {
+		return errors.New("webhook signature does not match")
+	}
+	return nil
+}
+
+func webhookSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notify adds webhooks notified of the pipeline's events: document events
+// for each source of Run, ProcessBatch and QueueWorker, and BatchCompleted
+// when Run or ProcessBatch returns. The events are delivered in the
+// background; see Webhook.Flush.
+func (p *Pipeline) Notify(hooks ...*Webhook) *Pipeline {
+	p.webhooks = append(p.webhooks, hooks...)
+	return p
+}
+
+// notify queues payload on the webhooks that want it. Delivery goes ahead
+// after ctx is done, so a cancelled run still reports how far it got.
+func (p *Pipeline) notify(ctx context.Context, payload *WebhookPayload) {
+	if len(p.webhooks) == 0 {
+		return
+	}
+	payload.Time = time.Now().UTC()
+	for _, w := range p.webhooks {
+		if w.Wants(payload.Event) {
+			w.enqueue(ctx, payload)
+		}
+	}
+}
+
+// notifyDocument delivers DocumentProcessed for doc, or DocumentFailed
+// when err is set.
+func (p *Pipeline) notifyDocument(ctx context.Context, source string, doc *Document, chunks int, err error) {
+	payload := &WebhookPayload{Event: DocumentProcessed, Source: source, Chunks: chunks}
+	if doc != nil {
+		payload.Source, payload.Version = storeSource(doc), doc.Version
+	}
+	if err != nil {
+		payload.Event, payload.Error = DocumentFailed, err.Error()
+	}
+	p.notify(ctx, payload)
+}
+
+// notifyBatch delivers BatchCompleted for a batch that started at start.
+func (p *Pipeline) notifyBatch(ctx context.Context, files, failed, chunks int, start time.Time) {
+	p.notify(ctx, &WebhookPayload{
+		Event:     BatchCompleted,
+		Files:     files,
+		Failed:    failed,
+		Chunks:    chunks,
+		ElapsedMS: time.Since(start).Milliseconds(),
+	})
+}
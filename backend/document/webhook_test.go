@@ -0,0 +1,272 @@
+package document
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookSend(t *testing.T) {
+	var got http.Header
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" || r.URL.Path == "/rejected" {
+			http.Error(w, "not a JSON post", http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	hook := NewWebhook(srv.URL, "secret")
+	hook.Header = http.Header{"X-Tenant": {"acme"}}
+	payload := &WebhookPayload{Event: DocumentFailed, Source: "a.pdf", Error: "bad xref"}
+	if err := hook.Send(ctx, payload); err != nil {
+		t.Fatal(err)
+	}
+	if got.Get(WebhookEventHeader) != "document.failed" || got.Get("X-Tenant") != "acme" ||
+		!strings.HasPrefix(got.Get(WebhookSignatureHeader), "sha256=") {
+		t.Errorf("headers %v", got)
+	}
+	if err := VerifyWebhook("secret", got, body, time.Minute); err != nil {
+		t.Errorf("VerifyWebhook: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]any{"event": "document.failed", "source": "a.pdf", "error": "bad xref", "chunks": 0.0} {
+		if decoded[key] != want {
+			t.Errorf("payload %s = %v, want %v", key, decoded[key], want)
+		}
+	}
+	if _, ok := decoded["files"]; ok {
+		t.Errorf("document payload has batch fields: %s", body)
+	}
+
+	if err := NewWebhook(srv.URL, "").Send(ctx, payload); err != nil || got.Get(WebhookSignatureHeader) != "" {
+		t.Errorf("unsigned delivery: %v, signature %q", err, got.Get(WebhookSignatureHeader))
+	}
+	if err := NewWebhook(srv.URL+"/rejected", "").Send(ctx, payload); err == nil || !strings.Contains(err.Error(), "deliver document.failed webhook") {
+		t.Errorf("rejected delivery: %v", err)
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	body := []byte(`{"event":"batch.completed"}`)
+	signed := func(at time.Time, secret string) http.Header {
+		timestamp := strconv.FormatInt(at.Unix(), 10)
+		return http.Header{
+			WebhookTimestampHeader: {timestamp},
+			WebhookSignatureHeader: {webhookSignature(secret, timestamp, body)},
+		}
+	}
+	now := time.Now()
+	if err := VerifyWebhook("s", signed(now, "s"), body, time.Minute); err != nil {
+		t.Errorf("valid delivery: %v", err)
+	}
+	if err := VerifyWebhook("s", signed(now, "other"), body, time.Minute); err == nil {
+		t.Error("wrong secret verified")
+	}
+	if err := VerifyWebhook("s", signed(now, "s"), []byte(`{"event":"document.failed"}`), time.Minute); err == nil {
+		t.Error("changed body verified")
+	}
+	old := signed(now.Add(-time.Hour), "s")
+	if err := VerifyWebhook("s", old, body, time.Minute); err == nil {
+		t.Error("stale delivery verified")
+	}
+	if err := VerifyWebhook("s", old, body, 0); err != nil {
+		t.Errorf("stale delivery without maxAge: %v", err)
+	}
+	if err := VerifyWebhook("s", http.Header{}, body, 0); err == nil {
+		t.Error("delivery without a timestamp verified")
+	}
+}
+
+func TestWebhookWants(t *testing.T) {
+	if !NewWebhook("u", "").Wants(DocumentFailed) {
+		t.Error("webhook without events does not want every event")
+	}
+	hook := NewWebhook("u", "", BatchCompleted)
+	if hook.Wants(DocumentProcessed) || !hook.Wants(BatchCompleted) {
+		t.Errorf("events %v", hook.Events)
+	}
+}
+
+func TestWebhookDeliversInBackground(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var events []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		body, _ := io.ReadAll(r.Body)
+		if err := VerifyWebhook("secret", r.Header, body, time.Minute); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		events = append(events, r.Header.Get(WebhookEventHeader))
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	hook := NewWebhook(srv.URL, "secret")
+	p := NewPipeline().Notify(hook)
+	done := make(chan error, 1)
+	go func() { done <- p.Run(context.Background(), fixture("test-txt.txt")) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run waited for a blocked webhook receiver")
+	}
+
+	release <- struct{}{}
+	release <- struct{}{}
+	if err := hook.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{string(DocumentProcessed), string(BatchCompleted)}
+	if len(events) != len(want) || events[0] != want[0] || events[1] != want[1] {
+		t.Errorf("delivered %v, want %v in order", events, want)
+	}
+}
+
+func TestWebhookQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var dropped int
+	hook := &Webhook{URL: srv.URL, QueueSize: 1, OnError: func(_ *WebhookPayload, err error) {
+		if errors.Is(err, ErrWebhookQueueFull) {
+			mu.Lock()
+			dropped++
+			mu.Unlock()
+		}
+	}}
+	p := NewPipeline().Notify(hook)
+	// The first event is taken off the queue by the delivery goroutine,
+	// the second waits, and the rest are dropped.
+	p.notify(context.Background(), &WebhookPayload{Event: BatchCompleted})
+	for {
+		hook.mu.Lock()
+		waiting := len(hook.queue)
+		hook.mu.Unlock()
+		if waiting == 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	for range 4 {
+		p.notify(context.Background(), &WebhookPayload{Event: BatchCompleted})
+	}
+	close(release)
+	if err := hook.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if dropped != 3 {
+		t.Errorf("dropped %d events, want 3", dropped)
+	}
+}
+
+func TestWebhookFlushConcurrent(t *testing.T) {
+	var mu sync.Mutex
+	delivered := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		delivered++
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	hook := &Webhook{URL: srv.URL, QueueSize: 1000}
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				hook.enqueue(context.Background(), &WebhookPayload{Event: DocumentProcessed})
+				if err := hook.Flush(context.Background()); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := hook.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if delivered != 160 {
+		t.Errorf("delivered %d of 160 events", delivered)
+	}
+}
+
+func TestWebhookFlushTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	hook := NewWebhook(srv.URL, "")
+	hook.enqueue(context.Background(), &WebhookPayload{Event: BatchCompleted})
+	before := runtime.NumGoroutine()
+	for range 100 {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Microsecond)
+		if err := hook.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Flush with a blocked receiver = %v", err)
+		}
+		cancel()
+	}
+	if after := runtime.NumGoroutine(); after >= before+100 {
+		t.Errorf("%d goroutines after timed out flushes, %d before", after, before)
+	}
+}
+
+func TestWebhookRetriesAreResigned(t *testing.T) {
+	var mu sync.Mutex
+	var timestamps []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := VerifyWebhook("secret", r.Header, body, 0); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		timestamps = append(timestamps, r.Header.Get(WebhookTimestampHeader))
+		first := len(timestamps) == 1
+		mu.Unlock()
+		if first {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	hook := NewWebhook(srv.URL, "secret")
+	if err := hook.Send(context.Background(), &WebhookPayload{Event: BatchCompleted}); err != nil {
+		t.Fatal(err)
+	}
+	if len(timestamps) != 2 || timestamps[0] == timestamps[1] {
+		t.Errorf("attempts timestamped %v, want two different timestamps", timestamps)
+	}
+}
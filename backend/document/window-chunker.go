@@ -0,0 +1,66 @@
+package document
+
+// WindowChunker cuts text into windows of exactly Size tokens, starting a
+// new window every Stride tokens, regardless of sentence or paragraph
+// boundaries. A Stride below Size makes windows overlap; only the last
+// window may be shorter.
+type WindowChunker struct {
+	// Size is the window length in tokens. Defaults to 200.
+	Size int
+	// Stride is the distance between window starts in tokens. Defaults to
+	// Size.
+	Stride int
+	// Tokenizer measures windows. Defaults to DefaultTokenizer.
+	Tokenizer Tokenizer
+}
+
+// NewWindowChunker creates a window chunker with the given size and
+// stride in tokens, configured by opts.
+func NewWindowChunker(size, stride int, opts ...ChunkOption) *WindowChunker {
+	o := NewOptions(opts...)
+	return &WindowChunker{Size: size, Stride: stride, Tokenizer: o.Tokenizer}
+}
+
+// Split returns the windows of text with surrounding whitespace trimmed.
+func (c *WindowChunker) Split(text string) []string {
+	spans := c.spans(text)
+	out := make([]string, len(spans))
+	for i, sp := range spans {
+		out[i] = text[sp.start:sp.end]
+	}
+	return out
+}
+
+func (c *WindowChunker) spans(text string) []textSpan {
+	size := c.Size
+	if size <= 0 {
+		size = 200
+	}
+	stride := c.Stride
+	if stride <= 0 {
+		stride = size
+	}
+	tok := c.Tokenizer
+	if tok == nil {
+		tok = DefaultTokenizer
+	}
+	offsets := tok.Offsets(text)
+	// at returns the offset of token i, or the end of text past the last
+	// token, moved back to the start of a grapheme cluster.
+	at := func(i int) int {
+		if i >= len(offsets) {
+			return len(text)
+		}
+		return graphemeStart(text, offsets[i])
+	}
+	var out []textSpan
+	for i := 0; i < len(offsets); i += stride {
+		if sp := trimSpan(text, textSpan{at(i), at(i + size)}); sp.end > sp.start {
+			out = append(out, sp)
+		}
+		if i+size >= len(offsets) {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,26 @@
+package document
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWindowChunker(t *testing.T) {
+	tests := []struct {
+		name string
+		c    *WindowChunker
+		text string
+		want []string
+	}{
+		{"overlapping", &WindowChunker{Size: 3, Stride: 2}, "a b c d e f g", []string{"a b c", "c d e", "e f g"}},
+		{"stride defaults to size", &WindowChunker{Size: 3}, "a b c d e f g", []string{"a b c", "d e f", "g"}},
+		{"characters", &WindowChunker{Size: 2, Tokenizer: CharacterTokenizer{}}, "héllo", []string{"hé", "ll", "o"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.c.Split(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Split = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,439 @@
+package document
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XLSXParser renders Excel (.xlsx) workbooks sheet by sheet, using the
+// first non-empty row of each sheet as its header and one "column: value"
+// line per data row, like CSVParser. Worksheets are decoded as a stream of
+// rows, so only the shared string table is held in memory.
+type XLSXParser struct {
+	// Sheets restricts output to the named sheets (case-insensitive).
+	Sheets []string
+	// RowsPerDocument splits each sheet into documents of at most this many
+	// data rows in ParseAll when positive.
+	RowsPerDocument int
+	// MaxDecompressedBytes limits the bytes read from all the parts of a
+	// package together, so a ZIP bomb fails with ErrTooLarge. Defaults to
+	// 256 MB.
+	MaxDecompressedBytes int64
+}
+
+// NewXLSXParser creates a new XLSX parser instance.
+func NewXLSXParser() *XLSXParser {
+	return &XLSXParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *XLSXParser) Supports(mimeType string) bool {
+	return mimeType == "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+// xlsxBatch is a run of rendered rows from one sheet.
+type xlsxBatch struct {
+	sheet       string
+	columns     []string
+	first, last int // spreadsheet row numbers
+	lines       []string
+}
+
+// Parse returns every sheet in one document, each introduced by a "# name"
+// heading that is also recorded in Document.Headings.
+func (p *XLSXParser) Parse(buffer []byte, filename string) (*Document, error) {
+	var b strings.Builder
+	var headings []Heading
+	var sheets []string
+	rows := 0
+	err := p.each(buffer, 0, func(batch *xlsxBatch) {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		headings = append(headings, Heading{Level: 1, Text: batch.sheet, Offset: b.Len()})
+		b.WriteString("# " + batch.sheet + "\n\n" + strings.Join(batch.lines, "\n"))
+		sheets = append(sheets, batch.sheet)
+		rows += len(batch.lines)
+	})
+	if err != nil {
+		return nil, err
+	}
+	content := b.String()
+	if content == "" {
+		return nil, ErrEmptyDocument
+	}
+	return &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+		Headings:  headings,
+		Metadata: map[string]string{
+			"sheets": strings.Join(sheets, ", "),
+			"rows":   strconv.Itoa(rows),
+		},
+	}, nil
+}
+
+// ParseAll returns one document per sheet, or per RowsPerDocument rows.
+// The "sheet" metadata key holds the sheet name and "rows" the range of
+// spreadsheet row numbers covered, such as "2-101".
+func (p *XLSXParser) ParseAll(buffer []byte, filename string) ([]*Document, error) {
+	var docs []*Document
+	err := p.each(buffer, p.RowsPerDocument, func(batch *xlsxBatch) {
+		content := strings.Join(batch.lines, "\n")
+		docs = append(docs, &Document{
+			Content:   content,
+			Source:    filename,
+			WordCount: len(strings.Fields(content)),
+			Metadata: map[string]string{
+				"sheet":   batch.sheet,
+				"rows":    strconv.Itoa(batch.first) + "-" + strconv.Itoa(batch.last),
+				"columns": strings.Join(batch.columns, ", "),
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, ErrEmptyDocument
+	}
+	return docs, nil
+}
+
+// each streams the selected sheets and calls emit with batches of at most
+// size rendered rows, or one batch per sheet when size is zero.
+func (p *XLSXParser) each(buffer []byte, size int, emit func(*xlsxBatch)) error {
+	zr, err := openZip(buffer, p.MaxDecompressedBytes)
+	if err != nil {
+		return err
+	}
+	sheets, err := xlsxSheets(zr)
+	if err != nil {
+		return err
+	}
+	shared, err := xlsxSharedStrings(zr)
+	if err != nil {
+		return err
+	}
+	dates := xlsxDateStyles(zr)
+
+	for _, sheet := range sheets {
+		if !p.wantSheet(sheet.name) {
+			continue
+		}
+		rc, err := openZipEntry(zr, sheet.part)
+		if err != nil {
+			return err
+		}
+		var header []string
+		batch := &xlsxBatch{sheet: sheet.name}
+		err = xlsxReadRows(rc, shared, dates, func(row int, cells []string) {
+			if header == nil {
+				if strings.TrimSpace(strings.Join(cells, "")) == "" {
+					return
+				}
+				header = make([]string, len(cells))
+				for i, c := range cells {
+					header[i] = strings.TrimSpace(c)
+					if header[i] == "" {
+						header[i] = "Column " + strconv.Itoa(i+1)
+					}
+				}
+				batch.columns = header
+				return
+			}
+			var fields []string
+			for i, c := range cells {
+				v := strings.Join(strings.Fields(c), " ")
+				if v == "" {
+					continue
+				}
+				name := "Column " + strconv.Itoa(i+1)
+				if i < len(header) {
+					name = header[i]
+				}
+				fields = append(fields, name+": "+v)
+			}
+			if len(fields) == 0 {
+				return
+			}
+			if len(batch.lines) == 0 {
+				batch.first = row
+			}
+			batch.last = row
+			batch.lines = append(batch.lines, strings.Join(fields, ", "))
+			if size > 0 && len(batch.lines) >= size {
+				emit(batch)
+				batch = &xlsxBatch{sheet: sheet.name, columns: header}
+			}
+		})
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("read sheet %q: %w", sheet.name, err)
+		}
+		if len(batch.lines) > 0 {
+			emit(batch)
+		}
+	}
+	return zr.err()
+}
+
+func (p *XLSXParser) wantSheet(name string) bool {
+	if len(p.Sheets) == 0 {
+		return true
+	}
+	for _, s := range p.Sheets {
+		if strings.EqualFold(strings.TrimSpace(s), name) {
+			return true
+		}
+	}
+	return false
+}
+
+type xlsxSheet struct {
+	name string
+	part string
+}
+
+// xlsxSheets lists worksheets in workbook order.
+func xlsxSheets(zr *zipPackage) ([]xlsxSheet, error) {
+	data, err := readZipEntry(zr, "xl/workbook.xml")
+	if err != nil {
+		return nil, err
+	}
+	var wb struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			Rel  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(data, &wb); err != nil {
+		return nil, fmt.Errorf("parse xl/workbook.xml: %w", err)
+	}
+	rels := ooxmlRels(zr, "xl/workbook.xml")
+	var sheets []xlsxSheet
+	for _, s := range wb.Sheets {
+		if part := rels[s.Rel]; part != "" {
+			sheets = append(sheets, xlsxSheet{name: s.Name, part: part})
+		}
+	}
+	if len(sheets) == 0 {
+		return nil, errors.New("workbook has no sheets")
+	}
+	return sheets, nil
+}
+
+// xlsxSharedStrings reads the shared string table, skipping phonetic runs.
+func xlsxSharedStrings(zr *zipPackage) ([]string, error) {
+	rc, err := openZipEntry(zr, "xl/sharedStrings.xml")
+	if err != nil {
+		return nil, nil
+	}
+	defer rc.Close()
+
+	var table []string
+	var cur *strings.Builder
+	inText, skip := false, 0
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return table, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse xl/sharedStrings.xml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "si":
+				cur = &strings.Builder{}
+			case "rPh":
+				skip++
+			case "t":
+				inText = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "si":
+				if cur != nil {
+					table = append(table, cur.String())
+				}
+				cur = nil
+			case "rPh":
+				skip--
+			case "t":
+				inText = false
+			}
+		case xml.CharData:
+			if cur != nil && inText && skip == 0 {
+				cur.Write(t)
+			}
+		}
+	}
+}
+
+var xlsxDateCode = regexp.MustCompile(`"[^"]*"|\[[^\]]*\]|\\.`)
+
+// xlsxDateStyles reports, per cell style index, whether numbers in that
+// style are dates, using the built-in date formats and custom format codes.
+func xlsxDateStyles(zr *zipPackage) []bool {
+	data, err := readZipEntry(zr, "xl/styles.xml")
+	if err != nil {
+		return nil
+	}
+	var styles struct {
+		NumFmts []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		Xfs []struct {
+			NumFmt int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if xml.Unmarshal(data, &styles) != nil {
+		return nil
+	}
+	custom := map[int]bool{}
+	for _, f := range styles.NumFmts {
+		code := strings.ToLower(xlsxDateCode.ReplaceAllString(f.Code, ""))
+		custom[f.ID] = strings.ContainsAny(code, "dmyhs") && !strings.Contains(code, "general")
+	}
+	dates := make([]bool, len(styles.Xfs))
+	for i, xf := range styles.Xfs {
+		id := xf.NumFmt
+		dates[i] = (id >= 14 && id <= 22) || (id >= 45 && id <= 47) || custom[id]
+	}
+	return dates
+}
+
+// xlsxReadRows decodes a worksheet part and calls fn for each row with its
+// cell values placed at their column positions.
+func xlsxReadRows(r io.Reader, shared []string, dates []bool, fn func(row int, cells []string)) error {
+	dec := xml.NewDecoder(r)
+	var cells []string
+	var value strings.Builder
+	row, col := 0, -1
+	kind, style := "", 0
+	inValue := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				if n, err := strconv.Atoi(docxAttr(t, "r")); err == nil {
+					row = n
+				} else {
+					row++
+				}
+				cells, col = nil, -1
+			case "c":
+				if c := xlsxColumn(docxAttr(t, "r")); c >= 0 {
+					col = c
+				} else {
+					col++
+				}
+				kind = docxAttr(t, "t")
+				style, _ = strconv.Atoi(docxAttr(t, "s"))
+				value.Reset()
+			case "v", "t":
+				inValue = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "v", "t":
+				inValue = false
+			case "c":
+				v := xlsxCellValue(value.String(), kind, style, shared, dates)
+				if v != "" && col >= 0 && col < 16384 {
+					for len(cells) <= col {
+						cells = append(cells, "")
+					}
+					cells[col] = v
+				}
+			case "row":
+				if len(cells) > 0 {
+					fn(row, cells)
+				}
+			}
+		case xml.CharData:
+			if inValue {
+				value.Write(t)
+			}
+		}
+	}
+}
+
+// xlsxColumn converts the letters of a cell reference such as "AB12" to a
+// zero-based column index, or -1 when there are none.
+func xlsxColumn(ref string) int {
+	col := 0
+	n := 0
+	for ; n < len(ref) && ref[n] >= 'A' && ref[n] <= 'Z'; n++ {
+		col = col*26 + int(ref[n]-'A'+1)
+	}
+	if n == 0 {
+		return -1
+	}
+	return col - 1
+}
+
+// xlsxCellValue renders a raw cell value according to its type. Error
+// values such as "#N/A" are dropped.
+func xlsxCellValue(raw, kind string, style int, shared []string, dates []bool) string {
+	switch kind {
+	case "s":
+		i, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || i < 0 || i >= len(shared) {
+			return ""
+		}
+		return shared[i]
+	case "b":
+		if strings.TrimSpace(raw) == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	case "e":
+		return ""
+	case "str", "inlineStr":
+		return raw
+	}
+	if style >= 0 && style < len(dates) && dates[style] {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err == nil {
+			return xlsxDate(f)
+		}
+	}
+	return raw
+}
+
+// xlsxDate converts an Excel serial date (days since 1899-12-30) to ISO
+// 8601 text.
+func xlsxDate(serial float64) string {
+	days := math.Floor(serial)
+	secs := math.Round((serial - days) * 86400)
+	t := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).
+		AddDate(0, 0, int(days)).
+		Add(time.Duration(secs) * time.Second)
+	switch {
+	case days == 0 && secs > 0:
+		return t.Format("15:04:05")
+	case secs == 0:
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,51 @@
+package document
+
+import "testing"
+
+// xlsxBook returns a workbook with a "People" sheet using shared strings,
+// a date style and a sparse row, and an empty "Notes" sheet.
+func xlsxBook(t testing.TB) []byte {
+	const ns = `xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"`
+	return zipFiles(t,
+		"xl/workbook.xml", `<workbook `+ns+`><sheets><sheet name="People" r:id="rId1"/><sheet name="Notes" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels", `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml", `<sst `+ns+`><si><t>Name</t></si><si><t>Born</t></si><si><r><t>Ada</t></r><rPh><t>ADA</t></rPh></si><si><t>Active</t></si></sst>`,
+		"xl/styles.xml", `<styleSheet `+ns+`><cellXfs><xf numFmtId="0"/><xf numFmtId="14"/></cellXfs></styleSheet>`,
+		"xl/worksheets/sheet1.xml", `<worksheet `+ns+`><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>3</v></c></row>
+<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2" s="1"><v>45292</v></c><c r="C2" t="b"><v>1</v></c></row>
+<row r="4"><c r="C4" t="e"><v>#N/A</v></c><c r="B4" t="inlineStr"><is><t>unknown</t></is></c></row>
+</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml", `<worksheet `+ns+`><sheetData/></worksheet>`,
+	)
+}
+
+func TestXLSXParser(t *testing.T) {
+	doc, err := NewXLSXParser().Parse(xlsxBook(t), "book.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# People\n\nName: Ada, Born: 2024-01-01, Active: TRUE\nBorn: unknown"
+	if doc.Content != want {
+		t.Errorf("content %q, want %q", doc.Content, want)
+	}
+	if doc.Metadata["sheets"] != "People" || doc.Metadata["rows"] != "2" || len(doc.Headings) != 1 {
+		t.Errorf("metadata %v, headings %+v", doc.Metadata, doc.Headings)
+	}
+
+	p := &XLSXParser{Sheets: []string{"people"}, RowsPerDocument: 1}
+	docs, err := p.ParseAll(xlsxBook(t), "book.xlsx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("ParseAll returned %d documents, want 2", len(docs))
+	}
+	if m := docs[1].Metadata; m["sheet"] != "People" || m["rows"] != "4-4" || m["columns"] != "Name, Born, Active" {
+		t.Errorf("second batch metadata %v", m)
+	}
+
+	if _, err := (&XLSXParser{Sheets: []string{"Notes"}}).Parse(xlsxBook(t), "book.xlsx"); err == nil {
+		t.Error("empty sheet selection parsed without error")
+	}
+}
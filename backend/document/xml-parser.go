@@ -0,0 +1,161 @@
+package document
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// XMLParser extracts text content from generic XML such as sitemaps, RSS
+// and Atom feeds, or DITA topics.
+//
+// Include and Exclude take XPath-style patterns: "/rss/channel/item" is
+// anchored at the root, "//item" and "channel/item" match at any depth, and
+// "*" matches any single element name. When Include is set only text inside
+// matching elements is kept; Exclude always wins.
+type XMLParser struct {
+	Include []string
+	Exclude []string
+	// Labels prefixes each text line with its element name ("title: ...").
+	Labels bool
+}
+
+// NewXMLParser creates a new XML parser instance.
+func NewXMLParser() *XMLParser {
+	return &XMLParser{}
+}
+
+// Supports checks if the parser handles the given MIME type.
+func (p *XMLParser) Supports(mimeType string) bool {
+	switch mimeType {
+	case "application/xml", "text/xml", "application/rss+xml",
+		"application/atom+xml", "application/dita+xml":
+		return true
+	}
+	return false
+}
+
+// xmlFrame is an open element while streaming the token sequence.
+type xmlFrame struct {
+	name        string
+	text        strings.Builder
+	hasChildren bool
+	included    bool
+	excluded    bool
+}
+
+// Parse emits one line per element with direct text. Elements that contain
+// other elements, such as feed items, are separated by blank lines.
+func (p *XMLParser) Parse(buffer []byte, filename string) (*Document, error) {
+	dec := xml.NewDecoder(bytes.NewReader(buffer))
+	dec.Strict = false
+	dec.Entity = xml.HTMLEntity
+
+	var stack []*xmlFrame
+	var path []string
+	var lines []string
+	title := ""
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse xml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			path = append(path, t.Name.Local)
+			f := &xmlFrame{name: t.Name.Local}
+			if n := len(stack); n > 0 {
+				parent := stack[n-1]
+				parent.hasChildren = true
+				f.included, f.excluded = parent.included, parent.excluded
+				p.flushXMLText(parent, &lines)
+			} else {
+				f.included = len(p.Include) == 0
+			}
+			if !f.included && matchAnyXMLPath(p.Include, path) {
+				f.included = true
+			}
+			if matchAnyXMLPath(p.Exclude, path) {
+				f.excluded = true
+			}
+			stack = append(stack, f)
+		case xml.CharData:
+			if n := len(stack); n > 0 {
+				stack[n-1].text.Write(t)
+			}
+		case xml.EndElement:
+			n := len(stack)
+			if n == 0 {
+				continue
+			}
+			f := stack[n-1]
+			if title == "" && f.name == "title" && !f.excluded {
+				title = strings.Join(strings.Fields(f.text.String()), " ")
+			}
+			p.flushXMLText(f, &lines)
+			if f.hasChildren && len(lines) > 0 && lines[len(lines)-1] != "" {
+				lines = append(lines, "")
+			}
+			stack = stack[:n-1]
+			path = path[:len(path)-1]
+		}
+	}
+
+	content := strings.TrimSpace(strings.Join(lines, "\n"))
+	if content == "" {
+		return nil, ErrEmptyDocument
+	}
+	doc := &Document{
+		Content:   content,
+		Source:    filename,
+		WordCount: len(strings.Fields(content)),
+	}
+	if title != "" {
+		doc.Metadata = map[string]string{"title": title}
+	}
+	return doc, nil
+}
+
+// flushXMLText emits any text accumulated directly under f.
+func (p *XMLParser) flushXMLText(f *xmlFrame, lines *[]string) {
+	text := strings.Join(strings.Fields(f.text.String()), " ")
+	f.text.Reset()
+	if text == "" || !f.included || f.excluded {
+		return
+	}
+	if p.Labels {
+		text = f.name + ": " + text
+	}
+	*lines = append(*lines, text)
+}
+
+func matchAnyXMLPath(patterns []string, path []string) bool {
+	for _, pat := range patterns {
+		if matchXMLPath(pat, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchXMLPath reports whether the element path matches an XPath-style
+// pattern. Only the element steps "/", "//" and "*" are supported.
+func matchXMLPath(pattern string, path []string) bool {
+	anchored := strings.HasPrefix(pattern, "/") && !strings.HasPrefix(pattern, "//")
+	steps := strings.Split(strings.Trim(pattern, "/"), "/")
+	if len(steps) > len(path) || (anchored && len(steps) != len(path)) {
+		return false
+	}
+	tail := path[len(path)-len(steps):]
+	for i, s := range steps {
+		if s != "*" && s != tail[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package document
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestXMLParser(t *testing.T) {
+	const feed = `<?xml version="1.0"?>
+<rss><channel><title>News</title>
+<item><title>First</title><description>One &amp; only</description><guid>id-1</guid></item>
+<item><title>Second</title><description>Two</description><guid>id-2</guid></item>
+</channel></rss>`
+	tests := []struct {
+		name    string
+		parser  *XMLParser
+		want    []string
+		without []string
+	}{
+		{"all text", NewXMLParser(), []string{"News", "First", "One & only", "id-2"}, nil},
+		{"include", &XMLParser{Include: []string{"item/title"}}, []string{"First", "Second"}, []string{"News", "One & only"}},
+		{"exclude", &XMLParser{Exclude: []string{"guid"}}, []string{"First", "Two"}, []string{"id-1"}},
+		{"wildcard", &XMLParser{Include: []string{"channel/*/description"}}, []string{"One & only", "Two"}, []string{"First"}},
+		{"labels", &XMLParser{Labels: true, Include: []string{"item"}}, []string{"title: First", "description: Two"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := tt.parser.Parse([]byte(feed), "feed.xml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(doc.Content, want) {
+					t.Errorf("content %q does not contain %q", doc.Content, want)
+				}
+			}
+			for _, gone := range tt.without {
+				if strings.Contains(doc.Content, gone) {
+					t.Errorf("content %q contains %q", doc.Content, gone)
+				}
+			}
+		})
+	}
+
+	doc, err := NewXMLParser().Parse(mustRead(t, fixture("test-xml.xml")), "test-xml.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(doc.Content, "XML Developer's Guide") {
+		t.Errorf("fixture content %q", doc.Content[:min(80, len(doc.Content))])
+	}
+}